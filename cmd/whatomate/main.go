@@ -68,10 +68,12 @@ Server Options:
   -config string    Path to config file (default "config.toml")
   -migrate          Run database migrations on startup
   -workers int      Number of embedded workers (0 to disable) (default 1)
+  -processors       Run the SLA, flow delay, CSAT, catalog and note attachment processors (default true)
 
 Worker Options:
   -config string    Path to config file (default "config.toml")
   -workers int      Number of workers to run (default 1)
+  -processors       Also run the SLA, flow delay, CSAT, catalog and note attachment processors
 
 Examples:
   whatomate server                     # API + 1 embedded worker
@@ -79,11 +81,17 @@ Examples:
   whatomate server -workers 4          # API + 4 embedded workers
   whatomate server -migrate            # Run migrations and start server
   whatomate worker -workers 4          # 4 workers only (no API)
+  whatomate worker -processors         # 1 worker + SLA, flow delay, CSAT, catalog and note attachment processors
 
 Deployment Scenarios:
   All-in-one:    whatomate server
   Separate:      whatomate server -workers 0  (on API server)
-                 whatomate worker -workers 4  (on worker server)`)
+                 whatomate worker -workers 4  (on worker server)
+  Processors:    whatomate server -processors=false  (on API servers)
+                 whatomate worker -processors        (on worker servers)
+
+Processors running in several servers or workers share the work through
+Redis, so each SLA action and delayed flow step happens once.`)
 }
 
 // ============================================================================
@@ -95,6 +103,7 @@ func runServer(args []string) {
 	configPath := serverFlags.String("config", "config.toml", "Path to config file")
 	migrate := serverFlags.Bool("migrate", false, "Run database migrations")
 	numWorkers := serverFlags.Int("workers", 1, "Number of workers to run (0 to disable embedded workers)")
+	runProcessors := serverFlags.Bool("processors", true, "Run the SLA, flow delay, CSAT, catalog and note attachment processors")
 	_ = serverFlags.Parse(args)
 
 	// Initialize logger
@@ -195,11 +204,25 @@ func runServer(args []string) {
 		HTTPClient: httpClient,
 	}
 
+	// Only send conversation updates to the users allowed to see them
+	app.ScopeBroadcasts()
+
+	// Send read receipts and typing indicators, and share agent presence, from the inbox
+	app.HandleClientEvents()
+
+	// Record agent activity for idle auto-away
+	app.TrackAgentActivity()
+
 	// Start campaign stats subscriber for real-time WebSocket updates from worker
 	if err := app.StartCampaignStatsSubscriber(); err != nil {
 		lo.Error("Failed to start campaign stats subscriber", "error", err)
 	}
 
+	// Start broadcast subscriber for WebSocket updates from processors running in workers
+	if err := app.StartBroadcastSubscriber(); err != nil {
+		lo.Error("Failed to start broadcast subscriber", "error", err)
+	}
+
 	// Parse allowed origins for CORS
 	allowedOrigins := middleware.ParseAllowedOrigins(cfg.Server.AllowedOrigins)
 
@@ -228,11 +251,19 @@ func runServer(args []string) {
 		}
 	}()
 
-	// Start SLA processor (runs every minute)
-	slaProcessor := handlers.NewSLAProcessor(app, time.Minute)
-	slaCtx, slaCancel := context.WithCancel(context.Background())
-	go slaProcessor.Start(slaCtx)
-	lo.Info("SLA processor started")
+	// Start SLA, flow delay, CSAT, catalog and note attachment processors
+	var processors *backgroundProcessors
+	if *runProcessors {
+		processors = startProcessors(app, lo)
+	} else {
+		lo.Info("Processors disabled, run them in a worker with -processors")
+	}
+
+	// Start agent availability processor (shift schedules and idle auto-away).
+	// It always runs in servers; one of them applies the changes.
+	availabilityProcessor := handlers.NewAgentAvailabilityProcessor(app, time.Minute)
+	availabilityCtx, availabilityCancel := context.WithCancel(context.Background())
+	go availabilityProcessor.Start(availabilityCtx)
 
 	// Start embedded workers
 	var workers []*worker.Worker
@@ -273,11 +304,17 @@ func runServer(args []string) {
 	app.StopCampaignStatsSubscriber()
 	lo.Info("Campaign stats subscriber stopped")
 
-	// Stop SLA processor
-	lo.Info("Stopping SLA processor...")
-	slaCancel()
-	slaProcessor.Stop()
-	lo.Info("SLA processor stopped")
+	// Stop broadcast subscriber
+	app.StopBroadcastSubscriber()
+
+	// Stop SLA, flow delay, CSAT, catalog and note attachment processors
+	if processors != nil {
+		processors.stop()
+	}
+
+	// Stop agent availability processor
+	availabilityCancel()
+	availabilityProcessor.Stop()
 
 	// Stop workers first
 	if workerCancel != nil {
@@ -305,6 +342,7 @@ func runWorker(args []string) {
 	workerFlags := flag.NewFlagSet("worker", flag.ExitOnError)
 	configPath := workerFlags.String("config", "config.toml", "Path to config file")
 	workerCount := workerFlags.Int("workers", 1, "Number of workers to run")
+	runProcessors := workerFlags.Bool("processors", false, "Also run the SLA, flow delay, CSAT, catalog and note attachment processors")
 	_ = workerFlags.Parse(args)
 
 	// Initialize logger
@@ -374,6 +412,33 @@ func runWorker(args []string) {
 
 	lo.Info("Workers started", "count", *workerCount)
 
+	// Start SLA, flow delay, CSAT, catalog and note attachment processors. Their WebSocket broadcasts are
+	// relayed to the servers through Redis.
+	var processors *backgroundProcessors
+	if *runProcessors {
+		wsHub := websocket.NewHub(lo)
+		app := &handlers.App{
+			Config:   cfg,
+			DB:       db,
+			Redis:    rdb,
+			Log:      lo,
+			WhatsApp: whatsapp.NewWithBaseURL(lo, cfg.WhatsApp.BaseURL),
+			WSHub:    wsHub,
+			Queue:    queue.NewRedisQueue(rdb, lo),
+			HTTPClient: &http.Client{
+				Timeout: 30 * time.Second,
+				Transport: &http.Transport{
+					DialContext:         handlers.SSRFSafeDialer(),
+					MaxIdleConns:        100,
+					MaxIdleConnsPerHost: 10,
+					IdleConnTimeout:     90 * time.Second,
+				},
+			},
+		}
+		app.RelayBroadcasts()
+		processors = startProcessors(app, lo)
+	}
+
 	// Wait for shutdown signal or error
 	select {
 	case sig := <-quit:
@@ -387,6 +452,10 @@ func runWorker(args []string) {
 	}
 
 	// Cleanup
+	if processors != nil {
+		processors.stop()
+	}
+
 	lo.Info("Shutting down workers...")
 	for _, w := range workers {
 		if w != nil {
@@ -398,6 +467,55 @@ func runWorker(args []string) {
 	lo.Info("Workers stopped")
 }
 
+// ============================================================================
+// PROCESSORS
+// ============================================================================
+
+// backgroundProcessors are the periodic processors that may run in servers,
+// workers or both. Replicas coordinate through Redis so that each
+// organization's SLA checks and each delayed flow step run once.
+type backgroundProcessors struct {
+	sla       *handlers.SLAProcessor
+	flowDelay *handlers.FlowDelayProcessor
+	csat      *handlers.CSATProcessor
+	catalog   *handlers.CatalogProcessor
+	notes     *handlers.NoteAttachmentProcessor
+	cancel    context.CancelFunc
+}
+
+func startProcessors(app *handlers.App, lo logf.Logger) *backgroundProcessors {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &backgroundProcessors{
+		// SLA processor (runs every minute)
+		sla: handlers.NewSLAProcessor(app, time.Minute),
+		// Flow delay processor (resumes flows paused at delay steps)
+		flowDelay: handlers.NewFlowDelayProcessor(app, 15*time.Second),
+		// CSAT processor (sends satisfaction surveys once due)
+		csat: handlers.NewCSATProcessor(app, 30*time.Second),
+		// Catalog processor (scheduled product updates and product batches)
+		catalog: handlers.NewCatalogProcessor(app, time.Minute),
+		// Note attachment processor (deletes uploads never linked to a note)
+		notes:  handlers.NewNoteAttachmentProcessor(app, time.Hour),
+		cancel: cancel,
+	}
+	go p.sla.Start(ctx)
+	go p.flowDelay.Start(ctx)
+	go p.csat.Start(ctx)
+	go p.catalog.Start(ctx)
+	go p.notes.Start(ctx)
+	lo.Info("SLA, flow delay, CSAT, catalog and note attachment processors started")
+	return p
+}
+
+func (p *backgroundProcessors) stop() {
+	p.cancel()
+	p.sla.Stop()
+	p.flowDelay.Stop()
+	p.csat.Stop()
+	p.catalog.Stop()
+	p.notes.Stop()
+}
+
 // ============================================================================
 // ROUTES
 // ============================================================================
@@ -441,6 +559,7 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	// Webhook routes (public - for Meta)
 	g.GET("/api/webhook", app.WebhookVerify)
 	g.POST("/api/webhook", app.WebhookHandler)
+	g.POST("/api/webhook/flows/{id}", app.FlowDataEndpoint)
 
 	// WebSocket route (auth handled in handler via query param)
 	g.GET("/ws", app.WebSocketHandler)
@@ -463,6 +582,10 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 		if len(path) >= 13 && path[:13] == "/api/auth/sso" {
 			return r
 		}
+		// Skip auth for WhatsApp Flows data endpoints (requests are encrypted with the account's flow keys)
+		if len(path) >= 19 && path[:19] == "/api/webhook/flows/" {
+			return r
+		}
 		// Skip auth for custom action redirects (uses one-time token)
 		if len(path) >= 28 && path[:28] == "/api/custom-actions/redirect" {
 			return r
@@ -474,6 +597,9 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 		return r
 	})
 
+	// Authenticated requests keep agents from being set away as idle
+	g.Before(app.TouchAgentActivity)
+
 	// Role-based access control middleware
 	g.Before(func(r *fastglue.Request) *fastglue.Request {
 		method := string(r.RequestCtx.Method())
@@ -523,6 +649,8 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.DELETE("/api/accounts/{id}", app.DeleteAccount)
 	g.POST("/api/accounts/{id}/test", app.TestAccountConnection)
 	g.POST("/api/accounts/{id}/subscribe", app.SubscribeApp)
+	g.GET("/api/accounts/{id}/flow-keys", app.GetAccountFlowKeys)
+	g.POST("/api/accounts/{id}/flow-keys", app.GenerateAccountFlowKeys)
 	g.GET("/api/accounts/{id}/business_profile", app.GetBusinessProfile)
 	g.PUT("/api/accounts/{id}/business_profile", app.UpdateBusinessProfile)
 	g.POST("/api/accounts/{id}/business_profile/photo", app.UpdateProfilePicture)
@@ -536,6 +664,7 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.PUT("/api/contacts/{id}/assign", app.AssignContact)
 	g.PUT("/api/contacts/{id}/tags", app.UpdateContactTags)
 	g.GET("/api/contacts/{id}/session-data", app.GetContactSessionData)
+	g.GET("/api/contacts/{id}/orders", app.GetContactOrders)
 
 	// Generic Import/Export
 	g.POST("/api/export", app.ExportData)
@@ -553,16 +682,30 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.GET("/api/contacts/{id}/messages", app.GetMessages)
 	g.POST("/api/contacts/{id}/messages", app.SendMessage)
 	g.POST("/api/contacts/{id}/messages/{message_id}/reaction", app.SendReaction)
+	g.POST("/api/contacts/{id}/messages/{message_id}/unsend", app.UnsendMessage)
+	g.GET("/api/contacts/{id}/messages/{message_id}/history", app.GetMessageHistory)
 	g.POST("/api/messages", app.SendMessage) // Legacy route
 	g.POST("/api/messages/template", app.SendTemplateMessage)
 	g.POST("/api/messages/media", app.SendMediaMessage)
 	g.PUT("/api/messages/{id}/read", app.MarkMessageRead)
 
+	// One-time passwords (authentication templates)
+	g.POST("/api/otp/send", app.SendOTP)
+	g.POST("/api/otp/verify", app.VerifyOTP)
+
 	// Conversation Notes
 	g.GET("/api/contacts/{id}/notes", app.ListConversationNotes)
 	g.POST("/api/contacts/{id}/notes", app.CreateConversationNote)
 	g.PUT("/api/contacts/{id}/notes/{note_id}", app.UpdateConversationNote)
 	g.DELETE("/api/contacts/{id}/notes/{note_id}", app.DeleteConversationNote)
+	g.GET("/api/contacts/{id}/notes/{note_id}/replies", app.ListConversationNoteReplies)
+	g.POST("/api/contacts/{id}/notes/attachments", app.UploadNoteAttachment)
+	g.GET("/api/contacts/{id}/notes/attachments/{attachment_id}", app.ServeNoteAttachment)
+
+	// Notifications
+	g.GET("/api/notifications", app.ListNotifications)
+	g.PUT("/api/notifications/read-all", app.MarkAllNotificationsRead)
+	g.PUT("/api/notifications/{id}/read", app.MarkNotificationRead)
 
 	// Media (serves media files for messages, auth-protected)
 	g.GET("/api/media/{message_id}", app.ServeMedia)
@@ -575,7 +718,19 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.DELETE("/api/templates/{id}", app.DeleteTemplate)
 	g.POST("/api/templates/sync", app.SyncTemplates)
 	g.POST("/api/templates/{id}/publish", app.SubmitTemplate)
+	g.GET("/api/templates/{id}/lint", app.LintTemplate)
+	g.POST("/api/templates/lint", app.LintTemplateDraft)
+	g.POST("/api/templates/{id}/render", app.RenderTemplate)
 	g.POST("/api/templates/upload-media", app.UploadTemplateMedia)
+	g.POST("/api/templates/{id}/clone", app.CloneTemplate)
+
+	// Template groups (one template in many languages and accounts)
+	g.GET("/api/template-groups", app.ListTemplateGroups)
+	g.POST("/api/template-groups", app.CreateTemplateGroup)
+	g.GET("/api/template-groups/{id}", app.GetTemplateGroup)
+	g.PUT("/api/template-groups/{id}", app.UpdateTemplateGroup)
+	g.DELETE("/api/template-groups/{id}", app.DeleteTemplateGroup)
+	g.POST("/api/template-groups/{id}/clone", app.CloneTemplateGroup)
 
 	// WhatsApp Flows
 	g.GET("/api/flows", app.ListFlows)
@@ -620,9 +775,19 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	// Chatbot Flows
 	g.GET("/api/chatbot/flows", app.ListChatbotFlows)
 	g.POST("/api/chatbot/flows", app.CreateChatbotFlow)
+	g.POST("/api/chatbot/flows/validate", app.ValidateChatbotFlow)
+	g.POST("/api/chatbot/flows/media", app.UploadChatbotFlowMedia)
 	g.GET("/api/chatbot/flows/{id}", app.GetChatbotFlow)
 	g.PUT("/api/chatbot/flows/{id}", app.UpdateChatbotFlow)
 	g.DELETE("/api/chatbot/flows/{id}", app.DeleteChatbotFlow)
+	g.GET("/api/chatbot/flows/{id}/graph", app.GetChatbotFlowGraph)
+	g.POST("/api/chatbot/flows/{id}/simulate", app.SimulateChatbotFlow)
+	g.POST("/api/chatbot/flows/{id}/publish", app.PublishChatbotFlow)
+	g.POST("/api/chatbot/flows/{id}/draft/discard", app.DiscardChatbotFlowDraft)
+	g.GET("/api/chatbot/flows/{id}/versions", app.ListChatbotFlowVersions)
+	g.GET("/api/chatbot/flows/{id}/versions/diff", app.DiffChatbotFlowVersions)
+	g.GET("/api/chatbot/flows/{id}/versions/{version}", app.GetChatbotFlowVersion)
+	g.POST("/api/chatbot/flows/{id}/versions/{version}/rollback", app.RollbackChatbotFlow)
 
 	// AI Contexts
 	g.GET("/api/chatbot/ai-contexts", app.ListAIContexts)
@@ -631,6 +796,13 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.PUT("/api/chatbot/ai-contexts/{id}", app.UpdateAIContext)
 	g.DELETE("/api/chatbot/ai-contexts/{id}", app.DeleteAIContext)
 
+	// SLA Policies
+	g.GET("/api/chatbot/sla-policies", app.ListSLAPolicies)
+	g.POST("/api/chatbot/sla-policies", app.CreateSLAPolicy)
+	g.GET("/api/chatbot/sla-policies/{id}", app.GetSLAPolicy)
+	g.PUT("/api/chatbot/sla-policies/{id}", app.UpdateSLAPolicy)
+	g.DELETE("/api/chatbot/sla-policies/{id}", app.DeleteSLAPolicy)
+
 	// Agent Transfers
 	g.GET("/api/chatbot/transfers", app.ListAgentTransfers)
 	g.POST("/api/chatbot/transfers", app.CreateAgentTransfer)
@@ -651,10 +823,15 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	// Canned Responses
 	g.GET("/api/canned-responses", app.ListCannedResponses)
 	g.POST("/api/canned-responses", app.CreateCannedResponse)
+	g.POST("/api/canned-responses/media", app.UploadCannedResponseMedia)
+	g.GET("/api/canned-responses/analytics", app.GetCannedResponseAnalytics)
 	g.GET("/api/canned-responses/{id}", app.GetCannedResponse)
 	g.PUT("/api/canned-responses/{id}", app.UpdateCannedResponse)
 	g.DELETE("/api/canned-responses/{id}", app.DeleteCannedResponse)
 	g.POST("/api/canned-responses/{id}/use", app.IncrementCannedResponseUsage)
+	g.GET("/api/canned-responses/{id}/media", app.ServeCannedResponseMedia)
+	g.POST("/api/canned-responses/{id}/render", app.RenderCannedResponse)
+	g.POST("/api/canned-responses/{id}/send", app.SendCannedResponse)
 
 	// Sessions (admin/debug)
 	g.GET("/api/chatbot/sessions", app.ListChatbotSessions)
@@ -667,6 +844,8 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.GET("/api/analytics/agents", app.GetAgentAnalytics)
 	g.GET("/api/analytics/agents/{id}", app.GetAgentDetails)
 	g.GET("/api/analytics/agents/comparison", app.GetAgentComparison)
+	g.GET("/api/analytics/csat", app.ListCSATSurveys)
+	g.GET("/api/analytics/referrals", app.GetReferralAnalytics)
 
 	// Meta WhatsApp Analytics
 	g.GET("/api/analytics/meta", app.GetMetaAnalytics)
@@ -725,6 +904,21 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.GET("/api/catalogs/{id}", app.GetCatalog)
 	g.DELETE("/api/catalogs/{id}", app.DeleteCatalog)
 	g.POST("/api/catalogs/sync", app.SyncCatalogs)
+	g.POST("/api/catalogs/{id}/feed", app.ImportCatalogFeed)
+	g.POST("/api/catalogs/{id}/sync-products", app.SyncCatalogProducts)
+	g.GET("/api/catalogs/{id}/batches", app.ListCatalogBatches)
+	g.GET("/api/catalogs/{id}/review-report", app.GetCatalogReviewReport)
+
+	// Product sets
+	g.GET("/api/catalogs/{id}/product-sets", app.ListProductSets)
+	g.POST("/api/catalogs/{id}/product-sets", app.CreateProductSet)
+	g.PUT("/api/product-sets/{id}", app.UpdateProductSet)
+	g.DELETE("/api/product-sets/{id}", app.DeleteProductSet)
+
+	// Scheduled product updates
+	g.GET("/api/catalogs/{id}/scheduled-updates", app.ListScheduledUpdates)
+	g.POST("/api/catalogs/{id}/scheduled-updates", app.CreateScheduledUpdate)
+	g.DELETE("/api/catalogs/{id}/scheduled-updates/{update_id}", app.CancelScheduledUpdate)
 
 	// Catalog Products
 	g.GET("/api/catalogs/{id}/products", app.ListCatalogProducts)
@@ -733,6 +927,13 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.PUT("/api/products/{id}", app.UpdateCatalogProduct)
 	g.DELETE("/api/products/{id}", app.DeleteCatalogProduct)
 
+	// Orders
+	g.GET("/api/orders", app.ListOrders)
+	g.GET("/api/orders/notifications", app.ListOrderNotifications)
+	g.PUT("/api/orders/notifications", app.UpdateOrderNotifications)
+	g.GET("/api/orders/{id}", app.GetOrder)
+	g.PUT("/api/orders/{id}/status", app.UpdateOrderStatus)
+
 	// Serve embedded frontend (SPA)
 	if frontend.IsEmbedded() {
 		lo.Info("Serving embedded frontend", "base_path", basePath)
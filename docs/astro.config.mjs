@@ -46,8 +46,11 @@ export default defineConfig({
             { label: 'Contacts', slug: 'api-reference/contacts' },
             { label: 'Messages', slug: 'api-reference/messages' },
             { label: 'Templates', slug: 'api-reference/templates' },
+            { label: 'One-Time Passwords', slug: 'api-reference/otp' },
             { label: 'Flows', slug: 'api-reference/flows' },
             { label: 'Campaigns', slug: 'api-reference/campaigns' },
+            { label: 'Catalogs', slug: 'api-reference/catalogs' },
+            { label: 'Orders', slug: 'api-reference/orders' },
             { label: 'Chatbot', slug: 'api-reference/chatbot' },
             { label: 'Canned Responses', slug: 'api-reference/canned-responses' },
             { label: 'Custom Actions', slug: 'api-reference/custom-actions' },
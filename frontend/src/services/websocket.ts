@@ -42,10 +42,16 @@ function showNotification(title: string, body: string, contactId: string) {
 // WebSocket message types
 const WS_TYPE_NEW_MESSAGE = 'new_message'
 const WS_TYPE_STATUS_UPDATE = 'status_update'
+const WS_TYPE_MESSAGE_UPDATE = 'message_update'
 const WS_TYPE_SET_CONTACT = 'set_contact'
+const WS_TYPE_TYPING = 'typing'
+const WS_TYPE_ACTIVITY = 'activity'
 const WS_TYPE_PING = 'ping'
 const WS_TYPE_PONG = 'pong'
 
+// Agent presence types
+const WS_TYPE_AGENT_PRESENCE = 'agent_presence'
+
 // Reaction types
 const WS_TYPE_REACTION_UPDATE = 'reaction_update'
 
@@ -66,6 +72,11 @@ const WS_TYPE_CONVERSATION_NOTE_CREATED = 'conversation_note_created'
 const WS_TYPE_CONVERSATION_NOTE_UPDATED = 'conversation_note_updated'
 const WS_TYPE_CONVERSATION_NOTE_DELETED = 'conversation_note_deleted'
 
+// Activity is reported at most this often, so idle auto-away sees an agent
+// who is working without opening conversations or typing
+const ACTIVITY_INTERVAL = 30000
+const ACTIVITY_EVENTS = ['pointerdown', 'keydown', 'wheel', 'touchstart']
+
 interface WSMessage {
   type: string
   payload: any
@@ -80,6 +91,8 @@ class WebSocketService {
   private isConnected = false
   private hasConnectedBefore = false
   private campaignStatsCallbacks: ((payload: any) => void)[] = []
+  private lastActivitySent = 0
+  private trackingActivity = false
 
   connect(token: string) {
     if (this.ws?.readyState === WebSocket.OPEN) {
@@ -100,6 +113,7 @@ class WebSocketService {
         this.hasConnectedBefore = true
         this.reconnectAttempts = 0
         this.startPing()
+        this.startActivityTracking()
 
         // Force refresh data after reconnection to sync any missed updates
         if (isReconnection) {
@@ -127,6 +141,7 @@ class WebSocketService {
 
   disconnect() {
     this.stopPing()
+    this.stopActivityTracking()
     if (this.ws) {
       this.ws.close()
       this.ws = null
@@ -147,6 +162,12 @@ class WebSocketService {
         case WS_TYPE_STATUS_UPDATE:
           this.handleStatusUpdate(store, message.payload)
           break
+        case WS_TYPE_MESSAGE_UPDATE:
+          this.handleMessageUpdate(store, message.payload)
+          break
+        case WS_TYPE_AGENT_PRESENCE:
+          this.handleAgentPresence(store, message.payload)
+          break
         case WS_TYPE_AGENT_TRANSFER:
           this.handleAgentTransfer(message.payload)
           break
@@ -256,6 +277,20 @@ class WebSocketService {
     store.updateMessageStatus(payload.message_id, payload.status)
   }
 
+  private handleMessageUpdate(store: ReturnType<typeof useContactsStore>, payload: any) {
+    // A message was edited, deleted or retracted in the conversation we're viewing
+    if (store.currentContact?.id === payload.contact_id) {
+      store.updateMessage(payload)
+    }
+  }
+
+  private handleAgentPresence(store: ReturnType<typeof useContactsStore>, payload: any) {
+    // Only colleagues in the conversation we're viewing are shown
+    if (store.currentContact?.id === payload.contact_id) {
+      store.updateAgentPresence(payload)
+    }
+  }
+
   private handleReactionUpdate(store: ReturnType<typeof useContactsStore>, payload: any) {
     // Update the message reactions if we're viewing the contact
     const currentContact = store.currentContact
@@ -435,6 +470,14 @@ class WebSocketService {
     })
   }
 
+  // Tell colleagues and the customer that we started or stopped composing a reply
+  setTyping(contactId: string, typing: boolean) {
+    this.send({
+      type: WS_TYPE_TYPING,
+      payload: { contact_id: contactId, typing }
+    })
+  }
+
   private send(message: WSMessage) {
     if (this.ws?.readyState === WebSocket.OPEN) {
       this.ws.send(JSON.stringify(message))
@@ -455,6 +498,33 @@ class WebSocketService {
     }
   }
 
+  private reportActivity = () => {
+    if (document.visibilityState !== 'visible') {
+      return
+    }
+    const now = Date.now()
+    if (now - this.lastActivitySent < ACTIVITY_INTERVAL) {
+      return
+    }
+    this.lastActivitySent = now
+    this.send({ type: WS_TYPE_ACTIVITY, payload: {} })
+  }
+
+  private startActivityTracking() {
+    if (this.trackingActivity) {
+      return
+    }
+    this.trackingActivity = true
+    ACTIVITY_EVENTS.forEach(event => window.addEventListener(event, this.reportActivity, { passive: true }))
+    document.addEventListener('visibilitychange', this.reportActivity)
+  }
+
+  private stopActivityTracking() {
+    this.trackingActivity = false
+    ACTIVITY_EVENTS.forEach(event => window.removeEventListener(event, this.reportActivity))
+    document.removeEventListener('visibilitychange', this.reportActivity)
+  }
+
   private refreshStaleData() {
     // Refresh contacts list
     const contactsStore = useContactsStore()
@@ -23,6 +23,8 @@ export interface ReplyPreview {
   content: any
   message_type: string
   direction: 'incoming' | 'outgoing'
+  edited?: boolean
+  revoked?: boolean
 }
 
 export interface Reaction {
@@ -61,10 +63,20 @@ export interface Message {
   reply_to_message_id?: string
   reply_to_message?: ReplyPreview
   reactions?: Reaction[]
+  edited_at?: string
+  revoked_at?: string // Deleted by the customer or retracted by an agent
+  revoked_by_user_id?: string
   created_at: string
   updated_at: string
 }
 
+// A colleague viewing or typing in the current conversation
+export interface AgentPresence {
+  user_id: string
+  user_name: string
+  state: 'viewing' | 'typing'
+}
+
 export const useContactsStore = defineStore('contacts', () => {
   const contacts = ref<Contact[]>([])
   const currentContact = ref<Contact | null>(null)
@@ -76,6 +88,7 @@ export const useContactsStore = defineStore('contacts', () => {
   const searchQuery = ref('')
   const selectedTags = ref<string[]>([])
   const replyingTo = ref<Message | null>(null)
+  const agentPresence = ref<AgentPresence[]>([])
 
   // Contacts pagination
   const contactsPage = ref(1)
@@ -274,9 +287,29 @@ export const useContactsStore = defineStore('contacts', () => {
     }
   }
 
+  // Replace a message that was edited, deleted or retracted, and the
+  // previews of replies quoting it
+  function updateMessage(updated: Message) {
+    const index = messages.value.findIndex(m => m.id === updated.id)
+    if (index !== -1) {
+      messages.value[index] = { ...messages.value[index], ...updated }
+    }
+    for (const message of messages.value) {
+      if (message.reply_to_message?.id === updated.id) {
+        message.reply_to_message = {
+          ...message.reply_to_message,
+          content: updated.content,
+          edited: !!updated.edited_at,
+          revoked: !!updated.revoked_at
+        }
+      }
+    }
+  }
+
   function setCurrentContact(contact: Contact | null) {
     currentContact.value = contact
     replyingTo.value = null // Clear reply state when switching contacts
+    agentPresence.value = []
     if (contact) {
       contact.unread_count = 0
     }
@@ -294,6 +327,20 @@ export const useContactsStore = defineStore('contacts', () => {
     }
   }
 
+  function updateAgentPresence(payload: { user_id: string; user_name: string; state: string }) {
+    const others = agentPresence.value.filter(p => p.user_id !== payload.user_id)
+    if (payload.state === 'left') {
+      agentPresence.value = others
+      return
+    }
+    others.push({
+      user_id: payload.user_id,
+      user_name: payload.user_name,
+      state: payload.state === 'typing' ? 'typing' : 'viewing'
+    })
+    agentPresence.value = others
+  }
+
   function updateContactTags(contactId: string, tags: string[]) {
     // Update in contacts list
     const contact = contacts.value.find(c => c.id === contactId)
@@ -317,6 +364,7 @@ export const useContactsStore = defineStore('contacts', () => {
     searchQuery,
     selectedTags,
     replyingTo,
+    agentPresence,
     filteredContacts,
     sortedContacts,
     // Contacts pagination
@@ -335,11 +383,13 @@ export const useContactsStore = defineStore('contacts', () => {
     sendTemplate,
     addMessage,
     updateMessageStatus,
+    updateMessage,
     setCurrentContact,
     clearMessages,
     setReplyingTo,
     clearReplyingTo,
     updateMessageReactions,
+    updateAgentPresence,
     updateContactTags
   }
 })
@@ -249,3 +249,80 @@ func TestCreateDefaultAdmin_UsesExistingOrg(t *testing.T) {
 	db.Model(&models.Organization{}).Count(&orgCount)
 	assert.Equal(t, int64(1), orgCount, "should reuse existing organization")
 }
+
+func TestSeedPermissionsAndRoles_GrantsChatAllToRolesWithContactsRead(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cleanAll(t, db)
+
+	require.NoError(t, database.SeedPermissionsAndRoles(db))
+	org := testutil.CreateTestOrganization(t, db)
+	require.NoError(t, database.SeedSystemRolesForOrg(db, org.ID))
+	supervisor := testutil.CreateTestRoleWithKeys(t, db, org.ID, "supervisor", []string{"contacts:read"})
+	campaigns := testutil.CreateTestRoleWithKeys(t, db, org.ID, "campaigns", []string{"campaigns:read"})
+
+	// Simulate an upgrade from before chat.all:read existed
+	var chatAll models.Permission
+	require.NoError(t, db.Where("resource = ? AND action = ?", models.ResourceChatAll, models.ActionRead).First(&chatAll).Error)
+	require.NoError(t, db.Exec("DELETE FROM role_permissions WHERE permission_id = ?", chatAll.ID).Error)
+	require.NoError(t, db.Unscoped().Delete(&chatAll).Error)
+
+	require.NoError(t, database.SeedPermissionsAndRoles(db))
+
+	hasChatAll := func(roleID uuid.UUID) bool {
+		var count int64
+		db.Table("role_permissions").
+			Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+			Where("role_permissions.custom_role_id = ? AND permissions.resource = ? AND permissions.action = ?",
+				roleID, models.ResourceChatAll, models.ActionRead).
+			Count(&count)
+		return count > 0
+	}
+	systemRole := func(name string) uuid.UUID {
+		var role models.CustomRole
+		require.NoError(t, db.Where("organization_id = ? AND name = ? AND is_system = ?", org.ID, name, true).First(&role).Error)
+		return role.ID
+	}
+
+	assert.True(t, hasChatAll(supervisor.ID), "custom roles with contacts:read keep seeing every conversation")
+	assert.False(t, hasChatAll(campaigns.ID))
+	assert.True(t, hasChatAll(systemRole("admin")))
+	assert.True(t, hasChatAll(systemRole("manager")))
+	assert.False(t, hasChatAll(systemRole("agent")), "agents are scoped to their teams")
+}
+
+// --- CreateIndexes ---
+
+func TestCreateIndexes_ReAddedProductTakesOverMetaID(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, database.AutoMigrate(db))
+	require.NoError(t, database.CreateIndexes(db))
+
+	org := testutil.CreateTestOrganization(t, db)
+	catalog := &models.Catalog{OrganizationID: org.ID, MetaCatalogID: "meta-catalog-" + uuid.New().String()[:8], Name: "Store"}
+	require.NoError(t, db.Create(catalog).Error)
+	metaID := "meta-product-" + uuid.New().String()[:8]
+	newProduct := func(metaProductID string) *models.CatalogProduct {
+		return &models.CatalogProduct{
+			OrganizationID: org.ID,
+			CatalogID:      catalog.ID,
+			MetaProductID:  metaProductID,
+			RetailerID:     "SKU-1",
+			Name:           "Blue Shirt",
+			Currency:       "USD",
+		}
+	}
+
+	// A feed import deleted the product here, but the delete never reached Meta
+	removed := newProduct(metaID)
+	require.NoError(t, db.Create(removed).Error)
+	require.NoError(t, db.Delete(removed).Error)
+
+	// The product is added again, and a sync matches it to Meta's copy by retailer ID
+	readded := newProduct("")
+	require.NoError(t, db.Create(readded).Error)
+	readded.MetaProductID = metaID
+	require.NoError(t, db.Save(readded).Error)
+
+	// Two live products still can't share a Meta ID
+	assert.Error(t, db.Create(newProduct(metaID)).Error)
+}
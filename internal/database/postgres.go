@@ -3,6 +3,8 @@ package database
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -69,10 +71,14 @@ func GetMigrationModels() []MigrationModel {
 		{"CustomAction", &models.CustomAction{}},
 		{"WhatsAppAccount", &models.WhatsAppAccount{}},
 		{"Contact", &models.Contact{}},
+		{"ContactReferral", &models.ContactReferral{}},
 		{"Tag", &models.Tag{}},
 		{"Message", &models.Message{}},
+		{"MessageRevision", &models.MessageRevision{}},
+		{"TemplateGroup", &models.TemplateGroup{}},
 		{"Template", &models.Template{}},
 		{"WhatsAppFlow", &models.WhatsAppFlow{}},
+		{"OTPVerification", &models.OTPVerification{}},
 
 		// Bulk & Notifications
 		{"BulkMessageCampaign", &models.BulkMessageCampaign{}},
@@ -84,26 +90,39 @@ func GetMigrationModels() []MigrationModel {
 		{"KeywordRule", &models.KeywordRule{}},
 		{"ChatbotFlow", &models.ChatbotFlow{}},
 		{"ChatbotFlowStep", &models.ChatbotFlowStep{}},
+		{"ChatbotFlowVersion", &models.ChatbotFlowVersion{}},
 		{"ChatbotSession", &models.ChatbotSession{}},
 		{"ChatbotSessionMessage", &models.ChatbotSessionMessage{}},
 		{"AIContext", &models.AIContext{}},
 		{"AgentTransfer", &models.AgentTransfer{}},
+		{"SLAPolicy", &models.SLAPolicy{}},
+		{"CSATSurvey", &models.CSATSurvey{}},
 
 		// User tracking
 		{"UserAvailabilityLog", &models.UserAvailabilityLog{}},
 
 		// Canned responses
 		{"CannedResponse", &models.CannedResponse{}},
+		{"CannedResponseUsage", &models.CannedResponseUsage{}},
 
 		// Catalogs
 		{"Catalog", &models.Catalog{}},
 		{"CatalogProduct", &models.CatalogProduct{}},
+		{"CatalogProductSet", &models.CatalogProductSet{}},
+		{"CatalogBatch", &models.CatalogBatch{}},
+		{"CatalogScheduledUpdate", &models.CatalogScheduledUpdate{}},
+		{"Order", &models.Order{}},
+		{"OrderItem", &models.OrderItem{}},
+		{"OrderEvent", &models.OrderEvent{}},
+		{"OrderNotification", &models.OrderNotification{}},
 
 		// Dashboard
 		{"Widget", &models.Widget{}},
 
 		// Conversation Notes
 		{"ConversationNote", &models.ConversationNote{}},
+		{"ConversationNoteAttachment", &models.ConversationNoteAttachment{}},
+		{"UserNotification", &models.UserNotification{}},
 	}
 }
 
@@ -259,6 +278,12 @@ func getIndexes() []string {
 		`CREATE INDEX IF NOT EXISTS idx_contacts_tags ON contacts USING GIN (tags)`,
 		// User organizations
 		`CREATE UNIQUE INDEX IF NOT EXISTS idx_user_org_unique ON user_organizations(user_id, organization_id) WHERE deleted_at IS NULL`,
+		// Catalog products: batch-created products have no Meta ID until Meta processes them,
+		// and a soft-deleted product gives up its Meta ID to the product re-added in its place
+		`DROP INDEX IF EXISTS idx_catalog_products_meta_product_id`,
+		`DROP INDEX IF EXISTS idx_catalog_products_meta_product`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_catalog_products_live_meta_product ON catalog_products(meta_product_id) WHERE meta_product_id <> '' AND deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_catalog_products_catalog_retailer ON catalog_products(catalog_id, retailer_id)`,
 		// Conversation notes
 		`CREATE INDEX IF NOT EXISTS idx_conversation_notes_contact ON conversation_notes(organization_id, contact_id, created_at DESC)`,
 	}
@@ -377,12 +402,71 @@ func SeedPermissionsAndRoles(db *gorm.DB) error {
 			if err := db.Create(&perm).Error; err != nil {
 				return fmt.Errorf("failed to create permission %s:%s: %w", perm.Resource, perm.Action, err)
 			}
+			if err := linkPermissionToSystemRoles(db, perm); err != nil {
+				return err
+			}
+			if err := linkPermissionToInheritingRoles(db, perm); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// linkPermissionToSystemRoles grants a newly added permission to the existing
+// system roles that have it by default. It only runs when the permission is
+// created, so later changes to the roles are kept.
+func linkPermissionToSystemRoles(db *gorm.DB, perm models.Permission) error {
+	key := perm.Resource + ":" + perm.Action
+	for roleName, permKeys := range models.SystemRolePermissions() {
+		if !slices.Contains(permKeys, key) {
+			continue
+		}
+		err := db.Exec(`
+			INSERT INTO role_permissions (custom_role_id, permission_id)
+			SELECT id, ? FROM custom_roles
+			WHERE is_system = ? AND name = ? AND deleted_at IS NULL
+			ON CONFLICT DO NOTHING
+		`, perm.ID, true, roleName).Error
+		if err != nil {
+			return fmt.Errorf("failed to grant permission %s to %s roles: %w", key, roleName, err)
+		}
+	}
+	return nil
+}
+
+// inheritedPermissions maps permissions split off from an existing permission to
+// the permission that used to grant that access
+var inheritedPermissions = map[string]string{
+	// Seeing every conversation used to come with contacts:read
+	models.ResourceChatAll + ":" + models.ActionRead: models.ResourceContacts + ":" + models.ActionRead,
+}
+
+// linkPermissionToInheritingRoles grants a newly added permission to the custom
+// roles holding the permission it was split off from, so they keep their access.
+// System roles get their defaults from linkPermissionToSystemRoles instead.
+func linkPermissionToInheritingRoles(db *gorm.DB, perm models.Permission) error {
+	key := perm.Resource + ":" + perm.Action
+	source, ok := inheritedPermissions[key]
+	if !ok {
+		return nil
+	}
+	resource, action, _ := strings.Cut(source, ":")
+	err := db.Exec(`
+		INSERT INTO role_permissions (custom_role_id, permission_id)
+		SELECT rp.custom_role_id, ? FROM role_permissions rp
+		JOIN permissions p ON p.id = rp.permission_id
+		JOIN custom_roles cr ON cr.id = rp.custom_role_id
+		WHERE p.resource = ? AND p.action = ? AND cr.is_system = ? AND cr.deleted_at IS NULL
+		ON CONFLICT DO NOTHING
+	`, perm.ID, resource, action, false).Error
+	if err != nil {
+		return fmt.Errorf("failed to grant permission %s to roles with %s: %w", key, source, err)
+	}
+	return nil
+}
+
 // SeedSystemRolesForAllOrgs creates system roles for all existing organizations
 // This is idempotent - it skips organizations that already have system roles
 func SeedSystemRolesForAllOrgs(db *gorm.DB) error {
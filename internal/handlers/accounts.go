@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/crypto"
@@ -33,23 +34,40 @@ type AccountRequest struct {
 
 // AccountResponse represents the response for an account (without sensitive data)
 type AccountResponse struct {
-	ID                 uuid.UUID `json:"id"`
-	Name               string    `json:"name"`
-	AppID              string    `json:"app_id"`
-	PhoneID            string    `json:"phone_id"`
-	BusinessID         string    `json:"business_id"`
-	WebhookVerifyToken string    `json:"webhook_verify_token"`
-	APIVersion         string    `json:"api_version"`
-	IsDefaultIncoming  bool      `json:"is_default_incoming"`
-	IsDefaultOutgoing  bool      `json:"is_default_outgoing"`
-	AutoReadReceipt    bool      `json:"auto_read_receipt"`
-	Status             string    `json:"status"`
-	HasAccessToken     bool      `json:"has_access_token"`
-	HasAppSecret       bool      `json:"has_app_secret"`
-	PhoneNumber        string    `json:"phone_number,omitempty"`
-	DisplayName        string    `json:"display_name,omitempty"`
-	CreatedAt          string    `json:"created_at"`
-	UpdatedAt          string    `json:"updated_at"`
+	ID                 uuid.UUID     `json:"id"`
+	Name               string        `json:"name"`
+	AppID              string        `json:"app_id"`
+	PhoneID            string        `json:"phone_id"`
+	BusinessID         string        `json:"business_id"`
+	WebhookVerifyToken string        `json:"webhook_verify_token"`
+	APIVersion         string        `json:"api_version"`
+	IsDefaultIncoming  bool          `json:"is_default_incoming"`
+	IsDefaultOutgoing  bool          `json:"is_default_outgoing"`
+	AutoReadReceipt    bool          `json:"auto_read_receipt"`
+	Status             string        `json:"status"`
+	HasAccessToken     bool          `json:"has_access_token"`
+	HasAppSecret       bool          `json:"has_app_secret"`
+	PhoneNumber        string        `json:"phone_number,omitempty"`
+	DisplayName        string        `json:"display_name,omitempty"`
+	Health             AccountHealth `json:"health"`
+	HasFlowKeys        bool          `json:"has_flow_keys"`
+	FlowKeyStatus      string        `json:"flow_key_status,omitempty"` // VALID, MISMATCH
+	CreatedAt          string        `json:"created_at"`
+	UpdatedAt          string        `json:"updated_at"`
+}
+
+// AccountHealth is the phone number and account state reported by Meta
+type AccountHealth struct {
+	QualityRating       string            `json:"quality_rating,omitempty"`
+	QualityEvent        string            `json:"quality_event,omitempty"`
+	MessagingLimitTier  string            `json:"messaging_limit_tier,omitempty"`
+	NameStatus          string            `json:"name_status,omitempty"`
+	NameRejectionReason string            `json:"name_rejection_reason,omitempty"`
+	AccountEvent        string            `json:"account_event,omitempty"`
+	BanState            string            `json:"ban_state,omitempty"`
+	Restrictions        models.JSONBArray `json:"restrictions,omitempty"`
+	Capabilities        models.JSONB      `json:"capabilities,omitempty"`
+	UpdatedAt           *time.Time        `json:"updated_at,omitempty"`
 }
 
 // ListAccounts returns all WhatsApp accounts for the organization
@@ -347,6 +365,22 @@ func (a *App) TestAccountConnection(r *fastglue.Request) error {
 	var result map[string]interface{}
 	_ = json.Unmarshal(body, &result)
 
+	// Keep the number's details and health on the account
+	health := map[string]any{"health_updated_at": time.Now()}
+	for field, key := range map[string]string{
+		"display_phone_number": "display_phone_number",
+		"verified_name":        "verified_name",
+		"quality_rating":       "quality_rating",
+		"messaging_limit_tier": "messaging_limit_tier",
+	} {
+		if v, ok := result[key].(string); ok && v != "" {
+			health[field] = v
+		}
+	}
+	if err := a.DB.Model(account).Updates(health).Error; err != nil {
+		a.Log.Error("Failed to save account health", "error", err, "account", account.Name)
+	}
+
 	// Check if this is a test/sandbox number
 	accountMode, _ := result["account_mode"].(string)
 	isTestNumber := accountMode == "SANDBOX"
@@ -390,8 +424,24 @@ func accountToResponse(acc models.WhatsAppAccount) AccountResponse {
 		Status:             acc.Status,
 		HasAccessToken:     acc.AccessToken != "",
 		HasAppSecret:       acc.AppSecret != "",
-		CreatedAt:          acc.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:          acc.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		PhoneNumber:        acc.DisplayPhoneNumber,
+		DisplayName:        acc.VerifiedName,
+		Health: AccountHealth{
+			QualityRating:       acc.QualityRating,
+			QualityEvent:        acc.QualityEvent,
+			MessagingLimitTier:  acc.MessagingLimitTier,
+			NameStatus:          acc.NameStatus,
+			NameRejectionReason: acc.NameRejectionReason,
+			AccountEvent:        acc.AccountEvent,
+			BanState:            acc.BanState,
+			Restrictions:        acc.Restrictions,
+			Capabilities:        acc.Capabilities,
+			UpdatedAt:           acc.HealthUpdatedAt,
+		},
+		HasFlowKeys:   acc.FlowPrivateKey != "",
+		FlowKeyStatus: acc.FlowPublicKeyStatus,
+		CreatedAt:     acc.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:     acc.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 }
 
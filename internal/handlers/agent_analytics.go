@@ -11,29 +11,93 @@ import (
 
 // AgentAnalyticsSummary represents overall agent analytics
 type AgentAnalyticsSummary struct {
-	TotalTransfersHandled int64            `json:"total_transfers_handled"`
-	ActiveTransfers       int64            `json:"active_transfers"`
-	AvgQueueTimeMins      float64          `json:"avg_queue_time_mins"`
-	AvgFirstResponseMins  float64          `json:"avg_first_response_mins"`
-	AvgResolutionMins     float64          `json:"avg_resolution_mins"`
-	TransfersBySource     map[string]int64 `json:"transfers_by_source"`
-	TotalBreakTimeMins    float64          `json:"total_break_time_mins"`
-	BreakCount            int64            `json:"break_count"`
+	TotalTransfersHandled int64                 `json:"total_transfers_handled"`
+	ActiveTransfers       int64                 `json:"active_transfers"`
+	AvgQueueTimeMins      float64               `json:"avg_queue_time_mins"`
+	AvgFirstResponseMins  float64               `json:"avg_first_response_mins"`
+	AvgResolutionMins     float64               `json:"avg_resolution_mins"`
+	TransfersBySource     map[string]int64      `json:"transfers_by_source"`
+	TotalBreakTimeMins    float64               `json:"total_break_time_mins"`
+	BreakCount            int64                 `json:"break_count"`
+	BreakTimeByReason     map[string]float64    `json:"break_time_by_reason"`
+	SLACompliance         SLACompliance         `json:"sla_compliance"`
+	SLAComplianceByPolicy []SLAPolicyCompliance `json:"sla_compliance_by_policy,omitempty"`
+	CSAT                  CSATScore             `json:"csat"`
 }
 
 // AgentPerformanceStats represents performance metrics for an agent
 type AgentPerformanceStats struct {
-	AgentID              string   `json:"agent_id"`
-	AgentName            string   `json:"agent_name"`
-	AvgFirstResponseMins float64  `json:"avg_first_response_mins"`
-	AvgResolutionMins    float64  `json:"avg_resolution_mins"`
-	TransfersHandled     int64    `json:"transfers_handled"`
-	ActiveTransfers      int64    `json:"active_transfers"`
-	MessagesSent         int64    `json:"messages_sent"`
-	TotalBreakTimeMins   float64  `json:"total_break_time_mins"`
-	BreakCount           int64    `json:"break_count"`
-	IsAvailable          bool     `json:"is_available"`
-	CurrentBreakStart    *string  `json:"current_break_start,omitempty"`
+	AgentID              string             `json:"agent_id"`
+	AgentName            string             `json:"agent_name"`
+	AvgFirstResponseMins float64            `json:"avg_first_response_mins"`
+	AvgResolutionMins    float64            `json:"avg_resolution_mins"`
+	TransfersHandled     int64              `json:"transfers_handled"`
+	ActiveTransfers      int64              `json:"active_transfers"`
+	MessagesSent         int64              `json:"messages_sent"`
+	TotalBreakTimeMins   float64            `json:"total_break_time_mins"`
+	BreakCount           int64              `json:"break_count"`
+	IsAvailable          bool               `json:"is_available"`
+	CurrentBreakStart    *string            `json:"current_break_start,omitempty"`
+	CurrentAwayReason    string             `json:"current_away_reason,omitempty"`
+	BreakTimeByReason    map[string]float64 `json:"break_time_by_reason"`
+	ShiftAdherence       *ShiftAdherence    `json:"shift_adherence,omitempty"` // nil without a shift schedule
+	SLACompliance        SLACompliance      `json:"sla_compliance"`
+	CSAT                 CSATScore          `json:"csat"`
+}
+
+// SLACompliance reports the share of SLA targets met. Totals count the
+// targets that are due; targets still running are left out.
+type SLACompliance struct {
+	FirstResponseTotal int64   `json:"first_response_total"`
+	FirstResponsePct   float64 `json:"first_response_pct"`
+	NextResponseTotal  int64   `json:"next_response_total"`
+	NextResponsePct    float64 `json:"next_response_pct"`
+	ResolutionTotal    int64   `json:"resolution_total"`
+	ResolutionPct      float64 `json:"resolution_pct"`
+}
+
+// SLAPolicyCompliance is the SLA compliance of the transfers under one policy
+type SLAPolicyCompliance struct {
+	PolicyID   *string `json:"policy_id"` // null = chatbot settings
+	PolicyName string  `json:"policy_name"`
+	SLACompliance
+}
+
+// slaComplianceCounts holds the due and met SLA targets of a set of transfers
+type slaComplianceCounts struct {
+	PolicyID         *uuid.UUID
+	FirstResponseDue int64
+	FirstResponseMet int64
+	NextResponseDue  int64
+	NextResponseMet  int64
+	ResolutionDue    int64
+	ResolutionMet    int64
+}
+
+// CSATScore summarizes the satisfaction surveys sent in a period. CSAT
+// surveys are rated 1-5 and count as satisfied at 4 or more; NPS is the share
+// of promoters (9-10) minus the share of detractors (0-6).
+type CSATScore struct {
+	Sent          int64   `json:"sent"`
+	Responses     int64   `json:"responses"`
+	ResponseRate  float64 `json:"response_rate"`
+	CSATResponses int64   `json:"csat_responses"`
+	AvgCSAT       float64 `json:"avg_csat"`
+	SatisfiedPct  float64 `json:"satisfied_pct"`
+	NPSResponses  int64   `json:"nps_responses"`
+	NPS           float64 `json:"nps"`
+}
+
+// csatCounts holds the survey counts behind a CSATScore
+type csatCounts struct {
+	Sent          int64
+	Responses     int64
+	CSATResponses int64
+	CSATSum       int64
+	CSATSatisfied int64
+	NPSResponses  int64
+	NPSPromoters  int64
+	NPSDetractors int64
 }
 
 // TrendPoint represents a data point for time-series charts
@@ -86,6 +150,7 @@ func (a *App) GetAgentAnalytics(r *fastglue.Request) error {
 	response := AgentAnalyticsResponse{
 		Summary: AgentAnalyticsSummary{
 			TransfersBySource: make(map[string]int64),
+			BreakTimeByReason: make(map[string]float64),
 		},
 		TrendData: []TrendPoint{},
 	}
@@ -268,6 +333,12 @@ func (a *App) calculateSummaryStats(orgID uuid.UUID, start, end time.Time, summa
 	for _, sc := range sourceCounts {
 		summary.TransfersBySource[sc.Source] = sc.Count
 	}
+
+	// SLA compliance, overall and per policy
+	summary.SLACompliance = a.calculateSLACompliance(orgID, nil, start, end)
+	summary.SLAComplianceByPolicy = a.calculateSLAComplianceByPolicy(orgID, start, end)
+
+	summary.CSAT = a.calculateCSATScore(orgID, nil, start, end)
 }
 
 func (a *App) calculateAgentSummaryStats(orgID, agentID uuid.UUID, start, end time.Time, summary *AgentAnalyticsSummary) {
@@ -311,7 +382,141 @@ func (a *App) calculateAgentSummaryStats(orgID, agentID uuid.UUID, start, end ti
 	}
 
 	// Calculate break time
-	summary.TotalBreakTimeMins, summary.BreakCount = a.calculateBreakTime(agentID, start, end)
+	summary.TotalBreakTimeMins, summary.BreakCount, summary.BreakTimeByReason = a.calculateBreakTime(agentID, start, end)
+
+	// SLA compliance for this agent's transfers
+	summary.SLACompliance = a.calculateSLACompliance(orgID, &agentID, start, end)
+
+	summary.CSAT = a.calculateCSATScore(orgID, &agentID, start, end)
+}
+
+// slaComplianceSelect counts due and met SLA targets. A first response is met
+// when the agent replied by the response deadline, a resolution when the
+// transfer was resumed by the resolution deadline.
+const slaComplianceSelect = `
+	COUNT(*) FILTER (WHERE sla_response_deadline IS NOT NULL AND (first_response_at IS NOT NULL OR sla_response_deadline < @now)) AS first_response_due,
+	COUNT(*) FILTER (WHERE first_response_at IS NOT NULL AND first_response_at <= sla_response_deadline) AS first_response_met,
+	COALESCE(SUM(sla_next_responses), 0) AS next_response_due,
+	COALESCE(SUM(sla_next_responses - sla_next_response_breaches), 0) AS next_response_met,
+	COUNT(*) FILTER (WHERE sla_resolution_deadline IS NOT NULL AND (status <> 'active' OR sla_resolution_deadline < @now)) AS resolution_due,
+	COUNT(*) FILTER (WHERE status = 'resumed' AND resumed_at <= sla_resolution_deadline) AS resolution_met`
+
+// newSLACompliance turns due and met counts into percentages
+func newSLACompliance(c slaComplianceCounts) SLACompliance {
+	pct := func(met, due int64) float64 {
+		if due == 0 {
+			return 0
+		}
+		return float64(met) / float64(due) * 100
+	}
+	return SLACompliance{
+		FirstResponseTotal: c.FirstResponseDue,
+		FirstResponsePct:   pct(c.FirstResponseMet, c.FirstResponseDue),
+		NextResponseTotal:  c.NextResponseDue,
+		NextResponsePct:    pct(c.NextResponseMet, c.NextResponseDue),
+		ResolutionTotal:    c.ResolutionDue,
+		ResolutionPct:      pct(c.ResolutionMet, c.ResolutionDue),
+	}
+}
+
+// calculateSLACompliance reports SLA compliance for the transfers created in
+// the period, optionally only those of one agent
+func (a *App) calculateSLACompliance(orgID uuid.UUID, agentID *uuid.UUID, start, end time.Time) SLACompliance {
+	query := a.DB.Model(&models.AgentTransfer{}).
+		Select(slaComplianceSelect, map[string]any{"now": time.Now()}).
+		Where("organization_id = ? AND transferred_at >= ? AND transferred_at <= ?", orgID, start, end)
+	if agentID != nil {
+		query = query.Where("agent_id = ?", *agentID)
+	}
+
+	var counts slaComplianceCounts
+	if err := query.Scan(&counts).Error; err != nil {
+		a.Log.Error("Failed to calculate SLA compliance", "error", err, "org_id", orgID)
+	}
+	return newSLACompliance(counts)
+}
+
+// calculateSLAComplianceByPolicy reports SLA compliance per SLA policy for
+// the transfers created in the period
+func (a *App) calculateSLAComplianceByPolicy(orgID uuid.UUID, start, end time.Time) []SLAPolicyCompliance {
+	var rows []slaComplianceCounts
+	if err := a.DB.Model(&models.AgentTransfer{}).
+		Select("sla_policy_id AS policy_id,"+slaComplianceSelect, map[string]any{"now": time.Now()}).
+		Where("organization_id = ? AND transferred_at >= ? AND transferred_at <= ?", orgID, start, end).
+		Where("sla_response_deadline IS NOT NULL OR sla_resolution_deadline IS NOT NULL OR sla_policy_id IS NOT NULL").
+		Group("sla_policy_id").
+		Scan(&rows).Error; err != nil {
+		a.Log.Error("Failed to calculate SLA compliance by policy", "error", err, "org_id", orgID)
+		return nil
+	}
+
+	// Policy names, including deleted policies
+	var policies []models.SLAPolicy
+	a.DB.Unscoped().Select("id, name").Where("organization_id = ?", orgID).Find(&policies)
+	names := make(map[uuid.UUID]string, len(policies))
+	for _, p := range policies {
+		names[p.ID] = p.Name
+	}
+
+	result := make([]SLAPolicyCompliance, 0, len(rows))
+	for _, row := range rows {
+		entry := SLAPolicyCompliance{
+			PolicyName:    "Default",
+			SLACompliance: newSLACompliance(row),
+		}
+		if row.PolicyID != nil {
+			id := row.PolicyID.String()
+			entry.PolicyID = &id
+			entry.PolicyName = names[*row.PolicyID]
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// newCSATScore turns survey counts into averages and percentages
+func newCSATScore(c csatCounts) CSATScore {
+	ratio := func(n, total int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(n) / float64(total)
+	}
+	return CSATScore{
+		Sent:          c.Sent,
+		Responses:     c.Responses,
+		ResponseRate:  ratio(c.Responses, c.Sent) * 100,
+		CSATResponses: c.CSATResponses,
+		AvgCSAT:       ratio(c.CSATSum, c.CSATResponses),
+		SatisfiedPct:  ratio(c.CSATSatisfied, c.CSATResponses) * 100,
+		NPSResponses:  c.NPSResponses,
+		NPS:           (ratio(c.NPSPromoters, c.NPSResponses) - ratio(c.NPSDetractors, c.NPSResponses)) * 100,
+	}
+}
+
+// calculateCSATScore reports the results of the surveys sent in the period,
+// optionally only those about one agent
+func (a *App) calculateCSATScore(orgID uuid.UUID, agentID *uuid.UUID, start, end time.Time) CSATScore {
+	query := a.DB.Model(&models.CSATSurvey{}).
+		Select(`
+			COUNT(*) AS sent,
+			COUNT(*) FILTER (WHERE status = 'answered') AS responses,
+			COUNT(*) FILTER (WHERE status = 'answered' AND scale = 'csat') AS csat_responses,
+			COALESCE(SUM(score) FILTER (WHERE status = 'answered' AND scale = 'csat'), 0) AS csat_sum,
+			COUNT(*) FILTER (WHERE status = 'answered' AND scale = 'csat' AND score >= 4) AS csat_satisfied,
+			COUNT(*) FILTER (WHERE status = 'answered' AND scale = 'nps') AS nps_responses,
+			COUNT(*) FILTER (WHERE status = 'answered' AND scale = 'nps' AND score >= 9) AS nps_promoters,
+			COUNT(*) FILTER (WHERE status = 'answered' AND scale = 'nps' AND score <= 6) AS nps_detractors`).
+		Where("organization_id = ? AND sent_at >= ? AND sent_at <= ?", orgID, start, end)
+	if agentID != nil {
+		query = query.Where("agent_id = ?", *agentID)
+	}
+
+	var counts csatCounts
+	if err := query.Scan(&counts).Error; err != nil {
+		a.Log.Error("Failed to calculate CSAT score", "error", err, "org_id", orgID)
+	}
+	return newCSATScore(counts)
 }
 
 func (a *App) calculateAgentStats(orgID, agentID uuid.UUID, start, end time.Time) AgentPerformanceStats {
@@ -357,7 +562,18 @@ func (a *App) calculateAgentStats(orgID, agentID uuid.UUID, start, end time.Time
 	stats.AvgResolutionMins = resolutionTimeResult.Avg
 
 	// Calculate break time from availability logs
-	stats.TotalBreakTimeMins, stats.BreakCount = a.calculateBreakTime(agentID, start, end)
+	stats.TotalBreakTimeMins, stats.BreakCount, stats.BreakTimeByReason = a.calculateBreakTime(agentID, start, end)
+
+	// SLA compliance for this agent's transfers
+	stats.SLACompliance = a.calculateSLACompliance(orgID, &agentID, start, end)
+
+	// Satisfaction survey results for this agent's transfers
+	stats.CSAT = a.calculateCSATScore(orgID, &agentID, start, end)
+
+	// Compare availability with the agent's shift schedule
+	if agent.ID != uuid.Nil {
+		stats.ShiftAdherence = a.calculateShiftAdherence(&agent, start, end)
+	}
 
 	// Check if currently on break and get break start time
 	if !stats.IsAvailable {
@@ -366,6 +582,7 @@ func (a *App) calculateAgentStats(orgID, agentID uuid.UUID, start, end time.Time
 			Order("started_at DESC").First(&currentBreak).Error == nil {
 			breakStart := currentBreak.StartedAt.Format(time.RFC3339)
 			stats.CurrentBreakStart = &breakStart
+			stats.CurrentAwayReason = string(currentBreak.Reason)
 		}
 	}
 
@@ -394,18 +611,25 @@ func (a *App) calculateAllAgentStats(orgID uuid.UUID, start, end time.Time) []Ag
 	return stats
 }
 
-// calculateBreakTime calculates total break time and count for an agent within a time period
-func (a *App) calculateBreakTime(agentID uuid.UUID, start, end time.Time) (totalMins float64, count int64) {
+// calculateBreakTime calculates total break time and count for an agent within a time period,
+// with the break time per away reason. Time off shift is not break time.
+func (a *App) calculateBreakTime(agentID uuid.UUID, start, end time.Time) (totalMins float64, count int64, byReason map[string]float64) {
+	byReason = make(map[string]float64)
+
 	// Get all "away" periods that overlap with the time range
 	var logs []models.UserAvailabilityLog
 	if err := a.DB.Where("user_id = ? AND is_available = false AND started_at <= ? AND (ended_at >= ? OR ended_at IS NULL)",
 		agentID, end, start).
 		Find(&logs).Error; err != nil {
 		a.Log.Error("Failed to fetch availability logs for break time calculation", "error", err, "agent_id", agentID)
-		return 0, 0
+		return 0, 0, byReason
 	}
 
 	for _, log := range logs {
+		if !countsAsBreak(log.Reason) {
+			continue
+		}
+
 		// Calculate the overlap with our time range
 		logStart := log.StartedAt
 		if logStart.Before(start) {
@@ -428,10 +652,16 @@ func (a *App) calculateBreakTime(agentID uuid.UUID, start, end time.Time) (total
 			duration := logEnd.Sub(logStart).Minutes()
 			totalMins += duration
 			count++
+
+			reason := string(log.Reason)
+			if reason == "" {
+				reason = string(models.AwayReasonOther)
+			}
+			byReason[reason] += duration
 		}
 	}
 
-	return totalMins, count
+	return totalMins, count, byReason
 }
 
 func (a *App) calculateTrendData(orgID uuid.UUID, start, end time.Time, groupBy string, agentID *uuid.UUID) []TrendPoint {
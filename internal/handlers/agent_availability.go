@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+)
+
+// validAwayReason reports whether reason can be chosen by an agent going away
+func validAwayReason(reason models.AwayReason) bool {
+	switch reason {
+	case models.AwayReasonBreak, models.AwayReasonLunch, models.AwayReasonMeeting,
+		models.AwayReasonTraining, models.AwayReasonOther:
+		return true
+	}
+	return false
+}
+
+// countsAsBreak reports whether an away period with this reason is break
+// time. Time off shift is not.
+func countsAsBreak(reason models.AwayReason) bool {
+	return reason != models.AwayReasonOffShift
+}
+
+// setAgentAvailability changes a user's availability, logging the change with
+// its reason and source. Going away returns the user's active transfers to
+// the queue. Returns the number of transfers returned.
+func (a *App) setAgentAvailability(user *models.User, orgID uuid.UUID, available bool, reason models.AwayReason, source models.AvailabilitySource) (int, error) {
+	if available {
+		reason = ""
+	}
+
+	// Only log if status is actually changing, or an away agent's reason changes
+	logChange := user.IsAvailable != available
+	if !logChange && !available {
+		var current models.UserAvailabilityLog
+		if err := a.DB.Where("user_id = ? AND ended_at IS NULL", user.ID).
+			Order("started_at DESC").First(&current).Error; err == nil && current.Reason != reason {
+			logChange = true
+		}
+	}
+	if logChange {
+		now := time.Now()
+
+		// End the previous availability log (if exists)
+		a.DB.Model(&models.UserAvailabilityLog{}).
+			Where("user_id = ? AND ended_at IS NULL", user.ID).
+			Update("ended_at", now)
+
+		// Create new availability log
+		log := models.UserAvailabilityLog{
+			UserID:         user.ID,
+			OrganizationID: orgID,
+			IsAvailable:    available,
+			Reason:         reason,
+			Source:         source,
+			StartedAt:      now,
+		}
+		if err := a.DB.Create(&log).Error; err != nil {
+			a.Log.Error("Failed to create availability log", "error", err)
+			// Continue anyway - logging failure shouldn't block availability update
+		}
+	}
+
+	changed := user.IsAvailable != available
+	user.IsAvailable = available
+	if err := a.DB.Model(user).Update("is_available", available).Error; err != nil {
+		return 0, err
+	}
+
+	transfersReturned := 0
+	if !available {
+		// Return agent's active transfers to queue when going away
+		transfersReturned = a.ReturnAgentTransfersToQueue(user.ID, orgID)
+	}
+
+	if changed && source != models.AvailabilitySourceManual && a.WSHub != nil {
+		// Let the agent's open tabs know about automatic changes
+		a.WSHub.BroadcastToUser(orgID, user.ID, websocket.WSMessage{
+			Type: websocket.TypeAvailabilityUpdate,
+			Payload: map[string]any{
+				"is_available":       available,
+				"reason":             reason,
+				"source":             source,
+				"transfers_to_queue": transfersReturned,
+			},
+		})
+	}
+
+	return transfersReturned, nil
+}
+
+// validateShiftSchedule checks a user's timezone and weekly shift schedule
+func validateShiftSchedule(timezone string, schedule models.JSONBArray) error {
+	if err := validateBusinessHours(models.BusinessHoursConfig{Timezone: timezone, Hours: schedule}); err != nil {
+		return fmt.Errorf("shift schedule: %w", err)
+	}
+	return nil
+}
+
+// shiftCalendar returns the user's shift schedule as a calendar in the
+// user's timezone (or else the organization's), or nil without a schedule
+func (a *App) shiftCalendar(user *models.User) *businessCalendar {
+	if len(user.ShiftSchedule) == 0 {
+		return nil
+	}
+	var loc *time.Location
+	if user.Timezone != "" {
+		l, err := time.LoadLocation(user.Timezone)
+		if err != nil {
+			a.Log.Warn("Invalid user timezone, using organization timezone", "timezone", user.Timezone, "user_id", user.ID)
+		}
+		loc = l
+	}
+	if loc == nil {
+		loc = a.organizationTimezone(user.OrganizationID)
+	}
+	return newBusinessCalendar(models.BusinessHoursConfig{Hours: user.ShiftSchedule}, loc)
+}
+
+// timeSpan is a period of time, end exclusive
+type timeSpan struct {
+	start time.Time
+	end   time.Time
+}
+
+// workingSpans returns the working periods of the calendar that overlap
+// [start, end), clipped to it
+func (c *businessCalendar) workingSpans(start, end time.Time) []timeSpan {
+	var spans []timeSpan
+	local := start.In(c.loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.loc)
+	for !day.After(end) {
+		for _, iv := range c.intervalsOn(day) {
+			s := time.Date(day.Year(), day.Month(), day.Day(), iv.start/60, iv.start%60, 0, 0, c.loc)
+			e := time.Date(day.Year(), day.Month(), day.Day(), iv.end/60, iv.end%60, 0, 0, c.loc).Add(time.Minute)
+			if s.Before(start) {
+				s = start
+			}
+			if e.After(end) {
+				e = end
+			}
+			if e.After(s) {
+				spans = append(spans, timeSpan{start: s, end: e})
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, c.loc)
+	}
+	return spans
+}
+
+// spanMinutes returns the total length of spans in minutes
+func spanMinutes(spans []timeSpan) float64 {
+	var total time.Duration
+	for _, s := range spans {
+		total += s.end.Sub(s.start)
+	}
+	return total.Minutes()
+}
+
+// overlapMinutes returns how many minutes of a overlap b
+func overlapMinutes(a, b []timeSpan) float64 {
+	var total time.Duration
+	for _, x := range a {
+		for _, y := range b {
+			s, e := x.start, x.end
+			if y.start.After(s) {
+				s = y.start
+			}
+			if y.end.Before(e) {
+				e = y.end
+			}
+			if e.After(s) {
+				total += e.Sub(s)
+			}
+		}
+	}
+	return total.Minutes()
+}
+
+// availableSpans returns when the agent was available within [start, end),
+// from the availability logs
+func availableSpans(logs []models.UserAvailabilityLog, start, end, now time.Time) []timeSpan {
+	var spans []timeSpan
+	for _, log := range logs {
+		if !log.IsAvailable {
+			continue
+		}
+		s, e := log.StartedAt, now
+		if log.EndedAt != nil {
+			e = *log.EndedAt
+		}
+		if s.Before(start) {
+			s = start
+		}
+		if e.After(end) {
+			e = end
+		}
+		if e.After(s) {
+			spans = append(spans, timeSpan{start: s, end: e})
+		}
+	}
+	return spans
+}
+
+// ShiftAdherence reports how closely an agent kept to their shift schedule
+type ShiftAdherence struct {
+	ScheduledMins     float64 `json:"scheduled_mins"`
+	AvailableOnShift  float64 `json:"available_on_shift_mins"`
+	AvailableOffShift float64 `json:"available_off_shift_mins"`
+	AdherencePct      float64 `json:"adherence_pct"` // Share of scheduled time spent available
+}
+
+// calculateShiftAdherence compares an agent's availability with their shift
+// schedule. Returns nil for agents without a schedule.
+func (a *App) calculateShiftAdherence(agent *models.User, start, end time.Time) *ShiftAdherence {
+	cal := a.shiftCalendar(agent)
+	if cal == nil {
+		return nil
+	}
+	now := time.Now()
+	if end.After(now) {
+		end = now
+	}
+
+	var logs []models.UserAvailabilityLog
+	if err := a.DB.Where("user_id = ? AND is_available = true AND started_at <= ? AND (ended_at >= ? OR ended_at IS NULL)",
+		agent.ID, end, start).
+		Find(&logs).Error; err != nil {
+		a.Log.Error("Failed to fetch availability logs for shift adherence", "error", err, "agent_id", agent.ID)
+		return nil
+	}
+
+	return shiftAdherence(cal, logs, start, end, now)
+}
+
+// shiftAdherence computes adherence from a shift calendar and availability logs
+func shiftAdherence(cal *businessCalendar, logs []models.UserAvailabilityLog, start, end, now time.Time) *ShiftAdherence {
+	shifts := cal.workingSpans(start, end)
+	available := availableSpans(logs, start, end, now)
+
+	adherence := &ShiftAdherence{
+		ScheduledMins: spanMinutes(shifts),
+	}
+	adherence.AvailableOnShift = overlapMinutes(available, shifts)
+	adherence.AvailableOffShift = spanMinutes(available) - adherence.AvailableOnShift
+	if adherence.ScheduledMins > 0 {
+		adherence.AdherencePct = adherence.AvailableOnShift / adherence.ScheduledMins * 100
+	}
+	return adherence
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	// agentShiftLease names the leader lease for applying shift schedules and idle auto-away
+	agentShiftLease = "agent_shifts"
+
+	// agentActivityPrefix keys when an agent was last active on any server.
+	// It is kept after they disconnect so idle time keeps counting.
+	agentActivityPrefix = "agent_activity:"
+	agentActivityTTL    = 7 * 24 * time.Hour
+)
+
+// AgentAvailabilityProcessor sets agents available or away from their shift
+// schedules, and away after they have been idle for too long. Both are applied
+// by the replica holding the leader lease; idle time comes from the activity
+// the servers record in Redis.
+type AgentAvailabilityProcessor struct {
+	app      *App
+	interval time.Duration
+	lastRun  time.Time
+	lease    *queue.Lease
+	stopCh   chan struct{}
+}
+
+// NewAgentAvailabilityProcessor creates a new agent availability processor
+func NewAgentAvailabilityProcessor(app *App, interval time.Duration) *AgentAvailabilityProcessor {
+	return &AgentAvailabilityProcessor{
+		app:      app,
+		interval: interval,
+		lease:    app.newProcessorLease(agentShiftLease, 2*interval),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the agent availability processing loop
+func (p *AgentAvailabilityProcessor) Start(ctx context.Context) {
+	p.app.Log.Info("Agent availability processor started", "interval", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	defer p.app.releaseLease(p.lease, agentShiftLease)
+
+	p.lastRun = time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			p.app.Log.Info("Agent availability processor stopped by context")
+			return
+		case <-p.stopCh:
+			p.app.Log.Info("Agent availability processor stopped")
+			return
+		case <-ticker.C:
+			now := time.Now()
+			// lastRun advances on every replica so a new leader doesn't
+			// replay transitions the previous one already applied
+			if p.app.holdLease(p.lease, agentShiftLease) {
+				p.applyShiftSchedules(p.lastRun, now)
+				p.applyIdleAway(now)
+			}
+			p.lastRun = now
+		}
+	}
+}
+
+// Stop stops the agent availability processor
+func (p *AgentAvailabilityProcessor) Stop() {
+	close(p.stopCh)
+}
+
+// shiftTransition returns whether a shift started (true) or ended (false)
+// between prev and now. changed is false when neither happened.
+func shiftTransition(cal *businessCalendar, prev, now time.Time) (onShift bool, changed bool) {
+	was := cal.isOpen(prev)
+	is := cal.isOpen(now)
+	return is, was != is
+}
+
+// applyShiftSchedules sets agents available when their shift starts and away
+// when it ends. Only transitions are applied, so agents can still change
+// their availability by hand during or outside their shift.
+func (p *AgentAvailabilityProcessor) applyShiftSchedules(prev, now time.Time) {
+	var users []models.User
+	if err := p.app.DB.Where("is_active = ? AND shift_schedule IS NOT NULL AND shift_schedule != '[]'::jsonb", true).
+		Find(&users).Error; err != nil {
+		p.app.Log.Error("Failed to load agent shift schedules", "error", err)
+		return
+	}
+
+	for i := range users {
+		user := &users[i]
+		cal := p.app.shiftCalendar(user)
+		if cal == nil {
+			continue
+		}
+		onShift, changed := shiftTransition(cal, prev, now)
+		if !changed || user.IsAvailable == onShift {
+			continue
+		}
+
+		reason := models.AwayReason("")
+		if !onShift {
+			reason = models.AwayReasonOffShift
+		}
+		returned, err := p.app.setAgentAvailability(user, user.OrganizationID, onShift, reason, models.AvailabilitySourceSchedule)
+		if err != nil {
+			p.app.Log.Error("Failed to apply shift schedule", "error", err, "user_id", user.ID)
+			continue
+		}
+		p.app.Log.Info("Agent availability set by shift schedule",
+			"user_id", user.ID,
+			"is_available", onShift,
+			"transfers_to_queue", returned,
+		)
+	}
+}
+
+// applyIdleAway sets available agents away once they have had no WebSocket
+// activity for the organization's idle away time
+func (p *AgentAvailabilityProcessor) applyIdleAway(now time.Time) {
+	var orgIDs []uuid.UUID
+	if err := p.app.DB.Model(&models.ChatbotSettings{}).
+		Where("agent_idle_away_minutes > 0").
+		Distinct().
+		Pluck("organization_id", &orgIDs).Error; err != nil {
+		p.app.Log.Error("Failed to load idle away settings", "error", err)
+		return
+	}
+
+	for _, orgID := range orgIDs {
+		settings, err := p.app.getChatbotSettingsCached(orgID, "")
+		if err != nil || settings == nil || settings.AgentAssignment.IdleAwayMinutes <= 0 {
+			continue
+		}
+		idleAfter := time.Duration(settings.AgentAssignment.IdleAwayMinutes) * time.Minute
+
+		var users []models.User
+		if err := p.app.DB.Where("organization_id = ? AND is_active = ? AND is_available = ?", orgID, true, true).
+			Find(&users).Error; err != nil {
+			p.app.Log.Error("Failed to load available agents for idle away", "error", err, "org_id", orgID)
+			continue
+		}
+
+		for i := range users {
+			user := &users[i]
+			// Agents who haven't connected are left alone
+			last, ok := p.app.agentLastActivity(user.ID)
+			if !ok || now.Sub(last) < idleAfter {
+				continue
+			}
+			returned, err := p.app.setAgentAvailability(user, orgID, false, models.AwayReasonIdle, models.AvailabilitySourceIdle)
+			if err != nil {
+				p.app.Log.Error("Failed to set idle agent away", "error", err, "user_id", user.ID)
+				continue
+			}
+			p.app.Log.Info("Idle agent set away",
+				"user_id", user.ID,
+				"idle_since", last,
+				"transfers_to_queue", returned,
+			)
+		}
+	}
+}
+
+// TrackAgentActivity records the activity of this server's users, over
+// WebSocket and HTTP, in Redis so idle auto-away sees activity on every server
+func (a *App) TrackAgentActivity() {
+	if a.WSHub != nil {
+		a.WSHub.SetActivity(a.recordAgentActivity)
+	}
+}
+
+// TouchAgentActivity is a middleware that counts authenticated requests as
+// activity, so agents working over HTTP (picking up transfers, sending
+// templates) are not set away as idle. API key requests come from
+// integrations rather than the agent and are not counted.
+func (a *App) TouchAgentActivity(r *fastglue.Request) *fastglue.Request {
+	if a.WSHub == nil || len(r.RequestCtx.Request.Header.Peek("X-API-Key")) > 0 {
+		return r
+	}
+	if userID, ok := r.RequestCtx.UserValue("user_id").(uuid.UUID); ok {
+		a.WSHub.Touch(userID)
+	}
+	return r
+}
+
+// recordAgentActivity stores when the user was last active
+func (a *App) recordAgentActivity(userID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := a.Redis.Set(ctx, agentActivityPrefix+userID.String(), time.Now().Unix(), agentActivityTTL).Err(); err != nil {
+		a.Log.Error("Failed to record agent activity", "error", err, "user_id", userID)
+	}
+}
+
+// agentLastActivity returns when the user was last active on any server.
+// ok is false when they haven't been active since activity was tracked.
+func (a *App) agentLastActivity(userID uuid.UUID) (t time.Time, ok bool) {
+	unix, err := a.Redis.Get(context.Background(), agentActivityPrefix+userID.String()).Int64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
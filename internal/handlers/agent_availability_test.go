@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidAwayReason(t *testing.T) {
+	assert.True(t, validAwayReason(models.AwayReasonLunch))
+	assert.True(t, validAwayReason(models.AwayReasonMeeting))
+	assert.False(t, validAwayReason(models.AwayReasonIdle), "set by the system")
+	assert.False(t, validAwayReason(models.AwayReasonOffShift), "set by the system")
+	assert.False(t, validAwayReason("nap"))
+}
+
+func TestValidateShiftSchedule(t *testing.T) {
+	assert.NoError(t, validateShiftSchedule("America/New_York", weekdayHours()))
+	assert.Error(t, validateShiftSchedule("Nowhere/City", nil))
+	assert.Error(t, validateShiftSchedule("", models.JSONBArray{
+		map[string]interface{}{"day": float64(1), "enabled": true, "start_time": "18:00", "end_time": "9am"},
+	}))
+	assert.NoError(t, validateShiftSchedule("", models.JSONBArray{
+		map[string]interface{}{"day": float64(1), "enabled": true, "start_time": "22:00", "end_time": "06:00"},
+	}), "night shift")
+}
+
+func TestShiftTransition(t *testing.T) {
+	cal := newBusinessCalendar(models.BusinessHoursConfig{Hours: weekdayHours()}, time.UTC)
+
+	onShift, changed := shiftTransition(cal, time.Date(2026, 3, 9, 8, 59, 30, 0, time.UTC), time.Date(2026, 3, 9, 9, 0, 30, 0, time.UTC))
+	assert.True(t, changed)
+	assert.True(t, onShift, "shift started")
+
+	onShift, changed = shiftTransition(cal, time.Date(2026, 3, 9, 12, 59, 30, 0, time.UTC), time.Date(2026, 3, 9, 13, 0, 30, 0, time.UTC))
+	assert.True(t, changed)
+	assert.False(t, onShift, "lunch break")
+
+	_, changed = shiftTransition(cal, time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 10, 1, 0, 0, time.UTC))
+	assert.False(t, changed, "mid-shift")
+}
+
+func TestWorkingSpans(t *testing.T) {
+	cal := newBusinessCalendar(models.BusinessHoursConfig{Hours: weekdayHours()}, time.UTC)
+
+	// Monday 10:00 to Tuesday 10:00: 10:00-13:00 and 14:00-18:00 on Monday, 09:00-10:00 on Tuesday
+	spans := cal.workingSpans(time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC), time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC))
+	require.Len(t, spans, 3)
+	assert.Equal(t, float64(8*60), spanMinutes(spans))
+	assert.Equal(t, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC), spans[2].start)
+}
+
+func TestShiftAdherence(t *testing.T) {
+	cal := newBusinessCalendar(models.BusinessHoursConfig{Hours: weekdayHours()}, time.UTC)
+	start := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 9, h, m, 0, 0, time.UTC) }
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	logs := []models.UserAvailabilityLog{
+		// Late by 30 minutes, available through lunch until 15:00
+		{IsAvailable: true, StartedAt: at(9, 30), EndedAt: ptr(at(15, 0))},
+		{IsAvailable: false, StartedAt: at(15, 0), EndedAt: ptr(at(16, 0)), Reason: models.AwayReasonMeeting},
+		// Back until 19:00, an hour past the shift
+		{IsAvailable: true, StartedAt: at(16, 0), EndedAt: ptr(at(19, 0))},
+	}
+
+	adherence := shiftAdherence(cal, logs, start, end, end)
+	require.NotNil(t, adherence)
+	assert.Equal(t, float64(8*60), adherence.ScheduledMins)
+	// 09:30-13:00 (210) + 14:00-15:00 (60) + 16:00-18:00 (120)
+	assert.Equal(t, float64(390), adherence.AvailableOnShift)
+	// Lunch (60) + 18:00-19:00 (60)
+	assert.Equal(t, float64(120), adherence.AvailableOffShift)
+	assert.InDelta(t, 81.25, adherence.AdherencePct, 0.001)
+}
+
+func TestCountsAsBreak(t *testing.T) {
+	assert.True(t, countsAsBreak(models.AwayReasonLunch))
+	assert.True(t, countsAsBreak(""), "reasons recorded before away reasons existed")
+	assert.False(t, countsAsBreak(models.AwayReasonOffShift))
+}
+
+func TestApplyIdleAway_HTTPActivity(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	rdb := testutil.SetupTestRedis(t)
+	if rdb == nil {
+		t.Skip("TEST_REDIS_URL not set, skipping test")
+	}
+	log := testutil.NopLogger()
+	hub := websocket.NewHub(log)
+	go hub.Run()
+	app := &App{DB: db, Log: log, Redis: rdb, WSHub: hub}
+	app.TrackAgentActivity()
+
+	org := testutil.CreateTestOrganization(t, db)
+	require.NoError(t, db.Create(&models.ChatbotSettings{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		AgentAssignment: models.AgentAssignmentConfig{IdleAwayMinutes: 5},
+	}).Error)
+
+	// Both agents were last seen on the WebSocket ten minutes ago
+	working := testutil.CreateTestUser(t, db, org.ID)
+	idle := testutil.CreateTestUser(t, db, org.ID)
+	stale := time.Now().Add(-10 * time.Minute).Unix()
+	for _, user := range []*models.User{working, idle} {
+		require.NoError(t, db.Model(user).Update("is_available", true).Error)
+		require.NoError(t, rdb.Set(context.Background(), agentActivityPrefix+user.ID.String(), stale, time.Hour).Err())
+	}
+
+	// The working agent has since picked up a transfer over HTTP
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, working.ID)
+	require.NotNil(t, app.TouchAgentActivity(req))
+	require.Eventually(t, func() bool {
+		last, ok := app.agentLastActivity(working.ID)
+		return ok && last.Unix() > stale
+	}, time.Second, 10*time.Millisecond)
+
+	p := &AgentAvailabilityProcessor{app: app}
+	p.applyIdleAway(time.Now())
+
+	var got models.User
+	require.NoError(t, db.First(&got, working.ID).Error)
+	assert.True(t, got.IsAvailable, "active over HTTP")
+	require.NoError(t, db.First(&got, idle.ID).Error)
+	assert.False(t, got.IsAvailable)
+}
@@ -17,28 +17,33 @@ import (
 // agentTransferRow represents a flat row result from the JOINed query
 type agentTransferRow struct {
 	// AgentTransfer fields
-	ID                    uuid.UUID             `gorm:"column:id"`
-	OrganizationID        uuid.UUID             `gorm:"column:organization_id"`
-	ContactID             uuid.UUID             `gorm:"column:contact_id"`
-	WhatsAppAccount       string                `gorm:"column:whatsapp_account"`
-	PhoneNumber           string                `gorm:"column:phone_number"`
-	Status                models.TransferStatus `gorm:"column:status"`
-	Source                models.TransferSource `gorm:"column:source"`
-	AgentID               *uuid.UUID `gorm:"column:agent_id"`
-	TeamID                *uuid.UUID `gorm:"column:team_id"`
-	TransferredByUserID   *uuid.UUID `gorm:"column:transferred_by_user_id"`
-	Notes                 string     `gorm:"column:notes"`
-	TransferredAt         time.Time  `gorm:"column:transferred_at"`
-	ResumedAt             *time.Time `gorm:"column:resumed_at"`
-	ResumedBy             *uuid.UUID `gorm:"column:resumed_by"`
-	SLAResponseDeadline   *time.Time `gorm:"column:sla_response_deadline"`
-	SLAResolutionDeadline *time.Time `gorm:"column:sla_resolution_deadline"`
-	SLABreached           bool       `gorm:"column:sla_breached"`
-	SLABreachedAt         *time.Time `gorm:"column:sla_breached_at"`
-	EscalationLevel       int        `gorm:"column:escalation_level"`
-	EscalatedAt           *time.Time `gorm:"column:escalated_at"`
-	PickedUpAt            *time.Time `gorm:"column:picked_up_at"`
-	ExpiresAt             *time.Time `gorm:"column:expires_at"`
+	ID                      uuid.UUID             `gorm:"column:id"`
+	OrganizationID          uuid.UUID             `gorm:"column:organization_id"`
+	ContactID               uuid.UUID             `gorm:"column:contact_id"`
+	WhatsAppAccount         string                `gorm:"column:whatsapp_account"`
+	PhoneNumber             string                `gorm:"column:phone_number"`
+	Status                  models.TransferStatus `gorm:"column:status"`
+	Source                  models.TransferSource `gorm:"column:source"`
+	AgentID                 *uuid.UUID            `gorm:"column:agent_id"`
+	TeamID                  *uuid.UUID            `gorm:"column:team_id"`
+	TransferredByUserID     *uuid.UUID            `gorm:"column:transferred_by_user_id"`
+	Notes                   string                `gorm:"column:notes"`
+	TransferredAt           time.Time             `gorm:"column:transferred_at"`
+	ResumedAt               *time.Time            `gorm:"column:resumed_at"`
+	ResumedBy               *uuid.UUID            `gorm:"column:resumed_by"`
+	SLAResponseDeadline     *time.Time            `gorm:"column:sla_response_deadline"`
+	SLAResolutionDeadline   *time.Time            `gorm:"column:sla_resolution_deadline"`
+	SLABreached             bool                  `gorm:"column:sla_breached"`
+	SLABreachedAt           *time.Time            `gorm:"column:sla_breached_at"`
+	EscalationLevel         int                   `gorm:"column:escalation_level"`
+	EscalatedAt             *time.Time            `gorm:"column:escalated_at"`
+	PickedUpAt              *time.Time            `gorm:"column:picked_up_at"`
+	ExpiresAt               *time.Time            `gorm:"column:expires_at"`
+	SLAPolicyID             *uuid.UUID            `gorm:"column:sla_policy_id"`
+	SLANextResponseDeadline *time.Time            `gorm:"column:sla_next_response_deadline"`
+	Priority                int                   `gorm:"column:priority"`
+	RequiredSkills          models.StringArray    `gorm:"column:required_skills"`
+	RoutingDecision         models.JSONB          `gorm:"column:routing_decision"`
 
 	// Joined fields
 	ContactName       *string `gorm:"column:contact_name"`
@@ -50,12 +55,15 @@ type agentTransferRow struct {
 
 // CreateAgentTransferRequest represents the request to create an agent transfer
 type CreateAgentTransferRequest struct {
-	ContactID       string               `json:"contact_id"`
-	WhatsAppAccount string               `json:"whatsapp_account"`
-	AgentID         *string              `json:"agent_id"`
-	TeamID          *string              `json:"team_id"` // Optional team queue
-	Notes           string               `json:"notes"`
-	Source          models.TransferSource `json:"source"` // manual, flow, keyword
+	ContactID       string                `json:"contact_id"`
+	WhatsAppAccount string                `json:"whatsapp_account"`
+	AgentID         *string               `json:"agent_id"`
+	TeamID          *string               `json:"team_id"` // Optional team queue
+	Notes           string                `json:"notes"`
+	Source          models.TransferSource `json:"source"`   // manual, flow, keyword
+	Priority        int                   `json:"priority"` // Higher priorities are picked first
+	Skills          []string              `json:"skills"`   // Skills the assigned agent must have
+	Language        string                `json:"language"` // Language the assigned agent must speak
 }
 
 // AssignTransferRequest represents the request to assign a transfer to an agent
@@ -66,34 +74,39 @@ type AssignTransferRequest struct {
 
 // AgentTransferResponse represents an agent transfer in API responses
 type AgentTransferResponse struct {
-	ID                string               `json:"id"`
-	ContactID         string               `json:"contact_id"`
-	ContactName       string               `json:"contact_name"`
-	PhoneNumber       string               `json:"phone_number"`
-	WhatsAppAccount   string               `json:"whatsapp_account"`
+	ID                string                `json:"id"`
+	ContactID         string                `json:"contact_id"`
+	ContactName       string                `json:"contact_name"`
+	PhoneNumber       string                `json:"phone_number"`
+	WhatsAppAccount   string                `json:"whatsapp_account"`
 	Status            models.TransferStatus `json:"status"`
 	Source            models.TransferSource `json:"source"`
-	AgentID           *string              `json:"agent_id,omitempty"`
-	AgentName         *string              `json:"agent_name,omitempty"`
-	TeamID            *string              `json:"team_id,omitempty"`
-	TeamName          *string              `json:"team_name,omitempty"`
-	TransferredBy     *string              `json:"transferred_by,omitempty"`
-	TransferredByName *string              `json:"transferred_by_name,omitempty"`
-	Notes             string               `json:"notes"`
-	TransferredAt     string               `json:"transferred_at"`
-	ResumedAt         *string              `json:"resumed_at,omitempty"`
-	ResumedBy         *string              `json:"resumed_by,omitempty"`
-	ResumedByName     *string              `json:"resumed_by_name,omitempty"`
+	AgentID           *string               `json:"agent_id,omitempty"`
+	AgentName         *string               `json:"agent_name,omitempty"`
+	TeamID            *string               `json:"team_id,omitempty"`
+	TeamName          *string               `json:"team_name,omitempty"`
+	TransferredBy     *string               `json:"transferred_by,omitempty"`
+	TransferredByName *string               `json:"transferred_by_name,omitempty"`
+	Notes             string                `json:"notes"`
+	TransferredAt     string                `json:"transferred_at"`
+	ResumedAt         *string               `json:"resumed_at,omitempty"`
+	ResumedBy         *string               `json:"resumed_by,omitempty"`
+	ResumedByName     *string               `json:"resumed_by_name,omitempty"`
+	Priority          int                   `json:"priority"`
+	RequiredSkills    []string              `json:"required_skills"`
+	RoutingDecision   models.JSONB          `json:"routing_decision,omitempty"`
 
 	// SLA fields
-	SLAResponseDeadline   *string `json:"sla_response_deadline,omitempty"`
-	SLAResolutionDeadline *string `json:"sla_resolution_deadline,omitempty"`
-	SLABreached           bool    `json:"sla_breached"`
-	SLABreachedAt         *string `json:"sla_breached_at,omitempty"`
-	EscalationLevel       int     `json:"escalation_level"`
-	EscalatedAt           *string `json:"escalated_at,omitempty"`
-	PickedUpAt            *string `json:"picked_up_at,omitempty"`
-	ExpiresAt             *string `json:"expires_at,omitempty"`
+	SLAResponseDeadline     *string `json:"sla_response_deadline,omitempty"`
+	SLAResolutionDeadline   *string `json:"sla_resolution_deadline,omitempty"`
+	SLABreached             bool    `json:"sla_breached"`
+	SLABreachedAt           *string `json:"sla_breached_at,omitempty"`
+	EscalationLevel         int     `json:"escalation_level"`
+	EscalatedAt             *string `json:"escalated_at,omitempty"`
+	PickedUpAt              *string `json:"picked_up_at,omitempty"`
+	ExpiresAt               *string `json:"expires_at,omitempty"`
+	SLAPolicyID             *string `json:"sla_policy_id,omitempty"`
+	SLANextResponseDeadline *string `json:"sla_next_response_deadline,omitempty"`
 }
 
 // ListAgentTransfers lists agent transfers for the organization
@@ -104,8 +117,9 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
 	}
 
-	// Check permissions - users with write permission have full access (like admin)
-	hasFullAccess := a.HasPermission(userID, models.ResourceTransfers, models.ActionWrite, orgID)
+	// Users with chat.all:read see all transfers, others only those in their scope
+	scope := a.conversationScope(userID, orgID)
+	hasFullAccess := scope.All
 
 	// Query params
 	status := string(r.RequestCtx.QueryArgs().Peek("status"))
@@ -158,7 +172,7 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 	query := a.DB.Table("agent_transfers").
 		Select(strings.Join(selectCols, ", ")).
 		Where("agent_transfers.organization_id = ?", orgID).
-		Order("agent_transfers.transferred_at ASC") // FIFO
+		Order("agent_transfers.priority DESC, agent_transfers.transferred_at ASC") // Priority, then FIFO
 
 	// Only add JOINs for requested relations (lazy loading)
 	if includeAll || includeSet["contact"] {
@@ -194,27 +208,14 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 		}
 	}
 
-	// Get user's team memberships for filtering (needed for users without full access)
-	var userTeamIDs []uuid.UUID
-	if !hasFullAccess {
-		var memberships []models.TeamMember
-		if err := a.DB.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
-			a.Log.Error("Failed to fetch team memberships", "error", err, "user_id", userID)
-		}
-		for _, m := range memberships {
-			userTeamIDs = append(userTeamIDs, m.TeamID)
-		}
-	}
-
-	// Filter based on permissions
+	// Users without full access see their assigned transfers + unassigned in their team queues + general queue.
+	// Team managers also see all transfers of their teams and team members.
+	userTeamIDs := scope.TeamIDs
+	var scopeCond string
+	var scopeArgs []any
 	if !hasFullAccess {
-		// Users without full access see their assigned transfers + unassigned in their team queues + general queue
-		if len(userTeamIDs) > 0 {
-			query = query.Where("agent_transfers.agent_id = ? OR (agent_transfers.agent_id IS NULL AND (agent_transfers.team_id IS NULL OR agent_transfers.team_id IN ?))", userID, userTeamIDs)
-		} else {
-			// User not in any team - see own transfers + general queue only
-			query = query.Where("agent_transfers.agent_id = ? OR (agent_transfers.agent_id IS NULL AND agent_transfers.team_id IS NULL)", userID)
-		}
+		scopeCond, scopeArgs = scope.transferCondition("agent_transfers")
+		query = query.Where(scopeCond, scopeArgs...)
 	}
 	// Users with full access see all transfers (no filter applied)
 
@@ -232,11 +233,7 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 		}
 	}
 	if !hasFullAccess {
-		if len(userTeamIDs) > 0 {
-			countQuery = countQuery.Where("agent_transfers.agent_id = ? OR (agent_transfers.agent_id IS NULL AND (agent_transfers.team_id IS NULL OR agent_transfers.team_id IN ?))", userID, userTeamIDs)
-		} else {
-			countQuery = countQuery.Where("agent_transfers.agent_id = ? OR (agent_transfers.agent_id IS NULL AND agent_transfers.team_id IS NULL)", userID)
-		}
+		countQuery = countQuery.Where(scopeCond, scopeArgs...)
 	}
 	countQuery.Count(&totalCount)
 
@@ -304,6 +301,9 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 			Source:          t.Source,
 			Notes:           t.Notes,
 			TransferredAt:   t.TransferredAt.Format(time.RFC3339),
+			Priority:        t.Priority,
+			RequiredSkills:  t.RequiredSkills,
+			RoutingDecision: t.RoutingDecision,
 		}
 
 		if t.ContactName != nil {
@@ -346,6 +346,14 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 		// SLA fields
 		resp.SLABreached = t.SLABreached
 		resp.EscalationLevel = t.EscalationLevel
+		if t.SLAPolicyID != nil {
+			policyID := t.SLAPolicyID.String()
+			resp.SLAPolicyID = &policyID
+		}
+		if t.SLANextResponseDeadline != nil {
+			deadline := t.SLANextResponseDeadline.Format(time.RFC3339)
+			resp.SLANextResponseDeadline = &deadline
+		}
 		if t.SLAResponseDeadline != nil {
 			deadline := t.SLAResponseDeadline.Format(time.RFC3339)
 			resp.SLAResponseDeadline = &deadline
@@ -439,8 +447,11 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 		teamID = &parsedTeamID
 	}
 
+	opts := transferOptions{Priority: req.Priority, Skills: normalizeSkills(req.Skills), Language: req.Language}
+
 	// Determine agent assignment
 	var agentID *uuid.UUID
+	var decision *routingDecision
 
 	// First, try explicit agent from request
 	if req.AgentID != nil && *req.AgentID != "" {
@@ -458,12 +469,15 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 		}
 		agentID = &parsedAgentID
 	} else if teamID != nil {
-		// Apply team's assignment strategy
-		agentID = a.assignToTeam(*teamID, orgID)
+		// Route within the team (and its overflow teams)
+		routedAgentID, routedTeamID, d := a.routeTransfer(*teamID, orgID, newRoutingRequirements(opts, contact))
+		agentID = routedAgentID
+		teamID = &routedTeamID
+		decision = &d
 	} else if settings != nil && settings.AgentAssignment.AssignToSameAgent && contact.AssignedUserID != nil {
 		// Auto-assign to contact's existing assigned agent (if setting enabled and agent is available)
 		var assignedAgent models.User
-		if a.DB.Where("id = ?", contact.AssignedUserID).First(&assignedAgent).Error == nil && assignedAgent.IsAvailable {
+		if a.DB.Where("id = ?", contact.AssignedUserID).First(&assignedAgent).Error == nil && assignedAgent.IsAvailable && a.agentHasCapacity(orgID, &assignedAgent) {
 			agentID = contact.AssignedUserID
 		}
 		// If agent is not available, falls through to queue (agentID remains nil)
@@ -490,6 +504,11 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 		TransferredByUserID: &userID,
 		Notes:               req.Notes,
 		TransferredAt:       time.Now(),
+		Priority:            opts.Priority,
+		RequiredSkills:      opts.Skills,
+	}
+	if decision != nil {
+		transfer.RoutingDecision = decision.toJSONB()
 	}
 
 	// Set SLA deadlines if SLA is enabled
@@ -564,6 +583,9 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 		Source:          transfer.Source,
 		Notes:           transfer.Notes,
 		TransferredAt:   transfer.TransferredAt.Format(time.RFC3339),
+		Priority:        transfer.Priority,
+		RequiredSkills:  transfer.RequiredSkills,
+		RoutingDecision: transfer.RoutingDecision,
 	}
 
 	if transfer.AgentID != nil {
@@ -593,6 +615,14 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 	// SLA fields
 	resp.SLABreached = transfer.SLA.Breached
 	resp.EscalationLevel = transfer.SLA.EscalationLevel
+	if transfer.SLA.PolicyID != nil {
+		policyID := transfer.SLA.PolicyID.String()
+		resp.SLAPolicyID = &policyID
+	}
+	if transfer.SLA.NextResponseDeadline != nil {
+		deadline := transfer.SLA.NextResponseDeadline.Format(time.RFC3339)
+		resp.SLANextResponseDeadline = &deadline
+	}
 	if transfer.SLA.ResponseDeadline != nil {
 		deadline := transfer.SLA.ResponseDeadline.Format(time.RFC3339)
 		resp.SLAResponseDeadline = &deadline
@@ -660,6 +690,9 @@ func (a *App) ResumeFromTransfer(r *fastglue.Request) error {
 			Update("assigned_user_id", nil)
 	}
 
+	// Ask the customer how it went, if CSAT surveys are enabled
+	a.scheduleCSATSurvey(transfer)
+
 	// Broadcast WebSocket notification
 	a.broadcastTransferResumed(transfer)
 
@@ -846,6 +879,12 @@ func (a *App) PickNextTransfer(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "You don't have permission to pick up transfers", nil, "")
 	}
 
+	// Agents at their concurrent chat limit can't pick more
+	var picker models.User
+	if err := a.DB.Where("id = ?", userID).First(&picker).Error; err == nil && !a.agentHasCapacity(orgID, &picker) {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "You have reached your maximum concurrent chats", nil, "")
+	}
+
 	// Get optional team filter
 	teamIDStr := string(r.RequestCtx.QueryArgs().Peek("team_id"))
 
@@ -870,7 +909,7 @@ func (a *App) PickNextTransfer(r *fastglue.Request) error {
 	// Build query for picking transfer with row-level locking
 	query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
 		Where("organization_id = ? AND status = ? AND agent_id IS NULL", orgID, models.TransferStatusActive).
-		Order("transferred_at ASC")
+		Order("priority DESC, transferred_at ASC")
 
 	if teamIDStr != "" {
 		// Pick from specific team
@@ -906,7 +945,7 @@ func (a *App) PickNextTransfer(r *fastglue.Request) error {
 	}
 	// Users with full access can pick from any queue if no team_id specified
 
-	// Find oldest unassigned active transfer (FIFO) - locked row
+	// Find the highest priority, oldest unassigned active transfer - locked row
 	var transfer models.AgentTransfer
 	result := query.First(&transfer)
 
@@ -973,6 +1012,9 @@ func (a *App) PickNextTransfer(r *fastglue.Request) error {
 		Source:          transfer.Source,
 		Notes:           transfer.Notes,
 		TransferredAt:   transfer.TransferredAt.Format(time.RFC3339),
+		Priority:        transfer.Priority,
+		RequiredSkills:  transfer.RequiredSkills,
+		RoutingDecision: transfer.RoutingDecision,
 	}
 
 	if transfer.Contact != nil {
@@ -1005,6 +1047,14 @@ func (a *App) PickNextTransfer(r *fastglue.Request) error {
 	// SLA fields
 	resp.SLABreached = transfer.SLA.Breached
 	resp.EscalationLevel = transfer.SLA.EscalationLevel
+	if transfer.SLA.PolicyID != nil {
+		policyID := transfer.SLA.PolicyID.String()
+		resp.SLAPolicyID = &policyID
+	}
+	if transfer.SLA.NextResponseDeadline != nil {
+		deadline := transfer.SLA.NextResponseDeadline.Format(time.RFC3339)
+		resp.SLANextResponseDeadline = &deadline
+	}
 	if transfer.SLA.ResponseDeadline != nil {
 		deadline := transfer.SLA.ResponseDeadline.Format(time.RFC3339)
 		resp.SLAResponseDeadline = &deadline
@@ -1068,7 +1118,7 @@ func (a *App) broadcastTransferCreated(transfer *models.AgentTransfer, contact *
 		payload["team_id"] = transfer.TeamID.String()
 	}
 
-	a.WSHub.BroadcastToOrg(transfer.OrganizationID, websocket.WSMessage{
+	a.WSHub.BroadcastToConversation(transfer.OrganizationID, transfer.ContactID, websocket.WSMessage{
 		Type:    websocket.TypeAgentTransfer,
 		Payload: payload,
 	})
@@ -1092,7 +1142,7 @@ func (a *App) broadcastTransferResumed(transfer *models.AgentTransfer) {
 		payload["resumed_by"] = transfer.ResumedBy.String()
 	}
 
-	a.WSHub.BroadcastToOrg(transfer.OrganizationID, websocket.WSMessage{
+	a.WSHub.BroadcastToTransfer(transfer.OrganizationID, transfer.ID, websocket.WSMessage{
 		Type:    websocket.TypeAgentTransferResume,
 		Payload: payload,
 	})
@@ -1121,14 +1171,14 @@ func (a *App) broadcastTransferAssigned(transfer *models.AgentTransfer) {
 		payload["team_id"] = nil
 	}
 
-	a.WSHub.BroadcastToOrg(transfer.OrganizationID, websocket.WSMessage{
+	a.WSHub.BroadcastToTransfer(transfer.OrganizationID, transfer.ID, websocket.WSMessage{
 		Type:    websocket.TypeAgentTransferAssign,
 		Payload: payload,
 	})
 }
 
 // createTransferToQueue creates an unassigned agent transfer that goes to the queue
-func (a *App) createTransferToQueue(account *models.WhatsAppAccount, contact *models.Contact, source models.TransferSource) {
+func (a *App) createTransferToQueue(account *models.WhatsAppAccount, contact *models.Contact, source models.TransferSource, opts transferOptions) {
 	// Check for existing active transfer
 	var existingCount int64
 	a.DB.Model(&models.AgentTransfer{}).
@@ -1154,6 +1204,8 @@ func (a *App) createTransferToQueue(account *models.WhatsAppAccount, contact *mo
 		Source:          source,
 		AgentID:         nil, // Unassigned - goes to queue
 		TransferredAt:   time.Now(),
+		Priority:        opts.Priority,
+		RequiredSkills:  opts.Skills,
 	}
 
 	// Set SLA deadlines
@@ -1189,14 +1241,12 @@ func (a *App) createTransferFromKeyword(account *models.WhatsAppAccount, contact
 	settings, _ := a.getChatbotSettingsCached(account.OrganizationID, account.Name)
 
 	// Check business hours - if outside hours, send out of hours message instead of transfer
-	if settings != nil && settings.BusinessHours.Enabled && len(settings.BusinessHours.Hours) > 0 {
-		if !a.isWithinBusinessHours(settings.BusinessHours.Hours) {
-			a.Log.Info("Outside business hours, sending out of hours message instead of transfer", "contact_id", contact.ID)
-			if settings.BusinessHours.OutOfHoursMessage != "" {
-				_ = a.sendAndSaveTextMessage(account, contact, settings.BusinessHours.OutOfHoursMessage)
-			}
-			return
+	if closed, message := a.outsideBusinessHours(settings); closed {
+		a.Log.Info("Outside business hours, sending out of hours message instead of transfer", "contact_id", contact.ID)
+		if message != "" {
+			_ = a.sendAndSaveTextMessage(account, contact, message)
 		}
+		return
 	}
 
 	// Determine agent assignment
@@ -1204,7 +1254,7 @@ func (a *App) createTransferFromKeyword(account *models.WhatsAppAccount, contact
 	if settings != nil && settings.AgentAssignment.AssignToSameAgent && contact.AssignedUserID != nil {
 		// Check if the assigned agent is available
 		var assignedAgent models.User
-		if a.DB.Where("id = ?", contact.AssignedUserID).First(&assignedAgent).Error == nil && assignedAgent.IsAvailable {
+		if a.DB.Where("id = ?", contact.AssignedUserID).First(&assignedAgent).Error == nil && assignedAgent.IsAvailable && a.agentHasCapacity(account.OrganizationID, &assignedAgent) {
 			agentID = contact.AssignedUserID
 		}
 		// If agent is not available, falls through to queue (agentID remains nil)
@@ -1265,118 +1315,8 @@ func (a *App) createTransferFromKeyword(account *models.WhatsAppAccount, contact
 	a.broadcastTransferCreated(&transfer, contact)
 }
 
-// assignToTeam applies the team's assignment strategy to select an agent
-// Returns nil if manual strategy or no available agents
-func (a *App) assignToTeam(teamID uuid.UUID, orgID uuid.UUID) *uuid.UUID {
-	// Get team and its assignment strategy
-	var team models.Team
-	if err := a.DB.Where("id = ? AND organization_id = ? AND is_active = ?", teamID, orgID, true).First(&team).Error; err != nil {
-		a.Log.Error("Failed to get team for assignment", "error", err, "team_id", teamID)
-		return nil
-	}
-
-	switch team.AssignmentStrategy {
-	case models.AssignmentStrategyRoundRobin:
-		return a.assignToTeamRoundRobin(teamID, orgID)
-	case models.AssignmentStrategyLoadBalanced:
-		return a.assignToTeamLoadBalanced(teamID, orgID)
-	case models.AssignmentStrategyManual:
-		// Manual means no auto-assignment
-		return nil
-	default:
-		// Default to round-robin
-		return a.assignToTeamRoundRobin(teamID, orgID)
-	}
-}
-
-// assignToTeamRoundRobin selects the next agent using round-robin
-func (a *App) assignToTeamRoundRobin(teamID uuid.UUID, orgID uuid.UUID) *uuid.UUID {
-	// Get team members who are available agents, ordered by last assigned time
-	var members []models.TeamMember
-	err := a.DB.
-		Joins("JOIN users ON users.id = team_members.user_id").
-		Where("team_members.team_id = ? AND team_members.role = ? AND users.is_available = ? AND users.is_active = ?",
-			teamID, models.TeamRoleAgent, true, true).
-		Order("team_members.last_assigned_at ASC NULLS FIRST").
-		Find(&members).Error
-
-	if err != nil || len(members) == 0 {
-		a.Log.Debug("No available agents in team for round-robin", "team_id", teamID)
-		return nil
-	}
-
-	// Pick the first agent (least recently assigned)
-	selectedMember := members[0]
-
-	// Update last_assigned_at
-	now := time.Now()
-	a.DB.Model(&selectedMember).Update("last_assigned_at", now)
-
-	a.Log.Debug("Round-robin assigned to agent", "team_id", teamID, "user_id", selectedMember.UserID)
-	return &selectedMember.UserID
-}
-
-// assignToTeamLoadBalanced selects the agent with fewest active transfers
-func (a *App) assignToTeamLoadBalanced(teamID uuid.UUID, orgID uuid.UUID) *uuid.UUID {
-	// Get team members who are available agents
-	var members []models.TeamMember
-	err := a.DB.
-		Joins("JOIN users ON users.id = team_members.user_id").
-		Where("team_members.team_id = ? AND team_members.role = ? AND users.is_available = ? AND users.is_active = ?",
-			teamID, models.TeamRoleAgent, true, true).
-		Find(&members).Error
-
-	if err != nil || len(members) == 0 {
-		a.Log.Debug("No available agents in team for load-balanced", "team_id", teamID)
-		return nil
-	}
-
-	// Extract member user IDs
-	memberIDs := make([]uuid.UUID, len(members))
-	for i, m := range members {
-		memberIDs[i] = m.UserID
-	}
-
-	// Count active transfers for all members in a single query (optimized from N+1)
-	type AgentLoad struct {
-		AgentID uuid.UUID `gorm:"column:agent_id"`
-		Count   int64     `gorm:"column:count"`
-	}
-	var loads []AgentLoad
-	a.DB.Model(&models.AgentTransfer{}).
-		Select("agent_id, COUNT(*) as count").
-		Where("organization_id = ? AND agent_id IN ? AND status = ?", orgID, memberIDs, models.TransferStatusActive).
-		Group("agent_id").
-		Scan(&loads)
-
-	// Build a map of agent loads
-	loadMap := make(map[uuid.UUID]int64)
-	for _, l := range loads {
-		loadMap[l.AgentID] = l.Count
-	}
-
-	// Find agent with lowest load (agents with 0 transfers won't be in loadMap)
-	var lowestUserID *uuid.UUID
-	var lowestCount int64 = -1
-	for _, m := range members {
-		count := loadMap[m.UserID] // Will be 0 if not in map (no active transfers)
-		if lowestCount < 0 || count < lowestCount {
-			lowestCount = count
-			userID := m.UserID
-			lowestUserID = &userID
-		}
-	}
-
-	if lowestUserID == nil {
-		return nil
-	}
-
-	a.Log.Debug("Load-balanced assigned to agent", "team_id", teamID, "user_id", *lowestUserID, "current_load", lowestCount)
-	return lowestUserID
-}
-
 // createTransferToTeam creates an agent transfer to a specific team with appropriate assignment
-func (a *App) createTransferToTeam(account *models.WhatsAppAccount, contact *models.Contact, teamID uuid.UUID, notes string, source models.TransferSource) {
+func (a *App) createTransferToTeam(account *models.WhatsAppAccount, contact *models.Contact, teamID uuid.UUID, notes string, source models.TransferSource, opts transferOptions) {
 	// Check for existing active transfer
 	var existingCount int64
 	a.DB.Model(&models.AgentTransfer{}).
@@ -1391,8 +1331,8 @@ func (a *App) createTransferToTeam(account *models.WhatsAppAccount, contact *mod
 	// Get chatbot settings for SLA (use cache)
 	settings, _ := a.getChatbotSettingsCached(account.OrganizationID, account.Name)
 
-	// Apply team's assignment strategy
-	agentID := a.assignToTeam(teamID, account.OrganizationID)
+	// Route within the team (and its overflow teams)
+	agentID, teamID, decision := a.routeTransfer(teamID, account.OrganizationID, newRoutingRequirements(opts, contact))
 
 	// Create transfer
 	transfer := models.AgentTransfer{
@@ -1407,6 +1347,9 @@ func (a *App) createTransferToTeam(account *models.WhatsAppAccount, contact *mod
 		TeamID:          &teamID,
 		Notes:           notes,
 		TransferredAt:   time.Now(),
+		Priority:        opts.Priority,
+		RequiredSkills:  opts.Skills,
+		RoutingDecision: decision.toJSONB(),
 	}
 
 	// Set SLA deadlines
@@ -1447,6 +1390,7 @@ func (a *App) createTransferToTeam(account *models.WhatsAppAccount, contact *mod
 		"team_id", teamID,
 		"agent_id", agentIDStrLog,
 		"source", source,
+		"routing", decision.Reason,
 	)
 
 	// Broadcast to WebSocket
@@ -627,3 +627,47 @@ func TestApp_GetAgentComparison_EmptyAgents(t *testing.T) {
 
 	assert.Empty(t, resp.Data.Agents)
 }
+
+func TestApp_GetReferralAnalytics(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+
+	newContact := testutil.CreateTestContact(t, app.DB, org.ID)
+	returning := testutil.CreateTestContact(t, app.DB, org.ID)
+	for _, ref := range []models.ContactReferral{
+		{ContactID: newContact.ID, SourceType: "ad", SourceID: "ad-1", Headline: "Sale", IsNewContact: true},
+		{ContactID: newContact.ID, SourceType: "ad", SourceID: "ad-1", Headline: "Sale"},
+		{ContactID: returning.ID, SourceType: "ad", SourceID: "ad-1", Headline: "Sale"},
+		{ContactID: returning.ID, SourceType: "post", SourceID: "post-1"},
+	} {
+		ref.OrganizationID = org.ID
+		ref.WhatsAppAccount = "test-account"
+		require.NoError(t, app.DB.Create(&ref).Error)
+	}
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.GetReferralAnalytics(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			Referrals   int64                         `json:"referrals"`
+			Contacts    int64                         `json:"contacts"`
+			NewContacts int64                         `json:"new_contacts"`
+			Sources     []handlers.ReferralSourceStat `json:"sources"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, int64(4), resp.Data.Referrals)
+	assert.Equal(t, int64(2), resp.Data.Contacts)
+	assert.Equal(t, int64(1), resp.Data.NewContacts)
+	require.Len(t, resp.Data.Sources, 2)
+	assert.Equal(t, "ad-1", resp.Data.Sources[0].SourceID)
+	assert.Equal(t, "Sale", resp.Data.Sources[0].Headline)
+	assert.Equal(t, int64(3), resp.Data.Sources[0].Referrals)
+	assert.Equal(t, int64(2), resp.Data.Sources[0].Contacts)
+	assert.Equal(t, int64(1), resp.Data.Sources[0].NewContacts)
+}
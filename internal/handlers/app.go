@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
@@ -28,10 +29,14 @@ type App struct {
 	WSHub             *websocket.Hub
 	Queue             queue.Queue
 	CampaignSubCancel context.CancelFunc
+	// BroadcastSubCancel stops delivering WebSocket broadcasts relayed by workers
+	BroadcastSubCancel context.CancelFunc
 	// HTTPClient is a shared HTTP client with connection pooling for external API calls
 	HTTPClient *http.Client
 	// wg tracks background goroutines for graceful shutdown
 	wg sync.WaitGroup
+	// flowSim is set on the copy of App used to dry-run a chatbot flow
+	flowSim *flowSimulation
 }
 
 // WaitForBackgroundTasks blocks until all background goroutines complete.
@@ -169,6 +174,46 @@ func (a *App) StopCampaignStatsSubscriber() {
 	}
 }
 
+// StartBroadcastSubscriber delivers WebSocket broadcasts relayed over Redis
+// pub/sub by processes without WebSocket clients, such as workers running
+// the background processors
+func (a *App) StartBroadcastSubscriber() error {
+	if a.WSHub == nil {
+		a.Log.Warn("WebSocket hub not initialized, skipping broadcast subscriber")
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.BroadcastSubCancel = cancel
+
+	subscriber := queue.NewSubscriber(a.Redis, a.Log)
+	if err := subscriber.SubscribeBroadcasts(ctx, a.WSHub.Broadcast); err != nil {
+		cancel()
+		return err
+	}
+
+	a.Log.Info("Broadcast subscriber started")
+	return nil
+}
+
+// StopBroadcastSubscriber stops the broadcast subscriber
+func (a *App) StopBroadcastSubscriber() {
+	if a.BroadcastSubCancel != nil {
+		a.BroadcastSubCancel()
+	}
+}
+
+// RelayBroadcasts publishes this process's WebSocket broadcasts over Redis
+// pub/sub for the servers to deliver to their clients
+func (a *App) RelayBroadcasts() {
+	publisher := queue.NewPublisher(a.Redis, a.Log)
+	a.WSHub.SetRelay(func(msg websocket.BroadcastMessage) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = publisher.PublishBroadcast(ctx, msg)
+	})
+}
+
 // getOrgAndUserID extracts both organization ID and user ID from the request context.
 // Returns an error if either is missing or invalid.
 func (a *App) getOrgAndUserID(r *fastglue.Request) (orgID, userID uuid.UUID, err error) {
@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// holidayDateLayout is the date format of business hours holidays
+const holidayDateLayout = "2006-01-02"
+
+// maxCalendarSearchDays bounds how far ahead working time is searched for
+const maxCalendarSearchDays = 400
+
+// minutesPerDay is the number of minutes in a calendar day
+const minutesPerDay = 24 * 60
+
+// workInterval is a span of working time within a day, in minutes since
+// midnight. The end minute is included, matching the "HH:MM" end_time.
+// Overnight shifts end past minutesPerDay, in the next day's minutes.
+type workInterval struct {
+	start int
+	end   int
+}
+
+// businessHoliday is a dated closure or special-hours exception
+type businessHoliday struct {
+	message   string
+	from      string // YYYY-MM-DD, inclusive
+	to        string // YYYY-MM-DD, inclusive
+	intervals []workInterval
+}
+
+// businessCalendar evaluates business hours and holidays in a timezone
+type businessCalendar struct {
+	loc      *time.Location
+	week     [7][]workInterval // indexed by time.Weekday; empty = closed
+	holidays []businessHoliday
+}
+
+// parseClock parses an "HH:MM" time into minutes since midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseWorkIntervals reads intervals from a business hours entry: either its
+// intervals array (split shifts) or its start_time/end_time pair
+func parseWorkIntervals(entry map[string]interface{}) ([]workInterval, error) {
+	var raw []map[string]interface{}
+	if list, ok := entry["intervals"].([]interface{}); ok && len(list) > 0 {
+		for _, item := range list {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("intervals must be objects with start_time and end_time")
+			}
+			raw = append(raw, m)
+		}
+	} else if _, ok := entry["start_time"]; ok {
+		raw = append(raw, entry)
+	}
+
+	intervals := make([]workInterval, 0, len(raw))
+	for _, m := range raw {
+		startTime, _ := m["start_time"].(string)
+		endTime, _ := m["end_time"].(string)
+		start, err := parseClock(startTime)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(endTime)
+		if err != nil {
+			return nil, err
+		}
+		if end < start {
+			end += minutesPerDay // Overnight shift, e.g. 22:00-06:00
+		}
+		intervals = append(intervals, workInterval{start: start, end: end})
+	}
+	return intervals, nil
+}
+
+// parseBusinessHoliday reads a holidays entry
+func parseBusinessHoliday(entry map[string]interface{}) (businessHoliday, error) {
+	h := businessHoliday{}
+	h.message, _ = entry["message"].(string)
+	h.from, _ = entry["date"].(string)
+	h.to, _ = entry["end_date"].(string)
+	if _, err := time.Parse(holidayDateLayout, h.from); err != nil {
+		return h, fmt.Errorf("holiday date %q must be YYYY-MM-DD", h.from)
+	}
+	if h.to == "" {
+		h.to = h.from
+	} else if _, err := time.Parse(holidayDateLayout, h.to); err != nil || h.to < h.from {
+		return h, fmt.Errorf("holiday end_date %q must be a YYYY-MM-DD date on or after date", h.to)
+	}
+	intervals, err := parseWorkIntervals(entry)
+	if err != nil {
+		return h, err
+	}
+	h.intervals = intervals
+	return h, nil
+}
+
+// validateBusinessHours checks the hours and holidays of a business hours config
+func validateBusinessHours(cfg models.BusinessHoursConfig) error {
+	if cfg.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", cfg.Timezone)
+		}
+	}
+	for _, bh := range cfg.Hours {
+		entry, ok := bh.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("business hours entries must be objects")
+		}
+		if day, ok := entry["day"].(float64); !ok || day < 0 || day > 6 {
+			return fmt.Errorf("business hours day must be 0 (Sunday) to 6 (Saturday)")
+		}
+		if enabled, _ := entry["enabled"].(bool); !enabled {
+			continue
+		}
+		if _, err := parseWorkIntervals(entry); err != nil {
+			return err
+		}
+	}
+	for _, entry := range cfg.Holidays {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("holidays must be objects")
+		}
+		if _, err := parseBusinessHoliday(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newBusinessCalendar builds a calendar from a business hours config.
+// Malformed entries are skipped (they are rejected when settings are saved).
+func newBusinessCalendar(cfg models.BusinessHoursConfig, loc *time.Location) *businessCalendar {
+	cal := &businessCalendar{loc: loc}
+	for _, bh := range cfg.Hours {
+		entry, ok := bh.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		day, ok := entry["day"].(float64)
+		if !ok || day < 0 || day > 6 {
+			continue
+		}
+		if enabled, _ := entry["enabled"].(bool); !enabled {
+			continue
+		}
+		intervals, err := parseWorkIntervals(entry)
+		if err != nil {
+			continue
+		}
+		cal.week[int(day)] = append(cal.week[int(day)], intervals...)
+	}
+	for _, entry := range cfg.Holidays {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if h, err := parseBusinessHoliday(m); err == nil {
+			h.intervals = mergeWorkIntervals(h.intervals)
+			cal.holidays = append(cal.holidays, h)
+		}
+	}
+	for day := range cal.week {
+		cal.week[day] = mergeWorkIntervals(cal.week[day])
+	}
+	return cal
+}
+
+// mergeWorkIntervals sorts intervals and merges overlapping or adjacent ones
+func mergeWorkIntervals(intervals []workInterval) []workInterval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+	merged := make([]workInterval, 0, len(intervals))
+	for _, iv := range intervals {
+		if n := len(merged); n > 0 && iv.start <= merged[n-1].end+1 {
+			if iv.end > merged[n-1].end {
+				merged[n-1].end = iv.end
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+// holidayOn returns the holiday covering the calendar date of t, if any
+func (c *businessCalendar) holidayOn(t time.Time) *businessHoliday {
+	date := t.In(c.loc).Format(holidayDateLayout)
+	for i := range c.holidays {
+		if date >= c.holidays[i].from && date <= c.holidays[i].to {
+			return &c.holidays[i]
+		}
+	}
+	return nil
+}
+
+// shiftsOn returns the intervals that start on the calendar date of t
+func (c *businessCalendar) shiftsOn(t time.Time) []workInterval {
+	if h := c.holidayOn(t); h != nil {
+		return h.intervals
+	}
+	return c.week[t.In(c.loc).Weekday()]
+}
+
+// intervalsOn returns the working intervals of the calendar date of t,
+// including the hours after midnight of the previous date's overnight shifts
+func (c *businessCalendar) intervalsOn(t time.Time) []workInterval {
+	local := t.In(c.loc)
+	previous := time.Date(local.Year(), local.Month(), local.Day()-1, 12, 0, 0, 0, c.loc)
+
+	var intervals []workInterval
+	for _, iv := range c.shiftsOn(previous) {
+		if iv.end >= minutesPerDay {
+			intervals = append(intervals, workInterval{start: 0, end: iv.end - minutesPerDay})
+		}
+	}
+	for _, iv := range c.shiftsOn(local) {
+		intervals = append(intervals, workInterval{start: iv.start, end: min(iv.end, minutesPerDay-1)})
+	}
+	return mergeWorkIntervals(intervals)
+}
+
+// isOpen reports whether t falls within working hours
+func (c *businessCalendar) isOpen(t time.Time) bool {
+	local := t.In(c.loc)
+	minute := local.Hour()*60 + local.Minute()
+	for _, iv := range c.intervalsOn(local) {
+		if minute >= iv.start && minute <= iv.end {
+			return true
+		}
+	}
+	return false
+}
+
+// closedMessage returns the message to send when closed at t: the holiday's
+// own message, or fallback
+func (c *businessCalendar) closedMessage(t time.Time, fallback string) string {
+	if h := c.holidayOn(t); h != nil && h.message != "" {
+		return h.message
+	}
+	return fallback
+}
+
+// addWorkingTime returns the time at which d of working time has elapsed
+// after start, so that the clock pauses outside working hours. Calendars
+// without any working time fall back to wall-clock time.
+func (c *businessCalendar) addWorkingTime(start time.Time, d time.Duration) time.Time {
+	remaining := d
+	local := start.In(c.loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.loc)
+
+	for i := 0; i < maxCalendarSearchDays; i++ {
+		for _, iv := range c.intervalsOn(day) {
+			ivStart := time.Date(day.Year(), day.Month(), day.Day(), iv.start/60, iv.start%60, 0, 0, c.loc)
+			ivEnd := time.Date(day.Year(), day.Month(), day.Day(), iv.end/60, iv.end%60, 0, 0, c.loc).Add(time.Minute)
+			if !ivEnd.After(local) {
+				continue
+			}
+			if ivStart.Before(local) {
+				ivStart = local
+			}
+			available := ivEnd.Sub(ivStart)
+			if available >= remaining {
+				return ivStart.Add(remaining)
+			}
+			remaining -= available
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, c.loc)
+	}
+	return start.Add(d)
+}
+
+// organizationTimezone returns the organization's configured timezone, or UTC
+func (a *App) organizationTimezone(orgID uuid.UUID) *time.Location {
+	var org models.Organization
+	if err := a.DB.Select("settings").Where("id = ?", orgID).First(&org).Error; err != nil {
+		return time.UTC
+	}
+	name, _ := org.Settings["timezone"].(string)
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		a.Log.Warn("Invalid organization timezone, using UTC", "timezone", name, "org_id", orgID)
+		return time.UTC
+	}
+	return loc
+}
+
+// businessCalendar returns the business hours calendar of the settings, in
+// the account's timezone or else the organization's, or nil when business
+// hours are not enabled
+func (a *App) businessCalendar(settings *models.ChatbotSettings) *businessCalendar {
+	if settings == nil || !settings.BusinessHours.Enabled || len(settings.BusinessHours.Hours) == 0 {
+		return nil
+	}
+	var loc *time.Location
+	if tz := settings.BusinessHours.Timezone; tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			a.Log.Warn("Invalid business hours timezone, using organization timezone", "timezone", tz)
+		}
+		loc = l
+	}
+	if loc == nil {
+		loc = a.organizationTimezone(settings.OrganizationID)
+	}
+	return newBusinessCalendar(settings.BusinessHours, loc)
+}
+
+// outsideBusinessHours reports whether business hours are enabled and
+// closed now, with the out-of-hours (or holiday) message to send
+func (a *App) outsideBusinessHours(settings *models.ChatbotSettings) (bool, string) {
+	cal := a.businessCalendar(settings)
+	if cal == nil {
+		return false, ""
+	}
+	now := time.Now()
+	if cal.isOpen(now) {
+		return false, ""
+	}
+	return true, cal.closedMessage(now, settings.BusinessHours.OutOfHoursMessage)
+}
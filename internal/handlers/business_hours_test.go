@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// weekdayHours returns Monday-Friday business hours with a lunch break
+func weekdayHours() models.JSONBArray {
+	hours := models.JSONBArray{}
+	for day := 1; day <= 5; day++ {
+		hours = append(hours, map[string]interface{}{
+			"day":     float64(day),
+			"enabled": true,
+			"intervals": []interface{}{
+				map[string]interface{}{"start_time": "09:00", "end_time": "12:59"},
+				map[string]interface{}{"start_time": "14:00", "end_time": "17:59"},
+			},
+		})
+	}
+	return hours
+}
+
+func TestBusinessCalendarIsOpen_Timezone(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+	cal := newBusinessCalendar(models.BusinessHoursConfig{Hours: weekdayHours()}, kolkata)
+
+	// Monday 2026-03-09 04:00 UTC is 09:30 in Kolkata
+	assert.True(t, cal.isOpen(time.Date(2026, 3, 9, 4, 0, 0, 0, time.UTC)))
+	// 03:00 UTC is 08:30 in Kolkata
+	assert.False(t, cal.isOpen(time.Date(2026, 3, 9, 3, 0, 0, 0, time.UTC)))
+	// Lunch break: 13:30 in Kolkata
+	assert.False(t, cal.isOpen(time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)))
+	// Saturday
+	assert.False(t, cal.isOpen(time.Date(2026, 3, 14, 5, 0, 0, 0, time.UTC)))
+}
+
+func TestBusinessCalendarOvernightShift(t *testing.T) {
+	// Friday night shift, and Saturday closed for a holiday
+	cal := newBusinessCalendar(models.BusinessHoursConfig{
+		Hours: models.JSONBArray{
+			map[string]interface{}{"day": float64(5), "enabled": true, "start_time": "22:00", "end_time": "05:59"},
+		},
+		Holidays: models.JSONBArray{map[string]interface{}{"date": "2026-03-14"}},
+	}, time.UTC)
+
+	assert.False(t, cal.isOpen(time.Date(2026, 3, 13, 21, 59, 0, 0, time.UTC)))
+	assert.True(t, cal.isOpen(time.Date(2026, 3, 13, 23, 30, 0, 0, time.UTC)))
+	// The shift runs past midnight, even into a holiday
+	assert.True(t, cal.isOpen(time.Date(2026, 3, 14, 3, 0, 0, 0, time.UTC)))
+	assert.False(t, cal.isOpen(time.Date(2026, 3, 14, 6, 0, 0, 0, time.UTC)))
+	// Thursday has no shift, so Friday morning is closed
+	assert.False(t, cal.isOpen(time.Date(2026, 3, 13, 3, 0, 0, 0, time.UTC)))
+
+	// Working time continues across midnight
+	assert.Equal(t, time.Date(2026, 3, 14, 0, 30, 0, 0, time.UTC),
+		cal.addWorkingTime(time.Date(2026, 3, 13, 23, 30, 0, 0, time.UTC), time.Hour))
+}
+
+func TestBusinessCalendarHolidays(t *testing.T) {
+	cal := newBusinessCalendar(models.BusinessHoursConfig{
+		Hours: weekdayHours(),
+		Holidays: models.JSONBArray{
+			map[string]interface{}{"date": "2026-12-24", "name": "Christmas Eve", "intervals": []interface{}{
+				map[string]interface{}{"start_time": "09:00", "end_time": "11:59"},
+			}},
+			map[string]interface{}{"date": "2026-12-25", "end_date": "2026-12-26", "message": "Closed for Christmas"},
+		},
+	}, time.UTC)
+
+	assert.True(t, cal.isOpen(time.Date(2026, 12, 24, 10, 0, 0, 0, time.UTC)), "special hours")
+	assert.False(t, cal.isOpen(time.Date(2026, 12, 24, 15, 0, 0, 0, time.UTC)), "closes early")
+	assert.False(t, cal.isOpen(time.Date(2026, 12, 25, 10, 0, 0, 0, time.UTC)), "closed all day")
+
+	assert.Equal(t, "Closed for Christmas", cal.closedMessage(time.Date(2026, 12, 25, 10, 0, 0, 0, time.UTC), "We're closed"))
+	assert.Equal(t, "We're closed", cal.closedMessage(time.Date(2026, 12, 24, 15, 0, 0, 0, time.UTC), "We're closed"))
+	assert.Equal(t, "We're closed", cal.closedMessage(time.Date(2026, 12, 28, 20, 0, 0, 0, time.UTC), "We're closed"))
+}
+
+func TestBusinessCalendarAddWorkingTime(t *testing.T) {
+	cal := newBusinessCalendar(models.BusinessHoursConfig{
+		Hours:    weekdayHours(),
+		Holidays: models.JSONBArray{map[string]interface{}{"date": "2026-03-16"}},
+	}, time.UTC)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		d     time.Duration
+		want  time.Time
+	}{
+		{
+			name:  "within an interval",
+			start: time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC),
+			d:     30 * time.Minute,
+			want:  time.Date(2026, 3, 9, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "pauses over lunch",
+			start: time.Date(2026, 3, 9, 12, 30, 0, 0, time.UTC),
+			d:     time.Hour,
+			want:  time.Date(2026, 3, 9, 14, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "starts before opening",
+			start: time.Date(2026, 3, 9, 7, 0, 0, 0, time.UTC),
+			d:     15 * time.Minute,
+			want:  time.Date(2026, 3, 9, 9, 15, 0, 0, time.UTC),
+		},
+		{
+			name:  "skips the weekend and a holiday",
+			start: time.Date(2026, 3, 13, 17, 30, 0, 0, time.UTC),
+			d:     time.Hour,
+			want:  time.Date(2026, 3, 17, 9, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cal.addWorkingTime(tt.start, tt.d))
+		})
+	}
+
+	closed := newBusinessCalendar(models.BusinessHoursConfig{}, time.UTC)
+	start := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, start.Add(time.Hour), closed.addWorkingTime(start, time.Hour), "no working time uses wall clock")
+}
+
+func TestMergeWorkIntervals(t *testing.T) {
+	merged := mergeWorkIntervals([]workInterval{{start: 600, end: 700}, {start: 540, end: 610}, {start: 701, end: 720}, {start: 800, end: 900}})
+	assert.Equal(t, []workInterval{{start: 540, end: 720}, {start: 800, end: 900}}, merged)
+}
+
+func TestValidateBusinessHours(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     models.BusinessHoursConfig
+		wantErr bool
+	}{
+		{name: "valid", cfg: models.BusinessHoursConfig{Hours: weekdayHours(), Timezone: "Europe/Berlin"}},
+		{name: "disabled day is not checked", cfg: models.BusinessHoursConfig{Hours: models.JSONBArray{map[string]interface{}{"day": float64(0), "enabled": false}}}},
+		{name: "unknown timezone", cfg: models.BusinessHoursConfig{Timezone: "Mars/Olympus"}, wantErr: true},
+		{name: "bad day", cfg: models.BusinessHoursConfig{Hours: models.JSONBArray{map[string]interface{}{"day": float64(7), "enabled": true, "start_time": "09:00", "end_time": "17:00"}}}, wantErr: true},
+		{name: "bad time", cfg: models.BusinessHoursConfig{Hours: models.JSONBArray{map[string]interface{}{"day": float64(1), "enabled": true, "start_time": "9am", "end_time": "17:00"}}}, wantErr: true},
+		{name: "overnight shift", cfg: models.BusinessHoursConfig{Hours: models.JSONBArray{map[string]interface{}{"day": float64(1), "enabled": true, "start_time": "22:00", "end_time": "06:00"}}}},
+		{name: "bad holiday date", cfg: models.BusinessHoursConfig{Holidays: models.JSONBArray{map[string]interface{}{"date": "25/12/2026"}}}, wantErr: true},
+		{name: "holiday ends before it starts", cfg: models.BusinessHoursConfig{Holidays: models.JSONBArray{map[string]interface{}{"date": "2026-12-25", "end_date": "2026-12-24"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBusinessHours(tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
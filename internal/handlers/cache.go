@@ -17,6 +17,7 @@ const (
 	// Cache TTLs - 6 hours since these rarely change (invalidated on update anyway)
 	settingsCacheTTL        = 6 * time.Hour
 	flowsCacheTTL           = 6 * time.Hour
+	flowVersionCacheTTL     = 24 * time.Hour // Published versions are immutable
 	keywordRulesCacheTTL    = 6 * time.Hour
 	whatsappAccountCacheTTL = 6 * time.Hour
 	webhooksCacheTTL        = 6 * time.Hour
@@ -29,6 +30,7 @@ const (
 	// Cache key prefixes
 	settingsCachePrefix        = "chatbot:settings:"
 	flowsCachePrefix           = "chatbot:flows:"
+	flowVersionCachePrefix     = "chatbot:flow_version:"
 	keywordRulesCachePrefix    = "chatbot:keywords:"
 	whatsappAccountCachePrefix = "whatsapp:account:"
 	webhooksCachePrefix        = "webhooks:"
@@ -84,7 +86,8 @@ func (a *App) getChatbotSettingsCached(orgID uuid.UUID, whatsAppAccount string)
 	return &settings, nil
 }
 
-// getChatbotFlowsCached retrieves all enabled flows with steps from cache or database
+// getChatbotFlowsCached retrieves all enabled flows with steps from cache or database.
+// Published flows are returned as their published version; unpublished flows as their draft.
 func (a *App) getChatbotFlowsCached(orgID uuid.UUID) ([]models.ChatbotFlow, error) {
 	ctx := context.Background()
 	cacheKey := fmt.Sprintf("%s%s", flowsCachePrefix, orgID.String())
@@ -108,6 +111,20 @@ func (a *App) getChatbotFlowsCached(orgID uuid.UUID) ([]models.ChatbotFlow, erro
 		return nil, err
 	}
 
+	// Swap in the published version for flows that have one
+	for i := range flows {
+		if flows[i].PublishedVersion == 0 {
+			continue
+		}
+		published, err := a.getChatbotFlowVersionCached(orgID, flows[i].ID, flows[i].PublishedVersion)
+		if err != nil {
+			a.Log.Error("Failed to load published flow version", "error", err, "flow_id", flows[i].ID, "version", flows[i].PublishedVersion)
+			continue
+		}
+		published.IsEnabled = flows[i].IsEnabled
+		flows[i] = *published
+	}
+
 	// Cache the result
 	if data, err := json.Marshal(flows); err == nil {
 		a.Redis.Set(ctx, cacheKey, data, flowsCacheTTL)
@@ -116,8 +133,13 @@ func (a *App) getChatbotFlowsCached(orgID uuid.UUID) ([]models.ChatbotFlow, erro
 	return flows, nil
 }
 
-// getChatbotFlowByIDCached retrieves a specific flow by ID from the cached flows list
-func (a *App) getChatbotFlowByIDCached(orgID uuid.UUID, flowID uuid.UUID) (*models.ChatbotFlow, error) {
+// getChatbotFlowByIDCached retrieves a specific flow by ID and version.
+// Version 0 returns the live flow from the cached flows list (published version, or draft if never published).
+func (a *App) getChatbotFlowByIDCached(orgID uuid.UUID, flowID uuid.UUID, version int) (*models.ChatbotFlow, error) {
+	if version > 0 {
+		return a.getChatbotFlowVersionCached(orgID, flowID, version)
+	}
+
 	flows, err := a.getChatbotFlowsCached(orgID)
 	if err != nil {
 		return nil, err
@@ -132,6 +154,40 @@ func (a *App) getChatbotFlowByIDCached(orgID uuid.UUID, flowID uuid.UUID) (*mode
 	return nil, gorm.ErrRecordNotFound
 }
 
+// getChatbotFlowVersionCached retrieves a published flow version from cache or database
+func (a *App) getChatbotFlowVersionCached(orgID uuid.UUID, flowID uuid.UUID, version int) (*models.ChatbotFlow, error) {
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf("%s%s:%s:%d", flowVersionCachePrefix, orgID.String(), flowID.String(), version)
+
+	// Try cache first
+	cached, err := a.Redis.Get(ctx, cacheKey).Result()
+	if err == nil && cached != "" {
+		var flow models.ChatbotFlow
+		if err := json.Unmarshal([]byte(cached), &flow); err == nil {
+			return &flow, nil
+		}
+	}
+
+	// Cache miss - fetch from database
+	var flowVersion models.ChatbotFlowVersion
+	if err := a.DB.Where("organization_id = ? AND flow_id = ? AND version = ?", orgID, flowID, version).
+		First(&flowVersion).Error; err != nil {
+		return nil, err
+	}
+
+	flow, err := flowFromSnapshot(&flowVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the result
+	if data, err := json.Marshal(flow); err == nil {
+		a.Redis.Set(ctx, cacheKey, data, flowVersionCacheTTL)
+	}
+
+	return flow, nil
+}
+
 // getKeywordRulesCached retrieves keyword rules from cache or database
 func (a *App) getKeywordRulesCached(orgID uuid.UUID, whatsAppAccount string) ([]models.KeywordRule, error) {
 	ctx := context.Background()
@@ -192,6 +248,13 @@ func (a *App) InvalidateChatbotFlowsCache(orgID uuid.UUID) {
 	a.Redis.Del(ctx, cacheKey)
 }
 
+// InvalidateChatbotFlowVersionsCache invalidates the cached published versions of a flow
+func (a *App) InvalidateChatbotFlowVersionsCache(orgID, flowID uuid.UUID) {
+	ctx := context.Background()
+	pattern := fmt.Sprintf("%s%s:%s:*", flowVersionCachePrefix, orgID.String(), flowID.String())
+	a.deleteKeysByPattern(ctx, pattern)
+}
+
 // InvalidateKeywordRulesCache invalidates the keyword rules cache for an organization
 func (a *App) InvalidateKeywordRulesCache(orgID uuid.UUID) {
 	ctx := context.Background()
@@ -306,7 +369,8 @@ func (a *App) InvalidateWebhooksCache(orgID uuid.UUID) {
 	a.Redis.Del(ctx, cacheKey)
 }
 
-// getSLAEnabledSettingsCached retrieves all SLA-enabled chatbot settings from cache or database
+// getSLAEnabledSettingsCached retrieves the chatbot settings of organizations
+// with SLA enabled or active SLA policies from cache or database
 func (a *App) getSLAEnabledSettingsCached() ([]models.ChatbotSettings, error) {
 	ctx := context.Background()
 
@@ -321,7 +385,8 @@ func (a *App) getSLAEnabledSettingsCached() ([]models.ChatbotSettings, error) {
 
 	// Cache miss - fetch from database
 	var settings []models.ChatbotSettings
-	if err := a.DB.Where("sla_enabled = ?", true).Find(&settings).Error; err != nil {
+	policyOrgs := a.DB.Model(&models.SLAPolicy{}).Select("organization_id").Where("is_active = ?", true)
+	if err := a.DB.Where("sla_enabled = ? OR organization_id IN (?)", true, policyOrgs).Find(&settings).Error; err != nil {
 		return nil, err
 	}
 
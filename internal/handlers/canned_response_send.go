@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// CannedResponseSendRequest is the request body for rendering or sending a
+// canned response to a contact
+type CannedResponseSendRequest struct {
+	ContactID        uuid.UUID `json:"contact_id"`
+	ReplyToMessageID string    `json:"reply_to_message_id"`
+}
+
+// RenderedCannedResponse is a canned response with its placeholders resolved for a contact
+type RenderedCannedResponse struct {
+	ID             uuid.UUID         `json:"id"`
+	Content        string            `json:"content"`
+	MediaPath      string            `json:"media_path,omitempty"`
+	MediaMimeType  string            `json:"media_mime_type,omitempty"`
+	MediaFilename  string            `json:"media_filename,omitempty"`
+	Buttons        []ButtonContent   `json:"buttons"`
+	TemplateID     *uuid.UUID        `json:"template_id,omitempty"`
+	TemplateParams map[string]string `json:"template_params,omitempty"`
+}
+
+// CannedResponseUsageStat is the usage count of a canned response or an agent
+type CannedResponseUsageStat struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Count int64     `json:"count"`
+	Sent  int64     `json:"sent"`
+}
+
+// CannedResponseDailyUsage is the usage count for one day
+type CannedResponseDailyUsage struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+// RenderCannedResponse resolves the placeholders of a canned response for a
+// contact so the agent can review it before sending
+func (a *App) RenderCannedResponse(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "canned response")
+	if err != nil {
+		return nil
+	}
+
+	var req CannedResponseSendRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	cannedResponse, err := a.findCannedResponse(r, id, userID, orgID)
+	if err != nil {
+		return nil
+	}
+
+	var contact models.Contact
+	if err := a.scopeContacts(a.DB.Where("id = ? AND organization_id = ?", req.ContactID, orgID), userID, orgID).
+		First(&contact).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
+	}
+
+	vars := a.cannedResponseVariables(&contact, userID, orgID)
+	return r.SendEnvelope(renderCannedResponse(cannedResponse, vars))
+}
+
+// SendCannedResponse resolves a canned response for a contact and sends it as
+// a text, media, interactive button or template message
+func (a *App) SendCannedResponse(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "canned response")
+	if err != nil {
+		return nil
+	}
+
+	var req CannedResponseSendRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	cannedResponse, err := a.findCannedResponse(r, id, userID, orgID)
+	if err != nil {
+		return nil
+	}
+	if !cannedResponse.IsActive {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Canned response is inactive", nil, "")
+	}
+
+	// Users without chat.all:read can only message their own and their teams' conversations
+	var contact models.Contact
+	if err := a.scopeContacts(a.DB.Where("id = ? AND organization_id = ?", req.ContactID, orgID), userID, orgID).
+		First(&contact).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
+	}
+
+	rendered := renderCannedResponse(cannedResponse, a.cannedResponseVariables(&contact, userID, orgID))
+
+	// Reply from the account the customer is talking to
+	account, err := a.resolveWhatsAppAccount(orgID, contact.WhatsAppAccount)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to resolve WhatsApp account", nil, "")
+	}
+	msgReq := OutgoingMessageRequest{Contact: &contact, Account: account}
+
+	switch {
+	case cannedResponse.TemplateID != nil:
+		var template models.Template
+		if err := a.DB.Where("id = ? AND organization_id = ?", *cannedResponse.TemplateID, orgID).First(&template).Error; err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Template not found", nil, "")
+		}
+		// Templates are synced per account; use the same template on the contact's account
+		if template.WhatsAppAccount != account.Name {
+			if err := a.DB.Where("organization_id = ? AND whats_app_account = ? AND name = ? AND language = ?",
+				orgID, account.Name, template.Name, template.Language).First(&template).Error; err != nil {
+				return r.SendErrorEnvelope(fasthttp.StatusBadRequest,
+					fmt.Sprintf("Template is not available on the %s WhatsApp account", account.Name), nil, "")
+			}
+		}
+		if template.Status != "APPROVED" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Template is not approved (status: %s)", template.Status), nil, "")
+		}
+		if missingParams := templateutil.MissingBodyParams(template.BodyContent, rendered.TemplateParams); len(missingParams) > 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest,
+				fmt.Sprintf("Missing template parameters: %s", strings.Join(missingParams, ", ")), nil, "")
+		}
+		msgReq.Type = models.MessageTypeTemplate
+		msgReq.Template = &template
+		msgReq.BodyParams = rendered.TemplateParams
+
+	case cannedResponse.MediaPath != "":
+		data, err := a.readStoredMedia(orgID, cannedResponse.MediaPath)
+		if err != nil {
+			a.Log.Error("Failed to read canned response media", "error", err, "canned_response_id", cannedResponse.ID)
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to read media", nil, "")
+		}
+		msgReq.Type = cannedResponseMediaType(cannedResponse.MediaMimeType)
+		msgReq.MediaData = data
+		msgReq.MediaURL = cannedResponse.MediaPath
+		msgReq.MediaMimeType = cannedResponse.MediaMimeType
+		msgReq.MediaFilename = cannedResponse.MediaFilename
+		if msgReq.Type != models.MessageTypeAudio {
+			msgReq.Caption = rendered.Content // WhatsApp audio messages have no caption
+		}
+
+	case len(rendered.Buttons) > 0:
+		msgReq.Type = models.MessageTypeInteractive
+		msgReq.InteractiveType = "button"
+		msgReq.BodyText = rendered.Content
+		msgReq.Buttons = make([]whatsapp.Button, len(rendered.Buttons))
+		for i, btn := range rendered.Buttons {
+			msgReq.Buttons[i] = whatsapp.Button{ID: btn.ID, Title: btn.Title}
+		}
+
+	default:
+		msgReq.Type = models.MessageTypeText
+		msgReq.Content = rendered.Content
+	}
+
+	if req.ReplyToMessageID != "" {
+		if replyToID, err := uuid.Parse(req.ReplyToMessageID); err == nil {
+			var replyTo models.Message
+			if err := a.DB.Where("id = ? AND contact_id = ?", replyToID, contact.ID).First(&replyTo).Error; err == nil {
+				msgReq.ReplyToMessage = &replyTo
+			}
+		}
+	}
+
+	opts := DefaultSendOptions()
+	opts.SentByUserID = &userID
+
+	message, err := a.SendOutgoingMessage(context.Background(), msgReq, opts)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to send message", nil, "")
+	}
+
+	if err := a.recordCannedResponseUsage(cannedResponse, userID, &contact.ID, true); err != nil {
+		a.Log.Error("Failed to record canned response usage", "error", err, "canned_response_id", cannedResponse.ID)
+	}
+
+	return r.SendEnvelope(MessageResponse{
+		ID:              message.ID,
+		ContactID:       message.ContactID,
+		Direction:       message.Direction,
+		MessageType:     message.MessageType,
+		Content:         map[string]string{"body": message.Content},
+		MediaURL:        message.MediaURL,
+		MediaMimeType:   message.MediaMimeType,
+		MediaFilename:   message.MediaFilename,
+		InteractiveData: message.InteractiveData,
+		Status:          message.Status,
+		IsReply:         message.IsReply,
+		CreatedAt:       message.CreatedAt,
+		UpdatedAt:       message.UpdatedAt,
+	})
+}
+
+// GetCannedResponseAnalytics returns canned response usage per response, per
+// agent and per day. Users without analytics permission only see their own usage.
+func (a *App) GetCannedResponseAnalytics(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	fromStr := string(r.RequestCtx.QueryArgs().Peek("from"))
+	toStr := string(r.RequestCtx.QueryArgs().Peek("to"))
+	agentIDStr := string(r.RequestCtx.QueryArgs().Peek("agent_id"))
+
+	now := time.Now()
+	var periodStart, periodEnd time.Time
+	if fromStr != "" && toStr != "" {
+		var errMsg string
+		periodStart, periodEnd, errMsg = parseDateRange(fromStr, toStr)
+		if errMsg != "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, errMsg, nil, "")
+		}
+	} else {
+		// Default to current month
+		periodStart = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		periodEnd = now
+	}
+
+	query := a.DB.Model(&models.CannedResponseUsage{}).
+		Where("canned_response_usages.organization_id = ? AND canned_response_usages.created_at BETWEEN ? AND ?", orgID, periodStart, periodEnd)
+	if !a.HasPermission(userID, models.ResourceAnalytics, models.ActionRead, orgID) {
+		query = query.Where("canned_response_usages.user_id = ?", userID)
+	} else if agentIDStr != "" {
+		agentID, err := uuid.Parse(agentIDStr)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid agent_id", nil, "")
+		}
+		query = query.Where("canned_response_usages.user_id = ?", agentID)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	byResponse := []CannedResponseUsageStat{}
+	query.Session(&gorm.Session{}).
+		Select("canned_responses.id AS id, canned_responses.name AS name, COUNT(*) AS count, " +
+			"COUNT(*) FILTER (WHERE canned_response_usages.sent) AS sent").
+		Joins("JOIN canned_responses ON canned_responses.id = canned_response_usages.canned_response_id").
+		Group("canned_responses.id, canned_responses.name").
+		Order("count DESC").
+		Scan(&byResponse)
+
+	byAgent := []CannedResponseUsageStat{}
+	query.Session(&gorm.Session{}).
+		Select("users.id AS id, users.full_name AS name, COUNT(*) AS count, " +
+			"COUNT(*) FILTER (WHERE canned_response_usages.sent) AS sent").
+		Joins("JOIN users ON users.id = canned_response_usages.user_id").
+		Group("users.id, users.full_name").
+		Order("count DESC").
+		Scan(&byAgent)
+
+	byDay := []CannedResponseDailyUsage{}
+	query.Session(&gorm.Session{}).
+		Select("TO_CHAR(DATE(canned_response_usages.created_at), 'YYYY-MM-DD') AS date, COUNT(*) AS count").
+		Group("DATE(canned_response_usages.created_at)").
+		Order("DATE(canned_response_usages.created_at)").
+		Scan(&byDay)
+
+	return r.SendEnvelope(map[string]any{
+		"total":       total,
+		"by_response": byResponse,
+		"by_agent":    byAgent,
+		"by_day":      byDay,
+		"from":        periodStart.Format("2006-01-02"),
+		"to":          periodEnd.Format("2006-01-02"),
+	})
+}
+
+// recordCannedResponseUsage increments the usage count of a canned response
+// and records who used it, for which contact and whether it was sent directly
+func (a *App) recordCannedResponseUsage(cr *models.CannedResponse, userID uuid.UUID, contactID *uuid.UUID, sent bool) error {
+	return a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.CannedResponse{}).Where("id = ?", cr.ID).
+			UpdateColumn("usage_count", gorm.Expr("usage_count + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CannedResponseUsage{
+			OrganizationID:   cr.OrganizationID,
+			CannedResponseID: cr.ID,
+			UserID:           userID,
+			ContactID:        contactID,
+			Sent:             sent,
+		}).Error
+	})
+}
+
+// cannedResponseVariables builds the placeholder values for a contact: the
+// contact with its custom fields, the sending agent, the organization and the
+// data collected in the contact's latest chatbot session
+func (a *App) cannedResponseVariables(contact *models.Contact, userID, orgID uuid.UUID) map[string]interface{} {
+	var user models.User
+	a.DB.Select("id, full_name, email").Where("id = ?", userID).First(&user)
+
+	var org models.Organization
+	a.DB.Select("id, name").Where("id = ?", orgID).First(&org)
+
+	var session models.ChatbotSession
+	a.DB.Select("session_data").
+		Where("contact_id = ? AND organization_id = ?", contact.ID, orgID).
+		Where("status IN ?", []models.SessionStatus{models.SessionStatusActive, models.SessionStatusCompleted}).
+		Order("created_at DESC").
+		First(&session)
+
+	return buildCannedResponseVariables(contact, &user, &org, session.SessionData)
+}
+
+func buildCannedResponseVariables(contact *models.Contact, user *models.User, org *models.Organization, sessionData models.JSONB) map[string]interface{} {
+	contactName := contact.ProfileName
+	if contactName == "" {
+		contactName = "there"
+	}
+	metadata := map[string]interface{}(contact.Metadata)
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	tags := make([]string, 0, len(contact.Tags))
+	for _, tag := range contact.Tags {
+		tags = append(tags, fmt.Sprint(tag))
+	}
+	session := map[string]interface{}(sessionData)
+	if session == nil {
+		session = map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		// Flat names kept for responses written for the chat picker
+		"contact_name": contactName,
+		"phone_number": contact.PhoneNumber,
+		"agent_name":   user.FullName,
+
+		"contact": map[string]interface{}{
+			"id":           contact.ID.String(),
+			"name":         contactName,
+			"phone_number": contact.PhoneNumber,
+			"tags":         strings.Join(tags, ", "),
+			"metadata":     metadata,
+		},
+		"agent": map[string]interface{}{
+			"id":    user.ID.String(),
+			"name":  user.FullName,
+			"email": user.Email,
+		},
+		"organization": map[string]interface{}{
+			"name": org.Name,
+		},
+		"session": session,
+	}
+}
+
+// renderCannedResponse resolves the placeholders in the content, button
+// titles and template parameters of a canned response
+func renderCannedResponse(cr *models.CannedResponse, vars map[string]interface{}) RenderedCannedResponse {
+	rendered := RenderedCannedResponse{
+		ID:            cr.ID,
+		Content:       processVariables(cr.Content, vars),
+		MediaPath:     cr.MediaPath,
+		MediaMimeType: cr.MediaMimeType,
+		MediaFilename: cr.MediaFilename,
+		Buttons:       cannedResponseButtons(*cr),
+		TemplateID:    cr.TemplateID,
+	}
+	for i := range rendered.Buttons {
+		rendered.Buttons[i].Title = truncateString(processVariables(rendered.Buttons[i].Title, vars), maxCannedResponseButtonTitle)
+	}
+	if len(cr.TemplateParams) > 0 {
+		rendered.TemplateParams = make(map[string]string, len(cr.TemplateParams))
+		for key, value := range cr.TemplateParams {
+			rendered.TemplateParams[key] = processVariables(fmt.Sprint(value), vars)
+		}
+	}
+	return rendered
+}
+
+// cannedResponseMediaType returns the message type for an attachment's MIME type
+func cannedResponseMediaType(mimeType string) models.MessageType {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return models.MessageTypeImage
+	case strings.HasPrefix(mimeType, "video/"):
+		return models.MessageTypeVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		return models.MessageTypeAudio
+	default:
+		return models.MessageTypeDocument
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCannedResponse(t *testing.T) {
+	teamID := uuid.New()
+	templateID := uuid.New()
+	button := func(id, title string) interface{} {
+		return map[string]interface{}{"id": id, "title": title}
+	}
+
+	tests := []struct {
+		name    string
+		cr      models.CannedResponse
+		wantErr string
+	}{
+		{"text", models.CannedResponse{Scope: models.CannedResponseScopeOrganization, Content: "Hi"}, ""},
+		{"team without team", models.CannedResponse{Scope: models.CannedResponseScopeTeam, Content: "Hi"}, "team_id is required for team canned responses"},
+		{"team", models.CannedResponse{Scope: models.CannedResponseScopeTeam, TeamID: &teamID, Content: "Hi"}, ""},
+		{"unknown scope", models.CannedResponse{Scope: "global", Content: "Hi"}, "invalid scope: global"},
+		{"media", models.CannedResponse{Scope: models.CannedResponseScopePersonal, MediaPath: "images/a.png"}, ""},
+		{"template with media", models.CannedResponse{Scope: models.CannedResponseScopePersonal, TemplateID: &templateID, MediaPath: "images/a.png"}, "a template canned response cannot have media or buttons"},
+		{"buttons", models.CannedResponse{Scope: models.CannedResponseScopePersonal, Content: "Pick", Buttons: models.JSONBArray{button("a", "Yes"), button("b", "No")}}, ""},
+		{"buttons without body", models.CannedResponse{Scope: models.CannedResponseScopePersonal, Buttons: models.JSONBArray{button("a", "Yes")}}, "content is required for canned responses with buttons"},
+		{"too many buttons", models.CannedResponse{Scope: models.CannedResponseScopePersonal, Content: "Pick", Buttons: models.JSONBArray{button("a", "1"), button("b", "2"), button("c", "3"), button("d", "4")}}, "at most 3 buttons are allowed"},
+		{"long button title", models.CannedResponse{Scope: models.CannedResponseScopePersonal, Content: "Pick", Buttons: models.JSONBArray{button("a", "This title is far too long")}}, "button titles must be 1 to 20 characters"},
+		{"duplicate button id", models.CannedResponse{Scope: models.CannedResponseScopePersonal, Content: "Pick", Buttons: models.JSONBArray{button("a", "Yes"), button("a", "No")}}, "duplicate button id: a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCannedResponse(&tt.cr)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderCannedResponse(t *testing.T) {
+	contact := &models.Contact{
+		PhoneNumber: "919876543210",
+		ProfileName: "Asha",
+		Metadata:    models.JSONB{"plan": "gold"},
+	}
+	user := &models.User{FullName: "Ravi"}
+	org := &models.Organization{Name: "Acme"}
+	vars := buildCannedResponseVariables(contact, user, org, models.JSONB{"order_id": "A-17"})
+
+	cr := &models.CannedResponse{
+		Content: "Hi {{contact_name}}, {{agent.name}} from {{organization.name}} here about order {{session.order_id}} " +
+			"on your {{contact.metadata.plan}} plan ({{phone_number}})",
+		Buttons:        models.JSONBArray{map[string]interface{}{"id": "btn_1", "title": "Thanks {{contact.name}}"}},
+		TemplateParams: models.JSONB{"1": "{{contact.name}}"},
+	}
+	rendered := renderCannedResponse(cr, vars)
+	assert.Equal(t, "Hi Asha, Ravi from Acme here about order A-17 on your gold plan (919876543210)", rendered.Content)
+	require.Len(t, rendered.Buttons, 1)
+	assert.Equal(t, "Thanks Asha", rendered.Buttons[0].Title)
+	assert.Equal(t, map[string]string{"1": "Asha"}, rendered.TemplateParams)
+
+	// Contacts without a profile name keep the picker's fallback greeting
+	vars = buildCannedResponseVariables(&models.Contact{PhoneNumber: "1"}, user, org, nil)
+	assert.Equal(t, "Hi there!", processVariables("Hi {{contact_name}}!", vars))
+}
+
+func TestCannedResponseMediaType(t *testing.T) {
+	assert.Equal(t, models.MessageTypeImage, cannedResponseMediaType("image/png"))
+	assert.Equal(t, models.MessageTypeVideo, cannedResponseMediaType("video/mp4"))
+	assert.Equal(t, models.MessageTypeAudio, cannedResponseMediaType("audio/ogg"))
+	assert.Equal(t, models.MessageTypeDocument, cannedResponseMediaType("application/pdf"))
+}
@@ -1,6 +1,12 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/valyala/fasthttp"
@@ -8,31 +14,58 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	// maxCannedResponseButtons is the number of reply buttons WhatsApp allows
+	maxCannedResponseButtons = 3
+	// maxCannedResponseButtonTitle is the longest reply button title WhatsApp allows
+	maxCannedResponseButtonTitle = 20
+	// maxCannedResponseMediaSize is the largest attachment a canned response can carry
+	maxCannedResponseMediaSize = 16 << 20
+)
+
 // CannedResponseRequest represents the request body for creating/updating a canned response
 type CannedResponseRequest struct {
-	Name     string `json:"name"`
-	Shortcut string `json:"shortcut"`
-	Content  string `json:"content"`
-	Category string `json:"category"`
-	IsActive bool   `json:"is_active"`
+	Name           string                     `json:"name"`
+	Shortcut       string                     `json:"shortcut"`
+	Content        string                     `json:"content"`
+	Category       string                     `json:"category"`
+	IsActive       bool                       `json:"is_active"`
+	Scope          models.CannedResponseScope `json:"scope"`
+	TeamID         *uuid.UUID                 `json:"team_id"`
+	MediaPath      string                     `json:"media_path"`
+	MediaMimeType  string                     `json:"media_mime_type"`
+	MediaFilename  string                     `json:"media_filename"`
+	Buttons        []ButtonContent            `json:"buttons"`
+	TemplateID     *uuid.UUID                 `json:"template_id"`
+	TemplateParams map[string]string          `json:"template_params"`
 }
 
 // CannedResponseResponse represents the API response for a canned response
 type CannedResponseResponse struct {
-	ID         uuid.UUID `json:"id"`
-	Name       string    `json:"name"`
-	Shortcut   string    `json:"shortcut"`
-	Content    string    `json:"content"`
-	Category   string    `json:"category"`
-	IsActive   bool      `json:"is_active"`
-	UsageCount int       `json:"usage_count"`
-	CreatedAt  string    `json:"created_at"`
-	UpdatedAt  string    `json:"updated_at"`
+	ID             uuid.UUID                  `json:"id"`
+	Name           string                     `json:"name"`
+	Shortcut       string                     `json:"shortcut"`
+	Content        string                     `json:"content"`
+	Category       string                     `json:"category"`
+	IsActive       bool                       `json:"is_active"`
+	UsageCount     int                        `json:"usage_count"`
+	Scope          models.CannedResponseScope `json:"scope"`
+	TeamID         *uuid.UUID                 `json:"team_id,omitempty"`
+	CreatedByID    uuid.UUID                  `json:"created_by_id"`
+	MediaPath      string                     `json:"media_path,omitempty"`
+	MediaMimeType  string                     `json:"media_mime_type,omitempty"`
+	MediaFilename  string                     `json:"media_filename,omitempty"`
+	Buttons        []ButtonContent            `json:"buttons"`
+	TemplateID     *uuid.UUID                 `json:"template_id,omitempty"`
+	TemplateParams map[string]string          `json:"template_params,omitempty"`
+	CreatedAt      string                     `json:"created_at"`
+	UpdatedAt      string                     `json:"updated_at"`
 }
 
-// ListCannedResponses returns all canned responses for the organization
+// ListCannedResponses returns the canned responses the user can see: those of
+// the organization, of the user's teams and the user's personal ones
 func (a *App) ListCannedResponses(r *fastglue.Request) error {
-	orgID, err := a.getOrgID(r)
+	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
 	}
@@ -43,8 +76,9 @@ func (a *App) ListCannedResponses(r *fastglue.Request) error {
 	category := string(r.RequestCtx.QueryArgs().Peek("category"))
 	search := string(r.RequestCtx.QueryArgs().Peek("search"))
 	activeOnly := string(r.RequestCtx.QueryArgs().Peek("active_only"))
+	scope := string(r.RequestCtx.QueryArgs().Peek("scope"))
 
-	query := a.DB.Where("organization_id = ?", orgID)
+	query := a.scopeCannedResponses(a.DB.Where("organization_id = ?", orgID), userID, orgID)
 
 	// By default show all, but allow filtering to active only (for chat picker)
 	if activeOnly == "true" {
@@ -54,6 +88,9 @@ func (a *App) ListCannedResponses(r *fastglue.Request) error {
 	if category != "" {
 		query = query.Where("category = ?", category)
 	}
+	if scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
 	if search != "" {
 		searchPattern := "%" + search + "%"
 		query = query.Where("name ILIKE ? OR content ILIKE ? OR shortcut ILIKE ?",
@@ -84,7 +121,9 @@ func (a *App) ListCannedResponses(r *fastglue.Request) error {
 	})
 }
 
-// CreateCannedResponse creates a new canned response
+// CreateCannedResponse creates a new canned response. Without an explicit
+// scope it is shared with the organization when the user can manage canned
+// responses, and personal otherwise.
 func (a *App) CreateCannedResponse(r *fastglue.Request) error {
 	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
@@ -96,28 +135,47 @@ func (a *App) CreateCannedResponse(r *fastglue.Request) error {
 		return nil
 	}
 
-	if req.Name == "" || req.Content == "" {
+	if req.Name == "" || (req.Content == "" && req.MediaPath == "" && req.TemplateID == nil) {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest,
 			"name and content are required", nil, "")
 	}
 
-	// Check for duplicate name
-	var existing models.CannedResponse
-	if err := a.DB.Where("organization_id = ? AND name = ?", orgID, req.Name).
-		First(&existing).Error; err == nil {
-		return r.SendErrorEnvelope(fasthttp.StatusConflict,
-			"Canned response with this name already exists", nil, "")
+	if req.Scope == "" {
+		req.Scope = models.CannedResponseScopePersonal
+		if a.HasPermission(userID, models.ResourceCannedResponses, models.ActionWrite, orgID) {
+			req.Scope = models.CannedResponseScopeOrganization
+		}
 	}
 
 	cannedResponse := models.CannedResponse{
 		OrganizationID: orgID,
-		Name:           req.Name,
-		Shortcut:       req.Shortcut,
-		Content:        req.Content,
-		Category:       req.Category,
 		IsActive:       true,
 		CreatedByID:    userID,
 	}
+	applyCannedResponseRequest(&cannedResponse, req)
+	cannedResponse.Name = req.Name
+	cannedResponse.Content = req.Content
+
+	if err := validateCannedResponse(&cannedResponse); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+	if cannedResponse.MediaPath != "" && !ownsMediaPath(orgID, cannedResponse.MediaPath) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "media_path must be a file uploaded with the upload endpoint", nil, "")
+	}
+	if err := a.checkCannedResponseScope(r, &cannedResponse, userID, orgID); err != nil {
+		return nil
+	}
+	if err := a.checkCannedResponseTemplate(r, &cannedResponse, orgID); err != nil {
+		return nil
+	}
+
+	// Names are unique among the responses the user can see
+	var existing models.CannedResponse
+	if err := a.scopeCannedResponses(a.DB.Where("organization_id = ? AND name = ?", orgID, req.Name), userID, orgID).
+		First(&existing).Error; err == nil {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict,
+			"Canned response with this name already exists", nil, "")
+	}
 
 	if err := a.DB.Create(&cannedResponse).Error; err != nil {
 		a.Log.Error("Failed to create canned response", "error", err)
@@ -130,7 +188,7 @@ func (a *App) CreateCannedResponse(r *fastglue.Request) error {
 
 // GetCannedResponse returns a single canned response
 func (a *App) GetCannedResponse(r *fastglue.Request) error {
-	orgID, err := a.getOrgID(r)
+	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
 	}
@@ -140,19 +198,17 @@ func (a *App) GetCannedResponse(r *fastglue.Request) error {
 		return nil
 	}
 
-	var cannedResponse models.CannedResponse
-	if err := a.DB.Where("id = ? AND organization_id = ?", id, orgID).
-		First(&cannedResponse).Error; err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusNotFound,
-			"Canned response not found", nil, "")
+	cannedResponse, err := a.findCannedResponse(r, id, userID, orgID)
+	if err != nil {
+		return nil
 	}
 
-	return r.SendEnvelope(cannedResponseToResponse(cannedResponse))
+	return r.SendEnvelope(cannedResponseToResponse(*cannedResponse))
 }
 
 // UpdateCannedResponse updates an existing canned response
 func (a *App) UpdateCannedResponse(r *fastglue.Request) error {
-	orgID, err := a.getOrgID(r)
+	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
 	}
@@ -162,11 +218,13 @@ func (a *App) UpdateCannedResponse(r *fastglue.Request) error {
 		return nil
 	}
 
-	var cannedResponse models.CannedResponse
-	if err := a.DB.Where("id = ? AND organization_id = ?", id, orgID).
-		First(&cannedResponse).Error; err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusNotFound,
-			"Canned response not found", nil, "")
+	cannedResponse, err := a.findCannedResponse(r, id, userID, orgID)
+	if err != nil {
+		return nil
+	}
+	if !a.canManageCannedResponse(cannedResponse, userID, orgID, models.ActionWrite) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden,
+			"You don't have permission to edit this canned response", nil, "")
 	}
 
 	var req CannedResponseRequest
@@ -178,25 +236,40 @@ func (a *App) UpdateCannedResponse(r *fastglue.Request) error {
 	if req.Name != "" {
 		cannedResponse.Name = req.Name
 	}
-	cannedResponse.Shortcut = req.Shortcut
 	if req.Content != "" {
 		cannedResponse.Content = req.Content
 	}
-	cannedResponse.Category = req.Category
 	cannedResponse.IsActive = req.IsActive
+	previousScope, previousTeamID := cannedResponse.Scope, cannedResponse.TeamID
+	applyCannedResponseRequest(cannedResponse, req)
+
+	if err := validateCannedResponse(cannedResponse); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+	if cannedResponse.MediaPath != "" && !ownsMediaPath(orgID, cannedResponse.MediaPath) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "media_path must be a file uploaded with the upload endpoint", nil, "")
+	}
+	if cannedResponse.Scope != previousScope || !uuidPtrEqual(cannedResponse.TeamID, previousTeamID) {
+		if err := a.checkCannedResponseScope(r, cannedResponse, userID, orgID); err != nil {
+			return nil
+		}
+	}
+	if err := a.checkCannedResponseTemplate(r, cannedResponse, orgID); err != nil {
+		return nil
+	}
 
-	if err := a.DB.Save(&cannedResponse).Error; err != nil {
+	if err := a.DB.Omit("Organization", "CreatedBy", "Team", "Template").Save(cannedResponse).Error; err != nil {
 		a.Log.Error("Failed to update canned response", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError,
 			"Failed to update canned response", nil, "")
 	}
 
-	return r.SendEnvelope(cannedResponseToResponse(cannedResponse))
+	return r.SendEnvelope(cannedResponseToResponse(*cannedResponse))
 }
 
 // DeleteCannedResponse deletes a canned response
 func (a *App) DeleteCannedResponse(r *fastglue.Request) error {
-	orgID, err := a.getOrgID(r)
+	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
 	}
@@ -206,14 +279,16 @@ func (a *App) DeleteCannedResponse(r *fastglue.Request) error {
 		return nil
 	}
 
-	var cannedResponse models.CannedResponse
-	if err := a.DB.Where("id = ? AND organization_id = ?", id, orgID).
-		First(&cannedResponse).Error; err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusNotFound,
-			"Canned response not found", nil, "")
+	cannedResponse, err := a.findCannedResponse(r, id, userID, orgID)
+	if err != nil {
+		return nil
+	}
+	if !a.canManageCannedResponse(cannedResponse, userID, orgID, models.ActionDelete) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden,
+			"You don't have permission to delete this canned response", nil, "")
 	}
 
-	if err := a.DB.Delete(&cannedResponse).Error; err != nil {
+	if err := a.DB.Delete(cannedResponse).Error; err != nil {
 		a.Log.Error("Failed to delete canned response", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError,
 			"Failed to delete canned response", nil, "")
@@ -222,9 +297,10 @@ func (a *App) DeleteCannedResponse(r *fastglue.Request) error {
 	return r.SendEnvelope(map[string]string{"message": "Canned response deleted"})
 }
 
-// IncrementCannedResponseUsage increments the usage counter
+// IncrementCannedResponseUsage records that the user inserted a canned
+// response in the chat. The body may name the contact it was used for.
 func (a *App) IncrementCannedResponseUsage(r *fastglue.Request) error {
-	orgID, err := a.getOrgID(r)
+	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
 	}
@@ -234,26 +310,328 @@ func (a *App) IncrementCannedResponseUsage(r *fastglue.Request) error {
 		return nil
 	}
 
-	if err := a.DB.Model(&models.CannedResponse{}).
-		Where("id = ? AND organization_id = ?", id, orgID).
-		UpdateColumn("usage_count", gorm.Expr("usage_count + 1")).Error; err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError,
-			"Failed to update usage", nil, "")
+	var req struct {
+		ContactID *uuid.UUID `json:"contact_id"`
+	}
+	if body := r.RequestCtx.PostBody(); len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
+		}
+	}
+
+	// Responses the user cannot see are silently ignored
+	var cannedResponse models.CannedResponse
+	if err := a.scopeCannedResponses(a.DB.Where("id = ? AND organization_id = ?", id, orgID), userID, orgID).
+		First(&cannedResponse).Error; err == nil {
+		if err := a.recordCannedResponseUsage(&cannedResponse, userID, req.ContactID, false); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError,
+				"Failed to update usage", nil, "")
+		}
 	}
 
 	return r.SendEnvelope(map[string]string{"message": "Usage incremented"})
 }
 
+// UploadCannedResponseMedia stores a file to attach to canned responses. It is
+// saved with the organization's uploads, the only files canned responses can use.
+func (a *App) UploadCannedResponseMedia(r *fastglue.Request) error {
+	orgID, _, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	form, err := r.RequestCtx.MultipartForm()
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid multipart form", nil, "")
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "file is required", nil, "")
+	}
+	fileHeader := files[0]
+	if fileHeader.Size > maxCannedResponseMediaSize {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("File is larger than %d MB", maxCannedResponseMediaSize>>20), nil, "")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to read file", nil, "")
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to read file data", nil, "")
+	}
+
+	mimeType := fileHeader.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	localPath, err := a.saveOrgMediaLocally(orgID, data, mimeType, fileHeader.Filename)
+	if err != nil {
+		a.Log.Error("Failed to save canned response media", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save media", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"media_path": localPath,
+		"mime_type":  mimeType,
+		"filename":   fileHeader.Filename,
+	})
+}
+
+// ServeCannedResponseMedia serves the attachment of a canned response for previews
+func (a *App) ServeCannedResponseMedia(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "canned response")
+	if err != nil {
+		return nil
+	}
+
+	cannedResponse, err := a.findCannedResponse(r, id, userID, orgID)
+	if err != nil {
+		return nil
+	}
+	if cannedResponse.MediaPath == "" || !ownsMediaPath(orgID, cannedResponse.MediaPath) {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "No media found", nil, "")
+	}
+
+	return a.serveStoredMedia(r, cannedResponse.MediaPath)
+}
+
+// scopeCannedResponses limits a canned responses query to those the user can
+// see. Users who can manage canned responses see every team's responses.
+func (a *App) scopeCannedResponses(query *gorm.DB, userID, orgID uuid.UUID) *gorm.DB {
+	if a.HasPermission(userID, models.ResourceCannedResponses, models.ActionWrite, orgID) {
+		return query.Where("(scope = ? OR scope = ? OR (scope = ? AND created_by_id = ?))",
+			models.CannedResponseScopeOrganization, models.CannedResponseScopeTeam,
+			models.CannedResponseScopePersonal, userID)
+	}
+
+	var teamIDs []uuid.UUID
+	a.DB.Model(&models.TeamMember{}).
+		Joins("JOIN teams ON teams.id = team_members.team_id AND teams.deleted_at IS NULL").
+		Where("team_members.user_id = ? AND teams.organization_id = ?", userID, orgID).
+		Pluck("team_members.team_id", &teamIDs)
+
+	cond := "(scope = ? OR (scope = ? AND created_by_id = ?)"
+	args := []any{models.CannedResponseScopeOrganization, models.CannedResponseScopePersonal, userID}
+	if len(teamIDs) > 0 {
+		cond += " OR (scope = ? AND team_id IN ?)"
+		args = append(args, models.CannedResponseScopeTeam, teamIDs)
+	}
+	return query.Where(cond+")", args...)
+}
+
+// findCannedResponse loads a canned response the user can see, sending a 404 otherwise
+func (a *App) findCannedResponse(r *fastglue.Request, id, userID, orgID uuid.UUID) (*models.CannedResponse, error) {
+	var cannedResponse models.CannedResponse
+	if err := a.scopeCannedResponses(a.DB.Where("id = ? AND organization_id = ?", id, orgID), userID, orgID).
+		First(&cannedResponse).Error; err != nil {
+		_ = r.SendErrorEnvelope(fasthttp.StatusNotFound, "Canned response not found", nil, "")
+		return nil, errEnvelopeSent
+	}
+	return &cannedResponse, nil
+}
+
+// canManageCannedResponse reports whether the user may edit (ActionWrite) or
+// delete (ActionDelete) a canned response. Creators can always manage their
+// own responses, team managers those of their teams.
+func (a *App) canManageCannedResponse(cr *models.CannedResponse, userID, orgID uuid.UUID, action string) bool {
+	if cr.CreatedByID == userID {
+		return true
+	}
+	if cr.Scope == models.CannedResponseScopePersonal {
+		return false
+	}
+	if a.HasPermission(userID, models.ResourceCannedResponses, action, orgID) {
+		return true
+	}
+	return cr.Scope == models.CannedResponseScopeTeam && cr.TeamID != nil && a.isTeamManager(userID, *cr.TeamID)
+}
+
+// checkCannedResponseScope verifies the user may share a canned response
+// with its scope, sending the error response otherwise
+func (a *App) checkCannedResponseScope(r *fastglue.Request, cr *models.CannedResponse, userID, orgID uuid.UUID) error {
+	switch cr.Scope {
+	case models.CannedResponseScopePersonal:
+		return nil
+	case models.CannedResponseScopeTeam:
+		var team models.Team
+		if err := a.DB.Where("id = ? AND organization_id = ?", *cr.TeamID, orgID).First(&team).Error; err != nil {
+			_ = r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Team not found", nil, "")
+			return errEnvelopeSent
+		}
+		if a.HasPermission(userID, models.ResourceCannedResponses, models.ActionWrite, orgID) || a.isTeamManager(userID, team.ID) {
+			return nil
+		}
+		_ = r.SendErrorEnvelope(fasthttp.StatusForbidden,
+			"Only team managers can share canned responses with their team", nil, "")
+		return errEnvelopeSent
+	default:
+		if a.HasPermission(userID, models.ResourceCannedResponses, models.ActionWrite, orgID) {
+			return nil
+		}
+		_ = r.SendErrorEnvelope(fasthttp.StatusForbidden,
+			"You don't have permission to share canned responses with the organization", nil, "")
+		return errEnvelopeSent
+	}
+}
+
+// checkCannedResponseTemplate verifies the referenced template belongs to
+// the organization, sending the error response otherwise
+func (a *App) checkCannedResponseTemplate(r *fastglue.Request, cr *models.CannedResponse, orgID uuid.UUID) error {
+	if cr.TemplateID == nil {
+		return nil
+	}
+	var count int64
+	a.DB.Model(&models.Template{}).Where("id = ? AND organization_id = ?", *cr.TemplateID, orgID).Count(&count)
+	if count == 0 {
+		_ = r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Template not found", nil, "")
+		return errEnvelopeSent
+	}
+	return nil
+}
+
+// isTeamManager reports whether the user manages the team
+func (a *App) isTeamManager(userID, teamID uuid.UUID) bool {
+	var count int64
+	a.DB.Model(&models.TeamMember{}).
+		Where("team_id = ? AND user_id = ? AND role = ?", teamID, userID, models.TeamRoleManager).
+		Count(&count)
+	return count > 0
+}
+
+// applyCannedResponseRequest copies the optional fields of a request to a canned response
+func applyCannedResponseRequest(cr *models.CannedResponse, req CannedResponseRequest) {
+	cr.Shortcut = req.Shortcut
+	cr.Category = req.Category
+	if req.Scope != "" {
+		cr.Scope = req.Scope
+		cr.TeamID = req.TeamID
+		if req.Scope != models.CannedResponseScopeTeam {
+			cr.TeamID = nil
+		}
+	}
+	cr.MediaPath = req.MediaPath
+	cr.MediaMimeType = req.MediaMimeType
+	cr.MediaFilename = req.MediaFilename
+	cr.Buttons = make(models.JSONBArray, len(req.Buttons))
+	for i, btn := range req.Buttons {
+		id := btn.ID
+		if id == "" {
+			id = fmt.Sprintf("btn_%d", i+1)
+		}
+		cr.Buttons[i] = map[string]interface{}{"id": id, "title": strings.TrimSpace(btn.Title)}
+	}
+	cr.TemplateID = req.TemplateID
+	cr.TemplateParams = models.JSONB{}
+	for key, value := range req.TemplateParams {
+		cr.TemplateParams[key] = value
+	}
+}
+
+// validateCannedResponse checks the scope and the combination of content,
+// attachment, buttons and template of a canned response
+func validateCannedResponse(cr *models.CannedResponse) error {
+	switch cr.Scope {
+	case models.CannedResponseScopePersonal, models.CannedResponseScopeOrganization:
+	case models.CannedResponseScopeTeam:
+		if cr.TeamID == nil {
+			return fmt.Errorf("team_id is required for team canned responses")
+		}
+	default:
+		return fmt.Errorf("invalid scope: %s", cr.Scope)
+	}
+
+	if cr.TemplateID != nil && (cr.MediaPath != "" || len(cr.Buttons) > 0) {
+		return fmt.Errorf("a template canned response cannot have media or buttons")
+	}
+	if cr.MediaPath != "" && len(cr.Buttons) > 0 {
+		return fmt.Errorf("a canned response cannot have both media and buttons")
+	}
+	if cr.MediaPath == "" && (cr.MediaMimeType != "" || cr.MediaFilename != "") {
+		return fmt.Errorf("media_path is required for media canned responses")
+	}
+
+	if len(cr.Buttons) > maxCannedResponseButtons {
+		return fmt.Errorf("at most %d buttons are allowed", maxCannedResponseButtons)
+	}
+	if len(cr.Buttons) > 0 && strings.TrimSpace(cr.Content) == "" {
+		return fmt.Errorf("content is required for canned responses with buttons")
+	}
+	seen := make(map[string]bool, len(cr.Buttons))
+	for _, b := range cr.Buttons {
+		btn, _ := b.(map[string]interface{})
+		id, _ := btn["id"].(string)
+		title, _ := btn["title"].(string)
+		if title == "" || len([]rune(title)) > maxCannedResponseButtonTitle {
+			return fmt.Errorf("button titles must be 1 to %d characters", maxCannedResponseButtonTitle)
+		}
+		if seen[id] {
+			return fmt.Errorf("duplicate button id: %s", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+func uuidPtrEqual(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 func cannedResponseToResponse(cr models.CannedResponse) CannedResponseResponse {
-	return CannedResponseResponse{
-		ID:         cr.ID,
-		Name:       cr.Name,
-		Shortcut:   cr.Shortcut,
-		Content:    cr.Content,
-		Category:   cr.Category,
-		IsActive:   cr.IsActive,
-		UsageCount: cr.UsageCount,
-		CreatedAt:  cr.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:  cr.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	resp := CannedResponseResponse{
+		ID:            cr.ID,
+		Name:          cr.Name,
+		Shortcut:      cr.Shortcut,
+		Content:       cr.Content,
+		Category:      cr.Category,
+		IsActive:      cr.IsActive,
+		UsageCount:    cr.UsageCount,
+		Scope:         cr.Scope,
+		TeamID:        cr.TeamID,
+		CreatedByID:   cr.CreatedByID,
+		MediaPath:     cr.MediaPath,
+		MediaMimeType: cr.MediaMimeType,
+		MediaFilename: cr.MediaFilename,
+		Buttons:       cannedResponseButtons(cr),
+		TemplateID:    cr.TemplateID,
+		CreatedAt:     cr.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:     cr.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if resp.Scope == "" {
+		resp.Scope = models.CannedResponseScopeOrganization
+	}
+	if len(cr.TemplateParams) > 0 {
+		resp.TemplateParams = make(map[string]string, len(cr.TemplateParams))
+		for key, value := range cr.TemplateParams {
+			resp.TemplateParams[key] = fmt.Sprint(value)
+		}
+	}
+	return resp
+}
+
+// cannedResponseButtons returns the reply buttons of a canned response
+func cannedResponseButtons(cr models.CannedResponse) []ButtonContent {
+	buttons := make([]ButtonContent, 0, len(cr.Buttons))
+	for _, b := range cr.Buttons {
+		btn, ok := b.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := btn["id"].(string)
+		title, _ := btn["title"].(string)
+		buttons = append(buttons, ButtonContent{ID: id, Title: title})
 	}
+	return buttons
 }
@@ -1380,3 +1380,209 @@ func TestApp_IncrementCannedResponseUsage(t *testing.T) {
 		assert.Equal(t, fasthttp.StatusUnauthorized, testutil.GetResponseStatusCode(req))
 	})
 }
+
+func TestApp_CannedResponses_PersonalAndTeamScopes(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	agentRole := testutil.CreateAgentRole(t, app.DB, org.ID)
+	admin := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	agent := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&agentRole.ID))
+	otherAgent := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&agentRole.ID))
+
+	team := models.Team{OrganizationID: org.ID, Name: "Billing", IsActive: true}
+	require.NoError(t, app.DB.Create(&team).Error)
+	require.NoError(t, app.DB.Create(&models.TeamMember{TeamID: team.ID, UserID: agent.ID, Role: models.TeamRoleAgent}).Error)
+
+	create := func(userID uuid.UUID, body map[string]any) *fastglue.Request {
+		req := testutil.NewJSONRequest(t, body)
+		testutil.SetAuthContext(req, org.ID, userID)
+		require.NoError(t, app.CreateCannedResponse(req))
+		return req
+	}
+	list := func(userID uuid.UUID) []string {
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, org.ID, userID)
+		require.NoError(t, app.ListCannedResponses(req))
+		var resp struct {
+			CannedResponses []handlers.CannedResponseResponse `json:"canned_responses"`
+		}
+		testutil.ParseEnvelopeResponse(t, req, &resp)
+		names := make([]string, len(resp.CannedResponses))
+		for i, cr := range resp.CannedResponses {
+			names[i] = cr.Name
+		}
+		return names
+	}
+
+	// Agents without canned_responses:write create personal responses
+	req := create(agent.ID, map[string]any{"name": "My sign-off", "content": "Cheers, {{agent_name}}"})
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	var personal handlers.CannedResponseResponse
+	testutil.ParseEnvelopeResponse(t, req, &personal)
+	assert.Equal(t, models.CannedResponseScopePersonal, personal.Scope)
+
+	// ...and cannot share with the organization
+	req = create(agent.ID, map[string]any{"name": "Shared", "content": "Hi", "scope": "organization"})
+	assert.Equal(t, fasthttp.StatusForbidden, testutil.GetResponseStatusCode(req))
+
+	req = create(admin.ID, map[string]any{"name": "Refund policy", "content": "Refunds take 5 days", "scope": "team", "team_id": team.ID})
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	req = create(admin.ID, map[string]any{"name": "Welcome", "content": "Welcome!"})
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	var orgResponse handlers.CannedResponseResponse
+	testutil.ParseEnvelopeResponse(t, req, &orgResponse)
+	assert.Equal(t, models.CannedResponseScopeOrganization, orgResponse.Scope)
+
+	assert.ElementsMatch(t, []string{"My sign-off", "Refund policy", "Welcome"}, list(agent.ID))
+	assert.ElementsMatch(t, []string{"Welcome"}, list(otherAgent.ID))
+	assert.ElementsMatch(t, []string{"Refund policy", "Welcome"}, list(admin.ID))
+
+	// Agents can see but not edit organization responses
+	req = testutil.NewJSONRequest(t, map[string]any{"name": "Welcome", "content": "Changed", "is_active": true})
+	testutil.SetAuthContext(req, org.ID, agent.ID)
+	testutil.SetPathParam(req, "id", orgResponse.ID.String())
+	require.NoError(t, app.UpdateCannedResponse(req))
+	assert.Equal(t, fasthttp.StatusForbidden, testutil.GetResponseStatusCode(req))
+
+	// Personal responses are hidden from everyone else
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, admin.ID)
+	testutil.SetPathParam(req, "id", personal.ID.String())
+	require.NoError(t, app.GetCannedResponse(req))
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusNotFound, "Canned response not found")
+}
+
+func TestApp_CreateCannedResponse_Buttons(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"name":    "Satisfied?",
+		"content": "Did this solve your issue?",
+		"buttons": []map[string]string{{"title": "Yes"}, {"title": "No"}},
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.CreateCannedResponse(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var cr handlers.CannedResponseResponse
+	testutil.ParseEnvelopeResponse(t, req, &cr)
+	assert.Equal(t, []handlers.ButtonContent{{ID: "btn_1", Title: "Yes"}, {ID: "btn_2", Title: "No"}}, cr.Buttons)
+
+	req = testutil.NewJSONRequest(t, map[string]any{
+		"name":       "Invalid",
+		"content":    "Pick one",
+		"buttons":    []map[string]string{{"title": "A"}},
+		"media_path": "images/a.png",
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.CreateCannedResponse(req))
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "a canned response cannot have both media and buttons")
+}
+
+func TestApp_CreateCannedResponse_RejectsForeignMedia(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+
+	for _, path := range []string{
+		"images/customer-photo.jpg",                          // customer media
+		"orgs/" + uuid.New().String() + "/images/a.png",      // another organization's upload
+		"orgs/" + org.ID.String() + "/../../documents/a.pdf", // escapes the organization's uploads
+	} {
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"name":            "Brochure",
+			"media_path":      path,
+			"media_mime_type": "image/png",
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.CreateCannedResponse(req))
+		testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "media_path must be a file uploaded")
+	}
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"name":            "Brochure",
+		"media_path":      "orgs/" + org.ID.String() + "/images/a.png",
+		"media_mime_type": "image/png",
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.CreateCannedResponse(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+}
+
+func TestApp_IncrementCannedResponseUsage_RecordsUsage(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+	cr := createTestCannedResponse(t, app, org.ID, user.ID, "Greeting", "/greet", "Hello!", "general")
+
+	req := testutil.NewJSONRequest(t, map[string]any{"contact_id": contact.ID})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", cr.ID.String())
+	require.NoError(t, app.IncrementCannedResponseUsage(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var usage models.CannedResponseUsage
+	require.NoError(t, app.DB.Where("canned_response_id = ?", cr.ID).First(&usage).Error)
+	assert.Equal(t, user.ID, usage.UserID)
+	require.NotNil(t, usage.ContactID)
+	assert.Equal(t, contact.ID, *usage.ContactID)
+	assert.False(t, usage.Sent)
+
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.GetCannedResponseAnalytics(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var analytics struct {
+		Total      int64                              `json:"total"`
+		ByResponse []handlers.CannedResponseUsageStat `json:"by_response"`
+		ByAgent    []handlers.CannedResponseUsageStat `json:"by_agent"`
+	}
+	testutil.ParseEnvelopeResponse(t, req, &analytics)
+	assert.Equal(t, int64(1), analytics.Total)
+	require.Len(t, analytics.ByResponse, 1)
+	assert.Equal(t, cr.ID, analytics.ByResponse[0].ID)
+	require.Len(t, analytics.ByAgent, 1)
+	assert.Equal(t, user.ID, analytics.ByAgent[0].ID)
+}
+
+// --- SendCannedResponse Tests ---
+
+func TestApp_SendCannedResponse_TemplateNotOnContactAccount(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	templateAccount := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contactAccount := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(contactAccount.Name))
+
+	tmpl := createTestTemplateInDB(t, app, org.ID, templateAccount.Name, "welcome", "APPROVED")
+	cr := createTestCannedResponse(t, app, org.ID, user.ID, "Welcome", "/welcome", "", "general")
+	require.NoError(t, app.DB.Model(cr).Updates(map[string]any{
+		"template_id":     tmpl.ID,
+		"template_params": models.JSONB{"1": "{{contact.name}}"},
+	}).Error)
+
+	req := testutil.NewJSONRequest(t, map[string]any{"contact_id": contact.ID})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", cr.ID.String())
+
+	require.NoError(t, app.SendCannedResponse(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	assert.Contains(t, string(testutil.GetResponseBody(req)), "not available")
+}
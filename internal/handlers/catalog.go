@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/models"
@@ -54,6 +55,20 @@ type CatalogProductResponse struct {
 	IsActive      bool      `json:"is_active"`
 	CreatedAt     string    `json:"created_at"`
 	UpdatedAt     string    `json:"updated_at"`
+
+	// Commerce fields
+	Brand        string `json:"brand"`
+	Availability string `json:"availability"`
+	Condition    string `json:"condition"`
+	Inventory    *int   `json:"inventory,omitempty"`
+	SalePrice    int64  `json:"sale_price"`
+
+	// Sync and commerce review
+	SyncStatus             models.ProductSyncStatus `json:"sync_status"`
+	SyncError              string                   `json:"sync_error,omitempty"`
+	ReviewStatus           string                   `json:"review_status"`
+	ReviewRejectionReasons []string                 `json:"review_rejection_reasons"`
+	LastSyncedAt           *time.Time               `json:"last_synced_at,omitempty"`
 }
 
 // SyncCatalogsRequest represents the request body for syncing catalogs
@@ -386,6 +401,7 @@ func (a *App) CreateCatalogProduct(r *fastglue.Request) error {
 		ImageURL:       req.ImageURL,
 		RetailerID:     req.RetailerID,
 		IsActive:       true,
+		SyncStatus:     models.ProductSyncStatusSynced,
 	}
 
 	if err := a.DB.Create(&product).Error; err != nil {
@@ -490,6 +506,8 @@ func (a *App) UpdateCatalogProduct(r *fastglue.Request) error {
 	if req.RetailerID != "" {
 		product.RetailerID = req.RetailerID
 	}
+	product.SyncStatus = models.ProductSyncStatusSynced
+	product.SyncError = ""
 
 	if err := a.DB.Save(product).Error; err != nil {
 		a.Log.Error("Failed to save product", "error", err)
@@ -561,6 +579,10 @@ func catalogToResponse(c models.Catalog, productCount int) CatalogResponse {
 }
 
 func productToResponse(p models.CatalogProduct) CatalogProductResponse {
+	reasons := []string(p.ReviewRejectionReasons)
+	if reasons == nil {
+		reasons = []string{}
+	}
 	return CatalogProductResponse{
 		ID:            p.ID,
 		MetaProductID: p.MetaProductID,
@@ -574,5 +596,16 @@ func productToResponse(p models.CatalogProduct) CatalogProductResponse {
 		IsActive:      p.IsActive,
 		CreatedAt:     p.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedAt:     p.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+
+		Brand:                  p.Brand,
+		Availability:           p.Availability,
+		Condition:              p.Condition,
+		Inventory:              p.Inventory,
+		SalePrice:              p.SalePrice,
+		SyncStatus:             p.SyncStatus,
+		SyncError:              p.SyncError,
+		ReviewStatus:           p.ReviewStatus,
+		ReviewRejectionReasons: reasons,
+		LastSyncedAt:           p.LastSyncedAt,
 	}
 }
@@ -0,0 +1,541 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// maxFeedSize limits uploaded product feeds to 50MB
+const maxFeedSize = 50 << 20
+
+// maxFeedProducts limits how many products one feed may contain
+const maxFeedProducts = 50000
+
+// feedProduct is a product read from a CSV or XML feed. Prices are in cents.
+type feedProduct struct {
+	RetailerID   string
+	Name         string
+	Description  string
+	Brand        string
+	Price        int64
+	SalePrice    int64
+	Currency     string
+	URL          string
+	ImageURL     string
+	Availability string
+	Condition    string
+	Inventory    *int
+}
+
+// FeedRowError is a feed row that could not be imported
+type FeedRowError struct {
+	Row        int    `json:"row"` // 1-based, counting the CSV header or the XML item
+	RetailerID string `json:"retailer_id,omitempty"`
+	Message    string `json:"message"`
+}
+
+// feedColumnAliases maps CSV columns and XML elements to Meta's feed field names
+var feedColumnAliases = map[string]string{
+	"id":                           "id",
+	"retailer_id":                  "id",
+	"sku":                          "id",
+	"title":                        "title",
+	"name":                         "title",
+	"description":                  "description",
+	"brand":                        "brand",
+	"price":                        "price",
+	"sale_price":                   "sale_price",
+	"currency":                     "currency",
+	"link":                         "link",
+	"url":                          "link",
+	"image_link":                   "image_link",
+	"image_url":                    "image_link",
+	"image":                        "image_link",
+	"availability":                 "availability",
+	"condition":                    "condition",
+	"inventory":                    "inventory",
+	"quantity":                     "inventory",
+	"quantity_to_sell_on_facebook": "inventory",
+}
+
+var feedAvailabilities = map[string]bool{
+	"in stock":            true,
+	"out of stock":        true,
+	"preorder":            true,
+	"available for order": true,
+	"discontinued":        true,
+}
+
+var feedConditions = map[string]bool{
+	"new":         true,
+	"refurbished": true,
+	"used":        true,
+}
+
+// parseProductFeed reads a CSV feed or an RSS/Atom XML feed (Google Merchant
+// "g:" elements). Rows with errors are reported and left out.
+func parseProductFeed(data []byte, defaultCurrency string) ([]feedProduct, []FeedRowError, error) {
+	var rows []map[string]string
+	var err error
+	if isXMLFeed(data) {
+		rows, err = readXMLFeed(data)
+	} else {
+		rows, err = readCSVFeed(data)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) > maxFeedProducts {
+		return nil, nil, fmt.Errorf("feed has %d products, the limit is %d", len(rows), maxFeedProducts)
+	}
+
+	// Rows are numbered like a spreadsheet, so CSV data starts at row 2
+	firstRow := 2
+	if isXMLFeed(data) {
+		firstRow = 1
+	}
+
+	var products []feedProduct
+	var rowErrors []FeedRowError
+	seen := make(map[string]int)
+	for i, fields := range rows {
+		row := firstRow + i
+
+		product, err := feedProductFromFields(fields, defaultCurrency)
+		if err != nil {
+			rowErrors = append(rowErrors, FeedRowError{Row: row, RetailerID: fields["id"], Message: err.Error()})
+			continue
+		}
+		if first, ok := seen[product.RetailerID]; ok {
+			rowErrors = append(rowErrors, FeedRowError{Row: row, RetailerID: product.RetailerID, Message: fmt.Sprintf("duplicate id, first used in row %d", first)})
+			continue
+		}
+		seen[product.RetailerID] = row
+		products = append(products, product)
+	}
+	return products, rowErrors, nil
+}
+
+func isXMLFeed(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))), []byte("<"))
+}
+
+// readCSVFeed returns one map per row keyed by Meta's feed field names. Comma,
+// tab and semicolon separators are detected from the header.
+func readCSVFeed(data []byte) ([]map[string]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	headerLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		headerLine = data[:i]
+	}
+	switch {
+	case bytes.Count(headerLine, []byte("\t")) > bytes.Count(headerLine, []byte(",")):
+		reader.Comma = '\t'
+	case bytes.Count(headerLine, []byte(";")) > bytes.Count(headerLine, []byte(",")):
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, h := range header {
+		columns[i] = feedColumnAliases[normalizeFeedColumn(h)]
+	}
+
+	var rows []map[string]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		fields := make(map[string]string)
+		for i, value := range record {
+			if i < len(columns) && columns[i] != "" {
+				fields[columns[i]] = strings.TrimSpace(value)
+			}
+		}
+		if len(fields) > 0 && !allEmpty(fields) {
+			rows = append(rows, fields)
+		}
+	}
+	return rows, nil
+}
+
+// readXMLFeed returns one map per <item> (RSS) or <entry> (Atom), keyed by the
+// elements' local names so "g:price" and "price" read the same.
+func readXMLFeed(data []byte) ([]map[string]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+
+	var rows []map[string]string
+	var current map[string]string
+	var field string
+	var text strings.Builder
+	depth := 0 // Element depth inside the current item
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML feed: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if current == nil {
+				if name == "item" || name == "entry" {
+					current = make(map[string]string)
+					depth = 0
+				}
+				continue
+			}
+			depth++
+			// Only direct children are product fields; g:shipping also has a g:price
+			if depth != 1 {
+				continue
+			}
+			field = feedColumnAliases[name]
+			text.Reset()
+			// Atom links carry the URL in an attribute
+			if field == "link" {
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "href" {
+						text.WriteString(attr.Value)
+					}
+				}
+			}
+		case xml.CharData:
+			if current != nil && depth == 1 && field != "" {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if current == nil {
+				continue
+			}
+			if depth == 0 {
+				if !allEmpty(current) {
+					rows = append(rows, current)
+				}
+				current = nil
+				continue
+			}
+			// The first value wins, e.g. the g:id over a later plain id
+			if _, ok := current[field]; depth == 1 && field != "" && !ok {
+				current[field] = strings.TrimSpace(text.String())
+			}
+			if depth == 1 {
+				field = ""
+			}
+			depth--
+		}
+	}
+	return rows, nil
+}
+
+// feedProductFromFields validates a feed row and converts it to a product
+func feedProductFromFields(fields map[string]string, defaultCurrency string) (feedProduct, error) {
+	p := feedProduct{
+		RetailerID:   fields["id"],
+		Name:         fields["title"],
+		Description:  fields["description"],
+		Brand:        fields["brand"],
+		URL:          fields["link"],
+		ImageURL:     fields["image_link"],
+		Availability: normalizeFeedValue(fields["availability"]),
+		Condition:    normalizeFeedValue(fields["condition"]),
+	}
+	if p.RetailerID == "" {
+		return p, fmt.Errorf("id is required")
+	}
+	if len(p.RetailerID) > 100 {
+		return p, fmt.Errorf("id is longer than 100 characters")
+	}
+	if p.Name == "" {
+		return p, fmt.Errorf("title is required")
+	}
+
+	currency := strings.ToUpper(fields["currency"])
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	price, priceCurrency, err := parseFeedPrice(fields["price"])
+	if err != nil {
+		return p, fmt.Errorf("invalid price: %w", err)
+	}
+	if price <= 0 {
+		return p, fmt.Errorf("price is required")
+	}
+	if priceCurrency != "" {
+		currency = priceCurrency
+	}
+	p.Price = price
+	p.Currency = currency
+	if currency == "" {
+		p.Currency = "USD"
+	}
+
+	if fields["sale_price"] != "" {
+		salePrice, saleCurrency, err := parseFeedPrice(fields["sale_price"])
+		if err != nil {
+			return p, fmt.Errorf("invalid sale_price: %w", err)
+		}
+		if saleCurrency != "" && saleCurrency != p.Currency {
+			return p, fmt.Errorf("sale_price currency %s differs from price currency %s", saleCurrency, p.Currency)
+		}
+		if salePrice >= p.Price {
+			return p, fmt.Errorf("sale_price must be lower than price")
+		}
+		p.SalePrice = salePrice
+	}
+
+	if p.Availability == "" {
+		p.Availability = "in stock"
+	} else if !feedAvailabilities[p.Availability] {
+		return p, fmt.Errorf("invalid availability %q", fields["availability"])
+	}
+	if p.Condition == "" {
+		p.Condition = "new"
+	} else if !feedConditions[p.Condition] {
+		return p, fmt.Errorf("invalid condition %q", fields["condition"])
+	}
+
+	if fields["inventory"] != "" {
+		inventory, err := strconv.Atoi(fields["inventory"])
+		if err != nil || inventory < 0 {
+			return p, fmt.Errorf("invalid inventory %q", fields["inventory"])
+		}
+		p.Inventory = &inventory
+	}
+	return p, nil
+}
+
+// parseFeedPrice reads feed prices such as "12.50 USD", "USD 12.50", "$1,299",
+// "12,50 EUR" or "1.299,00 EUR" and returns cents and the currency, if one was given.
+func parseFeedPrice(value string) (int64, string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, "", nil
+	}
+
+	var currency string
+	var amount string
+	for _, part := range strings.Fields(value) {
+		if isCurrencyCode(part) {
+			currency = strings.ToUpper(part)
+			continue
+		}
+		amount += part
+	}
+	amount = strings.TrimLeft(amount, "$€£¥₹")
+
+	amount, ok := normalizeFeedAmount(amount)
+	if !ok {
+		return 0, "", fmt.Errorf("%q is not a price", value)
+	}
+
+	f, err := strconv.ParseFloat(amount, 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, "", fmt.Errorf("%q is not a price", value)
+	}
+	return int64(math.Round(f * 100)), currency, nil
+}
+
+// normalizeFeedAmount rewrites an amount with "," or "." separators to use a
+// "." decimal point. The last separator is the decimal one, unless it is
+// repeated ("1,299,000") or is a lone comma before three digits ("1,299"),
+// which group thousands. A lone "." before three digits ("1.299") is
+// ambiguous and rejected, as are malformed groups and more than two decimals.
+func normalizeFeedAmount(amount string) (string, bool) {
+	integer, fraction := amount, ""
+	if i := strings.LastIndexAny(amount, ",."); i >= 0 {
+		sep := amount[i : i+1]
+		lone := !strings.ContainsAny(amount[:i], ",.")
+		switch {
+		case strings.Count(amount, sep) > 1, lone && len(amount)-i-1 == 3 && sep == ",":
+			// Only thousands separators
+		case lone && len(amount)-i-1 == 3:
+			return "", false
+		default:
+			integer, fraction = amount[:i], amount[i+1:]
+		}
+	}
+	if len(fraction) > 2 || (strings.Contains(integer, ",") && strings.Contains(integer, ".")) {
+		return "", false
+	}
+
+	groups := strings.FieldsFunc(integer, func(r rune) bool { return r == ',' || r == '.' })
+	if separators := strings.Count(integer, ",") + strings.Count(integer, "."); separators > 0 {
+		if separators != len(groups)-1 || len(groups[0]) > 3 || strings.HasPrefix(groups[0], "0") {
+			return "", false
+		}
+		for _, g := range groups[1:] {
+			if len(g) != 3 {
+				return "", false
+			}
+		}
+	}
+
+	normalized := strings.Join(groups, "")
+	if fraction != "" {
+		normalized += "." + fraction
+	}
+	return normalized, true
+}
+
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeFeedColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\xef\xbb\xbf")))
+	name = strings.TrimPrefix(name, "g:")
+	return strings.ReplaceAll(name, " ", "_")
+}
+
+// normalizeFeedValue makes "In_Stock" and "in stock" compare equal
+func normalizeFeedValue(value string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(value)), "_", " ")
+}
+
+func allEmpty(fields map[string]string) bool {
+	for _, v := range fields {
+		if v != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// FeedDiffItem is a product the feed creates, changes or removes
+type FeedDiffItem struct {
+	RetailerID string   `json:"retailer_id"`
+	Name       string   `json:"name"`
+	Changes    []string `json:"changes,omitempty"` // Changed fields of updated products
+}
+
+// feedDiff is what applying a feed changes in a catalog
+type feedDiff struct {
+	Create    []feedProduct
+	Update    []feedProduct
+	Changes   map[string][]string // By retailer ID
+	Delete    []models.CatalogProduct
+	Unchanged int
+}
+
+// diffProductFeed compares a feed with the catalog's products by retailer ID.
+// Products whose last sync failed are sent again even when unchanged. Products
+// in rows with errors are still in the feed, so they are not deleted.
+func diffProductFeed(existing []models.CatalogProduct, feed []feedProduct, rowErrors []FeedRowError, deleteMissing bool) *feedDiff {
+	byRetailerID := make(map[string]*models.CatalogProduct, len(existing))
+	for i := range existing {
+		if existing[i].RetailerID != "" {
+			byRetailerID[existing[i].RetailerID] = &existing[i]
+		}
+	}
+
+	diff := &feedDiff{Changes: make(map[string][]string)}
+	inFeed := make(map[string]bool, len(feed)+len(rowErrors))
+	for _, e := range rowErrors {
+		inFeed[e.RetailerID] = true
+	}
+	for _, p := range feed {
+		inFeed[p.RetailerID] = true
+		current, ok := byRetailerID[p.RetailerID]
+		if !ok {
+			diff.Create = append(diff.Create, p)
+			continue
+		}
+		changes := feedProductChanges(current, &p)
+		if len(changes) == 0 && current.SyncStatus == models.ProductSyncStatusFailed {
+			changes = []string{"sync_retry"}
+		}
+		if len(changes) == 0 {
+			diff.Unchanged++
+			continue
+		}
+		diff.Update = append(diff.Update, p)
+		diff.Changes[p.RetailerID] = changes
+	}
+
+	if deleteMissing {
+		for _, p := range existing {
+			if p.RetailerID != "" && !inFeed[p.RetailerID] {
+				diff.Delete = append(diff.Delete, p)
+			}
+		}
+	}
+	return diff
+}
+
+// feedProductChanges lists the fields the feed changes on a product
+func feedProductChanges(current *models.CatalogProduct, p *feedProduct) []string {
+	var changes []string
+	check := func(field string, changed bool) {
+		if changed {
+			changes = append(changes, field)
+		}
+	}
+	check("name", current.Name != p.Name)
+	check("description", current.Description != p.Description)
+	check("brand", current.Brand != p.Brand)
+	check("price", current.Price != p.Price)
+	check("sale_price", current.SalePrice != p.SalePrice)
+	check("currency", !strings.EqualFold(current.Currency, p.Currency))
+	check("url", current.URL != p.URL)
+	check("image_url", current.ImageURL != p.ImageURL)
+	check("availability", current.Availability != p.Availability)
+	check("condition", current.Condition != p.Condition)
+	check("inventory", !sameInventory(current.Inventory, p.Inventory))
+	check("is_active", !current.IsActive)
+	return changes
+}
+
+func sameInventory(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// apply copies the feed's values onto a product
+func (p *feedProduct) apply(product *models.CatalogProduct) {
+	product.RetailerID = p.RetailerID
+	product.Name = p.Name
+	product.Description = p.Description
+	product.Brand = p.Brand
+	product.Price = p.Price
+	product.SalePrice = p.SalePrice
+	product.Currency = p.Currency
+	product.URL = p.URL
+	product.ImageURL = p.ImageURL
+	product.Availability = p.Availability
+	product.Condition = p.Condition
+	product.Inventory = p.Inventory
+	product.IsActive = true
+}
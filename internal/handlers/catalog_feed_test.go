@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProductFeed_CSV(t *testing.T) {
+	feed := "id;title;description;price;sale_price;availability;condition;link;image_link;brand;quantity_to_sell_on_facebook\n" +
+		"SKU-1;Blue Shirt;Cotton shirt;12,50 EUR;9,99 EUR;In_Stock;new;https://shop.example/1;https://shop.example/1.jpg;Acme;7\n" +
+		"SKU-2;Red Shirt;;15.00 EUR;;out of stock;;https://shop.example/2;https://shop.example/2.jpg;Acme;\n" +
+		";No id;;1.00 EUR;;;;;;;\n" +
+		"SKU-3;Green Shirt;;;;;;;;;\n" +
+		"SKU-1;Duplicate;;1.00 EUR;;;;;;;\n"
+
+	products, rowErrors, err := parseProductFeed([]byte(feed), "")
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+
+	shirt := products[0]
+	assert.Equal(t, "SKU-1", shirt.RetailerID)
+	assert.Equal(t, int64(1250), shirt.Price)
+	assert.Equal(t, int64(999), shirt.SalePrice)
+	assert.Equal(t, "EUR", shirt.Currency)
+	assert.Equal(t, "in stock", shirt.Availability)
+	assert.Equal(t, "https://shop.example/1.jpg", shirt.ImageURL)
+	require.NotNil(t, shirt.Inventory)
+	assert.Equal(t, 7, *shirt.Inventory)
+
+	assert.Equal(t, "out of stock", products[1].Availability)
+	assert.Equal(t, "new", products[1].Condition, "condition defaults to new")
+	assert.Nil(t, products[1].Inventory)
+
+	require.Len(t, rowErrors, 3)
+	assert.Equal(t, 4, rowErrors[0].Row)
+	assert.Contains(t, rowErrors[0].Message, "id is required")
+	assert.Equal(t, "SKU-3", rowErrors[1].RetailerID)
+	assert.Contains(t, rowErrors[1].Message, "price is required")
+	assert.Contains(t, rowErrors[2].Message, "duplicate id, first used in row 2")
+}
+
+func TestParseProductFeed_RSS(t *testing.T) {
+	feed := `<?xml version="1.0"?>
+<rss version="2.0" xmlns:g="http://base.google.com/ns/1.0">
+  <channel>
+    <title>Store</title>
+    <link>https://shop.example</link>
+    <item>
+      <g:id>SKU-1</g:id>
+      <g:title>Blue Shirt</g:title>
+      <g:description>Cotton &amp; linen</g:description>
+      <g:link>https://shop.example/1</g:link>
+      <g:image_link>https://shop.example/1.jpg</g:image_link>
+      <g:price>1,299.00 USD</g:price>
+      <g:availability>in stock</g:availability>
+      <g:shipping>
+        <g:country>US</g:country>
+        <g:price>5.00 USD</g:price>
+      </g:shipping>
+    </item>
+    <item>
+      <g:id>SKU-2</g:id>
+      <g:title>Broken</g:title>
+      <g:price>10 USD</g:price>
+      <g:condition>like new</g:condition>
+    </item>
+  </channel>
+</rss>`
+
+	products, rowErrors, err := parseProductFeed([]byte(feed), "")
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, "Blue Shirt", products[0].Name)
+	assert.Equal(t, "Cotton & linen", products[0].Description)
+	assert.Equal(t, int64(129900), products[0].Price, "the shipping price is ignored")
+	assert.Equal(t, "USD", products[0].Currency)
+
+	require.Len(t, rowErrors, 1)
+	assert.Equal(t, 2, rowErrors[0].Row)
+	assert.Contains(t, rowErrors[0].Message, "invalid condition")
+}
+
+func TestParseProductFeed_Atom(t *testing.T) {
+	feed := `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:g="http://base.google.com/ns/1.0">
+  <entry>
+    <g:id>SKU-9</g:id>
+    <title>Mug</title>
+    <link href="https://shop.example/mug"/>
+    <g:price>8</g:price>
+  </entry>
+</feed>`
+
+	products, rowErrors, err := parseProductFeed([]byte(feed), "INR")
+	require.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	require.Len(t, products, 1)
+	assert.Equal(t, "https://shop.example/mug", products[0].URL)
+	assert.Equal(t, int64(800), products[0].Price)
+	assert.Equal(t, "INR", products[0].Currency, "the default currency is used")
+}
+
+func TestParseFeedPrice(t *testing.T) {
+	tests := []struct {
+		value    string
+		cents    int64
+		currency string
+	}{
+		{"12.50 USD", 1250, "USD"},
+		{"USD 12.5", 1250, "USD"},
+		{"$1,299", 129900, ""},
+		{"1.299,00 EUR", 129900, "EUR"},
+		{"12,5 EUR", 1250, "EUR"},
+		{"$1,299.99", 129999, ""},
+		{"1,234,567", 123456700, ""},
+		{"12,50 EUR", 1250, "EUR"},
+		{"0.1", 10, ""},
+	}
+	for _, tt := range tests {
+		cents, currency, err := parseFeedPrice(tt.value)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.cents, cents, tt.value)
+		assert.Equal(t, tt.currency, currency, tt.value)
+	}
+
+	for _, value := range []string{"free", "1.299 EUR", "1,2,3", "1,234.567", "12.345,678", ",299", "1,,299"} {
+		_, _, err := parseFeedPrice(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestDiffProductFeed(t *testing.T) {
+	inventory := 5
+	existing := []models.CatalogProduct{
+		{RetailerID: "SKU-1", Name: "Blue Shirt", Price: 1250, Currency: "USD", Availability: "in stock", Condition: "new", IsActive: true, SyncStatus: models.ProductSyncStatusSynced},
+		{RetailerID: "SKU-2", Name: "Red Shirt", Price: 1500, Currency: "USD", Availability: "in stock", Condition: "new", IsActive: true, Inventory: &inventory, SyncStatus: models.ProductSyncStatusSynced},
+		{RetailerID: "SKU-3", Name: "Old Shirt", Price: 900, Currency: "USD", Availability: "in stock", Condition: "new", IsActive: true, SyncStatus: models.ProductSyncStatusFailed},
+		{RetailerID: "SKU-4", Name: "Gone", Price: 100, Currency: "USD", IsActive: true},
+		{Name: "Manual product without SKU", Price: 100, IsActive: true},
+	}
+	feed := []feedProduct{
+		{RetailerID: "SKU-1", Name: "Blue Shirt", Price: 1250, Currency: "usd", Availability: "in stock", Condition: "new"},
+		{RetailerID: "SKU-2", Name: "Red Shirt", Price: 1400, Currency: "USD", Availability: "in stock", Condition: "new"},
+		{RetailerID: "SKU-3", Name: "Old Shirt", Price: 900, Currency: "USD", Availability: "in stock", Condition: "new"},
+		{RetailerID: "SKU-5", Name: "New Shirt", Price: 2000, Currency: "USD", Availability: "in stock", Condition: "new"},
+	}
+
+	diff := diffProductFeed(existing, feed, nil, false)
+	assert.Equal(t, 1, diff.Unchanged)
+	require.Len(t, diff.Create, 1)
+	assert.Equal(t, "SKU-5", diff.Create[0].RetailerID)
+	require.Len(t, diff.Update, 2)
+	assert.Equal(t, []string{"price", "inventory"}, diff.Changes["SKU-2"])
+	assert.Equal(t, []string{"sync_retry"}, diff.Changes["SKU-3"], "failed products are sent again")
+	assert.Empty(t, diff.Delete)
+
+	diff = diffProductFeed(existing, feed, nil, true)
+	require.Len(t, diff.Delete, 1, "products without a SKU are never deleted")
+	assert.Equal(t, "SKU-4", diff.Delete[0].RetailerID)
+
+	rowErrors := []FeedRowError{{Row: 6, RetailerID: "SKU-4", Message: "invalid price"}}
+	diff = diffProductFeed(existing, feed, rowErrors, true)
+	assert.Empty(t, diff.Delete, "products in rows with errors are not deleted")
+}
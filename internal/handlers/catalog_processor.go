@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/queue"
+)
+
+// catalogBatchSize limits how many scheduled updates and pending batches are handled per tick
+const catalogBatchSize = 20
+
+// catalogLease names the leader lease of the catalog processor
+const catalogLease = "catalog"
+
+// CatalogProcessor applies scheduled product updates once due and settles
+// product batches after Meta has processed them. With several replicas only
+// the one holding the leader lease runs.
+type CatalogProcessor struct {
+	app      *App
+	interval time.Duration
+	lease    *queue.Lease
+	stopCh   chan struct{}
+}
+
+// NewCatalogProcessor creates a new catalog processor
+func NewCatalogProcessor(app *App, interval time.Duration) *CatalogProcessor {
+	return &CatalogProcessor{
+		app:      app,
+		interval: interval,
+		lease:    app.newProcessorLease(catalogLease, 2*interval),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the catalog processing loop
+func (p *CatalogProcessor) Start(ctx context.Context) {
+	p.app.Log.Info("Catalog processor started", "interval", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	defer p.app.releaseLease(p.lease, catalogLease)
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.app.Log.Info("Catalog processor stopped by context")
+			return
+		case <-p.stopCh:
+			p.app.Log.Info("Catalog processor stopped")
+			return
+		case <-ticker.C:
+			if p.app.holdLease(p.lease, catalogLease) {
+				p.applyDueUpdates()
+				p.checkPendingBatches(ctx)
+			}
+		}
+	}
+}
+
+// Stop stops the catalog processor
+func (p *CatalogProcessor) Stop() {
+	close(p.stopCh)
+}
+
+// applyDueUpdates applies every scheduled update whose time has come
+func (p *CatalogProcessor) applyDueUpdates() {
+	var updates []models.CatalogScheduledUpdate
+	if err := p.app.DB.Where("status = ? AND scheduled_at <= ?", models.CatalogScheduledUpdateStatusScheduled, time.Now()).
+		Order("scheduled_at ASC").
+		Limit(catalogBatchSize).
+		Find(&updates).Error; err != nil {
+		p.app.Log.Error("Failed to load due catalog updates", "error", err)
+		return
+	}
+
+	for i := range updates {
+		p.app.applyScheduledUpdate(&updates[i])
+	}
+}
+
+// checkPendingBatches asks Meta about batches that are still processing
+func (p *CatalogProcessor) checkPendingBatches(ctx context.Context) {
+	var batches []models.CatalogBatch
+	if err := p.app.DB.Where("status = ?", models.CatalogBatchStatusProcessing).
+		Order("updated_at ASC").
+		Limit(catalogBatchSize).
+		Find(&batches).Error; err != nil {
+		p.app.Log.Error("Failed to load pending catalog batches", "error", err)
+		return
+	}
+
+	for i := range batches {
+		p.app.checkCatalogBatch(ctx, &batches[i])
+		// Batches still processing go to the back of the queue
+		p.app.DB.Model(&batches[i]).Where("status = ?", models.CatalogBatchStatusProcessing).Update("updated_at", time.Now())
+	}
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// ProductSetRequest represents the request body for creating/updating a product set
+type ProductSetRequest struct {
+	Name        string                 `json:"name"`
+	RetailerIDs []string               `json:"retailer_ids"` // Products in the set, by SKU
+	Filter      map[string]interface{} `json:"filter"`       // Meta filter rule, instead of retailer_ids
+}
+
+// ProductSetResponse represents the API response for a product set
+type ProductSetResponse struct {
+	ID               uuid.UUID          `json:"id"`
+	CatalogID        uuid.UUID          `json:"catalog_id"`
+	MetaProductSetID string             `json:"meta_product_set_id"`
+	Name             string             `json:"name"`
+	RetailerIDs      models.StringArray `json:"retailer_ids"`
+	Filter           models.JSONB       `json:"filter"`
+	ProductCount     int                `json:"product_count"`
+	CreatedAt        string             `json:"created_at"`
+	UpdatedAt        string             `json:"updated_at"`
+}
+
+// ListProductSets returns the product sets of a catalog
+func (a *App) ListProductSets(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	catalogID, err := parsePathUUID(r, "id", "catalog")
+	if err != nil {
+		return nil
+	}
+
+	if _, err := findByIDAndOrg[models.Catalog](a.DB, r, catalogID, orgID, "Catalog"); err != nil {
+		return nil
+	}
+
+	var sets []models.CatalogProductSet
+	if err := a.DB.Where("catalog_id = ?", catalogID).Order("name ASC").Find(&sets).Error; err != nil {
+		a.Log.Error("Failed to list product sets", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list product sets", nil, "")
+	}
+
+	result := make([]ProductSetResponse, len(sets))
+	for i, s := range sets {
+		result[i] = productSetToResponse(s)
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"product_sets": result,
+	})
+}
+
+// CreateProductSet creates a product set in Meta and stores it locally
+func (a *App) CreateProductSet(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	catalogID, err := parsePathUUID(r, "id", "catalog")
+	if err != nil {
+		return nil
+	}
+
+	var req ProductSetRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if req.Name == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "name is required", nil, "")
+	}
+	if len(req.RetailerIDs) == 0 && len(req.Filter) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "retailer_ids or filter is required", nil, "")
+	}
+
+	catalog, err := findByIDAndOrg[models.Catalog](a.DB, r, catalogID, orgID, "Catalog")
+	if err != nil {
+		return nil
+	}
+
+	waAccount, err := a.catalogAccount(catalog)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, err.Error(), nil, "")
+	}
+
+	set := models.CatalogProductSet{
+		OrganizationID: orgID,
+		CatalogID:      catalogID,
+		Name:           req.Name,
+		RetailerIDs:    models.StringArray(req.RetailerIDs),
+		Filter:         models.JSONB(req.Filter),
+	}
+	a.countProductSet(&set)
+
+	metaID, err := a.WhatsApp.CreateProductSet(context.Background(), waAccount, catalog.MetaCatalogID, set.Name, productSetFilter(&set))
+	if err != nil {
+		a.Log.Error("Failed to create product set in Meta", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create product set", nil, "")
+	}
+	set.MetaProductSetID = metaID
+
+	if err := a.DB.Create(&set).Error; err != nil {
+		a.Log.Error("Failed to save product set", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save product set", nil, "")
+	}
+
+	return r.SendEnvelope(productSetToResponse(set))
+}
+
+// UpdateProductSet renames a product set or changes its products
+func (a *App) UpdateProductSet(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "product set")
+	if err != nil {
+		return nil
+	}
+
+	set, err := findByIDAndOrg[models.CatalogProductSet](a.DB, r, id, orgID, "Product set")
+	if err != nil {
+		return nil
+	}
+
+	var req ProductSetRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	var catalog models.Catalog
+	if err := a.DB.Where("id = ?", set.CatalogID).First(&catalog).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Catalog not found", nil, "")
+	}
+
+	waAccount, err := a.catalogAccount(&catalog)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, err.Error(), nil, "")
+	}
+
+	if req.Name != "" {
+		set.Name = req.Name
+	}
+	filterChanged := req.RetailerIDs != nil || req.Filter != nil
+	if req.RetailerIDs != nil {
+		set.RetailerIDs = models.StringArray(req.RetailerIDs)
+		set.Filter = models.JSONB{}
+	}
+	if req.Filter != nil {
+		set.Filter = models.JSONB(req.Filter)
+	}
+	if len(set.RetailerIDs) == 0 && len(set.Filter) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "retailer_ids or filter is required", nil, "")
+	}
+
+	var filter map[string]interface{}
+	if filterChanged {
+		filter = productSetFilter(set)
+		a.countProductSet(set)
+	}
+	if err := a.WhatsApp.UpdateProductSet(context.Background(), waAccount, set.MetaProductSetID, req.Name, filter); err != nil {
+		a.Log.Error("Failed to update product set in Meta", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update product set", nil, "")
+	}
+
+	if err := a.DB.Save(set).Error; err != nil {
+		a.Log.Error("Failed to save product set", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save product set", nil, "")
+	}
+
+	return r.SendEnvelope(productSetToResponse(*set))
+}
+
+// DeleteProductSet deletes a product set from Meta and locally. Its products stay in the catalog.
+func (a *App) DeleteProductSet(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "product set")
+	if err != nil {
+		return nil
+	}
+
+	set, err := findByIDAndOrg[models.CatalogProductSet](a.DB, r, id, orgID, "Product set")
+	if err != nil {
+		return nil
+	}
+
+	var catalog models.Catalog
+	if err := a.DB.Where("id = ?", set.CatalogID).First(&catalog).Error; err == nil && set.MetaProductSetID != "" {
+		if waAccount, err := a.catalogAccount(&catalog); err == nil {
+			if err := a.WhatsApp.DeleteProductSet(context.Background(), waAccount, set.MetaProductSetID); err != nil {
+				a.Log.Error("Failed to delete product set from Meta", "error", err)
+				// Continue with local deletion
+			}
+		}
+	}
+
+	if err := a.DB.Delete(set).Error; err != nil {
+		a.Log.Error("Failed to delete product set", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete product set", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]string{"message": "Product set deleted"})
+}
+
+// pullProductSets stores the catalog's product sets from Meta and returns how many it has
+func (a *App) pullProductSets(ctx context.Context, catalog *models.Catalog, waAccount *whatsapp.Account) (int, error) {
+	metaSets, err := a.WhatsApp.ListProductSets(ctx, waAccount, catalog.MetaCatalogID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, ms := range metaSets {
+		var filter models.JSONB
+		if ms.Filter != "" {
+			_ = json.Unmarshal([]byte(ms.Filter), &filter)
+		}
+
+		var set models.CatalogProductSet
+		err := a.DB.Where("catalog_id = ? AND meta_product_set_id = ?", catalog.ID, ms.ID).First(&set).Error
+		if err != nil {
+			set = models.CatalogProductSet{
+				OrganizationID:   catalog.OrganizationID,
+				CatalogID:        catalog.ID,
+				MetaProductSetID: ms.ID,
+				RetailerIDs:      models.StringArray{},
+			}
+		}
+		set.Name = ms.Name
+		set.ProductCount = ms.ProductCount
+		// Sets made here keep their retailer IDs; Meta returns them as an is_any filter
+		if len(set.RetailerIDs) == 0 && filter != nil {
+			set.Filter = filter
+		}
+		if err := a.DB.Save(&set).Error; err != nil {
+			a.Log.Error("Failed to save synced product set", "error", err, "meta_id", ms.ID)
+		}
+	}
+
+	return len(metaSets), nil
+}
+
+// productSetFilter returns the Meta filter rule of a product set
+func productSetFilter(set *models.CatalogProductSet) map[string]interface{} {
+	if len(set.Filter) > 0 {
+		return set.Filter
+	}
+	return map[string]interface{}{
+		"retailer_id": map[string]interface{}{"is_any": []string(set.RetailerIDs)},
+	}
+}
+
+// countProductSet counts the catalog's products in a set defined by retailer IDs.
+// Sets with a filter are counted by Meta and updated on the next product sync.
+func (a *App) countProductSet(set *models.CatalogProductSet) {
+	if len(set.Filter) > 0 || len(set.RetailerIDs) == 0 {
+		set.ProductCount = 0
+		return
+	}
+	var count int64
+	a.DB.Model(&models.CatalogProduct{}).
+		Where("catalog_id = ? AND retailer_id IN ?", set.CatalogID, []string(set.RetailerIDs)).
+		Count(&count)
+	set.ProductCount = int(count)
+}
+
+func productSetToResponse(s models.CatalogProductSet) ProductSetResponse {
+	retailerIDs := s.RetailerIDs
+	if retailerIDs == nil {
+		retailerIDs = models.StringArray{}
+	}
+	filter := s.Filter
+	if filter == nil {
+		filter = models.JSONB{}
+	}
+	return ProductSetResponse{
+		ID:               s.ID,
+		CatalogID:        s.CatalogID,
+		MetaProductSetID: s.MetaProductSetID,
+		Name:             s.Name,
+		RetailerIDs:      retailerIDs,
+		Filter:           filter,
+		ProductCount:     s.ProductCount,
+		CreatedAt:        s.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:        s.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// ProductUpdate changes one product's price, sale price, inventory or
+// availability. Fields left out are not changed.
+type ProductUpdate struct {
+	RetailerID   string  `json:"retailer_id"`
+	Price        *int64  `json:"price,omitempty"`      // In cents
+	SalePrice    *int64  `json:"sale_price,omitempty"` // In cents, 0 ends the sale
+	Inventory    *int    `json:"inventory,omitempty"`
+	Availability *string `json:"availability,omitempty"`
+}
+
+// ScheduledUpdateRequest represents the request body for scheduling product updates
+type ScheduledUpdateRequest struct {
+	Name        string          `json:"name"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+	Updates     []ProductUpdate `json:"updates"`
+}
+
+// ScheduledUpdateResponse represents the API response for a scheduled update
+type ScheduledUpdateResponse struct {
+	ID          uuid.UUID                           `json:"id"`
+	CatalogID   uuid.UUID                           `json:"catalog_id"`
+	Name        string                              `json:"name"`
+	Updates     []ProductUpdate                     `json:"updates"`
+	ScheduledAt time.Time                           `json:"scheduled_at"`
+	Status      models.CatalogScheduledUpdateStatus `json:"status"`
+	BatchID     *uuid.UUID                          `json:"batch_id,omitempty"`
+	Error       string                              `json:"error,omitempty"`
+	AppliedAt   *time.Time                          `json:"applied_at,omitempty"`
+	CreatedAt   time.Time                           `json:"created_at"`
+}
+
+// ListScheduledUpdates returns a catalog's scheduled price and inventory updates
+func (a *App) ListScheduledUpdates(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	catalogID, err := parsePathUUID(r, "id", "catalog")
+	if err != nil {
+		return nil
+	}
+
+	if _, err := findByIDAndOrg[models.Catalog](a.DB, r, catalogID, orgID, "Catalog"); err != nil {
+		return nil
+	}
+
+	query := a.DB.Where("catalog_id = ?", catalogID)
+	if status := string(r.RequestCtx.QueryArgs().Peek("status")); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var updates []models.CatalogScheduledUpdate
+	if err := query.Order("scheduled_at DESC").Find(&updates).Error; err != nil {
+		a.Log.Error("Failed to list scheduled updates", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list scheduled updates", nil, "")
+	}
+
+	result := make([]ScheduledUpdateResponse, len(updates))
+	for i, u := range updates {
+		result[i] = scheduledUpdateToResponse(u)
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"scheduled_updates": result,
+	})
+}
+
+// CreateScheduledUpdate schedules price, sale price, inventory and availability
+// changes for a catalog's products
+func (a *App) CreateScheduledUpdate(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	catalogID, err := parsePathUUID(r, "id", "catalog")
+	if err != nil {
+		return nil
+	}
+
+	var req ScheduledUpdateRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if req.ScheduledAt.IsZero() {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "scheduled_at is required", nil, "")
+	}
+	if len(req.Updates) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "updates are required", nil, "")
+	}
+	if len(req.Updates) > maxFeedProducts {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("At most %d updates are allowed", maxFeedProducts), nil, "")
+	}
+	for i := range req.Updates {
+		if err := validateProductUpdate(&req.Updates[i]); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("updates[%d]: %s", i, err), nil, "")
+		}
+	}
+
+	catalog, err := findByIDAndOrg[models.Catalog](a.DB, r, catalogID, orgID, "Catalog")
+	if err != nil {
+		return nil
+	}
+
+	retailerIDs := make([]string, len(req.Updates))
+	for i, u := range req.Updates {
+		retailerIDs[i] = u.RetailerID
+	}
+	if unknown := a.unknownRetailerIDs(catalog.ID, retailerIDs); len(unknown) > 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Unknown retailer IDs: "+strings.Join(unknown, ", "), nil, "")
+	}
+
+	encoded, _ := json.Marshal(req.Updates)
+	var updates models.JSONBArray
+	_ = json.Unmarshal(encoded, &updates)
+
+	scheduled := models.CatalogScheduledUpdate{
+		OrganizationID: orgID,
+		CatalogID:      catalog.ID,
+		Name:           req.Name,
+		Updates:        updates,
+		ScheduledAt:    req.ScheduledAt,
+		Status:         models.CatalogScheduledUpdateStatusScheduled,
+		CreatedByID:    &userID,
+	}
+	if err := a.DB.Create(&scheduled).Error; err != nil {
+		a.Log.Error("Failed to save scheduled update", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save scheduled update", nil, "")
+	}
+
+	return r.SendEnvelope(scheduledUpdateToResponse(scheduled))
+}
+
+// CancelScheduledUpdate cancels an update that has not been applied yet
+func (a *App) CancelScheduledUpdate(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	catalogID, err := parsePathUUID(r, "id", "catalog")
+	if err != nil {
+		return nil
+	}
+	updateID, err := parsePathUUID(r, "update_id", "scheduled update")
+	if err != nil {
+		return nil
+	}
+
+	scheduled, err := findByIDAndOrg[models.CatalogScheduledUpdate](a.DB, r, updateID, orgID, "Scheduled update")
+	if err != nil {
+		return nil
+	}
+	if scheduled.CatalogID != catalogID {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Scheduled update not found", nil, "")
+	}
+
+	result := a.DB.Model(scheduled).
+		Where("status = ?", models.CatalogScheduledUpdateStatusScheduled).
+		Update("status", models.CatalogScheduledUpdateStatusCancelled)
+	if result.Error != nil {
+		a.Log.Error("Failed to cancel scheduled update", "error", result.Error)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to cancel scheduled update", nil, "")
+	}
+	if result.RowsAffected == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Only scheduled updates can be cancelled", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]string{"message": "Scheduled update cancelled"})
+}
+
+// validateProductUpdate checks one product update and normalizes its availability
+func validateProductUpdate(u *ProductUpdate) error {
+	u.RetailerID = strings.TrimSpace(u.RetailerID)
+	if u.RetailerID == "" {
+		return fmt.Errorf("retailer_id is required")
+	}
+	if u.Price == nil && u.SalePrice == nil && u.Inventory == nil && u.Availability == nil {
+		return fmt.Errorf("nothing to update")
+	}
+	if u.Price != nil && *u.Price <= 0 {
+		return fmt.Errorf("price must be positive")
+	}
+	if u.SalePrice != nil && *u.SalePrice < 0 {
+		return fmt.Errorf("sale_price cannot be negative")
+	}
+	if u.Price != nil && u.SalePrice != nil && *u.SalePrice >= *u.Price {
+		return fmt.Errorf("sale_price must be lower than price")
+	}
+	if u.Inventory != nil && *u.Inventory < 0 {
+		return fmt.Errorf("inventory cannot be negative")
+	}
+	if u.Availability != nil {
+		availability := normalizeFeedValue(*u.Availability)
+		if !feedAvailabilities[availability] {
+			return fmt.Errorf("invalid availability %q", *u.Availability)
+		}
+		u.Availability = &availability
+	}
+	return nil
+}
+
+// unknownRetailerIDs returns up to ten retailer IDs the catalog has no product for
+func (a *App) unknownRetailerIDs(catalogID uuid.UUID, retailerIDs []string) []string {
+	var known []string
+	a.DB.Model(&models.CatalogProduct{}).
+		Where("catalog_id = ? AND retailer_id IN ?", catalogID, retailerIDs).
+		Pluck("retailer_id", &known)
+	exists := make(map[string]bool, len(known))
+	for _, id := range known {
+		exists[id] = true
+	}
+
+	var unknown []string
+	for _, id := range retailerIDs {
+		if !exists[id] {
+			unknown = append(unknown, id)
+			if len(unknown) == 10 {
+				break
+			}
+		}
+	}
+	return unknown
+}
+
+// applyScheduledUpdate changes the products locally and sends the changes to
+// Meta in a batch. Products deleted since the update was scheduled are skipped.
+func (a *App) applyScheduledUpdate(scheduled *models.CatalogScheduledUpdate) {
+	fail := func(message string) {
+		now := time.Now()
+		a.DB.Model(scheduled).Updates(map[string]interface{}{
+			"status":     models.CatalogScheduledUpdateStatusFailed,
+			"error":      message,
+			"applied_at": now,
+		})
+	}
+
+	var catalog models.Catalog
+	if err := a.DB.Where("id = ?", scheduled.CatalogID).First(&catalog).Error; err != nil {
+		fail("Catalog not found")
+		return
+	}
+
+	var updates []ProductUpdate
+	encoded, _ := json.Marshal(scheduled.Updates)
+	if err := json.Unmarshal(encoded, &updates); err != nil {
+		fail("Invalid updates")
+		return
+	}
+
+	retailerIDs := make([]string, len(updates))
+	for i, u := range updates {
+		retailerIDs[i] = u.RetailerID
+	}
+	var products []models.CatalogProduct
+	if err := a.DB.Where("catalog_id = ? AND retailer_id IN ?", catalog.ID, retailerIDs).Find(&products).Error; err != nil {
+		a.Log.Error("Failed to load products for scheduled update", "error", err, "update_id", scheduled.ID)
+		return
+	}
+	byRetailerID := make(map[string]*models.CatalogProduct, len(products))
+	for i := range products {
+		byRetailerID[products[i].RetailerID] = &products[i]
+	}
+
+	var items []whatsapp.ProductBatchItem
+	var skipped []string
+	for _, u := range updates {
+		product := byRetailerID[u.RetailerID]
+		if product == nil {
+			skipped = append(skipped, u.RetailerID)
+			continue
+		}
+		item := whatsapp.ProductBatchItem{
+			Method:     whatsapp.BatchMethodUpdate,
+			RetailerID: product.RetailerID,
+			Currency:   product.Currency,
+		}
+		if u.Price != nil {
+			product.Price = *u.Price
+			item.Price = *u.Price
+		}
+		if u.SalePrice != nil {
+			product.SalePrice = *u.SalePrice
+			item.SalePrice = *u.SalePrice
+			item.ClearSalePrice = *u.SalePrice == 0
+		}
+		if u.Inventory != nil {
+			inventory := *u.Inventory
+			product.Inventory = &inventory
+			item.Inventory = &inventory
+		}
+		if u.Availability != nil {
+			product.Availability = *u.Availability
+			item.Availability = *u.Availability
+		}
+		product.SyncStatus = models.ProductSyncStatusPending
+		product.SyncError = ""
+		if err := a.DB.Save(product).Error; err != nil {
+			a.Log.Error("Failed to save scheduled product update", "error", err, "product_id", product.ID)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	var message string
+	if len(skipped) > 0 {
+		message = "Skipped deleted products: " + strings.Join(skipped, ", ")
+	}
+	if len(items) == 0 {
+		fail(strings.TrimSpace("No products to update. " + message))
+		return
+	}
+
+	batch, err := a.submitProductBatch(&catalog, items, "schedule")
+	status := models.CatalogScheduledUpdateStatusApplied
+	if err != nil {
+		status = models.CatalogScheduledUpdateStatusFailed
+		message = strings.TrimSpace(err.Error() + ". " + message)
+	}
+	now := time.Now()
+	fields := map[string]interface{}{
+		"status":     status,
+		"error":      message,
+		"applied_at": now,
+	}
+	if batch != nil && batch.ID != uuid.Nil {
+		fields["batch_id"] = batch.ID
+	}
+	if err := a.DB.Model(scheduled).Updates(fields).Error; err != nil {
+		a.Log.Error("Failed to update scheduled update", "error", err, "update_id", scheduled.ID)
+	}
+}
+
+func scheduledUpdateToResponse(u models.CatalogScheduledUpdate) ScheduledUpdateResponse {
+	updates := []ProductUpdate{}
+	encoded, _ := json.Marshal(u.Updates)
+	_ = json.Unmarshal(encoded, &updates)
+	if updates == nil {
+		updates = []ProductUpdate{}
+	}
+	return ScheduledUpdateResponse{
+		ID:          u.ID,
+		CatalogID:   u.CatalogID,
+		Name:        u.Name,
+		Updates:     updates,
+		ScheduledAt: u.ScheduledAt,
+		Status:      u.Status,
+		BatchID:     u.BatchID,
+		Error:       u.Error,
+		AppliedAt:   u.AppliedAt,
+		CreatedAt:   u.CreatedAt,
+	}
+}
@@ -0,0 +1,738 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// catalogBatchTimeout is how long a batch may stay in Meta's queue before its
+// products are marked failed
+const catalogBatchTimeout = 24 * time.Hour
+
+// FeedImportReport describes what a feed import changed, or would change on a dry run
+type FeedImportReport struct {
+	DryRun    bool                  `json:"dry_run"`
+	Total     int                   `json:"total"` // Valid products in the feed
+	Created   []FeedDiffItem        `json:"created"`
+	Updated   []FeedDiffItem        `json:"updated"`
+	Deleted   []FeedDiffItem        `json:"deleted"`
+	Unchanged int                   `json:"unchanged"`
+	Errors    []FeedRowError        `json:"errors"`
+	Batch     *CatalogBatchResponse `json:"batch,omitempty"`
+}
+
+// CatalogBatchResponse represents the API response for a batch of product changes
+type CatalogBatchResponse struct {
+	ID          uuid.UUID                 `json:"id"`
+	Source      string                    `json:"source"`
+	Status      models.CatalogBatchStatus `json:"status"`
+	ItemCount   int                       `json:"item_count"`
+	ErrorCount  int                       `json:"error_count"`
+	Errors      models.JSONBArray         `json:"errors"`
+	CreatedAt   time.Time                 `json:"created_at"`
+	CompletedAt *time.Time                `json:"completed_at,omitempty"`
+}
+
+// ProductSyncReport describes what pulling products from Meta changed
+type ProductSyncReport struct {
+	Total   int `json:"total"` // Products in the Meta catalog
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+}
+
+// ImportCatalogFeed imports a CSV or XML product feed into a catalog. The feed is
+// compared with the catalog's products by retailer ID (SKU) and only the
+// differences are sent to Meta, in batches.
+func (a *App) ImportCatalogFeed(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	catalogID, err := parsePathUUID(r, "id", "catalog")
+	if err != nil {
+		return nil
+	}
+
+	catalog, err := findByIDAndOrg[models.Catalog](a.DB, r, catalogID, orgID, "Catalog")
+	if err != nil {
+		return nil
+	}
+
+	form, err := r.RequestCtx.MultipartForm()
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid multipart form", nil, "")
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "file is required", nil, "")
+	}
+	file, err := files[0].Open()
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to read file", nil, "")
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxFeedSize+1))
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to read file", nil, "")
+	}
+	if len(data) > maxFeedSize {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Feed is larger than 50MB", nil, "")
+	}
+
+	formValue := func(key string) string {
+		if values := form.Value[key]; len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+	dryRun := formValue("dry_run") == "true"
+	deleteMissing := formValue("delete_missing") == "true"
+
+	products, rowErrors, err := parseProductFeed(data, strings.ToUpper(formValue("currency")))
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	var existing []models.CatalogProduct
+	if err := a.DB.Where("catalog_id = ?", catalog.ID).Find(&existing).Error; err != nil {
+		a.Log.Error("Failed to load catalog products", "error", err, "catalog_id", catalog.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load products", nil, "")
+	}
+
+	diff := diffProductFeed(existing, products, rowErrors, deleteMissing)
+	report := diff.report()
+	report.DryRun = dryRun
+	report.Total = len(products)
+	report.Errors = rowErrors
+	if report.Errors == nil {
+		report.Errors = []FeedRowError{}
+	}
+	if dryRun || len(diff.Create)+len(diff.Update)+len(diff.Delete) == 0 {
+		return r.SendEnvelope(report)
+	}
+
+	batch, err := a.applyFeedDiff(catalog, existing, diff)
+	if batch != nil {
+		resp := catalogBatchToResponse(*batch)
+		report.Batch = &resp
+	}
+	if err != nil {
+		a.Log.Error("Failed to import catalog feed", "error", err, "catalog_id", catalog.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to submit products to Meta: "+err.Error(), report, "")
+	}
+
+	return r.SendEnvelope(report)
+}
+
+// report lists the feed's changes for the API response
+func (d *feedDiff) report() *FeedImportReport {
+	report := &FeedImportReport{
+		Created:   make([]FeedDiffItem, 0, len(d.Create)),
+		Updated:   make([]FeedDiffItem, 0, len(d.Update)),
+		Deleted:   make([]FeedDiffItem, 0, len(d.Delete)),
+		Unchanged: d.Unchanged,
+	}
+	for _, p := range d.Create {
+		report.Created = append(report.Created, FeedDiffItem{RetailerID: p.RetailerID, Name: p.Name})
+	}
+	for _, p := range d.Update {
+		report.Updated = append(report.Updated, FeedDiffItem{RetailerID: p.RetailerID, Name: p.Name, Changes: d.Changes[p.RetailerID]})
+	}
+	for _, p := range d.Delete {
+		report.Deleted = append(report.Deleted, FeedDiffItem{RetailerID: p.RetailerID, Name: p.Name})
+	}
+	return report
+}
+
+// applyFeedDiff saves the feed's changes locally and submits them to Meta.
+// Changed products stay pending until Meta has processed the batch.
+func (a *App) applyFeedDiff(catalog *models.Catalog, existing []models.CatalogProduct, diff *feedDiff) (*models.CatalogBatch, error) {
+	byRetailerID := make(map[string]*models.CatalogProduct, len(existing))
+	for i := range existing {
+		byRetailerID[existing[i].RetailerID] = &existing[i]
+	}
+
+	items := make([]whatsapp.ProductBatchItem, 0, len(diff.Create)+len(diff.Update)+len(diff.Delete))
+	err := a.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range diff.Create {
+			product := models.CatalogProduct{
+				OrganizationID: catalog.OrganizationID,
+				CatalogID:      catalog.ID,
+				SyncStatus:     models.ProductSyncStatusPending,
+			}
+			diff.Create[i].apply(&product)
+			if err := tx.Create(&product).Error; err != nil {
+				return err
+			}
+			items = append(items, productBatchItem(whatsapp.BatchMethodCreate, &product))
+		}
+		for i := range diff.Update {
+			product := byRetailerID[diff.Update[i].RetailerID]
+			diff.Update[i].apply(product)
+			product.SyncStatus = models.ProductSyncStatusPending
+			product.SyncError = ""
+			if err := tx.Save(product).Error; err != nil {
+				return err
+			}
+			items = append(items, productBatchItem(whatsapp.BatchMethodUpdate, product))
+		}
+		for i := range diff.Delete {
+			if err := tx.Delete(&diff.Delete[i]).Error; err != nil {
+				return err
+			}
+			items = append(items, whatsapp.ProductBatchItem{Method: whatsapp.BatchMethodDelete, RetailerID: diff.Delete[i].RetailerID})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.submitProductBatch(catalog, items, "feed")
+}
+
+// productBatchItem converts a product to a batch request
+func productBatchItem(method string, p *models.CatalogProduct) whatsapp.ProductBatchItem {
+	return whatsapp.ProductBatchItem{
+		Method:       method,
+		RetailerID:   p.RetailerID,
+		Name:         p.Name,
+		Description:  p.Description,
+		Brand:        p.Brand,
+		Price:        p.Price,
+		SalePrice:    p.SalePrice,
+		Currency:     p.Currency,
+		URL:          p.URL,
+		ImageURL:     p.ImageURL,
+		Availability: p.Availability,
+		Condition:    p.Condition,
+		Inventory:    p.Inventory,
+
+		ClearSalePrice: method == whatsapp.BatchMethodUpdate && p.SalePrice == 0,
+	}
+}
+
+// catalogAccount returns the WhatsApp account a catalog belongs to
+func (a *App) catalogAccount(catalog *models.Catalog) (*whatsapp.Account, error) {
+	var account models.WhatsAppAccount
+	if err := a.DB.Where("organization_id = ? AND name = ?", catalog.OrganizationID, catalog.WhatsAppAccount).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("WhatsApp account not found")
+	}
+	a.decryptAccountSecrets(&account)
+	return a.toWhatsAppAccount(&account), nil
+}
+
+// submitProductBatch sends product changes to Meta and records the batch.
+// Products Meta refuses right away, or that could not be sent, are marked
+// failed; the catalog processor settles the rest once Meta has processed them.
+func (a *App) submitProductBatch(catalog *models.Catalog, items []whatsapp.ProductBatchItem, source string) (*models.CatalogBatch, error) {
+	batch := &models.CatalogBatch{
+		OrganizationID: catalog.OrganizationID,
+		CatalogID:      catalog.ID,
+		Source:         source,
+		Handles:        models.StringArray{},
+		RetailerIDs:    models.StringArray{},
+		ItemCount:      len(items),
+		Errors:         models.JSONBArray{},
+		Status:         models.CatalogBatchStatusProcessing,
+	}
+	for _, item := range items {
+		if item.Method != whatsapp.BatchMethodDelete {
+			batch.RetailerIDs = append(batch.RetailerIDs, item.RetailerID)
+		}
+	}
+
+	var result *whatsapp.ProductBatchResult
+	waAccount, err := a.catalogAccount(catalog)
+	if err == nil {
+		result, err = a.WhatsApp.BatchProducts(context.Background(), waAccount, catalog.MetaCatalogID, items)
+	}
+	if result == nil {
+		result = &whatsapp.ProductBatchResult{}
+	}
+	batch.Handles = append(batch.Handles, result.Handles...)
+
+	failed := make(map[string]string)
+	for _, e := range result.Errors {
+		failed[e.RetailerID] = e.Message
+		batch.Errors = append(batch.Errors, map[string]interface{}{"retailer_id": e.RetailerID, "message": e.Message})
+	}
+	if err != nil {
+		for _, item := range items[result.Submitted:] {
+			if item.Method != whatsapp.BatchMethodDelete {
+				failed[item.RetailerID] = err.Error()
+			}
+		}
+		batch.Errors = append(batch.Errors, map[string]interface{}{"message": err.Error()})
+	}
+	batch.ErrorCount = len(failed)
+
+	switch {
+	case err != nil && len(batch.Handles) == 0:
+		batch.Status = models.CatalogBatchStatusFailed
+	case len(batch.Handles) == 0:
+		batch.Status = models.CatalogBatchStatusCompleted
+	}
+	if batch.Status != models.CatalogBatchStatusProcessing {
+		now := time.Now()
+		batch.CompletedAt = &now
+	}
+
+	if dbErr := a.DB.Create(batch).Error; dbErr != nil {
+		a.Log.Error("Failed to save catalog batch", "error", dbErr, "catalog_id", catalog.ID)
+	}
+	a.markProductsFailed(catalog.ID, failed)
+	return batch, err
+}
+
+// markProductsFailed records why Meta refused products, by retailer ID
+func (a *App) markProductsFailed(catalogID uuid.UUID, failed map[string]string) {
+	for retailerID, message := range failed {
+		a.DB.Model(&models.CatalogProduct{}).
+			Where("catalog_id = ? AND retailer_id = ?", catalogID, retailerID).
+			Updates(map[string]interface{}{
+				"sync_status": models.ProductSyncStatusFailed,
+				"sync_error":  message,
+			})
+	}
+}
+
+// checkCatalogBatch asks Meta whether a batch has been processed. Once it has,
+// its products are marked synced or failed and their Meta IDs and review
+// status are pulled from the catalog.
+func (a *App) checkCatalogBatch(ctx context.Context, batch *models.CatalogBatch) {
+	var catalog models.Catalog
+	if err := a.DB.Where("id = ?", batch.CatalogID).First(&catalog).Error; err != nil {
+		a.finishCatalogBatch(batch, models.CatalogBatchStatusFailed, nil, "Catalog not found")
+		return
+	}
+	waAccount, err := a.catalogAccount(&catalog)
+	if err != nil {
+		a.finishCatalogBatch(batch, models.CatalogBatchStatusFailed, nil, err.Error())
+		return
+	}
+
+	var errs []whatsapp.ProductBatchError
+	handleFailed := false
+	for _, handle := range batch.Handles {
+		status, err := a.WhatsApp.CheckBatchStatus(ctx, waAccount, catalog.MetaCatalogID, handle)
+		if err != nil {
+			a.Log.Warn("Failed to check catalog batch", "error", err, "batch_id", batch.ID)
+			return
+		}
+		switch status.Status {
+		case "finished":
+		case "error":
+			if len(status.Errors) == 0 {
+				handleFailed = true
+			}
+		default:
+			if time.Since(batch.CreatedAt) > catalogBatchTimeout {
+				a.finishCatalogBatch(batch, models.CatalogBatchStatusFailed, nil, "Meta did not process the batch in time")
+				return
+			}
+			return
+		}
+		errs = append(errs, status.Errors...)
+	}
+
+	if handleFailed {
+		a.finishCatalogBatch(batch, models.CatalogBatchStatusFailed, errs, "Meta could not process the batch")
+	} else {
+		a.finishCatalogBatch(batch, models.CatalogBatchStatusCompleted, errs, "")
+	}
+
+	if _, err := a.pullCatalogProducts(ctx, &catalog, waAccount); err != nil {
+		a.Log.Warn("Failed to pull catalog products after batch", "error", err, "catalog_id", catalog.ID)
+	}
+}
+
+// finishCatalogBatch settles a batch's pending products: the ones Meta reported
+// fail with its message, the rest are synced, or fail with failure when set.
+func (a *App) finishCatalogBatch(batch *models.CatalogBatch, status models.CatalogBatchStatus, errs []whatsapp.ProductBatchError, failure string) {
+	failed := make(map[string]string)
+	for _, e := range errs {
+		failed[e.RetailerID] = e.Message
+		batch.Errors = append(batch.Errors, map[string]interface{}{"retailer_id": e.RetailerID, "message": e.Message})
+	}
+	a.markProductsFailed(batch.CatalogID, failed)
+	batch.ErrorCount += len(failed)
+
+	now := time.Now()
+	if len(batch.RetailerIDs) > 0 {
+		settled := map[string]interface{}{
+			"sync_status":    models.ProductSyncStatusSynced,
+			"sync_error":     "",
+			"last_synced_at": now,
+		}
+		if failure != "" {
+			settled = map[string]interface{}{
+				"sync_status": models.ProductSyncStatusFailed,
+				"sync_error":  failure,
+			}
+		}
+		result := a.DB.Model(&models.CatalogProduct{}).
+			Where("catalog_id = ? AND retailer_id IN ? AND sync_status = ?", batch.CatalogID, []string(batch.RetailerIDs), models.ProductSyncStatusPending).
+			Updates(settled)
+		if failure != "" {
+			batch.ErrorCount += int(result.RowsAffected)
+		}
+	}
+	if failure != "" {
+		batch.Errors = append(batch.Errors, map[string]interface{}{"message": failure})
+	}
+
+	batch.Status = status
+	batch.CompletedAt = &now
+	if err := a.DB.Model(batch).Updates(map[string]interface{}{
+		"status":       batch.Status,
+		"errors":       batch.Errors,
+		"error_count":  batch.ErrorCount,
+		"completed_at": batch.CompletedAt,
+	}).Error; err != nil {
+		a.Log.Error("Failed to update catalog batch", "error", err, "batch_id", batch.ID)
+	}
+}
+
+// SyncCatalogProducts pulls a catalog's products and product sets from Meta.
+// Changes made in Commerce Manager flow back, review results are stored, and
+// products with changes still pending keep their local content.
+func (a *App) SyncCatalogProducts(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	catalogID, err := parsePathUUID(r, "id", "catalog")
+	if err != nil {
+		return nil
+	}
+
+	catalog, err := findByIDAndOrg[models.Catalog](a.DB, r, catalogID, orgID, "Catalog")
+	if err != nil {
+		return nil
+	}
+
+	waAccount, err := a.catalogAccount(catalog)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, err.Error(), nil, "")
+	}
+
+	ctx := context.Background()
+	report, err := a.pullCatalogProducts(ctx, catalog, waAccount)
+	if err != nil {
+		a.Log.Error("Failed to sync catalog products", "error", err, "catalog_id", catalog.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to fetch products", nil, "")
+	}
+
+	productSets, err := a.pullProductSets(ctx, catalog, waAccount)
+	if err != nil {
+		a.Log.Error("Failed to sync product sets", "error", err, "catalog_id", catalog.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to fetch product sets", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"message":      "Products synced",
+		"products":     report,
+		"product_sets": productSets,
+	})
+}
+
+// pullCatalogProducts brings the local catalog in line with Meta. Products are
+// matched by Meta ID, then by retailer ID.
+func (a *App) pullCatalogProducts(ctx context.Context, catalog *models.Catalog, waAccount *whatsapp.Account) (*ProductSyncReport, error) {
+	metaProducts, err := a.WhatsApp.ListCatalogProducts(ctx, waAccount, catalog.MetaCatalogID)
+	if err != nil {
+		return nil, err
+	}
+
+	var local []models.CatalogProduct
+	if err := a.DB.Where("catalog_id = ?", catalog.ID).Find(&local).Error; err != nil {
+		return nil, err
+	}
+	byMetaID := make(map[string]*models.CatalogProduct, len(local))
+	byRetailerID := make(map[string]*models.CatalogProduct, len(local))
+	for i := range local {
+		if local[i].MetaProductID != "" {
+			byMetaID[local[i].MetaProductID] = &local[i]
+		}
+		if local[i].RetailerID != "" {
+			byRetailerID[local[i].RetailerID] = &local[i]
+		}
+	}
+
+	// Products deleted here whose deletion hasn't reached Meta yet are not re-created
+	var deletedMetaIDs []string
+	a.DB.Unscoped().Model(&models.CatalogProduct{}).
+		Where("catalog_id = ? AND deleted_at IS NOT NULL AND meta_product_id <> ''", catalog.ID).
+		Pluck("meta_product_id", &deletedMetaIDs)
+	deleted := make(map[string]bool, len(deletedMetaIDs))
+	for _, id := range deletedMetaIDs {
+		deleted[id] = true
+	}
+
+	now := time.Now()
+	report := &ProductSyncReport{Total: len(metaProducts)}
+	seen := make(map[uuid.UUID]bool, len(metaProducts))
+	for i := range metaProducts {
+		mp := &metaProducts[i]
+		product := byMetaID[mp.ID]
+		if product == nil {
+			product = byRetailerID[mp.RetailerID]
+		}
+
+		if product == nil {
+			if deleted[mp.ID] {
+				continue
+			}
+			product = &models.CatalogProduct{
+				OrganizationID: catalog.OrganizationID,
+				CatalogID:      catalog.ID,
+				MetaProductID:  mp.ID,
+				RetailerID:     mp.RetailerID,
+				Currency:       "USD",
+				Availability:   "in stock",
+				Condition:      "new",
+				IsActive:       true,
+				SyncStatus:     models.ProductSyncStatusSynced,
+				LastSyncedAt:   &now,
+			}
+			applyMetaProduct(product, mp)
+			if err := a.DB.Create(product).Error; err != nil {
+				a.Log.Error("Failed to create synced product", "error", err, "meta_id", mp.ID)
+				continue
+			}
+			report.Created++
+			continue
+		}
+		seen[product.ID] = true
+
+		changed := product.MetaProductID != mp.ID ||
+			product.ReviewStatus != strings.ToLower(mp.ReviewStatus) ||
+			!equalStrings(product.ReviewRejectionReasons, mp.ReviewRejectionReasons)
+		product.MetaProductID = mp.ID
+		product.ReviewStatus = strings.ToLower(mp.ReviewStatus)
+		product.ReviewRejectionReasons = models.StringArray(mp.ReviewRejectionReasons)
+		if product.ReviewRejectionReasons == nil {
+			product.ReviewRejectionReasons = models.StringArray{}
+		}
+		// Local changes Meta hasn't processed yet win over Meta's copy
+		if product.SyncStatus == models.ProductSyncStatusSynced && applyMetaProduct(product, mp) {
+			report.Updated++
+			changed = true
+		}
+		if changed {
+			if err := a.DB.Save(product).Error; err != nil {
+				a.Log.Error("Failed to update synced product", "error", err, "product_id", product.ID)
+			}
+		}
+	}
+
+	// Synced products Meta no longer has were deleted in Commerce Manager
+	for i := range local {
+		p := &local[i]
+		if seen[p.ID] || p.MetaProductID == "" || p.SyncStatus != models.ProductSyncStatusSynced {
+			continue
+		}
+		if err := a.DB.Delete(p).Error; err != nil {
+			a.Log.Error("Failed to delete product removed from Meta", "error", err, "product_id", p.ID)
+			continue
+		}
+		report.Deleted++
+	}
+
+	a.DB.Model(&models.CatalogProduct{}).
+		Where("catalog_id = ? AND sync_status = ?", catalog.ID, models.ProductSyncStatusSynced).
+		Update("last_synced_at", now)
+
+	return report, nil
+}
+
+// applyMetaProduct copies Meta's product fields onto a local product and
+// reports whether anything changed. Fields Meta left empty are kept.
+func applyMetaProduct(product *models.CatalogProduct, mp *whatsapp.ProductInfo) bool {
+	changed := false
+	setString := func(field *string, value string) {
+		if value != "" && *field != value {
+			*field = value
+			changed = true
+		}
+	}
+	setString(&product.Name, mp.Name)
+	setString(&product.Description, mp.Description)
+	setString(&product.Brand, mp.Brand)
+	setString(&product.URL, mp.URL)
+	setString(&product.ImageURL, mp.ImageURL)
+	setString(&product.Currency, strings.ToUpper(mp.Currency))
+	setString(&product.Availability, normalizeFeedValue(mp.Availability))
+	setString(&product.Condition, normalizeFeedValue(mp.Condition))
+	if price, ok := whatsapp.ParseMetaPrice(mp.Price); ok && price != product.Price {
+		product.Price = price
+		changed = true
+	}
+	salePrice, _ := whatsapp.ParseMetaPrice(mp.SalePrice)
+	if salePrice != product.SalePrice {
+		product.SalePrice = salePrice
+		changed = true
+	}
+	if mp.Inventory != nil && !sameInventory(product.Inventory, mp.Inventory) {
+		inventory := *mp.Inventory
+		product.Inventory = &inventory
+		changed = true
+	}
+	return changed
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ListCatalogBatches returns the catalog's recent batches of product changes
+func (a *App) ListCatalogBatches(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	catalogID, err := parsePathUUID(r, "id", "catalog")
+	if err != nil {
+		return nil
+	}
+
+	if _, err := findByIDAndOrg[models.Catalog](a.DB, r, catalogID, orgID, "Catalog"); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	query := a.DB.Model(&models.CatalogBatch{}).Where("catalog_id = ?", catalogID)
+
+	var total int64
+	query.Count(&total)
+
+	var batches []models.CatalogBatch
+	if err := pg.Apply(query.Order("created_at DESC")).Find(&batches).Error; err != nil {
+		a.Log.Error("Failed to list catalog batches", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list batches", nil, "")
+	}
+
+	result := make([]CatalogBatchResponse, len(batches))
+	for i, b := range batches {
+		result[i] = catalogBatchToResponse(b)
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"batches": result,
+		"total":   total,
+		"page":    pg.Page,
+		"limit":   pg.Limit,
+	})
+}
+
+// GetCatalogReviewReport lists the products Meta's commerce review rejected and
+// the products Meta refused in a batch, with the reasons
+func (a *App) GetCatalogReviewReport(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	catalogID, err := parsePathUUID(r, "id", "catalog")
+	if err != nil {
+		return nil
+	}
+
+	if _, err := findByIDAndOrg[models.Catalog](a.DB, r, catalogID, orgID, "Catalog"); err != nil {
+		return nil
+	}
+
+	var counts []struct {
+		ReviewStatus string
+		Count        int64
+	}
+	if err := a.DB.Model(&models.CatalogProduct{}).
+		Select("review_status, COUNT(*) AS count").
+		Where("catalog_id = ?", catalogID).
+		Group("review_status").
+		Scan(&counts).Error; err != nil {
+		a.Log.Error("Failed to count product review status", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load review report", nil, "")
+	}
+	review := map[string]int64{"approved": 0, "pending": 0, "rejected": 0, "outdated": 0, "unknown": 0}
+	for _, c := range counts {
+		status := c.ReviewStatus
+		if status == "" {
+			status = "unknown"
+		}
+		review[status] += c.Count
+	}
+
+	var syncFailed int64
+	a.DB.Model(&models.CatalogProduct{}).
+		Where("catalog_id = ? AND sync_status = ?", catalogID, models.ProductSyncStatusFailed).
+		Count(&syncFailed)
+
+	pg := parsePagination(r)
+	query := a.DB.Model(&models.CatalogProduct{}).
+		Where("catalog_id = ? AND (review_status = ? OR sync_status = ?)", catalogID, "rejected", models.ProductSyncStatusFailed)
+
+	var total int64
+	query.Count(&total)
+
+	var products []models.CatalogProduct
+	if err := pg.Apply(query.Order("retailer_id ASC")).Find(&products).Error; err != nil {
+		a.Log.Error("Failed to list rejected products", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load review report", nil, "")
+	}
+
+	result := make([]CatalogProductResponse, len(products))
+	for i, p := range products {
+		result[i] = productToResponse(p)
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"review":      review,
+		"sync_failed": syncFailed,
+		"products":    result,
+		"total":       total,
+		"page":        pg.Page,
+		"limit":       pg.Limit,
+	})
+}
+
+func catalogBatchToResponse(b models.CatalogBatch) CatalogBatchResponse {
+	errs := b.Errors
+	if errs == nil {
+		errs = models.JSONBArray{}
+	}
+	return CatalogBatchResponse{
+		ID:          b.ID,
+		Source:      b.Source,
+		Status:      b.Status,
+		ItemCount:   b.ItemCount,
+		ErrorCount:  b.ErrorCount,
+		Errors:      errs,
+		CreatedAt:   b.CreatedAt,
+		CompletedAt: b.CompletedAt,
+	}
+}
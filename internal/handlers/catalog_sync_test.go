@@ -0,0 +1,366 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// mockCommerceServer is a Graph API server for batch, product and product set calls
+type mockCommerceServer struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	batches  [][]map[string]interface{} // Requests of each items_batch call
+	products []map[string]interface{}   // Returned when listing products
+	invalid  map[string]string          // Retailer ID -> validation error
+}
+
+func newMockCommerceServer(t *testing.T) *mockCommerceServer {
+	t.Helper()
+
+	m := &mockCommerceServer{invalid: map[string]string{}}
+	m.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/items_batch"):
+			var body struct {
+				Requests []map[string]interface{} `json:"requests"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			m.batches = append(m.batches, body.Requests)
+
+			var validation []map[string]interface{}
+			for retailerID, message := range m.invalid {
+				validation = append(validation, map[string]interface{}{
+					"retailer_id": retailerID,
+					"errors":      []map[string]string{{"message": message}},
+				})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"handles":           []string{"handle-" + uuid.New().String()[:8]},
+				"validation_status": validation,
+			})
+		case strings.HasSuffix(r.URL.Path, "/products"):
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": m.products})
+		case strings.HasSuffix(r.URL.Path, "/product_sets") && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": []interface{}{}})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": "meta-set-" + uuid.New().String()[:8]})
+		}
+	}))
+	return m
+}
+
+func newCommerceTestApp(t *testing.T, m *mockCommerceServer) *handlers.App {
+	t.Helper()
+
+	return newTestApp(t, withWhatsApp(whatsapp.NewWithBaseURL(testutil.NopLogger(), m.server.URL)))
+}
+
+// newFeedRequest builds a multipart request uploading a product feed
+func newFeedRequest(t *testing.T, feed string, fields map[string]string) *fastglue.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "feed.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(feed))
+	require.NoError(t, err)
+	for key, value := range fields {
+		require.NoError(t, writer.WriteField(key, value))
+	}
+	require.NoError(t, writer.Close())
+
+	req := testutil.NewRequest(t)
+	req.RequestCtx.Request.Header.SetMethod("POST")
+	req.RequestCtx.Request.Header.SetContentType(writer.FormDataContentType())
+	req.RequestCtx.Request.SetBody(body.Bytes())
+	return req
+}
+
+func createTestSKUProduct(t *testing.T, app *handlers.App, orgID, catalogID uuid.UUID, retailerID string, price int64) *models.CatalogProduct {
+	t.Helper()
+
+	product := &models.CatalogProduct{
+		BaseModel:      models.BaseModel{ID: uuid.New()},
+		OrganizationID: orgID,
+		CatalogID:      catalogID,
+		MetaProductID:  "meta-product-" + uuid.New().String()[:8],
+		Name:           "Product " + retailerID,
+		Price:          price,
+		Currency:       "USD",
+		RetailerID:     retailerID,
+		Availability:   "in stock",
+		Condition:      "new",
+		IsActive:       true,
+		SyncStatus:     models.ProductSyncStatusSynced,
+	}
+	require.NoError(t, app.DB.Create(product).Error)
+	return product
+}
+
+const testFeed = "id,title,price,availability\n" +
+	"SKU-1,Product SKU-1,12.00 USD,in stock\n" +
+	"SKU-2,Blue Shirt,20.00 USD,in stock\n" +
+	"SKU-3,,5.00 USD,in stock\n"
+
+func TestApp_ImportCatalogFeed_DryRun(t *testing.T) {
+	t.Parallel()
+
+	m := newMockCommerceServer(t)
+	defer m.server.Close()
+	app := newCommerceTestApp(t, m)
+
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createCatalogTestAccount(t, app, org.ID)
+	catalog := createTestCatalog(t, app, org.ID, account.Name, "Store")
+	createTestSKUProduct(t, app, org.ID, catalog.ID, "SKU-1", 1000)
+
+	req := newFeedRequest(t, testFeed, map[string]string{"dry_run": "true"})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", catalog.ID.String())
+
+	require.NoError(t, app.ImportCatalogFeed(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.FeedImportReport `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.True(t, resp.Data.DryRun)
+	assert.Equal(t, 2, resp.Data.Total)
+	require.Len(t, resp.Data.Created, 1)
+	assert.Equal(t, "SKU-2", resp.Data.Created[0].RetailerID)
+	require.Len(t, resp.Data.Updated, 1)
+	assert.Equal(t, []string{"price"}, resp.Data.Updated[0].Changes)
+	require.Len(t, resp.Data.Errors, 1)
+	assert.Equal(t, 4, resp.Data.Errors[0].Row)
+
+	var count int64
+	app.DB.Model(&models.CatalogProduct{}).Where("catalog_id = ?", catalog.ID).Count(&count)
+	assert.Equal(t, int64(1), count, "a dry run changes nothing")
+	assert.Empty(t, m.batches)
+}
+
+func TestApp_ImportCatalogFeed_SubmitsBatch(t *testing.T) {
+	t.Parallel()
+
+	m := newMockCommerceServer(t)
+	defer m.server.Close()
+	m.invalid["SKU-2"] = "Image could not be downloaded"
+	app := newCommerceTestApp(t, m)
+
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createCatalogTestAccount(t, app, org.ID)
+	catalog := createTestCatalog(t, app, org.ID, account.Name, "Store")
+	createTestSKUProduct(t, app, org.ID, catalog.ID, "SKU-1", 1000)
+	createTestSKUProduct(t, app, org.ID, catalog.ID, "SKU-OLD", 500)
+
+	req := newFeedRequest(t, testFeed, map[string]string{"delete_missing": "true"})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", catalog.ID.String())
+
+	require.NoError(t, app.ImportCatalogFeed(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.FeedImportReport `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	require.NotNil(t, resp.Data.Batch)
+	assert.Equal(t, models.CatalogBatchStatusProcessing, resp.Data.Batch.Status)
+	assert.Equal(t, 3, resp.Data.Batch.ItemCount)
+	assert.Equal(t, 1, resp.Data.Batch.ErrorCount)
+
+	require.Len(t, m.batches, 1)
+	methods := map[string]bool{}
+	for _, request := range m.batches[0] {
+		methods[request["method"].(string)] = true
+	}
+	assert.Equal(t, map[string]bool{"CREATE": true, "UPDATE": true, "DELETE": true}, methods)
+
+	var updated, created models.CatalogProduct
+	require.NoError(t, app.DB.Where("catalog_id = ? AND retailer_id = ?", catalog.ID, "SKU-1").First(&updated).Error)
+	assert.Equal(t, int64(1200), updated.Price)
+	assert.Equal(t, models.ProductSyncStatusPending, updated.SyncStatus)
+
+	require.NoError(t, app.DB.Where("catalog_id = ? AND retailer_id = ?", catalog.ID, "SKU-2").First(&created).Error)
+	assert.Equal(t, models.ProductSyncStatusFailed, created.SyncStatus)
+	assert.Equal(t, "Image could not be downloaded", created.SyncError)
+
+	var remaining int64
+	app.DB.Model(&models.CatalogProduct{}).Where("catalog_id = ? AND retailer_id = ?", catalog.ID, "SKU-OLD").Count(&remaining)
+	assert.Zero(t, remaining)
+}
+
+func TestApp_SyncCatalogProducts_PullsReviewStatus(t *testing.T) {
+	t.Parallel()
+
+	m := newMockCommerceServer(t)
+	defer m.server.Close()
+	app := newCommerceTestApp(t, m)
+
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createCatalogTestAccount(t, app, org.ID)
+	catalog := createTestCatalog(t, app, org.ID, account.Name, "Store")
+	synced := createTestSKUProduct(t, app, org.ID, catalog.ID, "SKU-1", 1000)
+	pending := createTestSKUProduct(t, app, org.ID, catalog.ID, "SKU-2", 1000)
+	require.NoError(t, app.DB.Model(pending).Updates(map[string]interface{}{"sync_status": models.ProductSyncStatusPending, "meta_product_id": ""}).Error)
+	removed := createTestSKUProduct(t, app, org.ID, catalog.ID, "SKU-3", 1000)
+
+	m.products = []map[string]interface{}{
+		{"id": synced.MetaProductID, "retailer_id": "SKU-1", "name": "Renamed in Commerce Manager", "price": "$15.00",
+			"review_status": "rejected", "review_rejection_reasons": []string{"PROHIBITED_CONTENT"}},
+		{"id": "meta-pending", "retailer_id": "SKU-2", "name": "Old name", "price": "$1.00", "review_status": "pending"},
+		{"id": "meta-new", "retailer_id": "SKU-4", "name": "Made in Commerce Manager", "price": "$3.00"},
+	}
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", catalog.ID.String())
+
+	require.NoError(t, app.SyncCatalogProducts(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			Products handlers.ProductSyncReport `json:"products"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, handlers.ProductSyncReport{Total: 3, Created: 1, Updated: 1, Deleted: 1}, resp.Data.Products)
+
+	var stored models.CatalogProduct
+	require.NoError(t, app.DB.First(&stored, synced.ID).Error)
+	assert.Equal(t, "Renamed in Commerce Manager", stored.Name)
+	assert.Equal(t, int64(1500), stored.Price)
+	assert.Equal(t, "rejected", stored.ReviewStatus)
+	assert.Equal(t, models.StringArray{"PROHIBITED_CONTENT"}, stored.ReviewRejectionReasons)
+
+	require.NoError(t, app.DB.First(&stored, pending.ID).Error)
+	assert.Equal(t, "meta-pending", stored.MetaProductID)
+	assert.Equal(t, "Product SKU-2", stored.Name, "pending local changes are kept")
+
+	assert.Error(t, app.DB.First(&stored, removed.ID).Error, "products deleted in Meta are removed")
+
+	// The review report lists the rejected product
+	report := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(report, org.ID, user.ID)
+	testutil.SetPathParam(report, "id", catalog.ID.String())
+	require.NoError(t, app.GetCatalogReviewReport(report))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(report))
+
+	var reportResp struct {
+		Data struct {
+			Review   map[string]int64                  `json:"review"`
+			Products []handlers.CatalogProductResponse `json:"products"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(report), &reportResp))
+	assert.Equal(t, int64(1), reportResp.Data.Review["rejected"])
+	require.Len(t, reportResp.Data.Products, 1)
+	assert.Equal(t, "SKU-1", reportResp.Data.Products[0].RetailerID)
+}
+
+func TestApp_CreateProductSet_FromRetailerIDs(t *testing.T) {
+	t.Parallel()
+
+	m := newMockCommerceServer(t)
+	defer m.server.Close()
+	app := newCommerceTestApp(t, m)
+
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createCatalogTestAccount(t, app, org.ID)
+	catalog := createTestCatalog(t, app, org.ID, account.Name, "Store")
+	createTestSKUProduct(t, app, org.ID, catalog.ID, "SKU-1", 1000)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"name":         "Summer sale",
+		"retailer_ids": []string{"SKU-1", "SKU-9"},
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", catalog.ID.String())
+
+	require.NoError(t, app.CreateProductSet(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.ProductSetResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.NotEmpty(t, resp.Data.MetaProductSetID)
+	assert.Equal(t, 1, resp.Data.ProductCount, "only products in the catalog are counted")
+}
+
+func TestApp_CreateScheduledUpdate_Validation(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	catalog := createTestCatalog(t, app, org.ID, "test-account", "Store")
+	createTestSKUProduct(t, app, org.ID, catalog.ID, "SKU-1", 1000)
+
+	schedule := func(updates []map[string]interface{}) *fastglue.Request {
+		req := testutil.NewJSONRequest(t, map[string]interface{}{
+			"name":         "Black Friday",
+			"scheduled_at": time.Now().Add(time.Hour),
+			"updates":      updates,
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", catalog.ID.String())
+		require.NoError(t, app.CreateScheduledUpdate(req))
+		return req
+	}
+
+	req := schedule([]map[string]interface{}{{"retailer_id": "SKU-404", "price": 900}})
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "Unknown retailer IDs: SKU-404")
+
+	req = schedule([]map[string]interface{}{{"retailer_id": "SKU-1", "availability": "sold"}})
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "invalid availability")
+
+	req = schedule([]map[string]interface{}{{"retailer_id": "SKU-1", "sale_price": 800, "availability": "In_Stock"}})
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.ScheduledUpdateResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, models.CatalogScheduledUpdateStatusScheduled, resp.Data.Status)
+	require.Len(t, resp.Data.Updates, 1)
+	assert.Equal(t, "in stock", *resp.Data.Updates[0].Availability)
+
+	// Cancelling works once
+	for i, status := range []int{fasthttp.StatusOK, fasthttp.StatusBadRequest} {
+		cancel := testutil.NewJSONRequest(t, nil)
+		testutil.SetAuthContext(cancel, org.ID, user.ID)
+		testutil.SetPathParam(cancel, "id", catalog.ID.String())
+		testutil.SetPathParam(cancel, "update_id", resp.Data.ID.String())
+		require.NoError(t, app.CancelScheduledUpdate(cancel))
+		assert.Equal(t, status, testutil.GetResponseStatusCode(cancel), "cancel attempt %d", i+1)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 	"gorm.io/gorm"
@@ -21,11 +22,14 @@ type ChatbotSettingsResponse struct {
 	SessionTimeoutMinutes int                      `json:"session_timeout_minutes"`
 	BusinessHoursEnabled       bool                     `json:"business_hours_enabled"`
 	BusinessHours              []map[string]interface{} `json:"business_hours"`
+	BusinessHoursTimezone      string                   `json:"business_hours_timezone"`
+	BusinessHoursHolidays      []map[string]interface{} `json:"business_hours_holidays"`
 	OutOfHoursMessage          string                   `json:"out_of_hours_message"`
 	AllowAutomatedOutsideHours bool                     `json:"allow_automated_outside_hours"`
 	AllowAgentQueuePickup        bool                     `json:"allow_agent_queue_pickup"`
 	AssignToSameAgent            bool                     `json:"assign_to_same_agent"`
 	AgentCurrentConversationOnly bool                     `json:"agent_current_conversation_only"`
+	AgentIdleAwayMinutes         int                      `json:"agent_idle_away_minutes"`
 	AIEnabled                    bool                     `json:"ai_enabled"`
 	AIProvider            models.AIProvider        `json:"ai_provider"`
 	AIModel               string                   `json:"ai_model"`
@@ -46,6 +50,21 @@ type ChatbotSettingsResponse struct {
 	ClientReminderMessage  string `json:"client_reminder_message"`
 	ClientAutoCloseMinutes int    `json:"client_auto_close_minutes"`
 	ClientAutoCloseMessage string `json:"client_auto_close_message"`
+	// CSAT Survey Settings
+	CSATEnabled         bool               `json:"csat_enabled"`
+	CSATScale           models.CSATScale   `json:"csat_scale"`
+	CSATChannel         models.CSATChannel `json:"csat_channel"`
+	CSATMessage         string             `json:"csat_message"`
+	CSATFlowID          string             `json:"csat_flow_id"`
+	CSATDelayMinutes    int                `json:"csat_delay_minutes"`
+	CSATSuppressionDays int                `json:"csat_suppression_days"`
+	CSATThankYouMessage string             `json:"csat_thank_you_message"`
+	// Language Settings
+	DefaultLanguage      string       `json:"default_language"`
+	SupportedLanguages   []string     `json:"supported_languages"`
+	LanguageContactField string       `json:"language_contact_field"`
+	DetectLanguage       bool         `json:"detect_language"`
+	Translations         models.JSONB `json:"translations"`
 }
 
 // ChatbotStatsResponse represents chatbot statistics
@@ -68,6 +87,10 @@ type KeywordRuleResponse struct {
 	MatchType       models.MatchType   `json:"match_type"`
 	ResponseType    models.ResponseType `json:"response_type"`
 	ResponseContent json.RawMessage    `json:"response_content"`
+	CaseSensitive   bool               `json:"case_sensitive"`
+	Conditions      string             `json:"conditions"`
+	ActiveFrom      *time.Time         `json:"active_from,omitempty"`
+	ActiveUntil     *time.Time         `json:"active_until,omitempty"`
 	Priority        int                `json:"priority"`
 	Enabled         bool               `json:"enabled"`
 	CreatedAt       string             `json:"created_at"`
@@ -75,13 +98,15 @@ type KeywordRuleResponse struct {
 
 // ChatbotFlowResponse represents a chatbot flow for API response
 type ChatbotFlowResponse struct {
-	ID              string   `json:"id"`
-	Name            string   `json:"name"`
-	Description     string   `json:"description"`
-	TriggerKeywords []string `json:"trigger_keywords"`
-	Enabled         bool     `json:"enabled"`
-	StepsCount      int      `json:"steps_count"`
-	CreatedAt       string   `json:"created_at"`
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	TriggerKeywords  []string `json:"trigger_keywords"`
+	Enabled          bool     `json:"enabled"`
+	StepsCount       int      `json:"steps_count"`
+	PublishedVersion int      `json:"published_version"`
+	HasDraftChanges  bool     `json:"has_draft_changes"`
+	CreatedAt        string   `json:"created_at"`
 }
 
 // AIContextResponse represents an AI context for API response
@@ -113,6 +138,11 @@ func (a *App) GetChatbotSettings(r *fastglue.Request) error {
 			DefaultResponse:    "Hello! How can I help you today?",
 			SessionTimeoutMins: 30,
 			AI:                 models.AIConfig{Enabled: false},
+			CSAT: models.CSATConfig{
+				Scale:           models.CSATScaleCSAT,
+				Channel:         models.CSATChannelButtons,
+				SuppressionDays: 7,
+			},
 		}
 	}
 
@@ -148,6 +178,13 @@ func (a *App) GetChatbotSettings(r *fastglue.Request) error {
 		}
 	}
 
+	holidays := make([]map[string]interface{}, 0)
+	for _, h := range settings.BusinessHours.Holidays {
+		if hMap, ok := h.(map[string]interface{}); ok {
+			holidays = append(holidays, hMap)
+		}
+	}
+
 	settingsResp := ChatbotSettingsResponse{
 		Enabled:               settings.IsEnabled,
 		GreetingMessage:       settings.DefaultResponse,
@@ -158,12 +195,15 @@ func (a *App) GetChatbotSettings(r *fastglue.Request) error {
 		// Business Hours
 		BusinessHoursEnabled:       settings.BusinessHours.Enabled,
 		BusinessHours:              businessHours,
+		BusinessHoursTimezone:      settings.BusinessHours.Timezone,
+		BusinessHoursHolidays:      holidays,
 		OutOfHoursMessage:          settings.BusinessHours.OutOfHoursMessage,
 		AllowAutomatedOutsideHours: settings.BusinessHours.AllowAutomatedOutside,
 		// Agent Assignment
 		AllowAgentQueuePickup:        settings.AgentAssignment.AllowQueuePickup,
 		AssignToSameAgent:            settings.AgentAssignment.AssignToSameAgent,
 		AgentCurrentConversationOnly: settings.AgentAssignment.CurrentConversationOnly,
+		AgentIdleAwayMinutes:         settings.AgentAssignment.IdleAwayMinutes,
 		// AI
 		AIEnabled:      settings.AI.Enabled,
 		AIProvider:     settings.AI.Provider,
@@ -185,6 +225,27 @@ func (a *App) GetChatbotSettings(r *fastglue.Request) error {
 		ClientReminderMessage:  settings.ClientInactivity.ReminderMessage,
 		ClientAutoCloseMinutes: settings.ClientInactivity.AutoCloseMinutes,
 		ClientAutoCloseMessage: settings.ClientInactivity.AutoCloseMessage,
+		// CSAT Survey Settings
+		CSATEnabled:         settings.CSAT.Enabled,
+		CSATScale:           settings.CSAT.Scale,
+		CSATChannel:         settings.CSAT.Channel,
+		CSATMessage:         settings.CSAT.Message,
+		CSATFlowID:          settings.CSAT.FlowID,
+		CSATDelayMinutes:    settings.CSAT.DelayMinutes,
+		CSATSuppressionDays: settings.CSAT.SuppressionDays,
+		CSATThankYouMessage: settings.CSAT.ThankYouMessage,
+		// Language Settings
+		DefaultLanguage:      settings.Language.DefaultLanguage,
+		SupportedLanguages:   settings.Language.SupportedLanguages,
+		LanguageContactField: settings.Language.ContactField,
+		DetectLanguage:       settings.Language.DetectLanguage,
+		Translations:         settings.Translations,
+	}
+	if settingsResp.SupportedLanguages == nil {
+		settingsResp.SupportedLanguages = []string{}
+	}
+	if settingsResp.Translations == nil {
+		settingsResp.Translations = models.JSONB{}
 	}
 
 	return r.SendEnvelope(map[string]interface{}{
@@ -209,11 +270,14 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 		SessionTimeoutMinutes      *int                       `json:"session_timeout_minutes"`
 		BusinessHoursEnabled       *bool                      `json:"business_hours_enabled"`
 		BusinessHours              *[]map[string]interface{}  `json:"business_hours"`
+		BusinessHoursTimezone      *string                    `json:"business_hours_timezone"`
+		BusinessHoursHolidays      *[]map[string]interface{}  `json:"business_hours_holidays"`
 		OutOfHoursMessage          *string                    `json:"out_of_hours_message"`
 		AllowAutomatedOutsideHours *bool                      `json:"allow_automated_outside_hours"`
 		AllowAgentQueuePickup        *bool                      `json:"allow_agent_queue_pickup"`
 		AssignToSameAgent            *bool                      `json:"assign_to_same_agent"`
 		AgentCurrentConversationOnly *bool                      `json:"agent_current_conversation_only"`
+		AgentIdleAwayMinutes         *int                       `json:"agent_idle_away_minutes"`
 		AIEnabled                    *bool                      `json:"ai_enabled"`
 		AIProvider                 *models.AIProvider         `json:"ai_provider"`
 		AIAPIKey                   *string                    `json:"ai_api_key"`
@@ -235,6 +299,21 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 		ClientReminderMessage  *string `json:"client_reminder_message"`
 		ClientAutoCloseMinutes *int    `json:"client_auto_close_minutes"`
 		ClientAutoCloseMessage *string `json:"client_auto_close_message"`
+		// CSAT Survey Settings
+		CSATEnabled         *bool               `json:"csat_enabled"`
+		CSATScale           *models.CSATScale   `json:"csat_scale"`
+		CSATChannel         *models.CSATChannel `json:"csat_channel"`
+		CSATMessage         *string             `json:"csat_message"`
+		CSATFlowID          *string             `json:"csat_flow_id"`
+		CSATDelayMinutes    *int                `json:"csat_delay_minutes"`
+		CSATSuppressionDays *int                `json:"csat_suppression_days"`
+		CSATThankYouMessage *string             `json:"csat_thank_you_message"`
+		// Language Settings
+		DefaultLanguage      *string      `json:"default_language"`
+		SupportedLanguages   *[]string    `json:"supported_languages"`
+		LanguageContactField *string      `json:"language_contact_field"`
+		DetectLanguage       *bool        `json:"detect_language"`
+		Translations         Translations `json:"translations"`
 	}
 
 	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
@@ -292,12 +371,25 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 		}
 		settings.BusinessHours.Hours = hours
 	}
+	if req.BusinessHoursTimezone != nil {
+		settings.BusinessHours.Timezone = *req.BusinessHoursTimezone
+	}
+	if req.BusinessHoursHolidays != nil {
+		holidays := make([]interface{}, len(*req.BusinessHoursHolidays))
+		for i, h := range *req.BusinessHoursHolidays {
+			holidays[i] = h
+		}
+		settings.BusinessHours.Holidays = holidays
+	}
 	if req.OutOfHoursMessage != nil {
 		settings.BusinessHours.OutOfHoursMessage = *req.OutOfHoursMessage
 	}
 	if req.AllowAutomatedOutsideHours != nil {
 		settings.BusinessHours.AllowAutomatedOutside = *req.AllowAutomatedOutsideHours
 	}
+	if err := validateBusinessHours(settings.BusinessHours); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid business hours: "+err.Error(), nil, "")
+	}
 
 	// Agent Assignment
 	if req.AllowAgentQueuePickup != nil {
@@ -309,6 +401,12 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 	if req.AgentCurrentConversationOnly != nil {
 		settings.AgentAssignment.CurrentConversationOnly = *req.AgentCurrentConversationOnly
 	}
+	if req.AgentIdleAwayMinutes != nil {
+		if *req.AgentIdleAwayMinutes < 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "agent_idle_away_minutes must be 0 (disabled) or more", nil, "")
+		}
+		settings.AgentAssignment.IdleAwayMinutes = *req.AgentIdleAwayMinutes
+	}
 
 	// AI Settings
 	if req.AIEnabled != nil {
@@ -373,6 +471,66 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 		settings.ClientInactivity.AutoCloseMessage = *req.ClientAutoCloseMessage
 	}
 
+	// CSAT Survey Settings
+	if req.CSATEnabled != nil {
+		settings.CSAT.Enabled = *req.CSATEnabled
+	}
+	if req.CSATScale != nil {
+		settings.CSAT.Scale = *req.CSATScale
+	}
+	if req.CSATChannel != nil {
+		settings.CSAT.Channel = *req.CSATChannel
+	}
+	if req.CSATMessage != nil {
+		settings.CSAT.Message = *req.CSATMessage
+	}
+	if req.CSATFlowID != nil {
+		settings.CSAT.FlowID = *req.CSATFlowID
+	}
+	if req.CSATDelayMinutes != nil {
+		settings.CSAT.DelayMinutes = *req.CSATDelayMinutes
+	}
+	if req.CSATThankYouMessage != nil {
+		settings.CSAT.ThankYouMessage = *req.CSATThankYouMessage
+	}
+	if req.CSATSuppressionDays != nil {
+		settings.CSAT.SuppressionDays = *req.CSATSuppressionDays
+	} else if isNew {
+		settings.CSAT.SuppressionDays = 7
+	}
+	if settings.CSAT.Scale == "" {
+		settings.CSAT.Scale = models.CSATScaleCSAT
+	}
+	if settings.CSAT.Channel == "" {
+		settings.CSAT.Channel = models.CSATChannelButtons
+	}
+	if err := validateCSATConfig(settings.CSAT); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid CSAT settings: "+err.Error(), nil, "")
+	}
+
+	// Language Settings
+	if req.DefaultLanguage != nil {
+		settings.Language.DefaultLanguage = templateutil.NormalizeLanguage(*req.DefaultLanguage)
+	}
+	if req.SupportedLanguages != nil {
+		languages := make(models.StringArray, 0, len(*req.SupportedLanguages))
+		for _, lang := range *req.SupportedLanguages {
+			if lang = templateutil.NormalizeLanguage(lang); lang != "" {
+				languages = append(languages, lang)
+			}
+		}
+		settings.Language.SupportedLanguages = languages
+	}
+	if req.LanguageContactField != nil {
+		settings.Language.ContactField = *req.LanguageContactField
+	}
+	if req.DetectLanguage != nil {
+		settings.Language.DetectLanguage = *req.DetectLanguage
+	}
+	if req.Translations != nil {
+		settings.Translations = translationsJSONB(req.Translations)
+	}
+
 	if err := a.DB.Save(&settings).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save settings", nil, "")
 	}
@@ -392,6 +550,9 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 		if req.AssignToSameAgent != nil && !*req.AssignToSameAgent {
 			zeroOverrides["assign_to_same_agent"] = false
 		}
+		if req.CSATSuppressionDays != nil && *req.CSATSuppressionDays == 0 {
+			zeroOverrides["csat_suppression_days"] = 0
+		}
 		if len(zeroOverrides) > 0 {
 			if err := a.DB.Model(&settings).Updates(zeroOverrides).Error; err != nil {
 				return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save settings", nil, "")
@@ -446,6 +607,10 @@ func (a *App) ListKeywordRules(r *fastglue.Request) error {
 			MatchType:       rule.MatchType,
 			ResponseType:    rule.ResponseType,
 			ResponseContent: responseContent,
+			CaseSensitive:   rule.CaseSensitive,
+			Conditions:      rule.Conditions,
+			ActiveFrom:      rule.ActiveFrom,
+			ActiveUntil:     rule.ActiveUntil,
 			Priority:        rule.Priority,
 			Enabled:         rule.IsEnabled,
 			CreatedAt:       rule.CreatedAt.Format(time.RFC3339),
@@ -473,6 +638,10 @@ func (a *App) CreateKeywordRule(r *fastglue.Request) error {
 		MatchType       models.MatchType       `json:"match_type"`
 		ResponseType    models.ResponseType    `json:"response_type"`
 		ResponseContent map[string]interface{} `json:"response_content"`
+		CaseSensitive   bool                   `json:"case_sensitive"`
+		Conditions      string                 `json:"conditions"`
+		ActiveFrom      string                 `json:"active_from"`
+		ActiveUntil     string                 `json:"active_until"`
 		Priority        int                    `json:"priority"`
 		Enabled         bool                   `json:"enabled"`
 	}
@@ -495,6 +664,13 @@ func (a *App) CreateKeywordRule(r *fastglue.Request) error {
 	if req.Name == "" {
 		req.Name = req.Keywords[0]
 	}
+	if err := validateKeywordResponse(req.ResponseType, req.ResponseContent); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid keyword rule: "+err.Error(), nil, "")
+	}
+	activeFrom, activeUntil, err := parseKeywordRuleWindow(req.ActiveFrom, req.ActiveUntil)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid keyword rule: "+err.Error(), nil, "")
+	}
 
 	rule := models.KeywordRule{
 		BaseModel:       models.BaseModel{ID: uuid.New()},
@@ -504,6 +680,10 @@ func (a *App) CreateKeywordRule(r *fastglue.Request) error {
 		MatchType:       req.MatchType,
 		ResponseType:    req.ResponseType,
 		ResponseContent: models.JSONB(req.ResponseContent),
+		CaseSensitive:   req.CaseSensitive,
+		Conditions:      req.Conditions,
+		ActiveFrom:      activeFrom,
+		ActiveUntil:     activeUntil,
 		Priority:        req.Priority,
 		IsEnabled:       req.Enabled,
 	}
@@ -546,6 +726,10 @@ func (a *App) GetKeywordRule(r *fastglue.Request) error {
 		MatchType:       rule.MatchType,
 		ResponseType:    rule.ResponseType,
 		ResponseContent: responseContent,
+		CaseSensitive:   rule.CaseSensitive,
+		Conditions:      rule.Conditions,
+		ActiveFrom:      rule.ActiveFrom,
+		ActiveUntil:     rule.ActiveUntil,
 		Priority:        rule.Priority,
 		Enabled:         rule.IsEnabled,
 		CreatedAt:       rule.CreatedAt.Format(time.RFC3339),
@@ -577,6 +761,10 @@ func (a *App) UpdateKeywordRule(r *fastglue.Request) error {
 		MatchType       *models.MatchType       `json:"match_type"`
 		ResponseType    *models.ResponseType    `json:"response_type"`
 		ResponseContent map[string]interface{}  `json:"response_content"`
+		CaseSensitive   *bool                   `json:"case_sensitive"`
+		Conditions      *string                 `json:"conditions"`
+		ActiveFrom      *string                 `json:"active_from"`  // "" clears the bound
+		ActiveUntil     *string                 `json:"active_until"` // "" clears the bound
 		Priority        *int                    `json:"priority"`
 		Enabled         *bool                   `json:"enabled"`
 	}
@@ -601,12 +789,40 @@ func (a *App) UpdateKeywordRule(r *fastglue.Request) error {
 	if req.ResponseContent != nil {
 		rule.ResponseContent = models.JSONB(req.ResponseContent)
 	}
+	if req.CaseSensitive != nil {
+		rule.CaseSensitive = *req.CaseSensitive
+	}
+	if req.Conditions != nil {
+		rule.Conditions = *req.Conditions
+	}
 	if req.Priority != nil {
 		rule.Priority = *req.Priority
 	}
 	if req.Enabled != nil {
 		rule.IsEnabled = *req.Enabled
 	}
+	if err := validateKeywordResponse(rule.ResponseType, rule.ResponseContent); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid keyword rule: "+err.Error(), nil, "")
+	}
+
+	// Schedule window: omitted fields keep their bound, "" clears it
+	from, until := "", ""
+	if rule.ActiveFrom != nil {
+		from = rule.ActiveFrom.Format(time.RFC3339Nano)
+	}
+	if rule.ActiveUntil != nil {
+		until = rule.ActiveUntil.Format(time.RFC3339Nano)
+	}
+	if req.ActiveFrom != nil {
+		from = *req.ActiveFrom
+	}
+	if req.ActiveUntil != nil {
+		until = *req.ActiveUntil
+	}
+	rule.ActiveFrom, rule.ActiveUntil, err = parseKeywordRuleWindow(from, until)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid keyword rule: "+err.Error(), nil, "")
+	}
 
 	if err := a.DB.Save(rule).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update keyword rule", nil, "")
@@ -682,13 +898,15 @@ func (a *App) ListChatbotFlows(r *fastglue.Request) error {
 	response := make([]ChatbotFlowResponse, len(flows))
 	for i, flow := range flows {
 		response[i] = ChatbotFlowResponse{
-			ID:              flow.ID.String(),
-			Name:            flow.Name,
-			Description:     flow.Description,
-			TriggerKeywords: flow.TriggerKeywords,
-			Enabled:         flow.IsEnabled,
-			StepsCount:      len(flow.Steps),
-			CreatedAt:       flow.CreatedAt.Format(time.RFC3339),
+			ID:               flow.ID.String(),
+			Name:             flow.Name,
+			Description:      flow.Description,
+			TriggerKeywords:  flow.TriggerKeywords,
+			Enabled:          flow.IsEnabled,
+			StepsCount:       len(flow.Steps),
+			PublishedVersion: flow.PublishedVersion,
+			HasDraftChanges:  flow.HasDraftChanges,
+			CreatedAt:        flow.CreatedAt.Format(time.RFC3339),
 		}
 	}
 
@@ -711,6 +929,10 @@ type FlowStepRequest struct {
 	ApiConfig       map[string]interface{}   `json:"api_config"`
 	Buttons         []map[string]interface{} `json:"buttons"`
 	TransferConfig  map[string]interface{}   `json:"transfer_config"`
+	JumpConfig      map[string]interface{}   `json:"jump_config"`
+	ActionConfig    map[string]interface{}   `json:"action_config"`
+	MediaConfig     map[string]interface{}   `json:"media_config"`
+	TemplateID      *uuid.UUID               `json:"template_id"`
 	ValidationRegex string                   `json:"validation_regex"`
 	ValidationError string                   `json:"validation_error"`
 	StoreAs         string                   `json:"store_as"`
@@ -719,6 +941,8 @@ type FlowStepRequest struct {
 	SkipCondition   string                   `json:"skip_condition"`
 	RetryOnInvalid  bool                     `json:"retry_on_invalid"`
 	MaxRetries      int                      `json:"max_retries"`
+	Position        map[string]interface{}   `json:"position"`
+	Translations    Translations             `json:"translations"`
 }
 
 // CreateChatbotFlow creates a new chatbot flow
@@ -740,7 +964,10 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 		CompletionMessage string                 `json:"completion_message"`
 		OnCompleteAction  string                 `json:"on_complete_action"`
 		CompletionConfig  map[string]interface{} `json:"completion_config"`
+		TimeoutMessage    string                 `json:"timeout_message"`
+		CancelKeywords    []string               `json:"cancel_keywords"`
 		PanelConfig       map[string]interface{} `json:"panel_config"`
+		Translations      Translations           `json:"translations"`
 		Enabled           bool                   `json:"enabled"`
 		Steps             []FlowStepRequest      `json:"steps"`
 	}
@@ -753,10 +980,18 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Name is required", nil, "")
 	}
 
+	flowID := uuid.New()
+	steps := buildFlowSteps(flowID, req.Steps)
+
+	// Validate the step graph before saving
+	graph := a.validateFlowSteps(orgID, flowID, req.Name, steps)
+	if graph.HasErrors() {
+		return sendFlowGraphErrors(r, graph)
+	}
+
 	// Use transaction for flow + steps
 	tx := a.DB.Begin()
 
-	flowID := uuid.New()
 	flow := models.ChatbotFlow{
 		BaseModel:         models.BaseModel{ID: flowID},
 		OrganizationID:    orgID,
@@ -767,7 +1002,10 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 		CompletionMessage: req.CompletionMessage,
 		OnCompleteAction:  req.OnCompleteAction,
 		CompletionConfig:  models.JSONB(req.CompletionConfig),
+		TimeoutMessage:    req.TimeoutMessage,
+		CancelKeywords:    req.CancelKeywords,
 		PanelConfig:       models.JSONB(req.PanelConfig),
+		Translations:      translationsJSONB(req.Translations),
 		IsEnabled:         req.Enabled,
 	}
 
@@ -777,41 +1015,8 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 	}
 
 	// Create steps
-	for i, stepReq := range req.Steps {
-		// Convert buttons to JSONBArray
-		var buttons models.JSONBArray
-		for _, btn := range stepReq.Buttons {
-			buttons = append(buttons, btn)
-		}
-
-		step := models.ChatbotFlowStep{
-			BaseModel:       models.BaseModel{ID: uuid.New()},
-			FlowID:          flowID,
-			StepName:        stepReq.StepName,
-			StepOrder:       i + 1,
-			Message:         stepReq.Message,
-			MessageType:     stepReq.MessageType,
-			InputType:       stepReq.InputType,
-			InputConfig:     models.JSONB(stepReq.InputConfig),
-			ApiConfig:       models.JSONB(stepReq.ApiConfig),
-			Buttons:         buttons,
-			TransferConfig:  models.JSONB(stepReq.TransferConfig),
-			ValidationRegex: stepReq.ValidationRegex,
-			ValidationError: stepReq.ValidationError,
-			StoreAs:         stepReq.StoreAs,
-			NextStep:        stepReq.NextStep,
-			ConditionalNext: models.JSONB(stepReq.ConditionalNext),
-			SkipCondition:   stepReq.SkipCondition,
-			RetryOnInvalid:  stepReq.RetryOnInvalid,
-			MaxRetries:      stepReq.MaxRetries,
-		}
-		if step.MessageType == "" {
-			step.MessageType = models.FlowStepTypeText
-		}
-		if step.MaxRetries == 0 {
-			step.MaxRetries = 3
-		}
-		if err := tx.Create(&step).Error; err != nil {
+	for i := range steps {
+		if err := tx.Create(&steps[i]).Error; err != nil {
 			tx.Rollback()
 			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create flow step", nil, "")
 		}
@@ -823,8 +1028,9 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 	a.InvalidateChatbotFlowsCache(orgID)
 
 	return r.SendEnvelope(map[string]interface{}{
-		"id":      flow.ID.String(),
-		"message": "Flow created successfully",
+		"id":       flow.ID.String(),
+		"message":  "Flow created successfully",
+		"warnings": graph.Warnings(),
 	})
 }
 
@@ -885,7 +1091,10 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 		CompletionMessage *string                `json:"completion_message"`
 		OnCompleteAction  *string                `json:"on_complete_action"`
 		CompletionConfig  map[string]interface{} `json:"completion_config"`
+		TimeoutMessage    *string                `json:"timeout_message"`
+		CancelKeywords    []string               `json:"cancel_keywords"`
 		PanelConfig       map[string]interface{} `json:"panel_config"`
+		Translations      Translations           `json:"translations"`
 		Enabled           *bool                  `json:"enabled"`
 		Steps             []FlowStepRequest      `json:"steps"`
 	}
@@ -894,6 +1103,25 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
 	}
 
+	// Validate the new step graph before saving
+	var steps []models.ChatbotFlowStep
+	warnings := make([]FlowGraphIssue, 0)
+	if len(req.Steps) > 0 {
+		name := flow.Name
+		if req.Name != nil {
+			name = *req.Name
+		}
+		steps = buildFlowSteps(id, req.Steps)
+		graph := a.validateFlowSteps(orgID, id, name, steps)
+		if graph.HasErrors() {
+			return sendFlowGraphErrors(r, graph)
+		}
+		warnings = graph.Warnings()
+	}
+
+	// Compare the content before and after the update to tell whether the draft changed
+	before := toDiffMap(flow)
+
 	tx := a.DB.Begin()
 
 	if req.Name != nil {
@@ -917,13 +1145,36 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 	if req.CompletionConfig != nil {
 		flow.CompletionConfig = models.JSONB(req.CompletionConfig)
 	}
+	if req.TimeoutMessage != nil {
+		flow.TimeoutMessage = *req.TimeoutMessage
+	}
+	if req.CancelKeywords != nil {
+		flow.CancelKeywords = req.CancelKeywords
+	}
 	if req.PanelConfig != nil {
 		flow.PanelConfig = models.JSONB(req.PanelConfig)
 	}
+	if req.Translations != nil {
+		flow.Translations = translationsJSONB(req.Translations)
+	}
 	if req.Enabled != nil {
 		flow.IsEnabled = *req.Enabled
 	}
 
+	// Once published, edits stay in the draft until the flow is published again
+	if flow.PublishedVersion > 0 && !flow.HasDraftChanges {
+		contentChanged := len(diffFields(before, toDiffMap(flow))) > 0
+		if !contentChanged && len(req.Steps) > 0 {
+			var current []models.ChatbotFlowStep
+			if err := tx.Where("flow_id = ?", id).Order("step_order ASC").Find(&current).Error; err != nil {
+				tx.Rollback()
+				return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update flow", nil, "")
+			}
+			contentChanged = flowStepsChanged(current, steps)
+		}
+		flow.HasDraftChanges = contentChanged
+	}
+
 	if err := tx.Save(flow).Error; err != nil {
 		tx.Rollback()
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update flow", nil, "")
@@ -938,41 +1189,8 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 		}
 
 		// Create new steps
-		for i, stepReq := range req.Steps {
-			// Convert buttons to JSONBArray
-			var buttons models.JSONBArray
-			for _, btn := range stepReq.Buttons {
-				buttons = append(buttons, btn)
-			}
-
-			step := models.ChatbotFlowStep{
-				BaseModel:       models.BaseModel{ID: uuid.New()},
-				FlowID:          id,
-				StepName:        stepReq.StepName,
-				StepOrder:       i + 1,
-				Message:         stepReq.Message,
-				MessageType:     stepReq.MessageType,
-				InputType:       stepReq.InputType,
-				InputConfig:     models.JSONB(stepReq.InputConfig),
-				ApiConfig:       models.JSONB(stepReq.ApiConfig),
-				Buttons:         buttons,
-				TransferConfig:  models.JSONB(stepReq.TransferConfig),
-				ValidationRegex: stepReq.ValidationRegex,
-				ValidationError: stepReq.ValidationError,
-				StoreAs:         stepReq.StoreAs,
-				NextStep:        stepReq.NextStep,
-				ConditionalNext: models.JSONB(stepReq.ConditionalNext),
-				SkipCondition:   stepReq.SkipCondition,
-				RetryOnInvalid:  stepReq.RetryOnInvalid,
-				MaxRetries:      stepReq.MaxRetries,
-			}
-			if step.MessageType == "" {
-				step.MessageType = models.FlowStepTypeText
-			}
-			if step.MaxRetries == 0 {
-				step.MaxRetries = 3
-			}
-			if err := tx.Create(&step).Error; err != nil {
+		for i := range steps {
+			if err := tx.Create(&steps[i]).Error; err != nil {
 				tx.Rollback()
 				return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create flow step", nil, "")
 			}
@@ -985,7 +1203,10 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 	a.InvalidateChatbotFlowsCache(orgID)
 
 	return r.SendEnvelope(map[string]interface{}{
-		"message": "Flow updated successfully",
+		"message":           "Flow updated successfully",
+		"warnings":          warnings,
+		"published_version": flow.PublishedVersion,
+		"has_draft_changes": flow.HasDraftChanges,
 	})
 }
 
@@ -1014,6 +1235,12 @@ func (a *App) DeleteChatbotFlow(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete flow steps", nil, "")
 	}
 
+	// Delete published versions
+	if err := tx.Where("flow_id = ? AND organization_id = ?", id, orgID).Delete(&models.ChatbotFlowVersion{}).Error; err != nil {
+		tx.Rollback()
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete flow versions", nil, "")
+	}
+
 	// Delete flow
 	result := tx.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.ChatbotFlow{})
 	if result.Error != nil {
@@ -1029,6 +1256,7 @@ func (a *App) DeleteChatbotFlow(r *fastglue.Request) error {
 
 	// Invalidate cache
 	a.InvalidateChatbotFlowsCache(orgID)
+	a.InvalidateChatbotFlowVersionsCache(orgID, id)
 
 	return r.SendEnvelope(map[string]interface{}{
 		"message": "Flow deleted successfully",
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/queue"
+)
+
+// flowDelayBatchSize limits how many delayed sessions are resumed per tick
+const flowDelayBatchSize = 100
+
+// flowDelayLease names the leader lease of the flow delay processor
+const flowDelayLease = "flow_delay"
+
+// FlowDelayProcessor resumes chatbot flows paused at delay steps. With
+// several replicas only the one holding the leader lease resumes sessions.
+type FlowDelayProcessor struct {
+	app      *App
+	interval time.Duration
+	lease    *queue.Lease
+	stopCh   chan struct{}
+}
+
+// NewFlowDelayProcessor creates a new flow delay processor
+func NewFlowDelayProcessor(app *App, interval time.Duration) *FlowDelayProcessor {
+	return &FlowDelayProcessor{
+		app:      app,
+		interval: interval,
+		lease:    app.newProcessorLease(flowDelayLease, 2*interval),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the flow delay processing loop
+func (p *FlowDelayProcessor) Start(ctx context.Context) {
+	p.app.Log.Info("Flow delay processor started", "interval", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	defer p.app.releaseLease(p.lease, flowDelayLease)
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.app.Log.Info("Flow delay processor stopped by context")
+			return
+		case <-p.stopCh:
+			p.app.Log.Info("Flow delay processor stopped")
+			return
+		case <-ticker.C:
+			if p.app.holdLease(p.lease, flowDelayLease) {
+				p.resumeDueSessions()
+			}
+		}
+	}
+}
+
+// Stop stops the flow delay processor
+func (p *FlowDelayProcessor) Stop() {
+	close(p.stopCh)
+}
+
+// resumeDueSessions continues every active session whose delay has elapsed
+func (p *FlowDelayProcessor) resumeDueSessions() {
+	var sessions []models.ChatbotSession
+	if err := p.app.DB.Where("status = ? AND resume_at IS NOT NULL AND resume_at <= ?", models.SessionStatusActive, time.Now()).
+		Order("resume_at ASC").
+		Limit(flowDelayBatchSize).
+		Find(&sessions).Error; err != nil {
+		p.app.Log.Error("Failed to load delayed chatbot sessions", "error", err)
+		return
+	}
+
+	for i := range sessions {
+		p.app.resumeDelayedFlow(&sessions[i])
+	}
+}
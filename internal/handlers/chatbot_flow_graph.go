@@ -0,0 +1,518 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// maxFlowCallDepth limits how deeply call_flow steps can nest sub-flows
+const maxFlowCallDepth = 10
+
+// Flow graph edge kinds
+const (
+	FlowEdgeNext        = "next"        // Explicit next_step
+	FlowEdgeOrder       = "order"       // Implicit next step by step_order
+	FlowEdgeConditional = "conditional" // conditional_next entry
+	FlowEdgeGoto        = "goto"        // goto_flow jump into another flow
+	FlowEdgeCall        = "call"        // call_flow into a sub-flow
+	FlowEdgeReturn      = "return"      // Where a call_flow step resumes after the sub-flow completes
+)
+
+// Flow graph issue severities
+const (
+	FlowIssueError   = "error"
+	FlowIssueWarning = "warning"
+)
+
+// FlowGraphNode is a single step in the flow graph
+type FlowGraphNode struct {
+	ID            string              `json:"id"` // Step name
+	StepOrder     int                 `json:"step_order"`
+	MessageType   models.FlowStepType `json:"message_type"`
+	InputType     models.InputType    `json:"input_type"`
+	Message       string              `json:"message"`
+	WaitsForInput bool                `json:"waits_for_input"`
+	IsEntry       bool                `json:"is_entry"`
+	Reachable     bool                `json:"reachable"`
+	Position      models.JSONB        `json:"position,omitempty"`
+}
+
+// FlowGraphEdge is a transition between two steps, or from a step into another flow
+type FlowGraphEdge struct {
+	Source         string `json:"source"`
+	Target         string `json:"target"` // Step name (target flow's step for goto/call, empty = entry step)
+	Kind           string `json:"kind"`
+	Label          string `json:"label,omitempty"` // Condition value for conditional edges
+	TargetFlowID   string `json:"target_flow_id,omitempty"`
+	TargetFlowName string `json:"target_flow_name,omitempty"`
+}
+
+// FlowGraphIssue describes a validation problem found in the flow graph
+type FlowGraphIssue struct {
+	Severity string `json:"severity"` // error, warning
+	Code     string `json:"code"`     // duplicate_step, missing_step_name, dangling_edge, unreachable_step, input_less_cycle, invalid_jump, invalid_step_config
+	Step     string `json:"step,omitempty"`
+	Message  string `json:"message"`
+}
+
+// FlowGraph is the graph representation of a chatbot flow used by the visual builder
+type FlowGraph struct {
+	FlowID    string           `json:"flow_id,omitempty"`
+	FlowName  string           `json:"flow_name,omitempty"`
+	EntryStep string           `json:"entry_step"`
+	Nodes     []FlowGraphNode  `json:"nodes"`
+	Edges     []FlowGraphEdge  `json:"edges"`
+	Issues    []FlowGraphIssue `json:"issues"`
+}
+
+// HasErrors reports whether the graph has any error-level issues
+func (g *FlowGraph) HasErrors() bool {
+	for _, issue := range g.Issues {
+		if issue.Severity == FlowIssueError {
+			return true
+		}
+	}
+	return false
+}
+
+// Warnings returns only the warning-level issues
+func (g *FlowGraph) Warnings() []FlowGraphIssue {
+	warnings := make([]FlowGraphIssue, 0)
+	for _, issue := range g.Issues {
+		if issue.Severity == FlowIssueWarning {
+			warnings = append(warnings, issue)
+		}
+	}
+	return warnings
+}
+
+func (g *FlowGraph) addIssue(severity, code, step, message string) {
+	g.Issues = append(g.Issues, FlowGraphIssue{
+		Severity: severity,
+		Code:     code,
+		Step:     step,
+		Message:  message,
+	})
+}
+
+// isJumpStep reports whether the step leaves the current flow (goto_flow or call_flow)
+func isJumpStep(step *models.ChatbotFlowStep) bool {
+	return step.MessageType == models.FlowStepTypeGotoFlow || step.MessageType == models.FlowStepTypeCallFlow
+}
+
+// stepWaitsForInput reports whether the flow pauses at this step until the customer replies
+func stepWaitsForInput(step *models.ChatbotFlowStep) bool {
+	switch step.MessageType {
+	case models.FlowStepTypeTransfer, models.FlowStepTypeGotoFlow, models.FlowStepTypeCallFlow,
+		models.FlowStepTypeDelay, models.FlowStepTypeSetVariable, models.FlowStepTypeCondition, models.FlowStepTypeAssignTag:
+		return false
+	}
+	return step.InputType != models.InputTypeNone
+}
+
+// stepAutoNext returns the step the flow advances to without considering conditional_next:
+// the explicit next_step, or the following step by order. Empty means the flow completes.
+func stepAutoNext(steps []models.ChatbotFlowStep, index int) (string, string) {
+	if steps[index].NextStep != "" {
+		return steps[index].NextStep, FlowEdgeNext
+	}
+	if index+1 < len(steps) {
+		return steps[index+1].StepName, FlowEdgeOrder
+	}
+	return "", ""
+}
+
+// jumpTarget extracts the target flow ID and optional entry step from a goto_flow/call_flow step
+func jumpTarget(step *models.ChatbotFlowStep) (uuid.UUID, string, error) {
+	if step.JumpConfig == nil {
+		return uuid.Nil, "", fmt.Errorf("jump_config is required")
+	}
+	flowIDStr, _ := step.JumpConfig["flow_id"].(string)
+	if flowIDStr == "" {
+		return uuid.Nil, "", fmt.Errorf("jump_config.flow_id is required")
+	}
+	flowID, err := uuid.Parse(flowIDStr)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("jump_config.flow_id is not a valid UUID")
+	}
+	stepName, _ := step.JumpConfig["step_name"].(string)
+	return flowID, stepName, nil
+}
+
+// buildFlowGraph converts ordered flow steps into a graph and validates its structure.
+// Cross-flow jump targets are only checked for syntax here; see checkFlowJumpTargets.
+func buildFlowGraph(steps []models.ChatbotFlowStep) *FlowGraph {
+	graph := &FlowGraph{
+		Nodes:  make([]FlowGraphNode, 0, len(steps)),
+		Edges:  make([]FlowGraphEdge, 0),
+		Issues: make([]FlowGraphIssue, 0),
+	}
+
+	index := make(map[string]int, len(steps))
+	for i := range steps {
+		name := steps[i].StepName
+		if name == "" {
+			graph.addIssue(FlowIssueError, "missing_step_name", "", fmt.Sprintf("Step %d has no name", i+1))
+			continue
+		}
+		if _, exists := index[name]; exists {
+			graph.addIssue(FlowIssueError, "duplicate_step", name, fmt.Sprintf("Step name '%s' is used more than once", name))
+			continue
+		}
+		index[name] = i
+	}
+
+	if len(steps) > 0 {
+		graph.EntryStep = steps[0].StepName
+	}
+
+	// Adjacency (all edges) used for reachability
+	adjacency := make(map[string][]string, len(steps))
+	addEdge := func(edge FlowGraphEdge) {
+		graph.Edges = append(graph.Edges, edge)
+		if edge.TargetFlowID != "" {
+			return
+		}
+		if _, ok := index[edge.Target]; !ok {
+			graph.addIssue(FlowIssueError, "dangling_edge", edge.Source,
+				fmt.Sprintf("Step '%s' points to unknown step '%s'", edge.Source, edge.Target))
+			return
+		}
+		adjacency[edge.Source] = append(adjacency[edge.Source], edge.Target)
+	}
+
+	for i := range steps {
+		step := &steps[i]
+		graph.Nodes = append(graph.Nodes, FlowGraphNode{
+			ID:            step.StepName,
+			StepOrder:     i + 1,
+			MessageType:   step.MessageType,
+			InputType:     step.InputType,
+			Message:       step.Message,
+			WaitsForInput: stepWaitsForInput(step),
+			IsEntry:       i == 0,
+			Position:      step.Position,
+		})
+		if step.StepName == "" {
+			continue
+		}
+		if err := validateFlowStepConfig(step); err != nil {
+			graph.addIssue(FlowIssueError, "invalid_step_config", step.StepName,
+				fmt.Sprintf("Step '%s': %s", step.StepName, err.Error()))
+		}
+
+		switch step.MessageType {
+		case models.FlowStepTypeTransfer:
+			// Transfer ends the flow - no outgoing edges
+			continue
+		case models.FlowStepTypeGotoFlow, models.FlowStepTypeCallFlow:
+			flowID, entry, err := jumpTarget(step)
+			if err != nil {
+				graph.addIssue(FlowIssueError, "invalid_jump", step.StepName,
+					fmt.Sprintf("Step '%s': %s", step.StepName, err.Error()))
+			} else {
+				kind := FlowEdgeGoto
+				if step.MessageType == models.FlowStepTypeCallFlow {
+					kind = FlowEdgeCall
+				}
+				addEdge(FlowGraphEdge{Source: step.StepName, Target: entry, Kind: kind, TargetFlowID: flowID.String()})
+			}
+			if step.MessageType == models.FlowStepTypeGotoFlow {
+				continue
+			}
+			if next, _ := stepAutoNext(steps, i); next != "" {
+				addEdge(FlowGraphEdge{Source: step.StepName, Target: next, Kind: FlowEdgeReturn})
+			}
+			continue
+		}
+
+		if step.MessageType == models.FlowStepTypeCondition {
+			if branches, defaultNext, err := flowBranches(step); err == nil {
+				for _, b := range branches {
+					addEdge(FlowGraphEdge{Source: step.StepName, Target: b.Next, Kind: FlowEdgeConditional, Label: b.Condition})
+				}
+				if defaultNext != "" {
+					addEdge(FlowGraphEdge{Source: step.StepName, Target: defaultNext, Kind: FlowEdgeConditional, Label: "default"})
+					continue
+				}
+			}
+		}
+
+		if next, kind := stepAutoNext(steps, i); next != "" {
+			addEdge(FlowGraphEdge{Source: step.StepName, Target: next, Kind: kind})
+		}
+		for value, target := range step.ConditionalNext {
+			targetName, ok := target.(string)
+			if !ok || targetName == "" {
+				continue
+			}
+			addEdge(FlowGraphEdge{Source: step.StepName, Target: targetName, Kind: FlowEdgeConditional, Label: value})
+		}
+	}
+
+	// Reachability from the entry step
+	reachable := make(map[string]bool, len(steps))
+	if graph.EntryStep != "" {
+		queue := []string{graph.EntryStep}
+		reachable[graph.EntryStep] = true
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, target := range adjacency[current] {
+				if !reachable[target] {
+					reachable[target] = true
+					queue = append(queue, target)
+				}
+			}
+		}
+	}
+	for i := range graph.Nodes {
+		node := &graph.Nodes[i]
+		node.Reachable = reachable[node.ID]
+		if !node.Reachable && node.ID != "" {
+			graph.addIssue(FlowIssueWarning, "unreachable_step", node.ID,
+				fmt.Sprintf("Step '%s' can never be reached from the first step", node.ID))
+		}
+	}
+
+	// Cycles made only of steps that don't wait for input would loop forever.
+	// Each such step has exactly one automatic successor, so follow the chain.
+	// Condition steps branch and delay steps pause, so the chain stops at them.
+	endsChain := func(step *models.ChatbotFlowStep) bool {
+		return stepWaitsForInput(step) || isJumpStep(step) ||
+			step.MessageType == models.FlowStepTypeTransfer ||
+			step.MessageType == models.FlowStepTypeCondition ||
+			step.MessageType == models.FlowStepTypeDelay
+	}
+	reported := make(map[string]bool)
+	for i := range steps {
+		start := &steps[i]
+		if start.StepName == "" || endsChain(start) {
+			continue
+		}
+		visited := map[string]bool{start.StepName: true}
+		current := i
+		for {
+			next, _ := stepAutoNext(steps, current)
+			nextIdx, ok := index[next]
+			if next == "" || !ok {
+				break
+			}
+			nextStep := &steps[nextIdx]
+			if endsChain(nextStep) {
+				break
+			}
+			if visited[next] {
+				if next == start.StepName && !reported[next] {
+					for name := range visited {
+						reported[name] = true
+					}
+					graph.addIssue(FlowIssueError, "input_less_cycle", start.StepName,
+						fmt.Sprintf("Step '%s' is part of a loop where no step waits for user input", start.StepName))
+				}
+				break
+			}
+			visited[next] = true
+			current = nextIdx
+		}
+	}
+
+	return graph
+}
+
+// checkFlowJumpTargets verifies that goto_flow/call_flow targets exist in the organization
+// and fills in the target flow names on the graph edges.
+// selfID is the flow being saved (may be uuid.Nil on create) and selfSteps its step names.
+func (a *App) checkFlowJumpTargets(orgID, selfID uuid.UUID, graph *FlowGraph, selfSteps map[string]bool) {
+	for i := range graph.Edges {
+		edge := &graph.Edges[i]
+		if edge.TargetFlowID == "" {
+			continue
+		}
+		targetID, _ := uuid.Parse(edge.TargetFlowID)
+
+		if targetID == selfID {
+			edge.TargetFlowName = graph.FlowName
+			if edge.Target != "" && !selfSteps[edge.Target] {
+				graph.addIssue(FlowIssueError, "invalid_jump", edge.Source,
+					fmt.Sprintf("Step '%s' jumps to unknown step '%s'", edge.Source, edge.Target))
+			}
+			continue
+		}
+
+		var target models.ChatbotFlow
+		if err := a.DB.Where("id = ? AND organization_id = ?", targetID, orgID).
+			Preload("Steps").
+			First(&target).Error; err != nil {
+			graph.addIssue(FlowIssueError, "invalid_jump", edge.Source,
+				fmt.Sprintf("Step '%s' jumps to a flow that does not exist", edge.Source))
+			continue
+		}
+		edge.TargetFlowName = target.Name
+
+		if edge.Target != "" {
+			found := false
+			for _, s := range target.Steps {
+				if s.StepName == edge.Target {
+					found = true
+					break
+				}
+			}
+			if !found {
+				graph.addIssue(FlowIssueError, "invalid_jump", edge.Source,
+					fmt.Sprintf("Step '%s' jumps to unknown step '%s' in flow '%s'", edge.Source, edge.Target, target.Name))
+			}
+		}
+	}
+}
+
+// validateFlowSteps builds and validates the graph for a set of steps being saved.
+func (a *App) validateFlowSteps(orgID, flowID uuid.UUID, flowName string, steps []models.ChatbotFlowStep) *FlowGraph {
+	graph := buildFlowGraph(steps)
+	if flowID != uuid.Nil {
+		graph.FlowID = flowID.String()
+	}
+	graph.FlowName = flowName
+
+	stepNames := make(map[string]bool, len(steps))
+	for _, s := range steps {
+		stepNames[s.StepName] = true
+	}
+	a.checkFlowJumpTargets(orgID, flowID, graph, stepNames)
+	return graph
+}
+
+// buildFlowSteps converts step requests into flow step models with defaults applied
+func buildFlowSteps(flowID uuid.UUID, reqs []FlowStepRequest) []models.ChatbotFlowStep {
+	steps := make([]models.ChatbotFlowStep, 0, len(reqs))
+	for i, stepReq := range reqs {
+		// Convert buttons to JSONBArray
+		var buttons models.JSONBArray
+		for _, btn := range stepReq.Buttons {
+			buttons = append(buttons, btn)
+		}
+
+		step := models.ChatbotFlowStep{
+			BaseModel:       models.BaseModel{ID: uuid.New()},
+			FlowID:          flowID,
+			StepName:        stepReq.StepName,
+			StepOrder:       i + 1,
+			Message:         stepReq.Message,
+			MessageType:     stepReq.MessageType,
+			InputType:       stepReq.InputType,
+			InputConfig:     models.JSONB(stepReq.InputConfig),
+			ApiConfig:       models.JSONB(stepReq.ApiConfig),
+			Buttons:         buttons,
+			TransferConfig:  models.JSONB(stepReq.TransferConfig),
+			JumpConfig:      models.JSONB(stepReq.JumpConfig),
+			ActionConfig:    models.JSONB(stepReq.ActionConfig),
+			MediaConfig:     models.JSONB(stepReq.MediaConfig),
+			TemplateID:      stepReq.TemplateID,
+			ValidationRegex: stepReq.ValidationRegex,
+			ValidationError: stepReq.ValidationError,
+			StoreAs:         stepReq.StoreAs,
+			NextStep:        stepReq.NextStep,
+			ConditionalNext: models.JSONB(stepReq.ConditionalNext),
+			SkipCondition:   stepReq.SkipCondition,
+			RetryOnInvalid:  stepReq.RetryOnInvalid,
+			MaxRetries:      stepReq.MaxRetries,
+			Position:        models.JSONB(stepReq.Position),
+			Translations:    translationsJSONB(stepReq.Translations),
+		}
+		if step.MessageType == "" {
+			step.MessageType = models.FlowStepTypeText
+		}
+		if step.MaxRetries == 0 {
+			step.MaxRetries = 3
+		}
+		if step.InputType == "" {
+			switch step.MessageType {
+			case models.FlowStepTypeLocationRequest:
+				step.InputType = models.InputTypeLocation
+			case models.FlowStepTypeMedia:
+				step.InputType = models.InputTypeNone
+			case models.FlowStepTypeLanguageSelect:
+				step.InputType = models.InputTypeButton
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// sendFlowGraphErrors sends a 400 response listing the flow graph issues
+func sendFlowGraphErrors(r *fastglue.Request, graph *FlowGraph) error {
+	return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid flow graph", map[string]any{
+		"issues": graph.Issues,
+	}, "")
+}
+
+// GetChatbotFlowGraph returns the flow as a graph of steps and transitions for the visual builder
+func (a *App) GetChatbotFlowGraph(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionRead, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	var flow models.ChatbotFlow
+	if err := a.DB.Where("id = ? AND organization_id = ?", id, orgID).
+		Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("step_order ASC")
+		}).
+		First(&flow).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow not found", nil, "")
+	}
+
+	return r.SendEnvelope(a.validateFlowSteps(orgID, flow.ID, flow.Name, flow.Steps))
+}
+
+// ValidateChatbotFlow validates a set of flow steps without saving them
+func (a *App) ValidateChatbotFlow(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionRead, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	var req struct {
+		FlowID string            `json:"flow_id"`
+		Name   string            `json:"name"`
+		Steps  []FlowStepRequest `json:"steps"`
+	}
+	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
+	}
+
+	flowID := uuid.Nil
+	if req.FlowID != "" {
+		parsed, err := uuid.Parse(req.FlowID)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid flow ID", nil, "")
+		}
+		flowID = parsed
+	}
+
+	graph := a.validateFlowSteps(orgID, flowID, req.Name, buildFlowSteps(flowID, req.Steps))
+	return r.SendEnvelope(map[string]any{
+		"valid": !graph.HasErrors(),
+		"graph": graph,
+	})
+}
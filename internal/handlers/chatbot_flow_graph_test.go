@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func issueCodes(graph *FlowGraph) []string {
+	codes := make([]string, 0, len(graph.Issues))
+	for _, issue := range graph.Issues {
+		codes = append(codes, issue.Code)
+	}
+	return codes
+}
+
+func TestBuildFlowGraph_LinearFlow(t *testing.T) {
+	steps := []models.ChatbotFlowStep{
+		{StepName: "ask_name", InputType: models.InputTypeText},
+		{StepName: "ask_email", InputType: models.InputTypeEmail},
+	}
+
+	graph := buildFlowGraph(steps)
+
+	assert.False(t, graph.HasErrors())
+	assert.Empty(t, graph.Issues)
+	assert.Equal(t, "ask_name", graph.EntryStep)
+	require.Len(t, graph.Nodes, 2)
+	assert.True(t, graph.Nodes[0].IsEntry)
+	assert.True(t, graph.Nodes[1].Reachable)
+	require.Len(t, graph.Edges, 1)
+	assert.Equal(t, FlowGraphEdge{Source: "ask_name", Target: "ask_email", Kind: FlowEdgeOrder}, graph.Edges[0])
+}
+
+func TestBuildFlowGraph_ConditionalBranches(t *testing.T) {
+	steps := []models.ChatbotFlowStep{
+		{
+			StepName:  "menu",
+			InputType: models.InputTypeButton,
+			NextStep:  "fallback",
+			ConditionalNext: models.JSONB{
+				"sales":   "sales",
+				"support": "support",
+			},
+		},
+		{StepName: "sales", InputType: models.InputTypeText, NextStep: "end"},
+		{StepName: "support", InputType: models.InputTypeText, NextStep: "end"},
+		{StepName: "fallback", InputType: models.InputTypeText},
+		{StepName: "end", InputType: models.InputTypeText},
+	}
+
+	graph := buildFlowGraph(steps)
+
+	assert.False(t, graph.HasErrors())
+	assert.Empty(t, graph.Warnings())
+	for _, node := range graph.Nodes {
+		assert.True(t, node.Reachable, node.ID)
+	}
+
+	conditional := 0
+	for _, edge := range graph.Edges {
+		if edge.Kind == FlowEdgeConditional {
+			conditional++
+			assert.Equal(t, edge.Label, edge.Target)
+		}
+	}
+	assert.Equal(t, 2, conditional)
+}
+
+func TestBuildFlowGraph_DuplicateAndMissingNames(t *testing.T) {
+	steps := []models.ChatbotFlowStep{
+		{StepName: "step1", InputType: models.InputTypeText},
+		{StepName: "step1", InputType: models.InputTypeText},
+		{StepName: "", InputType: models.InputTypeText},
+	}
+
+	graph := buildFlowGraph(steps)
+
+	assert.True(t, graph.HasErrors())
+	assert.Contains(t, issueCodes(graph), "duplicate_step")
+	assert.Contains(t, issueCodes(graph), "missing_step_name")
+}
+
+func TestBuildFlowGraph_DanglingEdge(t *testing.T) {
+	steps := []models.ChatbotFlowStep{
+		{StepName: "step1", InputType: models.InputTypeText, NextStep: "missing"},
+		{
+			StepName:        "step2",
+			InputType:       models.InputTypeText,
+			ConditionalNext: models.JSONB{"yes": "also_missing", "no": ""},
+		},
+	}
+
+	graph := buildFlowGraph(steps)
+
+	assert.True(t, graph.HasErrors())
+	dangling := 0
+	for _, issue := range graph.Issues {
+		if issue.Code == "dangling_edge" {
+			dangling++
+		}
+	}
+	assert.Equal(t, 2, dangling)
+}
+
+func TestBuildFlowGraph_UnreachableStepIsWarning(t *testing.T) {
+	steps := []models.ChatbotFlowStep{
+		{StepName: "start", InputType: models.InputTypeText, NextStep: "end"},
+		{StepName: "orphan", InputType: models.InputTypeText},
+		{StepName: "end", InputType: models.InputTypeText},
+	}
+
+	graph := buildFlowGraph(steps)
+
+	assert.False(t, graph.HasErrors())
+	warnings := graph.Warnings()
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "unreachable_step", warnings[0].Code)
+	assert.Equal(t, "orphan", warnings[0].Step)
+	assert.False(t, graph.Nodes[1].Reachable)
+}
+
+func TestBuildFlowGraph_InputLessCycle(t *testing.T) {
+	steps := []models.ChatbotFlowStep{
+		{StepName: "a", InputType: models.InputTypeNone, NextStep: "b"},
+		{StepName: "b", InputType: models.InputTypeNone, NextStep: "a"},
+	}
+
+	graph := buildFlowGraph(steps)
+
+	assert.True(t, graph.HasErrors())
+	assert.Equal(t, []string{"input_less_cycle"}, issueCodes(graph))
+}
+
+func TestBuildFlowGraph_CycleWithInputIsAllowed(t *testing.T) {
+	steps := []models.ChatbotFlowStep{
+		{StepName: "info", InputType: models.InputTypeNone},
+		{StepName: "ask_again", InputType: models.InputTypeText, NextStep: "info"},
+	}
+
+	graph := buildFlowGraph(steps)
+
+	assert.False(t, graph.HasErrors())
+}
+
+func TestBuildFlowGraph_GotoFlow(t *testing.T) {
+	targetID := uuid.New()
+	steps := []models.ChatbotFlowStep{
+		{StepName: "ask", InputType: models.InputTypeText},
+		{
+			StepName:    "jump",
+			MessageType: models.FlowStepTypeGotoFlow,
+			InputType:   models.InputTypeNone,
+			JumpConfig:  models.JSONB{"flow_id": targetID.String(), "step_name": "start"},
+		},
+		{StepName: "after", InputType: models.InputTypeText},
+	}
+
+	graph := buildFlowGraph(steps)
+
+	assert.False(t, graph.HasErrors())
+	// goto_flow never returns, so the step after it is unreachable
+	require.Len(t, graph.Warnings(), 1)
+	assert.Equal(t, "after", graph.Warnings()[0].Step)
+
+	var jump *FlowGraphEdge
+	for i := range graph.Edges {
+		if graph.Edges[i].Kind == FlowEdgeGoto {
+			jump = &graph.Edges[i]
+		}
+	}
+	require.NotNil(t, jump)
+	assert.Equal(t, targetID.String(), jump.TargetFlowID)
+	assert.Equal(t, "start", jump.Target)
+}
+
+func TestBuildFlowGraph_CallFlowReturns(t *testing.T) {
+	steps := []models.ChatbotFlowStep{
+		{
+			StepName:    "collect_address",
+			MessageType: models.FlowStepTypeCallFlow,
+			InputType:   models.InputTypeNone,
+			JumpConfig:  models.JSONB{"flow_id": uuid.New().String()},
+		},
+		{StepName: "confirm", InputType: models.InputTypeText},
+	}
+
+	graph := buildFlowGraph(steps)
+
+	assert.False(t, graph.HasErrors())
+	assert.Empty(t, graph.Warnings())
+
+	kinds := make([]string, 0, len(graph.Edges))
+	for _, edge := range graph.Edges {
+		kinds = append(kinds, edge.Kind)
+	}
+	assert.ElementsMatch(t, []string{FlowEdgeCall, FlowEdgeReturn}, kinds)
+}
+
+func TestBuildFlowGraph_InvalidJumpConfig(t *testing.T) {
+	steps := []models.ChatbotFlowStep{
+		{StepName: "no_config", MessageType: models.FlowStepTypeGotoFlow},
+		{StepName: "bad_id", MessageType: models.FlowStepTypeCallFlow, JumpConfig: models.JSONB{"flow_id": "not-a-uuid"}},
+	}
+
+	graph := buildFlowGraph(steps)
+
+	assert.True(t, graph.HasErrors())
+	invalid := 0
+	for _, issue := range graph.Issues {
+		if issue.Code == "invalid_jump" {
+			invalid++
+		}
+	}
+	assert.Equal(t, 2, invalid)
+}
+
+func TestBuildFlowSteps_Defaults(t *testing.T) {
+	flowID := uuid.New()
+	steps := buildFlowSteps(flowID, []FlowStepRequest{
+		{StepName: "first", Position: map[string]interface{}{"x": 10, "y": 20}},
+		{StepName: "second", MessageType: models.FlowStepTypeButtons, MaxRetries: 5},
+	})
+
+	require.Len(t, steps, 2)
+	assert.Equal(t, flowID, steps[0].FlowID)
+	assert.Equal(t, 1, steps[0].StepOrder)
+	assert.Equal(t, models.FlowStepTypeText, steps[0].MessageType)
+	assert.Equal(t, 3, steps[0].MaxRetries)
+	assert.Equal(t, 10, steps[0].Position["x"])
+	assert.Equal(t, 2, steps[1].StepOrder)
+	assert.Equal(t, 5, steps[1].MaxRetries)
+}
@@ -0,0 +1,650 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	// maxFlowDelay is the longest a delay step may pause a flow
+	maxFlowDelay = 30 * 24 * time.Hour
+
+	// maxFlowMediaSize is the largest file a media step will download and send
+	maxFlowMediaSize = 16 << 20
+)
+
+// flowVariable is a single assignment made by a set_variable step.
+// Value is processed as a template; Expression is evaluated to true/false.
+type flowVariable struct {
+	Name       string      `json:"name"`
+	Value      interface{} `json:"value"`
+	Expression string      `json:"expression"`
+}
+
+// flowBranch is a single branch of a condition step
+type flowBranch struct {
+	Condition string `json:"condition"`
+	Next      string `json:"next"`
+}
+
+// isActionStep reports whether the step only changes state and never sends a message
+func isActionStep(step *models.ChatbotFlowStep) bool {
+	switch step.MessageType {
+	case models.FlowStepTypeSetVariable, models.FlowStepTypeCondition, models.FlowStepTypeAssignTag:
+		return true
+	}
+	return false
+}
+
+// decodeActionConfig decodes a key of the step's action_config into out
+func decodeActionConfig(step *models.ChatbotFlowStep, key string, out interface{}) error {
+	value, ok := step.ActionConfig[key]
+	if !ok || value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("action_config.%s is invalid", key)
+	}
+	return nil
+}
+
+// flowDelayDuration returns how long a delay step pauses the flow.
+// action_config: {duration: number, unit: seconds|minutes|hours|days (default minutes)}
+func flowDelayDuration(step *models.ChatbotFlowStep) (time.Duration, error) {
+	var amount float64
+	if err := decodeActionConfig(step, "duration", &amount); err != nil {
+		return 0, err
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("action_config.duration must be greater than 0")
+	}
+
+	unit, _ := step.ActionConfig["unit"].(string)
+	var base time.Duration
+	switch unit {
+	case "seconds":
+		base = time.Second
+	case "", "minutes":
+		base = time.Minute
+	case "hours":
+		base = time.Hour
+	case "days":
+		base = 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("action_config.unit must be seconds, minutes, hours or days")
+	}
+
+	duration := time.Duration(amount * float64(base))
+	if duration > maxFlowDelay {
+		return 0, fmt.Errorf("delay cannot be longer than %d days", int(maxFlowDelay.Hours()/24))
+	}
+	return duration, nil
+}
+
+// flowVariables returns the assignments of a set_variable step.
+// action_config: {variables: [{name, value} | {name, expression}]}
+func flowVariables(step *models.ChatbotFlowStep) ([]flowVariable, error) {
+	var variables []flowVariable
+	if err := decodeActionConfig(step, "variables", &variables); err != nil {
+		return nil, err
+	}
+	if len(variables) == 0 {
+		return nil, fmt.Errorf("action_config.variables is required")
+	}
+	for i, v := range variables {
+		if v.Name == "" {
+			return nil, fmt.Errorf("variable %d has no name", i+1)
+		}
+		if strings.HasPrefix(v.Name, "_") {
+			return nil, fmt.Errorf("variable '%s' is reserved", v.Name)
+		}
+	}
+	return variables, nil
+}
+
+// flowBranches returns the branches and default next step of a condition step.
+// action_config: {branches: [{condition, next}], default_next}
+func flowBranches(step *models.ChatbotFlowStep) ([]flowBranch, string, error) {
+	var branches []flowBranch
+	if err := decodeActionConfig(step, "branches", &branches); err != nil {
+		return nil, "", err
+	}
+	if len(branches) == 0 {
+		return nil, "", fmt.Errorf("action_config.branches is required")
+	}
+	for i, b := range branches {
+		if b.Condition == "" || b.Next == "" {
+			return nil, "", fmt.Errorf("branch %d needs a condition and a next step", i+1)
+		}
+	}
+	defaultNext, _ := step.ActionConfig["default_next"].(string)
+	return branches, defaultNext, nil
+}
+
+// flowTagChanges returns the tags an assign_tag step adds and removes.
+// action_config: {add_tags: [], remove_tags: []}
+func flowTagChanges(step *models.ChatbotFlowStep) ([]string, []string, error) {
+	var add, remove []string
+	if err := decodeActionConfig(step, "add_tags", &add); err != nil {
+		return nil, nil, err
+	}
+	if err := decodeActionConfig(step, "remove_tags", &remove); err != nil {
+		return nil, nil, err
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return nil, nil, fmt.Errorf("action_config.add_tags or action_config.remove_tags is required")
+	}
+	return add, remove, nil
+}
+
+// flowMediaType returns the message type sent by a media config
+func flowMediaType(config models.JSONB) (models.MessageType, error) {
+	mediaType, _ := config["media_type"].(string)
+	switch models.MessageType(mediaType) {
+	case models.MessageTypeImage, models.MessageTypeDocument, models.MessageTypeVideo, models.MessageTypeAudio:
+		return models.MessageType(mediaType), nil
+	}
+	return "", fmt.Errorf("media_config.media_type must be image, document, video or audio")
+}
+
+// validateMediaConfig checks the file source of a media config; field names
+// the config in error messages
+func validateMediaConfig(config models.JSONB, field string) error {
+	mediaType, _ := config["media_type"].(string)
+	switch models.MessageType(mediaType) {
+	case models.MessageTypeImage, models.MessageTypeDocument, models.MessageTypeVideo, models.MessageTypeAudio:
+	default:
+		return fmt.Errorf("%s.media_type must be image, document, video or audio", field)
+	}
+	url, _ := config["url"].(string)
+	mediaPath, _ := config["media_path"].(string)
+	if url == "" && mediaPath == "" {
+		return fmt.Errorf("%s.url or %s.media_path is required", field, field)
+	}
+	if url != "" && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("%s.url must be an http(s) URL", field)
+	}
+	return nil
+}
+
+// validateFlowStepConfig checks the type-specific configuration of a step
+func validateFlowStepConfig(step *models.ChatbotFlowStep) error {
+	switch step.MessageType {
+	case models.FlowStepTypeDelay:
+		_, err := flowDelayDuration(step)
+		return err
+	case models.FlowStepTypeSetVariable:
+		_, err := flowVariables(step)
+		return err
+	case models.FlowStepTypeCondition:
+		_, _, err := flowBranches(step)
+		return err
+	case models.FlowStepTypeAssignTag:
+		_, _, err := flowTagChanges(step)
+		return err
+	case models.FlowStepTypeMedia:
+		return validateMediaConfig(step.MediaConfig, "media_config")
+	case models.FlowStepTypeLocationRequest:
+		if step.InputType != models.InputTypeLocation {
+			return fmt.Errorf("location_request steps must use the location input type")
+		}
+		if step.Message == "" {
+			return fmt.Errorf("location_request steps need a message")
+		}
+	case models.FlowStepTypeLanguageSelect:
+		if len(step.Buttons) == 0 {
+			return fmt.Errorf("language_select steps need a button per language")
+		}
+		for _, btn := range step.Buttons {
+			btnMap, _ := btn.(map[string]interface{})
+			if id, _ := btnMap["id"].(string); templateutil.NormalizeLanguage(id) == "" {
+				return fmt.Errorf("language_select button IDs must be language codes")
+			}
+		}
+	}
+	return nil
+}
+
+// parseLocationInput parses a shared location (stored as JSON text by the webhook handler)
+func parseLocationInput(input string) (map[string]interface{}, bool) {
+	if !strings.HasPrefix(strings.TrimSpace(input), "{") {
+		return nil, false
+	}
+	var location map[string]interface{}
+	if err := json.Unmarshal([]byte(input), &location); err != nil {
+		return nil, false
+	}
+	if _, ok := location["latitude"].(float64); !ok {
+		return nil, false
+	}
+	if _, ok := location["longitude"].(float64); !ok {
+		return nil, false
+	}
+	return location, true
+}
+
+// evaluateFlowBranches returns the next step chosen by a condition step.
+// Empty means no branch matched and there is no default.
+func evaluateFlowBranches(step *models.ChatbotFlowStep, data map[string]interface{}) string {
+	branches, defaultNext, err := flowBranches(step)
+	if err != nil {
+		return ""
+	}
+	for _, b := range branches {
+		if evaluateExpression(b.Condition, data) {
+			return b.Next
+		}
+	}
+	return defaultNext
+}
+
+// applyFlowVariables sets the variables of a set_variable step on the session data
+func applyFlowVariables(step *models.ChatbotFlowStep, data models.JSONB) error {
+	variables, err := flowVariables(step)
+	if err != nil {
+		return err
+	}
+	for _, v := range variables {
+		switch {
+		case v.Expression != "":
+			data[v.Name] = evaluateExpression(v.Expression, data)
+		default:
+			if str, ok := v.Value.(string); ok {
+				data[v.Name] = processTemplate(str, data)
+			} else {
+				data[v.Name] = v.Value
+			}
+		}
+	}
+	return nil
+}
+
+// mergeContactTags returns the contact's tags with add applied and remove taken out,
+// and whether anything changed
+func mergeContactTags(current models.JSONBArray, add, remove []string) (models.JSONBArray, bool) {
+	removed := make(map[string]bool, len(remove))
+	for _, t := range remove {
+		removed[t] = true
+	}
+
+	result := make(models.JSONBArray, 0, len(current)+len(add))
+	seen := make(map[string]bool, len(current)+len(add))
+	changed := false
+	for _, t := range current {
+		name, ok := t.(string)
+		if !ok || seen[name] {
+			continue
+		}
+		if removed[name] {
+			changed = true
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	for _, name := range add {
+		if name == "" || seen[name] || removed[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+		changed = true
+	}
+	return result, changed
+}
+
+// runActionStep executes a set_variable, condition or assign_tag step and moves on
+func (a *App) runActionStep(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep, flow *models.ChatbotFlow, visited map[string]bool) {
+	if session.SessionData == nil {
+		session.SessionData = models.JSONB{}
+	}
+
+	switch step.MessageType {
+	case models.FlowStepTypeSetVariable:
+		if err := applyFlowVariables(step, session.SessionData); err != nil {
+			a.Log.Error("Invalid set_variable step", "error", err, "flow_id", flow.ID, "step", step.StepName)
+		} else {
+			a.updateFlowSession(session, map[string]interface{}{"session_data": session.SessionData})
+		}
+
+	case models.FlowStepTypeAssignTag:
+		a.assignFlowTags(contact, step)
+
+	case models.FlowStepTypeCondition:
+		if next := evaluateFlowBranches(step, session.SessionData); next != "" {
+			a.Log.Info("Condition step matched", "step", step.StepName, "next", next)
+			a.goToFlowStep(account, session, contact, flow, next, visited)
+			return
+		}
+	}
+
+	a.advanceFlowFrom(account, session, contact, step, flow, visited)
+}
+
+// assignFlowTags adds and removes contact tags for an assign_tag step
+func (a *App) assignFlowTags(contact *models.Contact, step *models.ChatbotFlowStep) {
+	add, remove, err := flowTagChanges(step)
+	if err != nil {
+		a.Log.Error("Invalid assign_tag step", "error", err, "step", step.StepName)
+		return
+	}
+	if a.flowSim != nil {
+		a.flowSim.recordAction("assign_tag", map[string]interface{}{"add_tags": add, "remove_tags": remove})
+		return
+	}
+
+	tags, changed := mergeContactTags(contact.Tags, add, remove)
+	if !changed {
+		return
+	}
+	if err := a.DB.Model(contact).Update("tags", tags).Error; err != nil {
+		a.Log.Error("Failed to update contact tags from flow", "error", err, "contact_id", contact.ID)
+		return
+	}
+	contact.Tags = tags
+}
+
+// startFlowDelay pauses the flow at a delay step. The flow is resumed by the
+// FlowDelayProcessor once resume_at has passed; simulations continue immediately.
+func (a *App) startFlowDelay(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep, flow *models.ChatbotFlow, visited map[string]bool) {
+	duration, err := flowDelayDuration(step)
+	if err != nil {
+		a.Log.Error("Invalid delay step, continuing without delay", "error", err, "flow_id", flow.ID, "step", step.StepName)
+		a.advanceFlowFrom(account, session, contact, step, flow, visited)
+		return
+	}
+
+	// Optional message sent before waiting
+	if step.Message != "" {
+		message := processTemplate(localizeFlowStep(step, sessionLanguage(session)).Message, session.SessionData)
+		if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
+			a.Log.Error("Failed to send delay step message", "error", err, "contact", contact.PhoneNumber)
+		}
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
+	}
+
+	if a.flowSim != nil {
+		a.flowSim.recordAction("delay", map[string]interface{}{"seconds": duration.Seconds()})
+		a.advanceFlowFrom(account, session, contact, step, flow, visited)
+		return
+	}
+
+	resumeAt := time.Now().Add(duration)
+	session.CurrentStep = step.StepName
+	session.ResumeAt = &resumeAt
+	a.updateFlowSession(session, map[string]interface{}{
+		"current_step": step.StepName,
+		"resume_at":    resumeAt,
+	})
+	// The bot isn't waiting for the customer, so no inactivity reminders or auto-close
+	a.clearFlowContactTracking(contact.ID)
+	a.Log.Info("Flow paused at delay step", "flow_id", flow.ID, "step", step.StepName, "resume_at", resumeAt, "session_id", session.ID)
+}
+
+// resumeDelayedFlow continues a session whose delay step has elapsed.
+// The session is claimed by clearing resume_at so that it only resumes once.
+func (a *App) resumeDelayedFlow(session *models.ChatbotSession) {
+	now := time.Now()
+	result := a.DB.Model(&models.ChatbotSession{}).
+		Where("id = ? AND resume_at IS NOT NULL AND status = ?", session.ID, models.SessionStatusActive).
+		Updates(map[string]interface{}{
+			"resume_at":        nil,
+			"last_activity_at": now,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+	session.ResumeAt = nil
+	session.LastActivityAt = now
+
+	if session.CurrentFlowID == nil {
+		return
+	}
+
+	var account models.WhatsAppAccount
+	if err := a.DB.Where("organization_id = ? AND name = ?", session.OrganizationID, session.WhatsAppAccount).First(&account).Error; err != nil {
+		a.Log.Error("WhatsApp account not found for delayed flow", "error", err, "session_id", session.ID)
+		return
+	}
+	var contact models.Contact
+	if err := a.DB.Where("id = ? AND organization_id = ?", session.ContactID, session.OrganizationID).First(&contact).Error; err != nil {
+		a.Log.Error("Contact not found for delayed flow", "error", err, "session_id", session.ID)
+		return
+	}
+
+	// An agent has taken over the conversation in the meantime
+	if a.hasActiveAgentTransfer(session.OrganizationID, contact.ID) {
+		a.Log.Info("Contact has active agent transfer, not resuming delayed flow", "session_id", session.ID)
+		return
+	}
+
+	flow, err := a.loadChatbotFlow(session, *session.CurrentFlowID, session.CurrentFlowVersion)
+	if err != nil {
+		a.Log.Error("Failed to load flow for delayed session", "error", err, "session_id", session.ID)
+		a.exitFlow(session)
+		return
+	}
+	step := findFlowStep(flow, session.CurrentStep)
+	if step == nil || step.MessageType != models.FlowStepTypeDelay {
+		a.Log.Warn("Delayed session is no longer at a delay step", "step", session.CurrentStep, "session_id", session.ID)
+		return
+	}
+
+	a.Log.Info("Resuming flow after delay", "flow_id", flow.ID, "step", step.StepName, "session_id", session.ID)
+	a.advanceFlowFrom(&account, session, &contact, step, flow, nil)
+}
+
+// advanceFlowFrom moves the session to the step after the given one
+// (next_step, or the following step by order), completing the flow if there is none
+func (a *App) advanceFlowFrom(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep, flow *models.ChatbotFlow, visited map[string]bool) {
+	nextStepName := step.NextStep
+	if nextStepName == "" {
+		if next := flowStepAfter(flow, step.StepName); next != nil {
+			nextStepName = next.StepName
+		}
+	}
+	a.goToFlowStep(account, session, contact, flow, nextStepName, visited)
+}
+
+// goToFlowStep runs the named step, completing the flow if the name is empty or unknown
+func (a *App) goToFlowStep(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, flow *models.ChatbotFlow, stepName string, visited map[string]bool) {
+	if stepName == "" {
+		a.completeFlow(account, session, contact, flow)
+		return
+	}
+	next := findFlowStep(flow, stepName)
+	if next == nil {
+		a.Log.Warn("Next step not found, completing flow", "next_step", stepName)
+		a.completeFlow(account, session, contact, flow)
+		return
+	}
+
+	session.CurrentStep = next.StepName
+	session.StepRetries = 0
+	a.updateFlowSession(session, map[string]interface{}{
+		"current_step": next.StepName,
+		"step_retries": 0,
+	})
+	a.sendStepWithSkipCheck(account, session, contact, next, flow, visited)
+}
+
+// sendAndSaveMediaMessage sends the file described by a media config
+// (media step or keyword rule)
+func (a *App) sendAndSaveMediaMessage(account *models.WhatsAppAccount, contact *models.Contact, config models.JSONB, caption string) error {
+	mediaType, err := flowMediaType(config)
+	if err != nil {
+		return err
+	}
+	url, _ := config["url"].(string)
+	mediaPath, _ := config["media_path"].(string)
+	mimeType, _ := config["mime_type"].(string)
+	filename, _ := config["filename"].(string)
+
+	req := OutgoingMessageRequest{
+		Account:       account,
+		Contact:       contact,
+		Type:          mediaType,
+		MediaMimeType: mimeType,
+		MediaFilename: filename,
+		Caption:       caption,
+	}
+	if mediaType == models.MessageTypeAudio {
+		req.Caption = "" // WhatsApp audio messages have no caption
+	}
+
+	// Simulations don't download the file
+	if a.flowSim != nil {
+		req.MediaURL = mediaPath
+		if url != "" {
+			req.MediaURL = url
+		}
+		return a.sendChatbotMessage(req)
+	}
+
+	var data []byte
+	if mediaPath != "" {
+		data, err = a.readStoredMedia(account.OrganizationID, mediaPath)
+		req.MediaURL = mediaPath
+	} else {
+		var contentType string
+		data, contentType, err = a.downloadFlowMedia(url)
+		if req.MediaMimeType == "" {
+			req.MediaMimeType = contentType
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if req.MediaMimeType == "" {
+		req.MediaMimeType = http.DetectContentType(data)
+	}
+	if req.MediaURL == "" {
+		// Keep a local copy so the conversation view can show the file
+		if localPath, err := a.saveOrgMediaLocally(account.OrganizationID, data, req.MediaMimeType, filename); err == nil {
+			req.MediaURL = localPath
+		} else {
+			a.Log.Error("Failed to save flow media locally", "error", err)
+		}
+	}
+	req.MediaData = data
+
+	return a.sendChatbotMessage(req)
+}
+
+// downloadFlowMedia fetches a media step's file from its URL
+func (a *App) downloadFlowMedia(url string) ([]byte, string, error) {
+	client := a.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download media: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("failed to download media: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFlowMediaSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read media: %w", err)
+	}
+	if len(data) > maxFlowMediaSize {
+		return nil, "", fmt.Errorf("media is larger than %d MB", maxFlowMediaSize>>20)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// readStoredMedia reads one of the organization's uploads from local media
+// storage, rejecting paths outside it
+func (a *App) readStoredMedia(orgID uuid.UUID, relativePath string) ([]byte, error) {
+	if !ownsMediaPath(orgID, relativePath) {
+		return nil, fmt.Errorf("invalid media path")
+	}
+	baseDir, err := filepath.Abs(a.getMediaStoragePath())
+	if err != nil {
+		return nil, fmt.Errorf("storage configuration error: %w", err)
+	}
+	fullPath, err := filepath.Abs(filepath.Join(baseDir, filepath.Clean(relativePath)))
+	if err != nil || !strings.HasPrefix(fullPath, baseDir+string(os.PathSeparator)) {
+		return nil, fmt.Errorf("invalid media path")
+	}
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("media file not found")
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil, fmt.Errorf("invalid media path")
+	}
+	return os.ReadFile(fullPath)
+}
+
+// UploadChatbotFlowMedia stores a file for use in flow media steps
+func (a *App) UploadChatbotFlowMedia(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionWrite, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	form, err := r.RequestCtx.MultipartForm()
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid multipart form", nil, "")
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "file is required", nil, "")
+	}
+	fileHeader := files[0]
+	if fileHeader.Size > maxFlowMediaSize {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("File is larger than %d MB", maxFlowMediaSize>>20), nil, "")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to read file", nil, "")
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to read file data", nil, "")
+	}
+
+	mimeType := fileHeader.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	localPath, err := a.saveOrgMediaLocally(orgID, data, mimeType, fileHeader.Filename)
+	if err != nil {
+		a.Log.Error("Failed to save flow media", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save media", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"media_path": localPath,
+		"mime_type":  mimeType,
+		"filename":   fileHeader.Filename,
+	})
+}
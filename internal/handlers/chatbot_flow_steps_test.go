@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlowDelayDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  models.JSONB
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default unit is minutes", config: models.JSONB{"duration": float64(5)}, want: 5 * time.Minute},
+		{name: "seconds", config: models.JSONB{"duration": float64(30), "unit": "seconds"}, want: 30 * time.Second},
+		{name: "days", config: models.JSONB{"duration": float64(2), "unit": "days"}, want: 48 * time.Hour},
+		{name: "missing duration", config: models.JSONB{}, wantErr: true},
+		{name: "unknown unit", config: models.JSONB{"duration": float64(1), "unit": "weeks"}, wantErr: true},
+		{name: "too long", config: models.JSONB{"duration": float64(60), "unit": "days"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := flowDelayDuration(&models.ChatbotFlowStep{ActionConfig: tt.config})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateFlowStepConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    models.ChatbotFlowStep
+		wantErr bool
+	}{
+		{
+			name:    "set_variable without variables",
+			step:    models.ChatbotFlowStep{MessageType: models.FlowStepTypeSetVariable},
+			wantErr: true,
+		},
+		{
+			name: "set_variable with reserved name",
+			step: models.ChatbotFlowStep{
+				MessageType:  models.FlowStepTypeSetVariable,
+				ActionConfig: models.JSONB{"variables": []interface{}{map[string]interface{}{"name": "_flow_id", "value": "x"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "condition branch without next",
+			step: models.ChatbotFlowStep{
+				MessageType:  models.FlowStepTypeCondition,
+				ActionConfig: models.JSONB{"branches": []interface{}{map[string]interface{}{"condition": "a == 'b'"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "assign_tag with tags",
+			step: models.ChatbotFlowStep{
+				MessageType:  models.FlowStepTypeAssignTag,
+				ActionConfig: models.JSONB{"add_tags": []interface{}{"vip"}},
+			},
+		},
+		{
+			name: "media without source",
+			step: models.ChatbotFlowStep{
+				MessageType: models.FlowStepTypeMedia,
+				MediaConfig: models.JSONB{"media_type": "image"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "media with unknown type",
+			step: models.ChatbotFlowStep{
+				MessageType: models.FlowStepTypeMedia,
+				MediaConfig: models.JSONB{"media_type": "sticker", "url": "https://example.com/a.webp"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "media from url",
+			step: models.ChatbotFlowStep{
+				MessageType: models.FlowStepTypeMedia,
+				MediaConfig: models.JSONB{"media_type": "document", "url": "https://example.com/menu.pdf"},
+			},
+		},
+		{
+			name: "location request with text input",
+			step: models.ChatbotFlowStep{
+				MessageType: models.FlowStepTypeLocationRequest,
+				Message:     "Where are you?",
+				InputType:   models.InputTypeText,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFlowStepConfig(&tt.step)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMergeContactTags(t *testing.T) {
+	tags, changed := mergeContactTags(models.JSONBArray{"lead", "cold"}, []string{"vip", "lead"}, []string{"cold"})
+	assert.True(t, changed)
+	assert.Equal(t, models.JSONBArray{"lead", "vip"}, tags)
+
+	tags, changed = mergeContactTags(models.JSONBArray{"vip"}, []string{"vip"}, nil)
+	assert.False(t, changed)
+	assert.Equal(t, models.JSONBArray{"vip"}, tags)
+}
+
+func TestParseLocationInput(t *testing.T) {
+	location, ok := parseLocationInput(`{"latitude": 12.97, "longitude": 77.59, "name": "Office"}`)
+	require.True(t, ok)
+	assert.Equal(t, 12.97, location["latitude"])
+	assert.Equal(t, "Office", location["name"])
+
+	_, ok = parseLocationInput("Bangalore")
+	assert.False(t, ok)
+	_, ok = parseLocationInput(`{"latitude": 12.97}`)
+	assert.False(t, ok)
+}
+
+func TestBuildFlowGraph_ConditionStep(t *testing.T) {
+	steps := []models.ChatbotFlowStep{
+		{
+			StepName:    "route",
+			MessageType: models.FlowStepTypeCondition,
+			ActionConfig: models.JSONB{
+				"branches":     []interface{}{map[string]interface{}{"condition": "tier == 'gold'", "next": "gold"}},
+				"default_next": "standard",
+			},
+		},
+		{StepName: "gold", Message: "Welcome back!", InputType: models.InputTypeNone},
+		{StepName: "standard", Message: "Hello!", InputType: models.InputTypeNone},
+		{StepName: "broken", MessageType: models.FlowStepTypeDelay, InputType: models.InputTypeNone},
+	}
+
+	graph := buildFlowGraph(steps)
+
+	labels := map[string]string{}
+	for _, e := range graph.Edges {
+		if e.Source == "route" {
+			labels[e.Target] = e.Label
+		}
+	}
+	assert.Equal(t, map[string]string{"gold": "tier == 'gold'", "standard": "default"}, labels)
+	assert.False(t, graph.Nodes[0].WaitsForInput)
+
+	require.True(t, graph.HasErrors())
+	var codes []string
+	for _, issue := range graph.Issues {
+		if issue.Severity == FlowIssueError {
+			codes = append(codes, issue.Code+":"+issue.Step)
+		}
+	}
+	assert.Equal(t, []string{"invalid_step_config:broken"}, codes)
+}
+
+func TestSimulateFlow_SetVariableAndCondition(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{StepName: "ask_total", Message: "Order total?", InputType: models.InputTypeNumber, StoreAs: "total"},
+		models.ChatbotFlowStep{
+			StepName:    "compute",
+			MessageType: models.FlowStepTypeSetVariable,
+			ActionConfig: models.JSONB{"variables": []interface{}{
+				map[string]interface{}{"name": "is_vip", "expression": "total > 100"},
+				map[string]interface{}{"name": "summary", "value": "Total {{total}}"},
+			}},
+		},
+		models.ChatbotFlowStep{
+			StepName:    "route",
+			MessageType: models.FlowStepTypeCondition,
+			ActionConfig: models.JSONB{
+				"branches":     []interface{}{map[string]interface{}{"condition": "is_vip == 'true'", "next": "vip"}},
+				"default_next": "regular",
+			},
+		},
+		models.ChatbotFlowStep{StepName: "regular", Message: "{{summary}}, thanks!", InputType: models.InputTypeNone, NextStep: "done"},
+		models.ChatbotFlowStep{StepName: "vip", Message: "{{summary}}, a VIP agent will call you", InputType: models.InputTypeNone},
+		models.ChatbotFlowStep{StepName: "done", Message: "Bye", InputType: models.InputTypeNone},
+	)
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{
+		Inputs: []SimulatedInput{{Text: "250"}},
+	})
+
+	assert.Equal(t, true, result.SessionData["is_vip"])
+	assert.Equal(t, "Total 250", result.SessionData["summary"])
+	assert.Equal(t, []string{"Order total?", "Total 250, a VIP agent will call you", "Bye"}, outgoingContents(result))
+	assert.Equal(t, models.SessionStatusCompleted, result.Status)
+}
+
+func TestSimulateFlow_DelayAndAssignTag(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{
+			StepName:     "wait",
+			Message:      "Give us a moment",
+			MessageType:  models.FlowStepTypeDelay,
+			ActionConfig: models.JSONB{"duration": float64(10), "unit": "seconds"},
+		},
+		models.ChatbotFlowStep{
+			StepName:     "tag",
+			MessageType:  models.FlowStepTypeAssignTag,
+			ActionConfig: models.JSONB{"add_tags": []interface{}{"qualified"}},
+		},
+		models.ChatbotFlowStep{StepName: "done", Message: "All set", InputType: models.InputTypeNone},
+	)
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{})
+
+	assert.Equal(t, []string{"Give us a moment", "All set"}, outgoingContents(result))
+	require.Len(t, result.Actions, 2)
+	assert.Equal(t, "delay", result.Actions[0].Type)
+	assert.Equal(t, "assign_tag", result.Actions[1].Type)
+	assert.Equal(t, models.SessionStatusCompleted, result.Status)
+}
+
+func TestSimulateFlow_ActionStepLoopEndsFlow(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{
+			StepName:     "a",
+			MessageType:  models.FlowStepTypeSetVariable,
+			ActionConfig: models.JSONB{"variables": []interface{}{map[string]interface{}{"name": "x", "value": "1"}}},
+		},
+		models.ChatbotFlowStep{
+			StepName:     "b",
+			MessageType:  models.FlowStepTypeCondition,
+			ActionConfig: models.JSONB{"branches": []interface{}{map[string]interface{}{"condition": "x == '1'", "next": "a"}}},
+		},
+	)
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{})
+
+	assert.Equal(t, models.SessionStatusCompleted, result.Status)
+}
+
+func TestSimulateFlow_MediaAndLocation(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{
+			StepName:    "menu",
+			Message:     "Our menu, {{name}}",
+			MessageType: models.FlowStepTypeMedia,
+			InputType:   models.InputTypeNone,
+			MediaConfig: models.JSONB{"media_type": "document", "url": "https://example.com/menu.pdf", "filename": "menu.pdf"},
+		},
+		models.ChatbotFlowStep{
+			StepName:       "where",
+			Message:        "Where should we deliver?",
+			MessageType:    models.FlowStepTypeLocationRequest,
+			InputType:      models.InputTypeLocation,
+			StoreAs:        "delivery",
+			RetryOnInvalid: true,
+			MaxRetries:     3,
+		},
+	)
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{
+		SessionData: map[string]interface{}{"name": "Sam"},
+		Inputs: []SimulatedInput{
+			{Text: "Near the park"},
+			{Location: map[string]interface{}{"latitude": 12.97, "longitude": 77.59}},
+		},
+	})
+
+	require.GreaterOrEqual(t, len(result.Messages), 2)
+	assert.Equal(t, models.MessageTypeDocument, result.Messages[0].Type)
+	assert.Equal(t, "Our menu, Sam", result.Messages[0].Content)
+	assert.Equal(t, "https://example.com/menu.pdf", result.Messages[0].URL)
+	assert.Equal(t, "location_request", result.Messages[1].InteractiveType)
+
+	assert.Contains(t, outgoingContents(result), "Please share your location to continue.")
+	assert.Equal(t, 12.97, result.SessionData["delivery_latitude"])
+	assert.Equal(t, 77.59, result.SessionData["delivery_longitude"])
+	assert.Equal(t, models.SessionStatusCompleted, result.Status)
+}
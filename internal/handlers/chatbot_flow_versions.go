@@ -0,0 +1,575 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// FlowVersionResponse represents a published flow version for API response
+type FlowVersionResponse struct {
+	ID             string `json:"id"`
+	Version        int    `json:"version"`
+	Note           string `json:"note"`
+	RolledBackFrom int    `json:"rolled_back_from,omitempty"`
+	PublishedByID  string `json:"published_by_id,omitempty"`
+	StepsCount     int    `json:"steps_count"`
+	IsLive         bool   `json:"is_live"`
+	CreatedAt      string `json:"created_at"`
+}
+
+// FlowFieldChange is a single changed field between two flow versions
+type FlowFieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// FlowStepChange lists the changed fields of a step present in both versions
+type FlowStepChange struct {
+	Step   string            `json:"step"`
+	Fields []FlowFieldChange `json:"fields"`
+}
+
+// FlowDiff describes the differences between two flow versions
+type FlowDiff struct {
+	From         string            `json:"from"`
+	To           string            `json:"to"`
+	Fields       []FlowFieldChange `json:"fields"`
+	AddedSteps   []string          `json:"added_steps"`
+	RemovedSteps []string          `json:"removed_steps"`
+	ChangedSteps []FlowStepChange  `json:"changed_steps"`
+}
+
+// Fields ignored when snapshotting and diffing flows: identity, timestamps,
+// relations and operational state that isn't part of a version
+var flowDiffIgnoredFields = map[string]bool{
+	"id":                true,
+	"created_at":        true,
+	"updated_at":        true,
+	"deleted_at":        true,
+	"organization_id":   true,
+	"organization":      true,
+	"initial_template":  true,
+	"steps":             true,
+	"is_enabled":        true,
+	"published_version": true,
+	"has_draft_changes": true,
+	"flow_id":           true,
+	"flow":              true,
+	"template":          true,
+	"position":          true,
+}
+
+// flowSnapshot serializes a flow and its steps for storing in a version
+func flowSnapshot(flow *models.ChatbotFlow) (models.JSONB, error) {
+	data, err := json.Marshal(flow)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot models.JSONB
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// flowFromSnapshot rebuilds the flow stored in a version
+func flowFromSnapshot(version *models.ChatbotFlowVersion) (*models.ChatbotFlow, error) {
+	data, err := json.Marshal(version.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	var flow models.ChatbotFlow
+	if err := json.Unmarshal(data, &flow); err != nil {
+		return nil, err
+	}
+	flow.ID = version.FlowID
+	flow.OrganizationID = version.OrganizationID
+	flow.PublishedVersion = version.Version
+	flow.HasDraftChanges = false
+	return &flow, nil
+}
+
+// toDiffMap converts a model to a JSON map without the ignored fields
+func toDiffMap(v any) map[string]any {
+	data, _ := json.Marshal(v)
+	var m map[string]any
+	_ = json.Unmarshal(data, &m)
+	for field := range flowDiffIgnoredFields {
+		delete(m, field)
+	}
+	return m
+}
+
+// diffFields compares two JSON maps and returns the changed fields in key order
+func diffFields(from, to map[string]any) []FlowFieldChange {
+	keys := make(map[string]bool, len(from)+len(to))
+	for k := range from {
+		keys[k] = true
+	}
+	for k := range to {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	changes := make([]FlowFieldChange, 0)
+	for _, k := range sorted {
+		if !reflect.DeepEqual(from[k], to[k]) {
+			changes = append(changes, FlowFieldChange{Field: k, From: from[k], To: to[k]})
+		}
+	}
+	return changes
+}
+
+// diffFlows compares two flows field by field and step by step (steps are matched by name)
+func diffFlows(from, to *models.ChatbotFlow) FlowDiff {
+	diff := FlowDiff{
+		Fields:       diffFields(toDiffMap(from), toDiffMap(to)),
+		AddedSteps:   make([]string, 0),
+		RemovedSteps: make([]string, 0),
+		ChangedSteps: make([]FlowStepChange, 0),
+	}
+
+	fromSteps := make(map[string]*models.ChatbotFlowStep, len(from.Steps))
+	for i := range from.Steps {
+		fromSteps[from.Steps[i].StepName] = &from.Steps[i]
+	}
+	toSteps := make(map[string]bool, len(to.Steps))
+
+	for i := range to.Steps {
+		step := &to.Steps[i]
+		toSteps[step.StepName] = true
+		old, ok := fromSteps[step.StepName]
+		if !ok {
+			diff.AddedSteps = append(diff.AddedSteps, step.StepName)
+			continue
+		}
+		if changes := diffFields(toDiffMap(old), toDiffMap(step)); len(changes) > 0 {
+			diff.ChangedSteps = append(diff.ChangedSteps, FlowStepChange{Step: step.StepName, Fields: changes})
+		}
+	}
+	for _, step := range from.Steps {
+		if !toSteps[step.StepName] {
+			diff.RemovedSteps = append(diff.RemovedSteps, step.StepName)
+		}
+	}
+
+	return diff
+}
+
+// flowStepsChanged reports whether two step lists differ in any versioned field
+func flowStepsChanged(from, to []models.ChatbotFlowStep) bool {
+	if len(from) != len(to) {
+		return true
+	}
+	diff := diffFlows(&models.ChatbotFlow{Steps: from}, &models.ChatbotFlow{Steps: to})
+	return len(diff.AddedSteps) > 0 || len(diff.RemovedSteps) > 0 || len(diff.ChangedSteps) > 0
+}
+
+// loadFlowDraft loads a flow with its draft steps in order
+func (a *App) loadFlowDraft(orgID, flowID uuid.UUID) (*models.ChatbotFlow, error) {
+	var flow models.ChatbotFlow
+	if err := a.DB.Where("id = ? AND organization_id = ?", flowID, orgID).
+		Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("step_order ASC")
+		}).
+		First(&flow).Error; err != nil {
+		return nil, err
+	}
+	return &flow, nil
+}
+
+// loadFlowVersion loads a flow version by number, or the draft when ref is "draft"
+func (a *App) loadFlowVersion(orgID uuid.UUID, draft *models.ChatbotFlow, ref string) (*models.ChatbotFlow, error) {
+	if ref == "draft" {
+		return draft, nil
+	}
+	number, err := strconv.Atoi(ref)
+	if err != nil || number < 1 {
+		return nil, fmt.Errorf("invalid version")
+	}
+	var version models.ChatbotFlowVersion
+	if err := a.DB.Where("organization_id = ? AND flow_id = ? AND version = ?", orgID, draft.ID, number).
+		First(&version).Error; err != nil {
+		return nil, err
+	}
+	return flowFromSnapshot(&version)
+}
+
+// publishFlowVersion stores the snapshot as the next version and makes it live
+func publishFlowVersion(tx *gorm.DB, flow *models.ChatbotFlow, snapshot models.JSONB, note string, rolledBackFrom int, userID uuid.UUID) (*models.ChatbotFlowVersion, error) {
+	var latest int
+	if err := tx.Model(&models.ChatbotFlowVersion{}).
+		Where("flow_id = ?", flow.ID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		return nil, err
+	}
+
+	version := &models.ChatbotFlowVersion{
+		BaseModel:      models.BaseModel{ID: uuid.New()},
+		OrganizationID: flow.OrganizationID,
+		FlowID:         flow.ID,
+		Version:        latest + 1,
+		Note:           note,
+		Snapshot:       snapshot,
+		RolledBackFrom: rolledBackFrom,
+		PublishedByID:  &userID,
+	}
+	if err := tx.Create(version).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(flow).Updates(map[string]interface{}{
+		"published_version": version.Version,
+		"has_draft_changes": false,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// restoreFlowDraft replaces the draft flow settings and steps with those of a published version
+func restoreFlowDraft(tx *gorm.DB, draft, source *models.ChatbotFlow) error {
+	if err := tx.Model(draft).Updates(map[string]interface{}{
+		"name":                 source.Name,
+		"description":          source.Description,
+		"trigger_keywords":     source.TriggerKeywords,
+		"trigger_button_id":    source.TriggerButtonID,
+		"initial_message":      source.InitialMessage,
+		"initial_message_type": source.InitialMessageType,
+		"initial_template_id":  source.InitialTemplateID,
+		"completion_message":   source.CompletionMessage,
+		"on_complete_action":   source.OnCompleteAction,
+		"completion_config":    source.CompletionConfig,
+		"timeout_message":      source.TimeoutMessage,
+		"cancel_keywords":      source.CancelKeywords,
+		"panel_config":         source.PanelConfig,
+		"translations":         source.Translations,
+		"has_draft_changes":    false,
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("flow_id = ?", draft.ID).Delete(&models.ChatbotFlowStep{}).Error; err != nil {
+		return err
+	}
+	for _, step := range source.Steps {
+		step.ID = uuid.New()
+		step.FlowID = draft.ID
+		step.CreatedAt = time.Time{}
+		step.UpdatedAt = time.Time{}
+		if err := tx.Create(&step).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishChatbotFlow publishes the current draft as a new immutable version
+func (a *App) PublishChatbotFlow(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionWrite, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if len(r.RequestCtx.PostBody()) > 0 {
+		if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
+		}
+	}
+
+	flow, err := a.loadFlowDraft(orgID, id)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow not found", nil, "")
+	}
+
+	if len(flow.Steps) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Flow has no steps", nil, "")
+	}
+
+	graph := a.validateFlowSteps(orgID, flow.ID, flow.Name, flow.Steps)
+	if graph.HasErrors() {
+		return sendFlowGraphErrors(r, graph)
+	}
+
+	snapshot, err := flowSnapshot(flow)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to publish flow", nil, "")
+	}
+
+	var version *models.ChatbotFlowVersion
+	if err := a.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		version, err = publishFlowVersion(tx, flow, snapshot, req.Note, 0, userID)
+		return err
+	}); err != nil {
+		a.Log.Error("Failed to publish flow", "error", err, "flow_id", flow.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to publish flow", nil, "")
+	}
+
+	a.InvalidateChatbotFlowsCache(orgID)
+
+	return r.SendEnvelope(map[string]any{
+		"version":  version.Version,
+		"message":  "Flow published successfully",
+		"warnings": graph.Warnings(),
+	})
+}
+
+// ListChatbotFlowVersions lists the published versions of a flow, newest first
+func (a *App) ListChatbotFlowVersions(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionRead, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	flow, err := findByIDAndOrg[models.ChatbotFlow](a.DB, r, id, orgID, "Flow")
+	if err != nil {
+		return nil
+	}
+
+	var versions []models.ChatbotFlowVersion
+	if err := a.DB.Where("organization_id = ? AND flow_id = ?", orgID, id).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to fetch flow versions", nil, "")
+	}
+
+	response := make([]FlowVersionResponse, len(versions))
+	for i, v := range versions {
+		steps, _ := v.Snapshot["steps"].([]interface{})
+		response[i] = FlowVersionResponse{
+			ID:             v.ID.String(),
+			Version:        v.Version,
+			Note:           v.Note,
+			RolledBackFrom: v.RolledBackFrom,
+			StepsCount:     len(steps),
+			IsLive:         v.Version == flow.PublishedVersion,
+			CreatedAt:      v.CreatedAt.Format(time.RFC3339),
+		}
+		if v.PublishedByID != nil {
+			response[i].PublishedByID = v.PublishedByID.String()
+		}
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"versions":          response,
+		"published_version": flow.PublishedVersion,
+		"has_draft_changes": flow.HasDraftChanges,
+	})
+}
+
+// GetChatbotFlowVersion returns the flow and steps as they were in a published version
+func (a *App) GetChatbotFlowVersion(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionRead, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	draft, err := a.loadFlowDraft(orgID, id)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow not found", nil, "")
+	}
+
+	versionRef, _ := r.RequestCtx.UserValue("version").(string)
+	flow, err := a.loadFlowVersion(orgID, draft, versionRef)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow version not found", nil, "")
+	}
+
+	return r.SendEnvelope(flow)
+}
+
+// DiffChatbotFlowVersions compares two versions of a flow.
+// Query params from/to take a version number or "draft" (defaults: live version -> draft).
+func (a *App) DiffChatbotFlowVersions(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionRead, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	draft, err := a.loadFlowDraft(orgID, id)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow not found", nil, "")
+	}
+
+	fromRef := string(r.RequestCtx.QueryArgs().Peek("from"))
+	toRef := string(r.RequestCtx.QueryArgs().Peek("to"))
+	if fromRef == "" {
+		if draft.PublishedVersion == 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Flow has not been published", nil, "")
+		}
+		fromRef = strconv.Itoa(draft.PublishedVersion)
+	}
+	if toRef == "" {
+		toRef = "draft"
+	}
+
+	from, err := a.loadFlowVersion(orgID, draft, fromRef)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow version not found: "+fromRef, nil, "")
+	}
+	to, err := a.loadFlowVersion(orgID, draft, toRef)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow version not found: "+toRef, nil, "")
+	}
+
+	diff := diffFlows(from, to)
+	diff.From = fromRef
+	diff.To = toRef
+	return r.SendEnvelope(diff)
+}
+
+// RollbackChatbotFlow republishes an earlier version as a new version and resets the draft to it
+func (a *App) RollbackChatbotFlow(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionWrite, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	draft, err := a.loadFlowDraft(orgID, id)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow not found", nil, "")
+	}
+
+	versionRef, _ := r.RequestCtx.UserValue("version").(string)
+	number, err := strconv.Atoi(versionRef)
+	if err != nil || number < 1 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid version", nil, "")
+	}
+
+	var source models.ChatbotFlowVersion
+	if err := a.DB.Where("organization_id = ? AND flow_id = ? AND version = ?", orgID, id, number).
+		First(&source).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow version not found", nil, "")
+	}
+
+	sourceFlow, err := flowFromSnapshot(&source)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to roll back flow", nil, "")
+	}
+
+	var version *models.ChatbotFlowVersion
+	if err := a.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		note := fmt.Sprintf("Rollback to version %d", number)
+		if version, err = publishFlowVersion(tx, draft, source.Snapshot, note, number, userID); err != nil {
+			return err
+		}
+		return restoreFlowDraft(tx, draft, sourceFlow)
+	}); err != nil {
+		a.Log.Error("Failed to roll back flow", "error", err, "flow_id", id, "version", number)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to roll back flow", nil, "")
+	}
+
+	a.InvalidateChatbotFlowsCache(orgID)
+
+	return r.SendEnvelope(map[string]any{
+		"version": version.Version,
+		"message": fmt.Sprintf("Flow rolled back to version %d", number),
+	})
+}
+
+// DiscardChatbotFlowDraft resets the draft to the live published version
+func (a *App) DiscardChatbotFlowDraft(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionWrite, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	draft, err := a.loadFlowDraft(orgID, id)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow not found", nil, "")
+	}
+	if draft.PublishedVersion == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Flow has not been published", nil, "")
+	}
+
+	published, err := a.loadFlowVersion(orgID, draft, strconv.Itoa(draft.PublishedVersion))
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow version not found", nil, "")
+	}
+
+	if err := a.DB.Transaction(func(tx *gorm.DB) error {
+		return restoreFlowDraft(tx, draft, published)
+	}); err != nil {
+		a.Log.Error("Failed to discard flow draft", "error", err, "flow_id", id)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to discard draft", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"message": "Draft discarded",
+	})
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVersionTestFlow(steps ...models.ChatbotFlowStep) *models.ChatbotFlow {
+	flowID := uuid.New()
+	for i := range steps {
+		steps[i].BaseModel = models.BaseModel{ID: uuid.New()}
+		steps[i].FlowID = flowID
+		steps[i].StepOrder = i + 1
+	}
+	return &models.ChatbotFlow{
+		BaseModel:       models.BaseModel{ID: flowID},
+		OrganizationID:  uuid.New(),
+		Name:            "Support",
+		TriggerKeywords: models.StringArray{"help"},
+		IsEnabled:       true,
+		Steps:           steps,
+	}
+}
+
+func TestFlowSnapshot_RoundTrip(t *testing.T) {
+	flow := newVersionTestFlow(
+		models.ChatbotFlowStep{StepName: "ask_name", Message: "Name?", InputType: models.InputTypeText, StoreAs: "name"},
+		models.ChatbotFlowStep{StepName: "ask_city", Message: "City?", InputType: models.InputTypeText, StoreAs: "city"},
+	)
+	flow.CompletionMessage = "Thanks {{name}}"
+
+	snapshot, err := flowSnapshot(flow)
+	require.NoError(t, err)
+
+	version := &models.ChatbotFlowVersion{
+		OrganizationID: flow.OrganizationID,
+		FlowID:         flow.ID,
+		Version:        3,
+		Snapshot:       snapshot,
+	}
+	restored, err := flowFromSnapshot(version)
+	require.NoError(t, err)
+
+	assert.Equal(t, flow.ID, restored.ID)
+	assert.Equal(t, flow.OrganizationID, restored.OrganizationID)
+	assert.Equal(t, 3, restored.PublishedVersion)
+	assert.False(t, restored.HasDraftChanges)
+	assert.Equal(t, "Thanks {{name}}", restored.CompletionMessage)
+	require.Len(t, restored.Steps, 2)
+	assert.Equal(t, "ask_city", restored.Steps[1].StepName)
+	assert.Equal(t, "city", restored.Steps[1].StoreAs)
+	assert.Empty(t, diffFlows(flow, restored).Fields)
+}
+
+func TestDiffFlows(t *testing.T) {
+	from := newVersionTestFlow(
+		models.ChatbotFlowStep{StepName: "greet", Message: "Hello", InputType: models.InputTypeNone},
+		models.ChatbotFlowStep{StepName: "ask_email", Message: "Email?", InputType: models.InputTypeEmail},
+		models.ChatbotFlowStep{StepName: "legacy", Message: "Old step", InputType: models.InputTypeNone},
+	)
+	to := newVersionTestFlow(
+		models.ChatbotFlowStep{StepName: "greet", Message: "Hello", InputType: models.InputTypeNone},
+		models.ChatbotFlowStep{StepName: "ask_email", Message: "Your email?", InputType: models.InputTypeEmail},
+		models.ChatbotFlowStep{StepName: "ask_phone", Message: "Phone?", InputType: models.InputTypePhone},
+	)
+	to.Name = "Support v2"
+	to.IsEnabled = false
+
+	diff := diffFlows(from, to)
+
+	require.Len(t, diff.Fields, 1, "is_enabled and identity fields are not versioned")
+	assert.Equal(t, "name", diff.Fields[0].Field)
+	assert.Equal(t, "Support", diff.Fields[0].From)
+	assert.Equal(t, "Support v2", diff.Fields[0].To)
+
+	assert.Equal(t, []string{"ask_phone"}, diff.AddedSteps)
+	assert.Equal(t, []string{"legacy"}, diff.RemovedSteps)
+	require.Len(t, diff.ChangedSteps, 1)
+	assert.Equal(t, "ask_email", diff.ChangedSteps[0].Step)
+	require.Len(t, diff.ChangedSteps[0].Fields, 1)
+	assert.Equal(t, "message", diff.ChangedSteps[0].Fields[0].Field)
+}
+
+func TestApplySessionUpdates_FlowVersion(t *testing.T) {
+	session := &models.ChatbotSession{}
+	applySessionUpdates(session, map[string]interface{}{
+		"current_step":         "ask_name",
+		"current_flow_version": 2,
+	})
+
+	assert.Equal(t, "ask_name", session.CurrentStep)
+	assert.Equal(t, 2, session.CurrentFlowVersion)
+}
+
+func TestDiffFields_FlowContentFields(t *testing.T) {
+	flow := newVersionTestFlow()
+	before := toDiffMap(flow)
+
+	flow.TimeoutMessage = "Are you still there?"
+	flow.CancelKeywords = models.StringArray{"stop"}
+	flow.Translations = models.JSONB{"es": map[string]interface{}{"completion_message": "Gracias"}}
+	flow.IsEnabled = false
+
+	changed := make([]string, 0)
+	for _, change := range diffFields(before, toDiffMap(flow)) {
+		changed = append(changed, change.Field)
+	}
+	assert.Equal(t, []string{"cancel_keywords", "timeout_message", "translations"}, changed)
+}
+
+func TestFlowStepsChanged(t *testing.T) {
+	current := newVersionTestFlow(
+		models.ChatbotFlowStep{StepName: "greet", Message: "Hello", InputType: models.InputTypeNone},
+	).Steps
+	same := newVersionTestFlow(
+		models.ChatbotFlowStep{StepName: "greet", Message: "Hello", InputType: models.InputTypeNone},
+	).Steps
+	translated := newVersionTestFlow(
+		models.ChatbotFlowStep{StepName: "greet", Message: "Hello", InputType: models.InputTypeNone,
+			Translations: models.JSONB{"es": map[string]interface{}{"message": "Hola"}}},
+	).Steps
+
+	assert.False(t, flowStepsChanged(current, same), "resaving the same steps is not a change")
+	assert.True(t, flowStepsChanged(current, translated))
+	assert.True(t, flowStepsChanged(current, nil))
+}
+
+func TestPinDraftFlow_KeepsSessionOnDraftAsStarted(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newVersionTestFlow(
+		models.ChatbotFlowStep{StepName: "ask_name", Message: "Name?", InputType: models.InputTypeText},
+	)
+	session := &models.ChatbotSession{OrganizationID: flow.OrganizationID}
+
+	app.pinDraftFlow(session, flow)
+	flow.Steps[0].Message = "What is your name?"
+	app.pinDraftFlow(session, flow)
+
+	pinned, err := app.loadChatbotFlow(session, flow.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, pinned.Steps, 1)
+	assert.Equal(t, "Name?", pinned.Steps[0].Message, "edits after the session started are not used")
+	assert.Equal(t, flow.OrganizationID, pinned.OrganizationID)
+	assert.Equal(t, 0, pinned.PublishedVersion)
+}
+
+func TestPinDraftFlow_SkipsPublishedFlows(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newVersionTestFlow()
+	flow.PublishedVersion = 2
+	session := &models.ChatbotSession{OrganizationID: flow.OrganizationID}
+
+	app.pinDraftFlow(session, flow)
+
+	assert.Empty(t, session.FlowSnapshots)
+}
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+)
+
+// keywordRuleActive reports whether now falls inside the rule's active window.
+// ActiveFrom is inclusive, ActiveUntil exclusive; an unset bound is open.
+func keywordRuleActive(rule *models.KeywordRule, now time.Time) bool {
+	if rule.ActiveFrom != nil && now.Before(*rule.ActiveFrom) {
+		return false
+	}
+	if rule.ActiveUntil != nil && !now.Before(*rule.ActiveUntil) {
+		return false
+	}
+	return true
+}
+
+// keywordRuleMatches reports whether any of the rule's keywords matches the message
+func keywordRuleMatches(rule *models.KeywordRule, messageText string) bool {
+	messageLower := strings.ToLower(messageText)
+
+	for _, keyword := range rule.Keywords {
+		keywordLower := strings.ToLower(keyword)
+		matched := false
+
+		switch rule.MatchType {
+		case models.MatchTypeExact:
+			if rule.CaseSensitive {
+				matched = messageText == keyword
+			} else {
+				matched = messageLower == keywordLower
+			}
+		case models.MatchTypeContains:
+			if rule.CaseSensitive {
+				matched = strings.Contains(messageText, keyword)
+			} else {
+				matched = strings.Contains(messageLower, keywordLower)
+			}
+		case models.MatchTypeStartsWith:
+			if rule.CaseSensitive {
+				matched = strings.HasPrefix(messageText, keyword)
+			} else {
+				matched = strings.HasPrefix(messageLower, keywordLower)
+			}
+		case models.MatchTypeRegex:
+			re, err := regexp.Compile(keyword)
+			if err == nil {
+				matched = re.MatchString(messageText)
+			}
+		default:
+			// Default to contains
+			matched = strings.Contains(messageLower, keywordLower)
+		}
+
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// keywordRuleConditionsMet evaluates the rule's conditions expression against
+// data. Rules without conditions always pass.
+func keywordRuleConditionsMet(rule *models.KeywordRule, data map[string]interface{}) bool {
+	if strings.TrimSpace(rule.Conditions) == "" {
+		return true
+	}
+	return evaluateExpression(rule.Conditions, data)
+}
+
+// buildKeywordResponse builds the response of a matched rule, or nil when the
+// rule has nothing to send
+func buildKeywordResponse(rule *models.KeywordRule) *KeywordResponse {
+	response := &KeywordResponse{
+		ResponseType: rule.ResponseType,
+		Content:      rule.ResponseContent,
+	}
+	if body, ok := rule.ResponseContent["body"].(string); ok {
+		response.Body = body
+	}
+
+	switch rule.ResponseType {
+	case models.ResponseTypeTransfer, models.ResponseTypeTemplate, models.ResponseTypeMedia, models.ResponseTypeFlow:
+		// The body is optional: transfer message, or text fallback when sending fails
+		return response
+	}
+
+	// Get buttons if present
+	if buttons, ok := rule.ResponseContent["buttons"].([]interface{}); ok && len(buttons) > 0 {
+		response.Buttons = make([]map[string]interface{}, 0, len(buttons))
+		for _, btn := range buttons {
+			if btnMap, ok := btn.(map[string]interface{}); ok {
+				response.Buttons = append(response.Buttons, btnMap)
+			}
+		}
+	}
+
+	if response.Body == "" {
+		return nil
+	}
+	return response
+}
+
+// keywordRuleData collects the variables available to keyword rule conditions
+// and templates: contact metadata, session data and contact fields
+func keywordRuleData(contact *models.Contact, session *models.ChatbotSession) map[string]interface{} {
+	data := make(map[string]interface{})
+	for k, v := range contact.Metadata {
+		data[k] = v
+	}
+	if session != nil {
+		for k, v := range session.SessionData {
+			data[k] = v
+		}
+	}
+	data["phone_number"] = contact.PhoneNumber
+	data["contact_name"] = contact.ProfileName
+	data["language"] = contact.Language
+	return data
+}
+
+// parseKeywordRuleWindow parses the RFC3339 active_from/active_until bounds of
+// a keyword rule; an empty value means no bound
+func parseKeywordRuleWindow(from, until string) (*time.Time, *time.Time, error) {
+	var activeFrom, activeUntil *time.Time
+	if from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, nil, fmt.Errorf("active_from must be an RFC3339 time")
+		}
+		activeFrom = &t
+	}
+	if until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, nil, fmt.Errorf("active_until must be an RFC3339 time")
+		}
+		activeUntil = &t
+	}
+	if activeFrom != nil && activeUntil != nil && !activeFrom.Before(*activeUntil) {
+		return nil, nil, fmt.Errorf("active_from must be before active_until")
+	}
+	return activeFrom, activeUntil, nil
+}
+
+// validateKeywordResponse checks the response_content of template, media and
+// flow keyword rules
+func validateKeywordResponse(responseType models.ResponseType, content models.JSONB) error {
+	switch responseType {
+	case models.ResponseTypeTemplate:
+		templateID, _ := content["template_id"].(string)
+		templateName, _ := content["template_name"].(string)
+		if templateID == "" && templateName == "" {
+			return fmt.Errorf("response_content needs a template_id or template_name")
+		}
+		if templateID != "" {
+			if _, err := uuid.Parse(templateID); err != nil {
+				return fmt.Errorf("response_content.template_id must be a template ID")
+			}
+		}
+		if params, ok := content["params"]; ok {
+			if _, ok := params.(map[string]interface{}); !ok {
+				return fmt.Errorf("response_content.params must be an object")
+			}
+		}
+	case models.ResponseTypeMedia:
+		return validateMediaConfig(content, "response_content")
+	case models.ResponseTypeFlow:
+		flowID, _ := content["flow_id"].(string)
+		waFlowID, _ := content["whatsapp_flow_id"].(string)
+		if (flowID == "") == (waFlowID == "") {
+			return fmt.Errorf("response_content needs either flow_id or whatsapp_flow_id")
+		}
+		if flowID != "" {
+			if _, err := uuid.Parse(flowID); err != nil {
+				return fmt.Errorf("response_content.flow_id must be a chatbot flow ID")
+			}
+		}
+		if waFlowID != "" {
+			if body, _ := content["body"].(string); body == "" {
+				return fmt.Errorf("response_content.body is required for WhatsApp Flows")
+			}
+		}
+	}
+	return nil
+}
+
+// sendKeywordResponse sends the reply of a matched (non-transfer) keyword rule.
+// Template, media and flow replies fall back to the rule body as plain text
+// when they cannot be sent.
+func (a *App) sendKeywordResponse(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, resp *KeywordResponse, data map[string]interface{}) {
+	message := resp.Body
+	sent := false
+
+	switch resp.ResponseType {
+	case models.ResponseTypeTemplate:
+		template, err := a.keywordTemplate(account, resp.Content, sessionLanguage(session))
+		if err != nil {
+			a.Log.Error("Failed to load keyword template", "error", err, "template_id", resp.Content["template_id"], "template_name", resp.Content["template_name"])
+			break
+		}
+		params, _ := resp.Content["params"].(map[string]interface{})
+		if err := a.sendAndSaveTemplateMessage(account, contact, template, templateParams(params, data)); err != nil {
+			a.Log.Error("Failed to send keyword template", "error", err, "template", template.Name, "contact", contact.PhoneNumber)
+			break
+		}
+		message = template.BodyContent
+		sent = true
+
+	case models.ResponseTypeMedia:
+		caption, _ := resp.Content["caption"].(string)
+		if caption == "" {
+			caption = resp.Body
+		}
+		message = processTemplate(caption, data)
+		if err := a.sendAndSaveMediaMessage(account, contact, resp.Content, message); err != nil {
+			a.Log.Error("Failed to send keyword media", "error", err, "contact", contact.PhoneNumber)
+			break
+		}
+		sent = true
+
+	case models.ResponseTypeFlow:
+		if flowID, _ := resp.Content["flow_id"].(string); flowID != "" {
+			id, err := uuid.Parse(flowID)
+			if err != nil {
+				a.Log.Error("Keyword rule has an invalid flow ID", "flow_id", flowID)
+				break
+			}
+			// Only enabled flows are cached; the live (published) version is started
+			flow, err := a.getChatbotFlowByIDCached(account.OrganizationID, id, 0)
+			if err != nil {
+				a.Log.Error("Keyword rule flow not found or disabled", "error", err, "flow_id", flowID)
+				break
+			}
+			a.startFlow(account, session, contact, flow)
+			return
+		}
+
+		waFlowID, _ := resp.Content["whatsapp_flow_id"].(string)
+		if waFlowID == "" {
+			a.Log.Error("Keyword rule flow response has no flow ID")
+			break
+		}
+		message = processTemplate(resp.Body, data)
+		headerText, _ := resp.Content["flow_header"].(string)
+		ctaText, _ := resp.Content["flow_cta"].(string)
+		flowToken := fmt.Sprintf("keyword_%s_%d", session.ID.String(), time.Now().UnixNano())
+		flowAction, firstScreen := a.whatsAppFlowEntry(account, waFlowID)
+		if err := a.sendAndSaveFlowMessage(account, contact, waFlowID, processTemplate(headerText, data), message, ctaText, flowToken, flowAction, firstScreen); err != nil {
+			a.Log.Error("Failed to send WhatsApp Flow message", "error", err, "contact", contact.PhoneNumber, "flow_id", waFlowID)
+			break
+		}
+		sent = true
+
+	case models.ResponseTypeScript:
+		// Script replies render the body against contact and session variables
+		message = processTemplate(resp.Body, data)
+	}
+
+	if !sent && message != "" {
+		if len(resp.Buttons) > 0 {
+			if err := a.sendAndSaveInteractiveButtons(account, contact, message, resp.Buttons); err != nil {
+				a.Log.Error("Failed to send interactive buttons", "error", err, "contact", contact.PhoneNumber)
+			}
+		} else {
+			if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
+				a.Log.Error("Failed to send text message", "error", err, "contact", contact.PhoneNumber)
+			}
+		}
+	}
+	// Log outgoing message
+	a.logSessionMessage(session.ID, models.DirectionOutgoing, message, "keyword_response")
+}
+
+// keywordTemplate loads the template of a template keyword rule, by
+// template_id or by template_name among the account's approved templates.
+// The variant in lang (or the rule's language) is preferred.
+func (a *App) keywordTemplate(account *models.WhatsAppAccount, content models.JSONB, lang string) (*models.Template, error) {
+	if language, _ := content["language"].(string); language != "" {
+		lang = language
+	}
+	if templateID, _ := content["template_id"].(string); templateID != "" {
+		id, err := uuid.Parse(templateID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template ID %q", templateID)
+		}
+		return a.loadTemplateVariant(account, id, lang)
+	}
+
+	templateName, _ := content["template_name"].(string)
+	var templates []models.Template
+	if err := a.DB.Where("organization_id = ? AND whats_app_account = ? AND name = ? AND status = ?",
+		account.OrganizationID, account.Name, templateName, "APPROVED").
+		Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("no approved template named %q", templateName)
+	}
+	if template := templateutil.SelectLanguage(templates, lang); template != nil {
+		return template, nil
+	}
+	return &templates[0], nil
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeywordRuleActive(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	after := now.Add(time.Hour)
+
+	assert.True(t, keywordRuleActive(&models.KeywordRule{}, now), "no window")
+	assert.True(t, keywordRuleActive(&models.KeywordRule{ActiveFrom: &before, ActiveUntil: &after}, now))
+	assert.True(t, keywordRuleActive(&models.KeywordRule{ActiveFrom: &now}, now), "start is inclusive")
+	assert.False(t, keywordRuleActive(&models.KeywordRule{ActiveUntil: &now}, now), "end is exclusive")
+	assert.False(t, keywordRuleActive(&models.KeywordRule{ActiveFrom: &after}, now), "not started")
+	assert.False(t, keywordRuleActive(&models.KeywordRule{ActiveUntil: &before}, now), "expired")
+}
+
+func TestKeywordRuleConditionsMet(t *testing.T) {
+	contact := &models.Contact{
+		PhoneNumber: "+15550001",
+		ProfileName: "Ana",
+		Language:    "es",
+		Metadata:    models.JSONB{"tier": "gold"},
+	}
+	session := &models.ChatbotSession{SessionData: models.JSONB{"orders": float64(3)}}
+	data := keywordRuleData(contact, session)
+
+	tests := []struct {
+		conditions string
+		want       bool
+	}{
+		{"", true},
+		{"tier == 'gold'", true},
+		{"tier == 'silver'", false},
+		{"orders > 2 AND language == 'es'", true},
+		{"orders > 5 OR contact_name == 'Ana'", true},
+		{"phone_number == '+15550002'", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.conditions, func(t *testing.T) {
+			assert.Equal(t, tt.want, keywordRuleConditionsMet(&models.KeywordRule{Conditions: tt.conditions}, data))
+		})
+	}
+}
+
+func TestBuildKeywordResponse(t *testing.T) {
+	text := buildKeywordResponse(&models.KeywordRule{
+		ResponseType:    models.ResponseTypeText,
+		ResponseContent: models.JSONB{"body": "Hi", "buttons": []interface{}{map[string]interface{}{"id": "a", "title": "A"}}},
+	})
+	require.NotNil(t, text)
+	assert.Equal(t, "Hi", text.Body)
+	assert.Len(t, text.Buttons, 1)
+
+	assert.Nil(t, buildKeywordResponse(&models.KeywordRule{ResponseType: models.ResponseTypeText, ResponseContent: models.JSONB{}}), "text rules need a body")
+
+	media := buildKeywordResponse(&models.KeywordRule{
+		ResponseType:    models.ResponseTypeMedia,
+		ResponseContent: models.JSONB{"media_type": "image", "url": "https://example.com/a.png"},
+	})
+	require.NotNil(t, media, "media rules don't need a body")
+	assert.Equal(t, "https://example.com/a.png", media.Content["url"])
+}
+
+func TestValidateKeywordResponse(t *testing.T) {
+	tests := []struct {
+		name         string
+		responseType models.ResponseType
+		content      models.JSONB
+		wantErr      bool
+	}{
+		{name: "text is not checked", responseType: models.ResponseTypeText, content: models.JSONB{"text": "Hi"}},
+		{name: "template by id", responseType: models.ResponseTypeTemplate, content: models.JSONB{"template_id": uuid.New().String(), "params": map[string]interface{}{"1": "{{contact_name}}"}}},
+		{name: "template by name", responseType: models.ResponseTypeTemplate, content: models.JSONB{"template_name": "welcome"}},
+		{name: "template missing", responseType: models.ResponseTypeTemplate, content: models.JSONB{}, wantErr: true},
+		{name: "template bad params", responseType: models.ResponseTypeTemplate, content: models.JSONB{"template_name": "welcome", "params": "x"}, wantErr: true},
+		{name: "media", responseType: models.ResponseTypeMedia, content: models.JSONB{"media_type": "document", "url": "https://example.com/a.pdf"}},
+		{name: "media without source", responseType: models.ResponseTypeMedia, content: models.JSONB{"media_type": "image"}, wantErr: true},
+		{name: "chatbot flow", responseType: models.ResponseTypeFlow, content: models.JSONB{"flow_id": uuid.New().String()}},
+		{name: "whatsapp flow", responseType: models.ResponseTypeFlow, content: models.JSONB{"whatsapp_flow_id": "123", "body": "Book now"}},
+		{name: "whatsapp flow without body", responseType: models.ResponseTypeFlow, content: models.JSONB{"whatsapp_flow_id": "123"}, wantErr: true},
+		{name: "both flows", responseType: models.ResponseTypeFlow, content: models.JSONB{"flow_id": uuid.New().String(), "whatsapp_flow_id": "123", "body": "x"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKeywordResponse(tt.responseType, tt.content)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseKeywordRuleWindow(t *testing.T) {
+	from, until, err := parseKeywordRuleWindow("2026-12-01T00:00:00Z", "")
+	require.NoError(t, err)
+	require.NotNil(t, from)
+	assert.Nil(t, until)
+
+	_, _, err = parseKeywordRuleWindow("2026-12-01", "")
+	assert.Error(t, err)
+	_, _, err = parseKeywordRuleWindow("2026-12-02T00:00:00Z", "2026-12-01T00:00:00Z")
+	assert.Error(t, err)
+}
+
+func TestSendKeywordResponse(t *testing.T) {
+	sim := newFlowSimulation(SimulateFlowRequest{})
+	app := &App{Log: testutil.NopLogger(), flowSim: sim}
+	account := &models.WhatsAppAccount{Name: "main"}
+	contact := &models.Contact{PhoneNumber: "+15550001", ProfileName: "Ana"}
+	session := &models.ChatbotSession{}
+	data := keywordRuleData(contact, session)
+
+	app.sendKeywordResponse(account, session, contact, &KeywordResponse{
+		ResponseType: models.ResponseTypeScript,
+		Body:         "Hello {{contact_name}}",
+	}, data)
+	app.sendKeywordResponse(account, session, contact, &KeywordResponse{
+		ResponseType: models.ResponseTypeMedia,
+		Body:         "Menu for {{contact_name}}",
+		Content:      models.JSONB{"media_type": "image", "url": "https://example.com/menu.png"},
+	}, data)
+	app.sendKeywordResponse(account, session, contact, &KeywordResponse{
+		ResponseType: models.ResponseTypeMedia,
+		Body:         "Our menu is unavailable",
+		Content:      models.JSONB{"media_type": "sticker"},
+	}, data)
+
+	messages := sim.result.Messages
+	require.Len(t, messages, 3)
+	assert.Equal(t, "Hello Ana", messages[0].Content)
+	assert.Equal(t, models.MessageTypeImage, messages[1].Type)
+	assert.Equal(t, "Menu for Ana", messages[1].Content)
+	assert.Equal(t, "https://example.com/menu.png", messages[1].URL)
+	assert.Equal(t, models.MessageTypeText, messages[2].Type, "invalid media falls back to text")
+	assert.Equal(t, "Our menu is unavailable", messages[2].Content)
+}
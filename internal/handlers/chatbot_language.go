@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+)
+
+// sessionLanguageKey is the session data key holding the conversation language
+const sessionLanguageKey = "_language"
+
+// matchLanguage returns the candidate matching lang exactly, or else the first
+// candidate with the same base language. Returns "" when nothing matches.
+func matchLanguage(candidates []string, lang string) string {
+	lang = templateutil.NormalizeLanguage(lang)
+	if lang == "" {
+		return ""
+	}
+	for _, c := range candidates {
+		if templateutil.NormalizeLanguage(c) == lang {
+			return c
+		}
+	}
+	base := templateutil.BaseLanguage(lang)
+	for _, c := range candidates {
+		if templateutil.BaseLanguage(c) == base {
+			return c
+		}
+	}
+	return ""
+}
+
+// languageTranslation returns the translated fields for lang from a
+// {"es": {"field": "text"}} translations object
+func languageTranslation(translations models.JSONB, lang string) map[string]interface{} {
+	if len(translations) == 0 || lang == "" {
+		return nil
+	}
+	keys := make([]string, 0, len(translations))
+	for k := range translations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	key := matchLanguage(keys, lang)
+	if key == "" {
+		return nil
+	}
+	fields, _ := translations[key].(map[string]interface{})
+	return fields
+}
+
+// localizedText returns the translation of field for lang, or fallback when there is none
+func localizedText(translations models.JSONB, lang, field, fallback string) string {
+	if text, ok := languageTranslation(translations, lang)[field].(string); ok && text != "" {
+		return text
+	}
+	return fallback
+}
+
+// localizedButtons replaces button titles with the translated titles listed under
+// field, matched by position. Button IDs are kept so routing does not depend on the language.
+func localizedButtons(translations models.JSONB, lang, field string, buttons models.JSONBArray) models.JSONBArray {
+	titles, ok := languageTranslation(translations, lang)[field].([]interface{})
+	if !ok || len(titles) == 0 {
+		return buttons
+	}
+	result := make(models.JSONBArray, len(buttons))
+	for i, btn := range buttons {
+		result[i] = btn
+		btnMap, ok := btn.(map[string]interface{})
+		if !ok || i >= len(titles) {
+			continue
+		}
+		title, _ := titles[i].(string)
+		if title == "" {
+			continue
+		}
+		localized := make(map[string]interface{}, len(btnMap)+1)
+		for k, v := range btnMap {
+			localized[k] = v
+		}
+		// Pin the generated ID so it matches the untranslated button
+		if id, _ := btnMap["id"].(string); id == "" {
+			localized["id"] = fmt.Sprintf("btn_%d", i+1)
+		}
+		localized["title"] = title
+		result[i] = localized
+	}
+	return result
+}
+
+// localizeFlowStep returns a copy of step with its texts in lang. The step itself
+// is returned when it has no translation for lang.
+func localizeFlowStep(step *models.ChatbotFlowStep, lang string) *models.ChatbotFlowStep {
+	fields := languageTranslation(step.Translations, lang)
+	if len(fields) == 0 {
+		return step
+	}
+	localized := *step
+	localized.Message = localizedText(step.Translations, lang, "message", step.Message)
+	localized.ValidationError = localizedText(step.Translations, lang, "validation_error", step.ValidationError)
+	localized.Buttons = localizedButtons(step.Translations, lang, "buttons", step.Buttons)
+	if caption, ok := fields["caption"].(string); ok && caption != "" {
+		mediaConfig := make(models.JSONB, len(step.MediaConfig)+1)
+		for k, v := range step.MediaConfig {
+			mediaConfig[k] = v
+		}
+		mediaConfig["caption"] = caption
+		localized.MediaConfig = mediaConfig
+	}
+	return &localized
+}
+
+// localizeChatbotSettings returns a copy of settings with the customer-facing texts in lang.
+// Translation keys match the settings API field names.
+func localizeChatbotSettings(settings *models.ChatbotSettings, lang string) *models.ChatbotSettings {
+	if len(languageTranslation(settings.Translations, lang)) == 0 {
+		return settings
+	}
+	t := settings.Translations
+	localized := *settings
+	localized.DefaultResponse = localizedText(t, lang, "greeting_message", settings.DefaultResponse)
+	localized.GreetingButtons = localizedButtons(t, lang, "greeting_buttons", settings.GreetingButtons)
+	localized.FallbackMessage = localizedText(t, lang, "fallback_message", settings.FallbackMessage)
+	localized.FallbackButtons = localizedButtons(t, lang, "fallback_buttons", settings.FallbackButtons)
+	localized.BusinessHours.OutOfHoursMessage = localizedText(t, lang, "out_of_hours_message", settings.BusinessHours.OutOfHoursMessage)
+	localized.SLA.WarningMessage = localizedText(t, lang, "sla_warning_message", settings.SLA.WarningMessage)
+	localized.SLA.AutoCloseMessage = localizedText(t, lang, "sla_auto_close_message", settings.SLA.AutoCloseMessage)
+	localized.ClientInactivity.ReminderMessage = localizedText(t, lang, "client_reminder_message", settings.ClientInactivity.ReminderMessage)
+	localized.ClientInactivity.AutoCloseMessage = localizedText(t, lang, "client_auto_close_message", settings.ClientInactivity.AutoCloseMessage)
+	localized.CSAT.Message = localizedText(t, lang, "csat_message", settings.CSAT.Message)
+	localized.CSAT.ThankYouMessage = localizedText(t, lang, "csat_thank_you_message", settings.CSAT.ThankYouMessage)
+	return &localized
+}
+
+// Translations holds per-language variants of texts in API requests:
+// language code -> field name -> translated text (or button titles)
+type Translations map[string]map[string]interface{}
+
+// translationsJSONB converts per-language translations from a request into JSONB
+func translationsJSONB(translations Translations) models.JSONB {
+	result := make(models.JSONB, len(translations))
+	for lang, fields := range translations {
+		if lang = templateutil.NormalizeLanguage(lang); lang != "" {
+			result[lang] = map[string]interface{}(fields)
+		}
+	}
+	return result
+}
+
+// supportedLanguage returns lang in its configured form, or "" when it is not supported.
+// Any language is supported when no supported languages are configured.
+func supportedLanguage(cfg models.LanguageConfig, lang string) string {
+	lang = templateutil.NormalizeLanguage(lang)
+	if lang == "" || len(cfg.SupportedLanguages) == 0 {
+		return lang
+	}
+	return matchLanguage(cfg.SupportedLanguages, lang)
+}
+
+// contactLanguage returns the stored language of a contact: the language it chose
+// or was detected with, else the configured contact metadata field.
+// Returns "" when neither is set to a supported language.
+func contactLanguage(settings *models.ChatbotSettings, contact *models.Contact) string {
+	if lang := supportedLanguage(settings.Language, contact.Language); lang != "" {
+		return lang
+	}
+	if field := settings.Language.ContactField; field != "" {
+		if value, ok := contact.Metadata[field].(string); ok {
+			return supportedLanguage(settings.Language, value)
+		}
+	}
+	return ""
+}
+
+// resolveContactLanguage returns the language to talk to a contact in. Without a
+// stored language it is detected from the message when detection is enabled and
+// remembered on the contact. Falls back to the default language.
+func (a *App) resolveContactLanguage(settings *models.ChatbotSettings, contact *models.Contact, messageText string) string {
+	lang := contactLanguage(settings, contact)
+	if lang == "" && settings.Language.DetectLanguage {
+		if lang = detectLanguage(messageText, settings.Language.SupportedLanguages); lang != "" {
+			a.Log.Info("Detected contact language", "contact_id", contact.ID, "language", lang)
+			a.setContactLanguage(contact, lang)
+		}
+	}
+	if lang == "" {
+		lang = templateutil.NormalizeLanguage(settings.Language.DefaultLanguage)
+	}
+	return lang
+}
+
+// setContactLanguage remembers the chatbot language of a contact
+func (a *App) setContactLanguage(contact *models.Contact, lang string) {
+	contact.Language = templateutil.NormalizeLanguage(lang)
+	if a.flowSim != nil {
+		return
+	}
+	if err := a.DB.Model(contact).Update("language", contact.Language).Error; err != nil {
+		a.Log.Error("Failed to update contact language", "error", err, "contact_id", contact.ID)
+	}
+}
+
+// sessionLanguage returns the conversation language stored in the session
+func sessionLanguage(session *models.ChatbotSession) string {
+	lang, _ := session.SessionData[sessionLanguageKey].(string)
+	return lang
+}
+
+// setSessionLanguage stores the conversation language so flow steps are sent in it
+func (a *App) setSessionLanguage(session *models.ChatbotSession, lang string) {
+	lang = templateutil.NormalizeLanguage(lang)
+	if lang == "" || sessionLanguage(session) == lang {
+		return
+	}
+	if session.SessionData == nil {
+		session.SessionData = models.JSONB{}
+	}
+	session.SessionData[sessionLanguageKey] = lang
+	a.updateFlowSession(session, map[string]interface{}{"session_data": session.SessionData})
+}
+
+// flowStepTemplate loads the template of a template step, switching to the
+// approved variant in lang when one exists
+func (a *App) flowStepTemplate(account *models.WhatsAppAccount, step *models.ChatbotFlowStep, lang string) (*models.Template, error) {
+	if step.TemplateID == nil {
+		return nil, fmt.Errorf("template step has no template")
+	}
+	return a.loadTemplateVariant(account, *step.TemplateID, lang)
+}
+
+// loadTemplateVariant loads a template by ID, switching to the approved
+// variant in lang when one exists
+func (a *App) loadTemplateVariant(account *models.WhatsAppAccount, templateID uuid.UUID, lang string) (*models.Template, error) {
+	var template models.Template
+	if err := a.DB.Where("id = ? AND organization_id = ?", templateID, account.OrganizationID).First(&template).Error; err != nil {
+		return nil, err
+	}
+	return templateutil.LanguageVariant(a.DB, &template, lang)
+}
+
+// templateStepParams resolves the body parameters of a template step from
+// input_config.template_params, substituting session variables
+func templateStepParams(step *models.ChatbotFlowStep, data models.JSONB) map[string]string {
+	raw, _ := step.InputConfig["template_params"].(map[string]interface{})
+	return templateParams(raw, data)
+}
+
+// templateParams renders template body parameters against data
+func templateParams(raw map[string]interface{}, data map[string]interface{}) map[string]string {
+	params := make(map[string]string, len(raw))
+	for name, value := range raw {
+		params[name] = processTemplate(fmt.Sprint(value), data)
+	}
+	return params
+}
+
+// scriptLanguages maps writing systems to the languages commonly written in them
+var scriptLanguages = []struct {
+	table     *unicode.RangeTable
+	languages []string
+}{
+	{unicode.Arabic, []string{"ar", "fa", "ur"}},
+	{unicode.Hebrew, []string{"he"}},
+	{unicode.Greek, []string{"el"}},
+	{unicode.Cyrillic, []string{"ru", "uk", "bg", "sr"}},
+	{unicode.Devanagari, []string{"hi", "mr", "ne"}},
+	{unicode.Bengali, []string{"bn"}},
+	{unicode.Gurmukhi, []string{"pa"}},
+	{unicode.Gujarati, []string{"gu"}},
+	{unicode.Tamil, []string{"ta"}},
+	{unicode.Telugu, []string{"te"}},
+	{unicode.Kannada, []string{"kn"}},
+	{unicode.Malayalam, []string{"ml"}},
+	{unicode.Thai, []string{"th"}},
+	{unicode.Hangul, []string{"ko"}},
+	{unicode.Han, []string{"zh", "ja"}},
+}
+
+// latinStopwords lists common words used to tell Latin-script languages apart
+var latinStopwords = []struct {
+	language string
+	words    []string
+}{
+	{"en", []string{"the", "and", "is", "are", "you", "i", "my", "to", "for", "what", "how", "hello", "hi", "please", "thanks", "thank", "want", "need", "can", "with", "have", "order"}},
+	{"es", []string{"el", "los", "las", "y", "es", "por", "para", "hola", "gracias", "quiero", "necesito", "cómo", "qué", "mi", "tengo", "buenos", "buenas", "está", "estoy", "pedido", "ayuda"}},
+	{"pt", []string{"o", "os", "é", "olá", "ola", "obrigado", "obrigada", "quero", "preciso", "meu", "minha", "tenho", "bom", "não", "você", "pedido", "ajuda", "oi", "tudo"}},
+	{"fr", []string{"le", "les", "et", "est", "je", "vous", "pour", "bonjour", "merci", "veux", "besoin", "comment", "mon", "ma", "avec", "salut", "suis", "pas", "commande", "aide"}},
+	{"de", []string{"der", "die", "das", "und", "ist", "ich", "nicht", "für", "hallo", "danke", "möchte", "brauche", "wie", "mein", "mit", "guten", "bitte", "haben", "bestellung", "hilfe"}},
+	{"it", []string{"il", "lo", "gli", "è", "che", "di", "per", "ciao", "grazie", "voglio", "bisogno", "come", "mio", "buongiorno", "sono", "non", "con", "ordine", "aiuto"}},
+	{"nl", []string{"het", "een", "en", "ik", "niet", "voor", "hoi", "bedankt", "wil", "hoe", "mijn", "met", "goedemorgen", "graag", "heb", "bestelling"}},
+	{"id", []string{"yang", "dan", "ini", "itu", "saya", "anda", "tidak", "untuk", "halo", "terima", "kasih", "mau", "ingin", "bagaimana", "apa", "dengan", "ada", "selamat", "bisa", "pesanan"}},
+	{"tr", []string{"ve", "bir", "bu", "ben", "için", "merhaba", "teşekkürler", "teşekkür", "istiyorum", "nasıl", "ne", "benim", "ile", "var", "değil", "lütfen", "iyi", "sipariş"}},
+}
+
+// detectLanguage guesses the language of a message from its script and, for
+// Latin script, from common words. Only languages in candidates are returned
+// (any language when candidates is empty). Returns "" when unsure.
+func detectLanguage(text string, candidates []string) string {
+	counts := make([]int, len(scriptLanguages))
+	latin, kana := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana) {
+			kana++
+			continue
+		}
+		for i, script := range scriptLanguages {
+			if unicode.Is(script.table, r) {
+				counts[i]++
+				break
+			}
+		}
+	}
+
+	// Non-Latin scripts identify the language (or a small set of them) directly
+	best := -1
+	for i, n := range counts {
+		if n > 0 && (best < 0 || n > counts[best]) {
+			best = i
+		}
+	}
+	if kana > 0 && (best < 0 || kana >= counts[best]) && kana >= latin {
+		return pickCandidateLanguage([]string{"ja"}, candidates)
+	}
+	if best >= 0 && counts[best] >= latin {
+		languages := scriptLanguages[best].languages
+		if kana > 0 && scriptLanguages[best].table == unicode.Han {
+			languages = []string{"ja"}
+		}
+		return pickCandidateLanguage(languages, candidates)
+	}
+	if latin == 0 {
+		return ""
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	bestLang, bestScore, secondScore := "", 0, 0
+	for _, sw := range latinStopwords {
+		lang := sw.language
+		if len(candidates) > 0 {
+			if lang = matchLanguage(candidates, sw.language); lang == "" {
+				continue
+			}
+		}
+		score := 0
+		for _, word := range words {
+			for _, stopword := range sw.words {
+				if word == stopword {
+					score++
+					break
+				}
+			}
+		}
+		if score > bestScore {
+			bestLang, bestScore, secondScore = lang, score, bestScore
+		} else if score > secondScore {
+			secondScore = score
+		}
+	}
+	if bestScore == 0 || bestScore == secondScore {
+		return ""
+	}
+	return bestLang
+}
+
+// pickCandidateLanguage returns the first of languages allowed by candidates
+func pickCandidateLanguage(languages, candidates []string) string {
+	if len(candidates) == 0 {
+		return languages[0]
+	}
+	for _, lang := range languages {
+		if match := matchLanguage(candidates, lang); match != "" {
+			return match
+		}
+	}
+	return ""
+}
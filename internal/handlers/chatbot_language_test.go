@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchLanguage(t *testing.T) {
+	candidates := []string{"en", "pt_BR", "es"}
+
+	assert.Equal(t, "pt_BR", matchLanguage(candidates, "pt-br"))
+	assert.Equal(t, "pt_BR", matchLanguage(candidates, "pt_PT"), "falls back to the base language")
+	assert.Equal(t, "es", matchLanguage(candidates, "es_MX"))
+	assert.Equal(t, "", matchLanguage(candidates, "fr"))
+	assert.Equal(t, "", matchLanguage(candidates, ""))
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		candidates []string
+		want       string
+	}{
+		{name: "spanish", text: "Hola, quiero saber el estado de mi pedido", want: "es"},
+		{name: "portuguese", text: "Olá, preciso de ajuda com meu pedido", want: "pt"},
+		{name: "english", text: "Hi, I need help with my order", want: "en"},
+		{name: "german", text: "Hallo, ich brauche Hilfe mit meiner Bestellung", want: "de"},
+		{name: "arabic script", text: "مرحبا، أحتاج مساعدة", want: "ar"},
+		{name: "devanagari restricted to candidates", text: "नमस्ते मुझे मदद चाहिए", candidates: []string{"en", "mr"}, want: "mr"},
+		{name: "japanese", text: "注文について質問があります", want: "ja"},
+		{name: "candidate with region", text: "Hola, gracias", candidates: []string{"en_US", "es_MX"}, want: "es_MX"},
+		{name: "unsupported language", text: "Bonjour, merci", candidates: []string{"en", "es"}, want: ""},
+		{name: "ambiguous", text: "ok", want: ""},
+		{name: "no letters", text: "12345", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectLanguage(tt.text, tt.candidates))
+		})
+	}
+}
+
+func TestLocalizeFlowStep(t *testing.T) {
+	step := &models.ChatbotFlowStep{
+		StepName:        "menu",
+		Message:         "Pick an option",
+		ValidationError: "Invalid choice",
+		Buttons: models.JSONBArray{
+			map[string]interface{}{"id": "sales", "title": "Sales"},
+			map[string]interface{}{"title": "Support"},
+		},
+		Translations: models.JSONB{
+			"es": map[string]interface{}{
+				"message": "Elige una opción",
+				"buttons": []interface{}{"Ventas", "Soporte"},
+			},
+		},
+	}
+
+	localized := localizeFlowStep(step, "es_MX")
+	assert.Equal(t, "Elige una opción", localized.Message)
+	assert.Equal(t, "Invalid choice", localized.ValidationError, "untranslated fields keep the default text")
+	assert.Equal(t, "sales", localized.Buttons[0].(map[string]interface{})["id"])
+	assert.Equal(t, "Ventas", localized.Buttons[0].(map[string]interface{})["title"])
+	assert.Equal(t, "btn_2", localized.Buttons[1].(map[string]interface{})["id"])
+	assert.Equal(t, "Soporte", localized.Buttons[1].(map[string]interface{})["title"])
+
+	assert.Equal(t, "Pick an option", step.Message, "original step is not modified")
+	assert.Equal(t, "Sales", step.Buttons[0].(map[string]interface{})["title"])
+	assert.Same(t, step, localizeFlowStep(step, "fr"))
+}
+
+func TestLocalizeChatbotSettings(t *testing.T) {
+	settings := &models.ChatbotSettings{
+		DefaultResponse: "Hello!",
+		FallbackMessage: "Sorry, I did not get that",
+		SLA:             models.SLAConfig{WarningMessage: "Please wait"},
+		Translations: models.JSONB{
+			"pt": map[string]interface{}{
+				"greeting_message":    "Olá!",
+				"sla_warning_message": "Por favor, aguarde",
+			},
+		},
+	}
+
+	localized := localizeChatbotSettings(settings, "pt_br")
+	assert.Equal(t, "Olá!", localized.DefaultResponse)
+	assert.Equal(t, "Por favor, aguarde", localized.SLA.WarningMessage)
+	assert.Equal(t, "Sorry, I did not get that", localized.FallbackMessage)
+	assert.Equal(t, "Hello!", settings.DefaultResponse, "cached settings are not modified")
+}
+
+func TestContactLanguage(t *testing.T) {
+	settings := &models.ChatbotSettings{
+		Language: models.LanguageConfig{
+			DefaultLanguage:    "en",
+			SupportedLanguages: models.StringArray{"en", "es"},
+			ContactField:       "lang",
+		},
+	}
+
+	assert.Equal(t, "es", contactLanguage(settings, &models.Contact{Language: "es"}))
+	assert.Equal(t, "es", contactLanguage(settings, &models.Contact{Language: "fr", Metadata: models.JSONB{"lang": "ES"}}))
+	assert.Equal(t, "", contactLanguage(settings, &models.Contact{Metadata: models.JSONB{"lang": "de"}}))
+
+	app := &App{Log: testutil.NopLogger(), flowSim: newFlowSimulation(SimulateFlowRequest{})}
+	assert.Equal(t, "en", app.resolveContactLanguage(settings, &models.Contact{}, "Hola, gracias"), "detection is off")
+
+	settings.Language.DetectLanguage = true
+	contact := &models.Contact{}
+	assert.Equal(t, "es", app.resolveContactLanguage(settings, contact, "Hola, gracias"))
+	assert.Equal(t, "es", contact.Language, "detected language is remembered")
+}
+
+func TestSimulateFlow_Translations(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{
+			StepName:    "language",
+			Message:     "Choose a language",
+			MessageType: models.FlowStepTypeLanguageSelect,
+			InputType:   models.InputTypeButton,
+			Buttons: models.JSONBArray{
+				map[string]interface{}{"id": "en", "title": "English"},
+				map[string]interface{}{"id": "es", "title": "Español"},
+			},
+		},
+		models.ChatbotFlowStep{
+			StepName:     "ask_name",
+			Message:      "What is your name?",
+			InputType:    models.InputTypeText,
+			StoreAs:      "name",
+			Translations: models.JSONB{"es": map[string]interface{}{"message": "¿Cómo te llamas?"}},
+		},
+	)
+	flow.CompletionMessage = "Thanks {{name}}"
+	flow.Translations = models.JSONB{"es": map[string]interface{}{"completion_message": "Gracias {{name}}"}}
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{
+		Inputs: []SimulatedInput{{ButtonID: "es", Text: "Español"}, {Text: "Ana"}},
+	})
+
+	assert.Equal(t, []string{"Choose a language", "¿Cómo te llamas?", "Gracias Ana"}, outgoingContents(result))
+	assert.Equal(t, "es", result.SessionData[sessionLanguageKey])
+
+	result = app.simulateFlow(flow, SimulateFlowRequest{
+		Language: "es",
+		Inputs:   []SimulatedInput{{ButtonID: "en", Text: "English"}, {Text: "Ana"}},
+	})
+	assert.Equal(t, []string{"Choose a language", "What is your name?", "Thanks Ana"}, outgoingContents(result))
+}
@@ -18,7 +18,8 @@ import (
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
 )
 
-// IncomingTextMessage represents a text, interactive, or media message from the webhook
+// IncomingTextMessage represents a message from the webhook: text, interactive,
+// media, template button, order, system or unsupported
 type IncomingTextMessage struct {
 	From      string `json:"from"`
 	ID        string `json:"id"`
@@ -98,6 +99,23 @@ type IncomingTextMessage struct {
 			Type  string `json:"type,omitempty"`
 		} `json:"phones,omitempty"`
 	} `json:"contacts,omitempty"`
+	Button *struct {
+		Payload string `json:"payload"` // Payload set on the template's quick reply button
+		Text    string `json:"text"`
+	} `json:"button,omitempty"`
+	Order    *IncomingOrder    `json:"order,omitempty"`
+	Referral *IncomingReferral `json:"referral,omitempty"`
+	System   *IncomingSystem   `json:"system,omitempty"`
+	Edit     *IncomingEdit     `json:"edit,omitempty"`
+	Revoke   *IncomingRevoke   `json:"revoke,omitempty"`
+	Errors   []struct {
+		Code      int    `json:"code"`
+		Title     string `json:"title"`
+		Message   string `json:"message,omitempty"`
+		ErrorData *struct {
+			Details string `json:"details"`
+		} `json:"error_data,omitempty"`
+	} `json:"errors,omitempty"` // Set on unsupported messages
 }
 
 // processIncomingMessageFull processes incoming WhatsApp messages with chatbot logic
@@ -122,6 +140,16 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 		return
 	}
 
+	// Edits and deletions change the message they refer to
+	if msg.Type == "edit" && msg.Edit != nil {
+		a.handleIncomingEdit(account, msg.Edit)
+		return
+	}
+	if msg.Type == "revoke" && msg.Revoke != nil {
+		a.handleIncomingRevoke(account, msg.Revoke.OriginalMessageID)
+		return
+	}
+
 	// Get or create contact (always do this for all incoming messages)
 	contact, isNewContact, _ := contactutil.GetOrCreateContact(a.DB, account.OrganizationID, msg.From, profileName)
 
@@ -144,6 +172,11 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	// Track flow response data for WhatsApp Flow forms
 	var flowResponseData map[string]interface{}
 
+	// Structured data stored on the message for types without a column of their own
+	metadata := models.JSONB{}
+	var order *models.Order
+	newOrder := false
+
 	if msg.Type == "text" && msg.Text != nil {
 		messageText = msg.Text.Body
 	} else if msg.Type == "interactive" && msg.Interactive != nil {
@@ -272,6 +305,32 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 		if jsonBytes, err := json.Marshal(contactsData); err == nil {
 			messageText = string(jsonBytes)
 		}
+	} else if msg.Type == "button" && msg.Button != nil {
+		// Quick reply on a template message - the payload routes like a button ID
+		messageText = msg.Button.Text
+		buttonID = msg.Button.Payload
+		metadata["button"] = map[string]any{"payload": msg.Button.Payload, "text": msg.Button.Text}
+	} else if msg.Type == "order" && msg.Order != nil {
+		// Cart sent from a catalog; the note sent with it is the chatbot input
+		messageText = msg.Order.Text
+		order, newOrder = a.createIncomingOrder(account, contact, msg.ID, msg.Order)
+		metadata["order"] = orderMetadata(msg.Order, order)
+	} else if msg.Type == "system" && msg.System != nil {
+		messageText = msg.System.Body
+		metadata["system"] = systemMetadata(msg.System)
+	} else if msg.Type == "unsupported" {
+		unsupported := make([]map[string]any, 0, len(msg.Errors))
+		for _, e := range msg.Errors {
+			item := map[string]any{"code": e.Code, "title": e.Title}
+			if e.ErrorData != nil && e.ErrorData.Details != "" {
+				item["details"] = e.ErrorData.Details
+			}
+			unsupported = append(unsupported, item)
+		}
+		metadata["errors"] = unsupported
+	}
+	if msg.Referral != nil {
+		metadata["referral"] = referralMetadata(msg.Referral)
 	}
 
 	// Save incoming message to messages table (always, even if chatbot is disabled)
@@ -279,13 +338,42 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	if msg.Context != nil && msg.Context.ID != "" {
 		replyToWAMID = msg.Context.ID
 	}
-	a.saveIncomingMessage(account, contact, msg.ID, messageType, messageText, mediaInfo, replyToWAMID)
+	saved := a.saveIncomingMessage(account, contact, msg.ID, messageType, messageText, mediaInfo, replyToWAMID, metadata)
+
+	var savedID *uuid.UUID
+	if saved != nil {
+		savedID = &saved.ID
+	}
+	if order != nil && newOrder {
+		if savedID != nil {
+			order.MessageID = savedID
+			a.DB.Model(order).Update("message_id", *savedID)
+		}
+		a.orderCreated(order)
+	}
+	if msg.Referral != nil {
+		a.recordContactReferral(account, contact, savedID, msg.Referral, isNewContact)
+	}
+
+	// System messages (e.g. a changed number) are not conversation input
+	if msg.Type == "system" {
+		if msg.System != nil {
+			a.handleSystemMessage(account, contact, msg.System)
+		}
+		return
+	}
 
 	// Clear chatbot tracking since client has replied
 	a.ClearContactChatbotTracking(contact.ID)
 
+	// Satisfaction survey answers aren't chatbot input
+	if a.handleCSATResponse(account, contact, buttonID, flowResponseData) {
+		return
+	}
+
 	// Check for active agent transfer - skip chatbot processing if transferred
 	if a.hasActiveAgentTransfer(account.OrganizationID, contact.ID) {
+		a.UpdateSLAOnCustomerMessage(account.OrganizationID, contact.ID)
 		a.Log.Info("Contact has active agent transfer, skipping chatbot processing",
 			"contact_id", contact.ID,
 			"phone_number", contact.PhoneNumber)
@@ -301,27 +389,30 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	if !settings.IsEnabled {
 		a.Log.Debug("Chatbot not enabled for this account, creating transfer for agent queue", "account", account.Name, "settings_id", settings.ID)
 		// Create transfer to agent queue when chatbot is disabled
-		a.createTransferToQueue(account, contact, models.TransferSourceChatbotDisabled)
+		a.createTransferToQueue(account, contact, models.TransferSourceChatbotDisabled, transferOptions{})
 		return
 	}
 	a.Log.Info("Chatbot settings loaded", "settings_id", settings.ID, "is_enabled", settings.IsEnabled, "ai_enabled", settings.AI.Enabled, "ai_provider", settings.AI.Provider, "default_response", settings.DefaultResponse)
 
-	// Check business hours if enabled
-	if settings.BusinessHours.Enabled && len(settings.BusinessHours.Hours) > 0 {
-		if !a.isWithinBusinessHours(settings.BusinessHours.Hours) {
-			// If automated responses are not allowed outside hours, send out-of-hours message and stop
-			if !settings.BusinessHours.AllowAutomatedOutside {
-				a.Log.Info("Outside business hours, sending out of hours message")
-				if settings.BusinessHours.OutOfHoursMessage != "" {
-					if err := a.sendAndSaveTextMessage(account, contact, settings.BusinessHours.OutOfHoursMessage); err != nil {
-						a.Log.Error("Failed to send out of hours message", "error", err, "contact", contact.PhoneNumber)
-					}
+	// Pick the conversation language and use the matching texts
+	language := a.resolveContactLanguage(settings, contact, messageText)
+	settings = localizeChatbotSettings(settings, language)
+
+	// Check business hours if enabled (in the account or organization timezone, honouring holidays)
+	closed, outOfHoursMessage := a.outsideBusinessHours(settings)
+	if closed {
+		// If automated responses are not allowed outside hours, send out-of-hours message and stop
+		if !settings.BusinessHours.AllowAutomatedOutside {
+			a.Log.Info("Outside business hours, sending out of hours message")
+			if outOfHoursMessage != "" {
+				if err := a.sendAndSaveTextMessage(account, contact, outOfHoursMessage); err != nil {
+					a.Log.Error("Failed to send out of hours message", "error", err, "contact", contact.PhoneNumber)
 				}
-				return
 			}
-			// AllowAutomatedOutsideHours is true, continue processing flows/keywords/AI
-			a.Log.Info("Outside business hours but automated responses allowed, continuing")
+			return
 		}
+		// AllowAutomatedOutsideHours is true, continue processing flows/keywords/AI
+		a.Log.Info("Outside business hours but automated responses allowed, continuing")
 	}
 
 	// Only process text and interactive messages for chatbot
@@ -334,25 +425,31 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 
 	// Get or create active session for this contact
 	session, isNewSession := a.getOrCreateSession(account.OrganizationID, contact.ID, account.Name, msg.From, settings.SessionTimeoutMins)
+	a.setSessionLanguage(session, language)
 
 	// Log incoming message to session
 	a.logSessionMessage(session.ID, models.DirectionIncoming, messageText, "keyword_check")
 
 	// Check for transfer keyword BEFORE sending greeting (transfer takes priority)
-	keywordResponse, keywordMatched := a.matchKeywordRules(account.OrganizationID, account.Name, messageText)
+	keywordData := keywordRuleData(contact, session)
+	keywordResponse, keywordMatched := a.matchKeywordRules(account.OrganizationID, account.Name, messageText, keywordData)
+	if templatePayload := templateButtonPayload(msg); templatePayload != "" {
+		// Template button payloads trigger rules ahead of the button text
+		if response, matched := a.matchKeywordRules(account.OrganizationID, account.Name, templatePayload, keywordData); matched {
+			keywordResponse, keywordMatched = response, true
+		}
+	}
 	if keywordMatched && keywordResponse.ResponseType == models.ResponseTypeTransfer {
 		a.Log.Info("Transfer keyword matched", "response", keywordResponse.Body)
 		// Check business hours - if outside hours, send out of hours message instead
-		if settings.BusinessHours.Enabled && len(settings.BusinessHours.Hours) > 0 {
-			if !a.isWithinBusinessHours(settings.BusinessHours.Hours) {
-				a.Log.Info("Outside business hours, sending out of hours message instead of transfer")
-				if settings.BusinessHours.OutOfHoursMessage != "" {
-					if err := a.sendAndSaveTextMessage(account, contact, settings.BusinessHours.OutOfHoursMessage); err != nil {
-						a.Log.Error("Failed to send out of hours message", "error", err, "contact", contact.PhoneNumber)
-					}
+		if closed {
+			a.Log.Info("Outside business hours, sending out of hours message instead of transfer")
+			if outOfHoursMessage != "" {
+				if err := a.sendAndSaveTextMessage(account, contact, outOfHoursMessage); err != nil {
+					a.Log.Error("Failed to send out of hours message", "error", err, "contact", contact.PhoneNumber)
 				}
-				return
 			}
+			return
 		}
 		// Within business hours - send transfer message and create transfer
 		if keywordResponse.Body != "" {
@@ -371,6 +468,12 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	}
 
 	// Try to match flow trigger keywords first (before greeting to avoid duplicate messages)
+	if templatePayload := templateButtonPayload(msg); templatePayload != "" {
+		if flow := a.matchFlowTrigger(account.OrganizationID, account.Name, templatePayload); flow != nil {
+			a.startFlow(account, session, contact, flow)
+			return
+		}
+	}
 	if flow := a.matchFlowTrigger(account.OrganizationID, account.Name, messageText); flow != nil {
 		a.startFlow(account, session, contact, flow)
 		return
@@ -408,18 +511,7 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	if keywordMatched && keywordResponse.ResponseType != models.ResponseTypeTransfer {
 		a.Log.Info("Keyword rule matched", "response_type", keywordResponse.ResponseType, "response", keywordResponse.Body)
 
-		// Handle regular text response
-		if len(keywordResponse.Buttons) > 0 {
-			if err := a.sendAndSaveInteractiveButtons(account, contact, keywordResponse.Body, keywordResponse.Buttons); err != nil {
-				a.Log.Error("Failed to send interactive buttons", "error", err, "contact", contact.PhoneNumber)
-			}
-		} else {
-			if err := a.sendAndSaveTextMessage(account, contact, keywordResponse.Body); err != nil {
-				a.Log.Error("Failed to send text message", "error", err, "contact", contact.PhoneNumber)
-			}
-		}
-		// Log outgoing message
-		a.logSessionMessage(session.ID, models.DirectionOutgoing, keywordResponse.Body, "keyword_response")
+		a.sendKeywordResponse(account, session, contact, keywordResponse, keywordData)
 		return
 	}
 
@@ -479,11 +571,13 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 type KeywordResponse struct {
 	Body         string
 	Buttons      []map[string]interface{}
-	ResponseType models.ResponseType // text, transfer
+	ResponseType models.ResponseType // text, template, media, flow, script, transfer
+	Content      models.JSONB        // Raw response_content, read by template, media and flow responses
 }
 
-// matchKeywordRules checks if the message matches any keyword rules
-func (a *App) matchKeywordRules(orgID uuid.UUID, accountName, messageText string) (*KeywordResponse, bool) {
+// matchKeywordRules checks if the message matches any keyword rules that are
+// active now and whose conditions hold against data (contact and session variables)
+func (a *App) matchKeywordRules(orgID uuid.UUID, accountName, messageText string, data map[string]interface{}) (*KeywordResponse, bool) {
 	// Use cached keyword rules (includes both account-specific and global rules)
 	rules, err := a.getKeywordRulesCached(orgID, accountName)
 	if err != nil {
@@ -491,74 +585,18 @@ func (a *App) matchKeywordRules(orgID uuid.UUID, accountName, messageText string
 		return nil, false
 	}
 
-	messageLower := strings.ToLower(messageText)
-
-	for _, rule := range rules {
-		for _, keyword := range rule.Keywords {
-			keywordLower := strings.ToLower(keyword)
-			matched := false
-
-			switch rule.MatchType {
-			case models.MatchTypeExact:
-				if rule.CaseSensitive {
-					matched = messageText == keyword
-				} else {
-					matched = messageLower == keywordLower
-				}
-			case models.MatchTypeContains:
-				if rule.CaseSensitive {
-					matched = strings.Contains(messageText, keyword)
-				} else {
-					matched = strings.Contains(messageLower, keywordLower)
-				}
-			case models.MatchTypeStartsWith:
-				if rule.CaseSensitive {
-					matched = strings.HasPrefix(messageText, keyword)
-				} else {
-					matched = strings.HasPrefix(messageLower, keywordLower)
-				}
-			case models.MatchTypeRegex:
-				re, err := regexp.Compile(keyword)
-				if err == nil {
-					matched = re.MatchString(messageText)
-				}
-			default:
-				// Default to contains
-				matched = strings.Contains(messageLower, keywordLower)
-			}
-
-			if matched {
-				response := &KeywordResponse{
-					ResponseType: rule.ResponseType,
-				}
-
-				// For transfer type, use body as the transfer message
-				if rule.ResponseType == models.ResponseTypeTransfer {
-					if body, ok := rule.ResponseContent["body"].(string); ok {
-						response.Body = body
-					}
-					return response, true
-				}
-
-				// Get response body
-				if body, ok := rule.ResponseContent["body"].(string); ok {
-					response.Body = body
-				}
-
-				// Get buttons if present
-				if buttons, ok := rule.ResponseContent["buttons"].([]interface{}); ok && len(buttons) > 0 {
-					response.Buttons = make([]map[string]interface{}, 0, len(buttons))
-					for _, btn := range buttons {
-						if btnMap, ok := btn.(map[string]interface{}); ok {
-							response.Buttons = append(response.Buttons, btnMap)
-						}
-					}
-				}
-
-				if response.Body != "" {
-					return response, true
-				}
-			}
+	now := time.Now()
+	for i := range rules {
+		rule := &rules[i]
+		if !keywordRuleActive(rule, now) || !keywordRuleMatches(rule, messageText) {
+			continue
+		}
+		if !keywordRuleConditionsMet(rule, data) {
+			a.Log.Debug("Keyword rule conditions not met", "rule", rule.Name, "conditions", rule.Conditions)
+			continue
+		}
+		if response := buildKeywordResponse(rule); response != nil {
+			return response, true
 		}
 	}
 
@@ -568,14 +606,12 @@ func (a *App) matchKeywordRules(orgID uuid.UUID, accountName, messageText string
 // sendAndSaveTextMessage sends a text message and saves it to the database
 // Uses the unified SendOutgoingMessage for consistent behavior
 func (a *App) sendAndSaveTextMessage(account *models.WhatsAppAccount, contact *models.Contact, message string) error {
-	ctx := context.Background()
-	_, err := a.SendOutgoingMessage(ctx, OutgoingMessageRequest{
+	return a.sendChatbotMessage(OutgoingMessageRequest{
 		Account: account,
 		Contact: contact,
 		Type:    models.MessageTypeText,
 		Content: message,
-	}, ChatbotSendOptions())
-	return err
+	})
 }
 
 // sendAndSaveInteractiveButtons sends an interactive button message and saves it to the database
@@ -612,23 +648,20 @@ func (a *App) sendAndSaveInteractiveButtons(account *models.WhatsAppAccount, con
 		interactiveType = "list"
 	}
 
-	ctx := context.Background()
-	_, err := a.SendOutgoingMessage(ctx, OutgoingMessageRequest{
+	return a.sendChatbotMessage(OutgoingMessageRequest{
 		Account:         account,
 		Contact:         contact,
 		Type:            models.MessageTypeInteractive,
 		InteractiveType: interactiveType,
 		BodyText:        bodyText,
 		Buttons:         waButtons,
-	}, ChatbotSendOptions())
-	return err
+	})
 }
 
 // sendAndSaveCTAURLButton sends a CTA URL button message and saves it to the database
 // Uses the unified SendOutgoingMessage for consistent behavior
 func (a *App) sendAndSaveCTAURLButton(account *models.WhatsAppAccount, contact *models.Contact, bodyText, buttonText, url string) error {
-	ctx := context.Background()
-	_, err := a.SendOutgoingMessage(ctx, OutgoingMessageRequest{
+	return a.sendChatbotMessage(OutgoingMessageRequest{
 		Account:         account,
 		Contact:         contact,
 		Type:            models.MessageTypeInteractive,
@@ -636,15 +669,36 @@ func (a *App) sendAndSaveCTAURLButton(account *models.WhatsAppAccount, contact *
 		BodyText:        bodyText,
 		ButtonText:      buttonText,
 		URL:             url,
-	}, ChatbotSendOptions())
-	return err
+	})
+}
+
+// sendAndSaveLocationRequest sends a location request message and saves it to the database
+// Uses the unified SendOutgoingMessage for consistent behavior
+func (a *App) sendAndSaveLocationRequest(account *models.WhatsAppAccount, contact *models.Contact, bodyText string) error {
+	return a.sendChatbotMessage(OutgoingMessageRequest{
+		Account:         account,
+		Contact:         contact,
+		Type:            models.MessageTypeInteractive,
+		InteractiveType: "location_request",
+		BodyText:        bodyText,
+	})
+}
+
+// sendAndSaveTemplateMessage sends an approved template and saves it to the database
+func (a *App) sendAndSaveTemplateMessage(account *models.WhatsAppAccount, contact *models.Contact, template *models.Template, params map[string]string) error {
+	return a.sendChatbotMessage(OutgoingMessageRequest{
+		Account:    account,
+		Contact:    contact,
+		Type:       models.MessageTypeTemplate,
+		Template:   template,
+		BodyParams: params,
+	})
 }
 
 // sendAndSaveFlowMessage sends a WhatsApp Flow message and saves it to the database
 // Uses the unified SendOutgoingMessage for consistent behavior
-func (a *App) sendAndSaveFlowMessage(account *models.WhatsAppAccount, contact *models.Contact, flowID, headerText, bodyText, ctaText, flowToken, firstScreen string) error {
-	ctx := context.Background()
-	_, err := a.SendOutgoingMessage(ctx, OutgoingMessageRequest{
+func (a *App) sendAndSaveFlowMessage(account *models.WhatsAppAccount, contact *models.Contact, flowID, headerText, bodyText, ctaText, flowToken, flowAction, firstScreen string) error {
+	return a.sendChatbotMessage(OutgoingMessageRequest{
 		Account:         account,
 		Contact:         contact,
 		Type:            models.MessageTypeFlow,
@@ -653,21 +707,71 @@ func (a *App) sendAndSaveFlowMessage(account *models.WhatsAppAccount, contact *m
 		BodyText:        bodyText,
 		FlowCTA:         ctaText,
 		FlowToken:       flowToken,
+		FlowAction:      flowAction,
 		FlowFirstScreen: firstScreen,
-	}, ChatbotSendOptions())
-	return err
+	})
+}
+
+// whatsAppFlowEntry returns how a WhatsApp Flow message sent from an account opens the flow:
+// the flow action and the ID of the first screen, or "" to let WhatsApp use its default
+func (a *App) whatsAppFlowEntry(account *models.WhatsAppAccount, metaFlowID string) (string, string) {
+	var waFlow models.WhatsAppFlow
+	if err := a.DB.Where("organization_id = ? AND meta_flow_id = ?", account.OrganizationID, metaFlowID).First(&waFlow).Error; err != nil {
+		a.Log.Debug("Could not find WhatsApp Flow in database, using default screen", "meta_flow_id", metaFlowID, "org_id", account.OrganizationID)
+		return whatsapp.FlowMessageActionNavigate, ""
+	}
+	// Endpoint flows with an INIT handler get their first screen from the data endpoint
+	if _, ok := waFlow.ScreenHandlers[whatsapp.FlowActionInit]; ok && waFlow.EndpointEnabled {
+		return whatsapp.FlowMessageActionDataExchange, ""
+	}
+	return whatsapp.FlowMessageActionNavigate, whatsAppFlowFirstScreen(&waFlow)
+}
+
+// whatsAppFlowFirstScreen returns the ID of the first screen of a WhatsApp Flow, or ""
+func whatsAppFlowFirstScreen(waFlow *models.WhatsAppFlow) string {
+	// Extract first screen name from screens array
+	if len(waFlow.Screens) > 0 {
+		if screenMap, ok := waFlow.Screens[0].(map[string]interface{}); ok {
+			if screenID, ok := screenMap["id"].(string); ok {
+				return screenID
+			}
+		}
+	}
+	// If screens array is empty, try to get from flow_json
+	if waFlow.FlowJSON != nil {
+		if screens, ok := waFlow.FlowJSON["screens"].([]interface{}); ok && len(screens) > 0 {
+			if screenMap, ok := screens[0].(map[string]interface{}); ok {
+				if screenID, ok := screenMap["id"].(string); ok {
+					return screenID
+				}
+			}
+		}
+	}
+	return ""
 }
 
 
+// sendChatbotMessage sends a chatbot message through SendOutgoingMessage,
+// or captures it when running a flow simulation
+func (a *App) sendChatbotMessage(req OutgoingMessageRequest) error {
+	if a.flowSim != nil {
+		a.flowSim.captureMessage(req)
+		return nil
+	}
+	_, err := a.SendOutgoingMessage(context.Background(), req, ChatbotSendOptions())
+	return err
+}
+
 // getOrCreateSession finds an active session or creates a new one
 // Returns the session and a boolean indicating if it's a new session
 func (a *App) getOrCreateSession(orgID, contactID uuid.UUID, accountName, phoneNumber string, timeoutMins int) (*models.ChatbotSession, bool) {
 	now := time.Now()
 
-	// Look for an active session that hasn't timed out
+	// Look for an active session that hasn't timed out. Sessions paused at a
+	// delay step don't time out until the delay processor resumes them.
 	var session models.ChatbotSession
 	timeout := now.Add(-time.Duration(timeoutMins) * time.Minute)
-	result := a.DB.Where("organization_id = ? AND contact_id = ? AND whats_app_account = ? AND status = ? AND last_activity_at > ?",
+	result := a.DB.Where("organization_id = ? AND contact_id = ? AND whats_app_account = ? AND status = ? AND (last_activity_at > ? OR resume_at IS NOT NULL)",
 		orgID, contactID, accountName, models.SessionStatusActive, timeout).First(&session)
 
 	if result.Error == nil {
@@ -696,6 +800,9 @@ func (a *App) getOrCreateSession(orgID, contactID uuid.UUID, accountName, phoneN
 
 // logSessionMessage logs a message to the chatbot session
 func (a *App) logSessionMessage(sessionID uuid.UUID, direction models.Direction, message, stepName string) {
+	if a.flowSim != nil {
+		return
+	}
 	msg := models.ChatbotSessionMessage{
 		BaseModel: models.BaseModel{ID: uuid.New()},
 		SessionID: sessionID,
@@ -738,22 +845,41 @@ func (a *App) startFlow(account *models.WhatsAppAccount, session *models.Chatbot
 		a.Log.Info("Flow step", "index", i, "step_name", step.StepName, "step_order", step.StepOrder, "message_type", step.MessageType)
 	}
 
-	// Update session with flow info
+	// Update session with flow info (pinned to the version being started)
+	session.FlowSnapshots = models.JSONB{}
+	a.pinDraftFlow(session, flow)
 	session.CurrentFlowID = &flow.ID
+	session.CurrentFlowVersion = flow.PublishedVersion
 	session.CurrentStep = ""
 	session.StepRetries = 0
+	language := sessionLanguage(session)
 	session.SessionData = models.JSONB{
 		"_flow_id":   flow.ID.String(),
 		"_flow_name": flow.Name,
 	}
-	a.DB.Save(session)
+	if language != "" {
+		session.SessionData[sessionLanguageKey] = language
+	}
+	if a.flowSim != nil {
+		for k, v := range a.flowSim.initialData {
+			session.SessionData[k] = v
+		}
+	}
+	a.updateFlowSession(session, map[string]interface{}{
+		"current_flow_id":      flow.ID,
+		"current_flow_version": flow.PublishedVersion,
+		"current_step":         "",
+		"step_retries":         0,
+		"session_data":         session.SessionData,
+		"flow_snapshots":       session.FlowSnapshots,
+	})
 
 	// Send initial message if configured
-	if flow.InitialMessage != "" {
-		if err := a.sendAndSaveTextMessage(account, contact, flow.InitialMessage); err != nil {
+	if initialMessage := localizedText(flow.Translations, sessionLanguage(session), "initial_message", flow.InitialMessage); initialMessage != "" {
+		if err := a.sendAndSaveTextMessage(account, contact, initialMessage); err != nil {
 			a.Log.Error("Failed to send flow initial message", "error", err, "contact", contact.PhoneNumber)
 		}
-		a.logSessionMessage(session.ID, models.DirectionOutgoing, flow.InitialMessage, "flow_start")
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, initialMessage, "flow_start")
 	}
 
 	// Send first step message (with skip check)
@@ -761,7 +887,7 @@ func (a *App) startFlow(account *models.WhatsAppAccount, session *models.Chatbot
 		firstStep := &flow.Steps[0]
 		a.Log.Info("Sending first step", "step_name", firstStep.StepName, "message_type", firstStep.MessageType, "message", firstStep.Message)
 		session.CurrentStep = firstStep.StepName
-		a.DB.Model(session).Update("current_step", firstStep.StepName)
+		a.updateFlowSession(session, map[string]interface{}{"current_step": firstStep.StepName})
 
 		a.sendStepWithSkipCheck(account, session, contact, firstStep, flow, nil)
 	} else {
@@ -772,8 +898,8 @@ func (a *App) startFlow(account *models.WhatsAppAccount, session *models.Chatbot
 
 // processFlowResponse handles user response within a flow
 func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, userInput string, buttonID string, flowResponseData map[string]interface{}) {
-	// Load the current flow from cache
-	flow, err := a.getChatbotFlowByIDCached(account.OrganizationID, *session.CurrentFlowID)
+	// Load the flow version the session started with
+	flow, err := a.loadChatbotFlow(session, *session.CurrentFlowID, session.CurrentFlowVersion)
 	if err != nil {
 		a.Log.Error("Failed to load flow", "error", err)
 		a.exitFlow(session)
@@ -793,6 +919,9 @@ func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *mode
 		}
 	}
 
+	// User replied, so reset the jump counter used to detect input-less loops
+	delete(session.SessionData, "_jumps")
+
 	// Find current step
 	var currentStep *models.ChatbotFlowStep
 	var currentStepIndex int
@@ -809,6 +938,36 @@ func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *mode
 		a.exitFlow(session)
 		return
 	}
+	currentStep = localizeFlowStep(currentStep, sessionLanguage(session))
+
+	// Replies don't move the flow on while it is paused at a delay step
+	if currentStep.MessageType == models.FlowStepTypeDelay {
+		a.Log.Info("Flow is paused at a delay step, ignoring reply", "step", currentStep.StepName, "session_id", session.ID)
+		return
+	}
+
+	// Location steps expect a shared location
+	var location map[string]interface{}
+	if currentStep.InputType == models.InputTypeLocation {
+		var ok bool
+		if location, ok = parseLocationInput(userInput); !ok {
+			session.StepRetries++
+			if currentStep.RetryOnInvalid && session.StepRetries < currentStep.MaxRetries {
+				a.updateFlowSession(session, map[string]interface{}{"step_retries": session.StepRetries})
+				errorMsg := currentStep.ValidationError
+				if errorMsg == "" {
+					errorMsg = "Please share your location to continue."
+				}
+				if err := a.sendAndSaveTextMessage(account, contact, errorMsg); err != nil {
+					a.Log.Error("Failed to send validation error", "error", err, "contact", contact.PhoneNumber)
+				}
+				a.logSessionMessage(session.ID, models.DirectionOutgoing, errorMsg, currentStep.StepName+"_retry")
+				return
+			}
+			// Max retries exceeded, continue without a location
+			a.Log.Warn("Max retries exceeded", "step", currentStep.StepName)
+		}
+	}
 
 	// Validate input if required (skip validation for button/list responses)
 	if currentStep.ValidationRegex != "" && buttonID == "" {
@@ -817,7 +976,7 @@ func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *mode
 			// Invalid input
 			session.StepRetries++
 			if currentStep.RetryOnInvalid && session.StepRetries < currentStep.MaxRetries {
-				a.DB.Model(session).Update("step_retries", session.StepRetries)
+				a.updateFlowSession(session, map[string]interface{}{"step_retries": session.StepRetries})
 				errorMsg := currentStep.ValidationError
 				if errorMsg == "" {
 					errorMsg = "Invalid input. Please try again."
@@ -873,7 +1032,7 @@ func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *mode
 			// Invalid button selection
 			session.StepRetries++
 			a.Log.Debug("Invalid button selection", "buttonID", buttonID, "userInput", userInput, "step", currentStep.StepName, "retries", session.StepRetries)
-			a.DB.Model(session).Update("step_retries", session.StepRetries)
+			a.updateFlowSession(session, map[string]interface{}{"step_retries": session.StepRetries})
 
 			maxRetries := currentStep.MaxRetries
 			if maxRetries == 0 {
@@ -897,6 +1056,12 @@ func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *mode
 		}
 	}
 
+	// Switch the conversation language when the customer picks one
+	if currentStep.MessageType == models.FlowStepTypeLanguageSelect && buttonID != "" {
+		a.setContactLanguage(contact, buttonID)
+		a.setSessionLanguage(session, buttonID)
+	}
+
 	// Store the user's response (use buttonID if available, otherwise userInput)
 	if currentStep.StoreAs != "" {
 		sessionData := session.SessionData
@@ -907,10 +1072,14 @@ func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *mode
 		if buttonID != "" {
 			sessionData[currentStep.StoreAs] = buttonID
 			sessionData[currentStep.StoreAs+"_title"] = userInput
+		} else if location != nil {
+			sessionData[currentStep.StoreAs] = location
+			sessionData[currentStep.StoreAs+"_latitude"] = location["latitude"]
+			sessionData[currentStep.StoreAs+"_longitude"] = location["longitude"]
 		} else {
 			sessionData[currentStep.StoreAs] = userInput
 		}
-		a.DB.Model(session).Update("session_data", sessionData)
+		a.updateFlowSession(session, map[string]interface{}{"session_data": sessionData})
 		session.SessionData = sessionData
 	}
 
@@ -927,7 +1096,7 @@ func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *mode
 		}
 		// Also store the raw flow response for reference
 		sessionData["_flow_response"] = flowResponseData
-		a.DB.Model(session).Update("session_data", sessionData)
+		a.updateFlowSession(session, map[string]interface{}{"session_data": sessionData})
 		session.SessionData = sessionData
 		a.Log.Info("Stored WhatsApp Flow response in session", "fields", len(flowResponseData))
 	}
@@ -981,7 +1150,7 @@ func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *mode
 	}
 
 	// Update session and send next step message (with skip check)
-	a.DB.Model(session).Updates(map[string]interface{}{
+	a.updateFlowSession(session, map[string]interface{}{
 		"current_step": nextStep.StepName,
 		"step_retries": 0,
 	})
@@ -995,8 +1164,8 @@ func (a *App) completeFlow(account *models.WhatsAppAccount, session *models.Chat
 	a.Log.Info("Completing flow", "flow_id", flow.ID, "session_id", session.ID)
 
 	// Send completion message
-	if flow.CompletionMessage != "" {
-		message := a.replaceVariables(flow.CompletionMessage, session.SessionData)
+	if completionMessage := localizedText(flow.Translations, sessionLanguage(session), "completion_message", flow.CompletionMessage); completionMessage != "" {
+		message := a.replaceVariables(completionMessage, session.SessionData)
 		if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
 			a.Log.Error("Failed to send flow completion message", "error", err, "contact", contact.PhoneNumber)
 		}
@@ -1005,19 +1174,216 @@ func (a *App) completeFlow(account *models.WhatsAppAccount, session *models.Chat
 
 	// Execute on-complete action
 	if flow.OnCompleteAction == "webhook" && len(flow.CompletionConfig) > 0 {
-		go a.sendFlowCompletionWebhook(flow, session, contact)
+		if a.flowSim != nil {
+			a.flowSim.recordAction("webhook", flow.CompletionConfig)
+		} else {
+			go a.sendFlowCompletionWebhook(flow, session, contact)
+		}
+	}
+
+	// Return to the calling flow if this flow was started by a call_flow step
+	if a.returnFromSubFlow(account, session, contact) {
+		return
 	}
 
 	// Update session (keep current_flow_id for panel config reference)
 	now := time.Now()
-	a.DB.Model(session).Updates(map[string]interface{}{
+	a.updateFlowSession(session, map[string]interface{}{
 		"current_step": "",
 		"status":       models.SessionStatusCompleted,
 		"completed_at": now,
 	})
 
 	// Clear chatbot tracking so SLA doesn't fire after flow completion
-	a.ClearContactChatbotTracking(contact.ID)
+	a.clearFlowContactTracking(contact.ID)
+}
+
+// maxFlowJumpsWithoutInput limits how many goto_flow/call_flow jumps can happen
+// between two user replies, guarding against loops across flows
+const maxFlowJumpsWithoutInput = 20
+
+// jumpToFlow moves the session into another flow for goto_flow and call_flow steps.
+// Session data is shared with the target flow. For call_flow, a return frame is pushed
+// so that completing the target flow resumes this flow after the calling step.
+func (a *App) jumpToFlow(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep, flow *models.ChatbotFlow) {
+	if session.SessionData == nil {
+		session.SessionData = models.JSONB{}
+	}
+
+	jumps, _ := session.SessionData["_jumps"].(float64)
+	if jumps >= maxFlowJumpsWithoutInput {
+		a.Log.Warn("Too many flow jumps without user input, exiting flow", "flow_id", flow.ID, "step", step.StepName)
+		a.exitFlow(session)
+		return
+	}
+
+	targetID, entryStep, err := jumpTarget(step)
+	if err != nil {
+		a.Log.Error("Invalid jump step", "error", err, "flow_id", flow.ID, "step", step.StepName)
+		a.exitFlow(session)
+		return
+	}
+
+	target, err := a.loadChatbotFlow(session, targetID, 0)
+	if err != nil {
+		a.Log.Error("Jump target flow not found", "error", err, "flow_id", flow.ID, "step", step.StepName, "target_flow_id", targetID)
+		a.exitFlow(session)
+		return
+	}
+
+	var firstStep *models.ChatbotFlowStep
+	if len(target.Steps) > 0 {
+		firstStep = &target.Steps[0]
+		if entryStep != "" {
+			firstStep = findFlowStep(target, entryStep)
+			if firstStep == nil {
+				a.Log.Error("Jump target step not found", "target_flow_id", targetID, "step", entryStep)
+				a.exitFlow(session)
+				return
+			}
+		}
+	}
+
+	if step.MessageType == models.FlowStepTypeCallFlow {
+		stack, _ := session.SessionData["_call_stack"].([]interface{})
+		if len(stack) >= maxFlowCallDepth {
+			a.Log.Warn("Maximum sub-flow depth reached, exiting flow", "flow_id", flow.ID, "step", step.StepName)
+			a.exitFlow(session)
+			return
+		}
+		returnStep := step.NextStep
+		if returnStep == "" {
+			if next := flowStepAfter(flow, step.StepName); next != nil {
+				returnStep = next.StepName
+			}
+		}
+		session.SessionData["_call_stack"] = append(stack, map[string]interface{}{
+			"flow_id":     flow.ID.String(),
+			"version":     flow.PublishedVersion,
+			"return_step": returnStep,
+		})
+	}
+
+	// Optional message sent before leaving the current flow
+	if step.Message != "" {
+		message := processTemplate(localizeFlowStep(step, sessionLanguage(session)).Message, session.SessionData)
+		if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
+			a.Log.Error("Failed to send step message", "error", err, "contact", contact.PhoneNumber)
+		}
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
+	}
+
+	a.Log.Info("Jumping to flow", "from_flow", flow.ID, "to_flow", target.ID, "step", step.StepName, "type", step.MessageType)
+
+	a.pinDraftFlow(session, target)
+	session.SessionData["_jumps"] = jumps + 1
+	session.SessionData["_flow_id"] = target.ID.String()
+	session.SessionData["_flow_name"] = target.Name
+	session.CurrentFlowID = &target.ID
+	session.CurrentFlowVersion = target.PublishedVersion
+	session.CurrentStep = ""
+	session.StepRetries = 0
+	if firstStep != nil {
+		session.CurrentStep = firstStep.StepName
+	}
+	a.updateFlowSession(session, map[string]interface{}{
+		"current_flow_id":      target.ID,
+		"current_flow_version": target.PublishedVersion,
+		"current_step":         session.CurrentStep,
+		"step_retries":         0,
+		"session_data":         session.SessionData,
+		"flow_snapshots":       session.FlowSnapshots,
+	})
+
+	if firstStep == nil {
+		a.completeFlow(account, session, contact, target)
+		return
+	}
+	a.sendStepWithSkipCheck(account, session, contact, firstStep, target, nil)
+}
+
+// returnFromSubFlow pops the call stack and resumes the calling flow.
+// Returns false if the session is not inside a sub-flow.
+func (a *App) returnFromSubFlow(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact) bool {
+	stack, _ := session.SessionData["_call_stack"].([]interface{})
+	if len(stack) == 0 {
+		return false
+	}
+
+	frame, _ := stack[len(stack)-1].(map[string]interface{})
+	session.SessionData["_call_stack"] = stack[:len(stack)-1]
+	if len(stack) == 1 {
+		delete(session.SessionData, "_call_stack")
+	}
+
+	flowIDStr, _ := frame["flow_id"].(string)
+	returnStep, _ := frame["return_step"].(string)
+	flowID, err := uuid.Parse(flowIDStr)
+	if err != nil {
+		a.Log.Error("Invalid call stack frame", "frame", frame, "session_id", session.ID)
+		return false
+	}
+	// Frames read back from the database hold numbers as float64
+	version := 0
+	switch v := frame["version"].(type) {
+	case int:
+		version = v
+	case float64:
+		version = int(v)
+	}
+
+	caller, err := a.loadChatbotFlow(session, flowID, version)
+	if err != nil {
+		a.Log.Error("Calling flow not found", "error", err, "flow_id", flowID, "session_id", session.ID)
+		return false
+	}
+
+	a.Log.Info("Returning to calling flow", "flow_id", caller.ID, "return_step", returnStep, "session_id", session.ID)
+
+	session.SessionData["_flow_id"] = caller.ID.String()
+	session.SessionData["_flow_name"] = caller.Name
+	session.CurrentFlowID = &caller.ID
+	session.CurrentFlowVersion = caller.PublishedVersion
+	session.CurrentStep = returnStep
+	session.StepRetries = 0
+	a.updateFlowSession(session, map[string]interface{}{
+		"current_flow_id":      caller.ID,
+		"current_flow_version": caller.PublishedVersion,
+		"current_step":         returnStep,
+		"step_retries":         0,
+		"session_data":         session.SessionData,
+	})
+
+	next := findFlowStep(caller, returnStep)
+	if next == nil {
+		a.completeFlow(account, session, contact, caller)
+		return true
+	}
+	a.sendStepWithSkipCheck(account, session, contact, next, caller, nil)
+	return true
+}
+
+// findFlowStep returns the step with the given name, or nil
+func findFlowStep(flow *models.ChatbotFlow, stepName string) *models.ChatbotFlowStep {
+	if stepName == "" {
+		return nil
+	}
+	for i := range flow.Steps {
+		if flow.Steps[i].StepName == stepName {
+			return &flow.Steps[i]
+		}
+	}
+	return nil
+}
+
+// flowStepAfter returns the step following the named step by step order, or nil
+func flowStepAfter(flow *models.ChatbotFlow, stepName string) *models.ChatbotFlowStep {
+	for i := range flow.Steps {
+		if flow.Steps[i].StepName == stepName && i+1 < len(flow.Steps) {
+			return &flow.Steps[i+1]
+		}
+	}
+	return nil
 }
 
 // sendFlowCompletionWebhook sends session data to configured webhook URL
@@ -1117,7 +1483,7 @@ func (a *App) sendFlowCompletionWebhook(flow *models.ChatbotFlow, session *model
 // exitFlow ends a flow session (transfer, cancel, or error)
 func (a *App) exitFlow(session *models.ChatbotSession) {
 	now := time.Now()
-	a.DB.Model(session).Updates(map[string]interface{}{
+	a.updateFlowSession(session, map[string]interface{}{
 		"current_step": "",
 		"step_retries": 0,
 		"status":       models.SessionStatusCompleted,
@@ -1125,18 +1491,96 @@ func (a *App) exitFlow(session *models.ChatbotSession) {
 	})
 
 	// Clear chatbot tracking so SLA doesn't fire after flow exit
-	a.ClearContactChatbotTracking(session.ContactID)
+	a.clearFlowContactTracking(session.ContactID)
 }
 
 // closeSession ends the chatbot session and clears contact tracking
 func (a *App) closeSession(session *models.ChatbotSession) {
-	a.DB.Model(session).Updates(map[string]interface{}{
+	a.updateFlowSession(session, map[string]interface{}{
 		"status":       models.SessionStatusCompleted,
 		"completed_at": time.Now(),
 	})
 
 	// Clear chatbot tracking on contact
-	a.ClearContactChatbotTracking(session.ContactID)
+	a.clearFlowContactTracking(session.ContactID)
+}
+
+// updateFlowSession persists session field changes made by the flow engine.
+// During a flow simulation the changes are applied to the in-memory session instead.
+func (a *App) updateFlowSession(session *models.ChatbotSession, updates map[string]interface{}) {
+	if a.flowSim != nil {
+		applySessionUpdates(session, updates)
+		return
+	}
+	a.DB.Model(session).Updates(updates)
+}
+
+// clearFlowContactTracking clears chatbot SLA tracking for the contact (skipped when simulating)
+func (a *App) clearFlowContactTracking(contactID uuid.UUID) {
+	if a.flowSim != nil {
+		return
+	}
+	a.ClearContactChatbotTracking(contactID)
+}
+
+// loadChatbotFlow returns a flow with steps at the given version (0 = live) for a session,
+// preferring flows supplied to a simulation and drafts pinned on the session
+func (a *App) loadChatbotFlow(session *models.ChatbotSession, flowID uuid.UUID, version int) (*models.ChatbotFlow, error) {
+	if a.flowSim != nil {
+		if flow, ok := a.flowSim.flows[flowID]; ok {
+			return flow, nil
+		}
+	}
+	if version == 0 {
+		if flow := pinnedDraftFlow(session, flowID); flow != nil {
+			return flow, nil
+		}
+	}
+	return a.getChatbotFlowByIDCached(session.OrganizationID, flowID, version)
+}
+
+// pinDraftFlow keeps the draft of a never-published flow on the session, so later edits
+// don't change conversations already running it. Callers persist session.FlowSnapshots.
+func (a *App) pinDraftFlow(session *models.ChatbotSession, flow *models.ChatbotFlow) {
+	if flow.PublishedVersion > 0 || a.flowSim != nil {
+		return
+	}
+	if session.FlowSnapshots == nil {
+		session.FlowSnapshots = models.JSONB{}
+	}
+	key := flow.ID.String()
+	if _, ok := session.FlowSnapshots[key]; ok {
+		return
+	}
+	snapshot, err := flowSnapshot(flow)
+	if err != nil {
+		a.Log.Error("Failed to snapshot flow draft", "error", err, "flow_id", flow.ID, "session_id", session.ID)
+		return
+	}
+	session.FlowSnapshots[key] = snapshot
+}
+
+// pinnedDraftFlow returns the draft pinned on the session for a flow, or nil
+func pinnedDraftFlow(session *models.ChatbotSession, flowID uuid.UUID) *models.ChatbotFlow {
+	snapshot, ok := session.FlowSnapshots[flowID.String()]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil
+	}
+	var version models.ChatbotFlowVersion
+	if err := json.Unmarshal(data, &version.Snapshot); err != nil {
+		return nil
+	}
+	version.FlowID = flowID
+	version.OrganizationID = session.OrganizationID
+	flow, err := flowFromSnapshot(&version)
+	if err != nil {
+		return nil
+	}
+	return flow
 }
 
 // replaceVariables replaces {{variable}} placeholders with session data values
@@ -1161,6 +1605,9 @@ func (a *App) sendStepWithSkipCheck(account *models.WhatsAppAccount, session *mo
 	if skippedSteps == nil {
 		skippedSteps = make(map[string]bool)
 	}
+	if a.flowSim != nil {
+		a.flowSim.enterStep(flow, step)
+	}
 	if skippedSteps[step.StepName] {
 		a.Log.Warn("Skip loop detected, completing flow", "step", step.StepName)
 		a.completeFlow(account, session, contact, flow)
@@ -1176,6 +1623,9 @@ func (a *App) sendStepWithSkipCheck(account *models.WhatsAppAccount, session *mo
 	if a.shouldSkipStep(step, sessionData) {
 		a.Log.Info("Skipping step", "step", step.StepName, "condition", step.SkipCondition)
 		skippedSteps[step.StepName] = true
+		if a.flowSim != nil {
+			a.flowSim.skipStep()
+		}
 
 		// Find next step
 		nextStepName := step.NextStep
@@ -1212,13 +1662,34 @@ func (a *App) sendStepWithSkipCheck(account *models.WhatsAppAccount, session *mo
 
 		// Update session to next step
 		session.CurrentStep = nextStep.StepName
-		a.DB.Model(session).Update("current_step", nextStep.StepName)
+		a.updateFlowSession(session, map[string]interface{}{"current_step": nextStep.StepName})
 
 		// Recursively check next step (it may also need to be skipped)
 		a.sendStepWithSkipCheck(account, session, contact, nextStep, flow, skippedSteps)
 		return
 	}
 
+	// Jump steps hand the session over to another flow
+	if isJumpStep(step) {
+		a.jumpToFlow(account, session, contact, step, flow)
+		return
+	}
+
+	// Action steps change session or contact state without sending a message.
+	// They are tracked like skipped steps so that loops between them end the flow.
+	if isActionStep(step) {
+		skippedSteps[step.StepName] = true
+		a.runActionStep(account, session, contact, step, flow, skippedSteps)
+		return
+	}
+
+	// Delay steps pause the flow until the delay processor resumes it
+	if step.MessageType == models.FlowStepTypeDelay {
+		skippedSteps[step.StepName] = true
+		a.startFlowDelay(account, session, contact, step, flow, skippedSteps)
+		return
+	}
+
 	// Not skipping - send the step message normally
 	a.sendStepMessage(account, session, contact, step)
 
@@ -1260,7 +1731,7 @@ func (a *App) sendStepWithSkipCheck(account *models.WhatsAppAccount, session *mo
 
 		// Update session to next step
 		session.CurrentStep = nextStep.StepName
-		a.DB.Model(session).Update("current_step", nextStep.StepName)
+		a.updateFlowSession(session, map[string]interface{}{"current_step": nextStep.StepName})
 
 		// Recursively process next step (it may also need to skip or have no input)
 		a.sendStepWithSkipCheck(account, session, contact, nextStep, flow, skippedSteps)
@@ -1272,6 +1743,7 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 	var message string
 
 	a.Log.Debug("sendStepMessage called", "step", step.StepName, "message_type", step.MessageType, "input_config", step.InputConfig)
+	step = localizeFlowStep(step, sessionLanguage(session))
 
 	switch step.MessageType {
 	case models.FlowStepTypeAPIFetch:
@@ -1299,7 +1771,7 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 				for k, v := range apiResp.MappedData {
 					session.SessionData[k] = v
 				}
-				a.DB.Model(session).Update("session_data", session.SessionData)
+				a.updateFlowSession(session, map[string]interface{}{"session_data": session.SessionData})
 			}
 
 			// Check if API returned buttons
@@ -1315,8 +1787,8 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 		}
 		a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
 
-	case models.FlowStepTypeButtons:
-		// Send interactive buttons message
+	case models.FlowStepTypeButtons, models.FlowStepTypeLanguageSelect:
+		// Send interactive buttons message (language_select buttons carry language codes as IDs)
 		message = processTemplate(step.Message, session.SessionData)
 		if len(step.Buttons) > 0 {
 			// Separate reply buttons from URL buttons
@@ -1386,6 +1858,7 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 		// Get transfer configuration
 		var teamID *uuid.UUID
 		var notes string
+		opts := transferOptionsFromConfig(step.TransferConfig)
 		if step.TransferConfig != nil {
 			if teamIDStr, ok := step.TransferConfig["team_id"].(string); ok && teamIDStr != "" && teamIDStr != "_general" {
 				if parsedID, err := uuid.Parse(teamIDStr); err == nil {
@@ -1398,17 +1871,63 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 		}
 
 		// Create the transfer
-		if teamID != nil {
-			a.createTransferToTeam(account, contact, *teamID, notes, models.TransferSourceFlow)
+		if a.flowSim != nil {
+			a.flowSim.recordAction("transfer", map[string]interface{}{"team_id": teamID, "notes": notes, "priority": opts.Priority, "skills": opts.Skills})
+		} else if teamID != nil {
+			a.createTransferToTeam(account, contact, *teamID, notes, models.TransferSourceFlow, opts)
 		} else {
 			// General queue transfer
-			a.createTransferToQueue(account, contact, models.TransferSourceFlow)
+			a.createTransferToQueue(account, contact, models.TransferSourceFlow, opts)
 		}
 
 		// End the flow session (transfer takes over)
 		a.exitFlow(session)
 		return
 
+	case models.FlowStepTypeMedia:
+		// Send an image, document, video or audio file with the message as caption
+		caption, _ := step.MediaConfig["caption"].(string)
+		if caption == "" {
+			caption = step.Message
+		}
+		message = processTemplate(caption, session.SessionData)
+		if err := a.sendAndSaveMediaMessage(account, contact, step.MediaConfig, message); err != nil {
+			a.Log.Error("Failed to send media step", "error", err, "step", step.StepName, "contact", contact.PhoneNumber)
+			if message != "" {
+				if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
+					a.Log.Error("Failed to send media fallback message", "error", err, "contact", contact.PhoneNumber)
+				}
+			}
+		}
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
+
+	case models.FlowStepTypeTemplate:
+		// Send an approved template, in the conversation language when a variant exists
+		message = processTemplate(step.Message, session.SessionData)
+		template, err := a.flowStepTemplate(account, step, sessionLanguage(session))
+		if err != nil {
+			a.Log.Error("Failed to load step template", "error", err, "step", step.StepName)
+			if message != "" {
+				if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
+					a.Log.Error("Failed to send template fallback message", "error", err, "contact", contact.PhoneNumber)
+				}
+			}
+		} else {
+			if err := a.sendAndSaveTemplateMessage(account, contact, template, templateStepParams(step, session.SessionData)); err != nil {
+				a.Log.Error("Failed to send template step", "error", err, "template", template.Name, "contact", contact.PhoneNumber)
+			}
+			message = template.BodyContent
+		}
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
+
+	case models.FlowStepTypeLocationRequest:
+		// Ask the customer to share their location
+		message = processTemplate(step.Message, session.SessionData)
+		if err := a.sendAndSaveLocationRequest(account, contact, message); err != nil {
+			a.Log.Error("Failed to send location request", "error", err, "contact", contact.PhoneNumber)
+		}
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
+
 	case models.FlowStepTypeWhatsAppFlow:
 		// Send a WhatsApp Flow (interactive form)
 		a.Log.Debug("Processing WhatsApp Flow step", "step", step.StepName, "input_config", step.InputConfig)
@@ -1436,39 +1955,13 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 				a.Log.Error("Failed to send fallback message", "error", err, "contact", contact.PhoneNumber)
 			}
 		} else {
-			// Look up the WhatsApp Flow to get the first screen name
-			var waFlow models.WhatsAppFlow
-			firstScreen := ""
-			if err := a.DB.Where("meta_flow_id = ?", flowID).First(&waFlow).Error; err != nil {
-				a.Log.Debug("Could not find WhatsApp Flow in database, using default screen", "meta_flow_id", flowID)
-			} else {
-				// Extract first screen name from screens array
-				if len(waFlow.Screens) > 0 {
-					if screenMap, ok := waFlow.Screens[0].(map[string]interface{}); ok {
-						if screenID, ok := screenMap["id"].(string); ok {
-							firstScreen = screenID
-							a.Log.Debug("Found first screen from flow", "first_screen", firstScreen)
-						}
-					}
-				}
-				// If screens array is empty, try to get from flow_json
-				if firstScreen == "" && waFlow.FlowJSON != nil {
-					if screens, ok := waFlow.FlowJSON["screens"].([]interface{}); ok && len(screens) > 0 {
-						if screenMap, ok := screens[0].(map[string]interface{}); ok {
-							if screenID, ok := screenMap["id"].(string); ok {
-								firstScreen = screenID
-								a.Log.Debug("Found first screen from flow_json", "first_screen", firstScreen)
-							}
-						}
-					}
-				}
-			}
+			flowAction, firstScreen := a.whatsAppFlowEntry(account, flowID)
 
 			// Generate a unique flow token for tracking
 			flowToken := fmt.Sprintf("chatbot_%s_%s_%d", session.ID.String(), step.StepName, time.Now().UnixNano())
-			a.Log.Debug("Sending WhatsApp Flow message", "flow_id", flowID, "first_screen", firstScreen, "cta", ctaText)
+			a.Log.Debug("Sending WhatsApp Flow message", "flow_id", flowID, "flow_action", flowAction, "first_screen", firstScreen, "cta", ctaText)
 
-			if err := a.sendAndSaveFlowMessage(account, contact, flowID, headerText, message, ctaText, flowToken, firstScreen); err != nil {
+			if err := a.sendAndSaveFlowMessage(account, contact, flowID, headerText, message, ctaText, flowToken, flowAction, firstScreen); err != nil {
 				a.Log.Error("Failed to send WhatsApp Flow message", "error", err, "contact", contact.PhoneNumber, "flow_id", flowID)
 			}
 		}
@@ -1517,11 +2010,23 @@ func (a *App) fetchApiResponse(apiConfig models.JSONB, sessionData models.JSONB,
 
 	// Prepare request body if configured
 	var bodyReader io.Reader
+	var bodyWithVars string
 	if bodyTemplate, ok := apiConfig["body"].(string); ok && bodyTemplate != "" {
-		bodyWithVars := processTemplate(bodyTemplate, sessionData)
+		bodyWithVars = processTemplate(bodyTemplate, sessionData)
 		bodyReader = strings.NewReader(bodyWithVars)
 	}
 
+	// Use the mocked response when simulating a flow
+	if a.flowSim != nil {
+		respBody, handled, err := a.flowSim.apiCall(method, apiURL, bodyWithVars)
+		if handled {
+			if err != nil {
+				return nil, err
+			}
+			return buildApiResponse(apiConfig, sessionData, messageTemplate, respBody), nil
+		}
+	}
+
 	// Create request
 	req, err := http.NewRequest(method, apiURL, bodyReader)
 	if err != nil {
@@ -1557,14 +2062,25 @@ func (a *App) fetchApiResponse(apiConfig models.JSONB, sessionData models.JSONB,
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if a.flowSim != nil {
+			a.flowSim.recordAPIResult(resp.StatusCode, fmt.Errorf("API returned status %d", resp.StatusCode))
+		}
 		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
 	}
+	if a.flowSim != nil {
+		a.flowSim.recordAPIResult(resp.StatusCode, nil)
+	}
+
+	return buildApiResponse(apiConfig, sessionData, messageTemplate, respBody), nil
+}
 
+// buildApiResponse parses an API response body into message, buttons and mapped session data
+func buildApiResponse(apiConfig models.JSONB, sessionData models.JSONB, messageTemplate string, respBody []byte) *ApiResponse {
 	// Parse JSON response
 	var jsonResp map[string]interface{}
 	if err := json.Unmarshal(respBody, &jsonResp); err != nil {
 		// If not JSON, return raw response as message
-		return &ApiResponse{Message: string(respBody)}, nil
+		return &ApiResponse{Message: string(respBody)}
 	}
 
 	result := &ApiResponse{
@@ -1626,7 +2142,7 @@ func (a *App) fetchApiResponse(apiConfig models.JSONB, sessionData models.JSONB,
 		}
 	}
 
-	return result, nil
+	return result
 }
 
 // generateAIResponse generates a response using the configured AI provider
@@ -2141,29 +2657,10 @@ func (a *App) handleIncomingReaction(account *models.WhatsAppAccount, fromPhone,
 	)
 
 	// Find the message being reacted to
-	// WhatsApp encodes phone numbers in the WAMID prefix, so the same message
-	// has different WAMIDs from sender vs recipient perspective.
-	// We match on the suffix after "FQIA" + 4 chars (type indicator like "ERgS" or "EhgU")
-	var message models.Message
-	if err := a.DB.Where("whats_app_message_id = ?", messageWAMID).First(&message).Error; err != nil {
-		// Try matching on WAMID suffix (the unique message ID part)
-		if idx := strings.Index(messageWAMID, "FQIA"); idx != -1 {
-			// Extract suffix after "FQIA" + 4 char type indicator (e.g., "ERgS", "EhgU")
-			suffixStart := idx + 8
-			if suffixStart < len(messageWAMID) {
-				suffix := messageWAMID[suffixStart:]
-				if err := a.DB.Where("whats_app_message_id LIKE ?", "%"+suffix).First(&message).Error; err != nil {
-					a.Log.Warn("Message not found for reaction", "wamid", messageWAMID, "suffix", suffix)
-					return
-				}
-			} else {
-				a.Log.Warn("Message not found for reaction - invalid WAMID format", "wamid", messageWAMID)
-				return
-			}
-		} else {
-			a.Log.Warn("Message not found for reaction - no FQIA pattern", "wamid", messageWAMID)
-			return
-		}
+	message, err := a.findMessageByWAMID(account.OrganizationID, messageWAMID)
+	if err != nil {
+		a.Log.Warn("Message not found for reaction", "wamid", messageWAMID)
+		return
 	}
 
 	// Get or create contact
@@ -2214,7 +2711,7 @@ func (a *App) handleIncomingReaction(account *models.WhatsAppAccount, fromPhone,
 	metadata["reactions"] = newReactions
 
 	// Save to database
-	if err := a.DB.Model(&message).Update("metadata", metadata).Error; err != nil {
+	if err := a.DB.Model(message).Update("metadata", metadata).Error; err != nil {
 		a.Log.Error("Failed to update message reactions", "error", err)
 		return
 	}
@@ -2223,7 +2720,7 @@ func (a *App) handleIncomingReaction(account *models.WhatsAppAccount, fromPhone,
 
 	// Broadcast via WebSocket
 	if a.WSHub != nil {
-		a.WSHub.BroadcastToOrg(account.OrganizationID, websocket.WSMessage{
+		a.WSHub.BroadcastToConversation(account.OrganizationID, contact.ID, websocket.WSMessage{
 			Type: "reaction_update",
 			Payload: map[string]any{
 				"message_id": message.ID.String(),
@@ -2252,7 +2749,7 @@ type MediaInfo struct {
 }
 
 // saveIncomingMessage saves an incoming message to the messages table
-func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *models.Contact, whatsappMsgID, msgType, content string, mediaInfo *MediaInfo, replyToWAMID string) {
+func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *models.Contact, whatsappMsgID, msgType, content string, mediaInfo *MediaInfo, replyToWAMID string, metadata models.JSONB) *models.Message {
 	now := time.Now()
 
 	message := models.Message{
@@ -2265,6 +2762,7 @@ func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *mode
 		MessageType:       models.MessageType(msgType),
 		Content:           content,
 		Status:            models.MessageStatusReceived,
+		Metadata:          metadata,
 	}
 
 	// Handle reply context - look up the original message by WhatsApp message ID
@@ -2287,7 +2785,7 @@ func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *mode
 
 	if err := a.DB.Create(&message).Error; err != nil {
 		a.Log.Error("Failed to save incoming message", "error", err)
-		return
+		return nil
 	}
 
 	// Update contact's last message info
@@ -2331,26 +2829,29 @@ func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *mode
 			"updated_at":       message.UpdatedAt,
 			"is_reply":         message.IsReply,
 		}
+		if len(metadata) > 0 {
+			wsPayload["metadata"] = metadata
+		}
 		// Include reply context if this is a reply
 		if message.IsReply && message.ReplyToMessageID != nil {
 			wsPayload["reply_to_message_id"] = message.ReplyToMessageID.String()
 			// Load the replied-to message for preview
 			var replyToMsg models.Message
 			if err := a.DB.First(&replyToMsg, message.ReplyToMessageID).Error; err == nil {
-				wsPayload["reply_to_message"] = map[string]any{
-					"id":           replyToMsg.ID.String(),
-					"content":      map[string]string{"body": replyToMsg.Content},
-					"message_type": replyToMsg.MessageType,
-					"direction":    replyToMsg.Direction,
-				}
+				wsPayload["reply_to_message"] = replyPreview(&replyToMsg)
 			}
 		}
-		a.WSHub.BroadcastToOrg(account.OrganizationID, websocket.WSMessage{
+		a.WSHub.BroadcastToConversation(account.OrganizationID, contact.ID, websocket.WSMessage{
 			Type:    websocket.TypeNewMessage,
 			Payload: wsPayload,
 		})
 	}
 
+	// Agents viewing the conversation read the message as it arrives
+	if account.AutoReadReceipt && len(a.viewers(contact.ID)) > 0 {
+		a.sendReadReceipt(account.OrganizationID, contact.ID)
+	}
+
 	// Dispatch webhook for incoming message
 	a.DispatchWebhook(account.OrganizationID, models.WebhookEventMessageIncoming, MessageEventData{
 		MessageID:       message.ID.String(),
@@ -2361,56 +2862,10 @@ func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *mode
 		Content:         content,
 		WhatsAppAccount: account.Name,
 		Direction:       models.DirectionIncoming,
+		Metadata:        metadata,
 	})
-}
-
-// isWithinBusinessHours checks if current time is within configured business hours
-func (a *App) isWithinBusinessHours(businessHours models.JSONBArray) bool {
-	now := time.Now()
-	currentDay := int(now.Weekday()) // 0 = Sunday, 1 = Monday, etc.
-	currentTime := now.Format("15:04")
-
-	for _, bh := range businessHours {
-		bhMap, ok := bh.(map[string]interface{})
-		if !ok {
-			continue
-		}
 
-		// Get day (0-6, Sunday-Saturday)
-		day, ok := bhMap["day"].(float64)
-		if !ok {
-			continue
-		}
-
-		if int(day) != currentDay {
-			continue
-		}
-
-		// Check if enabled for this day
-		enabled, ok := bhMap["enabled"].(bool)
-		if !ok || !enabled {
-			return false // Day exists but is disabled
-		}
-
-		// Get start and end times
-		startTime, ok := bhMap["start_time"].(string)
-		if !ok {
-			continue
-		}
-		endTime, ok := bhMap["end_time"].(string)
-		if !ok {
-			continue
-		}
-
-		// Compare times (simple string comparison works for HH:MM format)
-		if currentTime >= startTime && currentTime <= endTime {
-			return true
-		}
-		return false // Found the day but outside hours
-	}
-
-	// If no matching day found, assume outside business hours
-	return false
+	return &message
 }
 
 // shouldSkipStep evaluates a text expression like "(status == 'vip' OR amount > 100) AND name != ”"
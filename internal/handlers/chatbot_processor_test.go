@@ -73,19 +73,19 @@ func TestMatchKeywordRules_ExactMatch(t *testing.T) {
 	}
 	require.NoError(t, app.DB.Create(rule).Error)
 
-	resp, matched := app.matchKeywordRules(org.ID, account.Name, "hello")
+	resp, matched := app.matchKeywordRules(org.ID, account.Name, "hello", nil)
 	assert.True(t, matched)
 	require.NotNil(t, resp)
 	assert.Equal(t, "Hello response", resp.Body)
 
 	// Different case should also match (case insensitive by default)
-	resp2, matched2 := app.matchKeywordRules(org.ID, account.Name, "HELLO")
+	resp2, matched2 := app.matchKeywordRules(org.ID, account.Name, "HELLO", nil)
 	assert.True(t, matched2)
 	require.NotNil(t, resp2)
 	assert.Equal(t, "Hello response", resp2.Body)
 
 	// Partial should NOT match exact
-	_, matched3 := app.matchKeywordRules(org.ID, account.Name, "hello world")
+	_, matched3 := app.matchKeywordRules(org.ID, account.Name, "hello world", nil)
 	assert.False(t, matched3)
 }
 
@@ -108,10 +108,10 @@ func TestMatchKeywordRules_ExactMatch_CaseSensitive(t *testing.T) {
 	}
 	require.NoError(t, app.DB.Create(rule).Error)
 
-	_, matched := app.matchKeywordRules(org.ID, account.Name, "Hello")
+	_, matched := app.matchKeywordRules(org.ID, account.Name, "Hello", nil)
 	assert.True(t, matched)
 
-	_, matched2 := app.matchKeywordRules(org.ID, account.Name, "hello")
+	_, matched2 := app.matchKeywordRules(org.ID, account.Name, "hello", nil)
 	assert.False(t, matched2)
 }
 
@@ -133,15 +133,15 @@ func TestMatchKeywordRules_ContainsMatch(t *testing.T) {
 	}
 	require.NoError(t, app.DB.Create(rule).Error)
 
-	resp, matched := app.matchKeywordRules(org.ID, account.Name, "I need help please")
+	resp, matched := app.matchKeywordRules(org.ID, account.Name, "I need help please", nil)
 	assert.True(t, matched)
 	require.NotNil(t, resp)
 	assert.Equal(t, "Help response", resp.Body)
 
-	_, matched2 := app.matchKeywordRules(org.ID, account.Name, "HELP ME")
+	_, matched2 := app.matchKeywordRules(org.ID, account.Name, "HELP ME", nil)
 	assert.True(t, matched2)
 
-	_, matched3 := app.matchKeywordRules(org.ID, account.Name, "goodbye")
+	_, matched3 := app.matchKeywordRules(org.ID, account.Name, "goodbye", nil)
 	assert.False(t, matched3)
 }
 
@@ -163,12 +163,12 @@ func TestMatchKeywordRules_StartsWithMatch(t *testing.T) {
 	}
 	require.NoError(t, app.DB.Create(rule).Error)
 
-	resp, matched := app.matchKeywordRules(org.ID, account.Name, "hi there")
+	resp, matched := app.matchKeywordRules(org.ID, account.Name, "hi there", nil)
 	assert.True(t, matched)
 	require.NotNil(t, resp)
 	assert.Equal(t, "Hi response", resp.Body)
 
-	_, matched2 := app.matchKeywordRules(org.ID, account.Name, "say hi")
+	_, matched2 := app.matchKeywordRules(org.ID, account.Name, "say hi", nil)
 	assert.False(t, matched2)
 }
 
@@ -190,12 +190,12 @@ func TestMatchKeywordRules_RegexMatch(t *testing.T) {
 	}
 	require.NoError(t, app.DB.Create(rule).Error)
 
-	resp, matched := app.matchKeywordRules(org.ID, account.Name, "I have order #12345")
+	resp, matched := app.matchKeywordRules(org.ID, account.Name, "I have order #12345", nil)
 	assert.True(t, matched)
 	require.NotNil(t, resp)
 	assert.Equal(t, "Order lookup", resp.Body)
 
-	_, matched2 := app.matchKeywordRules(org.ID, account.Name, "where is my package")
+	_, matched2 := app.matchKeywordRules(org.ID, account.Name, "where is my package", nil)
 	assert.False(t, matched2)
 }
 
@@ -217,7 +217,7 @@ func TestMatchKeywordRules_NoMatch(t *testing.T) {
 	}
 	require.NoError(t, app.DB.Create(rule).Error)
 
-	resp, matched := app.matchKeywordRules(org.ID, account.Name, "random message")
+	resp, matched := app.matchKeywordRules(org.ID, account.Name, "random message", nil)
 	assert.False(t, matched)
 	assert.Nil(t, resp)
 }
@@ -257,7 +257,7 @@ func TestMatchKeywordRules_Priority(t *testing.T) {
 	require.NoError(t, app.DB.Create(highRule).Error)
 
 	// The higher priority rule should be returned (rules are ORDER BY priority DESC)
-	resp, matched := app.matchKeywordRules(org.ID, account.Name, "this is a test")
+	resp, matched := app.matchKeywordRules(org.ID, account.Name, "this is a test", nil)
 	assert.True(t, matched)
 	require.NotNil(t, resp)
 	assert.Equal(t, "High priority", resp.Body)
@@ -283,7 +283,7 @@ func TestMatchKeywordRules_DisabledRuleIgnored(t *testing.T) {
 	// Explicitly disable: GORM skips zero-value bools with default:true on INSERT.
 	require.NoError(t, app.DB.Model(rule).Update("is_enabled", false).Error)
 
-	_, matched := app.matchKeywordRules(org.ID, account.Name, "disabled")
+	_, matched := app.matchKeywordRules(org.ID, account.Name, "disabled", nil)
 	assert.False(t, matched)
 }
 
@@ -305,7 +305,7 @@ func TestMatchKeywordRules_TransferType(t *testing.T) {
 	}
 	require.NoError(t, app.DB.Create(rule).Error)
 
-	resp, matched := app.matchKeywordRules(org.ID, account.Name, "agent")
+	resp, matched := app.matchKeywordRules(org.ID, account.Name, "agent", nil)
 	assert.True(t, matched)
 	require.NotNil(t, resp)
 	assert.Equal(t, models.ResponseTypeTransfer, resp.ResponseType)
@@ -336,7 +336,7 @@ func TestMatchKeywordRules_WithButtons(t *testing.T) {
 	}
 	require.NoError(t, app.DB.Create(rule).Error)
 
-	resp, matched := app.matchKeywordRules(org.ID, account.Name, "menu")
+	resp, matched := app.matchKeywordRules(org.ID, account.Name, "menu", nil)
 	assert.True(t, matched)
 	require.NotNil(t, resp)
 	assert.Equal(t, "Choose an option:", resp.Body)
@@ -416,12 +416,37 @@ func TestGetOrCreateSession_ExpiredSession(t *testing.T) {
 	assert.NotEqual(t, expired.ID, session.ID, "should create a new session, not return expired one")
 }
 
+func TestGetOrCreateSession_PausedSessionDoesNotExpire(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	// Paused at a delay step for longer than the session timeout
+	resumeAt := time.Now().Add(24 * time.Hour)
+	paused := models.ChatbotSession{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: account.Name,
+		PhoneNumber:     contact.PhoneNumber,
+		Status:          models.SessionStatusActive,
+		SessionData:     models.JSONB{},
+		StartedAt:       time.Now().Add(-2 * time.Hour),
+		LastActivityAt:  time.Now().Add(-2 * time.Hour),
+		ResumeAt:        &resumeAt,
+	}
+	require.NoError(t, app.DB.Create(&paused).Error)
+
+	session, isNew := app.getOrCreateSession(org.ID, contact.ID, account.Name, contact.PhoneNumber, 30)
+	assert.False(t, isNew, "a reply during the delay must not start a second session")
+	assert.Equal(t, paused.ID, session.ID)
+}
+
 // =============================================================================
-// isWithinBusinessHours
+// businessCalendar.isOpen
 // =============================================================================
 
-func TestIsWithinBusinessHours_WithinHours(t *testing.T) {
-	app := newProcessorTestApp(t)
+func TestBusinessCalendarIsOpen_WithinHours(t *testing.T) {
 	now := time.Now()
 	dayOfWeek := float64(now.Weekday())
 
@@ -434,12 +459,11 @@ func TestIsWithinBusinessHours_WithinHours(t *testing.T) {
 		},
 	}
 
-	result := app.isWithinBusinessHours(hours)
+	result := newBusinessCalendar(models.BusinessHoursConfig{Hours: hours}, time.Local).isOpen(now)
 	assert.True(t, result)
 }
 
-func TestIsWithinBusinessHours_OutsideHours(t *testing.T) {
-	app := newProcessorTestApp(t)
+func TestBusinessCalendarIsOpen_OutsideHours(t *testing.T) {
 	now := time.Now()
 	dayOfWeek := float64(now.Weekday())
 
@@ -457,13 +481,12 @@ func TestIsWithinBusinessHours_OutsideHours(t *testing.T) {
 	// This will only be true if running at midnight; for all practical purposes it tests false
 	currentTime := now.Format("15:04")
 	if currentTime > "00:01" {
-		result := app.isWithinBusinessHours(hours)
+		result := newBusinessCalendar(models.BusinessHoursConfig{Hours: hours}, time.Local).isOpen(now)
 		assert.False(t, result)
 	}
 }
 
-func TestIsWithinBusinessHours_DayDisabled(t *testing.T) {
-	app := newProcessorTestApp(t)
+func TestBusinessCalendarIsOpen_DayDisabled(t *testing.T) {
 	now := time.Now()
 	dayOfWeek := float64(now.Weekday())
 
@@ -476,12 +499,11 @@ func TestIsWithinBusinessHours_DayDisabled(t *testing.T) {
 		},
 	}
 
-	result := app.isWithinBusinessHours(hours)
+	result := newBusinessCalendar(models.BusinessHoursConfig{Hours: hours}, time.Local).isOpen(now)
 	assert.False(t, result)
 }
 
-func TestIsWithinBusinessHours_NoMatchingDay(t *testing.T) {
-	app := newProcessorTestApp(t)
+func TestBusinessCalendarIsOpen_NoMatchingDay(t *testing.T) {
 	now := time.Now()
 	// Use a different day of the week
 	otherDay := float64((int(now.Weekday()) + 1) % 7)
@@ -495,14 +517,13 @@ func TestIsWithinBusinessHours_NoMatchingDay(t *testing.T) {
 		},
 	}
 
-	result := app.isWithinBusinessHours(hours)
+	result := newBusinessCalendar(models.BusinessHoursConfig{Hours: hours}, time.Local).isOpen(now)
 	assert.False(t, result)
 }
 
-func TestIsWithinBusinessHours_EmptyHours(t *testing.T) {
-	app := newProcessorTestApp(t)
+func TestBusinessCalendarIsOpen_EmptyHours(t *testing.T) {
 
-	result := app.isWithinBusinessHours(models.JSONBArray{})
+	result := newBusinessCalendar(models.BusinessHoursConfig{Hours: models.JSONBArray{}}, time.Local).isOpen(time.Now())
 	assert.False(t, result)
 }
 
@@ -734,6 +755,259 @@ func TestCompleteFlow_UpdatesSession(t *testing.T) {
 	assert.NotNil(t, dbSession.CompletedAt)
 }
 
+// =============================================================================
+// call_flow / goto_flow
+// =============================================================================
+
+func TestCallFlow_ReturnsToCallingFlow(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	subFlowID := uuid.New()
+	subFlow := &models.ChatbotFlow{
+		BaseModel:       models.BaseModel{ID: subFlowID},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "Address Sub-flow",
+		IsEnabled:       true,
+		Steps: []models.ChatbotFlowStep{
+			{
+				BaseModel:   models.BaseModel{ID: uuid.New()},
+				FlowID:      subFlowID,
+				StepName:    "ask_address",
+				StepOrder:   1,
+				Message:     "What is your address?",
+				MessageType: models.FlowStepTypeText,
+				InputType:   models.InputTypeText,
+				StoreAs:     "address",
+			},
+		},
+	}
+	require.NoError(t, app.DB.Create(subFlow).Error)
+
+	mainFlowID := uuid.New()
+	mainFlow := &models.ChatbotFlow{
+		BaseModel:       models.BaseModel{ID: mainFlowID},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "Order Flow",
+		IsEnabled:       true,
+		Steps: []models.ChatbotFlowStep{
+			{
+				BaseModel:   models.BaseModel{ID: uuid.New()},
+				FlowID:      mainFlowID,
+				StepName:    "get_address",
+				StepOrder:   1,
+				MessageType: models.FlowStepTypeCallFlow,
+				InputType:   models.InputTypeNone,
+				JumpConfig:  models.JSONB{"flow_id": subFlowID.String()},
+			},
+			{
+				BaseModel:   models.BaseModel{ID: uuid.New()},
+				FlowID:      mainFlowID,
+				StepName:    "confirm",
+				StepOrder:   2,
+				Message:     "Deliver to {{address}}?",
+				MessageType: models.FlowStepTypeText,
+				InputType:   models.InputTypeText,
+			},
+		},
+	}
+	require.NoError(t, app.DB.Create(mainFlow).Error)
+	app.InvalidateChatbotFlowsCache(org.ID)
+
+	session := &models.ChatbotSession{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: account.Name,
+		PhoneNumber:     contact.PhoneNumber,
+		Status:          models.SessionStatusActive,
+		SessionData:     models.JSONB{},
+		StartedAt:       time.Now(),
+		LastActivityAt:  time.Now(),
+	}
+	require.NoError(t, app.DB.Create(session).Error)
+
+	// Starting the main flow jumps straight into the sub-flow
+	app.startFlow(account, session, contact, mainFlow)
+
+	var dbSession models.ChatbotSession
+	require.NoError(t, app.DB.First(&dbSession, session.ID).Error)
+	require.NotNil(t, dbSession.CurrentFlowID)
+	assert.Equal(t, subFlowID, *dbSession.CurrentFlowID)
+	assert.Equal(t, "ask_address", dbSession.CurrentStep)
+
+	// Answering the sub-flow returns to the step after the call
+	app.processFlowResponse(account, session, contact, "221B Baker Street", "", nil)
+
+	require.NoError(t, app.DB.First(&dbSession, session.ID).Error)
+	require.NotNil(t, dbSession.CurrentFlowID)
+	assert.Equal(t, mainFlowID, *dbSession.CurrentFlowID)
+	assert.Equal(t, "confirm", dbSession.CurrentStep)
+	assert.Equal(t, models.SessionStatusActive, dbSession.Status)
+	assert.Equal(t, "221B Baker Street", dbSession.SessionData["address"])
+	assert.Nil(t, dbSession.SessionData["_call_stack"])
+}
+
+func TestGotoFlow_LocalizesMessage(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	targetID := uuid.New()
+	target := &models.ChatbotFlow{
+		BaseModel:       models.BaseModel{ID: targetID},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "Support",
+		IsEnabled:       true,
+		Steps: []models.ChatbotFlowStep{
+			{
+				BaseModel:   models.BaseModel{ID: uuid.New()},
+				FlowID:      targetID,
+				StepName:    "ask_issue",
+				StepOrder:   1,
+				Message:     "What is the issue?",
+				MessageType: models.FlowStepTypeText,
+				InputType:   models.InputTypeText,
+			},
+		},
+	}
+	require.NoError(t, app.DB.Create(target).Error)
+
+	flowID := uuid.New()
+	flow := &models.ChatbotFlow{
+		BaseModel:       models.BaseModel{ID: flowID},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "Menu",
+		IsEnabled:       true,
+		Steps: []models.ChatbotFlowStep{
+			{
+				BaseModel:    models.BaseModel{ID: uuid.New()},
+				FlowID:       flowID,
+				StepName:     "to_support",
+				StepOrder:    1,
+				Message:      "Connecting you to support",
+				Translations: models.JSONB{"es": map[string]interface{}{"message": "Te conectamos con soporte"}},
+				MessageType:  models.FlowStepTypeGotoFlow,
+				InputType:    models.InputTypeNone,
+				JumpConfig:   models.JSONB{"flow_id": targetID.String()},
+			},
+		},
+	}
+	require.NoError(t, app.DB.Create(flow).Error)
+	app.InvalidateChatbotFlowsCache(org.ID)
+
+	session := &models.ChatbotSession{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: account.Name,
+		PhoneNumber:     contact.PhoneNumber,
+		Status:          models.SessionStatusActive,
+		SessionData:     models.JSONB{sessionLanguageKey: "es"},
+		StartedAt:       time.Now(),
+		LastActivityAt:  time.Now(),
+	}
+	require.NoError(t, app.DB.Create(session).Error)
+
+	app.startFlow(account, session, contact, flow)
+
+	var messages []models.Message
+	require.NoError(t, app.DB.Where("contact_id = ? AND direction = ?", contact.ID, models.DirectionOutgoing).
+		Order("created_at").Find(&messages).Error)
+	require.NotEmpty(t, messages)
+	assert.Equal(t, "Te conectamos con soporte", messages[0].Content)
+}
+
+func TestDelayStep_ResumesAfterDelay(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	flowID := uuid.New()
+	flow := &models.ChatbotFlow{
+		BaseModel:       models.BaseModel{ID: flowID},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "Follow-up Flow",
+		IsEnabled:       true,
+		Steps: []models.ChatbotFlowStep{
+			{
+				BaseModel:    models.BaseModel{ID: uuid.New()},
+				FlowID:       flowID,
+				StepName:     "wait",
+				StepOrder:    1,
+				MessageType:  models.FlowStepTypeDelay,
+				InputType:    models.InputTypeNone,
+				ActionConfig: models.JSONB{"duration": float64(1), "unit": "hours"},
+			},
+			{
+				BaseModel:   models.BaseModel{ID: uuid.New()},
+				FlowID:      flowID,
+				StepName:    "follow_up",
+				StepOrder:   2,
+				Message:     "How was your order?",
+				MessageType: models.FlowStepTypeText,
+				InputType:   models.InputTypeText,
+			},
+		},
+	}
+	require.NoError(t, app.DB.Create(flow).Error)
+	app.InvalidateChatbotFlowsCache(org.ID)
+
+	session := &models.ChatbotSession{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: account.Name,
+		PhoneNumber:     contact.PhoneNumber,
+		Status:          models.SessionStatusActive,
+		SessionData:     models.JSONB{},
+		StartedAt:       time.Now(),
+		LastActivityAt:  time.Now(),
+	}
+	require.NoError(t, app.DB.Create(session).Error)
+
+	now := time.Now()
+	require.NoError(t, app.DB.Model(contact).Update("chatbot_last_message_at", now).Error)
+
+	app.startFlow(account, session, contact, flow)
+
+	var dbSession models.ChatbotSession
+	require.NoError(t, app.DB.First(&dbSession, session.ID).Error)
+	assert.Equal(t, "wait", dbSession.CurrentStep)
+	require.NotNil(t, dbSession.ResumeAt)
+	assert.True(t, dbSession.ResumeAt.After(time.Now().Add(50*time.Minute)))
+
+	// No inactivity reminders while the flow is paused
+	var dbContact models.Contact
+	require.NoError(t, app.DB.First(&dbContact, contact.ID).Error)
+	assert.Nil(t, dbContact.ChatbotLastMessageAt)
+
+	// Replies during the delay don't move the flow on
+	app.processFlowResponse(account, &dbSession, contact, "hello?", "", nil)
+	require.NoError(t, app.DB.First(&dbSession, session.ID).Error)
+	assert.Equal(t, "wait", dbSession.CurrentStep)
+
+	// Not due yet
+	processor := NewFlowDelayProcessor(app, time.Minute)
+	processor.resumeDueSessions()
+	require.NoError(t, app.DB.First(&dbSession, session.ID).Error)
+	assert.Equal(t, "wait", dbSession.CurrentStep)
+
+	// Once due, the flow continues with the next step
+	require.NoError(t, app.DB.Model(&dbSession).Update("resume_at", time.Now().Add(-time.Second)).Error)
+	processor.resumeDueSessions()
+	require.NoError(t, app.DB.First(&dbSession, session.ID).Error)
+	assert.Equal(t, "follow_up", dbSession.CurrentStep)
+	assert.Nil(t, dbSession.ResumeAt)
+	assert.Equal(t, models.SessionStatusActive, dbSession.Status)
+}
+
 // =============================================================================
 // exitFlow
 // =============================================================================
@@ -788,7 +1062,7 @@ func TestSaveIncomingMessage_TextMessage(t *testing.T) {
 	contact := testutil.CreateTestContact(t, app.DB, org.ID)
 
 	waMsgID := "wamid." + uuid.New().String()[:16]
-	app.saveIncomingMessage(account, contact, waMsgID, "text", "Hello from test", nil, "")
+	app.saveIncomingMessage(account, contact, waMsgID, "text", "Hello from test", nil, "", nil)
 
 	// Verify message was saved
 	var msg models.Message
@@ -819,7 +1093,7 @@ func TestSaveIncomingMessage_WithMedia(t *testing.T) {
 		MediaMimeType: "image/jpeg",
 		MediaFilename: "photo.jpg",
 	}
-	app.saveIncomingMessage(account, contact, waMsgID, "image", "Look at this", media, "")
+	app.saveIncomingMessage(account, contact, waMsgID, "image", "Look at this", media, "", nil)
 
 	var msg models.Message
 	require.NoError(t, app.DB.Where("whats_app_message_id = ?", waMsgID).First(&msg).Error)
@@ -855,7 +1129,7 @@ func TestSaveIncomingMessage_WithReplyContext(t *testing.T) {
 
 	// Save reply message
 	replyWAMID := "wamid.reply_" + uuid.New().String()[:8]
-	app.saveIncomingMessage(account, contact, replyWAMID, "text", "Reply to your message", nil, originalWAMID)
+	app.saveIncomingMessage(account, contact, replyWAMID, "text", "Reply to your message", nil, originalWAMID, nil)
 
 	var replyMsg models.Message
 	require.NoError(t, app.DB.Where("whats_app_message_id = ?", replyWAMID).First(&replyMsg).Error)
@@ -875,7 +1149,7 @@ func TestSaveIncomingMessage_LongContent(t *testing.T) {
 		longContent += "x"
 	}
 	waMsgID := "wamid." + uuid.New().String()[:16]
-	app.saveIncomingMessage(account, contact, waMsgID, "text", longContent, nil, "")
+	app.saveIncomingMessage(account, contact, waMsgID, "text", longContent, nil, "", nil)
 
 	var dbContact models.Contact
 	require.NoError(t, app.DB.First(&dbContact, contact.ID).Error)
@@ -1007,3 +1281,41 @@ func TestEvaluateExpression_Parentheses(t *testing.T) {
 func TestEvaluateExpression_EmptyExpression(t *testing.T) {
 	assert.False(t, evaluateExpression("", map[string]interface{}{}))
 }
+
+// =============================================================================
+// whatsAppFlowEntry
+// =============================================================================
+
+func TestWhatsAppFlowEntry_ScopedToOrganization(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	otherOrg, otherAccount := createProcessorTestOrg(t, app)
+
+	metaFlowID := "meta-flow-" + uuid.New().String()[:8]
+	otherFlow := &models.WhatsAppFlow{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  otherOrg.ID,
+		WhatsAppAccount: otherAccount.Name,
+		MetaFlowID:      metaFlowID,
+		Name:            "Other tenant flow",
+		Screens:         models.JSONBArray{map[string]interface{}{"id": "OTHER_SCREEN"}},
+	}
+	require.NoError(t, app.DB.Create(otherFlow).Error)
+
+	action, screen := app.whatsAppFlowEntry(account, metaFlowID)
+	assert.Equal(t, whatsapp.FlowMessageActionNavigate, action)
+	assert.Empty(t, screen, "another organization's flow is not used")
+
+	ownFlow := &models.WhatsAppFlow{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		MetaFlowID:      metaFlowID,
+		Name:            "Own flow",
+		Screens:         models.JSONBArray{map[string]interface{}{"id": "WELCOME"}},
+	}
+	require.NoError(t, app.DB.Create(ownFlow).Error)
+
+	_, screen = app.whatsAppFlowEntry(account, metaFlowID)
+	assert.Equal(t, "WELCOME", screen)
+}
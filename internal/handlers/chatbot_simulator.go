@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// SimulateFlowRequest is the request body for a flow dry-run
+type SimulateFlowRequest struct {
+	Steps        []FlowStepRequest      `json:"steps"`          // Optional unsaved steps to simulate instead of the saved ones
+	Inputs       []SimulatedInput       `json:"inputs"`         // Customer replies, in order
+	SessionData  map[string]interface{} `json:"session_data"`   // Initial session variables
+	APIMocks     map[string]interface{} `json:"api_mocks"`      // Step name -> mocked API response for api_fetch steps
+	LiveAPICalls bool                   `json:"live_api_calls"` // Call the real API when a saved step has no mock
+	Language     string                 `json:"language"`       // Conversation language, e.g. "es"
+}
+
+// SimulatedInput is a single customer reply fed into the simulation
+type SimulatedInput struct {
+	Text         string                 `json:"text"`
+	ButtonID     string                 `json:"button_id"`
+	FlowResponse map[string]interface{} `json:"flow_response"`
+	Location     map[string]interface{} `json:"location"` // {latitude, longitude, name, address}
+}
+
+// SimulatedMessage is a message captured during the simulation
+type SimulatedMessage struct {
+	Direction       models.Direction   `json:"direction"`
+	Type            models.MessageType `json:"type"`
+	Content         string             `json:"content"`
+	InteractiveType string             `json:"interactive_type,omitempty"`
+	Buttons         []whatsapp.Button  `json:"buttons,omitempty"`
+	ButtonText      string             `json:"button_text,omitempty"`
+	URL             string             `json:"url,omitempty"`
+	FlowID          string             `json:"flow_id,omitempty"`
+	Step            string             `json:"step,omitempty"`
+}
+
+// SimulatedStep is a step entered during the simulation
+type SimulatedStep struct {
+	FlowID   string `json:"flow_id"`
+	FlowName string `json:"flow_name"`
+	Step     string `json:"step"`
+	Skipped  bool   `json:"skipped"`
+}
+
+// SimulatedAPICall is an api_fetch request made (or mocked) during the simulation.
+// Response is only set for mocked calls; live calls report their status code.
+type SimulatedAPICall struct {
+	Step       string `json:"step"`
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	Body       string `json:"body,omitempty"`
+	Mocked     bool   `json:"mocked"`
+	Response   string `json:"response,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SimulatedAction is a side effect that was suppressed during the simulation (transfer, webhook)
+type SimulatedAction struct {
+	Type   string      `json:"type"`
+	Step   string      `json:"step,omitempty"`
+	Config interface{} `json:"config,omitempty"`
+}
+
+// FlowSimulationResult is the outcome of a flow dry-run
+type FlowSimulationResult struct {
+	Messages      []SimulatedMessage   `json:"messages"`
+	Path          []SimulatedStep      `json:"path"`
+	APICalls      []SimulatedAPICall   `json:"api_calls"`
+	Actions       []SimulatedAction    `json:"actions"`
+	SessionData   models.JSONB         `json:"session_data"`
+	Status        models.SessionStatus `json:"status"`
+	CurrentFlowID string               `json:"current_flow_id,omitempty"`
+	CurrentStep   string               `json:"current_step"`
+	InputsUsed    int                  `json:"inputs_used"`
+}
+
+// flowSimulation captures the side effects of running a flow instead of performing them
+type flowSimulation struct {
+	result       FlowSimulationResult
+	flows        map[uuid.UUID]*models.ChatbotFlow
+	initialData  map[string]interface{}
+	apiMocks     map[string]interface{}
+	liveAPICalls bool
+	currentStep  string
+}
+
+func newFlowSimulation(req SimulateFlowRequest) *flowSimulation {
+	return &flowSimulation{
+		result: FlowSimulationResult{
+			Messages: make([]SimulatedMessage, 0),
+			Path:     make([]SimulatedStep, 0),
+			APICalls: make([]SimulatedAPICall, 0),
+			Actions:  make([]SimulatedAction, 0),
+		},
+		flows:        make(map[uuid.UUID]*models.ChatbotFlow),
+		initialData:  req.SessionData,
+		apiMocks:     req.APIMocks,
+		liveAPICalls: req.LiveAPICalls,
+	}
+}
+
+func (s *flowSimulation) captureMessage(req OutgoingMessageRequest) {
+	msg := SimulatedMessage{
+		Direction:       models.DirectionOutgoing,
+		Type:            req.Type,
+		Content:         req.Content,
+		InteractiveType: req.InteractiveType,
+		Buttons:         req.Buttons,
+		ButtonText:      req.ButtonText,
+		URL:             req.URL,
+		FlowID:          req.FlowID,
+		Step:            s.currentStep,
+	}
+	if msg.Content == "" {
+		msg.Content = req.BodyText
+	}
+	if msg.Content == "" {
+		msg.Content = req.Caption
+	}
+	if msg.Content == "" && req.Template != nil {
+		msg.Content = req.Template.BodyContent
+	}
+	if msg.URL == "" {
+		msg.URL = req.MediaURL
+	}
+	s.result.Messages = append(s.result.Messages, msg)
+}
+
+func (s *flowSimulation) captureIncoming(input SimulatedInput) {
+	msg := SimulatedMessage{
+		Direction: models.DirectionIncoming,
+		Type:      models.MessageTypeText,
+		Content:   input.Text,
+		Step:      s.currentStep,
+	}
+	if input.ButtonID != "" || len(input.FlowResponse) > 0 {
+		msg.Type = models.MessageTypeInteractive
+	}
+	if input.Location != nil {
+		msg.Type = models.MessageTypeLocation
+	}
+	s.result.Messages = append(s.result.Messages, msg)
+}
+
+func (s *flowSimulation) enterStep(flow *models.ChatbotFlow, step *models.ChatbotFlowStep) {
+	s.currentStep = step.StepName
+	s.result.Path = append(s.result.Path, SimulatedStep{
+		FlowID:   flow.ID.String(),
+		FlowName: flow.Name,
+		Step:     step.StepName,
+	})
+}
+
+func (s *flowSimulation) skipStep() {
+	if len(s.result.Path) > 0 {
+		s.result.Path[len(s.result.Path)-1].Skipped = true
+	}
+}
+
+func (s *flowSimulation) recordAction(actionType string, config interface{}) {
+	s.result.Actions = append(s.result.Actions, SimulatedAction{
+		Type:   actionType,
+		Step:   s.currentStep,
+		Config: config,
+	})
+}
+
+// apiCall records an api_fetch request and returns the mocked response body.
+// handled is false when the real API should be called instead.
+func (s *flowSimulation) apiCall(method, url, body string) ([]byte, bool, error) {
+	call := SimulatedAPICall{
+		Step:   s.currentStep,
+		Method: method,
+		URL:    url,
+		Body:   body,
+	}
+
+	mock, ok := s.apiMocks[s.currentStep]
+	if !ok {
+		if s.liveAPICalls {
+			if err := validateWebhookURL(url); err != nil {
+				call.Error = err.Error()
+				s.result.APICalls = append(s.result.APICalls, call)
+				return nil, true, err
+			}
+			s.result.APICalls = append(s.result.APICalls, call)
+			return nil, false, nil
+		}
+		call.Error = "no mocked response"
+		s.result.APICalls = append(s.result.APICalls, call)
+		return nil, true, fmt.Errorf("no mocked response for step %s", s.currentStep)
+	}
+
+	call.Mocked = true
+	var respBody []byte
+	if str, isString := mock.(string); isString {
+		respBody = []byte(str)
+	} else {
+		var err error
+		if respBody, err = json.Marshal(mock); err != nil {
+			call.Error = err.Error()
+			s.result.APICalls = append(s.result.APICalls, call)
+			return nil, true, err
+		}
+	}
+	call.Response = string(respBody)
+	s.result.APICalls = append(s.result.APICalls, call)
+	return respBody, true, nil
+}
+
+// recordAPIResult stores the outcome of a live API call made during the simulation.
+// The response body is not recorded so the simulator can't be used to read arbitrary URLs.
+func (s *flowSimulation) recordAPIResult(statusCode int, err error) {
+	if len(s.result.APICalls) == 0 {
+		return
+	}
+	call := &s.result.APICalls[len(s.result.APICalls)-1]
+	call.StatusCode = statusCode
+	if err != nil {
+		call.Error = err.Error()
+	}
+}
+
+// applySessionUpdates applies flow engine column updates to an in-memory session
+func applySessionUpdates(session *models.ChatbotSession, updates map[string]interface{}) {
+	for key, value := range updates {
+		switch key {
+		case "current_step":
+			session.CurrentStep, _ = value.(string)
+		case "step_retries":
+			session.StepRetries, _ = value.(int)
+		case "status":
+			session.Status, _ = value.(models.SessionStatus)
+		case "completed_at":
+			if t, ok := value.(time.Time); ok {
+				session.CompletedAt = &t
+			}
+		case "session_data":
+			session.SessionData, _ = value.(models.JSONB)
+		case "current_flow_id":
+			if id, ok := value.(uuid.UUID); ok {
+				session.CurrentFlowID = &id
+			}
+		case "current_flow_version":
+			session.CurrentFlowVersion, _ = value.(int)
+		}
+	}
+}
+
+// simulationHTTPClient returns the client used for live api_fetch calls during a simulation.
+// It always dials through SSRFSafeDialer, whatever client the App was configured with.
+func simulationHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: SSRFSafeDialer(),
+		},
+	}
+}
+
+// simulationApp returns a copy of the App that runs the flow engine in simulation mode.
+// It keeps the real dependencies so every code path has what it expects; side effects
+// are suppressed by the flowSim checks rather than by leaving dependencies nil.
+func (a *App) simulationApp(sim *flowSimulation) *App {
+	return &App{
+		Config:             a.Config,
+		DB:                 a.DB,
+		Redis:              a.Redis,
+		Log:                a.Log,
+		WhatsApp:           a.WhatsApp,
+		WSHub:              a.WSHub,
+		Queue:              a.Queue,
+		CampaignSubCancel:  a.CampaignSubCancel,
+		BroadcastSubCancel: a.BroadcastSubCancel,
+		HTTPClient:         simulationHTTPClient(),
+		flowSim:            sim,
+	}
+}
+
+// simulateFlow runs a flow against an in-memory session, capturing outgoing messages
+// and suppressing transfers, webhooks and database writes.
+func (a *App) simulateFlow(flow *models.ChatbotFlow, req SimulateFlowRequest) *FlowSimulationResult {
+	sim := newFlowSimulation(req)
+	sim.flows[flow.ID] = flow
+
+	simApp := a.simulationApp(sim)
+
+	now := time.Now()
+	account := &models.WhatsAppAccount{
+		OrganizationID: flow.OrganizationID,
+		Name:           flow.WhatsAppAccount,
+	}
+	contact := &models.Contact{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  flow.OrganizationID,
+		PhoneNumber:     "simulator",
+		ProfileName:     "Simulator",
+		WhatsAppAccount: flow.WhatsAppAccount,
+	}
+	session := &models.ChatbotSession{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  flow.OrganizationID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: flow.WhatsAppAccount,
+		PhoneNumber:     contact.PhoneNumber,
+		Status:          models.SessionStatusActive,
+		SessionData:     models.JSONB{},
+		StartedAt:       now,
+		LastActivityAt:  now,
+	}
+	if lang := templateutil.NormalizeLanguage(req.Language); lang != "" {
+		contact.Language = lang
+		session.SessionData[sessionLanguageKey] = lang
+	}
+
+	simApp.startFlow(account, session, contact, flow)
+
+	for _, input := range req.Inputs {
+		if session.Status != models.SessionStatusActive || session.CurrentFlowID == nil {
+			break
+		}
+		text := input.Text
+		if input.Location != nil {
+			// Shared locations reach the flow engine as JSON text, as they do from the webhook
+			if data, err := json.Marshal(input.Location); err == nil {
+				text = string(data)
+			}
+		}
+		sim.captureIncoming(input)
+		simApp.processFlowResponse(account, session, contact, text, input.ButtonID, input.FlowResponse)
+		sim.result.InputsUsed++
+	}
+
+	result := &sim.result
+	result.SessionData = session.SessionData
+	result.Status = session.Status
+	result.CurrentStep = session.CurrentStep
+	if session.CurrentFlowID != nil {
+		result.CurrentFlowID = session.CurrentFlowID.String()
+	}
+	return result
+}
+
+// SimulateChatbotFlow dry-runs a flow with scripted customer replies without sending any messages
+func (a *App) SimulateChatbotFlow(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	// Simulations can call external APIs, so they need the same access as editing the flow
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionWrite, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	var req SimulateFlowRequest
+	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
+	}
+	if len(req.Steps) > 0 && req.LiveAPICalls {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "live_api_calls can only be used with saved steps", nil, "")
+	}
+
+	var flow models.ChatbotFlow
+	if err := a.DB.Where("id = ? AND organization_id = ?", id, orgID).
+		Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("step_order ASC")
+		}).
+		First(&flow).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow not found", nil, "")
+	}
+
+	// Simulate unsaved steps from the builder if provided
+	if len(req.Steps) > 0 {
+		flow.Steps = buildFlowSteps(flow.ID, req.Steps)
+		graph := a.validateFlowSteps(orgID, flow.ID, flow.Name, flow.Steps)
+		if graph.HasErrors() {
+			return sendFlowGraphErrors(r, graph)
+		}
+	}
+
+	return r.SendEnvelope(a.simulateFlow(&flow, req))
+}
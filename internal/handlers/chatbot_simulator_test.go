@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/shridarpatil/whatomate/internal/config"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newSimulationTestFlow builds an in-memory flow; simulations don't need a database.
+func newSimulationTestFlow(steps ...models.ChatbotFlowStep) *models.ChatbotFlow {
+	flowID := uuid.New()
+	for i := range steps {
+		steps[i].FlowID = flowID
+		steps[i].StepOrder = i + 1
+		if steps[i].MessageType == "" {
+			steps[i].MessageType = models.FlowStepTypeText
+		}
+	}
+	return &models.ChatbotFlow{
+		BaseModel:      models.BaseModel{ID: flowID},
+		OrganizationID: uuid.New(),
+		Name:           "Simulated Flow",
+		IsEnabled:      true,
+		Steps:          steps,
+	}
+}
+
+func outgoingContents(result *FlowSimulationResult) []string {
+	contents := make([]string, 0)
+	for _, msg := range result.Messages {
+		if msg.Direction == models.DirectionOutgoing {
+			contents = append(contents, msg.Content)
+		}
+	}
+	return contents
+}
+
+func TestSimulationApp_KeepsDependencies(t *testing.T) {
+	app := &App{
+		Config:             &config.Config{},
+		DB:                 &gorm.DB{},
+		Redis:              &redis.Client{},
+		Log:                testutil.NopLogger(),
+		WhatsApp:           &whatsapp.Client{},
+		WSHub:              &websocket.Hub{},
+		Queue:              queue.NewRedisQueue(&redis.Client{}, testutil.NopLogger()),
+		CampaignSubCancel:  func() {},
+		BroadcastSubCancel: func() {},
+		HTTPClient:         &http.Client{},
+	}
+
+	simApp := app.simulationApp(newFlowSimulation(SimulateFlowRequest{}))
+
+	v := reflect.ValueOf(simApp).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		assert.False(t, v.Field(i).IsZero(), "simulation App is missing %s", field.Name)
+	}
+	assert.NotNil(t, simApp.flowSim)
+	assert.NotSame(t, app.HTTPClient, simApp.HTTPClient)
+}
+
+func TestSimulateFlow_LinearFlow(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{StepName: "ask_name", Message: "What is your name?", InputType: models.InputTypeText, StoreAs: "name"},
+		models.ChatbotFlowStep{StepName: "ask_city", Message: "Where do you live, {{name}}?", InputType: models.InputTypeText, StoreAs: "city"},
+	)
+	flow.InitialMessage = "Welcome!"
+	flow.CompletionMessage = "Thanks {{name}} from {{city}}"
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{
+		Inputs: []SimulatedInput{{Text: "Alice"}, {Text: "Paris"}, {Text: "ignored"}},
+	})
+
+	assert.Equal(t, []string{"Welcome!", "What is your name?", "Where do you live, Alice?", "Thanks Alice from Paris"}, outgoingContents(result))
+	assert.Equal(t, models.SessionStatusCompleted, result.Status)
+	assert.Equal(t, 2, result.InputsUsed)
+	assert.Equal(t, "Alice", result.SessionData["name"])
+	assert.Equal(t, "Paris", result.SessionData["city"])
+	require.Len(t, result.Path, 2)
+	assert.Equal(t, "ask_name", result.Path[0].Step)
+	assert.Equal(t, "ask_city", result.Path[1].Step)
+}
+
+func TestSimulateFlow_ConditionalBranch(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{
+			StepName:    "menu",
+			Message:     "How can we help?",
+			MessageType: models.FlowStepTypeButtons,
+			InputType:   models.InputTypeButton,
+			Buttons: models.JSONBArray{
+				map[string]interface{}{"id": "sales", "title": "Sales"},
+				map[string]interface{}{"id": "support", "title": "Support"},
+			},
+			ConditionalNext: models.JSONB{"sales": "sales", "support": "support"},
+		},
+		models.ChatbotFlowStep{StepName: "sales", Message: "Sales team here", InputType: models.InputTypeText},
+		models.ChatbotFlowStep{StepName: "support", Message: "Support team here", InputType: models.InputTypeText},
+	)
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{
+		Inputs: []SimulatedInput{{Text: "Support", ButtonID: "support"}},
+	})
+
+	require.Len(t, result.Path, 2)
+	assert.Equal(t, "support", result.Path[1].Step)
+	assert.Equal(t, "support", result.CurrentStep)
+	assert.Equal(t, models.SessionStatusActive, result.Status)
+
+	require.NotEmpty(t, result.Messages)
+	assert.Equal(t, models.MessageTypeInteractive, result.Messages[0].Type)
+	assert.Len(t, result.Messages[0].Buttons, 2)
+}
+
+func TestSimulateFlow_SkipConditionAndInitialData(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{StepName: "ask_email", Message: "Email?", InputType: models.InputTypeEmail, SkipCondition: "email != ''"},
+		models.ChatbotFlowStep{StepName: "done", Message: "Using {{email}}", InputType: models.InputTypeNone},
+	)
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{
+		SessionData: map[string]interface{}{"email": "a@example.com"},
+	})
+
+	require.Len(t, result.Path, 2)
+	assert.True(t, result.Path[0].Skipped)
+	assert.False(t, result.Path[1].Skipped)
+	assert.Equal(t, []string{"Using a@example.com"}, outgoingContents(result))
+	assert.Equal(t, models.SessionStatusCompleted, result.Status)
+}
+
+func TestSimulateFlow_MockedAPIFetch(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{
+			StepName:    "lookup",
+			Message:     "Order {{order_id}} is {{status}}",
+			MessageType: models.FlowStepTypeAPIFetch,
+			InputType:   models.InputTypeNone,
+			ApiConfig: models.JSONB{
+				"url":              "https://api.example.com/orders/{{order_id}}",
+				"response_mapping": map[string]interface{}{"status": "order.status"},
+			},
+		},
+	)
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{
+		SessionData: map[string]interface{}{"order_id": "42"},
+		APIMocks: map[string]interface{}{
+			"lookup": map[string]interface{}{"order": map[string]interface{}{"status": "shipped"}},
+		},
+	})
+
+	assert.Equal(t, []string{"Order 42 is shipped"}, outgoingContents(result))
+	require.Len(t, result.APICalls, 1)
+	assert.True(t, result.APICalls[0].Mocked)
+	assert.Equal(t, "GET", result.APICalls[0].Method)
+	assert.Equal(t, "https://api.example.com/orders/42", result.APICalls[0].URL)
+	assert.Equal(t, "shipped", result.SessionData["status"])
+}
+
+func TestSimulateFlow_APIFetchWithoutMock(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{
+			StepName:    "lookup",
+			MessageType: models.FlowStepTypeAPIFetch,
+			InputType:   models.InputTypeNone,
+			ApiConfig: models.JSONB{
+				"url":              "https://api.example.com/orders",
+				"fallback_message": "Lookup failed",
+			},
+		},
+	)
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{})
+
+	assert.Equal(t, []string{"Lookup failed"}, outgoingContents(result))
+	require.Len(t, result.APICalls, 1)
+	assert.False(t, result.APICalls[0].Mocked)
+	assert.NotEmpty(t, result.APICalls[0].Error)
+}
+
+func TestSimulateFlow_LiveAPICallToInternalAddressIsBlocked(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		_, _ = w.Write([]byte(`{"secret":"internal"}`))
+	}))
+	defer server.Close()
+
+	app := &App{Log: testutil.NopLogger(), HTTPClient: server.Client()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{
+			StepName:    "lookup",
+			MessageType: models.FlowStepTypeAPIFetch,
+			InputType:   models.InputTypeNone,
+			ApiConfig: models.JSONB{
+				"url":              server.URL,
+				"fallback_message": "Lookup failed",
+			},
+		},
+	)
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{LiveAPICalls: true})
+
+	assert.Equal(t, 0, hits)
+	assert.Equal(t, []string{"Lookup failed"}, outgoingContents(result))
+	require.Len(t, result.APICalls, 1)
+	assert.NotEmpty(t, result.APICalls[0].Error)
+	assert.Empty(t, result.APICalls[0].Response)
+}
+
+func TestSimulateFlow_TransferIsCaptured(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	teamID := uuid.New()
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{StepName: "ask_issue", Message: "Describe the issue", InputType: models.InputTypeText, StoreAs: "issue"},
+		models.ChatbotFlowStep{
+			StepName:       "handoff",
+			Message:        "Connecting you to an agent",
+			MessageType:    models.FlowStepTypeTransfer,
+			InputType:      models.InputTypeNone,
+			TransferConfig: models.JSONB{"team_id": teamID.String(), "notes": "Issue: {{issue}}"},
+		},
+	)
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{
+		Inputs: []SimulatedInput{{Text: "Broken screen"}},
+	})
+
+	require.Len(t, result.Actions, 1)
+	assert.Equal(t, "transfer", result.Actions[0].Type)
+	assert.Equal(t, "handoff", result.Actions[0].Step)
+	config, ok := result.Actions[0].Config.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "Issue: Broken screen", config["notes"])
+	assert.Equal(t, models.SessionStatusCompleted, result.Status)
+}
+
+func TestSimulateFlow_ValidationRetry(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := newSimulationTestFlow(
+		models.ChatbotFlowStep{
+			StepName:        "ask_age",
+			Message:         "How old are you?",
+			InputType:       models.InputTypeNumber,
+			ValidationRegex: `^\d+$`,
+			ValidationError: "Please enter a number",
+			RetryOnInvalid:  true,
+			MaxRetries:      3,
+			StoreAs:         "age",
+		},
+	)
+
+	result := app.simulateFlow(flow, SimulateFlowRequest{
+		Inputs: []SimulatedInput{{Text: "old"}, {Text: "30"}},
+	})
+
+	assert.Equal(t, []string{"How old are you?", "Please enter a number"}, outgoingContents(result))
+	assert.Equal(t, "30", result.SessionData["age"])
+	assert.Equal(t, models.SessionStatusCompleted, result.Status)
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
 	"github.com/shridarpatil/whatomate/internal/websocket"
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
 	"github.com/valyala/fasthttp"
@@ -31,10 +32,15 @@ type ContactResponse struct {
 	Status             string     `json:"status"`
 	Tags               []string   `json:"tags"`
 	Metadata           any        `json:"metadata"`
+	Language           string     `json:"language"`
 	LastMessageAt      *time.Time `json:"last_message_at"`
 	LastMessagePreview string     `json:"last_message_preview"`
 	UnreadCount        int        `json:"unread_count"`
 	AssignedUserID     *uuid.UUID `json:"assigned_user_id,omitempty"`
+	ReferralSourceType string     `json:"referral_source_type,omitempty"`
+	ReferralSourceID   string     `json:"referral_source_id,omitempty"`
+	ReferralSourceURL  string     `json:"referral_source_url,omitempty"`
+	ReferredAt         *time.Time `json:"referred_at,omitempty"`
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 }
@@ -50,6 +56,7 @@ type MessageResponse struct {
 	MediaMimeType    string               `json:"media_mime_type,omitempty"`
 	MediaFilename    string               `json:"media_filename,omitempty"`
 	InteractiveData  models.JSONB         `json:"interactive_data,omitempty"`
+	Metadata         models.JSONB         `json:"metadata,omitempty"`
 	Status           models.MessageStatus `json:"status"`
 	WAMID            string               `json:"wamid"`
 	Error            string               `json:"error_message"`
@@ -57,6 +64,9 @@ type MessageResponse struct {
 	ReplyToMessageID *string              `json:"reply_to_message_id,omitempty"`
 	ReplyToMessage   *ReplyPreview        `json:"reply_to_message,omitempty"`
 	Reactions        []ReactionInfo       `json:"reactions,omitempty"`
+	EditedAt         *time.Time           `json:"edited_at,omitempty"`
+	RevokedAt        *time.Time           `json:"revoked_at,omitempty"`         // Deleted by the customer or retracted by an agent; content is left out
+	RevokedByUserID  *uuid.UUID           `json:"revoked_by_user_id,omitempty"` // Agent who retracted the message
 	CreatedAt        time.Time            `json:"created_at"`
 	UpdatedAt        time.Time            `json:"updated_at"`
 }
@@ -67,6 +77,8 @@ type ReplyPreview struct {
 	Content     any                `json:"content"`
 	MessageType models.MessageType `json:"message_type"`
 	Direction   models.Direction   `json:"direction"`
+	Edited      bool               `json:"edited,omitempty"`
+	Revoked     bool               `json:"revoked,omitempty"` // The message was deleted or retracted
 }
 
 // ReactionInfo represents a reaction on a message
@@ -76,8 +88,8 @@ type ReactionInfo struct {
 	FromUser  string `json:"from_user,omitempty"`
 }
 
-// ListContacts returns all contacts for the organization
-// Users without contacts:read permission only see contacts assigned to them
+// ListContacts returns the contacts of the organization the user may see
+// Users without chat.all:read only see their own and their teams' conversations
 func (a *App) ListContacts(r *fastglue.Request) error {
 	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
@@ -92,10 +104,8 @@ func (a *App) ListContacts(r *fastglue.Request) error {
 	var contacts []models.Contact
 	query := a.ScopeToOrg(a.DB, userID, orgID)
 
-	// Users without contacts:read permission can only see contacts assigned to them
-	if !a.HasPermission(userID, models.ResourceContacts, models.ActionRead, orgID) {
-		query = query.Where("assigned_user_id = ?", userID)
-	}
+	// Users without chat.all:read only see their own and their teams' conversations
+	query = a.scopeContacts(query, userID, orgID)
 
 	if search != "" {
 		// Limit search string length to prevent abuse
@@ -175,10 +185,15 @@ func (a *App) ListContacts(r *fastglue.Request) error {
 			Status:             "active",
 			Tags:               tags,
 			Metadata:           c.Metadata,
+			Language:           c.Language,
 			LastMessageAt:      c.LastMessageAt,
 			LastMessagePreview: c.LastMessagePreview,
 			UnreadCount:        int(unreadCount),
 			AssignedUserID:     c.AssignedUserID,
+			ReferralSourceType: c.ReferralSourceType,
+			ReferralSourceID:   c.ReferralSourceID,
+			ReferralSourceURL:  c.ReferralSourceURL,
+			ReferredAt:         c.ReferredAt,
 			CreatedAt:          c.CreatedAt,
 			UpdatedAt:          c.UpdatedAt,
 		}
@@ -193,7 +208,7 @@ func (a *App) ListContacts(r *fastglue.Request) error {
 }
 
 // GetContact returns a single contact
-// Users without contacts:read permission can only access contacts assigned to them
+// Users without chat.all:read can only access their own and their teams' conversations
 func (a *App) GetContact(r *fastglue.Request) error {
 	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
@@ -207,10 +222,8 @@ func (a *App) GetContact(r *fastglue.Request) error {
 	var contact models.Contact
 	query := a.DB.Where("id = ? AND organization_id = ?", contactID, orgID)
 
-	// Users without contacts:read permission can only access their assigned contacts
-	if !a.HasPermission(userID, models.ResourceContacts, models.ActionRead, orgID) {
-		query = query.Where("assigned_user_id = ?", userID)
-	}
+	// Users without chat.all:read can only access their own and their teams' conversations
+	query = a.scopeContacts(query, userID, orgID)
 
 	if err := query.First(&contact).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
@@ -247,10 +260,15 @@ func (a *App) GetContact(r *fastglue.Request) error {
 		Status:             "active",
 		Tags:               tags,
 		Metadata:           contact.Metadata,
+		Language:           contact.Language,
 		LastMessageAt:      contact.LastMessageAt,
 		LastMessagePreview: contact.LastMessagePreview,
 		UnreadCount:        int(unreadCount),
 		AssignedUserID:     contact.AssignedUserID,
+		ReferralSourceType: contact.ReferralSourceType,
+		ReferralSourceID:   contact.ReferralSourceID,
+		ReferralSourceURL:  contact.ReferralSourceURL,
+		ReferredAt:         contact.ReferredAt,
 		CreatedAt:          contact.CreatedAt,
 		UpdatedAt:          contact.UpdatedAt,
 	}
@@ -259,7 +277,7 @@ func (a *App) GetContact(r *fastglue.Request) error {
 }
 
 // GetMessages returns messages for a contact
-// Agents can only access messages for their assigned contacts
+// Users without chat.all:read can only access their own and their teams' conversations
 // Supports cursor-based pagination with before_id for loading older messages
 func (a *App) GetMessages(r *fastglue.Request) error {
 	orgID, userID, err := a.getOrgAndUserID(r)
@@ -273,12 +291,9 @@ func (a *App) GetMessages(r *fastglue.Request) error {
 
 	hasContactsReadPermission := a.HasPermission(userID, models.ResourceContacts, models.ActionRead, orgID)
 
-	// Verify contact belongs to org (and to user if no contacts:read permission)
+	// Verify contact belongs to org and is visible to the user
 	var contact models.Contact
-	query := a.DB.Where("id = ? AND organization_id = ?", contactID, orgID)
-	if !hasContactsReadPermission {
-		query = query.Where("assigned_user_id = ?", userID)
-	}
+	query := a.scopeContacts(a.DB.Where("id = ? AND organization_id = ?", contactID, orgID), userID, orgID)
 	if err := query.First(&contact).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
 	}
@@ -368,7 +383,7 @@ func (a *App) GetMessages(r *fastglue.Request) error {
 	}
 
 	// Mark messages as read
-	a.markMessagesAsRead(orgID, contactID, &contact)
+	a.markMessagesAsRead(contactID, &contact)
 
 	response := a.buildMessagesResponse(messages)
 	return r.SendEnvelope(map[string]any{
@@ -401,24 +416,32 @@ func (a *App) buildMessagesResponse(messages []models.Message) []MessageResponse
 			MediaMimeType:   m.MediaMimeType,
 			MediaFilename:   m.MediaFilename,
 			InteractiveData: m.InteractiveData,
+			Metadata:        m.Metadata,
 			Status:          m.Status,
 			WAMID:           m.WhatsAppMessageID,
 			Error:           m.ErrorMessage,
 			IsReply:         m.IsReply,
+			EditedAt:        m.EditedAt,
+			RevokedAt:       m.RevokedAt,
+			RevokedByUserID: m.RevokedByUserID,
 			CreatedAt:       m.CreatedAt,
 			UpdatedAt:       m.UpdatedAt,
 		}
 
+		// Deleted and retracted messages keep their content only in the history
+		if m.RevokedAt != nil {
+			msgResp.Content = map[string]string{"body": ""}
+			msgResp.MediaURL = ""
+			msgResp.MediaMimeType = ""
+			msgResp.MediaFilename = ""
+			msgResp.InteractiveData = nil
+		}
+
 		if m.IsReply && m.ReplyToMessageID != nil {
 			replyToID := m.ReplyToMessageID.String()
 			msgResp.ReplyToMessageID = &replyToID
 			if m.ReplyToMessage != nil {
-				msgResp.ReplyToMessage = &ReplyPreview{
-					ID:          m.ReplyToMessage.ID.String(),
-					Content:     map[string]string{"body": m.ReplyToMessage.Content},
-					MessageType: m.ReplyToMessage.MessageType,
-					Direction:   m.ReplyToMessage.Direction,
-				}
+				msgResp.ReplyToMessage = replyPreview(m.ReplyToMessage)
 			}
 		}
 
@@ -446,46 +469,14 @@ func (a *App) buildMessagesResponse(messages []models.Message) []MessageResponse
 	return response
 }
 
-// markMessagesAsRead marks messages as read and sends read receipts
-func (a *App) markMessagesAsRead(orgID uuid.UUID, contactID uuid.UUID, contact *models.Contact) {
-	var unreadMessages []models.Message
-	a.DB.Where("contact_id = ? AND direction = ? AND status != ?", contactID, models.DirectionIncoming, models.MessageStatusRead).
-		Find(&unreadMessages)
-
+// markMessagesAsRead marks the contact's messages as read in the inbox. Read
+// receipts are sent to the customer when an agent opens the conversation.
+func (a *App) markMessagesAsRead(contactID uuid.UUID, contact *models.Contact) {
 	a.DB.Model(&models.Message{}).
-		Where("contact_id = ? AND direction = ?", contactID, models.DirectionIncoming).
+		Where("contact_id = ? AND direction = ? AND status != ?", contactID, models.DirectionIncoming, models.MessageStatusRead).
 		Update("status", models.MessageStatusRead)
 
 	a.DB.Model(contact).Update("is_read", true)
-
-	if len(unreadMessages) > 0 && contact.WhatsAppAccount != "" {
-		var account models.WhatsAppAccount
-		if err := a.DB.Where("organization_id = ? AND name = ?", orgID, contact.WhatsAppAccount).First(&account).Error; err == nil {
-			if account.AutoReadReceipt {
-				a.wg.Add(1)
-				go func() {
-					defer a.wg.Done()
-					// Use timeout context for external API calls
-					ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-					defer cancel()
-
-					waAccount := a.toWhatsAppAccount(&account)
-					for _, msg := range unreadMessages {
-						// Check if context was cancelled
-						if ctx.Err() != nil {
-							a.Log.Warn("Read receipt sending cancelled", "reason", ctx.Err())
-							return
-						}
-						if msg.WhatsAppMessageID != "" {
-							if err := a.WhatsApp.MarkMessageRead(ctx, waAccount, msg.WhatsAppMessageID); err != nil {
-								a.Log.Error("Failed to send read receipt", "error", err, "message_id", msg.WhatsAppMessageID)
-							}
-						}
-					}
-				}()
-			}
-		}
-	}
 }
 
 // SendMessageRequest represents a send message request
@@ -533,12 +524,9 @@ func (a *App) SendMessage(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
 	}
 
-	// Get contact (users without full read permission can only message their assigned contacts)
+	// Get contact (users without chat.all:read can only message their own and their teams' conversations)
 	var contact models.Contact
-	query := a.DB.Where("id = ? AND organization_id = ?", contactID, orgID)
-	if !a.HasPermission(userID, models.ResourceContacts, models.ActionRead, orgID) {
-		query = query.Where("assigned_user_id = ?", userID)
-	}
+	query := a.scopeContacts(a.DB.Where("id = ? AND organization_id = ?", contactID, orgID), userID, orgID)
 	if err := query.First(&contact).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
 	}
@@ -616,12 +604,7 @@ func (a *App) SendMessage(r *fastglue.Request) error {
 	if message.IsReply && message.ReplyToMessageID != nil && replyToMessage != nil {
 		replyToID := message.ReplyToMessageID.String()
 		response.ReplyToMessageID = &replyToID
-		response.ReplyToMessage = &ReplyPreview{
-			ID:          replyToMessage.ID.String(),
-			Content:     map[string]string{"body": replyToMessage.Content},
-			MessageType: replyToMessage.MessageType,
-			Direction:   replyToMessage.Direction,
-		}
+		response.ReplyToMessage = replyPreview(replyToMessage)
 	}
 
 	return r.SendEnvelope(response)
@@ -716,12 +699,9 @@ func (a *App) SendMediaMessage(r *fastglue.Request) error {
 		mimeType = "application/octet-stream"
 	}
 
-	// Get contact (users without full read permission can only message their assigned contacts)
+	// Get contact (users without chat.all:read can only message their own and their teams' conversations)
 	var contact models.Contact
-	query := a.DB.Where("id = ? AND organization_id = ?", contactID, orgID)
-	if !a.HasPermission(userID, models.ResourceContacts, models.ActionRead, orgID) {
-		query = query.Where("assigned_user_id = ?", userID)
-	}
+	query := a.scopeContacts(a.DB.Where("id = ? AND organization_id = ?", contactID, orgID), userID, orgID)
 	if err := query.First(&contact).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
 	}
@@ -788,6 +768,17 @@ func (a *App) SendMediaMessage(r *fastglue.Request) error {
 
 // saveMediaLocally saves media data to local storage and returns the relative path
 func (a *App) saveMediaLocally(data []byte, mimeType, filename string) (string, error) {
+	return a.saveMediaIn("", data, mimeType, filename)
+}
+
+// saveOrgMediaLocally saves a file uploaded by an organization in its own
+// directory, so references to it can be checked with ownsMediaPath
+func (a *App) saveOrgMediaLocally(orgID uuid.UUID, data []byte, mimeType, filename string) (string, error) {
+	return a.saveMediaIn(orgMediaDir(orgID), data, mimeType, filename)
+}
+
+// saveMediaIn saves media data under dir in local storage and returns the relative path
+func (a *App) saveMediaIn(dir string, data []byte, mimeType, filename string) (string, error) {
 	// Determine subdirectory based on MIME type
 	var subdir string
 	switch {
@@ -800,6 +791,7 @@ func (a *App) saveMediaLocally(data []byte, mimeType, filename string) (string,
 	default:
 		subdir = "documents"
 	}
+	subdir = filepath.Join(dir, subdir)
 
 	// Ensure directory exists
 	if err := a.ensureMediaDir(subdir); err != nil {
@@ -862,12 +854,9 @@ func (a *App) SendReaction(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
 	}
 
-	// Get contact (users without full read permission can only react to messages in their assigned contacts)
+	// Get contact (users without chat.all:read can only react in their own and their teams' conversations)
 	var contact models.Contact
-	query := a.DB.Where("id = ? AND organization_id = ?", contactID, orgID)
-	if !a.HasPermission(userID, models.ResourceContacts, models.ActionRead, orgID) {
-		query = query.Where("assigned_user_id = ?", userID)
-	}
+	query := a.scopeContacts(a.DB.Where("id = ? AND organization_id = ?", contactID, orgID), userID, orgID)
 	if err := query.First(&contact).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
 	}
@@ -953,7 +942,7 @@ func (a *App) SendReaction(r *fastglue.Request) error {
 
 	// Broadcast via WebSocket
 	if a.WSHub != nil {
-		a.WSHub.BroadcastToOrg(orgID, websocket.WSMessage{
+		a.WSHub.BroadcastToConversation(orgID, contact.ID, websocket.WSMessage{
 			Type: "reaction_update",
 			Payload: map[string]any{
 				"message_id": message.ID.String(),
@@ -1095,12 +1084,9 @@ func (a *App) GetContactSessionData(r *fastglue.Request) error {
 		return nil
 	}
 
-	// Verify contact belongs to org (users without full read permission can only access assigned contacts)
+	// Verify contact belongs to org and is visible to the user
 	var contact models.Contact
-	query := a.DB.Where("id = ? AND organization_id = ?", contactID, orgID)
-	if !a.HasPermission(userID, models.ResourceContacts, models.ActionRead, orgID) {
-		query = query.Where("assigned_user_id = ?", userID)
-	}
+	query := a.scopeContacts(a.DB.Where("id = ? AND organization_id = ?", contactID, orgID), userID, orgID)
 	if err := query.First(&contact).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
 	}
@@ -1124,8 +1110,10 @@ func (a *App) GetContactSessionData(r *fastglue.Request) error {
 		// Get the flow to retrieve panel config
 		// First try current_flow_id, then fall back to _flow_id in session_data
 		var flowID *uuid.UUID
+		flowVersion := 0
 		if session.CurrentFlowID != nil {
 			flowID = session.CurrentFlowID
+			flowVersion = session.CurrentFlowVersion
 		} else if flowIDStr, ok := session.SessionData["_flow_id"].(string); ok {
 			if parsedID, err := uuid.Parse(flowIDStr); err == nil {
 				flowID = &parsedID
@@ -1134,7 +1122,7 @@ func (a *App) GetContactSessionData(r *fastglue.Request) error {
 
 		if flowID != nil {
 			// Use cached flow to avoid DB query
-			flow, err := a.getChatbotFlowByIDCached(orgID, *flowID)
+			flow, err := a.loadChatbotFlow(&session, *flowID, flowVersion)
 			if err == nil && flow != nil {
 				response.FlowName = flow.Name
 				response.FlowID = flowID
@@ -1349,6 +1337,7 @@ type UpdateContactRequest struct {
 	WhatsAppAccount *string         `json:"whatsapp_account"`
 	Tags            []string        `json:"tags"`
 	Metadata        *map[string]any `json:"metadata"`
+	Language        *string         `json:"language"`
 	AssignedUserID  *uuid.UUID      `json:"assigned_user_id"`
 }
 
@@ -1399,6 +1388,9 @@ func (a *App) UpdateContact(r *fastglue.Request) error {
 	if req.Metadata != nil {
 		updates["metadata"] = models.JSONB(*req.Metadata)
 	}
+	if req.Language != nil {
+		updates["language"] = templateutil.NormalizeLanguage(*req.Language)
+	}
 	if req.AssignedUserID != nil {
 		// Verify user exists in same org
 		var user models.User
@@ -1490,10 +1482,15 @@ func (a *App) buildContactResponse(contact *models.Contact, orgID uuid.UUID) Con
 		Status:             "active",
 		Tags:               tags,
 		Metadata:           contact.Metadata,
+		Language:           contact.Language,
 		LastMessageAt:      contact.LastMessageAt,
 		LastMessagePreview: contact.LastMessagePreview,
 		UnreadCount:        int(unreadCount),
 		AssignedUserID:     contact.AssignedUserID,
+		ReferralSourceType: contact.ReferralSourceType,
+		ReferralSourceID:   contact.ReferralSourceID,
+		ReferralSourceURL:  contact.ReferralSourceURL,
+		ReferredAt:         contact.ReferredAt,
 		CreatedAt:          contact.CreatedAt,
 		UpdatedAt:          contact.UpdatedAt,
 	}
@@ -1420,6 +1420,60 @@ func TestApp_ListContacts_Page2(t *testing.T) {
 	assert.Equal(t, 2, resp.Data.Limit)
 }
 
+func TestApp_ListContacts_ScopedToTeams(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	agent := createTestAgent(t, app, org.ID)
+	otherAgent := createTestAgent(t, app, org.ID)
+	team := createTestTeam(t, app, org.ID, agent.ID)
+	otherTeam := createTestTeam(t, app, org.ID, otherAgent.ID)
+
+	assigned := testutil.CreateTestContact(t, app.DB, org.ID)
+	require.NoError(t, app.DB.Model(assigned).Update("assigned_user_id", agent.ID).Error)
+
+	inTeamQueue := testutil.CreateTestContact(t, app.DB, org.ID)
+	queued := createTestTransfer(t, app, org.ID, inTeamQueue.ID, account.Name, models.TransferStatusActive, nil)
+	require.NoError(t, app.DB.Model(queued).Update("team_id", team.ID).Error)
+
+	inOtherQueue := testutil.CreateTestContact(t, app.DB, org.ID)
+	other := createTestTransfer(t, app, org.ID, inOtherQueue.ID, account.Name, models.TransferStatusActive, nil)
+	require.NoError(t, app.DB.Model(other).Update("team_id", otherTeam.ID).Error)
+
+	otherAssigned := testutil.CreateTestContact(t, app.DB, org.ID)
+	require.NoError(t, app.DB.Model(otherAssigned).Update("assigned_user_id", otherAgent.ID).Error)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, agent.ID)
+
+	err := app.ListContacts(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			Contacts []handlers.ContactResponse `json:"contacts"`
+			Total    int64                      `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, int64(2), resp.Data.Total)
+	ids := make([]uuid.UUID, 0, len(resp.Data.Contacts))
+	for _, c := range resp.Data.Contacts {
+		ids = append(ids, c.ID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{assigned.ID, inTeamQueue.ID}, ids)
+
+	// Contacts outside the agent's scope are not found
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, agent.ID)
+	testutil.SetPathParam(req, "id", otherAssigned.ID.String())
+	require.NoError(t, app.GetContact(req))
+	assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+}
+
 // --- GetContact additional tests ---
 
 func TestApp_GetContact_WithAssignedUser(t *testing.T) {
@@ -8,25 +8,47 @@ import (
 	"github.com/shridarpatil/whatomate/internal/websocket"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
 )
 
+// maxNoteAttachments limits the number of files attached to a single note
+const maxNoteAttachments = 10
+
 // ConversationNoteRequest represents the request body for creating/updating a note.
+// ParentID and AttachmentIDs are only used when creating a note.
 type ConversationNoteRequest struct {
-	Content string `json:"content"`
+	Content       string      `json:"content"`
+	ParentID      *uuid.UUID  `json:"parent_id"`
+	AttachmentIDs []uuid.UUID `json:"attachment_ids"`
 }
 
 // ConversationNoteResponse represents the API response for a conversation note.
 type ConversationNoteResponse struct {
-	ID            uuid.UUID `json:"id"`
-	ContactID     uuid.UUID `json:"contact_id"`
-	CreatedByID   uuid.UUID `json:"created_by_id"`
-	CreatedByName string    `json:"created_by_name"`
-	Content       string    `json:"content"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uuid.UUID                `json:"id"`
+	ContactID     uuid.UUID                `json:"contact_id"`
+	ParentID      *uuid.UUID               `json:"parent_id,omitempty"`
+	CreatedByID   uuid.UUID                `json:"created_by_id"`
+	CreatedByName string                   `json:"created_by_name"`
+	Content       string                   `json:"content"`
+	Mentions      []NoteMention            `json:"mentions"`
+	Attachments   []NoteAttachmentResponse `json:"attachments"`
+	ReplyCount    int64                    `json:"reply_count"`
+	CreatedAt     time.Time                `json:"created_at"`
+	UpdatedAt     time.Time                `json:"updated_at"`
+}
+
+// NoteAttachmentResponse represents a file attached to a conversation note.
+type NoteAttachmentResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	NoteID    *uuid.UUID `json:"note_id,omitempty"`
+	FileName  string     `json:"file_name"`
+	MimeType  string     `json:"mime_type"`
+	Size      int64      `json:"size"`
+	CreatedAt time.Time  `json:"created_at"`
 }
 
 // ListConversationNotes returns paginated notes for a contact (latest at bottom).
+// Replies are not included; they are listed per thread with ListConversationNoteReplies.
 func (a *App) ListConversationNotes(r *fastglue.Request) error {
 	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
@@ -42,10 +64,14 @@ func (a *App) ListConversationNotes(r *fastglue.Request) error {
 		return nil
 	}
 
+	if !a.canAccessContact(contactID, userID, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
+	}
+
 	pg := parsePaginationWithDefaults(r, 30, 100)
 	limit := pg.Limit
 
-	query := a.DB.Where("organization_id = ? AND contact_id = ?", orgID, contactID)
+	query := a.DB.Where("organization_id = ? AND contact_id = ? AND parent_id IS NULL", orgID, contactID)
 
 	// Get total count
 	var total int64
@@ -65,7 +91,7 @@ func (a *App) ListConversationNotes(r *fastglue.Request) error {
 
 	// Fetch DESC then reverse to get chronological order (oldest first, latest last)
 	var notes []models.ConversationNote
-	if err := query.Preload("CreatedBy").
+	if err := query.Preload("CreatedBy").Preload("Attachments").
 		Order("created_at DESC").
 		Limit(limit).
 		Find(&notes).Error; err != nil {
@@ -79,9 +105,11 @@ func (a *App) ListConversationNotes(r *fastglue.Request) error {
 		notes[i], notes[j] = notes[j], notes[i]
 	}
 
+	replyCounts := a.noteReplyCounts(notes)
 	result := make([]ConversationNoteResponse, len(notes))
 	for i, n := range notes {
 		result[i] = noteToResponse(n)
+		result[i].ReplyCount = replyCounts[n.ID]
 	}
 
 	return r.SendEnvelope(map[string]any{
@@ -91,7 +119,54 @@ func (a *App) ListConversationNotes(r *fastglue.Request) error {
 	})
 }
 
-// CreateConversationNote creates a new note on a contact.
+// ListConversationNoteReplies returns the replies in the thread of a note, oldest first.
+func (a *App) ListConversationNoteReplies(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionRead); err != nil {
+		return nil
+	}
+
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil
+	}
+
+	noteID, err := parsePathUUID(r, "note_id", "note")
+	if err != nil {
+		return nil
+	}
+
+	if !a.canAccessContact(contactID, userID, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
+	}
+
+	var replies []models.ConversationNote
+	if err := a.DB.Where("organization_id = ? AND contact_id = ? AND parent_id = ?", orgID, contactID, noteID).
+		Preload("CreatedBy").Preload("Attachments").
+		Order("created_at ASC").
+		Find(&replies).Error; err != nil {
+		a.Log.Error("Failed to list note replies", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError,
+			"Failed to list replies", nil, "")
+	}
+
+	result := make([]ConversationNoteResponse, len(replies))
+	for i, n := range replies {
+		result[i] = noteToResponse(n)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"replies": result,
+	})
+}
+
+// CreateConversationNote creates a new note on a contact, or a reply when
+// parent_id is set. Mentioned users and the author of the note replied to
+// are notified.
 func (a *App) CreateConversationNote(r *fastglue.Request) error {
 	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
@@ -112,27 +187,77 @@ func (a *App) CreateConversationNote(r *fastglue.Request) error {
 		return nil
 	}
 
-	if req.Content == "" {
+	if req.Content == "" && len(req.AttachmentIDs) == 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "content is required", nil, "")
 	}
+	if len(req.AttachmentIDs) > maxNoteAttachments {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Too many attachments", nil, "")
+	}
+
+	if !a.canAccessContact(contactID, userID, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
+	}
+
+	// Replies to a reply join the thread of the top-level note
+	var parent *models.ConversationNote
+	if req.ParentID != nil {
+		parent = &models.ConversationNote{}
+		if err := a.DB.Where("id = ? AND organization_id = ? AND contact_id = ?", *req.ParentID, orgID, contactID).
+			First(parent).Error; err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Parent note not found", nil, "")
+		}
+		if parent.ParentID != nil {
+			if err := a.DB.Where("id = ?", *parent.ParentID).First(parent).Error; err != nil {
+				return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Parent note not found", nil, "")
+			}
+		}
+	}
 
 	note := models.ConversationNote{
+		BaseModel:      models.BaseModel{ID: uuid.New()},
 		OrganizationID: orgID,
 		ContactID:      contactID,
 		CreatedByID:    userID,
 		Content:        req.Content,
 	}
+	if parent != nil {
+		note.ParentID = &parent.ID
+	}
 
-	if err := a.DB.Create(&note).Error; err != nil {
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+		if len(req.AttachmentIDs) == 0 {
+			return nil
+		}
+		// Only the uploader's unlinked attachments for this contact can be attached
+		result := tx.Model(&models.ConversationNoteAttachment{}).
+			Where("id IN ? AND organization_id = ? AND contact_id = ? AND uploaded_by_id = ? AND note_id IS NULL",
+				req.AttachmentIDs, orgID, contactID, userID).
+			Update("note_id", note.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(req.AttachmentIDs)) {
+			return errInvalidNoteAttachments
+		}
+		return nil
+	})
+	if err == errInvalidNoteAttachments {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid attachments", nil, "")
+	}
+	if err != nil {
 		a.Log.Error("Failed to create conversation note", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError,
 			"Failed to create note", nil, "")
 	}
 
-	// Load the creator relation for the response
+	// Load the creator and attachments for the response
 	var user models.User
 	a.DB.First(&user, "id = ?", userID)
 	note.CreatedBy = &user
+	a.DB.Where("note_id = ?", note.ID).Order("created_at ASC").Find(&note.Attachments)
 
 	resp := noteToResponse(note)
 
@@ -144,6 +269,8 @@ func (a *App) CreateConversationNote(r *fastglue.Request) error {
 		})
 	}
 
+	a.notifyNoteActivity(&note, &user, resp.Mentions, parent)
+
 	return r.SendEnvelope(resp)
 }
 
@@ -187,8 +314,9 @@ func (a *App) UpdateConversationNote(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "content is required", nil, "")
 	}
 
+	previous := note.Content
 	note.Content = req.Content
-	if err := a.DB.Save(note).Error; err != nil {
+	if err := a.DB.Omit("Attachments").Save(note).Error; err != nil {
 		a.Log.Error("Failed to update conversation note", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError,
 			"Failed to update note", nil, "")
@@ -198,8 +326,12 @@ func (a *App) UpdateConversationNote(r *fastglue.Request) error {
 	var user models.User
 	a.DB.First(&user, "id = ?", note.CreatedByID)
 	note.CreatedBy = &user
+	a.DB.Where("note_id = ?", note.ID).Order("created_at ASC").Find(&note.Attachments)
 
 	resp := noteToResponse(*note)
+	if note.ParentID == nil {
+		resp.ReplyCount = a.noteReplyCounts([]models.ConversationNote{*note})[note.ID]
+	}
 
 	// Broadcast via WebSocket
 	if a.WSHub != nil {
@@ -209,10 +341,16 @@ func (a *App) UpdateConversationNote(r *fastglue.Request) error {
 		})
 	}
 
+	// Only users mentioned by this edit are notified
+	if added := newNoteMentions(previous, note.Content); len(added) > 0 {
+		a.notifyNoteActivity(note, &user, added, nil)
+	}
+
 	return r.SendEnvelope(resp)
 }
 
-// DeleteConversationNote deletes a note (creator only).
+// DeleteConversationNote deletes a note with its replies and attachments (creator only).
+// A thread with replies from other users can only be deleted with chat:delete.
 func (a *App) DeleteConversationNote(r *fastglue.Request) error {
 	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
@@ -245,12 +383,34 @@ func (a *App) DeleteConversationNote(r *fastglue.Request) error {
 
 	contactID := note.ContactID
 
-	if err := a.DB.Delete(note).Error; err != nil {
+	// Deleting a thread removes its replies too
+	var othersReplies int64
+	a.DB.Model(&models.ConversationNote{}).Where("parent_id = ? AND created_by_id != ?", note.ID, userID).Count(&othersReplies)
+	if othersReplies > 0 && !a.HasPermission(userID, models.ResourceChat, models.ActionDelete, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "This thread has replies from other users", nil, "")
+	}
+
+	noteIDs := []uuid.UUID{note.ID}
+	var replyIDs []uuid.UUID
+	a.DB.Model(&models.ConversationNote{}).Where("parent_id = ?", note.ID).Pluck("id", &replyIDs)
+	noteIDs = append(noteIDs, replyIDs...)
+
+	var attachments []models.ConversationNoteAttachment
+	a.DB.Where("note_id IN ?", noteIDs).Find(&attachments)
+
+	if err := a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("note_id IN ?", noteIDs).Delete(&models.ConversationNoteAttachment{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", noteIDs).Delete(&models.ConversationNote{}).Error
+	}); err != nil {
 		a.Log.Error("Failed to delete conversation note", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError,
 			"Failed to delete note", nil, "")
 	}
 
+	a.removeNoteAttachmentFiles(attachments)
+
 	// Broadcast via WebSocket
 	if a.WSHub != nil {
 		a.WSHub.BroadcastToContact(orgID, contactID, websocket.WSMessage{
@@ -258,6 +418,7 @@ func (a *App) DeleteConversationNote(r *fastglue.Request) error {
 			Payload: map[string]any{
 				"id":         noteID,
 				"contact_id": contactID,
+				"parent_id":  note.ParentID,
 			},
 		})
 	}
@@ -270,13 +431,49 @@ func noteToResponse(n models.ConversationNote) ConversationNoteResponse {
 	if n.CreatedBy != nil {
 		createdByName = n.CreatedBy.FullName
 	}
+	mentions := parseNoteMentions(n.Content)
+	if mentions == nil {
+		mentions = []NoteMention{}
+	}
+	attachments := make([]NoteAttachmentResponse, len(n.Attachments))
+	for i, att := range n.Attachments {
+		attachments[i] = noteAttachmentToResponse(att)
+	}
 	return ConversationNoteResponse{
 		ID:            n.ID,
 		ContactID:     n.ContactID,
+		ParentID:      n.ParentID,
 		CreatedByID:   n.CreatedByID,
 		CreatedByName: createdByName,
 		Content:       n.Content,
+		Mentions:      mentions,
+		Attachments:   attachments,
 		CreatedAt:     n.CreatedAt,
 		UpdatedAt:     n.UpdatedAt,
 	}
 }
+
+// noteReplyCounts returns the number of replies in the threads of the given notes
+func (a *App) noteReplyCounts(notes []models.ConversationNote) map[uuid.UUID]int64 {
+	counts := make(map[uuid.UUID]int64, len(notes))
+	if len(notes) == 0 {
+		return counts
+	}
+	ids := make([]uuid.UUID, len(notes))
+	for i, n := range notes {
+		ids[i] = n.ID
+	}
+	var rows []struct {
+		ParentID uuid.UUID
+		Count    int64
+	}
+	a.DB.Model(&models.ConversationNote{}).
+		Select("parent_id, COUNT(*) AS count").
+		Where("parent_id IN ?", ids).
+		Group("parent_id").
+		Scan(&rows)
+	for _, row := range rows {
+		counts[row.ParentID] = row.Count
+	}
+	return counts
+}
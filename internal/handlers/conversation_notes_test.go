@@ -0,0 +1,210 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+func createNote(t *testing.T, app *handlers.App, orgID, userID, contactID uuid.UUID, body map[string]any) handlers.ConversationNoteResponse {
+	t.Helper()
+
+	req := testutil.NewJSONRequest(t, body)
+	testutil.SetAuthContext(req, orgID, userID)
+	testutil.SetPathParam(req, "id", contactID.String())
+	require.NoError(t, app.CreateConversationNote(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req), string(testutil.GetResponseBody(req)))
+
+	var note handlers.ConversationNoteResponse
+	testutil.ParseEnvelopeResponse(t, req, &note)
+	return note
+}
+
+func TestApp_CreateConversationNote_MentionNotifiesUser(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	author := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	mentioned := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	note := createNote(t, app, org.ID, author.ID, contact.ID, map[string]any{
+		"content": "Can you take this, @[Teammate](user:" + mentioned.ID.String() + ")?",
+	})
+	require.Len(t, note.Mentions, 1)
+	assert.Equal(t, mentioned.ID, note.Mentions[0].ID)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, mentioned.ID)
+	require.NoError(t, app.ListNotifications(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var inbox struct {
+		Notifications []handlers.NotificationResponse `json:"notifications"`
+		UnreadCount   int64                           `json:"unread_count"`
+	}
+	testutil.ParseEnvelopeResponse(t, req, &inbox)
+	require.Len(t, inbox.Notifications, 1)
+	assert.Equal(t, int64(1), inbox.UnreadCount)
+	assert.Equal(t, models.NotificationTypeMention, inbox.Notifications[0].Type)
+	assert.Equal(t, "Can you take this, @Teammate?", inbox.Notifications[0].Body)
+	require.NotNil(t, inbox.Notifications[0].NoteID)
+	assert.Equal(t, note.ID, *inbox.Notifications[0].NoteID)
+
+	// The author is not notified about their own note
+	var authorCount int64
+	app.DB.Model(&models.UserNotification{}).Where("user_id = ?", author.ID).Count(&authorCount)
+	assert.Zero(t, authorCount)
+
+	req = testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, mentioned.ID)
+	testutil.SetPathParam(req, "id", inbox.Notifications[0].ID.String())
+	require.NoError(t, app.MarkNotificationRead(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var unread int64
+	app.DB.Model(&models.UserNotification{}).Where("user_id = ? AND read_at IS NULL", mentioned.ID).Count(&unread)
+	assert.Zero(t, unread)
+}
+
+func TestApp_CreateConversationNote_ReplyThread(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	author := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	replier := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	parent := createNote(t, app, org.ID, author.ID, contact.ID, map[string]any{"content": "Customer asked for a refund"})
+	reply := createNote(t, app, org.ID, replier.ID, contact.ID, map[string]any{"content": "Approved", "parent_id": parent.ID})
+	require.NotNil(t, reply.ParentID)
+	assert.Equal(t, parent.ID, *reply.ParentID)
+
+	// Replying to a reply stays in the top-level thread
+	nested := createNote(t, app, org.ID, author.ID, contact.ID, map[string]any{"content": "Thanks", "parent_id": reply.ID})
+	require.NotNil(t, nested.ParentID)
+	assert.Equal(t, parent.ID, *nested.ParentID)
+
+	var notification models.UserNotification
+	require.NoError(t, app.DB.Where("user_id = ?", author.ID).First(&notification).Error)
+	assert.Equal(t, models.NotificationTypeNoteReply, notification.Type)
+
+	// Only top-level notes are listed, with their reply counts
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, author.ID)
+	testutil.SetPathParam(req, "id", contact.ID.String())
+	require.NoError(t, app.ListConversationNotes(req))
+	var list struct {
+		Notes []handlers.ConversationNoteResponse `json:"notes"`
+	}
+	testutil.ParseEnvelopeResponse(t, req, &list)
+	require.Len(t, list.Notes, 1)
+	assert.Equal(t, int64(2), list.Notes[0].ReplyCount)
+
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, author.ID)
+	testutil.SetPathParam(req, "id", contact.ID.String())
+	testutil.SetPathParam(req, "note_id", parent.ID.String())
+	require.NoError(t, app.ListConversationNoteReplies(req))
+	var thread struct {
+		Replies []handlers.ConversationNoteResponse `json:"replies"`
+	}
+	testutil.ParseEnvelopeResponse(t, req, &thread)
+	require.Len(t, thread.Replies, 2)
+	assert.Equal(t, reply.ID, thread.Replies[0].ID)
+
+	// Deleting the thread removes its replies
+	req = testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, author.ID)
+	testutil.SetPathParam(req, "id", contact.ID.String())
+	testutil.SetPathParam(req, "note_id", parent.ID.String())
+	require.NoError(t, app.DeleteConversationNote(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var remaining int64
+	app.DB.Model(&models.ConversationNote{}).Where("contact_id = ?", contact.ID).Count(&remaining)
+	assert.Zero(t, remaining)
+}
+
+func TestApp_DeleteConversationNote_OthersRepliesRequireDeletePermission(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	admin := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	agent := createTestAgent(t, app, org.ID)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	parent := models.ConversationNote{OrganizationID: org.ID, ContactID: contact.ID, CreatedByID: agent.ID, Content: "Customer asked for a refund"}
+	require.NoError(t, app.DB.Create(&parent).Error)
+	reply := models.ConversationNote{OrganizationID: org.ID, ContactID: contact.ID, ParentID: &parent.ID, CreatedByID: admin.ID, Content: "Approved"}
+	require.NoError(t, app.DB.Create(&reply).Error)
+
+	deleteNote := func(userID uuid.UUID) *fastglue.Request {
+		req := testutil.NewJSONRequest(t, nil)
+		testutil.SetAuthContext(req, org.ID, userID)
+		testutil.SetPathParam(req, "id", contact.ID.String())
+		testutil.SetPathParam(req, "note_id", parent.ID.String())
+		require.NoError(t, app.DeleteConversationNote(req))
+		return req
+	}
+
+	// The agent lacks chat:delete, so the admin's reply keeps the thread
+	req := deleteNote(agent.ID)
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusForbidden, "This thread has replies from other users")
+
+	var remaining int64
+	app.DB.Model(&models.ConversationNote{}).Where("contact_id = ?", contact.ID).Count(&remaining)
+	assert.Equal(t, int64(2), remaining)
+
+	// Once only the agent's own replies are left the thread can be deleted
+	require.NoError(t, app.DB.Delete(&reply).Error)
+	req = deleteNote(agent.ID)
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+}
+
+func TestApp_CreateConversationNote_RejectsForeignAttachment(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	other := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	attachment := models.ConversationNoteAttachment{
+		OrganizationID: org.ID,
+		ContactID:      contact.ID,
+		UploadedByID:   other.ID,
+		FileName:       "invoice.pdf",
+		MimeType:       "application/pdf",
+		Path:           "documents/invoice.pdf",
+	}
+	require.NoError(t, app.DB.Create(&attachment).Error)
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"content":        "See attached",
+		"attachment_ids": []uuid.UUID{attachment.ID},
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", contact.ID.String())
+	require.NoError(t, app.CreateConversationNote(req))
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "Invalid attachments")
+
+	var notes int64
+	app.DB.Model(&models.ConversationNote{}).Where("contact_id = ?", contact.ID).Count(&notes)
+	assert.Zero(t, notes, "the note is rolled back")
+}
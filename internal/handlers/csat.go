@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm/clause"
+)
+
+// csatReplyPrefix starts the button IDs and flow tokens of satisfaction surveys
+const csatReplyPrefix = "csat:"
+
+// csatServiceWindow is how long after the customer's last message a survey,
+// being a free-form message, can still be sent
+const csatServiceWindow = 24 * time.Hour
+
+// defaultCSATMessage is the survey question when none is configured
+const defaultCSATMessage = "How would you rate the help you received?"
+
+// csatRatingTitles are the button titles of the CSAT scale, best first
+var csatRatingTitles = map[int]string{
+	5: "5 - Excellent",
+	4: "4 - Good",
+	3: "3 - Okay",
+	2: "2 - Poor",
+	1: "1 - Very poor",
+}
+
+// CSATSurveyResponse represents a satisfaction survey for API response
+type CSATSurveyResponse struct {
+	ID              string                  `json:"id"`
+	TransferID      string                  `json:"transfer_id"`
+	ContactID       string                  `json:"contact_id"`
+	ContactName     string                  `json:"contact_name"`
+	PhoneNumber     string                  `json:"phone_number"`
+	AgentID         *string                 `json:"agent_id"`
+	AgentName       string                  `json:"agent_name"`
+	WhatsAppAccount string                  `json:"whatsapp_account"`
+	Scale           models.CSATScale        `json:"scale"`
+	Channel         models.CSATChannel      `json:"channel"`
+	Status          models.CSATSurveyStatus `json:"status"`
+	Score           *int                    `json:"score"`
+	Comment         string                  `json:"comment"`
+	SendAt          time.Time               `json:"send_at"`
+	SentAt          *time.Time              `json:"sent_at"`
+	AnsweredAt      *time.Time              `json:"answered_at"`
+}
+
+// csatScaleRange returns the lowest and highest score of a scale
+func csatScaleRange(scale models.CSATScale) (lowest, highest int) {
+	if scale == models.CSATScaleNPS {
+		return 0, 10
+	}
+	return 1, 5
+}
+
+// validateCSATConfig checks the satisfaction survey settings
+func validateCSATConfig(cfg models.CSATConfig) error {
+	switch cfg.Scale {
+	case models.CSATScaleCSAT, models.CSATScaleNPS:
+	default:
+		return fmt.Errorf("scale must be %q or %q", models.CSATScaleCSAT, models.CSATScaleNPS)
+	}
+	switch cfg.Channel {
+	case models.CSATChannelButtons:
+		// Interactive messages hold at most 10 options
+		if cfg.Scale == models.CSATScaleNPS {
+			return errors.New("nps surveys need the flow channel, 11 options don't fit interactive buttons")
+		}
+	case models.CSATChannelFlow:
+		if cfg.Enabled && strings.TrimSpace(cfg.FlowID) == "" {
+			return errors.New("flow id is required for the flow channel")
+		}
+	default:
+		return fmt.Errorf("channel must be %q or %q", models.CSATChannelButtons, models.CSATChannelFlow)
+	}
+	if cfg.DelayMinutes < 0 {
+		return errors.New("delay minutes must not be negative")
+	}
+	if cfg.SuppressionDays < 0 {
+		return errors.New("suppression days must not be negative")
+	}
+	return nil
+}
+
+// csatButtons returns the reply buttons of a CSAT survey, best rating first
+func csatButtons(surveyID uuid.UUID) []whatsapp.Button {
+	buttons := make([]whatsapp.Button, 0, len(csatRatingTitles))
+	for score := 5; score >= 1; score-- {
+		buttons = append(buttons, whatsapp.Button{
+			ID:    fmt.Sprintf("%s%s:%d", csatReplyPrefix, surveyID, score),
+			Title: csatRatingTitles[score],
+		})
+	}
+	return buttons
+}
+
+// parseCSATReply extracts the survey and score from a survey button ID.
+// ok is false for buttons that don't belong to a survey.
+func parseCSATReply(buttonID string) (surveyID uuid.UUID, score int, ok bool) {
+	rest, found := strings.CutPrefix(buttonID, csatReplyPrefix)
+	if !found {
+		return uuid.Nil, 0, false
+	}
+	idPart, scorePart, found := strings.Cut(rest, ":")
+	if !found {
+		return uuid.Nil, 0, false
+	}
+	surveyID, err := uuid.Parse(idPart)
+	if err != nil {
+		return uuid.Nil, 0, false
+	}
+	score, err = strconv.Atoi(scorePart)
+	if err != nil {
+		return uuid.Nil, 0, false
+	}
+	return surveyID, score, true
+}
+
+// parseCSATFlowResponse extracts the survey, score and comment from a survey
+// flow response. ok is false for responses of other flows.
+func parseCSATFlowResponse(data map[string]interface{}) (surveyID uuid.UUID, score int, comment string, ok bool) {
+	token, _ := data["flow_token"].(string)
+	idPart, found := strings.CutPrefix(token, csatReplyPrefix)
+	if !found {
+		return uuid.Nil, 0, "", false
+	}
+	surveyID, err := uuid.Parse(idPart)
+	if err != nil {
+		return uuid.Nil, 0, "", false
+	}
+
+	switch v := data["score"].(type) {
+	case float64:
+		score = int(v)
+	case string:
+		if score, err = strconv.Atoi(strings.TrimSpace(v)); err != nil {
+			return uuid.Nil, 0, "", false
+		}
+	default:
+		return uuid.Nil, 0, "", false
+	}
+	comment, _ = data["comment"].(string)
+	return surveyID, score, strings.TrimSpace(comment), true
+}
+
+// scheduleCSATSurvey schedules a satisfaction survey for a closed transfer
+// when surveys are enabled, the transfer had an agent and the contact wasn't
+// surveyed within the suppression window
+func (a *App) scheduleCSATSurvey(transfer *models.AgentTransfer) {
+	if transfer.AgentID == nil {
+		return
+	}
+	settings, err := a.getChatbotSettingsCached(transfer.OrganizationID, transfer.WhatsAppAccount)
+	if err != nil || settings == nil || !settings.CSAT.Enabled {
+		return
+	}
+	now := time.Now()
+
+	if settings.CSAT.SuppressionDays > 0 {
+		var recent int64
+		a.DB.Model(&models.CSATSurvey{}).
+			Where("organization_id = ? AND contact_id = ? AND status IN ? AND created_at > ?",
+				transfer.OrganizationID, transfer.ContactID,
+				[]models.CSATSurveyStatus{models.CSATSurveyStatusScheduled, models.CSATSurveyStatusSent, models.CSATSurveyStatusAnswered},
+				now.AddDate(0, 0, -settings.CSAT.SuppressionDays)).
+			Count(&recent)
+		if recent > 0 {
+			return
+		}
+	}
+
+	survey := models.CSATSurvey{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  transfer.OrganizationID,
+		TransferID:      transfer.ID,
+		ContactID:       transfer.ContactID,
+		AgentID:         transfer.AgentID,
+		TeamID:          transfer.TeamID,
+		WhatsAppAccount: transfer.WhatsAppAccount,
+		Scale:           settings.CSAT.Scale,
+		Channel:         settings.CSAT.Channel,
+		Status:          models.CSATSurveyStatusScheduled,
+		SendAt:          now.Add(time.Duration(settings.CSAT.DelayMinutes) * time.Minute),
+	}
+	// One survey per transfer
+	if err := a.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&survey).Error; err != nil {
+		a.Log.Error("Failed to schedule CSAT survey", "error", err, "transfer_id", transfer.ID)
+		return
+	}
+
+	a.Log.Info("CSAT survey scheduled", "transfer_id", transfer.ID, "send_at", survey.SendAt)
+}
+
+// sendCSATSurvey sends a due survey to the customer. Surveys of contacts that
+// were transferred again in the meantime are cancelled, and surveys whose
+// customer service window has closed expire.
+func (a *App) sendCSATSurvey(survey *models.CSATSurvey) {
+	if a.hasActiveAgentTransfer(survey.OrganizationID, survey.ContactID) {
+		a.DB.Model(&models.CSATSurvey{}).
+			Where("id = ? AND status = ?", survey.ID, models.CSATSurveyStatusScheduled).
+			Update("status", models.CSATSurveyStatusCancelled)
+		return
+	}
+
+	if !a.inCSATServiceWindow(survey.ContactID, time.Now()) {
+		a.DB.Model(&models.CSATSurvey{}).
+			Where("id = ? AND status = ?", survey.ID, models.CSATSurveyStatusScheduled).
+			Update("status", models.CSATSurveyStatusExpired)
+		a.Log.Info("CSAT survey expired, customer service window closed", "survey_id", survey.ID)
+		return
+	}
+
+	// Claim the survey so it is sent once
+	now := time.Now()
+	result := a.DB.Model(&models.CSATSurvey{}).
+		Where("id = ? AND status = ?", survey.ID, models.CSATSurveyStatusScheduled).
+		Updates(map[string]interface{}{
+			"status":  models.CSATSurveyStatusSent,
+			"sent_at": now,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+
+	if err := a.deliverCSATSurvey(survey); err != nil {
+		a.Log.Error("Failed to send CSAT survey", "error", err, "survey_id", survey.ID)
+		a.DB.Model(&models.CSATSurvey{}).Where("id = ?", survey.ID).Update("status", models.CSATSurveyStatusFailed)
+		return
+	}
+
+	a.Log.Info("CSAT survey sent", "survey_id", survey.ID, "transfer_id", survey.TransferID)
+}
+
+// inCSATServiceWindow reports whether the contact's last incoming message is
+// recent enough for WhatsApp to accept a free-form message
+func (a *App) inCSATServiceWindow(contactID uuid.UUID, now time.Time) bool {
+	var last models.Message
+	if err := a.DB.Select("created_at").
+		Where("contact_id = ? AND direction = ?", contactID, models.DirectionIncoming).
+		Order("created_at DESC").
+		First(&last).Error; err != nil {
+		return false
+	}
+	return now.Sub(last.CreatedAt) < csatServiceWindow
+}
+
+// deliverCSATSurvey sends the survey message over the survey's channel
+func (a *App) deliverCSATSurvey(survey *models.CSATSurvey) error {
+	var account models.WhatsAppAccount
+	if err := a.DB.Where("organization_id = ? AND name = ?", survey.OrganizationID, survey.WhatsAppAccount).First(&account).Error; err != nil {
+		return fmt.Errorf("whatsapp account not found: %w", err)
+	}
+	var contact models.Contact
+	if err := a.DB.Where("id = ?", survey.ContactID).First(&contact).Error; err != nil {
+		return fmt.Errorf("contact not found: %w", err)
+	}
+	settings, err := a.getChatbotSettingsCached(survey.OrganizationID, survey.WhatsAppAccount)
+	if err != nil || settings == nil {
+		return errors.New("chatbot settings not found")
+	}
+	settings = localizeChatbotSettings(settings, contactLanguage(settings, &contact))
+
+	message := settings.CSAT.Message
+	if message == "" {
+		message = defaultCSATMessage
+	}
+
+	req := OutgoingMessageRequest{
+		Account:  &account,
+		Contact:  &contact,
+		BodyText: message,
+	}
+	if survey.Channel == models.CSATChannelFlow {
+		req.Type = models.MessageTypeFlow
+		req.FlowID = settings.CSAT.FlowID
+		req.FlowCTA = "Rate us"
+		req.FlowToken = csatReplyPrefix + survey.ID.String()
+		req.FlowAction, req.FlowFirstScreen = a.whatsAppFlowEntry(&account, settings.CSAT.FlowID)
+	} else {
+		req.Type = models.MessageTypeInteractive
+		req.InteractiveType = "list"
+		req.Buttons = csatButtons(survey.ID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err = a.SendOutgoingMessage(ctx, req, SLASendOptions())
+	return err
+}
+
+// handleCSATResponse records a customer's reply to a satisfaction survey.
+// It returns true when the message answered a survey, so it isn't handled
+// as a chatbot message.
+func (a *App) handleCSATResponse(account *models.WhatsAppAccount, contact *models.Contact, buttonID string, flowResponse map[string]interface{}) bool {
+	surveyID, score, ok := parseCSATReply(buttonID)
+	comment := ""
+	if !ok && flowResponse != nil {
+		surveyID, score, comment, ok = parseCSATFlowResponse(flowResponse)
+	}
+	if !ok {
+		return false
+	}
+
+	var survey models.CSATSurvey
+	if err := a.DB.Where("id = ? AND organization_id = ? AND contact_id = ?", surveyID, account.OrganizationID, contact.ID).
+		First(&survey).Error; err != nil {
+		return true
+	}
+	if lowest, highest := csatScaleRange(survey.Scale); score < lowest || score > highest {
+		a.Log.Warn("CSAT score out of range", "survey_id", survey.ID, "score", score)
+		return true
+	}
+
+	// Only the first answer counts
+	result := a.DB.Model(&models.CSATSurvey{}).
+		Where("id = ? AND status = ?", survey.ID, models.CSATSurveyStatusSent).
+		Updates(map[string]interface{}{
+			"status":      models.CSATSurveyStatusAnswered,
+			"answered_at": time.Now(),
+			"score":       score,
+			"comment":     comment,
+		})
+	if result.Error != nil {
+		a.Log.Error("Failed to record CSAT response", "error", result.Error, "survey_id", survey.ID)
+		return true
+	}
+	if result.RowsAffected == 0 {
+		return true
+	}
+
+	a.Log.Info("CSAT response recorded", "survey_id", survey.ID, "score", score, "agent_id", survey.AgentID)
+
+	settings, err := a.getChatbotSettingsCached(account.OrganizationID, account.Name)
+	if err == nil && settings != nil {
+		thanks := localizeChatbotSettings(settings, contactLanguage(settings, contact)).CSAT.ThankYouMessage
+		if thanks != "" {
+			if err := a.sendAndSaveTextMessage(account, contact, thanks); err != nil {
+				a.Log.Error("Failed to send CSAT thank you message", "error", err, "contact", contact.PhoneNumber)
+			}
+		}
+	}
+	return true
+}
+
+// ListCSATSurveys lists satisfaction surveys and their responses, newest
+// first. Filters: agent_id, status, from and to (YYYY-MM-DD, by send time).
+func (a *App) ListCSATSurveys(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceAnalytics, models.ActionRead); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	query := a.DB.Model(&models.CSATSurvey{}).Where("organization_id = ?", orgID)
+
+	if agentIDStr := string(r.RequestCtx.QueryArgs().Peek("agent_id")); agentIDStr != "" {
+		agentID, err := uuid.Parse(agentIDStr)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid agent_id", nil, "")
+		}
+		query = query.Where("agent_id = ?", agentID)
+	}
+	if status := string(r.RequestCtx.QueryArgs().Peek("status")); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	fromStr := string(r.RequestCtx.QueryArgs().Peek("from"))
+	toStr := string(r.RequestCtx.QueryArgs().Peek("to"))
+	if fromStr != "" && toStr != "" {
+		start, end, errMsg := parseDateRange(fromStr, toStr)
+		if errMsg != "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, errMsg, nil, "")
+		}
+		query = query.Where("send_at >= ? AND send_at <= ?", start, end)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var surveys []models.CSATSurvey
+	if err := pg.Apply(query.Preload("Contact").Preload("Agent").Order("send_at DESC")).
+		Find(&surveys).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to fetch CSAT surveys", nil, "")
+	}
+
+	response := make([]CSATSurveyResponse, len(surveys))
+	for i, survey := range surveys {
+		resp := CSATSurveyResponse{
+			ID:              survey.ID.String(),
+			TransferID:      survey.TransferID.String(),
+			ContactID:       survey.ContactID.String(),
+			WhatsAppAccount: survey.WhatsAppAccount,
+			Scale:           survey.Scale,
+			Channel:         survey.Channel,
+			Status:          survey.Status,
+			Score:           survey.Score,
+			Comment:         survey.Comment,
+			SendAt:          survey.SendAt,
+			SentAt:          survey.SentAt,
+			AnsweredAt:      survey.AnsweredAt,
+		}
+		if survey.Contact != nil {
+			resp.ContactName = survey.Contact.ProfileName
+			resp.PhoneNumber = survey.Contact.PhoneNumber
+		}
+		if survey.AgentID != nil {
+			agentID := survey.AgentID.String()
+			resp.AgentID = &agentID
+		}
+		if survey.Agent != nil {
+			resp.AgentName = survey.Agent.FullName
+		}
+		response[i] = resp
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"surveys": response,
+		"total":   total,
+		"page":    pg.Page,
+		"limit":   pg.Limit,
+	})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/queue"
+)
+
+// csatBatchSize limits how many due surveys are sent per tick
+const csatBatchSize = 100
+
+// csatLease names the leader lease of the CSAT processor
+const csatLease = "csat"
+
+// CSATProcessor sends satisfaction surveys once their delay has passed.
+// With several replicas only the one holding the leader lease sends them.
+type CSATProcessor struct {
+	app      *App
+	interval time.Duration
+	lease    *queue.Lease
+	stopCh   chan struct{}
+}
+
+// NewCSATProcessor creates a new CSAT survey processor
+func NewCSATProcessor(app *App, interval time.Duration) *CSATProcessor {
+	return &CSATProcessor{
+		app:      app,
+		interval: interval,
+		lease:    app.newProcessorLease(csatLease, 2*interval),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the CSAT survey processing loop
+func (p *CSATProcessor) Start(ctx context.Context) {
+	p.app.Log.Info("CSAT processor started", "interval", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	defer p.app.releaseLease(p.lease, csatLease)
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.app.Log.Info("CSAT processor stopped by context")
+			return
+		case <-p.stopCh:
+			p.app.Log.Info("CSAT processor stopped")
+			return
+		case <-ticker.C:
+			if p.app.holdLease(p.lease, csatLease) {
+				p.sendDueSurveys()
+			}
+		}
+	}
+}
+
+// Stop stops the CSAT processor
+func (p *CSATProcessor) Stop() {
+	close(p.stopCh)
+}
+
+// sendDueSurveys sends every scheduled survey whose delay has passed
+func (p *CSATProcessor) sendDueSurveys() {
+	var surveys []models.CSATSurvey
+	if err := p.app.DB.Where("status = ? AND send_at <= ?", models.CSATSurveyStatusScheduled, time.Now()).
+		Order("send_at ASC").
+		Limit(csatBatchSize).
+		Find(&surveys).Error; err != nil {
+		p.app.Log.Error("Failed to load due CSAT surveys", "error", err)
+		return
+	}
+
+	for i := range surveys {
+		p.app.sendCSATSurvey(&surveys[i])
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCSATConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     models.CSATConfig
+		wantErr bool
+	}{
+		{"csat buttons", models.CSATConfig{Enabled: true, Scale: models.CSATScaleCSAT, Channel: models.CSATChannelButtons}, false},
+		{"csat flow", models.CSATConfig{Enabled: true, Scale: models.CSATScaleCSAT, Channel: models.CSATChannelFlow, FlowID: "123"}, false},
+		{"nps flow", models.CSATConfig{Enabled: true, Scale: models.CSATScaleNPS, Channel: models.CSATChannelFlow, FlowID: "123"}, false},
+		{"nps buttons", models.CSATConfig{Scale: models.CSATScaleNPS, Channel: models.CSATChannelButtons}, true},
+		{"flow without id", models.CSATConfig{Enabled: true, Scale: models.CSATScaleCSAT, Channel: models.CSATChannelFlow}, true},
+		{"disabled flow without id", models.CSATConfig{Scale: models.CSATScaleCSAT, Channel: models.CSATChannelFlow}, false},
+		{"unknown scale", models.CSATConfig{Scale: "stars", Channel: models.CSATChannelButtons}, true},
+		{"unknown channel", models.CSATConfig{Scale: models.CSATScaleCSAT, Channel: "sms"}, true},
+		{"negative delay", models.CSATConfig{Scale: models.CSATScaleCSAT, Channel: models.CSATChannelButtons, DelayMinutes: -1}, true},
+		{"negative suppression", models.CSATConfig{Scale: models.CSATScaleCSAT, Channel: models.CSATChannelButtons, SuppressionDays: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCSATConfig(tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCSATButtons_RoundTrip(t *testing.T) {
+	surveyID := uuid.New()
+	buttons := csatButtons(surveyID)
+	require.Len(t, buttons, 5)
+	assert.Equal(t, "5 - Excellent", buttons[0].Title)
+
+	for i, button := range buttons {
+		assert.LessOrEqual(t, len(button.Title), 24, "list row titles are limited to 24 characters")
+		gotID, score, ok := parseCSATReply(button.ID)
+		require.True(t, ok, button.ID)
+		assert.Equal(t, surveyID, gotID)
+		assert.Equal(t, 5-i, score)
+	}
+}
+
+func TestParseCSATReply_Invalid(t *testing.T) {
+	for _, id := range []string{"", "btn_1", "csat:", "csat:not-a-uuid:5", "csat:" + uuid.NewString(), "csat:" + uuid.NewString() + ":x"} {
+		_, _, ok := parseCSATReply(id)
+		assert.False(t, ok, id)
+	}
+}
+
+func TestParseCSATFlowResponse(t *testing.T) {
+	surveyID := uuid.New()
+	token := csatReplyPrefix + surveyID.String()
+
+	gotID, score, comment, ok := parseCSATFlowResponse(map[string]interface{}{
+		"flow_token": token,
+		"score":      float64(9),
+		"comment":    "  Quick and friendly ",
+	})
+	require.True(t, ok)
+	assert.Equal(t, surveyID, gotID)
+	assert.Equal(t, 9, score)
+	assert.Equal(t, "Quick and friendly", comment)
+
+	_, score, comment, ok = parseCSATFlowResponse(map[string]interface{}{"flow_token": token, "score": "3"})
+	require.True(t, ok)
+	assert.Equal(t, 3, score)
+	assert.Empty(t, comment)
+
+	_, _, _, ok = parseCSATFlowResponse(map[string]interface{}{"flow_token": "other-flow", "score": float64(5)})
+	assert.False(t, ok, "responses of other flows are ignored")
+	_, _, _, ok = parseCSATFlowResponse(map[string]interface{}{"flow_token": token})
+	assert.False(t, ok, "score is required")
+	_, _, _, ok = parseCSATFlowResponse(map[string]interface{}{"flow_token": token, "score": "great"})
+	assert.False(t, ok)
+}
+
+func TestNewCSATScore(t *testing.T) {
+	score := newCSATScore(csatCounts{
+		Sent:          10,
+		Responses:     8,
+		CSATResponses: 4,
+		CSATSum:       17,
+		CSATSatisfied: 3,
+		NPSResponses:  4,
+		NPSPromoters:  2,
+		NPSDetractors: 1,
+	})
+	assert.InDelta(t, 80, score.ResponseRate, 0.001)
+	assert.InDelta(t, 4.25, score.AvgCSAT, 0.001)
+	assert.InDelta(t, 75, score.SatisfiedPct, 0.001)
+	assert.InDelta(t, 25, score.NPS, 0.001)
+
+	empty := newCSATScore(csatCounts{})
+	assert.Zero(t, empty.ResponseRate)
+	assert.Zero(t, empty.AvgCSAT)
+	assert.Zero(t, empty.NPS)
+}
+
+func TestSendCSATSurvey_ExpiresOutsideServiceWindow(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	incoming := func(at time.Time) {
+		message := models.Message{
+			BaseModel:       models.BaseModel{CreatedAt: at},
+			OrganizationID:  org.ID,
+			WhatsAppAccount: account.Name,
+			ContactID:       contact.ID,
+			Direction:       models.DirectionIncoming,
+			MessageType:     models.MessageTypeText,
+			Content:         "Thanks",
+			Status:          models.MessageStatusReceived,
+		}
+		require.NoError(t, app.DB.Create(&message).Error)
+	}
+	incoming(time.Now().Add(-csatServiceWindow - time.Hour))
+
+	transfer := models.AgentTransfer{
+		OrganizationID:  org.ID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: account.Name,
+		PhoneNumber:     contact.PhoneNumber,
+		Status:          models.TransferStatusResumed,
+	}
+	require.NoError(t, app.DB.Create(&transfer).Error)
+	survey := models.CSATSurvey{
+		OrganizationID:  org.ID,
+		TransferID:      transfer.ID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: account.Name,
+		Scale:           models.CSATScaleCSAT,
+		Channel:         models.CSATChannelButtons,
+		Status:          models.CSATSurveyStatusScheduled,
+		SendAt:          time.Now(),
+	}
+	require.NoError(t, app.DB.Create(&survey).Error)
+
+	app.sendCSATSurvey(&survey)
+
+	require.NoError(t, app.DB.First(&survey, "id = ?", survey.ID).Error)
+	assert.Equal(t, models.CSATSurveyStatusExpired, survey.Status, "the survey can no longer be sent as a free-form message")
+	assert.Nil(t, survey.SentAt)
+
+	incoming(time.Now())
+	assert.True(t, app.inCSATServiceWindow(contact.ID, time.Now()))
+}
@@ -0,0 +1,503 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/crypto"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// Screen handler types for WhatsApp Flows data exchange
+const (
+	flowHandlerHTTP        = "http"         // Outbound HTTP call: {url, method, headers, body, response_mapping}
+	flowHandlerChatbotStep = "chatbot_step" // Reuse the API config of a chatbot api_fetch step: {chatbot_flow_id, step_name}
+)
+
+// flowSignatureErrorStatus is the status WhatsApp expects when a flow
+// endpoint request's signature can't be verified
+const flowSignatureErrorStatus = 432
+
+// errFlowKeyUnavailable is returned when a stored flow private key can't be decrypted
+var errFlowKeyUnavailable = errors.New("flow private key unavailable")
+
+// flowEndpointErrorMessage is shown on the screen when a handler fails
+const flowEndpointErrorMessage = "Something went wrong. Please try again."
+
+// FlowKeysResponse describes the flow endpoint encryption keys of an account
+type FlowKeysResponse struct {
+	PublicKey  string     `json:"public_key"`
+	Status     string     `json:"status"` // VALID, MISMATCH
+	UploadedAt *time.Time `json:"uploaded_at,omitempty"`
+}
+
+// FlowDataEndpoint handles encrypted data exchange requests from WhatsApp Flows.
+// It is public (called by Meta) and authenticated by request encryption and the app secret signature.
+func (a *App) FlowDataEndpoint(r *fastglue.Request) error {
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	var flow models.WhatsAppFlow
+	if err := a.DB.Where("id = ?", id).First(&flow).Error; err != nil || !flow.EndpointEnabled {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow not found", nil, "")
+	}
+
+	var account models.WhatsAppAccount
+	if err := a.DB.Where("organization_id = ? AND name = ?", flow.OrganizationID, flow.WhatsAppAccount).First(&account).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "WhatsApp account not found", nil, "")
+	}
+	a.decryptAccountSecrets(&account)
+
+	body := r.RequestCtx.PostBody()
+	// The flow public key is public, so anyone can encrypt a request. With an
+	// app secret configured, only signed requests from Meta are accepted.
+	signature := r.RequestCtx.Request.Header.Peek("X-Hub-Signature-256")
+	if account.AppSecret != "" && !verifyWebhookSignature(body, signature, []byte(account.AppSecret)) {
+		a.Log.Warn("Invalid flow endpoint signature", "flow_id", flow.ID)
+		return r.SendErrorEnvelope(flowSignatureErrorStatus, "Invalid signature", nil, "")
+	}
+
+	var encrypted whatsapp.EncryptedFlowRequest
+	if err := json.Unmarshal(body, &encrypted); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid payload", nil, "")
+	}
+
+	if account.FlowPrivateKey == "" && account.FlowPendingPrivateKey == "" {
+		a.Log.Warn("Flow endpoint called but account has no flow keys", "flow_id", flow.ID, "account", account.Name)
+		return r.SendErrorEnvelope(fasthttp.StatusMisdirectedRequest, "Flow keys not configured", nil, "")
+	}
+
+	req, session, err := a.decryptFlowRequest(&account, &encrypted)
+	if errors.Is(err, errFlowKeyUnavailable) {
+		a.Log.Error("Failed to decrypt flow private key", "error", err, "account", account.Name)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load flow keys", nil, "")
+	}
+	if err != nil {
+		a.Log.Warn("Failed to decrypt flow request", "error", err, "flow_id", flow.ID)
+		// 421 tells WhatsApp to re-fetch the public key and retry
+		if errors.Is(err, whatsapp.ErrFlowDecryption) {
+			return r.SendErrorEnvelope(fasthttp.StatusMisdirectedRequest, "Failed to decrypt request", nil, "")
+		}
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid flow request", nil, "")
+	}
+
+	response := a.handleFlowDataRequest(&flow, req)
+
+	encryptedResponse, err := session.EncryptResponse(response)
+	if err != nil {
+		a.Log.Error("Failed to encrypt flow response", "error", err, "flow_id", flow.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to encrypt response", nil, "")
+	}
+
+	r.RequestCtx.SetStatusCode(fasthttp.StatusOK)
+	r.RequestCtx.SetContentType("text/plain")
+	r.RequestCtx.SetBodyString(encryptedResponse)
+	return nil
+}
+
+// decryptFlowRequest decrypts a flow request with the account's private key.
+// A pending key is tried too, as Meta may already use a public key that was
+// uploaded while its private key wasn't activated yet.
+func (a *App) decryptFlowRequest(account *models.WhatsAppAccount, encrypted *whatsapp.EncryptedFlowRequest) (*whatsapp.FlowDataRequest, *whatsapp.FlowDataSession, error) {
+	err := whatsapp.ErrFlowDecryption
+	for _, storedKey := range []string{account.FlowPrivateKey, account.FlowPendingPrivateKey} {
+		if storedKey == "" {
+			continue
+		}
+		privateKey, keyErr := crypto.Decrypt(storedKey, a.Config.App.EncryptionKey)
+		if keyErr != nil {
+			return nil, nil, fmt.Errorf("%w: %v", errFlowKeyUnavailable, keyErr)
+		}
+		var req *whatsapp.FlowDataRequest
+		var session *whatsapp.FlowDataSession
+		req, session, err = whatsapp.DecryptFlowRequest(encrypted, privateKey)
+		if !errors.Is(err, whatsapp.ErrFlowDecryption) {
+			return req, session, err
+		}
+	}
+	return nil, nil, err
+}
+
+// handleFlowDataRequest resolves a decrypted flow request to the screen and data to return
+func (a *App) handleFlowDataRequest(flow *models.WhatsAppFlow, req *whatsapp.FlowDataRequest) map[string]interface{} {
+	switch req.Action {
+	case whatsapp.FlowActionPing:
+		return map[string]interface{}{"data": map[string]interface{}{"status": "active"}}
+	case whatsapp.FlowActionDataExchange:
+		// Client-side error notifications only need an acknowledgement
+		if _, ok := req.Data["error"]; ok {
+			a.Log.Warn("WhatsApp Flow reported an error", "flow_id", flow.ID, "screen", req.Screen, "error", req.Data["error"], "error_message", req.Data["error_message"])
+			return map[string]interface{}{"data": map[string]interface{}{"acknowledged": true}}
+		}
+	}
+
+	screen := req.Screen
+	handlerKey := req.Screen
+	if req.Action == whatsapp.FlowActionInit {
+		screen = firstFlowScreen(flow)
+		handlerKey = whatsapp.FlowActionInit
+	}
+
+	handler, _ := flow.ScreenHandlers[handlerKey].(map[string]interface{})
+	if handler == nil {
+		if req.Action == whatsapp.FlowActionDataExchange {
+			a.Log.Warn("No handler configured for flow screen", "flow_id", flow.ID, "screen", req.Screen)
+			return flowScreenResponse(screen, map[string]interface{}{"error_message": flowEndpointErrorMessage})
+		}
+		return flowScreenResponse(screen, map[string]interface{}{})
+	}
+
+	vars := a.flowRequestVariables(flow.OrganizationID, req)
+
+	apiConfig, err := a.flowHandlerAPIConfig(flow.OrganizationID, handler)
+	var apiResp *ApiResponse
+	if err == nil {
+		apiResp, err = a.fetchApiResponse(apiConfig, vars, "")
+	}
+	if err != nil {
+		a.Log.Error("Flow screen handler failed", "error", err, "flow_id", flow.ID, "screen", handlerKey)
+		message := flowEndpointErrorMessage
+		if m, ok := handler["error_message"].(string); ok && m != "" {
+			message = m
+		}
+		return flowScreenResponse(screen, map[string]interface{}{"error_message": message})
+	}
+
+	return buildFlowHandlerResponse(handler, screen, req.FlowToken, apiResp)
+}
+
+// buildFlowHandlerResponse turns a handler's API response into a flow endpoint response.
+// APIs that return {screen, data} drive navigation themselves; otherwise the mapped data
+// (or the whole response) is sent to the handler's next_screen.
+func buildFlowHandlerResponse(handler map[string]interface{}, screen, flowToken string, apiResp *ApiResponse) map[string]interface{} {
+	if next, ok := apiResp.ResponseData["screen"].(string); ok && next != "" {
+		data, _ := apiResp.ResponseData["data"].(map[string]interface{})
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		return flowScreenResponse(next, data)
+	}
+
+	data := apiResp.MappedData
+	if len(data) == 0 {
+		data = apiResp.ResponseData
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	if complete, _ := handler["complete"].(bool); complete {
+		params := make(map[string]interface{}, len(data)+1)
+		for k, v := range data {
+			params[k] = v
+		}
+		params["flow_token"] = flowToken
+		return flowScreenResponse("SUCCESS", map[string]interface{}{
+			"extension_message_response": map[string]interface{}{"params": params},
+		})
+	}
+
+	if next, ok := handler["next_screen"].(string); ok && next != "" {
+		screen = next
+	}
+	return flowScreenResponse(screen, data)
+}
+
+func flowScreenResponse(screen string, data map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"screen": screen, "data": data}
+}
+
+// firstFlowScreen returns the ID of the first screen of a flow
+func firstFlowScreen(flow *models.WhatsAppFlow) string {
+	if len(flow.Screens) > 0 {
+		if screenMap, ok := flow.Screens[0].(map[string]interface{}); ok {
+			if id, ok := screenMap["id"].(string); ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// flowRequestVariables builds the template variables for a screen handler: the screen's
+// data plus flow_token, screen and action. Flows sent by a chatbot also expose the session variables.
+func (a *App) flowRequestVariables(orgID uuid.UUID, req *whatsapp.FlowDataRequest) models.JSONB {
+	vars := models.JSONB{}
+	if sessionID, ok := chatbotFlowTokenSessionID(req.FlowToken); ok {
+		var session models.ChatbotSession
+		if err := a.DB.Where("id = ? AND organization_id = ?", sessionID, orgID).First(&session).Error; err == nil {
+			for k, v := range session.SessionData {
+				vars[k] = v
+			}
+		}
+	}
+	for k, v := range req.Data {
+		vars[k] = v
+	}
+	vars["flow_token"] = req.FlowToken
+	vars["screen"] = req.Screen
+	vars["action"] = req.Action
+	return vars
+}
+
+// chatbotFlowTokenSessionID extracts the session ID from a chatbot flow token
+// (chatbot_<session_id>_<step_name>_<nanos>)
+func chatbotFlowTokenSessionID(token string) (uuid.UUID, bool) {
+	rest, ok := strings.CutPrefix(token, "chatbot_")
+	if !ok || len(rest) < 36 {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(rest[:36])
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// flowHandlerAPIConfig resolves a screen handler to the API config used to fetch screen data
+func (a *App) flowHandlerAPIConfig(orgID uuid.UUID, handler map[string]interface{}) (models.JSONB, error) {
+	handlerType, _ := handler["type"].(string)
+	switch handlerType {
+	case "", flowHandlerHTTP:
+		return models.JSONB(handler), nil
+	case flowHandlerChatbotStep:
+		flowID, _ := handler["chatbot_flow_id"].(string)
+		stepName, _ := handler["step_name"].(string)
+		var step models.ChatbotFlowStep
+		if err := a.DB.Joins("JOIN chatbot_flows ON chatbot_flows.id = chatbot_flow_steps.flow_id").
+			Where("chatbot_flows.organization_id = ? AND chatbot_flow_steps.flow_id = ? AND chatbot_flow_steps.step_name = ?", orgID, flowID, stepName).
+			First(&step).Error; err != nil {
+			return nil, fmt.Errorf("chatbot step %q not found: %w", stepName, err)
+		}
+		if step.MessageType != models.FlowStepTypeAPIFetch {
+			return nil, fmt.Errorf("chatbot step %q is not an api_fetch step", stepName)
+		}
+		return step.ApiConfig, nil
+	default:
+		return nil, fmt.Errorf("unknown screen handler type %q", handlerType)
+	}
+}
+
+// validateScreenHandlers checks the screen handler configs of a flow
+func validateScreenHandlers(handlers map[string]interface{}) error {
+	for key, value := range handlers {
+		handler, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("screen handler %q must be an object", key)
+		}
+		handlerType, _ := handler["type"].(string)
+		switch handlerType {
+		case "", flowHandlerHTTP:
+			if url, _ := handler["url"].(string); url == "" {
+				return fmt.Errorf("screen handler %q requires a url", key)
+			}
+		case flowHandlerChatbotStep:
+			flowID, _ := handler["chatbot_flow_id"].(string)
+			if _, err := uuid.Parse(flowID); err != nil {
+				return fmt.Errorf("screen handler %q requires a valid chatbot_flow_id", key)
+			}
+			if stepName, _ := handler["step_name"].(string); stepName == "" {
+				return fmt.Errorf("screen handler %q requires a step_name", key)
+			}
+		default:
+			return fmt.Errorf("screen handler %q has unknown type %q", key, handlerType)
+		}
+	}
+	return nil
+}
+
+// flowRoutingModel builds the routing model Meta requires for endpoint flows from the
+// screens' navigate actions and the handlers' next_screen targets
+func flowRoutingModel(screens []interface{}, handlers map[string]interface{}) map[string]interface{} {
+	routes := map[string][]string{}
+	order := []string{}
+	add := func(from, to string) {
+		if to == "" || to == from || to == "SUCCESS" {
+			return
+		}
+		for _, existing := range routes[from] {
+			if existing == to {
+				return
+			}
+		}
+		routes[from] = append(routes[from], to)
+	}
+
+	for _, s := range screens {
+		screenMap, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := screenMap["id"].(string)
+		if id == "" {
+			continue
+		}
+		if _, seen := routes[id]; !seen {
+			routes[id] = []string{}
+			order = append(order, id)
+		}
+		for _, target := range collectNavigateTargets(screenMap["layout"]) {
+			add(id, target)
+		}
+		if handler, ok := handlers[id].(map[string]interface{}); ok {
+			next, _ := handler["next_screen"].(string)
+			add(id, next)
+		}
+	}
+
+	model := make(map[string]interface{}, len(order))
+	for _, id := range order {
+		model[id] = routes[id]
+	}
+	return model
+}
+
+// collectNavigateTargets walks a screen layout and returns the targets of navigate actions
+func collectNavigateTargets(node interface{}) []string {
+	var targets []string
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if name, _ := v["name"].(string); name == "navigate" {
+			if next, ok := v["next"].(map[string]interface{}); ok {
+				if target, _ := next["name"].(string); target != "" {
+					targets = append(targets, target)
+				}
+			}
+		}
+		for _, child := range v {
+			targets = append(targets, collectNavigateTargets(child)...)
+		}
+	case []interface{}:
+		for _, child := range v {
+			targets = append(targets, collectNavigateTargets(child)...)
+		}
+	}
+	return targets
+}
+
+// flowEndpointURL builds the public data endpoint URL of a flow from the current request
+func (a *App) flowEndpointURL(r *fastglue.Request, flowID uuid.UUID) string {
+	scheme := "https"
+	if !r.RequestCtx.IsTLS() && a.Config.App.Environment == "development" {
+		scheme = "http"
+	}
+	host := string(r.RequestCtx.Host())
+	basePath := sanitizeRedirectPath(a.Config.Server.BasePath)
+	return fmt.Sprintf("%s://%s%s/api/webhook/flows/%s", scheme, host, basePath, flowID)
+}
+
+// GetAccountFlowKeys returns the flow endpoint public key of an account and its status at Meta
+func (a *App) GetAccountFlowKeys(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "account")
+	if err != nil {
+		return nil
+	}
+
+	account, err := findByIDAndOrg[models.WhatsAppAccount](a.DB, r, id, orgID, "Account")
+	if err != nil {
+		return nil
+	}
+
+	if account.FlowPublicKey != "" {
+		a.decryptAccountSecrets(account)
+		_, status, err := a.WhatsApp.GetBusinessPublicKey(context.Background(), a.toWhatsAppAccount(account))
+		if err != nil {
+			a.Log.Warn("Failed to fetch flow public key status", "error", err, "account", account.Name)
+		} else if status != account.FlowPublicKeyStatus {
+			account.FlowPublicKeyStatus = status
+			a.DB.Model(account).Update("flow_public_key_status", status)
+		}
+	}
+
+	return r.SendEnvelope(FlowKeysResponse{
+		PublicKey:  account.FlowPublicKey,
+		Status:     account.FlowPublicKeyStatus,
+		UploadedAt: account.FlowKeyUploadedAt,
+	})
+}
+
+// GenerateAccountFlowKeys creates a new flow endpoint key pair for an account and uploads
+// the public key to Meta. Existing flows keep working once Meta picks up the new key.
+// The private key is stored as pending before the upload and only replaces the
+// current one once Meta accepted the public key, so a failed upload keeps the old pair.
+func (a *App) GenerateAccountFlowKeys(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "account")
+	if err != nil {
+		return nil
+	}
+
+	account, err := findByIDAndOrg[models.WhatsAppAccount](a.DB, r, id, orgID, "Account")
+	if err != nil {
+		return nil
+	}
+	a.decryptAccountSecrets(account)
+
+	privateKey, publicKey, err := whatsapp.GenerateFlowKeyPair()
+	if err != nil {
+		a.Log.Error("Failed to generate flow key pair", "error", err, "account", account.Name)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to generate keys", nil, "")
+	}
+
+	encryptedKey, err := crypto.Encrypt(privateKey, a.Config.App.EncryptionKey)
+	if err != nil {
+		a.Log.Error("Failed to encrypt flow private key", "error", err, "account", account.Name)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to store keys", nil, "")
+	}
+	if err := a.DB.Model(account).Update("flow_pending_private_key", encryptedKey).Error; err != nil {
+		a.Log.Error("Failed to save pending flow key", "error", err, "account", account.Name)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to store keys", nil, "")
+	}
+
+	ctx := context.Background()
+	waAccount := a.toWhatsAppAccount(account)
+	if err := a.WhatsApp.SetBusinessPublicKey(ctx, waAccount, publicKey); err != nil {
+		a.Log.Error("Failed to upload flow public key", "error", err, "account", account.Name)
+		a.DB.Model(account).Update("flow_pending_private_key", "")
+		return r.SendErrorEnvelope(fasthttp.StatusBadGateway, "Failed to upload public key to Meta", nil, "")
+	}
+
+	status := ""
+	if _, s, err := a.WhatsApp.GetBusinessPublicKey(ctx, waAccount); err == nil {
+		status = s
+	}
+
+	// Until this is saved the endpoint still decrypts with the pending key
+	now := time.Now()
+	if err := a.DB.Model(account).Updates(map[string]interface{}{
+		"flow_private_key":         encryptedKey,
+		"flow_pending_private_key": "",
+		"flow_public_key":          publicKey,
+		"flow_public_key_status":   status,
+		"flow_key_uploaded_at":     now,
+	}).Error; err != nil {
+		a.Log.Error("Failed to save flow keys", "error", err, "account", account.Name)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to store keys", nil, "")
+	}
+
+	a.Log.Info("Flow endpoint keys generated", "account", account.Name)
+	return r.SendEnvelope(FlowKeysResponse{
+		PublicKey:  publicKey,
+		Status:     status,
+		UploadedAt: &now,
+	})
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/config"
+	"github.com/shridarpatil/whatomate/internal/crypto"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEndpointFlow(handlers models.JSONB) *models.WhatsAppFlow {
+	return &models.WhatsAppFlow{
+		EndpointEnabled: true,
+		Screens: models.JSONBArray{
+			map[string]interface{}{"id": "BOOKING"},
+			map[string]interface{}{"id": "SLOTS"},
+		},
+		ScreenHandlers: handlers,
+	}
+}
+
+func TestHandleFlowDataRequest_PingAndErrors(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := testEndpointFlow(nil)
+
+	resp := app.handleFlowDataRequest(flow, &whatsapp.FlowDataRequest{Action: whatsapp.FlowActionPing})
+	assert.Equal(t, map[string]interface{}{"status": "active"}, resp["data"])
+
+	resp = app.handleFlowDataRequest(flow, &whatsapp.FlowDataRequest{
+		Action: whatsapp.FlowActionDataExchange,
+		Screen: "BOOKING",
+		Data:   map[string]interface{}{"error": "INVALID_SCREEN", "error_message": "bad"},
+	})
+	assert.Equal(t, map[string]interface{}{"acknowledged": true}, resp["data"])
+}
+
+func TestHandleFlowDataRequest_WithoutHandler(t *testing.T) {
+	app := &App{Log: testutil.NopLogger()}
+	flow := testEndpointFlow(nil)
+
+	resp := app.handleFlowDataRequest(flow, &whatsapp.FlowDataRequest{Action: whatsapp.FlowActionInit})
+	assert.Equal(t, "BOOKING", resp["screen"])
+
+	resp = app.handleFlowDataRequest(flow, &whatsapp.FlowDataRequest{Action: whatsapp.FlowActionBack, Screen: "SLOTS"})
+	assert.Equal(t, "SLOTS", resp["screen"])
+
+	resp = app.handleFlowDataRequest(flow, &whatsapp.FlowDataRequest{Action: whatsapp.FlowActionDataExchange, Screen: "SLOTS"})
+	assert.Equal(t, "SLOTS", resp["screen"])
+	assert.Equal(t, flowEndpointErrorMessage, resp["data"].(map[string]interface{})["error_message"])
+}
+
+func TestHandleFlowDataRequest_HTTPHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "2026-01-02", body["date"])
+		assert.Equal(t, "tok-1", body["token"])
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"slots": []string{"09:00", "10:00"},
+		})
+	}))
+	defer server.Close()
+
+	app := &App{Log: testutil.NopLogger(), HTTPClient: server.Client()}
+	flow := testEndpointFlow(models.JSONB{
+		"BOOKING": map[string]interface{}{
+			"type":             flowHandlerHTTP,
+			"url":              server.URL,
+			"method":           "POST",
+			"body":             `{"date": "{{date}}", "token": "{{flow_token}}"}`,
+			"response_mapping": map[string]interface{}{"slots": "slots"},
+			"next_screen":      "SLOTS",
+		},
+	})
+
+	resp := app.handleFlowDataRequest(flow, &whatsapp.FlowDataRequest{
+		Action:    whatsapp.FlowActionDataExchange,
+		Screen:    "BOOKING",
+		FlowToken: "tok-1",
+		Data:      map[string]interface{}{"date": "2026-01-02"},
+	})
+	assert.Equal(t, "SLOTS", resp["screen"])
+	data := resp["data"].(map[string]interface{})
+	assert.Len(t, data["slots"], 2)
+}
+
+func TestHandleFlowDataRequest_HandlerFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	app := &App{Log: testutil.NopLogger(), HTTPClient: server.Client()}
+	flow := testEndpointFlow(models.JSONB{
+		"BOOKING": map[string]interface{}{"url": server.URL, "error_message": "No slots available"},
+	})
+
+	resp := app.handleFlowDataRequest(flow, &whatsapp.FlowDataRequest{Action: whatsapp.FlowActionDataExchange, Screen: "BOOKING"})
+	assert.Equal(t, "BOOKING", resp["screen"])
+	assert.Equal(t, "No slots available", resp["data"].(map[string]interface{})["error_message"])
+}
+
+func TestBuildFlowHandlerResponse(t *testing.T) {
+	t.Run("api drives navigation", func(t *testing.T) {
+		resp := buildFlowHandlerResponse(map[string]interface{}{"next_screen": "SLOTS"}, "BOOKING", "tok", &ApiResponse{
+			ResponseData: map[string]interface{}{"screen": "CONFIRM", "data": map[string]interface{}{"id": "1"}},
+		})
+		assert.Equal(t, "CONFIRM", resp["screen"])
+		assert.Equal(t, map[string]interface{}{"id": "1"}, resp["data"])
+	})
+
+	t.Run("complete closes the flow", func(t *testing.T) {
+		resp := buildFlowHandlerResponse(map[string]interface{}{"complete": true}, "CONFIRM", "tok", &ApiResponse{
+			ResponseData: map[string]interface{}{"booking_id": "B1"},
+		})
+		assert.Equal(t, "SUCCESS", resp["screen"])
+		params := resp["data"].(map[string]interface{})["extension_message_response"].(map[string]interface{})["params"].(map[string]interface{})
+		assert.Equal(t, "B1", params["booking_id"])
+		assert.Equal(t, "tok", params["flow_token"])
+	})
+
+	t.Run("defaults to the current screen", func(t *testing.T) {
+		resp := buildFlowHandlerResponse(map[string]interface{}{}, "BOOKING", "tok", &ApiResponse{})
+		assert.Equal(t, "BOOKING", resp["screen"])
+		assert.Equal(t, map[string]interface{}{}, resp["data"])
+	})
+}
+
+func TestValidateScreenHandlers(t *testing.T) {
+	assert.NoError(t, validateScreenHandlers(nil))
+	assert.NoError(t, validateScreenHandlers(map[string]interface{}{
+		"INIT":    map[string]interface{}{"url": "https://example.com"},
+		"BOOKING": map[string]interface{}{"type": flowHandlerChatbotStep, "chatbot_flow_id": uuid.New().String(), "step_name": "slots"},
+	}))
+
+	assert.Error(t, validateScreenHandlers(map[string]interface{}{"INIT": "https://example.com"}))
+	assert.Error(t, validateScreenHandlers(map[string]interface{}{"INIT": map[string]interface{}{"type": flowHandlerHTTP}}))
+	assert.Error(t, validateScreenHandlers(map[string]interface{}{"INIT": map[string]interface{}{"type": flowHandlerChatbotStep, "step_name": "x"}}))
+	assert.Error(t, validateScreenHandlers(map[string]interface{}{"INIT": map[string]interface{}{"type": "script"}}))
+}
+
+func TestFlowRoutingModel(t *testing.T) {
+	screens := []interface{}{
+		map[string]interface{}{
+			"id": "BOOKING",
+			"layout": map[string]interface{}{
+				"children": []interface{}{
+					map[string]interface{}{
+						"type":            "Footer",
+						"on-click-action": map[string]interface{}{"name": "navigate", "next": map[string]interface{}{"type": "screen", "name": "DETAILS"}},
+					},
+				},
+			},
+		},
+		map[string]interface{}{"id": "DETAILS"},
+		map[string]interface{}{"id": "SLOTS"},
+	}
+	handlers := map[string]interface{}{
+		"DETAILS": map[string]interface{}{"url": "https://example.com", "next_screen": "SLOTS"},
+	}
+
+	model := flowRoutingModel(screens, handlers)
+	assert.Equal(t, []string{"DETAILS"}, model["BOOKING"])
+	assert.Equal(t, []string{"SLOTS"}, model["DETAILS"])
+	assert.Equal(t, []string{}, model["SLOTS"])
+}
+
+func TestChatbotFlowTokenSessionID(t *testing.T) {
+	sessionID := uuid.New()
+
+	id, ok := chatbotFlowTokenSessionID("chatbot_" + sessionID.String() + "_ask_date_123")
+	require.True(t, ok)
+	assert.Equal(t, sessionID, id)
+
+	_, ok = chatbotFlowTokenSessionID("flow_123")
+	assert.False(t, ok)
+	_, ok = chatbotFlowTokenSessionID("chatbot_short")
+	assert.False(t, ok)
+}
+
+func TestFlowDataEndpoint_RequiresSignature(t *testing.T) {
+	app := newProcessorTestApp(t)
+	app.Config = &config.Config{}
+	org, account := createProcessorTestOrg(t, app)
+	require.NoError(t, app.DB.Model(account).Update("app_secret", "app-secret").Error)
+	flow := &models.WhatsAppFlow{
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "Booking",
+		EndpointEnabled: true,
+	}
+	require.NoError(t, app.DB.Create(flow).Error)
+
+	body := []byte("not encrypted")
+	mac := hmac.New(sha256.New, []byte("app-secret"))
+	mac.Write(body)
+	validSig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	for name, tt := range map[string]struct {
+		signature string
+		status    int
+	}{
+		"missing signature": {"", flowSignatureErrorStatus},
+		"invalid signature": {"sha256=" + hex.EncodeToString(make([]byte, 32)), flowSignatureErrorStatus},
+		"valid signature":   {validSig, http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := testutil.NewRequest(t)
+			req.RequestCtx.Request.SetBody(body)
+			if tt.signature != "" {
+				testutil.SetHeader(req, "X-Hub-Signature-256", tt.signature)
+			}
+			testutil.SetPathParam(req, "id", flow.ID.String())
+			require.NoError(t, app.FlowDataEndpoint(req))
+			assert.Equal(t, tt.status, testutil.GetResponseStatusCode(req))
+		})
+	}
+}
+
+func TestDecryptFlowRequest_FallsBackToPendingKey(t *testing.T) {
+	app := &App{Config: &config.Config{}}
+	app.Config.App.EncryptionKey = "test-encryption-key"
+
+	storeKey := func(privateKey string) string {
+		encrypted, err := crypto.Encrypt(privateKey, app.Config.App.EncryptionKey)
+		require.NoError(t, err)
+		return encrypted
+	}
+	oldPrivate, _, err := whatsapp.GenerateFlowKeyPair()
+	require.NoError(t, err)
+	newPrivate, newPublic, err := whatsapp.GenerateFlowKeyPair()
+	require.NoError(t, err)
+
+	encrypted, _, err := whatsapp.EncryptFlowRequest(&whatsapp.FlowDataRequest{Version: "3.0", Action: whatsapp.FlowActionPing}, newPublic)
+	require.NoError(t, err)
+
+	// Meta already uses the new public key but the key pair wasn't activated
+	account := &models.WhatsAppAccount{FlowPrivateKey: storeKey(oldPrivate), FlowPendingPrivateKey: storeKey(newPrivate)}
+	req, _, err := app.decryptFlowRequest(account, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, whatsapp.FlowActionPing, req.Action)
+
+	account.FlowPendingPrivateKey = ""
+	_, _, err = app.decryptFlowRequest(account, encrypted)
+	assert.ErrorIs(t, err, whatsapp.ErrFlowDecryption)
+}
+
+func TestGenerateAccountFlowKeys_FailedUploadKeepsKeys(t *testing.T) {
+	app := newProcessorTestApp(t)
+	app.Config = &config.Config{}
+	org, account := createProcessorTestOrg(t, app)
+	require.NoError(t, app.DB.Model(account).Updates(map[string]interface{}{
+		"flow_private_key": "old-private-key",
+		"flow_public_key":  "old-public-key",
+	}).Error)
+
+	metaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"Invalid parameter","code":100}}`))
+	}))
+	t.Cleanup(metaServer.Close)
+	app.WhatsApp = whatsapp.NewWithBaseURL(app.Log, metaServer.URL)
+
+	req := testutil.NewRequest(t)
+	testutil.SetAuthContext(req, org.ID, uuid.New())
+	testutil.SetPathParam(req, "id", account.ID.String())
+	require.NoError(t, app.GenerateAccountFlowKeys(req))
+	assert.Equal(t, http.StatusBadGateway, testutil.GetResponseStatusCode(req))
+
+	var stored models.WhatsAppAccount
+	require.NoError(t, app.DB.First(&stored, "id = ?", account.ID).Error)
+	assert.Equal(t, "old-private-key", stored.FlowPrivateKey)
+	assert.Equal(t, "old-public-key", stored.FlowPublicKey)
+	assert.Empty(t, stored.FlowPendingPrivateKey)
+}
@@ -20,6 +20,8 @@ type FlowRequest struct {
 	JSONVersion     string                 `json:"json_version"`
 	FlowJSON        map[string]interface{} `json:"flow_json"`
 	Screens         []interface{}          `json:"screens"`
+	EndpointEnabled *bool                  `json:"endpoint_enabled"`
+	ScreenHandlers  map[string]interface{} `json:"screen_handlers"` // Screen ID (or INIT) -> data handler config
 }
 
 // FlowResponse represents the response for a flow
@@ -35,6 +37,8 @@ type FlowResponse struct {
 	Screens         []interface{}          `json:"screens"`
 	PreviewURL      string                 `json:"preview_url"`
 	HasLocalChanges bool                   `json:"has_local_changes"`
+	EndpointEnabled bool                   `json:"endpoint_enabled"`
+	ScreenHandlers  map[string]interface{} `json:"screen_handlers"`
 	CreatedAt       string                 `json:"created_at"`
 	UpdatedAt       string                 `json:"updated_at"`
 }
@@ -109,6 +113,9 @@ func (a *App) CreateFlow(r *fastglue.Request) error {
 	if req.WhatsAppAccount == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account is required", nil, "")
 	}
+	if err := validateScreenHandlers(req.ScreenHandlers); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
 
 	// Verify account exists and belongs to org
 	var account models.WhatsAppAccount
@@ -131,6 +138,8 @@ func (a *App) CreateFlow(r *fastglue.Request) error {
 		JSONVersion:     jsonVersion,
 		FlowJSON:        models.JSONB(req.FlowJSON),
 		Screens:         models.JSONBArray(req.Screens),
+		EndpointEnabled: req.EndpointEnabled != nil && *req.EndpointEnabled,
+		ScreenHandlers:  models.JSONB(req.ScreenHandlers),
 	}
 
 	if err := a.DB.Create(&flow).Error; err != nil {
@@ -206,6 +215,15 @@ func (a *App) UpdateFlow(r *fastglue.Request) error {
 	if req.Screens != nil {
 		updates["screens"] = models.JSONBArray(req.Screens)
 	}
+	if req.EndpointEnabled != nil {
+		updates["endpoint_enabled"] = *req.EndpointEnabled
+	}
+	if req.ScreenHandlers != nil {
+		if err := validateScreenHandlers(req.ScreenHandlers); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		updates["screen_handlers"] = models.JSONB(req.ScreenHandlers)
+	}
 
 	if len(updates) > 0 {
 		// Mark as having local changes that need to be synced to Meta
@@ -331,6 +349,16 @@ func (a *App) SaveFlowToMeta(r *fastglue.Request) error {
 			Screens: sanitizedScreens,
 		}
 
+		// Endpoint flows need the data API version and a routing model
+		if flow.EndpointEnabled {
+			flowJSON.DataAPIVersion = "3.0"
+			if routing, ok := flow.FlowJSON["routing_model"].(map[string]interface{}); ok {
+				flowJSON.RoutingModel = routing
+			} else {
+				flowJSON.RoutingModel = flowRoutingModel(sanitizedScreens, flow.ScreenHandlers)
+			}
+		}
+
 		if err := waClient.UpdateFlowJSON(ctx, waAccount, metaFlowID, flowJSON); err != nil {
 			a.Log.Error("Failed to update flow JSON in Meta", "error", err, "flow_id", id, "meta_flow_id", metaFlowID)
 			// Save the meta flow ID even if JSON update fails
@@ -341,6 +369,17 @@ func (a *App) SaveFlowToMeta(r *fastglue.Request) error {
 		}
 	}
 
+	// Point Meta at our data endpoint
+	if flow.EndpointEnabled {
+		if err := waClient.SetFlowEndpoint(ctx, waAccount, metaFlowID, a.flowEndpointURL(r, flow.ID)); err != nil {
+			a.Log.Error("Failed to set flow endpoint in Meta", "error", err, "flow_id", id, "meta_flow_id", metaFlowID)
+			a.DB.Model(flow).Updates(map[string]interface{}{
+				"meta_flow_id": metaFlowID,
+			})
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to set flow endpoint", nil, "")
+		}
+	}
+
 	// Update local database with meta flow ID and set status to DRAFT
 	// (updating on Meta creates a new draft version that needs to be published)
 	if err := a.DB.Model(flow).Updates(map[string]interface{}{
@@ -521,6 +560,8 @@ func (a *App) DuplicateFlow(r *fastglue.Request) error {
 		JSONVersion:     flow.JSONVersion,
 		FlowJSON:        flow.FlowJSON,
 		Screens:         flow.Screens,
+		EndpointEnabled: flow.EndpointEnabled,
+		ScreenHandlers:  flow.ScreenHandlers,
 		// MetaFlowID is intentionally left empty - this is a new flow
 	}
 
@@ -1076,6 +1117,8 @@ func flowToResponse(f models.WhatsAppFlow) FlowResponse {
 		Screens:         []interface{}(f.Screens),
 		PreviewURL:      f.PreviewURL,
 		HasLocalChanges: f.HasLocalChanges,
+		EndpointEnabled: f.EndpointEnabled,
+		ScreenHandlers:  map[string]interface{}(f.ScreenHandlers),
 		CreatedAt:       f.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedAt:       f.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
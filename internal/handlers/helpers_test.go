@@ -400,3 +400,17 @@ func TestParseDateRange_SameDay(t *testing.T) {
 	assert.Equal(t, 0, start.Hour())
 	assert.Equal(t, 23, end.Hour())
 }
+
+// --- ownsMediaPath ---
+
+func TestOwnsMediaPath(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.New()
+	own := "orgs/" + orgID.String() + "/images/a.png"
+
+	assert.True(t, ownsMediaPath(orgID, own))
+	assert.False(t, ownsMediaPath(uuid.New(), own), "another organization's upload")
+	assert.False(t, ownsMediaPath(orgID, "images/a.png"), "customer media")
+	assert.False(t, ownsMediaPath(orgID, "orgs/"+orgID.String()+"/../"+uuid.New().String()+"/images/a.png"))
+	assert.False(t, ownsMediaPath(orgID, "orgs/"+orgID.String()))
+}
@@ -24,6 +24,7 @@ type ExportConfig struct {
 	DefaultColumns  []string
 	ColumnLabels    map[string]string // Column name -> CSV header label
 	ColumnTransform map[string]func(interface{}) string
+	ColumnSQL       map[string]string // Column name -> SQL expression selected instead, e.g. for related tables
 }
 
 // ImportConfig defines allowed tables and their importable columns
@@ -117,6 +118,49 @@ var exportConfigs = map[string]ExportConfig{
 			"created_at":  "Created At",
 		},
 	},
+	"orders": {
+		Model:    &models.Order{},
+		Resource: "orders",
+		AllowedColumns: []string{
+			"id", "status", "contact_phone", "contact_name", "whats_app_account", "items", "currency", "total",
+			"text", "tracking_number", "tracking_url", "cancel_reason",
+			"created_at", "confirmed_at", "paid_at", "shipped_at", "cancelled_at",
+		},
+		DefaultColumns: []string{"id", "status", "contact_phone", "contact_name", "items", "currency", "total", "created_at"},
+		ColumnLabels: map[string]string{
+			"id":                "Order ID",
+			"status":            "Status",
+			"contact_phone":     "Phone Number",
+			"contact_name":      "Name",
+			"whats_app_account": "WhatsApp Account",
+			"items":             "Items",
+			"currency":          "Currency",
+			"total":             "Total",
+			"text":              "Note",
+			"tracking_number":   "Tracking Number",
+			"tracking_url":      "Tracking URL",
+			"cancel_reason":     "Cancel Reason",
+			"created_at":        "Created At",
+			"confirmed_at":      "Confirmed At",
+			"paid_at":           "Paid At",
+			"shipped_at":        "Shipped At",
+			"cancelled_at":      "Cancelled At",
+		},
+		ColumnSQL: map[string]string{
+			"contact_phone": "(SELECT c.phone_number FROM contacts c WHERE c.id = orders.contact_id) AS contact_phone",
+			"contact_name":  "(SELECT c.profile_name FROM contacts c WHERE c.id = orders.contact_id) AS contact_name",
+			"items": "(SELECT string_agg(COALESCE(NULLIF(i.name, ''), i.retailer_id) || ' x' || i.quantity, '; ' ORDER BY i.created_at) " +
+				"FROM order_items i WHERE i.order_id = orders.id AND i.deleted_at IS NULL) AS items",
+		},
+		ColumnTransform: map[string]func(interface{}) string{
+			"total": func(v interface{}) string {
+				if cents, ok := v.(int64); ok {
+					return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+				}
+				return ""
+			},
+		},
+	},
 }
 
 var importConfigs = map[string]ImportConfig{
@@ -215,6 +259,13 @@ func (a *App) ExportData(r *fastglue.Request) error {
 
 	// Build query
 	query := a.DB.Model(config.Model).Where("organization_id = ?", orgID)
+	// Exports only include the conversations the user may see
+	switch req.Table {
+	case "contacts":
+		query = a.scopeContacts(query, userID, orgID)
+	case "orders":
+		query = a.scopeOrders(query, userID, orgID)
+	}
 
 	// Apply filters
 	if search, ok := req.Filters["search"]; ok && search != "" {
@@ -225,9 +276,15 @@ func (a *App) ExportData(r *fastglue.Request) error {
 			query = query.Where("phone_number LIKE ? OR profile_name ILIKE ?", searchPattern, searchPattern)
 		case "tags":
 			query = query.Where("name ILIKE ? OR description ILIKE ?", searchPattern, searchPattern)
+		case "orders":
+			query = query.Where("contact_id IN (SELECT id FROM contacts WHERE phone_number LIKE ? OR profile_name ILIKE ?)", searchPattern, searchPattern)
 		}
 	}
 
+	if status, ok := req.Filters["status"]; ok && status != "" && req.Table == "orders" {
+		query = query.Where("status IN ?", strings.Split(status, ","))
+	}
+
 	if tags, ok := req.Filters["tags"]; ok && tags != "" {
 		tagList := strings.Split(tags, ",")
 		conditions := make([]string, 0, len(tagList))
@@ -256,6 +313,11 @@ func (a *App) ExportData(r *fastglue.Request) error {
 		}
 	}
 	selectCols := append([]string{"id"}, safeColumns...)
+	for i, col := range selectCols {
+		if expr, ok := config.ColumnSQL[col]; ok {
+			selectCols[i] = expr
+		}
+	}
 	query = query.Select(selectCols)
 
 	// Execute query
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"gorm.io/gorm"
+)
+
+// IncomingOrder is the cart a customer sends from a catalog or product message
+type IncomingOrder struct {
+	CatalogID    string `json:"catalog_id"`
+	Text         string `json:"text,omitempty"`
+	ProductItems []struct {
+		ProductRetailerID string      `json:"product_retailer_id"`
+		Quantity          json.Number `json:"quantity"`
+		ItemPrice         json.Number `json:"item_price"`
+		Currency          string      `json:"currency"`
+	} `json:"product_items"`
+}
+
+// IncomingReferral is the click-to-WhatsApp ad or post a message was sent from
+type IncomingReferral struct {
+	SourceURL    string `json:"source_url"`
+	SourceID     string `json:"source_id"`
+	SourceType   string `json:"source_type"` // ad or post
+	Headline     string `json:"headline,omitempty"`
+	Body         string `json:"body,omitempty"`
+	MediaType    string `json:"media_type,omitempty"` // image or video
+	ImageURL     string `json:"image_url,omitempty"`
+	VideoURL     string `json:"video_url,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	CtwaClid     string `json:"ctwa_clid,omitempty"`
+}
+
+// IncomingSystem is a system message, e.g. a customer changing their number
+type IncomingSystem struct {
+	Body     string `json:"body"`
+	Type     string `json:"type"` // user_changed_number, customer_identity_changed
+	WaID     string `json:"wa_id,omitempty"`
+	NewWaID  string `json:"new_wa_id,omitempty"`
+	Identity string `json:"identity,omitempty"`
+	Customer string `json:"customer,omitempty"`
+}
+
+// templateButtonPayload returns the payload of a template quick reply tap
+func templateButtonPayload(msg IncomingTextMessage) string {
+	if msg.Type == "button" && msg.Button != nil {
+		return msg.Button.Payload
+	}
+	return ""
+}
+
+// buildIncomingOrder converts a cart to an order with prices in cents.
+// Products are not resolved.
+func buildIncomingOrder(in *IncomingOrder) models.Order {
+	order := models.Order{
+		MetaCatalogID: in.CatalogID,
+		Text:          in.Text,
+		Status:        models.OrderStatusPending,
+		Items:         make([]models.OrderItem, 0, len(in.ProductItems)),
+	}
+	for _, p := range in.ProductItems {
+		quantity, _ := p.Quantity.Int64()
+		if quantity == 0 {
+			if f, err := p.Quantity.Float64(); err == nil {
+				quantity = int64(f)
+			}
+		}
+		price, _ := p.ItemPrice.Float64()
+		item := models.OrderItem{
+			RetailerID: p.ProductRetailerID,
+			Quantity:   int(quantity),
+			ItemPrice:  int64(math.Round(price * 100)),
+			Currency:   p.Currency,
+		}
+		if order.Currency == "" {
+			order.Currency = p.Currency
+		}
+		order.Total += item.ItemPrice * quantity
+		order.Items = append(order.Items, item)
+	}
+	return order
+}
+
+// createIncomingOrder stores a cart as a pending order, linking the catalog and
+// products that have been synced. When Meta retries the webhook, the order already
+// stored for the message is returned with created set to false.
+func (a *App) createIncomingOrder(account *models.WhatsAppAccount, contact *models.Contact, wamid string, in *IncomingOrder) (order *models.Order, created bool) {
+	if wamid != "" {
+		if existing := a.findOrderBySourceWAMID(account.OrganizationID, wamid); existing != nil {
+			a.Log.Debug("Order already stored for message, skipping", "message_id", wamid)
+			return existing, false
+		}
+	}
+
+	built := buildIncomingOrder(in)
+	order = &built
+	order.OrganizationID = account.OrganizationID
+	order.WhatsAppAccount = account.Name
+	order.ContactID = contact.ID
+	if wamid != "" {
+		order.SourceWAMID = &wamid
+	}
+
+	var catalog models.Catalog
+	if err := a.DB.Where("organization_id = ? AND meta_catalog_id = ?", account.OrganizationID, in.CatalogID).
+		First(&catalog).Error; err == nil {
+		order.CatalogID = &catalog.ID
+	}
+
+	retailerIDs := make([]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		retailerIDs = append(retailerIDs, item.RetailerID)
+	}
+	var products []models.CatalogProduct
+	query := a.DB.Where("organization_id = ? AND retailer_id IN ?", account.OrganizationID, retailerIDs)
+	if order.CatalogID != nil {
+		query = query.Where("catalog_id = ?", *order.CatalogID)
+	}
+	query.Find(&products)
+
+	byRetailerID := make(map[string]models.CatalogProduct, len(products))
+	for _, p := range products {
+		byRetailerID[p.RetailerID] = p
+	}
+	for i := range order.Items {
+		if p, ok := byRetailerID[order.Items[i].RetailerID]; ok {
+			order.Items[i].ProductID = &p.ID
+			order.Items[i].Name = p.Name
+		}
+	}
+
+	if err := a.DB.Create(order).Error; err != nil {
+		// A concurrent delivery of the same message stored it first
+		if wamid != "" {
+			if existing := a.findOrderBySourceWAMID(account.OrganizationID, wamid); existing != nil {
+				return existing, false
+			}
+		}
+		a.Log.Error("Failed to save incoming order", "error", err, "contact_id", contact.ID)
+		return nil, false
+	}
+	return order, true
+}
+
+// findOrderBySourceWAMID returns the order created from a WhatsApp message, or nil
+func (a *App) findOrderBySourceWAMID(orgID uuid.UUID, wamid string) *models.Order {
+	var order models.Order
+	if err := a.DB.Where("organization_id = ? AND source_wamid = ?", orgID, wamid).First(&order).Error; err != nil {
+		return nil
+	}
+	return &order
+}
+
+// orderMetadata is the cart as stored on the order message
+func orderMetadata(in *IncomingOrder, order *models.Order) map[string]any {
+	items := make([]map[string]any, 0, len(in.ProductItems))
+	for _, p := range in.ProductItems {
+		items = append(items, map[string]any{
+			"product_retailer_id": p.ProductRetailerID,
+			"quantity":            p.Quantity.String(),
+			"item_price":          p.ItemPrice.String(),
+			"currency":            p.Currency,
+		})
+	}
+	data := map[string]any{
+		"catalog_id":    in.CatalogID,
+		"text":          in.Text,
+		"product_items": items,
+	}
+	if order != nil {
+		data["order_id"] = order.ID.String()
+		data["total"] = order.Total
+		data["currency"] = order.Currency
+	}
+	return data
+}
+
+// referralMetadata is the ad referral as stored on the message
+func referralMetadata(ref *IncomingReferral) map[string]any {
+	data := map[string]any{
+		"source_type": ref.SourceType,
+		"source_id":   ref.SourceID,
+		"source_url":  ref.SourceURL,
+	}
+	for key, value := range map[string]string{
+		"headline":      ref.Headline,
+		"body":          ref.Body,
+		"media_type":    ref.MediaType,
+		"image_url":     ref.ImageURL,
+		"video_url":     ref.VideoURL,
+		"thumbnail_url": ref.ThumbnailURL,
+		"ctwa_clid":     ref.CtwaClid,
+	} {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	return data
+}
+
+// systemMetadata is the system event as stored on the message
+func systemMetadata(sys *IncomingSystem) map[string]any {
+	data := map[string]any{"type": sys.Type}
+	if sys.NewWaID != "" {
+		data["new_wa_id"] = sys.NewWaID
+	}
+	if sys.Identity != "" {
+		data["identity"] = sys.Identity
+	}
+	return data
+}
+
+// recordContactReferral stores the ad or post a contact messaged from and
+// attributes the contact to it
+func (a *App) recordContactReferral(account *models.WhatsAppAccount, contact *models.Contact, messageID *uuid.UUID, ref *IncomingReferral, isNewContact bool) {
+	mediaURL := ref.ImageURL
+	if mediaURL == "" {
+		mediaURL = ref.VideoURL
+	}
+	referral := models.ContactReferral{
+		OrganizationID:  account.OrganizationID,
+		ContactID:       contact.ID,
+		MessageID:       messageID,
+		WhatsAppAccount: account.Name,
+		SourceType:      ref.SourceType,
+		SourceID:        ref.SourceID,
+		SourceURL:       ref.SourceURL,
+		Headline:        ref.Headline,
+		Body:            ref.Body,
+		MediaType:       ref.MediaType,
+		MediaURL:        mediaURL,
+		CtwaClid:        ref.CtwaClid,
+		IsNewContact:    isNewContact,
+	}
+
+	now := time.Now()
+	err := a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&referral).Error; err != nil {
+			return err
+		}
+		return tx.Model(contact).Updates(map[string]any{
+			"referral_source_type": ref.SourceType,
+			"referral_source_id":   ref.SourceID,
+			"referral_source_url":  ref.SourceURL,
+			"referred_at":          now,
+		}).Error
+	})
+	if err != nil {
+		a.Log.Error("Failed to record contact referral", "error", err, "contact_id", contact.ID, "source_id", ref.SourceID)
+	}
+}
+
+// handleSystemMessage applies system events to the contact. When a customer
+// changes their number the contact moves to the new number, unless another
+// contact already has it.
+func (a *App) handleSystemMessage(account *models.WhatsAppAccount, contact *models.Contact, sys *IncomingSystem) {
+	if sys.Type != "user_changed_number" || sys.NewWaID == "" || sys.NewWaID == contact.PhoneNumber {
+		return
+	}
+
+	var existing int64
+	a.DB.Model(&models.Contact{}).
+		Where("organization_id = ? AND phone_number = ?", account.OrganizationID, sys.NewWaID).
+		Count(&existing)
+	if existing > 0 {
+		a.Log.Warn("Contact changed number to one that already has a contact",
+			"contact_id", contact.ID, "new_phone", sys.NewWaID)
+		return
+	}
+
+	if err := a.DB.Model(contact).Update("phone_number", sys.NewWaID).Error; err != nil {
+		a.Log.Error("Failed to update changed contact number", "error", err, "contact_id", contact.ID)
+		return
+	}
+	a.Log.Info("Contact changed number", "contact_id", contact.ID, "new_phone", sys.NewWaID)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildIncomingOrder(t *testing.T) {
+	// Meta sends quantities and prices both as numbers and as strings
+	payload := `{
+		"catalog_id": "cat-1",
+		"text": "Deliver after 6pm",
+		"product_items": [
+			{"product_retailer_id": "SKU-1", "quantity": 2, "item_price": 12.5, "currency": "USD"},
+			{"product_retailer_id": "SKU-2", "quantity": "1", "item_price": "0.99", "currency": "USD"}
+		]
+	}`
+	var in IncomingOrder
+	require.NoError(t, json.Unmarshal([]byte(payload), &in))
+
+	order := buildIncomingOrder(&in)
+	assert.Equal(t, "cat-1", order.MetaCatalogID)
+	assert.Equal(t, "Deliver after 6pm", order.Text)
+	assert.Equal(t, models.OrderStatusPending, order.Status)
+	assert.Equal(t, "USD", order.Currency)
+	require.Len(t, order.Items, 2)
+	assert.Equal(t, "SKU-1", order.Items[0].RetailerID)
+	assert.Equal(t, 2, order.Items[0].Quantity)
+	assert.Equal(t, int64(1250), order.Items[0].ItemPrice)
+	assert.Equal(t, 1, order.Items[1].Quantity)
+	assert.Equal(t, int64(99), order.Items[1].ItemPrice)
+	assert.Equal(t, int64(2599), order.Total)
+}
+
+func TestTemplateButtonPayload(t *testing.T) {
+	var msg IncomingTextMessage
+	require.NoError(t, json.Unmarshal([]byte(`{"type":"button","button":{"payload":"TRACK_ORDER","text":"Track order"}}`), &msg))
+	assert.Equal(t, "TRACK_ORDER", templateButtonPayload(msg))
+
+	msg = IncomingTextMessage{Type: "text"}
+	assert.Empty(t, templateButtonPayload(msg))
+}
+
+func TestReferralMetadata(t *testing.T) {
+	data := referralMetadata(&IncomingReferral{
+		SourceType: "ad",
+		SourceID:   "120200",
+		SourceURL:  "https://fb.me/abc",
+		Headline:   "Summer sale",
+		MediaType:  "image",
+		ImageURL:   "https://example.com/ad.jpg",
+	})
+	assert.Equal(t, map[string]any{
+		"source_type": "ad",
+		"source_id":   "120200",
+		"source_url":  "https://fb.me/abc",
+		"headline":    "Summer sale",
+		"media_type":  "image",
+		"image_url":   "https://example.com/ad.jpg",
+	}, data)
+}
+
+func TestEditedContent(t *testing.T) {
+	var msg IncomingTextMessage
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"from": "15550001111",
+		"type": "edit",
+		"edit": {
+			"original_message_id": "wamid.original",
+			"message": {"type": "text", "text": {"body": "See you at 6"}}
+		}
+	}`), &msg))
+	require.NotNil(t, msg.Edit)
+	assert.Equal(t, "wamid.original", msg.Edit.OriginalMessageID)
+	assert.Equal(t, "See you at 6", editedContent(msg.Edit.Message))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"type":"image","image":{"id":"media-1","caption":"New caption"}}`), &msg))
+	assert.Equal(t, "New caption", editedContent(&msg))
+	assert.Empty(t, editedContent(nil))
+}
+
+func TestHandleIncomingEditAndRevoke(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	message := models.Message{
+		OrganizationID:    org.ID,
+		WhatsAppAccount:   account.Name,
+		ContactID:         contact.ID,
+		WhatsAppMessageID: "wamid.HBgLMTU1NTAwMDExMTEVAgASGBQzQUFCQ0RFRkdISUpLTE1OT1BRUgA=",
+		Direction:         models.DirectionIncoming,
+		MessageType:       models.MessageTypeText,
+		Content:           "See you at 5",
+		Status:            models.MessageStatusReceived,
+	}
+	require.NoError(t, app.DB.Create(&message).Error)
+
+	var edit IncomingEdit
+	require.NoError(t, json.Unmarshal([]byte(`{"message":{"type":"text","text":{"body":"See you at 6"}}}`), &edit))
+	edit.OriginalMessageID = message.WhatsAppMessageID
+	app.handleIncomingEdit(account, &edit)
+
+	var edited models.Message
+	require.NoError(t, app.DB.First(&edited, message.ID).Error)
+	assert.Equal(t, "See you at 6", edited.Content)
+	assert.NotNil(t, edited.EditedAt)
+	var updated models.Contact
+	require.NoError(t, app.DB.First(&updated, contact.ID).Error)
+	assert.Equal(t, "See you at 6", updated.LastMessagePreview)
+
+	app.handleIncomingRevoke(account, message.WhatsAppMessageID)
+	// A second deletion of the same message is ignored
+	app.handleIncomingRevoke(account, message.WhatsAppMessageID)
+
+	var revoked models.Message
+	require.NoError(t, app.DB.First(&revoked, message.ID).Error)
+	assert.NotNil(t, revoked.RevokedAt)
+	assert.Nil(t, revoked.RevokedByUserID)
+	require.NoError(t, app.DB.First(&updated, contact.ID).Error)
+	assert.Equal(t, "[Deleted]", updated.LastMessagePreview, "the deleted content is not shown in the preview")
+
+	var revisions []models.MessageRevision
+	require.NoError(t, app.DB.Where("message_id = ?", message.ID).Order("created_at ASC").Find(&revisions).Error)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, models.MessageRevisionEdited, revisions[0].Action)
+	assert.Equal(t, "See you at 5", revisions[0].PreviousContent)
+	assert.Equal(t, "See you at 6", revisions[0].Content)
+	assert.Equal(t, models.MessageRevisionDeleted, revisions[1].Action)
+
+	resp := app.buildMessagesResponse([]models.Message{revoked})[0]
+	assert.Equal(t, map[string]string{"body": ""}, resp.Content, "deleted messages are shown without content")
+	preview := replyPreview(&revoked)
+	assert.True(t, preview.Revoked)
+	assert.True(t, preview.Edited)
+}
+
+func TestCreateIncomingOrder_OncePerMessage(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	var in IncomingOrder
+	require.NoError(t, json.Unmarshal([]byte(`{"catalog_id":"cat-1","product_items":[{"product_retailer_id":"SKU-1","quantity":1,"item_price":10,"currency":"USD"}]}`), &in))
+	wamid := "wamid.order-" + contact.ID.String()
+
+	first, created := app.createIncomingOrder(account, contact, wamid, &in)
+	require.NotNil(t, first)
+	assert.True(t, created)
+
+	retried, created := app.createIncomingOrder(account, contact, wamid, &in)
+	require.NotNil(t, retried)
+	assert.False(t, created, "a retried webhook does not create the order again")
+	assert.Equal(t, first.ID, retried.ID)
+
+	var count int64
+	app.DB.Model(&models.Order{}).Where("contact_id = ?", contact.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestProcessIncomingMessage_OrderDeliveredTwice(t *testing.T) {
+	app := newProcessorTestApp(t)
+	if app.Redis == nil {
+		t.Skip("TEST_REDIS_URL not set, skipping test")
+	}
+	org, account := createProcessorTestOrg(t, app)
+
+	var msg IncomingTextMessage
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"from": "15550001111",
+		"id": "wamid.order-redelivered-`+account.PhoneID+`",
+		"type": "order",
+		"order": {"catalog_id": "cat-1", "product_items": [{"product_retailer_id": "SKU-1", "quantity": 2, "item_price": 5, "currency": "USD"}]}
+	}`), &msg))
+
+	// Meta retries the webhook; the second delivery can race past the message dedup
+	app.processIncomingMessageFull(account.PhoneID, msg, "Buyer")
+	app.processIncomingMessageFull(account.PhoneID, msg, "Buyer")
+
+	var orders []models.Order
+	require.NoError(t, app.DB.Where("organization_id = ?", org.ID).Find(&orders).Error)
+	require.Len(t, orders, 1)
+
+	var events int64
+	app.DB.Model(&models.OrderEvent{}).Where("order_id = ?", orders[0].ID).Count(&events)
+	assert.Equal(t, int64(1), events, "the redelivered order must not be announced again")
+}
@@ -23,6 +23,18 @@ func (a *App) getMediaStoragePath() string {
 	return basePath
 }
 
+// orgMediaDir is the directory of an organization's uploads in media storage
+func orgMediaDir(orgID uuid.UUID) string {
+	return filepath.Join("orgs", orgID.String())
+}
+
+// ownsMediaPath reports whether a path in media storage is one of the
+// organization's uploads. Uploads are referenced by path in canned responses
+// and flows, so the path is checked whenever the file is read.
+func ownsMediaPath(orgID uuid.UUID, relativePath string) bool {
+	return strings.HasPrefix(filepath.Clean(relativePath), orgMediaDir(orgID)+string(os.PathSeparator))
+}
+
 // ensureMediaDir ensures the media directory exists
 func (a *App) ensureMediaDir(subdir string) error {
 	path := filepath.Join(a.getMediaStoragePath(), subdir)
@@ -152,12 +164,11 @@ func (a *App) ServeMedia(r *fastglue.Request) error {
 		return nil
 	}
 
-	// Users without contacts:read permission can only access media from their assigned contacts
-	if !a.HasPermission(userID, models.ResourceContacts, models.ActionRead, orgID) {
-		var contact models.Contact
-		if err := a.DB.Where("id = ? AND assigned_user_id = ?", message.ContactID, userID).First(&contact).Error; err != nil {
-			return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Access denied", nil, "")
-		}
+	// Users without chat.all:read can only access media from their own and their teams' conversations
+	var contact models.Contact
+	if err := a.scopeContacts(a.DB.Where("id = ? AND organization_id = ?", message.ContactID, orgID), userID, orgID).
+		First(&contact).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Access denied", nil, "")
 	}
 
 	// Check if message has media
@@ -165,8 +176,13 @@ func (a *App) ServeMedia(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "No media found", nil, "")
 	}
 
+	return a.serveStoredMedia(r, message.MediaURL)
+}
+
+// serveStoredMedia writes a file from local media storage to the response
+func (a *App) serveStoredMedia(r *fastglue.Request, relativePath string) error {
 	// Security: prevent directory traversal and symlink attacks
-	filePath := filepath.Clean(message.MediaURL)
+	filePath := filepath.Clean(relativePath)
 	baseDir, err := filepath.Abs(a.getMediaStoragePath())
 	if err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Storage configuration error", nil, "")
@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// errMessageRevoked is returned when a message was deleted or retracted already
+var errMessageRevoked = errors.New("message was already revoked")
+
+// IncomingEdit is a customer's edit of a message they sent earlier
+type IncomingEdit struct {
+	OriginalMessageID string               `json:"original_message_id"` // WhatsApp message ID of the edited message
+	Message           *IncomingTextMessage `json:"message"`             // The message with its new content
+}
+
+// IncomingRevoke is a customer deleting a message they sent for everyone
+type IncomingRevoke struct {
+	OriginalMessageID string `json:"original_message_id"` // WhatsApp message ID of the deleted message
+}
+
+// MessageRevisionResponse represents a change to a message in its history
+type MessageRevisionResponse struct {
+	ID              uuid.UUID                    `json:"id"`
+	Action          models.MessageRevisionAction `json:"action"`
+	PreviousContent string                       `json:"previous_content"`
+	Content         string                       `json:"content,omitempty"`
+	UserID          *uuid.UUID                   `json:"user_id,omitempty"`
+	UserName        string                       `json:"user_name,omitempty"`
+	Reason          string                       `json:"reason,omitempty"`
+	CreatedAt       time.Time                    `json:"created_at"`
+}
+
+// UnsendMessageRequest represents the request body for retracting a message
+type UnsendMessageRequest struct {
+	Reason string `json:"reason"`
+}
+
+// UnsendMessage retracts an outgoing message sent by mistake. WhatsApp can't
+// delete a message from the customer's phone, so the message is only hidden in
+// the inbox; its content is kept in the message history. Agents can retract
+// their own messages, other messages need chat:delete.
+func (a *App) UnsendMessage(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	message, err := a.loadConversationMessage(r, orgID, userID)
+	if err != nil {
+		return nil
+	}
+
+	var req UnsendMessageRequest
+	if len(r.RequestCtx.PostBody()) > 0 {
+		if err := a.decodeRequest(r, &req); err != nil {
+			return nil
+		}
+	}
+
+	if message.Direction != models.DirectionOutgoing {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Only outgoing messages can be retracted", nil, "")
+	}
+	if message.RevokedAt != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Message was already retracted", nil, "")
+	}
+	ownMessage := message.SentByUserID != nil && *message.SentByUserID == userID
+	if !ownMessage && !a.HasPermission(userID, models.ResourceChat, models.ActionDelete, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "You can only retract your own messages", nil, "")
+	}
+
+	err = a.revokeMessage(message, models.MessageRevisionRetracted, &userID, strings.TrimSpace(req.Reason))
+	if errors.Is(err, errMessageRevoked) {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Message was already retracted", nil, "")
+	}
+	if err != nil {
+		a.Log.Error("Failed to retract message", "error", err, "message_id", message.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to retract message", nil, "")
+	}
+
+	return r.SendEnvelope(a.buildMessagesResponse([]models.Message{*message})[0])
+}
+
+// GetMessageHistory returns the edits, deletion and retraction of a message, oldest first
+func (a *App) GetMessageHistory(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionRead); err != nil {
+		return nil
+	}
+
+	message, err := a.loadConversationMessage(r, orgID, userID)
+	if err != nil {
+		return nil
+	}
+
+	var revisions []models.MessageRevision
+	if err := a.DB.Where("message_id = ?", message.ID).
+		Preload("User").
+		Order("created_at ASC").
+		Find(&revisions).Error; err != nil {
+		a.Log.Error("Failed to load message history", "error", err, "message_id", message.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load message history", nil, "")
+	}
+
+	result := make([]MessageRevisionResponse, len(revisions))
+	for i, rev := range revisions {
+		result[i] = MessageRevisionResponse{
+			ID:              rev.ID,
+			Action:          rev.Action,
+			PreviousContent: rev.PreviousContent,
+			Content:         rev.Content,
+			UserID:          rev.UserID,
+			Reason:          rev.Reason,
+			CreatedAt:       rev.CreatedAt,
+		}
+		if rev.User != nil {
+			result[i].UserName = rev.User.FullName
+		}
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"message_id": message.ID,
+		"revisions":  result,
+	})
+}
+
+// loadConversationMessage loads the message in the path from a conversation the
+// user may see. An error response has been sent when it fails.
+func (a *App) loadConversationMessage(r *fastglue.Request, orgID, userID uuid.UUID) (*models.Message, error) {
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil, err
+	}
+	messageID, err := parsePathUUID(r, "message_id", "message")
+	if err != nil {
+		return nil, err
+	}
+
+	if !a.canAccessContact(contactID, userID, orgID) {
+		_ = r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
+		return nil, errEnvelopeSent
+	}
+
+	var message models.Message
+	if err := a.DB.Preload("ReplyToMessage").
+		Where("id = ? AND contact_id = ? AND organization_id = ?", messageID, contactID, orgID).
+		First(&message).Error; err != nil {
+		_ = r.SendErrorEnvelope(fasthttp.StatusNotFound, "Message not found", nil, "")
+		return nil, errEnvelopeSent
+	}
+	return &message, nil
+}
+
+// handleIncomingEdit stores a customer's edit of one of their messages and
+// keeps the previous content in the message history
+func (a *App) handleIncomingEdit(account *models.WhatsAppAccount, edit *IncomingEdit) {
+	message, err := a.findMessageByWAMID(account.OrganizationID, edit.OriginalMessageID)
+	if err != nil {
+		a.Log.Warn("Message not found for edit", "wamid", edit.OriginalMessageID)
+		return
+	}
+	if message.Direction != models.DirectionIncoming || message.RevokedAt != nil {
+		return
+	}
+
+	content := editedContent(edit.Message)
+	if content == message.Content {
+		return
+	}
+
+	now := time.Now()
+	revision := models.MessageRevision{
+		OrganizationID:  message.OrganizationID,
+		MessageID:       message.ID,
+		Action:          models.MessageRevisionEdited,
+		PreviousContent: message.Content,
+		Content:         content,
+	}
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(message).Updates(map[string]any{"content": content, "edited_at": now}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&revision).Error
+	})
+	if err != nil {
+		a.Log.Error("Failed to save message edit", "error", err, "message_id", message.ID)
+		return
+	}
+	message.Content = content
+	message.EditedAt = &now
+
+	a.Log.Info("Customer edited message", "message_id", message.ID)
+	a.broadcastMessageUpdate(message)
+	if message.MessageType == models.MessageTypeText {
+		a.updateContactPreview(message, truncateString(content, 100))
+	}
+}
+
+// handleIncomingRevoke marks a message the customer deleted for everyone
+func (a *App) handleIncomingRevoke(account *models.WhatsAppAccount, wamid string) {
+	message, err := a.findMessageByWAMID(account.OrganizationID, wamid)
+	if err != nil {
+		a.Log.Warn("Message not found for deletion", "wamid", wamid)
+		return
+	}
+	if message.Direction != models.DirectionIncoming || message.RevokedAt != nil {
+		return
+	}
+
+	if err := a.revokeMessage(message, models.MessageRevisionDeleted, nil, ""); err != nil && !errors.Is(err, errMessageRevoked) {
+		a.Log.Error("Failed to mark message deleted", "error", err, "message_id", message.ID)
+		return
+	}
+	a.Log.Info("Customer deleted message", "message_id", message.ID)
+}
+
+// revokeMessage marks a message deleted or retracted, records who did it and
+// tells everyone viewing the conversation
+func (a *App) revokeMessage(message *models.Message, action models.MessageRevisionAction, userID *uuid.UUID, reason string) error {
+	now := time.Now()
+	revision := models.MessageRevision{
+		OrganizationID:  message.OrganizationID,
+		MessageID:       message.ID,
+		Action:          action,
+		PreviousContent: message.Content,
+		UserID:          userID,
+		Reason:          reason,
+	}
+	err := a.DB.Transaction(func(tx *gorm.DB) error {
+		// Only the first deletion counts
+		result := tx.Model(&models.Message{}).
+			Where("id = ? AND revoked_at IS NULL", message.ID).
+			Updates(map[string]any{"revoked_at": now, "revoked_by_user_id": userID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errMessageRevoked
+		}
+		return tx.Create(&revision).Error
+	})
+	if err != nil {
+		return err
+	}
+	message.RevokedAt = &now
+	message.RevokedByUserID = userID
+
+	a.broadcastMessageUpdate(message)
+	a.updateContactPreview(message, "[Deleted]")
+	return nil
+}
+
+// updateContactPreview replaces the contact's last message preview after its
+// latest message was edited or revoked
+func (a *App) updateContactPreview(message *models.Message, preview string) {
+	var newer int64
+	if err := a.DB.Model(&models.Message{}).
+		Where("contact_id = ? AND created_at > ?", message.ContactID, message.CreatedAt).
+		Count(&newer).Error; err != nil || newer > 0 {
+		return
+	}
+	if err := a.DB.Model(&models.Contact{}).
+		Where("id = ? AND organization_id = ?", message.ContactID, message.OrganizationID).
+		Update("last_message_preview", preview).Error; err != nil {
+		a.Log.Error("Failed to update last message preview", "error", err, "contact_id", message.ContactID)
+	}
+}
+
+// broadcastMessageUpdate sends the changed message to everyone viewing the conversation
+func (a *App) broadcastMessageUpdate(message *models.Message) {
+	if a.WSHub == nil {
+		return
+	}
+	a.WSHub.BroadcastToConversation(message.OrganizationID, message.ContactID, websocket.WSMessage{
+		Type:    websocket.TypeMessageUpdate,
+		Payload: a.buildMessagesResponse([]models.Message{*message})[0],
+	})
+}
+
+// findMessageByWAMID finds a message of the organization by its WhatsApp message ID.
+// WhatsApp encodes phone numbers in the WAMID prefix, so the same message
+// has different WAMIDs from sender vs recipient perspective. When there is no
+// exact match, we match on the suffix after "FQIA" + 4 chars (type indicator
+// like "ERgS" or "EhgU").
+func (a *App) findMessageByWAMID(orgID uuid.UUID, wamid string) (*models.Message, error) {
+	var message models.Message
+	err := a.DB.Where("organization_id = ? AND whats_app_message_id = ?", orgID, wamid).First(&message).Error
+	if err == nil {
+		return &message, nil
+	}
+
+	idx := strings.Index(wamid, "FQIA")
+	if idx == -1 || idx+8 >= len(wamid) {
+		return nil, err
+	}
+	suffix := wamid[idx+8:]
+	if err := a.DB.Where("organization_id = ? AND whats_app_message_id LIKE ?", orgID, "%"+suffix).First(&message).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// editedContent is the new content of an edited message: its text, or the
+// caption of edited media
+func editedContent(msg *IncomingTextMessage) string {
+	if msg == nil {
+		return ""
+	}
+	switch {
+	case msg.Text != nil:
+		return msg.Text.Body
+	case msg.Image != nil:
+		return msg.Image.Caption
+	case msg.Video != nil:
+		return msg.Video.Caption
+	case msg.Document != nil:
+		return msg.Document.Caption
+	}
+	return ""
+}
+
+// replyPreview is the preview of a replied-to message shown above a reply.
+// Deleted and retracted messages are shown without their content.
+func replyPreview(m *models.Message) *ReplyPreview {
+	preview := &ReplyPreview{
+		ID:          m.ID.String(),
+		Content:     map[string]string{"body": m.Content},
+		MessageType: m.MessageType,
+		Direction:   m.Direction,
+		Edited:      m.EditedAt != nil,
+	}
+	if m.RevokedAt != nil {
+		preview.Content = map[string]string{"body": ""}
+		preview.Revoked = true
+	}
+	return preview
+}
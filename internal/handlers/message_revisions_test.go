@@ -0,0 +1,175 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// createOutgoingMessage creates an outgoing message directly in the database for testing.
+func createOutgoingMessage(t *testing.T, app *handlers.App, contact *models.Contact, sentBy *uuid.UUID, content string) *models.Message {
+	t.Helper()
+
+	message := &models.Message{
+		OrganizationID:    contact.OrganizationID,
+		WhatsAppAccount:   "test-account",
+		ContactID:         contact.ID,
+		WhatsAppMessageID: "wamid." + uuid.New().String(),
+		Direction:         models.DirectionOutgoing,
+		MessageType:       models.MessageTypeText,
+		Content:           content,
+		Status:            models.MessageStatusDelivered,
+		SentByUserID:      sentBy,
+	}
+	require.NoError(t, app.DB.Create(message).Error)
+	return message
+}
+
+func unsendMessage(t *testing.T, app *handlers.App, orgID, userID uuid.UUID, message *models.Message, body map[string]any) *fastglue.Request {
+	t.Helper()
+
+	req := testutil.NewJSONRequest(t, body)
+	testutil.SetAuthContext(req, orgID, userID)
+	testutil.SetPathParam(req, "id", message.ContactID.String())
+	testutil.SetPathParam(req, "message_id", message.ID.String())
+	require.NoError(t, app.UnsendMessage(req))
+	return req
+}
+
+// --- UnsendMessage Tests ---
+
+func TestApp_UnsendMessage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("agent retracts own message", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAgentRole(t, app.DB, org.ID)
+		agent := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		contact := testutil.CreateTestContact(t, app.DB, org.ID)
+		require.NoError(t, app.DB.Model(contact).Update("assigned_user_id", agent.ID).Error)
+		message := createOutgoingMessage(t, app, contact, &agent.ID, "Your code is 1234")
+
+		req := unsendMessage(t, app, org.ID, agent.ID, message, map[string]any{"reason": "Wrong customer"})
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data handlers.MessageResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.NotNil(t, resp.Data.RevokedAt)
+		require.NotNil(t, resp.Data.RevokedByUserID)
+		assert.Equal(t, agent.ID, *resp.Data.RevokedByUserID)
+		assert.Equal(t, map[string]any{"body": ""}, resp.Data.Content)
+
+		var revision models.MessageRevision
+		require.NoError(t, app.DB.Where("message_id = ?", message.ID).First(&revision).Error)
+		assert.Equal(t, models.MessageRevisionRetracted, revision.Action)
+		assert.Equal(t, "Your code is 1234", revision.PreviousContent, "the content is kept for audit")
+		assert.Equal(t, "Wrong customer", revision.Reason)
+
+		req = unsendMessage(t, app, org.ID, agent.ID, message, nil)
+		testutil.AssertErrorResponse(t, req, fasthttp.StatusConflict, "already retracted")
+	})
+
+	t.Run("agent cannot retract others' messages", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAgentRole(t, app.DB, org.ID)
+		agent := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		other := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		contact := testutil.CreateTestContact(t, app.DB, org.ID)
+		require.NoError(t, app.DB.Model(contact).Update("assigned_user_id", agent.ID).Error)
+
+		message := createOutgoingMessage(t, app, contact, &other.ID, "Hello")
+		req := unsendMessage(t, app, org.ID, agent.ID, message, nil)
+		testutil.AssertErrorResponse(t, req, fasthttp.StatusForbidden, "only retract your own messages")
+
+		// Chatbot messages have no sender either
+		message = createOutgoingMessage(t, app, contact, nil, "Welcome!")
+		req = unsendMessage(t, app, org.ID, agent.ID, message, nil)
+		assert.Equal(t, fasthttp.StatusForbidden, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("admin retracts any message", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		admin := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+		message := createOutgoingMessage(t, app, contact, nil, "Welcome!")
+		req := unsendMessage(t, app, org.ID, admin.ID, message, nil)
+		assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("incoming messages cannot be retracted", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		admin := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+		message := createOutgoingMessage(t, app, contact, nil, "Hi")
+		require.NoError(t, app.DB.Model(message).Update("direction", models.DirectionIncoming).Error)
+		req := unsendMessage(t, app, org.ID, admin.ID, message, nil)
+		testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "Only outgoing messages")
+	})
+
+	t.Run("message of another organization", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		otherOrg := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		admin := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		contact := testutil.CreateTestContact(t, app.DB, otherOrg.ID)
+
+		message := createOutgoingMessage(t, app, contact, nil, "Hi")
+		req := unsendMessage(t, app, org.ID, admin.ID, message, nil)
+		assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+	})
+}
+
+// --- GetMessageHistory Tests ---
+
+func TestApp_GetMessageHistory(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	admin := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+	message := createOutgoingMessage(t, app, contact, &admin.ID, "Hello")
+
+	req := unsendMessage(t, app, org.ID, admin.ID, message, map[string]any{"reason": "Typo"})
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, admin.ID)
+	testutil.SetPathParam(req, "id", contact.ID.String())
+	testutil.SetPathParam(req, "message_id", message.ID.String())
+	require.NoError(t, app.GetMessageHistory(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			Revisions []handlers.MessageRevisionResponse `json:"revisions"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	require.Len(t, resp.Data.Revisions, 1)
+	rev := resp.Data.Revisions[0]
+	assert.Equal(t, models.MessageRevisionRetracted, rev.Action)
+	assert.Equal(t, "Hello", rev.PreviousContent)
+	assert.Equal(t, "Typo", rev.Reason)
+	assert.Equal(t, admin.FullName, rev.UserName)
+}
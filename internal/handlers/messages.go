@@ -40,7 +40,7 @@ type OutgoingMessageRequest struct {
 	Caption       string
 
 	// Interactive messages
-	InteractiveType string            // "button", "list", "cta_url"
+	InteractiveType string            // "button", "list", "cta_url", "location_request"
 	BodyText        string            // Body text for interactive messages
 	Buttons         []whatsapp.Button // For button/list messages
 	ButtonText      string            // For CTA URL button
@@ -55,6 +55,7 @@ type OutgoingMessageRequest struct {
 	FlowHeader      string // Optional header text for flow
 	FlowCTA         string // CTA button text (max 20 chars)
 	FlowToken       string // Unique token for flow response tracking
+	FlowAction      string // How the flow opens: navigate (default) or data_exchange
 	FlowFirstScreen string // First screen name to navigate to
 
 	// Reply context
@@ -172,6 +173,8 @@ func (a *App) SendOutgoingMessage(ctx context.Context, req OutgoingMessageReques
 			switch req.InteractiveType {
 			case "cta_url":
 				return a.WhatsApp.SendCTAURLButton(sendCtx, waAccount, req.Contact.PhoneNumber, req.BodyText, req.ButtonText, req.URL)
+			case "location_request":
+				return a.WhatsApp.SendLocationRequest(sendCtx, waAccount, req.Contact.PhoneNumber, req.BodyText)
 			default: // "button" or "list"
 				return a.WhatsApp.SendInteractiveButtons(sendCtx, waAccount, req.Contact.PhoneNumber, req.BodyText, req.Buttons)
 			}
@@ -186,7 +189,7 @@ func (a *App) SendOutgoingMessage(ctx context.Context, req OutgoingMessageReques
 			if req.FlowID == "" {
 				return "", fmt.Errorf("flow ID is required for flow messages")
 			}
-			return a.WhatsApp.SendFlowMessage(sendCtx, waAccount, req.Contact.PhoneNumber, req.FlowID, req.FlowHeader, req.BodyText, req.FlowCTA, req.FlowToken, req.FlowFirstScreen)
+			return a.WhatsApp.SendFlowMessage(sendCtx, waAccount, req.Contact.PhoneNumber, req.FlowID, req.FlowHeader, req.BodyText, req.FlowCTA, req.FlowToken, req.FlowAction, req.FlowFirstScreen)
 
 		default:
 			return "", fmt.Errorf("unsupported message type: %s", req.Type)
@@ -218,6 +221,11 @@ func (a *App) SendOutgoingMessage(ctx context.Context, req OutgoingMessageReques
 		a.UpdateContactChatbotMessage(req.Contact.ID)
 	}
 
+	// Agent replies count towards the transfer's first and next response SLA
+	if opts.SentByUserID != nil {
+		a.UpdateSLAOnAgentReply(req.Account.OrganizationID, req.Contact.ID)
+	}
+
 	// Update contact's last message
 	preview := a.getMessagePreview(req)
 	a.updateContactLastMessage(req.Contact, preview)
@@ -304,6 +312,11 @@ func (a *App) buildInteractiveData(req OutgoingMessageRequest) models.JSONB {
 			"button_text": req.ButtonText,
 			"url":         req.URL,
 		}
+	case "location_request":
+		return models.JSONB{
+			"type": "location_request",
+			"body": req.BodyText,
+		}
 	case "list":
 		rows := make([]interface{}, len(req.Buttons))
 		for i, btn := range req.Buttons {
@@ -353,7 +366,7 @@ func (a *App) finalizeMessageSend(msg *models.Message, req OutgoingMessageReques
 
 	// Broadcast status update via WebSocket
 	if opts.BroadcastWebSocket && a.WSHub != nil {
-		a.WSHub.BroadcastToOrg(req.Account.OrganizationID, websocket.WSMessage{
+		a.WSHub.BroadcastToConversation(req.Account.OrganizationID, req.Contact.ID, websocket.WSMessage{
 			Type: "message_status",
 			Payload: map[string]any{
 				"message_id": msg.ID,
@@ -408,16 +421,11 @@ func (a *App) broadcastNewMessage(orgID uuid.UUID, msg *models.Message, contact
 		// Include reply preview for UI
 		var replyToMsg models.Message
 		if err := a.DB.First(&replyToMsg, msg.ReplyToMessageID).Error; err == nil {
-			payload["reply_to_message"] = map[string]any{
-				"id":           replyToMsg.ID.String(),
-				"content":      replyToMsg.Content,
-				"message_type": replyToMsg.MessageType,
-				"direction":    replyToMsg.Direction,
-			}
+			payload["reply_to_message"] = replyPreview(&replyToMsg)
 		}
 	}
 
-	a.WSHub.BroadcastToOrg(orgID, websocket.WSMessage{
+	a.WSHub.BroadcastToConversation(orgID, contact.ID, websocket.WSMessage{
 		Type:    websocket.TypeNewMessage,
 		Payload: payload,
 	})
@@ -497,6 +505,7 @@ type SendTemplateMessageRequest struct {
 	TemplateID     string            `json:"template_id"`     // Alternative: template UUID
 	TemplateParams map[string]string `json:"template_params"` // Named or positional params
 	AccountName    string            `json:"account_name"`    // Optional: specific WhatsApp account
+	Language       string            `json:"language"`        // Optional: variant language, defaults to the contact's language
 }
 
 // SendTemplateMessage sends a template message to a contact or phone number
@@ -553,11 +562,13 @@ func (a *App) SendTemplateMessage(r *fastglue.Request) error {
 		if err != nil {
 			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid contact_id", nil, "")
 		}
-		c, err := findByIDAndOrg[models.Contact](a.DB, r, cID, orgID, "Contact")
-		if err != nil {
-			return nil
+		// Users without chat.all:read can only message their own and their teams' conversations
+		var c models.Contact
+		if err := a.scopeContacts(a.DB.Where("id = ? AND organization_id = ?", cID, orgID), userID, orgID).
+			First(&c).Error; err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
 		}
-		contact = c
+		contact = &c
 		phoneNumber = c.PhoneNumber
 	} else {
 		// Find or create contact from phone number
@@ -576,10 +587,23 @@ func (a *App) SendTemplateMessage(r *fastglue.Request) error {
 				return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create contact", nil, "")
 			}
 			a.Log.Info("Contact created from API", "contact_id", c.ID, "phone", phoneNumber)
+		} else if !a.canAccessContact(c.ID, userID, orgID) {
+			return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
 		}
 		contact = &c
 	}
 
+	// Switch to the variant in the contact's language when the template has one
+	language := req.Language
+	if language == "" {
+		language = contact.Language
+	}
+	if variant, err := templateutil.LanguageVariant(a.DB, &template, language); err != nil {
+		a.Log.Error("Failed to load template variants", "error", err, "template", template.Name)
+	} else {
+		template = *variant
+	}
+
 	// Get WhatsApp account
 	var account models.WhatsAppAccount
 	if req.AccountName != "" {
@@ -603,23 +627,11 @@ func (a *App) SendTemplateMessage(r *fastglue.Request) error {
 		}
 	}
 
-	// Extract parameter names and resolve values
-	paramNames := templateutil.ExtParamNames(template.BodyContent)
-	bodyParams := templateutil.ResolveParamsFromMap(paramNames, req.TemplateParams)
-
 	// Validate that all required parameters are provided
-	if len(paramNames) > 0 {
-		var missingParams []string
-		for i, name := range paramNames {
-			if i >= len(bodyParams) || bodyParams[i] == "" {
-				missingParams = append(missingParams, name)
-			}
-		}
-		if len(missingParams) > 0 {
-			return r.SendErrorEnvelope(fasthttp.StatusBadRequest,
-				fmt.Sprintf("Missing template parameters: %s. Expected parameters: %v", strings.Join(missingParams, ", "), paramNames),
-				nil, "")
-		}
+	if missingParams := templateutil.MissingBodyParams(template.BodyContent, req.TemplateParams); len(missingParams) > 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest,
+			fmt.Sprintf("Missing template parameters: %s. Expected parameters: %v", strings.Join(missingParams, ", "), templateutil.ExtParamNames(template.BodyContent)),
+			nil, "")
 	}
 
 	// Send using unified message sender
@@ -15,6 +15,7 @@ import (
 	"github.com/shridarpatil/whatomate/test/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
 )
 
 // mockWhatsAppServer creates a mock WhatsApp API server for testing.
@@ -1034,3 +1035,32 @@ func TestResolveParams_WrongParamNames(t *testing.T) {
 	assert.Equal(t, "", result[0])
 	assert.Equal(t, "", result[1])
 }
+
+func TestApp_SendTemplateMessage_ScopedToVisibleContacts(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	agent := createTestAgent(t, app, org.ID)
+	otherAgent := createTestAgent(t, app, org.ID)
+	tmpl := createTestTemplateInDB(t, app, org.ID, account.Name, "order_update", "APPROVED")
+
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+	require.NoError(t, app.DB.Model(contact).Update("assigned_user_id", otherAgent.ID).Error)
+
+	for _, body := range []map[string]any{
+		{"contact_id": contact.ID.String(), "template_id": tmpl.ID.String()},
+		{"phone_number": contact.PhoneNumber, "template_id": tmpl.ID.String()},
+	} {
+		req := testutil.NewJSONRequest(t, body)
+		testutil.SetAuthContext(req, org.ID, agent.ID)
+
+		require.NoError(t, app.SendTemplateMessage(req))
+		testutil.AssertErrorResponse(t, req, fasthttp.StatusNotFound, "Contact not found")
+	}
+
+	var count int64
+	app.DB.Model(&models.Message{}).Where("contact_id = ?", contact.ID).Count(&count)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/queue"
+)
+
+// noteAttachmentOrphanAge is how long an upload may stay unlinked to a note
+// before it is deleted
+const noteAttachmentOrphanAge = 24 * time.Hour
+
+// noteAttachmentBatchSize limits how many orphaned uploads are deleted per tick
+const noteAttachmentBatchSize = 100
+
+// noteAttachmentLease names the leader lease of the note attachment processor
+const noteAttachmentLease = "note_attachments"
+
+// NoteAttachmentProcessor deletes note attachments that were uploaded but
+// never linked to a note. With several replicas only the one holding the
+// leader lease deletes them.
+type NoteAttachmentProcessor struct {
+	app      *App
+	interval time.Duration
+	lease    *queue.Lease
+	stopCh   chan struct{}
+}
+
+// NewNoteAttachmentProcessor creates a new note attachment cleanup processor
+func NewNoteAttachmentProcessor(app *App, interval time.Duration) *NoteAttachmentProcessor {
+	return &NoteAttachmentProcessor{
+		app:      app,
+		interval: interval,
+		lease:    app.newProcessorLease(noteAttachmentLease, 2*interval),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the note attachment cleanup loop
+func (p *NoteAttachmentProcessor) Start(ctx context.Context) {
+	p.app.Log.Info("Note attachment processor started", "interval", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	defer p.app.releaseLease(p.lease, noteAttachmentLease)
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.app.Log.Info("Note attachment processor stopped by context")
+			return
+		case <-p.stopCh:
+			p.app.Log.Info("Note attachment processor stopped")
+			return
+		case <-ticker.C:
+			if p.app.holdLease(p.lease, noteAttachmentLease) {
+				p.app.deleteOrphanedNoteAttachments(time.Now().Add(-noteAttachmentOrphanAge))
+			}
+		}
+	}
+}
+
+// Stop stops the note attachment processor
+func (p *NoteAttachmentProcessor) Stop() {
+	close(p.stopCh)
+}
+
+// deleteOrphanedNoteAttachments deletes uploads created before the cutoff that
+// were never linked to a note, along with their files
+func (a *App) deleteOrphanedNoteAttachments(before time.Time) {
+	var attachments []models.ConversationNoteAttachment
+	if err := a.DB.Where("note_id IS NULL AND created_at < ?", before).
+		Order("created_at ASC").
+		Limit(noteAttachmentBatchSize).
+		Find(&attachments).Error; err != nil {
+		a.Log.Error("Failed to load orphaned note attachments", "error", err)
+		return
+	}
+	if len(attachments) == 0 {
+		return
+	}
+
+	ids := make([]uuid.UUID, len(attachments))
+	for i, att := range attachments {
+		ids[i] = att.ID
+	}
+	// note_id IS NULL again so an upload linked in the meantime is kept
+	result := a.DB.Where("id IN ? AND note_id IS NULL", ids).Delete(&models.ConversationNoteAttachment{})
+	if result.Error != nil {
+		a.Log.Error("Failed to delete orphaned note attachments", "error", result.Error)
+		return
+	}
+	if result.RowsAffected != int64(len(attachments)) {
+		// Some were linked in the meantime, keep their files
+		var kept []models.ConversationNoteAttachment
+		a.DB.Where("id IN ?", ids).Find(&kept)
+		linked := make(map[uuid.UUID]bool, len(kept))
+		for _, att := range kept {
+			linked[att.ID] = true
+		}
+		removed := attachments[:0]
+		for _, att := range attachments {
+			if !linked[att.ID] {
+				removed = append(removed, att)
+			}
+		}
+		attachments = removed
+	}
+
+	a.removeNoteAttachmentFiles(attachments)
+	a.Log.Info("Deleted orphaned note attachments", "count", len(attachments))
+}
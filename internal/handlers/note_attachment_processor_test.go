@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteOrphanedNoteAttachments(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, _ := createProcessorTestOrg(t, app)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	note := models.ConversationNote{
+		OrganizationID: org.ID,
+		ContactID:      contact.ID,
+		CreatedByID:    user.ID,
+		Content:        "See attached",
+	}
+	require.NoError(t, app.DB.Create(&note).Error)
+
+	old := time.Now().Add(-2 * noteAttachmentOrphanAge)
+	attach := func(createdAt time.Time, linked bool) models.ConversationNoteAttachment {
+		att := models.ConversationNoteAttachment{
+			BaseModel:      models.BaseModel{CreatedAt: createdAt},
+			OrganizationID: org.ID,
+			ContactID:      contact.ID,
+			UploadedByID:   user.ID,
+			FileName:       "invoice.pdf",
+			MimeType:       "application/pdf",
+			Path:           "documents/invoice.pdf",
+		}
+		if linked {
+			att.NoteID = &note.ID
+		}
+		require.NoError(t, app.DB.Create(&att).Error)
+		return att
+	}
+	orphaned := attach(old, false)
+	recent := attach(time.Now(), false)
+	linked := attach(old, true)
+
+	app.deleteOrphanedNoteAttachments(time.Now().Add(-noteAttachmentOrphanAge))
+
+	var remaining []models.ConversationNoteAttachment
+	require.NoError(t, app.DB.Where("contact_id = ?", contact.ID).Find(&remaining).Error)
+	ids := make([]any, len(remaining))
+	for i, att := range remaining {
+		ids[i] = att.ID
+	}
+	assert.ElementsMatch(t, []any{recent.ID, linked.ID}, ids, "only the old unlinked upload is deleted")
+	assert.NotContains(t, ids, orphaned.ID)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// maxNoteAttachmentSize is the largest file that can be attached to a note
+const maxNoteAttachmentSize = 16 << 20
+
+// errInvalidNoteAttachments is returned when a note references attachments
+// that are missing, already linked or uploaded by someone else
+var errInvalidNoteAttachments = errors.New("invalid note attachments")
+
+// UploadNoteAttachment stores a file to be attached to a note on the contact.
+// The returned ID is passed in attachment_ids when creating the note.
+func (a *App) UploadNoteAttachment(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil
+	}
+
+	if !a.canAccessContact(contactID, userID, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
+	}
+
+	form, err := r.RequestCtx.MultipartForm()
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid multipart form", nil, "")
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "file is required", nil, "")
+	}
+	fileHeader := files[0]
+	if fileHeader.Size > maxNoteAttachmentSize {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("File is larger than %d MB", maxNoteAttachmentSize>>20), nil, "")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to read file", nil, "")
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to read file data", nil, "")
+	}
+
+	mimeType := fileHeader.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	localPath, err := a.saveMediaLocally(data, mimeType, fileHeader.Filename)
+	if err != nil {
+		a.Log.Error("Failed to save note attachment", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save attachment", nil, "")
+	}
+
+	attachment := models.ConversationNoteAttachment{
+		OrganizationID: orgID,
+		ContactID:      contactID,
+		UploadedByID:   userID,
+		FileName:       filepath.Base(fileHeader.Filename),
+		MimeType:       mimeType,
+		Size:           int64(len(data)),
+		Path:           localPath,
+	}
+	if err := a.DB.Create(&attachment).Error; err != nil {
+		a.Log.Error("Failed to create note attachment", "error", err)
+		a.removeNoteAttachmentFiles([]models.ConversationNoteAttachment{attachment})
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save attachment", nil, "")
+	}
+
+	return r.SendEnvelope(noteAttachmentToResponse(attachment))
+}
+
+// ServeNoteAttachment serves a note attachment to users who can see the conversation
+func (a *App) ServeNoteAttachment(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionRead); err != nil {
+		return nil
+	}
+
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil
+	}
+
+	attachmentID, err := parsePathUUID(r, "attachment_id", "attachment")
+	if err != nil {
+		return nil
+	}
+
+	if !a.canAccessContact(contactID, userID, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
+	}
+
+	var attachment models.ConversationNoteAttachment
+	if err := a.DB.Where("id = ? AND organization_id = ? AND contact_id = ?", attachmentID, orgID, contactID).
+		First(&attachment).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Attachment not found", nil, "")
+	}
+
+	r.RequestCtx.Response.Header.Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", attachment.FileName))
+	return a.serveStoredMedia(r, attachment.Path)
+}
+
+// removeNoteAttachmentFiles deletes the stored files of note attachments
+func (a *App) removeNoteAttachmentFiles(attachments []models.ConversationNoteAttachment) {
+	for _, att := range attachments {
+		path := filepath.Join(a.getMediaStoragePath(), filepath.Clean(att.Path))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			a.Log.Warn("Failed to remove note attachment file", "path", att.Path, "error", err)
+		}
+	}
+}
+
+func noteAttachmentToResponse(att models.ConversationNoteAttachment) NoteAttachmentResponse {
+	return NoteAttachmentResponse{
+		ID:        att.ID,
+		NoteID:    att.NoteID,
+		FileName:  att.FileName,
+		MimeType:  att.MimeType,
+		Size:      att.Size,
+		CreatedAt: att.CreatedAt,
+	}
+}
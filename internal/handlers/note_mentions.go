@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"regexp"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// mentionPattern matches the mention markup the note editor inserts:
+// @[Display Name](user:<uuid>) or @[Team Name](team:<uuid>)
+var mentionPattern = regexp.MustCompile(`@\[([^\]\n]+)\]\((user|team):([0-9a-fA-F-]{36})\)`)
+
+// NoteMention is a user or team mentioned in a conversation note
+type NoteMention struct {
+	Type string    `json:"type"` // user or team
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// parseNoteMentions returns the distinct mentions in a note, in order of appearance
+func parseNoteMentions(content string) []NoteMention {
+	var mentions []NoteMention
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		id, err := uuid.Parse(m[3])
+		if err != nil {
+			continue
+		}
+		mention := NoteMention{Type: m[2], ID: id, Name: m[1]}
+		if !slices.ContainsFunc(mentions, func(x NoteMention) bool { return x.Type == mention.Type && x.ID == mention.ID }) {
+			mentions = append(mentions, mention)
+		}
+	}
+	return mentions
+}
+
+// renderNoteMentions replaces mention markup with plain @Name text
+func renderNoteMentions(content string) string {
+	return mentionPattern.ReplaceAllString(content, "@$1")
+}
+
+// newNoteMentions returns the mentions in content that are not in previous
+func newNoteMentions(previous, content string) []NoteMention {
+	old := parseNoteMentions(previous)
+	var added []NoteMention
+	for _, m := range parseNoteMentions(content) {
+		if !slices.ContainsFunc(old, func(x NoteMention) bool { return x.Type == m.Type && x.ID == m.ID }) {
+			added = append(added, m)
+		}
+	}
+	return added
+}
+
+// mentionRecipients resolves mentioned users and the members of mentioned
+// teams to the users who can see the contact's conversation, without the author
+func (a *App) mentionRecipients(orgID, contactID, authorID uuid.UUID, mentions []NoteMention) []uuid.UUID {
+	var userIDs, teamIDs []uuid.UUID
+	for _, m := range mentions {
+		switch m.Type {
+		case "user":
+			userIDs = append(userIDs, m.ID)
+		case "team":
+			teamIDs = append(teamIDs, m.ID)
+		}
+	}
+
+	var candidates []uuid.UUID
+	if len(userIDs) > 0 {
+		var members []uuid.UUID
+		a.DB.Model(&models.UserOrganization{}).
+			Where("organization_id = ? AND user_id IN ?", orgID, userIDs).
+			Pluck("user_id", &members)
+		candidates = append(candidates, members...)
+	}
+	if len(teamIDs) > 0 {
+		var members []uuid.UUID
+		a.DB.Model(&models.TeamMember{}).
+			Joins("JOIN teams ON teams.id = team_members.team_id AND teams.deleted_at IS NULL").
+			Where("team_members.team_id IN ? AND teams.organization_id = ?", teamIDs, orgID).
+			Pluck("team_members.user_id", &members)
+		candidates = append(candidates, members...)
+	}
+
+	slices.SortFunc(candidates, func(x, y uuid.UUID) int { return slices.Compare(x[:], y[:]) })
+	candidates = slices.Compact(candidates)
+	candidates = slices.DeleteFunc(candidates, func(id uuid.UUID) bool { return id == authorID })
+	return a.filterConversationViewers(orgID, contactID, candidates)
+}
+
+// filterConversationViewers keeps the users who can see the contact's conversation
+func (a *App) filterConversationViewers(orgID, contactID uuid.UUID, userIDs []uuid.UUID) []uuid.UUID {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	visible, ok := a.contactVisibility(orgID, contactID)
+	if !ok {
+		return nil
+	}
+	scopes := a.conversationScopes(orgID, userIDs)
+	var viewers []uuid.UUID
+	for _, userID := range userIDs {
+		if visible(scopes[userID]) {
+			viewers = append(viewers, userID)
+		}
+	}
+	return viewers
+}
+
+// notifyNoteActivity notifies users mentioned in a note and the author of the
+// note replied to, and dispatches the note.mention webhook
+func (a *App) notifyNoteActivity(note *models.ConversationNote, author *models.User, mentions []NoteMention, parent *models.ConversationNote) {
+	mentioned := a.mentionRecipients(note.OrganizationID, note.ContactID, author.ID, mentions)
+	body := renderNoteMentions(note.Content)
+
+	notifications := make([]models.UserNotification, 0, len(mentioned)+1)
+	for _, userID := range mentioned {
+		notifications = append(notifications, newNoteNotification(note, author, userID,
+			models.NotificationTypeMention, author.FullName+" mentioned you in a note", body))
+	}
+	if parent != nil && parent.CreatedByID != author.ID && !slices.Contains(mentioned, parent.CreatedByID) &&
+		len(a.filterConversationViewers(note.OrganizationID, note.ContactID, []uuid.UUID{parent.CreatedByID})) > 0 {
+		notifications = append(notifications, newNoteNotification(note, author, parent.CreatedByID,
+			models.NotificationTypeNoteReply, author.FullName+" replied to your note", body))
+	}
+	a.notifyUsers(notifications)
+
+	if len(mentioned) > 0 {
+		a.dispatchNoteMentionWebhook(note, author, mentions, mentioned, body)
+	}
+}
+
+func newNoteNotification(note *models.ConversationNote, author *models.User, userID uuid.UUID, typ models.NotificationType, title, body string) models.UserNotification {
+	return models.UserNotification{
+		BaseModel:      models.BaseModel{ID: uuid.New()},
+		OrganizationID: note.OrganizationID,
+		UserID:         userID,
+		Type:           typ,
+		ActorID:        &author.ID,
+		ContactID:      &note.ContactID,
+		NoteID:         &note.ID,
+		Title:          title,
+		Body:           body,
+		Actor:          author,
+	}
+}
+
+// dispatchNoteMentionWebhook sends the note.mention event for the notified users
+func (a *App) dispatchNoteMentionWebhook(note *models.ConversationNote, author *models.User, mentions []NoteMention, mentioned []uuid.UUID, body string) {
+	var contact models.Contact
+	a.DB.Select("id, phone_number, profile_name").Where("id = ?", note.ContactID).First(&contact)
+
+	var users []models.User
+	a.DB.Select("id, full_name, email").Where("id IN ?", mentioned).Find(&users)
+
+	data := NoteMentionEventData{
+		NoteID:         note.ID.String(),
+		ContactID:      note.ContactID.String(),
+		ContactPhone:   contact.PhoneNumber,
+		ContactName:    contact.ProfileName,
+		AuthorID:       author.ID.String(),
+		AuthorName:     author.FullName,
+		Content:        body,
+		MentionedUsers: make([]MentionedUserData, len(users)),
+	}
+	if note.ParentID != nil {
+		parentID := note.ParentID.String()
+		data.ParentNoteID = &parentID
+	}
+	for i, u := range users {
+		data.MentionedUsers[i] = MentionedUserData{UserID: u.ID.String(), Name: u.FullName, Email: u.Email}
+	}
+	for _, m := range mentions {
+		if m.Type == "team" {
+			data.MentionedTeamIDs = append(data.MentionedTeamIDs, m.ID.String())
+		}
+	}
+
+	a.DispatchWebhook(note.OrganizationID, models.WebhookEventNoteMention, data)
+}
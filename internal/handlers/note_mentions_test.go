@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNoteMentions(t *testing.T) {
+	alice := uuid.New()
+	support := uuid.New()
+	content := "@[Alice Smith](user:" + alice.String() + ") can you check with @[Support](team:" + support.String() + ")? " +
+		"Thanks @[Alice](user:" + alice.String() + ")"
+
+	mentions := parseNoteMentions(content)
+	require.Len(t, mentions, 2, "repeated mentions are reported once")
+	assert.Equal(t, NoteMention{Type: "user", ID: alice, Name: "Alice Smith"}, mentions[0])
+	assert.Equal(t, NoteMention{Type: "team", ID: support, Name: "Support"}, mentions[1])
+
+	assert.Empty(t, parseNoteMentions("email me at alice@example.com"))
+	assert.Empty(t, parseNoteMentions("@[Bob](user:not-a-uuid-not-a-uuid-not-a-uuid-00000)"))
+	assert.Empty(t, parseNoteMentions("@[Bob](group:"+uuid.NewString()+")"))
+}
+
+func TestRenderNoteMentions(t *testing.T) {
+	content := "Ping @[Alice Smith](user:" + uuid.NewString() + ") and @[Support](team:" + uuid.NewString() + ")"
+	assert.Equal(t, "Ping @Alice Smith and @Support", renderNoteMentions(content))
+	assert.Equal(t, "No mentions", renderNoteMentions("No mentions"))
+}
+
+func TestNewNoteMentions(t *testing.T) {
+	alice := "@[Alice](user:" + uuid.NewString() + ")"
+	bob := uuid.New()
+	bobMention := "@[Bob](user:" + bob.String() + ")"
+
+	added := newNoteMentions("Hi "+alice, "Hi "+alice+" and "+bobMention)
+	require.Len(t, added, 1)
+	assert.Equal(t, bob, added[0].ID)
+
+	assert.Empty(t, newNoteMentions("Hi "+alice, "Hello "+alice), "editing text around a mention does not notify again")
+	assert.Len(t, newNoteMentions("", alice+" "+bobMention), 2)
+}
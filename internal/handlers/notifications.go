@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// NotificationResponse represents a notification in the user's inbox
+type NotificationResponse struct {
+	ID          uuid.UUID               `json:"id"`
+	Type        models.NotificationType `json:"type"`
+	ActorID     *uuid.UUID              `json:"actor_id,omitempty"`
+	ActorName   string                  `json:"actor_name,omitempty"`
+	ContactID   *uuid.UUID              `json:"contact_id,omitempty"`
+	ContactName string                  `json:"contact_name,omitempty"`
+	NoteID      *uuid.UUID              `json:"note_id,omitempty"`
+	Title       string                  `json:"title"`
+	Body        string                  `json:"body"`
+	ReadAt      *time.Time              `json:"read_at,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+}
+
+// ListNotifications returns the current user's notifications, newest first
+func (a *App) ListNotifications(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	pg := parsePagination(r)
+	query := a.DB.Model(&models.UserNotification{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID)
+
+	var unreadCount int64
+	query.Session(&gorm.Session{}).Where("read_at IS NULL").Count(&unreadCount)
+
+	if string(r.RequestCtx.QueryArgs().Peek("unread")) == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	var notifications []models.UserNotification
+	if err := pg.Apply(query.Preload("Actor").Preload("Contact").Order("created_at DESC")).
+		Find(&notifications).Error; err != nil {
+		a.Log.Error("Failed to list notifications", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list notifications", nil, "")
+	}
+
+	result := make([]NotificationResponse, len(notifications))
+	for i, n := range notifications {
+		result[i] = notificationToResponse(n)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"notifications": result,
+		"total":         total,
+		"unread_count":  unreadCount,
+		"page":          pg.Page,
+		"limit":         pg.Limit,
+	})
+}
+
+// MarkNotificationRead marks one of the current user's notifications as read
+func (a *App) MarkNotificationRead(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "notification")
+	if err != nil {
+		return nil
+	}
+
+	result := a.DB.Model(&models.UserNotification{}).
+		Where("id = ? AND organization_id = ? AND user_id = ?", id, orgID, userID).
+		Where("read_at IS NULL").
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		a.Log.Error("Failed to mark notification read", "error", result.Error)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update notification", nil, "")
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		a.DB.Model(&models.UserNotification{}).
+			Where("id = ? AND organization_id = ? AND user_id = ?", id, orgID, userID).
+			Count(&count)
+		if count == 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Notification not found", nil, "")
+		}
+	}
+
+	return r.SendEnvelope(map[string]string{"message": "Notification marked as read"})
+}
+
+// MarkAllNotificationsRead marks all of the current user's notifications as read
+func (a *App) MarkAllNotificationsRead(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	result := a.DB.Model(&models.UserNotification{}).
+		Where("organization_id = ? AND user_id = ? AND read_at IS NULL", orgID, userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		a.Log.Error("Failed to mark notifications read", "error", result.Error)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update notifications", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{"updated": result.RowsAffected})
+}
+
+// notifyUsers stores notifications in the recipients' inboxes and pushes
+// them to their open sessions
+func (a *App) notifyUsers(notifications []models.UserNotification) {
+	if len(notifications) == 0 {
+		return
+	}
+	if err := a.DB.Omit("Actor", "Contact").Create(&notifications).Error; err != nil {
+		a.Log.Error("Failed to create notifications", "error", err)
+		return
+	}
+	if a.WSHub == nil {
+		return
+	}
+	for _, n := range notifications {
+		a.WSHub.BroadcastToUser(n.OrganizationID, n.UserID, websocket.WSMessage{
+			Type:    websocket.TypeNotification,
+			Payload: notificationToResponse(n),
+		})
+	}
+}
+
+func notificationToResponse(n models.UserNotification) NotificationResponse {
+	resp := NotificationResponse{
+		ID:        n.ID,
+		Type:      n.Type,
+		ActorID:   n.ActorID,
+		ContactID: n.ContactID,
+		NoteID:    n.NoteID,
+		Title:     n.Title,
+		Body:      n.Body,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+	if n.Actor != nil {
+		resp.ActorName = n.Actor.FullName
+	}
+	if n.Contact != nil {
+		resp.ContactName = n.Contact.ProfileName
+	}
+	return resp
+}
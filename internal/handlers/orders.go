@@ -0,0 +1,775 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// orderTransitions lists the statuses an order may move to from each status.
+// Shipped and cancelled orders are final.
+var orderTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderStatusPending:   {models.OrderStatusConfirmed, models.OrderStatusPaid, models.OrderStatusCancelled},
+	models.OrderStatusConfirmed: {models.OrderStatusPaid, models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusPaid:      {models.OrderStatusShipped, models.OrderStatusCancelled},
+}
+
+// orderStatuses are all order statuses, in lifecycle order
+var orderStatuses = []models.OrderStatus{
+	models.OrderStatusPending,
+	models.OrderStatusConfirmed,
+	models.OrderStatusPaid,
+	models.OrderStatusShipped,
+	models.OrderStatusCancelled,
+}
+
+// contactPanelOrders is how many recent orders the inbox order panel shows
+const contactPanelOrders = 10
+
+// errOrderChanged is returned when an order's status changed during an update
+var errOrderChanged = errors.New("order status changed")
+
+// OrderItemResponse represents a product line of an order
+type OrderItemResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	ProductID  *uuid.UUID `json:"product_id,omitempty"`
+	RetailerID string     `json:"retailer_id"`
+	Name       string     `json:"name"`
+	ImageURL   string     `json:"image_url,omitempty"`
+	Quantity   int        `json:"quantity"`
+	ItemPrice  int64      `json:"item_price"`
+	Currency   string     `json:"currency"`
+}
+
+// OrderEventResponse represents a status change of an order
+type OrderEventResponse struct {
+	ID                    uuid.UUID          `json:"id"`
+	FromStatus            models.OrderStatus `json:"from_status,omitempty"`
+	ToStatus              models.OrderStatus `json:"to_status"`
+	Note                  string             `json:"note,omitempty"`
+	UserID                *uuid.UUID         `json:"user_id,omitempty"`
+	UserName              string             `json:"user_name,omitempty"`
+	NotificationMessageID *uuid.UUID         `json:"notification_message_id,omitempty"`
+	NotificationError     string             `json:"notification_error,omitempty"`
+	CreatedAt             time.Time          `json:"created_at"`
+}
+
+// OrderResponse represents the API response for an order
+type OrderResponse struct {
+	ID              uuid.UUID            `json:"id"`
+	Reference       string               `json:"reference"`
+	ContactID       uuid.UUID            `json:"contact_id"`
+	ContactName     string               `json:"contact_name"`
+	ContactPhone    string               `json:"contact_phone"`
+	WhatsAppAccount string               `json:"whatsapp_account"`
+	CatalogID       *uuid.UUID           `json:"catalog_id,omitempty"`
+	MetaCatalogID   string               `json:"meta_catalog_id"`
+	MessageID       *uuid.UUID           `json:"message_id,omitempty"`
+	Text            string               `json:"text"`
+	Currency        string               `json:"currency"`
+	Total           int64                `json:"total"`
+	ItemCount       int                  `json:"item_count"`
+	Status          models.OrderStatus   `json:"status"`
+	NextStatuses    []models.OrderStatus `json:"next_statuses"`
+	TrackingNumber  string               `json:"tracking_number,omitempty"`
+	TrackingURL     string               `json:"tracking_url,omitempty"`
+	CancelReason    string               `json:"cancel_reason,omitempty"`
+	ConfirmedAt     *time.Time           `json:"confirmed_at,omitempty"`
+	PaidAt          *time.Time           `json:"paid_at,omitempty"`
+	ShippedAt       *time.Time           `json:"shipped_at,omitempty"`
+	CancelledAt     *time.Time           `json:"cancelled_at,omitempty"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+	Items           []OrderItemResponse  `json:"items"`
+	Events          []OrderEventResponse `json:"events,omitempty"`
+}
+
+// UpdateOrderStatusRequest represents the request body for changing an order's status
+type UpdateOrderStatusRequest struct {
+	Status         models.OrderStatus `json:"status"`
+	Note           string             `json:"note"`
+	TrackingNumber string             `json:"tracking_number"` // Shipped orders
+	TrackingURL    string             `json:"tracking_url"`    // Shipped orders
+	CancelReason   string             `json:"cancel_reason"`   // Cancelled orders
+	Notify         *bool              `json:"notify"`          // Send the status's notification template (default: true)
+}
+
+// OrderNotificationRequest configures the template sent for one order status
+type OrderNotificationRequest struct {
+	Status       models.OrderStatus     `json:"status"`
+	TemplateName string                 `json:"template_name"`
+	Language     string                 `json:"language"`
+	Params       map[string]interface{} `json:"params"`
+	IsEnabled    bool                   `json:"is_enabled"`
+}
+
+// OrderEventData represents data for order webhook events
+type OrderEventData struct {
+	OrderID         string               `json:"order_id"`
+	Reference       string               `json:"reference"`
+	ContactID       string               `json:"contact_id"`
+	ContactPhone    string               `json:"contact_phone"`
+	ContactName     string               `json:"contact_name"`
+	WhatsAppAccount string               `json:"whatsapp_account"`
+	Status          models.OrderStatus   `json:"status"`
+	PreviousStatus  models.OrderStatus   `json:"previous_status,omitempty"`
+	Currency        string               `json:"currency"`
+	Total           int64                `json:"total"`
+	Items           []OrderItemEventData `json:"items"`
+	TrackingNumber  string               `json:"tracking_number,omitempty"`
+	TrackingURL     string               `json:"tracking_url,omitempty"`
+	CancelReason    string               `json:"cancel_reason,omitempty"`
+	Note            string               `json:"note,omitempty"`
+	ChangedBy       *string              `json:"changed_by,omitempty"` // User ID, nil for the system
+}
+
+// OrderItemEventData represents an order line in webhook events
+type OrderItemEventData struct {
+	RetailerID string `json:"retailer_id"`
+	Name       string `json:"name"`
+	Quantity   int    `json:"quantity"`
+	ItemPrice  int64  `json:"item_price"`
+}
+
+// ListOrders returns the organization's orders with optional filters
+func (a *App) ListOrders(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceOrders, models.ActionRead); err != nil {
+		return nil
+	}
+
+	query := a.scopeOrders(a.DB.Model(&models.Order{}).Where("organization_id = ?", orgID), userID, orgID)
+
+	args := r.RequestCtx.QueryArgs()
+	if status := string(args.Peek("status")); status != "" {
+		query = query.Where("status IN ?", strings.Split(status, ","))
+	}
+	if contactID := string(args.Peek("contact_id")); contactID != "" {
+		id, err := uuid.Parse(contactID)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid contact_id", nil, "")
+		}
+		query = query.Where("contact_id = ?", id)
+	}
+	if account := string(args.Peek("whatsapp_account")); account != "" {
+		query = query.Where("whats_app_account = ?", account)
+	}
+	if from := string(args.Peek("from")); from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid from date, use YYYY-MM-DD", nil, "")
+		}
+		query = query.Where("created_at >= ?", t)
+	}
+	if to := string(args.Peek("to")); to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid to date, use YYYY-MM-DD", nil, "")
+		}
+		query = query.Where("created_at < ?", t.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	query.Count(&total)
+
+	pg := parsePagination(r)
+	var orders []models.Order
+	if err := pg.Apply(query.Preload("Contact").Preload("Items.Product").Order("created_at DESC")).Find(&orders).Error; err != nil {
+		a.Log.Error("Failed to list orders", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list orders", nil, "")
+	}
+
+	shouldMask := a.ShouldMaskPhoneNumbers(orgID)
+	result := make([]OrderResponse, len(orders))
+	for i := range orders {
+		result[i] = orderToResponse(&orders[i], shouldMask)
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"orders": result,
+		"total":  total,
+		"page":   pg.Page,
+		"limit":  pg.Limit,
+	})
+}
+
+// GetOrder returns an order with its items and status history
+func (a *App) GetOrder(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceOrders, models.ActionRead); err != nil {
+		return nil
+	}
+
+	order, err := a.loadOrder(r, orgID, userID)
+	if err != nil {
+		return nil
+	}
+
+	return r.SendEnvelope(orderToResponse(order, a.ShouldMaskPhoneNumbers(orgID)))
+}
+
+// UpdateOrderStatus moves an order to its next status and notifies the customer
+// with the status's template
+func (a *App) UpdateOrderStatus(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceOrders, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	var req UpdateOrderStatusRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	order, err := a.loadOrder(r, orgID, userID)
+	if err != nil {
+		return nil
+	}
+
+	if !canTransitionOrder(order.Status, req.Status) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest,
+			fmt.Sprintf("Cannot change order from %s to %s", order.Status, req.Status), nil, "")
+	}
+
+	previous := order.Status
+	now := time.Now()
+	order.Status = req.Status
+	updates := map[string]interface{}{"status": req.Status}
+	switch req.Status {
+	case models.OrderStatusConfirmed:
+		order.ConfirmedAt = &now
+		updates["confirmed_at"] = now
+	case models.OrderStatusPaid:
+		order.PaidAt = &now
+		updates["paid_at"] = now
+	case models.OrderStatusShipped:
+		order.ShippedAt = &now
+		order.TrackingNumber = strings.TrimSpace(req.TrackingNumber)
+		order.TrackingURL = strings.TrimSpace(req.TrackingURL)
+		updates["shipped_at"] = now
+		updates["tracking_number"] = order.TrackingNumber
+		updates["tracking_url"] = order.TrackingURL
+	case models.OrderStatusCancelled:
+		order.CancelledAt = &now
+		order.CancelReason = strings.TrimSpace(req.CancelReason)
+		updates["cancelled_at"] = now
+		updates["cancel_reason"] = order.CancelReason
+	}
+
+	event := models.OrderEvent{
+		OrderID:    order.ID,
+		FromStatus: previous,
+		ToStatus:   req.Status,
+		Note:       strings.TrimSpace(req.Note),
+		UserID:     &userID,
+	}
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		// Only move the order if nobody changed it meanwhile
+		result := tx.Model(&models.Order{}).Where("id = ? AND status = ?", order.ID, previous).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errOrderChanged
+		}
+		return tx.Create(&event).Error
+	})
+	if errors.Is(err, errOrderChanged) {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Order was changed by someone else, reload it", nil, "")
+	}
+	if err != nil {
+		a.Log.Error("Failed to update order status", "error", err, "order_id", order.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update order", nil, "")
+	}
+
+	if req.Notify == nil || *req.Notify {
+		a.notifyOrderStatus(order, &event)
+	}
+
+	// Reload so the response has the new timestamps and history
+	order, err = a.loadOrder(r, orgID, userID)
+	if err != nil {
+		return nil
+	}
+	a.broadcastOrder(order, websocket.TypeOrderUpdated)
+	a.dispatchOrderWebhook(order, models.WebhookEventOrderUpdated, &event)
+
+	return r.SendEnvelope(orderToResponse(order, a.ShouldMaskPhoneNumbers(orgID)))
+}
+
+// GetContactOrders returns the order panel of a conversation: the contact's
+// recent orders and totals
+func (a *App) GetContactOrders(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceOrders, models.ActionRead); err != nil {
+		return nil
+	}
+
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil
+	}
+	if !a.canAccessContact(contactID, userID, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
+	}
+
+	var orders []models.Order
+	if err := a.DB.Where("organization_id = ? AND contact_id = ?", orgID, contactID).
+		Preload("Contact").Preload("Items.Product").
+		Order("created_at DESC").
+		Limit(contactPanelOrders).
+		Find(&orders).Error; err != nil {
+		a.Log.Error("Failed to load contact orders", "error", err, "contact_id", contactID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load orders", nil, "")
+	}
+
+	var counts []struct {
+		Status models.OrderStatus
+		Count  int64
+	}
+	a.DB.Model(&models.Order{}).
+		Select("status, COUNT(*) AS count").
+		Where("organization_id = ? AND contact_id = ?", orgID, contactID).
+		Group("status").
+		Scan(&counts)
+	byStatus := make(map[models.OrderStatus]int64, len(orderStatuses))
+	for _, s := range orderStatuses {
+		byStatus[s] = 0
+	}
+	var totalOrders, openOrders int64
+	for _, c := range counts {
+		byStatus[c.Status] = c.Count
+		totalOrders += c.Count
+		if _, open := orderTransitions[c.Status]; open {
+			openOrders += c.Count
+		}
+	}
+
+	// Spent is the value of orders that weren't cancelled, per currency
+	var sums []struct {
+		Currency string
+		Total    int64
+	}
+	a.DB.Model(&models.Order{}).
+		Select("currency, SUM(total) AS total").
+		Where("organization_id = ? AND contact_id = ? AND status <> ?", orgID, contactID, models.OrderStatusCancelled).
+		Group("currency").
+		Scan(&sums)
+	spent := make(map[string]int64, len(sums))
+	for _, s := range sums {
+		spent[s.Currency] = s.Total
+	}
+
+	shouldMask := a.ShouldMaskPhoneNumbers(orgID)
+	result := make([]OrderResponse, len(orders))
+	for i := range orders {
+		result[i] = orderToResponse(&orders[i], shouldMask)
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"orders":       result,
+		"total_orders": totalOrders,
+		"open_orders":  openOrders,
+		"by_status":    byStatus,
+		"total_spent":  spent,
+	})
+}
+
+// ListOrderNotifications returns the template notification of each order status
+func (a *App) ListOrderNotifications(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceSettingsGeneral, models.ActionRead); err != nil {
+		return nil
+	}
+
+	var notifications []models.OrderNotification
+	if err := a.DB.Where("organization_id = ?", orgID).Find(&notifications).Error; err != nil {
+		a.Log.Error("Failed to list order notifications", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list order notifications", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"notifications": sortOrderNotifications(notifications),
+	})
+}
+
+// UpdateOrderNotifications replaces the template notifications of order statuses.
+// Statuses left out are not notified.
+func (a *App) UpdateOrderNotifications(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceSettingsGeneral, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	var req struct {
+		Notifications []OrderNotificationRequest `json:"notifications"`
+	}
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	notifications := make([]models.OrderNotification, 0, len(req.Notifications))
+	seen := make(map[models.OrderStatus]bool, len(req.Notifications))
+	for _, n := range req.Notifications {
+		if !isOrderStatus(n.Status) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Invalid order status %q", n.Status), nil, "")
+		}
+		if seen[n.Status] {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Status %s is configured twice", n.Status), nil, "")
+		}
+		seen[n.Status] = true
+		name := strings.TrimSpace(n.TemplateName)
+		if name == "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("template_name is required for %s", n.Status), nil, "")
+		}
+
+		var approved int64
+		a.DB.Model(&models.Template{}).
+			Where("organization_id = ? AND name = ? AND status = ?", orgID, name, "APPROVED").
+			Count(&approved)
+		if approved == 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("No approved template named %q", name), nil, "")
+		}
+
+		params := models.JSONB(n.Params)
+		if params == nil {
+			params = models.JSONB{}
+		}
+		notifications = append(notifications, models.OrderNotification{
+			OrganizationID: orgID,
+			Status:         n.Status,
+			TemplateName:   name,
+			Language:       strings.TrimSpace(n.Language),
+			Params:         params,
+			IsEnabled:      n.IsEnabled,
+		})
+	}
+
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("organization_id = ?", orgID).Delete(&models.OrderNotification{}).Error; err != nil {
+			return err
+		}
+		if len(notifications) == 0 {
+			return nil
+		}
+		return tx.Create(&notifications).Error
+	})
+	if err != nil {
+		a.Log.Error("Failed to save order notifications", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save order notifications", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"notifications": sortOrderNotifications(notifications),
+	})
+}
+
+// orderCreated records a new order's history, notifies the customer and tells
+// agents and webhooks about it
+func (a *App) orderCreated(order *models.Order) {
+	event := models.OrderEvent{OrderID: order.ID, ToStatus: order.Status}
+	if err := a.DB.Create(&event).Error; err != nil {
+		a.Log.Error("Failed to record order event", "error", err, "order_id", order.ID)
+	}
+	a.notifyOrderStatus(order, &event)
+
+	var loaded models.Order
+	if err := a.DB.Preload("Contact").Preload("Items.Product").Where("id = ?", order.ID).First(&loaded).Error; err != nil {
+		return
+	}
+	a.broadcastOrder(&loaded, websocket.TypeOrderCreated)
+	a.dispatchOrderWebhook(&loaded, models.WebhookEventOrderCreated, &event)
+}
+
+// notifyOrderStatus sends the customer the template configured for the status
+// the order moved to, and records the outcome on the event
+func (a *App) notifyOrderStatus(order *models.Order, event *models.OrderEvent) {
+	var notification models.OrderNotification
+	if err := a.DB.Where("organization_id = ? AND status = ? AND is_enabled = ?", order.OrganizationID, event.ToStatus, true).
+		First(&notification).Error; err != nil {
+		return
+	}
+
+	messageID, err := a.sendOrderNotification(order, &notification)
+	updates := map[string]interface{}{}
+	if err != nil {
+		a.Log.Error("Failed to send order notification", "error", err, "order_id", order.ID, "status", event.ToStatus)
+		event.NotificationError = err.Error()
+		updates["notification_error"] = event.NotificationError
+	} else {
+		event.NotificationMessageID = &messageID
+		updates["notification_message_id"] = messageID
+	}
+	if event.ID != uuid.Nil {
+		a.DB.Model(event).Updates(updates)
+	}
+}
+
+// sendOrderNotification sends a notification template to the order's contact
+func (a *App) sendOrderNotification(order *models.Order, notification *models.OrderNotification) (uuid.UUID, error) {
+	var account models.WhatsAppAccount
+	if err := a.DB.Where("organization_id = ? AND name = ?", order.OrganizationID, order.WhatsAppAccount).First(&account).Error; err != nil {
+		return uuid.Nil, fmt.Errorf("whatsapp account not found")
+	}
+	var contact models.Contact
+	if err := a.DB.Where("id = ?", order.ContactID).First(&contact).Error; err != nil {
+		return uuid.Nil, fmt.Errorf("contact not found")
+	}
+
+	template, err := a.keywordTemplate(&account, models.JSONB{
+		"template_name": notification.TemplateName,
+		"language":      notification.Language,
+	}, contact.Language)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	msg, err := a.SendOutgoingMessage(ctx, OutgoingMessageRequest{
+		Account:    &account,
+		Contact:    &contact,
+		Type:       models.MessageTypeTemplate,
+		Template:   template,
+		BodyParams: templateParams(notification.Params, orderTemplateData(order, &contact)),
+	}, SLASendOptions())
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return msg.ID, nil
+}
+
+// orderTemplateData is the data notification template parameters are rendered with
+func orderTemplateData(order *models.Order, contact *models.Contact) map[string]interface{} {
+	items := 0
+	for _, item := range order.Items {
+		items += item.Quantity
+	}
+	return map[string]interface{}{
+		"contact_name": contact.ProfileName,
+		"order": map[string]interface{}{
+			"id":              order.ID.String(),
+			"reference":       orderReference(order.ID),
+			"status":          string(order.Status),
+			"total":           whatsapp.FormatBatchPrice(order.Total, order.Currency),
+			"currency":        order.Currency,
+			"items":           items,
+			"tracking_number": order.TrackingNumber,
+			"tracking_url":    order.TrackingURL,
+			"cancel_reason":   order.CancelReason,
+		},
+	}
+}
+
+// orderReference is the short order number shown to customers and agents
+func orderReference(id uuid.UUID) string {
+	return strings.ToUpper(id.String()[:8])
+}
+
+// broadcastOrder tells the agents who can see the conversation about an order
+func (a *App) broadcastOrder(order *models.Order, messageType string) {
+	if a.WSHub == nil {
+		return
+	}
+	a.WSHub.BroadcastToConversation(order.OrganizationID, order.ContactID, websocket.WSMessage{
+		Type:    messageType,
+		Payload: orderToResponse(order, a.ShouldMaskPhoneNumbers(order.OrganizationID)),
+	})
+}
+
+// dispatchOrderWebhook sends an order event to the organization's webhooks
+func (a *App) dispatchOrderWebhook(order *models.Order, eventType models.WebhookEvent, event *models.OrderEvent) {
+	data := OrderEventData{
+		OrderID:         order.ID.String(),
+		Reference:       orderReference(order.ID),
+		ContactID:       order.ContactID.String(),
+		WhatsAppAccount: order.WhatsAppAccount,
+		Status:          order.Status,
+		PreviousStatus:  event.FromStatus,
+		Currency:        order.Currency,
+		Total:           order.Total,
+		Items:           make([]OrderItemEventData, len(order.Items)),
+		TrackingNumber:  order.TrackingNumber,
+		TrackingURL:     order.TrackingURL,
+		CancelReason:    order.CancelReason,
+		Note:            event.Note,
+	}
+	if order.Contact != nil {
+		data.ContactPhone = order.Contact.PhoneNumber
+		data.ContactName = order.Contact.ProfileName
+	}
+	for i, item := range order.Items {
+		data.Items[i] = OrderItemEventData{
+			RetailerID: item.RetailerID,
+			Name:       item.Name,
+			Quantity:   item.Quantity,
+			ItemPrice:  item.ItemPrice,
+		}
+	}
+	if event.UserID != nil {
+		changedBy := event.UserID.String()
+		data.ChangedBy = &changedBy
+	}
+
+	a.DispatchWebhook(order.OrganizationID, eventType, data)
+}
+
+// loadOrder loads the order in the path with its items, contact and history,
+// if its conversation is visible to the user
+func (a *App) loadOrder(r *fastglue.Request, orgID, userID uuid.UUID) (*models.Order, error) {
+	id, err := parsePathUUID(r, "id", "order")
+	if err != nil {
+		return nil, err
+	}
+
+	var order models.Order
+	err = a.scopeOrders(a.DB.Where("id = ? AND organization_id = ?", id, orgID), userID, orgID).
+		Preload("Contact").
+		Preload("Items.Product").
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Events.User").
+		First(&order).Error
+	if err != nil {
+		_ = r.SendErrorEnvelope(fasthttp.StatusNotFound, "Order not found", nil, "")
+		return nil, errEnvelopeSent
+	}
+	return &order, nil
+}
+
+// scopeOrders limits an order query to the conversations the user may see
+func (a *App) scopeOrders(query *gorm.DB, userID, orgID uuid.UUID) *gorm.DB {
+	scope := a.conversationScope(userID, orgID)
+	if scope.All {
+		return query
+	}
+	contacts := a.scopeContacts(a.DB.Model(&models.Contact{}).Select("id").Where("organization_id = ?", orgID), userID, orgID)
+	return query.Where("contact_id IN (?)", contacts)
+}
+
+func canTransitionOrder(from, to models.OrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func isOrderStatus(status models.OrderStatus) bool {
+	for _, s := range orderStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// sortOrderNotifications puts notifications in lifecycle order
+func sortOrderNotifications(notifications []models.OrderNotification) []models.OrderNotification {
+	sorted := make([]models.OrderNotification, 0, len(notifications))
+	for _, status := range orderStatuses {
+		for _, n := range notifications {
+			if n.Status == status {
+				sorted = append(sorted, n)
+			}
+		}
+	}
+	return sorted
+}
+
+func orderToResponse(o *models.Order, maskPhone bool) OrderResponse {
+	resp := OrderResponse{
+		ID:              o.ID,
+		Reference:       orderReference(o.ID),
+		ContactID:       o.ContactID,
+		WhatsAppAccount: o.WhatsAppAccount,
+		CatalogID:       o.CatalogID,
+		MetaCatalogID:   o.MetaCatalogID,
+		MessageID:       o.MessageID,
+		Text:            o.Text,
+		Currency:        o.Currency,
+		Total:           o.Total,
+		Status:          o.Status,
+		NextStatuses:    orderTransitions[o.Status],
+		TrackingNumber:  o.TrackingNumber,
+		TrackingURL:     o.TrackingURL,
+		CancelReason:    o.CancelReason,
+		ConfirmedAt:     o.ConfirmedAt,
+		PaidAt:          o.PaidAt,
+		ShippedAt:       o.ShippedAt,
+		CancelledAt:     o.CancelledAt,
+		CreatedAt:       o.CreatedAt,
+		UpdatedAt:       o.UpdatedAt,
+		Items:           make([]OrderItemResponse, len(o.Items)),
+	}
+	if resp.NextStatuses == nil {
+		resp.NextStatuses = []models.OrderStatus{}
+	}
+	if o.Contact != nil {
+		resp.ContactName = o.Contact.ProfileName
+		resp.ContactPhone = o.Contact.PhoneNumber
+		if maskPhone {
+			resp.ContactPhone = MaskPhoneNumber(resp.ContactPhone)
+		}
+	}
+	for i, item := range o.Items {
+		resp.ItemCount += item.Quantity
+		resp.Items[i] = OrderItemResponse{
+			ID:         item.ID,
+			ProductID:  item.ProductID,
+			RetailerID: item.RetailerID,
+			Name:       item.Name,
+			Quantity:   item.Quantity,
+			ItemPrice:  item.ItemPrice,
+			Currency:   item.Currency,
+		}
+		if item.Product != nil {
+			resp.Items[i].ImageURL = item.Product.ImageURL
+		}
+	}
+	for _, e := range o.Events {
+		event := OrderEventResponse{
+			ID:                    e.ID,
+			FromStatus:            e.FromStatus,
+			ToStatus:              e.ToStatus,
+			Note:                  e.Note,
+			UserID:                e.UserID,
+			NotificationMessageID: e.NotificationMessageID,
+			NotificationError:     e.NotificationError,
+			CreatedAt:             e.CreatedAt,
+		}
+		if e.User != nil {
+			event.UserName = e.User.FullName
+		}
+		resp.Events = append(resp.Events, event)
+	}
+	return resp
+}
@@ -0,0 +1,339 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// createTestOrder creates an order with one line directly in the database for testing.
+func createTestOrder(t *testing.T, app *handlers.App, orgID uuid.UUID, contact *models.Contact, status models.OrderStatus, total int64) *models.Order {
+	t.Helper()
+
+	order := &models.Order{
+		OrganizationID:  orgID,
+		WhatsAppAccount: contact.WhatsAppAccount,
+		ContactID:       contact.ID,
+		MetaCatalogID:   "catalog-123",
+		Currency:        "USD",
+		Total:           total,
+		Status:          status,
+		Items: []models.OrderItem{
+			{RetailerID: "SKU-1", Name: "Blue Shirt", Quantity: 2, ItemPrice: total / 2, Currency: "USD"},
+		},
+	}
+	require.NoError(t, app.DB.Create(order).Error)
+	return order
+}
+
+func updateOrderStatus(t *testing.T, app *handlers.App, orgID, userID, orderID uuid.UUID, body map[string]any) *fastglue.Request {
+	t.Helper()
+
+	req := testutil.NewJSONRequest(t, body)
+	testutil.SetAuthContext(req, orgID, userID)
+	testutil.SetPathParam(req, "id", orderID.String())
+	require.NoError(t, app.UpdateOrderStatus(req))
+	return req
+}
+
+func decodeOrder(t *testing.T, req *fastglue.Request) handlers.OrderResponse {
+	t.Helper()
+
+	var resp struct {
+		Data handlers.OrderResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	return resp.Data
+}
+
+// --- UpdateOrderStatus Tests ---
+
+func TestApp_UpdateOrderStatus(t *testing.T) {
+	t.Parallel()
+
+	t.Run("moves through the lifecycle", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		contact := testutil.CreateTestContact(t, app.DB, org.ID)
+		order := createTestOrder(t, app, org.ID, contact, models.OrderStatusPending, 2500)
+
+		req := updateOrderStatus(t, app, org.ID, user.ID, order.ID, map[string]any{"status": "confirmed", "note": "Called the customer"})
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		resp := decodeOrder(t, req)
+		assert.Equal(t, models.OrderStatusConfirmed, resp.Status)
+		assert.NotNil(t, resp.ConfirmedAt)
+		assert.Equal(t, []models.OrderStatus{models.OrderStatusPaid, models.OrderStatusShipped, models.OrderStatusCancelled}, resp.NextStatuses)
+
+		req = updateOrderStatus(t, app, org.ID, user.ID, order.ID, map[string]any{
+			"status":          "shipped",
+			"tracking_number": " 1Z999 ",
+			"tracking_url":    "https://track.example/1Z999",
+		})
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		resp = decodeOrder(t, req)
+		assert.Equal(t, models.OrderStatusShipped, resp.Status)
+		assert.Equal(t, "1Z999", resp.TrackingNumber)
+		assert.NotNil(t, resp.ShippedAt)
+		assert.Empty(t, resp.NextStatuses, "shipped orders are final")
+
+		require.Len(t, resp.Events, 2)
+		assert.Equal(t, models.OrderStatusPending, resp.Events[0].FromStatus)
+		assert.Equal(t, models.OrderStatusConfirmed, resp.Events[0].ToStatus)
+		assert.Equal(t, "Called the customer", resp.Events[0].Note)
+		require.NotNil(t, resp.Events[0].UserID)
+		assert.Equal(t, user.ID, *resp.Events[0].UserID)
+		assert.Equal(t, models.OrderStatusShipped, resp.Events[1].ToStatus)
+	})
+
+	t.Run("rejects invalid transitions", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+		pending := createTestOrder(t, app, org.ID, contact, models.OrderStatusPending, 1000)
+		req := updateOrderStatus(t, app, org.ID, user.ID, pending.ID, map[string]any{"status": "shipped"})
+		testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "Cannot change order from pending to shipped")
+
+		cancelled := createTestOrder(t, app, org.ID, contact, models.OrderStatusCancelled, 1000)
+		req = updateOrderStatus(t, app, org.ID, user.ID, cancelled.ID, map[string]any{"status": "paid"})
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+
+		req = updateOrderStatus(t, app, org.ID, user.ID, pending.ID, map[string]any{"status": "refunded"})
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+
+		var count int64
+		app.DB.Model(&models.OrderEvent{}).Where("order_id IN ?", []uuid.UUID{pending.ID, cancelled.ID}).Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("order of another organization", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		other := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		contact := testutil.CreateTestContact(t, app.DB, other.ID)
+		order := createTestOrder(t, app, other.ID, contact, models.OrderStatusPending, 1000)
+
+		req := updateOrderStatus(t, app, org.ID, user.ID, order.ID, map[string]any{"status": "confirmed"})
+		assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("sends the status notification", func(t *testing.T) {
+		mockServer := newMockWhatsAppServer()
+		defer mockServer.close()
+
+		app := newMsgTestApp(t, mockServer)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		account := createTestAccount(t, app, org.ID)
+		contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+		template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+		require.NoError(t, app.DB.Create(&models.OrderNotification{
+			OrganizationID: org.ID,
+			Status:         models.OrderStatusShipped,
+			TemplateName:   template.Name,
+			Params:         models.JSONB{"1": "{{order.reference}} via {{order.tracking_number}}"},
+			IsEnabled:      true,
+		}).Error)
+		order := createTestOrder(t, app, org.ID, contact, models.OrderStatusPaid, 1000)
+
+		req := updateOrderStatus(t, app, org.ID, user.ID, order.ID, map[string]any{"status": "shipped", "tracking_number": "1Z999"})
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		require.Len(t, mockServer.sentMessages, 1)
+		sent := mockServer.sentMessages[0]
+		assert.Equal(t, "template", sent["type"])
+		sentTemplate := sent["template"].(map[string]interface{})
+		assert.Equal(t, template.Name, sentTemplate["name"])
+		body, _ := json.Marshal(sentTemplate["components"])
+		assert.Contains(t, string(body), strings.ToUpper(order.ID.String()[:8])+" via 1Z999")
+
+		resp := decodeOrder(t, req)
+		require.Len(t, resp.Events, 1)
+		assert.NotNil(t, resp.Events[0].NotificationMessageID)
+		assert.Empty(t, resp.Events[0].NotificationError)
+	})
+
+	t.Run("notify false skips the notification", func(t *testing.T) {
+		mockServer := newMockWhatsAppServer()
+		defer mockServer.close()
+
+		app := newMsgTestApp(t, mockServer)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		account := createTestAccount(t, app, org.ID)
+		contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+		template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+		require.NoError(t, app.DB.Create(&models.OrderNotification{
+			OrganizationID: org.ID,
+			Status:         models.OrderStatusCancelled,
+			TemplateName:   template.Name,
+			Params:         models.JSONB{},
+			IsEnabled:      true,
+		}).Error)
+		order := createTestOrder(t, app, org.ID, contact, models.OrderStatusPending, 1000)
+
+		req := updateOrderStatus(t, app, org.ID, user.ID, order.ID, map[string]any{"status": "cancelled", "notify": false})
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		assert.Empty(t, mockServer.sentMessages)
+	})
+}
+
+// --- ListOrders Tests ---
+
+func TestApp_ListOrders(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+	createTestOrder(t, app, org.ID, contact, models.OrderStatusPending, 1000)
+	createTestOrder(t, app, org.ID, contact, models.OrderStatusPaid, 2000)
+	createTestOrder(t, app, org.ID, contact, models.OrderStatusCancelled, 3000)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetQueryParam(req, "status", "pending,paid")
+	require.NoError(t, app.ListOrders(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			Orders []handlers.OrderResponse `json:"orders"`
+			Total  int64                    `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, int64(2), resp.Data.Total)
+	require.Len(t, resp.Data.Orders, 2)
+	for _, o := range resp.Data.Orders {
+		assert.NotEqual(t, models.OrderStatusCancelled, o.Status)
+		assert.Equal(t, 2, o.ItemCount)
+		assert.Len(t, o.Reference, 8)
+	}
+}
+
+// --- GetContactOrders Tests ---
+
+func TestApp_GetContactOrders(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+	otherContact := testutil.CreateTestContact(t, app.DB, org.ID)
+	createTestOrder(t, app, org.ID, contact, models.OrderStatusPending, 1000)
+	createTestOrder(t, app, org.ID, contact, models.OrderStatusShipped, 2000)
+	createTestOrder(t, app, org.ID, contact, models.OrderStatusCancelled, 4000)
+	createTestOrder(t, app, org.ID, otherContact, models.OrderStatusPaid, 8000)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", contact.ID.String())
+	require.NoError(t, app.GetContactOrders(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			Orders      []handlers.OrderResponse     `json:"orders"`
+			TotalOrders int64                        `json:"total_orders"`
+			OpenOrders  int64                        `json:"open_orders"`
+			ByStatus    map[models.OrderStatus]int64 `json:"by_status"`
+			TotalSpent  map[string]int64             `json:"total_spent"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Len(t, resp.Data.Orders, 3)
+	assert.Equal(t, int64(3), resp.Data.TotalOrders)
+	assert.Equal(t, int64(1), resp.Data.OpenOrders)
+	assert.Equal(t, int64(0), resp.Data.ByStatus[models.OrderStatusPaid])
+	assert.Equal(t, int64(1), resp.Data.ByStatus[models.OrderStatusShipped])
+	assert.Equal(t, map[string]int64{"USD": 3000}, resp.Data.TotalSpent, "cancelled orders are not counted")
+}
+
+// --- UpdateOrderNotifications Tests ---
+
+func TestApp_UpdateOrderNotifications(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, "test-account")
+
+	tests := []struct {
+		name          string
+		notifications []map[string]any
+		wantStatus    int
+		wantError     string
+	}{
+		{
+			name:          "invalid status",
+			notifications: []map[string]any{{"status": "refunded", "template_name": template.Name}},
+			wantStatus:    fasthttp.StatusBadRequest,
+			wantError:     "Invalid order status",
+		},
+		{
+			name: "status configured twice",
+			notifications: []map[string]any{
+				{"status": "paid", "template_name": template.Name},
+				{"status": "paid", "template_name": template.Name},
+			},
+			wantStatus: fasthttp.StatusBadRequest,
+			wantError:  "configured twice",
+		},
+		{
+			name:          "unknown template",
+			notifications: []map[string]any{{"status": "paid", "template_name": "missing"}},
+			wantStatus:    fasthttp.StatusBadRequest,
+			wantError:     "No approved template",
+		},
+		{
+			name: "success",
+			notifications: []map[string]any{
+				{"status": "shipped", "template_name": template.Name, "params": map[string]any{"1": "{{order.tracking_number}}"}, "is_enabled": true},
+				{"status": "confirmed", "template_name": template.Name, "is_enabled": false},
+			},
+			wantStatus: fasthttp.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testutil.NewJSONRequest(t, map[string]any{"notifications": tt.notifications})
+			testutil.SetAuthContext(req, org.ID, user.ID)
+			require.NoError(t, app.UpdateOrderNotifications(req))
+
+			if tt.wantError != "" {
+				testutil.AssertErrorResponse(t, req, tt.wantStatus, tt.wantError)
+				return
+			}
+			assert.Equal(t, tt.wantStatus, testutil.GetResponseStatusCode(req))
+		})
+	}
+
+	var saved []models.OrderNotification
+	require.NoError(t, app.DB.Where("organization_id = ?", org.ID).Find(&saved).Error)
+	assert.Len(t, saved, 2, "only the successful request is saved")
+}
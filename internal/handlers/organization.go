@@ -87,6 +87,9 @@ func (a *App) UpdateOrganizationSettings(r *fastglue.Request) error {
 		org.Settings["mask_phone_numbers"] = *req.MaskPhoneNumbers
 	}
 	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid timezone", nil, "")
+		}
 		org.Settings["timezone"] = *req.Timezone
 	}
 	if req.DateFormat != nil {
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// OTP defaults and limits
+const (
+	otpDefaultLength      = 6
+	otpMinLength          = 4
+	otpMaxLength          = 8
+	otpDefaultExpiry      = 10 // minutes, when the template has no code expiration
+	otpDefaultMaxAttempts = 5
+	otpMaxAttempts        = 10
+	otpMaxExpiry          = 60 // minutes, unless the template's code expiration is longer
+	otpResendInterval     = 30 * time.Second
+)
+
+// otpResendPrefix throttles codes per organization, account and phone number
+const otpResendPrefix = "otp_resend:"
+
+// SendOTPRequest represents the request body for sending a one-time password
+type SendOTPRequest struct {
+	PhoneNumber   string `json:"phone_number" validate:"required"`
+	TemplateID    string `json:"template_id"`    // Authentication template UUID
+	TemplateName  string `json:"template_name"`  // Alternative to template_id
+	CodeLength    int    `json:"code_length"`    // 4-8 digits, default 6
+	ExpiryMinutes int    `json:"expiry_minutes"` // Defaults to the template's code expiration, or 10
+	MaxAttempts   int    `json:"max_attempts"`   // Wrong guesses allowed, default 5
+}
+
+// VerifyOTPRequest represents the request body for verifying a one-time password
+type VerifyOTPRequest struct {
+	ID          string `json:"id"`           // ID returned when the code was sent
+	PhoneNumber string `json:"phone_number"` // Alternative to id: checks the latest pending code
+	Code        string `json:"code" validate:"required"`
+}
+
+// OTPResponse represents a sent one-time password. The code itself is never returned.
+type OTPResponse struct {
+	ID                uuid.UUID        `json:"id"`
+	PhoneNumber       string           `json:"phone_number"`
+	Status            models.OTPStatus `json:"status"`
+	ExpiresAt         time.Time        `json:"expires_at"`
+	AttemptsRemaining int              `json:"attempts_remaining"`
+	MessageID         string           `json:"message_id,omitempty"`
+}
+
+// SendOTP generates a code and sends it with an approved authentication template
+func (a *App) SendOTP(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	var req SendOTPRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	// "+91 98..." and "9198..." are the same number for the resend interval
+	req.PhoneNumber = phoneDigits(req.PhoneNumber)
+	if req.PhoneNumber == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "phone_number is required", nil, "")
+	}
+	if req.TemplateName == "" && req.TemplateID == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Either template_name or template_id is required", nil, "")
+	}
+
+	codeLength := req.CodeLength
+	if codeLength == 0 {
+		codeLength = otpDefaultLength
+	}
+	if codeLength < otpMinLength || codeLength > otpMaxLength {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("code_length must be between %d and %d", otpMinLength, otpMaxLength), nil, "")
+	}
+	maxAttempts := req.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = otpDefaultMaxAttempts
+	}
+	if maxAttempts > otpMaxAttempts {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("max_attempts must be at most %d", otpMaxAttempts), nil, "")
+	}
+
+	// Get template
+	var template models.Template
+	if req.TemplateID != "" {
+		templateID, err := uuid.Parse(req.TemplateID)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid template_id", nil, "")
+		}
+		t, err := findByIDAndOrg[models.Template](a.DB, r, templateID, orgID, "Template")
+		if err != nil {
+			return nil
+		}
+		template = *t
+	} else {
+		if err := a.DB.Where("name = ? AND organization_id = ? AND category = ?", req.TemplateName, orgID, "AUTHENTICATION").First(&template).Error; err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Template not found", nil, "")
+		}
+	}
+
+	if template.Category != "AUTHENTICATION" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Template is not an authentication template", nil, "")
+	}
+	if template.Status != "APPROVED" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Template is not approved (status: %s)", template.Status), nil, "")
+	}
+
+	expiryMinutes := req.ExpiryMinutes
+	if expiryMinutes <= 0 {
+		expiryMinutes = template.CodeExpirationMinutes
+	}
+	if expiryMinutes <= 0 {
+		expiryMinutes = otpDefaultExpiry
+	}
+	if maxExpiry := max(otpMaxExpiry, template.CodeExpirationMinutes); expiryMinutes > maxExpiry {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("expiry_minutes must be at most %d", maxExpiry), nil, "")
+	}
+
+	var account models.WhatsAppAccount
+	if err := a.DB.Where("name = ? AND organization_id = ?", template.WhatsAppAccount, orgID).First(&account).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Template's WhatsApp account not found", nil, "")
+	}
+	a.decryptAccountSecrets(&account)
+
+	// Each message costs money, so don't let a caller resend in a tight loop.
+	// SetNX claims the number atomically, so concurrent requests send one code.
+	resendKey := fmt.Sprintf("%s%s:%s:%s", otpResendPrefix, orgID, account.Name, req.PhoneNumber)
+	claimed, err := a.Redis.SetNX(context.Background(), resendKey, 1, otpResendInterval).Result()
+	if err != nil {
+		a.Log.Error("Failed to check OTP resend interval", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create code", nil, "")
+	}
+	if !claimed {
+		return r.SendErrorEnvelope(fasthttp.StatusTooManyRequests, "A code was sent to this number recently; please wait before requesting another", nil, "")
+	}
+	// Nothing reached the customer, so they may ask again straight away
+	releaseResend := func() { a.Redis.Del(context.Background(), resendKey) }
+
+	code, err := generateOTPCode(codeLength)
+	if err != nil {
+		a.Log.Error("Failed to generate OTP", "error", err)
+		releaseResend()
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to generate code", nil, "")
+	}
+	codeHash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		a.Log.Error("Failed to hash OTP", "error", err)
+		releaseResend()
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to generate code", nil, "")
+	}
+
+	otp := models.OTPVerification{
+		OrganizationID:  orgID,
+		WhatsAppAccount: account.Name,
+		TemplateID:      template.ID,
+		PhoneNumber:     req.PhoneNumber,
+		CodeHash:        string(codeHash),
+		Status:          models.OTPStatusPending,
+		MaxAttempts:     maxAttempts,
+		ExpiresAt:       time.Now().Add(time.Duration(expiryMinutes) * time.Minute),
+	}
+
+	// Only the newest code for a number can be used
+	if err := a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OTPVerification{}).
+			Where("organization_id = ? AND whats_app_account = ? AND phone_number = ? AND status = ?",
+				orgID, account.Name, req.PhoneNumber, models.OTPStatusPending).
+			Update("status", models.OTPStatusCancelled).Error; err != nil {
+			return err
+		}
+		return tx.Create(&otp).Error
+	}); err != nil {
+		a.Log.Error("Failed to create OTP", "error", err)
+		releaseResend()
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create code", nil, "")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// Sent directly rather than through SendOutgoingMessage so the code never lands in chat history
+	messageID, err := a.WhatsApp.SendTemplateMessageWithComponents(ctx, a.toWhatsAppAccount(&account), req.PhoneNumber,
+		template.Name, template.Language, templateutil.AuthenticationComponents(code))
+	if err != nil {
+		a.Log.Error("Failed to send OTP", "error", err, "phone", req.PhoneNumber)
+		a.DB.Model(&otp).Update("status", models.OTPStatusFailed)
+		releaseResend()
+		return r.SendErrorEnvelope(fasthttp.StatusBadGateway, "Failed to send code", nil, "")
+	}
+
+	otp.WhatsAppMessageID = messageID
+	a.DB.Model(&otp).Update("whats_app_message_id", messageID)
+
+	return r.SendEnvelope(otpToResponse(otp))
+}
+
+// VerifyOTP checks a code against the latest pending one-time password
+func (a *App) VerifyOTP(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	var req VerifyOTPRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if req.Code == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "code is required", nil, "")
+	}
+	if req.ID == "" && req.PhoneNumber == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Either id or phone_number is required", nil, "")
+	}
+
+	query := a.DB.Where("organization_id = ? AND status = ?", orgID, models.OTPStatusPending)
+	if req.ID != "" {
+		id, err := uuid.Parse(req.ID)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid id", nil, "")
+		}
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("phone_number = ?", phoneDigits(req.PhoneNumber))
+	}
+
+	var otp models.OTPVerification
+	if err := query.Order("created_at DESC").First(&otp).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "No pending code found", nil, "")
+	}
+
+	if time.Now().After(otp.ExpiresAt) {
+		a.DB.Model(&otp).Update("status", models.OTPStatusExpired)
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Code has expired", nil, "")
+	}
+
+	// Count the attempt atomically so parallel guesses can't exceed the limit
+	result := a.DB.Model(&models.OTPVerification{}).
+		Where("id = ? AND status = ? AND attempts < max_attempts", otp.ID, models.OTPStatusPending).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		a.Log.Error("Failed to record OTP attempt", "error", result.Error)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to verify code", nil, "")
+	}
+	if result.RowsAffected == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Too many attempts; request a new code", nil, "")
+	}
+	otp.Attempts++
+
+	if bcrypt.CompareHashAndPassword([]byte(otp.CodeHash), []byte(req.Code)) != nil {
+		if otp.Attempts >= otp.MaxAttempts {
+			otp.Status = models.OTPStatusFailed
+			a.DB.Model(&otp).Update("status", otp.Status)
+		}
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid code", otpToResponse(otp), "")
+	}
+
+	// Only one of parallel requests with the right code may use it
+	now := time.Now()
+	result = a.DB.Model(&models.OTPVerification{}).
+		Where("id = ? AND status = ?", otp.ID, models.OTPStatusPending).
+		Updates(map[string]any{
+			"status":      models.OTPStatusVerified,
+			"verified_at": now,
+		})
+	if result.Error != nil {
+		a.Log.Error("Failed to mark OTP verified", "error", result.Error)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to verify code", nil, "")
+	}
+	if result.RowsAffected == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Code was already used", nil, "")
+	}
+	otp.Status = models.OTPStatusVerified
+	otp.VerifiedAt = &now
+
+	return r.SendEnvelope(map[string]any{
+		"verified": true,
+		"otp":      otpToResponse(otp),
+	})
+}
+
+// generateOTPCode returns a random numeric code of the given length
+func generateOTPCode(length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code), nil
+}
+
+func otpToResponse(otp models.OTPVerification) OTPResponse {
+	remaining := otp.MaxAttempts - otp.Attempts
+	if remaining < 0 || otp.Status != models.OTPStatusPending {
+		remaining = 0
+	}
+	return OTPResponse{
+		ID:                otp.ID,
+		PhoneNumber:       otp.PhoneNumber,
+		Status:            otp.Status,
+		ExpiresAt:         otp.ExpiresAt,
+		AttemptsRemaining: remaining,
+		MessageID:         otp.WhatsAppMessageID,
+	}
+}
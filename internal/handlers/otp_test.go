@@ -0,0 +1,335 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newMockOTPServer returns a WhatsApp API server that records the last code sent
+func newMockOTPServer(t *testing.T, sentCode *string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Template struct {
+				Components []struct {
+					Type       string `json:"type"`
+					Parameters []struct {
+						Text string `json:"text"`
+					} `json:"parameters"`
+				} `json:"components"`
+			} `json:"template"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Template.Components) > 0 && len(body.Template.Components[0].Parameters) > 0 {
+			*sentCode = body.Template.Components[0].Parameters[0].Text
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": []map[string]interface{}{{"id": "wamid.otp"}},
+		})
+	}))
+}
+
+func createTestAuthTemplate(t *testing.T, app *handlers.App, orgID uuid.UUID, accountName, status string) *models.Template {
+	t.Helper()
+
+	tmpl := &models.Template{
+		BaseModel:             models.BaseModel{ID: uuid.New()},
+		OrganizationID:        orgID,
+		WhatsAppAccount:       accountName,
+		Name:                  "login_code",
+		Language:              "en",
+		Category:              "AUTHENTICATION",
+		Status:                status,
+		BodyContent:           "*{{1}}* is your verification code.",
+		CodeExpirationMinutes: 5,
+	}
+	require.NoError(t, app.DB.Create(tmpl).Error)
+	return tmpl
+}
+
+func createTestOTP(t *testing.T, app *handlers.App, orgID uuid.UUID, code string, expiresAt time.Time) *models.OTPVerification {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.MinCost)
+	require.NoError(t, err)
+	otp := &models.OTPVerification{
+		OrganizationID:  orgID,
+		WhatsAppAccount: "test-account",
+		TemplateID:      uuid.New(),
+		PhoneNumber:     "15550001111",
+		CodeHash:        string(hash),
+		Status:          models.OTPStatusPending,
+		MaxAttempts:     2,
+		ExpiresAt:       expiresAt,
+	}
+	require.NoError(t, app.DB.Create(otp).Error)
+	return otp
+}
+
+func TestApp_SendOTP_SendsAndVerifies(t *testing.T) {
+	t.Parallel()
+
+	var sentCode string
+	server := newMockOTPServer(t, &sentCode)
+	defer server.Close()
+	app := newTemplateTestApp(t, server)
+
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	tmpl := createTestAuthTemplate(t, app, org.ID, account.Name, "APPROVED")
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"phone_number": "15550001111",
+		"template_id":  tmpl.ID.String(),
+		"code_length":  8,
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+
+	require.NoError(t, app.SendOTP(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.OTPResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, models.OTPStatusPending, resp.Data.Status)
+	assert.Equal(t, "wamid.otp", resp.Data.MessageID)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), resp.Data.ExpiresAt, time.Minute)
+	require.Len(t, sentCode, 8)
+
+	var stored models.OTPVerification
+	require.NoError(t, app.DB.First(&stored, resp.Data.ID).Error)
+	assert.NotEqual(t, sentCode, stored.CodeHash)
+
+	// A second request right away is throttled
+	again := testutil.NewJSONRequest(t, map[string]interface{}{
+		"phone_number": "15550001111",
+		"template_id":  tmpl.ID.String(),
+	})
+	testutil.SetAuthContext(again, org.ID, user.ID)
+	require.NoError(t, app.SendOTP(again))
+	testutil.AssertErrorResponse(t, again, fasthttp.StatusTooManyRequests, "wait")
+
+	verify := testutil.NewJSONRequest(t, map[string]interface{}{
+		"phone_number": "15550001111",
+		"code":         sentCode,
+	})
+	testutil.SetAuthContext(verify, org.ID, user.ID)
+	require.NoError(t, app.VerifyOTP(verify))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(verify))
+
+	require.NoError(t, app.DB.First(&stored, resp.Data.ID).Error)
+	assert.Equal(t, models.OTPStatusVerified, stored.Status)
+	assert.NotNil(t, stored.VerifiedAt)
+}
+
+func TestApp_SendOTP_Limits(t *testing.T) {
+	t.Parallel()
+
+	var sentCode string
+	server := newMockOTPServer(t, &sentCode)
+	defer server.Close()
+	app := newTemplateTestApp(t, server)
+
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	tmpl := createTestAuthTemplate(t, app, org.ID, account.Name, "APPROVED")
+
+	sendOTP := func(body map[string]interface{}) *fastglue.Request {
+		body["template_id"] = tmpl.ID.String()
+		req := testutil.NewJSONRequest(t, body)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.SendOTP(req))
+		return req
+	}
+
+	req := sendOTP(map[string]interface{}{"phone_number": "15550001111", "max_attempts": 100000})
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "max_attempts must be at most 10")
+
+	req = sendOTP(map[string]interface{}{"phone_number": "15550001111", "expiry_minutes": 525600})
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "expiry_minutes must be at most 60")
+
+	req = sendOTP(map[string]interface{}{"phone_number": "15550001111"})
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	// The same number written differently is still throttled
+	req = sendOTP(map[string]interface{}{"phone_number": "+1 555-000-1111"})
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusTooManyRequests, "wait")
+}
+
+func TestApp_SendOTP_RequiresApprovedAuthenticationTemplate(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+
+	marketing := createTestTemplateInDB(t, app, org.ID, account.Name, "promo", "APPROVED")
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"phone_number": "15550001111",
+		"template_id":  marketing.ID.String(),
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.SendOTP(req))
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "not an authentication template")
+
+	pending := createTestAuthTemplate(t, app, org.ID, account.Name, "PENDING")
+	req = testutil.NewJSONRequest(t, map[string]interface{}{
+		"phone_number": "15550001111",
+		"template_id":  pending.ID.String(),
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.SendOTP(req))
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "not approved")
+}
+
+func TestApp_VerifyOTP_TooManyAttempts(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	otp := createTestOTP(t, app, org.ID, "123456", time.Now().Add(time.Minute))
+
+	for i := 0; i < 2; i++ {
+		req := testutil.NewJSONRequest(t, map[string]interface{}{"id": otp.ID.String(), "code": "000000"})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.VerifyOTP(req))
+		testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "Invalid code")
+	}
+
+	var stored models.OTPVerification
+	require.NoError(t, app.DB.First(&stored, otp.ID).Error)
+	assert.Equal(t, models.OTPStatusFailed, stored.Status)
+
+	// The right code no longer works once the code has failed
+	req := testutil.NewJSONRequest(t, map[string]interface{}{"id": otp.ID.String(), "code": "123456"})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.VerifyOTP(req))
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusNotFound, "No pending code")
+}
+
+func TestApp_VerifyOTP_Expired(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	otp := createTestOTP(t, app, org.ID, "123456", time.Now().Add(-time.Minute))
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{"phone_number": otp.PhoneNumber, "code": "123456"})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.VerifyOTP(req))
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "expired")
+
+	var stored models.OTPVerification
+	require.NoError(t, app.DB.First(&stored, otp.ID).Error)
+	assert.Equal(t, models.OTPStatusExpired, stored.Status)
+}
+
+func TestApp_VerifyOTP_CodeIsUsedOnce(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	otp := createTestOTP(t, app, org.ID, "123456", time.Now().Add(time.Minute))
+
+	const parallel = 5
+	statuses := make(chan int, parallel)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := testutil.NewJSONRequest(t, map[string]interface{}{"id": otp.ID.String(), "code": "123456"})
+			testutil.SetAuthContext(req, org.ID, user.ID)
+			if err := app.VerifyOTP(req); err == nil {
+				statuses <- testutil.GetResponseStatusCode(req)
+			}
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	verified := 0
+	for status := range statuses {
+		if status == fasthttp.StatusOK {
+			verified++
+		}
+	}
+	assert.Equal(t, 1, verified, "the code must only be accepted once")
+}
+
+func TestApp_SendOTP_ConcurrentRequestsSendOneCode(t *testing.T) {
+	t.Parallel()
+
+	var sends atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sends.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": []map[string]interface{}{{"id": "wamid.otp"}},
+		})
+	}))
+	defer server.Close()
+	app := newTemplateTestApp(t, server)
+
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	tmpl := createTestAuthTemplate(t, app, org.ID, account.Name, "APPROVED")
+
+	const requests = 5
+	statuses := make([]int, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := testutil.NewJSONRequest(t, map[string]interface{}{
+				"phone_number": "15550002222",
+				"template_id":  tmpl.ID.String(),
+			})
+			testutil.SetAuthContext(req, org.ID, user.ID)
+			_ = app.SendOTP(req)
+			statuses[i] = testutil.GetResponseStatusCode(req)
+		}(i)
+	}
+	wg.Wait()
+
+	ok := 0
+	for _, status := range statuses {
+		if status == fasthttp.StatusOK {
+			ok++
+		} else {
+			assert.Equal(t, fasthttp.StatusTooManyRequests, status)
+		}
+	}
+	assert.Equal(t, 1, ok)
+	assert.Equal(t, int32(1), sends.Load(), "only one paid message is sent")
+
+	var pending int64
+	app.DB.Model(&models.OTPVerification{}).
+		Where("organization_id = ? AND phone_number = ? AND status = ?", org.ID, "15550002222", models.OTPStatusPending).
+		Count(&pending)
+	assert.Equal(t, int64(1), pending, "the code that was sent stays usable")
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+)
+
+const (
+	// readReceiptPrefix keys the latest message of a contact a read receipt was sent for
+	readReceiptPrefix = "read_receipt:"
+	readReceiptTTL    = 7 * 24 * time.Hour
+
+	// typingIndicatorPrefix throttles typing indicators per contact. WhatsApp
+	// shows an indicator for up to 25 seconds, so it is resent while the agent
+	// keeps typing.
+	typingIndicatorPrefix   = "typing_indicator:"
+	typingIndicatorInterval = 20 * time.Second
+
+	// viewersPrefix keys a sorted set of the users viewing a contact on each
+	// server, scored by when the server last refreshed them. A server that
+	// stops refreshing its viewers, such as one that crashed, drops out.
+	viewersPrefix          = "conversation_viewers:"
+	viewersRefreshInterval = 30 * time.Second
+	viewersTTL             = 3 * viewersRefreshInterval
+)
+
+// HandleClientEvents sends read receipts when agents open conversations, typing
+// indicators while they compose replies, and tells colleagues viewing the same
+// conversation what they are doing
+func (a *App) HandleClientEvents() {
+	if a.WSHub != nil {
+		a.WSHub.SetClientEvents(a.handleClientEvent)
+		go a.refreshViewers()
+	}
+}
+
+// handleClientEvent acts on a user opening, typing in or leaving a conversation
+func (a *App) handleClientEvent(ev websocket.ClientEvent) {
+	if ev.State != websocket.PresenceLeft && !a.canAccessContact(ev.ContactID, ev.UserID, ev.OrgID) {
+		return
+	}
+
+	switch ev.State {
+	case websocket.PresenceViewing:
+		a.addViewers(ev)
+		a.sendReadReceipt(ev.OrgID, ev.ContactID)
+		a.sendCurrentViewers(ev)
+	case websocket.PresenceTyping:
+		a.sendTypingIndicator(ev.OrgID, ev.ContactID)
+	case websocket.PresenceLeft:
+		a.removeViewer(ev)
+		// The user may still view the conversation on another server
+		if slices.Contains(a.viewers(ev.ContactID), ev.UserID) {
+			return
+		}
+	}
+
+	a.broadcastPresence(ev)
+}
+
+// broadcastPresence tells the other users viewing the contact what the user is
+// doing. With the broadcast subscriber running it goes through Redis, so
+// viewers connected to the other servers are told too.
+func (a *App) broadcastPresence(ev websocket.ClientEvent) {
+	msg := websocket.BroadcastMessage{
+		OrgID:          ev.OrgID,
+		ContactID:      ev.ContactID,
+		ViewersOnly:    true,
+		ExcludeUserID:  ev.UserID,
+		ScopeContactID: ev.ContactID,
+		Message: websocket.WSMessage{
+			Type:    websocket.TypeAgentPresence,
+			Payload: a.presencePayload(ev),
+		},
+	}
+	if a.BroadcastSubCancel == nil {
+		a.WSHub.Broadcast(msg)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = queue.NewPublisher(a.Redis, a.Log).PublishBroadcast(ctx, msg)
+}
+
+// sendCurrentViewers tells a user who opened a conversation which colleagues
+// are viewing it already
+func (a *App) sendCurrentViewers(ev websocket.ClientEvent) {
+	for _, userID := range a.viewers(ev.ContactID) {
+		if userID == ev.UserID {
+			continue
+		}
+		a.WSHub.BroadcastToUser(ev.OrgID, ev.UserID, websocket.WSMessage{
+			Type: websocket.TypeAgentPresence,
+			Payload: a.presencePayload(websocket.ClientEvent{
+				OrgID:     ev.OrgID,
+				UserID:    userID,
+				ContactID: ev.ContactID,
+				State:     websocket.PresenceViewing,
+			}),
+		})
+	}
+}
+
+// viewerMember is the member of a contact's viewers set for a user viewing it on this server
+func viewerMember(userID uuid.UUID) string {
+	return userID.String() + "/" + processorInstanceID
+}
+
+// addViewers records that users are viewing contacts on this server
+func (a *App) addViewers(events ...websocket.ClientEvent) {
+	if len(events) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := float64(time.Now().UnixMilli())
+	pipe := a.Redis.Pipeline()
+	for _, ev := range events {
+		key := viewersPrefix + ev.ContactID.String()
+		pipe.ZAdd(ctx, key, redis.Z{Score: now, Member: viewerMember(ev.UserID)})
+		pipe.PExpire(ctx, key, viewersTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		a.Log.Error("Failed to record conversation viewers", "error", err)
+	}
+}
+
+// removeViewer records that a user left a contact on this server
+func (a *App) removeViewer(ev websocket.ClientEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := a.Redis.ZRem(ctx, viewersPrefix+ev.ContactID.String(), viewerMember(ev.UserID)).Err(); err != nil {
+		a.Log.Error("Failed to remove conversation viewer", "error", err, "contact_id", ev.ContactID)
+	}
+}
+
+// viewers returns the users viewing a contact on any server
+func (a *App) viewers(contactID uuid.UUID) []uuid.UUID {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cutoff := strconv.FormatInt(time.Now().Add(-viewersTTL).UnixMilli(), 10)
+	members, err := a.Redis.ZRangeByScore(ctx, viewersPrefix+contactID.String(), &redis.ZRangeBy{Min: cutoff, Max: "+inf"}).Result()
+	if err != nil {
+		a.Log.Error("Failed to load conversation viewers", "error", err, "contact_id", contactID)
+		return nil
+	}
+
+	var userIDs []uuid.UUID
+	for _, member := range members {
+		user, _, _ := strings.Cut(member, "/")
+		if userID, err := uuid.Parse(user); err == nil && !slices.Contains(userIDs, userID) {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs
+}
+
+// refreshViewers keeps the viewers on this server from expiring
+func (a *App) refreshViewers() {
+	ticker := time.NewTicker(viewersRefreshInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		a.addViewers(a.WSHub.Viewing()...)
+	}
+}
+
+// presencePayload builds the agent_presence payload of an event
+func (a *App) presencePayload(ev websocket.ClientEvent) websocket.AgentPresencePayload {
+	payload := websocket.AgentPresencePayload{
+		ContactID: ev.ContactID.String(),
+		UserID:    ev.UserID.String(),
+		State:     ev.State,
+	}
+	var user models.User
+	if err := a.DB.Select("full_name").Where("id = ?", ev.UserID).First(&user).Error; err == nil {
+		payload.UserName = user.FullName
+	}
+	return payload
+}
+
+// sendReadReceipt marks the customer's messages read on WhatsApp when an agent
+// opens the conversation, if the account sends read receipts. Marking the
+// latest message read marks the earlier ones too.
+func (a *App) sendReadReceipt(orgID, contactID uuid.UUID) {
+	message, account := a.latestIncomingMessage(orgID, contactID)
+	if message == nil {
+		return
+	}
+
+	ctx := context.Background()
+	key := readReceiptPrefix + contactID.String()
+	if sent, _ := a.Redis.Get(ctx, key).Result(); sent == message.WhatsAppMessageID {
+		return
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := a.WhatsApp.MarkMessageRead(ctx, a.toWhatsAppAccount(account), message.WhatsAppMessageID); err != nil {
+			a.Log.Error("Failed to send read receipt", "error", err, "message_id", message.WhatsAppMessageID)
+			return
+		}
+		a.Redis.Set(ctx, key, message.WhatsAppMessageID, readReceiptTTL)
+	}()
+}
+
+// sendTypingIndicator shows the customer that an agent is typing. It also marks
+// their latest message read, so it is only sent by accounts that send read receipts.
+func (a *App) sendTypingIndicator(orgID, contactID uuid.UUID) {
+	// Rate limit before touching the database; typing events arrive on every keystroke
+	ctx := context.Background()
+	if ok, err := a.Redis.SetNX(ctx, typingIndicatorPrefix+contactID.String(), 1, typingIndicatorInterval).Result(); err != nil || !ok {
+		return
+	}
+
+	message, account := a.latestIncomingMessage(orgID, contactID)
+	if message == nil {
+		return
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := a.WhatsApp.SendTypingIndicator(ctx, a.toWhatsAppAccount(account), message.WhatsAppMessageID); err != nil {
+			a.Log.Error("Failed to send typing indicator", "error", err, "message_id", message.WhatsAppMessageID)
+			return
+		}
+		a.Redis.Set(ctx, readReceiptPrefix+contactID.String(), message.WhatsAppMessageID, readReceiptTTL)
+	}()
+}
+
+// latestIncomingMessage returns the customer's latest message and the account
+// it was received on, or nil when there is none or the account doesn't send
+// read receipts
+func (a *App) latestIncomingMessage(orgID, contactID uuid.UUID) (*models.Message, *models.WhatsAppAccount) {
+	var message models.Message
+	if err := a.DB.Where("organization_id = ? AND contact_id = ? AND direction = ? AND whats_app_message_id != ''",
+		orgID, contactID, models.DirectionIncoming).
+		Order("created_at DESC").
+		First(&message).Error; err != nil {
+		return nil, nil
+	}
+
+	var account models.WhatsAppAccount
+	if err := a.DB.Where("organization_id = ? AND name = ?", orgID, message.WhatsAppAccount).First(&account).Error; err != nil {
+		return nil, nil
+	}
+	if !account.AutoReadReceipt {
+		return nil, nil
+	}
+	return &message, &account
+}
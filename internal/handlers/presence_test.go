@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPresenceTestApp creates an app whose WhatsApp API calls are recorded
+func newPresenceTestApp(t *testing.T) (*App, func() []map[string]any) {
+	t.Helper()
+	db := testutil.SetupTestDB(t)
+	rdb := testutil.SetupTestRedis(t)
+	if rdb == nil {
+		t.Skip("TEST_REDIS_URL not set, skipping test")
+	}
+	log := testutil.NopLogger()
+
+	var mu sync.Mutex
+	var requests []map[string]any
+	waServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		requests = append(requests, body)
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	}))
+	t.Cleanup(waServer.Close)
+
+	hub := websocket.NewHub(log)
+	go hub.Run()
+
+	app := &App{
+		DB:       db,
+		Log:      log,
+		Redis:    rdb,
+		WhatsApp: whatsapp.NewWithBaseURL(log, waServer.URL),
+		WSHub:    hub,
+	}
+	return app, func() []map[string]any {
+		app.WaitForBackgroundTasks()
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]map[string]any(nil), requests...)
+	}
+}
+
+func TestHandleClientEvent_ReadReceiptsAndTyping(t *testing.T) {
+	app, requests := newPresenceTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	agent := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	require.NoError(t, app.DB.Model(account).Update("auto_read_receipt", true).Error)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	for _, wamid := range []string{"wamid.first", "wamid.latest"} {
+		require.NoError(t, app.DB.Create(&models.Message{
+			OrganizationID:    org.ID,
+			WhatsAppAccount:   account.Name,
+			ContactID:         contact.ID,
+			WhatsAppMessageID: wamid,
+			Direction:         models.DirectionIncoming,
+			MessageType:       models.MessageTypeText,
+			Content:           "Hi",
+		}).Error)
+	}
+
+	ev := websocket.ClientEvent{OrgID: org.ID, UserID: agent.ID, ContactID: contact.ID, State: websocket.PresenceViewing}
+	app.handleClientEvent(ev)
+	sent := requests()
+	require.Len(t, sent, 1, "opening the conversation sends one read receipt")
+	assert.Equal(t, "read", sent[0]["status"])
+	assert.Equal(t, "wamid.latest", sent[0]["message_id"])
+	assert.Nil(t, sent[0]["typing_indicator"])
+
+	// Reopening doesn't send the receipt again
+	app.handleClientEvent(ev)
+	require.Len(t, requests(), 1)
+
+	ev.State = websocket.PresenceTyping
+	app.handleClientEvent(ev)
+	sent = requests()
+	require.Len(t, sent, 2)
+	assert.Equal(t, "wamid.latest", sent[1]["message_id"])
+	assert.Equal(t, map[string]any{"type": "text"}, sent[1]["typing_indicator"])
+
+	// Typing indicators are throttled
+	app.handleClientEvent(ev)
+	assert.Len(t, requests(), 2)
+}
+
+func TestSendTypingIndicator_ThrottledBeforeDatabase(t *testing.T) {
+	rdb := testutil.SetupTestRedis(t)
+	if rdb == nil {
+		t.Skip("TEST_REDIS_URL not set, skipping test")
+	}
+	// No database: a throttled typing event must not query it
+	app := &App{Log: testutil.NopLogger(), Redis: rdb}
+	contactID := uuid.New()
+	require.NoError(t, rdb.Set(context.Background(), typingIndicatorPrefix+contactID.String(), 1, time.Minute).Err())
+
+	assert.NotPanics(t, func() { app.sendTypingIndicator(uuid.New(), contactID) })
+}
+
+func TestHandleClientEvent_AccountWithoutReadReceipts(t *testing.T) {
+	app, requests := newPresenceTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	agent := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+	require.NoError(t, app.DB.Create(&models.Message{
+		OrganizationID:    org.ID,
+		WhatsAppAccount:   account.Name,
+		ContactID:         contact.ID,
+		WhatsAppMessageID: "wamid." + uuid.New().String(),
+		Direction:         models.DirectionIncoming,
+		MessageType:       models.MessageTypeText,
+		Content:           "Hi",
+	}).Error)
+
+	for _, state := range []string{websocket.PresenceViewing, websocket.PresenceTyping} {
+		app.handleClientEvent(websocket.ClientEvent{OrgID: org.ID, UserID: agent.ID, ContactID: contact.ID, State: state})
+	}
+	assert.Empty(t, requests(), "typing indicators would mark messages read too")
+}
+
+func TestConversationViewers(t *testing.T) {
+	app, _ := newPresenceTestApp(t)
+	ctx := context.Background()
+	orgID := uuid.New()
+	contactID := uuid.New()
+	here := uuid.New()
+	elsewhere := uuid.New()
+	gone := uuid.New()
+
+	app.addViewers(websocket.ClientEvent{OrgID: orgID, UserID: here, ContactID: contactID, State: websocket.PresenceViewing})
+	key := viewersPrefix + contactID.String()
+	require.NoError(t, app.Redis.ZAdd(ctx, key,
+		redis.Z{Score: float64(time.Now().UnixMilli()), Member: elsewhere.String() + "/other-server"},
+		redis.Z{Score: float64(time.Now().Add(-2 * viewersTTL).UnixMilli()), Member: gone.String() + "/crashed-server"},
+	).Err())
+
+	assert.ElementsMatch(t, []uuid.UUID{here, elsewhere}, app.viewers(contactID), "viewers of servers that stopped refreshing expire")
+
+	app.removeViewer(websocket.ClientEvent{OrgID: orgID, UserID: here, ContactID: contactID, State: websocket.PresenceLeft})
+	assert.Equal(t, []uuid.UUID{elsewhere}, app.viewers(contactID))
+}
+
+func TestSaveIncomingMessage_ReadWhileViewing(t *testing.T) {
+	app, requests := newPresenceTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	require.NoError(t, app.DB.Model(account).Update("auto_read_receipt", true).Error)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	app.saveIncomingMessage(account, contact, "wamid.unseen", "text", "Anyone there?", nil, "", nil)
+	assert.Empty(t, requests(), "nobody is viewing the conversation")
+
+	app.addViewers(websocket.ClientEvent{OrgID: org.ID, UserID: uuid.New(), ContactID: contact.ID, State: websocket.PresenceViewing})
+	app.saveIncomingMessage(account, contact, "wamid.seen", "text", "Hello?", nil, "", nil)
+	sent := requests()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "read", sent[0]["status"])
+	assert.Equal(t, "wamid.seen", sent[0]["message_id"])
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/queue"
+)
+
+// processorInstanceID identifies this process to the other server and worker
+// replicas sharing the background processors
+var processorInstanceID = newProcessorInstanceID()
+
+func newProcessorInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "whatomate"
+	}
+	return host + "-" + uuid.NewString()[:8]
+}
+
+// newProcessorLease returns the leader lease of the named processor, or nil
+// without Redis, in which case the processor always runs
+func (a *App) newProcessorLease(name string, ttl time.Duration) *queue.Lease {
+	if a.Redis == nil {
+		return nil
+	}
+	return queue.NewLease(a.Redis, name, processorInstanceID, ttl)
+}
+
+// holdLease takes or renews lease and reports whether this process should do
+// the work guarded by it. Redis errors count as not holding it, so replicas
+// never act twice while Redis is unreachable.
+func (a *App) holdLease(lease *queue.Lease, name string) bool {
+	if lease == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	held, err := lease.Acquire(ctx)
+	if err != nil {
+		a.Log.Error("Failed to acquire processor lease", "error", err, "lease", name)
+		return false
+	}
+	return held
+}
+
+// releaseLease gives lease up so another replica can take over right away
+func (a *App) releaseLease(lease *queue.Lease, name string) {
+	if lease == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := lease.Release(ctx); err != nil {
+		a.Log.Error("Failed to release processor lease", "error", err, "lease", name)
+	}
+}
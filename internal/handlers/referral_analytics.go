@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// ReferralSourceStat is the attribution of one ad or post
+type ReferralSourceStat struct {
+	SourceType  string `json:"source_type"`
+	SourceID    string `json:"source_id"`
+	SourceURL   string `json:"source_url"`
+	Headline    string `json:"headline"`
+	Referrals   int64  `json:"referrals"`
+	Contacts    int64  `json:"contacts"`
+	NewContacts int64  `json:"new_contacts"`
+}
+
+// GetReferralAnalytics reports the contacts that messaged from click-to-WhatsApp
+// ads and posts, per source
+func (a *App) GetReferralAnalytics(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceAnalytics, models.ActionRead); err != nil {
+		return nil
+	}
+
+	fromStr := string(r.RequestCtx.QueryArgs().Peek("from"))
+	toStr := string(r.RequestCtx.QueryArgs().Peek("to"))
+
+	now := time.Now()
+	var periodStart, periodEnd time.Time
+	if fromStr != "" && toStr != "" {
+		var errMsg string
+		periodStart, periodEnd, errMsg = parseDateRange(fromStr, toStr)
+		if errMsg != "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, errMsg, nil, "")
+		}
+	} else {
+		// Default to current month
+		periodStart = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		periodEnd = now
+	}
+
+	query := a.DB.Model(&models.ContactReferral{}).
+		Where("organization_id = ? AND created_at BETWEEN ? AND ?", orgID, periodStart, periodEnd)
+	if sourceType := string(r.RequestCtx.QueryArgs().Peek("source_type")); sourceType != "" {
+		query = query.Where("source_type = ?", sourceType)
+	}
+
+	var totals struct {
+		Referrals   int64
+		Contacts    int64
+		NewContacts int64
+	}
+	query.Session(&gorm.Session{}).
+		Select("COUNT(*) AS referrals, COUNT(DISTINCT contact_id) AS contacts, " +
+			"COUNT(DISTINCT contact_id) FILTER (WHERE is_new_contact) AS new_contacts").
+		Scan(&totals)
+
+	sources := []ReferralSourceStat{}
+	if err := query.Session(&gorm.Session{}).
+		Select("source_type, source_id, MAX(source_url) AS source_url, MAX(headline) AS headline, " +
+			"COUNT(*) AS referrals, COUNT(DISTINCT contact_id) AS contacts, " +
+			"COUNT(DISTINCT contact_id) FILTER (WHERE is_new_contact) AS new_contacts").
+		Group("source_type, source_id").
+		Order("referrals DESC").
+		Scan(&sources).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to fetch referral analytics", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"referrals":    totals.Referrals,
+		"contacts":     totals.Contacts,
+		"new_contacts": totals.NewContacts,
+		"sources":      sources,
+		"from":         periodStart.Format("2006-01-02"),
+		"to":           periodEnd.Format("2006-01-02"),
+	})
+}
@@ -0,0 +1,457 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// maxSLAEscalationLevels limits the length of a policy's escalation chain
+const maxSLAEscalationLevels = 5
+
+// SLAPolicyRequest represents the request body for creating/updating an SLA policy
+type SLAPolicyRequest struct {
+	Name                 string                   `json:"name"`
+	Description          string                   `json:"description"`
+	Priority             int                      `json:"priority"`  // Higher priority policies are matched first
+	IsActive             *bool                    `json:"is_active"` // Defaults to true
+	TeamIDs              []string                 `json:"team_ids"`
+	Tags                 []string                 `json:"tags"`
+	WhatsAppAccounts     []string                 `json:"whatsapp_accounts"`
+	MinPriority          *int                     `json:"min_priority"`
+	FirstResponseMinutes int                      `json:"first_response_minutes"`
+	NextResponseMinutes  int                      `json:"next_response_minutes"`
+	ResolutionMinutes    int                      `json:"resolution_minutes"`
+	EscalationLevels     []map[string]interface{} `json:"escalation_levels"` // [{after_minutes, notify_user_ids, notify_team_ids}]
+}
+
+// SLAPolicyResponse represents an SLA policy in API responses
+type SLAPolicyResponse struct {
+	ID                   string               `json:"id"`
+	Name                 string               `json:"name"`
+	Description          string               `json:"description"`
+	Priority             int                  `json:"priority"`
+	IsActive             bool                 `json:"is_active"`
+	TeamIDs              []string             `json:"team_ids"`
+	Tags                 []string             `json:"tags"`
+	WhatsAppAccounts     []string             `json:"whatsapp_accounts"`
+	MinPriority          *int                 `json:"min_priority,omitempty"`
+	FirstResponseMinutes int                  `json:"first_response_minutes"`
+	NextResponseMinutes  int                  `json:"next_response_minutes"`
+	ResolutionMinutes    int                  `json:"resolution_minutes"`
+	EscalationLevels     []slaEscalationLevel `json:"escalation_levels"`
+	CreatedAt            string               `json:"created_at"`
+	UpdatedAt            string               `json:"updated_at"`
+}
+
+// slaEscalationLevel is one step of a policy's escalation chain
+type slaEscalationLevel struct {
+	AfterMinutes  int      `json:"after_minutes"` // Since the transfer was created
+	NotifyUserIDs []string `json:"notify_user_ids"`
+	NotifyTeamIDs []string `json:"notify_team_ids"`
+}
+
+// parseSLAEscalationLevels reads and checks a policy's escalation chain
+func parseSLAEscalationLevels(levels models.JSONBArray) ([]slaEscalationLevel, error) {
+	if len(levels) > maxSLAEscalationLevels {
+		return nil, fmt.Errorf("at most %d escalation levels are allowed", maxSLAEscalationLevels)
+	}
+	out := make([]slaEscalationLevel, 0, len(levels))
+	for i, entry := range levels {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("escalation level %d must be an object", i+1)
+		}
+		after, _ := m["after_minutes"].(float64)
+		level := slaEscalationLevel{
+			AfterMinutes:  int(after),
+			NotifyUserIDs: stringList(m["notify_user_ids"]),
+			NotifyTeamIDs: stringList(m["notify_team_ids"]),
+		}
+		if level.AfterMinutes <= 0 {
+			return nil, fmt.Errorf("escalation level %d: after_minutes must be positive", i+1)
+		}
+		if i > 0 && level.AfterMinutes <= out[i-1].AfterMinutes {
+			return nil, fmt.Errorf("escalation level %d: after_minutes must be later than the previous level", i+1)
+		}
+		for _, id := range append(append([]string{}, level.NotifyUserIDs...), level.NotifyTeamIDs...) {
+			if _, err := uuid.Parse(id); err != nil {
+				return nil, fmt.Errorf("escalation level %d: invalid id %q", i+1, id)
+			}
+		}
+		if level.NotifyUserIDs == nil {
+			level.NotifyUserIDs = []string{}
+		}
+		if level.NotifyTeamIDs == nil {
+			level.NotifyTeamIDs = []string{}
+		}
+		out = append(out, level)
+	}
+	return out, nil
+}
+
+// validateSLAPolicy checks a policy's name, targets, rules and escalation chain
+func validateSLAPolicy(policy *models.SLAPolicy) error {
+	if strings.TrimSpace(policy.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(policy.Name) > 100 {
+		return errors.New("name must be at most 100 characters")
+	}
+	if policy.FirstResponseMinutes < 0 || policy.NextResponseMinutes < 0 || policy.ResolutionMinutes < 0 {
+		return errors.New("minutes must not be negative")
+	}
+	for _, id := range policy.TeamIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("invalid team id %q", id)
+		}
+	}
+	_, err := parseSLAEscalationLevels(policy.EscalationLevels)
+	return err
+}
+
+// slaPolicyMatches reports whether a transfer matches all of the policy's rules
+func slaPolicyMatches(policy *models.SLAPolicy, transfer *models.AgentTransfer, contactTags []string) bool {
+	if !policy.IsActive {
+		return false
+	}
+	if len(policy.TeamIDs) > 0 {
+		if transfer.TeamID == nil || !containsFold(policy.TeamIDs, transfer.TeamID.String()) {
+			return false
+		}
+	}
+	if len(policy.WhatsAppAccounts) > 0 && !containsFold(policy.WhatsAppAccounts, transfer.WhatsAppAccount) {
+		return false
+	}
+	if policy.MinPriority != nil && transfer.Priority < *policy.MinPriority {
+		return false
+	}
+	if len(policy.Tags) > 0 {
+		matched := false
+		for _, tag := range contactTags {
+			if containsFold(policy.Tags, tag) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSLAPolicy returns the first of the policies (highest priority first)
+// matching the transfer, or nil
+func matchSLAPolicy(policies []models.SLAPolicy, transfer *models.AgentTransfer, contactTags []string) *models.SLAPolicy {
+	for i := range policies {
+		if slaPolicyMatches(&policies[i], transfer, contactTags) {
+			return &policies[i]
+		}
+	}
+	return nil
+}
+
+// findSLAPolicy returns the SLA policy that applies to a transfer, or nil
+// when the organization's chatbot settings apply
+func (a *App) findSLAPolicy(transfer *models.AgentTransfer) *models.SLAPolicy {
+	var policies []models.SLAPolicy
+	if err := a.DB.Where("organization_id = ? AND is_active = ?", transfer.OrganizationID, true).
+		Order("priority DESC, created_at ASC").
+		Find(&policies).Error; err != nil {
+		a.Log.Error("Failed to load SLA policies", "error", err, "org_id", transfer.OrganizationID)
+		return nil
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	// Only load the contact when a policy matches on tags
+	var tags []string
+	for _, p := range policies {
+		if len(p.Tags) > 0 {
+			var contact models.Contact
+			if a.DB.Select("tags").Where("id = ?", transfer.ContactID).First(&contact).Error == nil {
+				tags = stringList(contact.Tags)
+			}
+			break
+		}
+	}
+
+	return matchSLAPolicy(policies, transfer, tags)
+}
+
+// slaEscalationNotifyIDs returns the users to notify for an escalation level,
+// including the members of its teams
+func (a *App) slaEscalationNotifyIDs(level slaEscalationLevel) []string {
+	ids := append([]string{}, level.NotifyUserIDs...)
+	if len(level.NotifyTeamIDs) > 0 {
+		var memberIDs []uuid.UUID
+		a.DB.Model(&models.TeamMember{}).
+			Where("team_id IN ?", level.NotifyTeamIDs).
+			Distinct().
+			Pluck("user_id", &memberIDs)
+		for _, id := range memberIDs {
+			if !containsFold(ids, id.String()) {
+				ids = append(ids, id.String())
+			}
+		}
+	}
+	return ids
+}
+
+// buildSLAPolicyResponse builds the API response for an SLA policy
+func buildSLAPolicyResponse(policy *models.SLAPolicy) SLAPolicyResponse {
+	levels, _ := parseSLAEscalationLevels(policy.EscalationLevels)
+	resp := SLAPolicyResponse{
+		ID:                   policy.ID.String(),
+		Name:                 policy.Name,
+		Description:          policy.Description,
+		Priority:             policy.Priority,
+		IsActive:             policy.IsActive,
+		TeamIDs:              policy.TeamIDs,
+		Tags:                 policy.Tags,
+		WhatsAppAccounts:     policy.WhatsAppAccounts,
+		MinPriority:          policy.MinPriority,
+		FirstResponseMinutes: policy.FirstResponseMinutes,
+		NextResponseMinutes:  policy.NextResponseMinutes,
+		ResolutionMinutes:    policy.ResolutionMinutes,
+		EscalationLevels:     levels,
+		CreatedAt:            policy.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:            policy.UpdatedAt.Format(time.RFC3339),
+	}
+	if resp.TeamIDs == nil {
+		resp.TeamIDs = []string{}
+	}
+	if resp.Tags == nil {
+		resp.Tags = []string{}
+	}
+	if resp.WhatsAppAccounts == nil {
+		resp.WhatsAppAccounts = []string{}
+	}
+	if resp.EscalationLevels == nil {
+		resp.EscalationLevels = []slaEscalationLevel{}
+	}
+	return resp
+}
+
+// applySLAPolicyRequest copies a request onto a policy and validates it,
+// checking that its teams belong to the organization
+func (a *App) applySLAPolicyRequest(orgID uuid.UUID, policy *models.SLAPolicy, req *SLAPolicyRequest) error {
+	policy.Name = strings.TrimSpace(req.Name)
+	policy.Description = req.Description
+	policy.Priority = req.Priority
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+	policy.TeamIDs = models.StringArray(req.TeamIDs)
+	policy.Tags = models.StringArray(req.Tags)
+	policy.WhatsAppAccounts = models.StringArray(req.WhatsAppAccounts)
+	policy.MinPriority = req.MinPriority
+	policy.FirstResponseMinutes = req.FirstResponseMinutes
+	policy.NextResponseMinutes = req.NextResponseMinutes
+	policy.ResolutionMinutes = req.ResolutionMinutes
+	policy.EscalationLevels = make(models.JSONBArray, 0, len(req.EscalationLevels))
+	for _, level := range req.EscalationLevels {
+		policy.EscalationLevels = append(policy.EscalationLevels, level)
+	}
+
+	if err := validateSLAPolicy(policy); err != nil {
+		return err
+	}
+
+	// Every referenced team must belong to the organization
+	teamIDs := append([]string{}, policy.TeamIDs...)
+	levels, _ := parseSLAEscalationLevels(policy.EscalationLevels)
+	for _, level := range levels {
+		teamIDs = append(teamIDs, level.NotifyTeamIDs...)
+	}
+	if len(teamIDs) > 0 {
+		unique := make(map[string]bool)
+		for _, id := range teamIDs {
+			unique[strings.ToLower(id)] = true
+		}
+		var count int64
+		a.DB.Model(&models.Team{}).
+			Where("id IN ? AND organization_id = ?", teamIDs, orgID).
+			Count(&count)
+		if int(count) != len(unique) {
+			return errors.New("team not found")
+		}
+	}
+	return nil
+}
+
+// ListSLAPolicies returns the organization's SLA policies, highest priority first
+func (a *App) ListSLAPolicies(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceSettingsChatbot, models.ActionRead); err != nil {
+		return nil
+	}
+
+	var policies []models.SLAPolicy
+	if err := a.DB.Where("organization_id = ?", orgID).
+		Order("priority DESC, created_at ASC").
+		Find(&policies).Error; err != nil {
+		a.Log.Error("Failed to list SLA policies", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list SLA policies", nil, "")
+	}
+
+	response := make([]SLAPolicyResponse, len(policies))
+	for i := range policies {
+		response[i] = buildSLAPolicyResponse(&policies[i])
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"policies": response,
+		"total":    len(response),
+	})
+}
+
+// CreateSLAPolicy creates a new SLA policy
+func (a *App) CreateSLAPolicy(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceSettingsChatbot, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	var req SLAPolicyRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	policy := models.SLAPolicy{
+		BaseModel:      models.BaseModel{ID: uuid.New()},
+		OrganizationID: orgID,
+		IsActive:       true,
+	}
+	if err := a.applySLAPolicyRequest(orgID, &policy, &req); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid SLA policy: "+err.Error(), nil, "")
+	}
+
+	if err := a.DB.Create(&policy).Error; err != nil {
+		a.Log.Error("Failed to create SLA policy", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create SLA policy", nil, "")
+	}
+	a.InvalidateSLASettingsCache() // The SLA processor also handles organizations with policies
+
+	return r.SendEnvelope(map[string]any{"policy": buildSLAPolicyResponse(&policy)})
+}
+
+// GetSLAPolicy returns a single SLA policy
+func (a *App) GetSLAPolicy(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceSettingsChatbot, models.ActionRead); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "SLA policy")
+	if err != nil {
+		return nil
+	}
+
+	policy, err := findByIDAndOrg[models.SLAPolicy](a.DB, r, id, orgID, "SLA policy")
+	if err != nil {
+		return nil
+	}
+
+	return r.SendEnvelope(map[string]any{"policy": buildSLAPolicyResponse(policy)})
+}
+
+// UpdateSLAPolicy replaces an SLA policy. Transfers keep the deadlines they
+// were given; the new targets apply to new transfers.
+func (a *App) UpdateSLAPolicy(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceSettingsChatbot, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "SLA policy")
+	if err != nil {
+		return nil
+	}
+
+	policy, err := findByIDAndOrg[models.SLAPolicy](a.DB, r, id, orgID, "SLA policy")
+	if err != nil {
+		return nil
+	}
+
+	var req SLAPolicyRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if err := a.applySLAPolicyRequest(orgID, policy, &req); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid SLA policy: "+err.Error(), nil, "")
+	}
+
+	if err := a.DB.Save(policy).Error; err != nil {
+		a.Log.Error("Failed to update SLA policy", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update SLA policy", nil, "")
+	}
+	a.InvalidateSLASettingsCache()
+
+	return r.SendEnvelope(map[string]any{"policy": buildSLAPolicyResponse(policy)})
+}
+
+// DeleteSLAPolicy deletes an SLA policy. Transfers it was applied to keep
+// their deadlines but escalate no further.
+func (a *App) DeleteSLAPolicy(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceSettingsChatbot, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "SLA policy")
+	if err != nil {
+		return nil
+	}
+
+	result := a.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.SLAPolicy{})
+	if result.Error != nil {
+		a.Log.Error("Failed to delete SLA policy", "error", result.Error)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete SLA policy", nil, "")
+	}
+	if result.RowsAffected == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "SLA policy not found", nil, "")
+	}
+	a.InvalidateSLASettingsCache()
+
+	return r.SendEnvelope(map[string]any{"message": "SLA policy deleted successfully"})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSLAEscalationLevels(t *testing.T) {
+	teamID := uuid.New().String()
+	levels, err := parseSLAEscalationLevels(models.JSONBArray{
+		map[string]interface{}{"after_minutes": float64(30), "notify_user_ids": []interface{}{uuid.New().String()}},
+		map[string]interface{}{"after_minutes": float64(60), "notify_team_ids": []interface{}{teamID}},
+	})
+	require.NoError(t, err)
+	require.Len(t, levels, 2)
+	assert.Equal(t, 30, levels[0].AfterMinutes)
+	assert.Len(t, levels[0].NotifyUserIDs, 1)
+	assert.Equal(t, []string{}, levels[0].NotifyTeamIDs)
+	assert.Equal(t, []string{teamID}, levels[1].NotifyTeamIDs)
+
+	_, err = parseSLAEscalationLevels(models.JSONBArray{
+		map[string]interface{}{"after_minutes": float64(60)},
+		map[string]interface{}{"after_minutes": float64(30)},
+	})
+	assert.ErrorContains(t, err, "later than the previous level")
+
+	_, err = parseSLAEscalationLevels(models.JSONBArray{map[string]interface{}{"after_minutes": float64(0)}})
+	assert.ErrorContains(t, err, "must be positive")
+
+	_, err = parseSLAEscalationLevels(models.JSONBArray{
+		map[string]interface{}{"after_minutes": float64(10), "notify_user_ids": []interface{}{"nope"}},
+	})
+	assert.ErrorContains(t, err, "invalid id")
+}
+
+func TestValidateSLAPolicy(t *testing.T) {
+	assert.NoError(t, validateSLAPolicy(&models.SLAPolicy{Name: "VIP", FirstResponseMinutes: 5}))
+	assert.ErrorContains(t, validateSLAPolicy(&models.SLAPolicy{Name: " "}), "name is required")
+	assert.ErrorContains(t, validateSLAPolicy(&models.SLAPolicy{Name: "VIP", ResolutionMinutes: -1}), "must not be negative")
+	assert.ErrorContains(t, validateSLAPolicy(&models.SLAPolicy{Name: "VIP", TeamIDs: models.StringArray{"x"}}), "invalid team id")
+}
+
+func TestMatchSLAPolicy(t *testing.T) {
+	teamID := uuid.New()
+	minPriority := 5
+	policies := []models.SLAPolicy{
+		{Name: "Inactive", IsActive: false},
+		{Name: "VIP", IsActive: true, Tags: models.StringArray{"vip"}},
+		{Name: "Urgent billing", IsActive: true, TeamIDs: models.StringArray{teamID.String()}, MinPriority: &minPriority},
+		{Name: "Sales number", IsActive: true, WhatsAppAccounts: models.StringArray{"sales"}},
+	}
+
+	transfer := &models.AgentTransfer{TeamID: &teamID, Priority: 7, WhatsAppAccount: "support"}
+	assert.Equal(t, "VIP", matchSLAPolicy(policies, transfer, []string{"VIP"}).Name, "tags match ignoring case")
+	assert.Equal(t, "Urgent billing", matchSLAPolicy(policies, transfer, nil).Name)
+
+	transfer.Priority = 1
+	assert.Nil(t, matchSLAPolicy(policies, transfer, nil), "priority below the policy's minimum")
+
+	transfer.WhatsAppAccount = "sales"
+	assert.Equal(t, "Sales number", matchSLAPolicy(policies, transfer, nil).Name)
+}
+
+func TestNewSLACompliance(t *testing.T) {
+	c := newSLACompliance(slaComplianceCounts{
+		FirstResponseDue: 4, FirstResponseMet: 3,
+		NextResponseDue: 10, NextResponseMet: 9,
+	})
+	assert.Equal(t, int64(4), c.FirstResponseTotal)
+	assert.InDelta(t, 75.0, c.FirstResponsePct, 0.001)
+	assert.InDelta(t, 90.0, c.NextResponsePct, 0.001)
+	assert.Equal(t, int64(0), c.ResolutionTotal)
+	assert.Equal(t, 0.0, c.ResolutionPct)
+}
@@ -6,23 +6,35 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/queue"
 	"github.com/shridarpatil/whatomate/internal/websocket"
+	"gorm.io/gorm"
 )
 
-// SLAProcessor handles periodic SLA checks and escalations
+// slaShardName names the shard membership and organization leases of the SLA processor
+const slaShardName = "sla"
+
+// SLAProcessor handles periodic SLA checks and escalations. With several
+// replicas, organizations are split between the running SLA processors so
+// each organization is processed by one of them at a time.
 type SLAProcessor struct {
 	app      *App
 	interval time.Duration
+	shards   *queue.Shards
 	stopCh   chan struct{}
 }
 
 // NewSLAProcessor creates a new SLA processor
 func NewSLAProcessor(app *App, interval time.Duration) *SLAProcessor {
-	return &SLAProcessor{
+	p := &SLAProcessor{
 		app:      app,
 		interval: interval,
 		stopCh:   make(chan struct{}),
 	}
+	if app.Redis != nil {
+		p.shards = queue.NewShards(app.Redis, slaShardName, processorInstanceID, 3*interval)
+	}
+	return p
 }
 
 // Start begins the SLA processing loop
@@ -31,6 +43,7 @@ func (p *SLAProcessor) Start(ctx context.Context) {
 
 	ticker := time.NewTicker(p.interval)
 	defer ticker.Stop()
+	defer p.leaveShards()
 
 	for {
 		select {
@@ -55,39 +68,92 @@ func (p *SLAProcessor) Stop() {
 func (p *SLAProcessor) processStaleTransfers() {
 	now := time.Now()
 
-	// Get all organizations with SLA enabled (use cache)
+	// Get all organizations with SLA enabled or SLA policies (use cache)
 	settings, err := p.app.getSLAEnabledSettingsCached()
 	if err != nil {
 		p.app.Log.Error("Failed to load SLA settings", "error", err)
 		return
 	}
 
+	members, ok := p.shardMembers()
+	if !ok {
+		return
+	}
+
 	for _, s := range settings {
+		if !p.ownsOrganization(s.OrganizationID, members) {
+			continue
+		}
 		p.processOrganizationSLA(s, now)
 	}
 }
 
+// shardMembers announces this processor and returns the live SLA processors.
+// ok is false when Redis can't be reached, so nothing is processed twice.
+func (p *SLAProcessor) shardMembers() (members []string, ok bool) {
+	if p.shards == nil {
+		return nil, true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	members, err := p.shards.Refresh(ctx)
+	if err != nil {
+		p.app.Log.Error("Failed to refresh SLA processor shards", "error", err)
+		return nil, false
+	}
+	return members, true
+}
+
+// ownsOrganization reports whether this processor handles orgID on this run.
+// Besides owning the organization's shard it must hold the organization's
+// lease, which keeps the previous owner and the new one apart while the
+// organization moves between replicas.
+func (p *SLAProcessor) ownsOrganization(orgID uuid.UUID, members []string) bool {
+	if p.shards == nil {
+		return true
+	}
+	if !p.shards.Owns(orgID.String(), members) {
+		return false
+	}
+	name := slaShardName + ":org:" + orgID.String()
+	return p.app.holdLease(p.app.newProcessorLease(name, 2*p.interval), name)
+}
+
+// leaveShards hands this processor's organizations over to the other replicas
+func (p *SLAProcessor) leaveShards() {
+	if p.shards == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.shards.Leave(ctx); err != nil {
+		p.app.Log.Error("Failed to leave SLA processor shards", "error", err)
+	}
+}
+
 // processOrganizationSLA processes SLA for a single organization
 func (p *SLAProcessor) processOrganizationSLA(settings models.ChatbotSettings, now time.Time) {
 	orgID := settings.OrganizationID
 
 	// 1. Auto-close expired transfers
-	if settings.SLA.AutoCloseHours > 0 {
+	if settings.SLA.Enabled && settings.SLA.AutoCloseHours > 0 {
 		p.autoCloseExpiredTransfers(orgID, settings, now)
 	}
 
-	// 2. Escalate transfers past escalation deadline
-	if settings.SLA.EscalationMinutes > 0 {
-		p.escalateTransfers(orgID, settings, now)
-	}
+	// 2. Escalate transfers past escalation deadline. SLA policies can set
+	// deadlines even when the settings don't, so these always run.
+	p.escalateTransfers(orgID, settings, now)
 
 	// 3. Mark SLA breached for transfers past response deadline
-	if settings.SLA.ResponseMinutes > 0 {
-		p.markSLABreached(orgID, settings, now)
-	}
+	p.markSLABreached(orgID, settings, now)
+
+	// 4. Count unanswered customer messages past their next response deadline
+	p.markNextResponseBreached(orgID, now)
 
-	// 4. Handle client inactivity (reminders and auto-close)
-	if settings.ClientInactivity.ReminderEnabled {
+	// 5. Handle client inactivity (reminders and auto-close)
+	if settings.SLA.Enabled && settings.ClientInactivity.ReminderEnabled {
 		p.processClientInactivity(orgID, settings, now)
 	}
 }
@@ -104,20 +170,26 @@ func (p *SLAProcessor) autoCloseExpiredTransfers(orgID uuid.UUID, settings model
 	}
 
 	for _, transfer := range transfers {
-		// Send auto-close message to customer if configured
-		if settings.SLA.AutoCloseMessage != "" {
-			p.sendSLAAutoCloseToCustomer(transfer, settings.SLA.AutoCloseMessage)
-		}
-
-		// Update transfer status
-		if err := p.app.DB.Model(&transfer).Updates(map[string]interface{}{
+		// Expire the transfer only if it is still active, so the customer
+		// gets the auto-close message once even if another replica got here first
+		result := p.app.DB.Model(&transfer).Where("status = ?", models.TransferStatusActive).Updates(map[string]interface{}{
 			"status":     models.TransferStatusExpired,
 			"resumed_at": now,
 			"notes":      transfer.Notes + "\n[Auto-closed: No agent response within SLA]",
-		}).Error; err != nil {
-			p.app.Log.Error("Failed to expire transfer", "error", err, "transfer_id", transfer.ID)
+		})
+		if result.Error != nil {
+			p.app.Log.Error("Failed to expire transfer", "error", result.Error, "transfer_id", transfer.ID)
 			continue
 		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		// Send auto-close message to customer if configured
+		if settings.SLA.AutoCloseMessage != "" {
+			p.sendSLAAutoCloseToCustomer(transfer, &settings)
+		}
+		p.app.scheduleCSATSurvey(&transfer)
 
 		p.app.Log.Info("Transfer auto-closed due to expiry",
 			"transfer_id", transfer.ID,
@@ -138,13 +210,14 @@ func (p *SLAProcessor) autoCloseExpiredTransfers(orgID uuid.UUID, settings model
 func (p *SLAProcessor) escalateTransfers(orgID uuid.UUID, settings models.ChatbotSettings, now time.Time) {
 	var transfers []models.AgentTransfer
 	if err := p.app.DB.Where(
-		"organization_id = ? AND status = ? AND sla_escalation_at IS NOT NULL AND sla_escalation_at < ? AND escalation_level < 2",
+		"organization_id = ? AND status = ? AND sla_escalation_at IS NOT NULL AND sla_escalation_at < ? AND (sla_policy_id IS NOT NULL OR escalation_level < 2)",
 		orgID, models.TransferStatusActive, now,
 	).Find(&transfers).Error; err != nil {
 		p.app.Log.Error("Failed to find transfers for escalation", "error", err, "org_id", orgID)
 		return
 	}
 
+	policies := make(map[uuid.UUID]*models.SLAPolicy)
 	for _, transfer := range transfers {
 		newLevel := transfer.SLA.EscalationLevel + 1
 
@@ -154,14 +227,47 @@ func (p *SLAProcessor) escalateTransfers(orgID uuid.UUID, settings models.Chatbo
 			"escalated_at":     now,
 		}
 
+		notifyIDs := []string(settings.SLA.EscalationNotifyIDs)
+		if transfer.SLA.PolicyID != nil {
+			// Policy escalation chain: notify this level's targets and
+			// schedule the next level, if any
+			notifyIDs = nil
+			updates["sla_escalation_at"] = nil
+			policy, ok := policies[*transfer.SLA.PolicyID]
+			if !ok {
+				policy = &models.SLAPolicy{}
+				if err := p.app.DB.Where("id = ?", transfer.SLA.PolicyID).First(policy).Error; err != nil {
+					policy = nil
+				}
+				policies[*transfer.SLA.PolicyID] = policy
+			}
+			if policy != nil {
+				levels, _ := parseSLAEscalationLevels(policy.EscalationLevels)
+				if newLevel <= len(levels) {
+					notifyIDs = p.app.slaEscalationNotifyIDs(levels[newLevel-1])
+				}
+				if newLevel < len(levels) {
+					accountSettings, _ := p.app.getChatbotSettingsCached(orgID, transfer.WhatsAppAccount)
+					after := time.Duration(levels[newLevel].AfterMinutes) * time.Minute
+					updates["sla_escalation_at"] = slaDeadline(p.app.businessCalendar(accountSettings), transfer.TransferredAt, after)
+				}
+			}
+		}
+
 		// If not yet breached and past response deadline, mark as breached
 		if !transfer.SLA.Breached && transfer.SLA.ResponseDeadline != nil && now.After(*transfer.SLA.ResponseDeadline) {
 			updates["sla_breached"] = true
 			updates["sla_breached_at"] = now
 		}
 
-		if err := p.app.DB.Model(&transfer).Updates(updates).Error; err != nil {
-			p.app.Log.Error("Failed to escalate transfer", "error", err, "transfer_id", transfer.ID)
+		// Only escalate from the level that was loaded, so a level is
+		// never escalated (and notified) twice
+		result := p.app.DB.Model(&transfer).Where("escalation_level = ?", transfer.SLA.EscalationLevel).Updates(updates)
+		if result.Error != nil {
+			p.app.Log.Error("Failed to escalate transfer", "error", result.Error, "transfer_id", transfer.ID)
+			continue
+		}
+		if result.RowsAffected == 0 {
 			continue
 		}
 
@@ -173,14 +279,14 @@ func (p *SLAProcessor) escalateTransfers(orgID uuid.UUID, settings models.Chatbo
 		)
 
 		// Send notification to escalation contacts
-		p.notifyEscalation(transfer, settings, newLevel)
+		p.notifyEscalation(transfer, notifyIDs, newLevel)
 
 		// Broadcast update
 		p.broadcastTransferUpdate(transfer, "escalated")
 
 		// Send warning message to customer if configured
-		if newLevel == 1 && settings.SLA.WarningMessage != "" {
-			p.sendSLAWarningToCustomer(transfer, settings.SLA.WarningMessage)
+		if newLevel == 1 && settings.SLA.Enabled && settings.SLA.WarningMessage != "" {
+			p.sendSLAWarningToCustomer(transfer, &settings)
 		}
 	}
 
@@ -209,9 +315,31 @@ func (p *SLAProcessor) markSLABreached(orgID uuid.UUID, settings models.ChatbotS
 	}
 }
 
+// markNextResponseBreached counts customer messages still unanswered past
+// their next response deadline as late replies and stops their clock
+func (p *SLAProcessor) markNextResponseBreached(orgID uuid.UUID, now time.Time) {
+	result := p.app.DB.Model(&models.AgentTransfer{}).Where(
+		"organization_id = ? AND status = ? AND sla_next_response_deadline IS NOT NULL AND sla_next_response_deadline < ?",
+		orgID, models.TransferStatusActive, now,
+	).Updates(map[string]interface{}{
+		"sla_next_responses":         gorm.Expr("sla_next_responses + 1"),
+		"sla_next_response_breaches": gorm.Expr("sla_next_response_breaches + 1"),
+		"sla_next_response_deadline": nil,
+	})
+
+	if result.Error != nil {
+		p.app.Log.Error("Failed to mark next response SLA breached", "error", result.Error, "org_id", orgID)
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		p.app.Log.Warn("Marked next responses as SLA breached", "count", result.RowsAffected, "org_id", orgID)
+	}
+}
+
 // notifyEscalation sends notifications to escalation contacts via WebSocket broadcast
-func (p *SLAProcessor) notifyEscalation(transfer models.AgentTransfer, settings models.ChatbotSettings, level int) {
-	if len(settings.SLA.EscalationNotifyIDs) == 0 {
+func (p *SLAProcessor) notifyEscalation(transfer models.AgentTransfer, notifyIDs []string, level int) {
+	if len(notifyIDs) == 0 {
 		return
 	}
 
@@ -228,9 +356,15 @@ func (p *SLAProcessor) notifyEscalation(transfer models.AgentTransfer, settings
 		levelName = "critical"
 	}
 
-	// Broadcast escalation notification to the organization
-	// Escalation contacts will receive this via the org-wide broadcast
-	p.app.WSHub.BroadcastToOrg(transfer.OrganizationID, websocket.WSMessage{
+	// Broadcast escalation notification to the users following the transfer
+	// and to the escalation contacts
+	var notifyUserIDs []uuid.UUID
+	for _, id := range notifyIDs {
+		if userID, err := uuid.Parse(id); err == nil {
+			notifyUserIDs = append(notifyUserIDs, userID)
+		}
+	}
+	p.app.WSHub.BroadcastToTransfer(transfer.OrganizationID, transfer.ID, websocket.WSMessage{
 		Type: "transfer_escalation",
 		Payload: map[string]interface{}{
 			"transfer_id":           transfer.ID.String(),
@@ -241,19 +375,34 @@ func (p *SLAProcessor) notifyEscalation(transfer models.AgentTransfer, settings
 			"level_name":            levelName,
 			"waiting_since":         transfer.TransferredAt.Format(time.RFC3339),
 			"team_id":               transfer.TeamID,
-			"escalation_notify_ids": settings.SLA.EscalationNotifyIDs,
+			"sla_policy_id":         transfer.SLA.PolicyID,
+			"escalation_notify_ids": notifyIDs,
 		},
-	})
+	}, notifyUserIDs...)
 
 	p.app.Log.Info("Escalation notification sent",
 		"transfer_id", transfer.ID,
 		"level", level,
-		"notify_count", len(settings.SLA.EscalationNotifyIDs),
+		"notify_count", len(notifyIDs),
 	)
 }
 
-// sendSLAWarningToCustomer sends a warning message to the customer
-func (p *SLAProcessor) sendSLAWarningToCustomer(transfer models.AgentTransfer, message string) {
+// sendSLAWarningToCustomer sends a warning message to the customer, at most
+// once per transfer
+func (p *SLAProcessor) sendSLAWarningToCustomer(transfer models.AgentTransfer, settings *models.ChatbotSettings) {
+	// Claim the warning before sending it. A failed send isn't retried, as
+	// the customer may have received it anyway.
+	result := p.app.DB.Model(&models.AgentTransfer{}).
+		Where("id = ? AND sla_warning_sent_at IS NULL", transfer.ID).
+		Update("sla_warning_sent_at", time.Now())
+	if result.Error != nil {
+		p.app.Log.Error("Failed to mark SLA warning sent", "error", result.Error, "transfer_id", transfer.ID)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
 	// Get WhatsApp account
 	var account models.WhatsAppAccount
 	if err := p.app.DB.Where("name = ?", transfer.WhatsAppAccount).First(&account).Error; err != nil {
@@ -267,6 +416,7 @@ func (p *SLAProcessor) sendSLAWarningToCustomer(transfer models.AgentTransfer, m
 		p.app.Log.Error("Failed to load contact for SLA warning", "error", err)
 		return
 	}
+	message := localizeChatbotSettings(settings, contactLanguage(settings, &contact)).SLA.WarningMessage
 
 	// Send using unified message sender
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -288,7 +438,7 @@ func (p *SLAProcessor) sendSLAWarningToCustomer(transfer models.AgentTransfer, m
 }
 
 // sendSLAAutoCloseToCustomer sends an auto-close notification message to the customer
-func (p *SLAProcessor) sendSLAAutoCloseToCustomer(transfer models.AgentTransfer, message string) {
+func (p *SLAProcessor) sendSLAAutoCloseToCustomer(transfer models.AgentTransfer, settings *models.ChatbotSettings) {
 	// Get WhatsApp account
 	var account models.WhatsAppAccount
 	if err := p.app.DB.Where("name = ?", transfer.WhatsAppAccount).First(&account).Error; err != nil {
@@ -302,6 +452,7 @@ func (p *SLAProcessor) sendSLAAutoCloseToCustomer(transfer models.AgentTransfer,
 		p.app.Log.Error("Failed to load contact for SLA auto-close message", "error", err)
 		return
 	}
+	message := localizeChatbotSettings(settings, contactLanguage(settings, &contact)).SLA.AutoCloseMessage
 
 	// Send using unified message sender
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -328,7 +479,7 @@ func (p *SLAProcessor) broadcastTransferUpdate(transfer models.AgentTransfer, ev
 	var contact models.Contact
 	p.app.DB.Where("id = ?", transfer.ContactID).First(&contact)
 
-	p.app.WSHub.BroadcastToOrg(transfer.OrganizationID, websocket.WSMessage{
+	p.app.WSHub.BroadcastToTransfer(transfer.OrganizationID, transfer.ID, websocket.WSMessage{
 		Type: "transfer_" + eventType,
 		Payload: map[string]interface{}{
 			"id":               transfer.ID.String(),
@@ -342,46 +493,75 @@ func (p *SLAProcessor) broadcastTransferUpdate(transfer models.AgentTransfer, ev
 	})
 }
 
-// SetSLADeadlines sets SLA deadlines on a new transfer based on settings
+// SetSLADeadlines sets SLA deadlines on a new transfer from the SLA policy
+// matching it, or from the settings when no policy matches and SLA tracking
+// is enabled. When business hours are enabled the SLA clock only runs during
+// working hours (including holidays), so deadlines skip closed periods.
 func (a *App) SetSLADeadlines(transfer *models.AgentTransfer, settings *models.ChatbotSettings) {
-	if !settings.SLA.Enabled {
+	policy := a.findSLAPolicy(transfer)
+	if policy == nil && !settings.SLA.Enabled {
 		return
 	}
 
 	now := time.Now()
+	cal := a.businessCalendar(settings)
+	deadlineAfter := func(d time.Duration) *time.Time {
+		return slaDeadline(cal, now, d)
+	}
+
+	responseMinutes := settings.SLA.ResponseMinutes
+	resolutionMinutes := settings.SLA.ResolutionMinutes
+	escalationMinutes := settings.SLA.EscalationMinutes
+	if policy != nil {
+		transfer.SLA.PolicyID = &policy.ID
+		transfer.SLA.NextResponseMinutes = policy.NextResponseMinutes
+		responseMinutes = policy.FirstResponseMinutes
+		resolutionMinutes = policy.ResolutionMinutes
+		escalationMinutes = 0
+		if levels, _ := parseSLAEscalationLevels(policy.EscalationLevels); len(levels) > 0 {
+			escalationMinutes = levels[0].AfterMinutes
+		}
+	}
 
-	// Response deadline (time to pick up)
-	if settings.SLA.ResponseMinutes > 0 {
-		deadline := now.Add(time.Duration(settings.SLA.ResponseMinutes) * time.Minute)
-		transfer.SLA.ResponseDeadline = &deadline
+	// Response deadline (time to pick up and first respond)
+	if responseMinutes > 0 {
+		transfer.SLA.ResponseDeadline = deadlineAfter(time.Duration(responseMinutes) * time.Minute)
 	}
 
 	// Resolution deadline
-	if settings.SLA.ResolutionMinutes > 0 {
-		deadline := now.Add(time.Duration(settings.SLA.ResolutionMinutes) * time.Minute)
-		transfer.SLA.ResolutionDeadline = &deadline
+	if resolutionMinutes > 0 {
+		transfer.SLA.ResolutionDeadline = deadlineAfter(time.Duration(resolutionMinutes) * time.Minute)
 	}
 
 	// Escalation deadline
-	if settings.SLA.EscalationMinutes > 0 {
-		deadline := now.Add(time.Duration(settings.SLA.EscalationMinutes) * time.Minute)
-		transfer.SLA.EscalationAt = &deadline
+	if escalationMinutes > 0 {
+		transfer.SLA.EscalationAt = deadlineAfter(time.Duration(escalationMinutes) * time.Minute)
 	}
 
 	// Expiry deadline (auto-close)
-	if settings.SLA.AutoCloseHours > 0 {
-		deadline := now.Add(time.Duration(settings.SLA.AutoCloseHours) * time.Hour)
-		transfer.SLA.ExpiresAt = &deadline
+	if settings.SLA.Enabled && settings.SLA.AutoCloseHours > 0 {
+		transfer.SLA.ExpiresAt = deadlineAfter(time.Duration(settings.SLA.AutoCloseHours) * time.Hour)
 	}
 
 	a.Log.Debug("SLA deadlines set",
 		"transfer_id", transfer.ID,
+		"sla_policy_id", transfer.SLA.PolicyID,
 		"response_deadline", transfer.SLA.ResponseDeadline,
 		"escalation_at", transfer.SLA.EscalationAt,
 		"expires_at", transfer.SLA.ExpiresAt,
 	)
 }
 
+// slaDeadline returns the time d after from, counting only working time when
+// there is a business calendar
+func slaDeadline(cal *businessCalendar, from time.Time, d time.Duration) *time.Time {
+	deadline := from.Add(d)
+	if cal != nil {
+		deadline = cal.addWorkingTime(from, d)
+	}
+	return &deadline
+}
+
 // UpdateSLAOnPickup updates SLA tracking when a transfer is picked up
 func (a *App) UpdateSLAOnPickup(transfer *models.AgentTransfer) {
 	now := time.Now()
@@ -404,6 +584,56 @@ func (a *App) UpdateSLAOnFirstResponse(transfer *models.AgentTransfer) {
 	transfer.SLA.FirstResponseAt = &now
 }
 
+// UpdateSLAOnAgentReply records an agent's reply on the contact's active
+// transfer: the first response, and whether a due next response was on time
+func (a *App) UpdateSLAOnAgentReply(orgID, contactID uuid.UUID) {
+	var transfer models.AgentTransfer
+	if err := a.DB.Where("organization_id = ? AND contact_id = ? AND status = ?", orgID, contactID, models.TransferStatusActive).
+		First(&transfer).Error; err != nil {
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if transfer.SLA.FirstResponseAt == nil {
+		a.UpdateSLAOnFirstResponse(&transfer)
+		updates["first_response_at"] = transfer.SLA.FirstResponseAt
+	}
+	if transfer.SLA.NextResponseDeadline != nil {
+		updates["sla_next_responses"] = gorm.Expr("sla_next_responses + 1")
+		if time.Now().After(*transfer.SLA.NextResponseDeadline) {
+			updates["sla_next_response_breaches"] = gorm.Expr("sla_next_response_breaches + 1")
+		}
+		updates["sla_next_response_deadline"] = nil
+	}
+	if len(updates) == 0 {
+		return
+	}
+
+	if err := a.DB.Model(&transfer).Updates(updates).Error; err != nil {
+		a.Log.Error("Failed to update SLA on agent reply", "error", err, "transfer_id", transfer.ID)
+	}
+}
+
+// UpdateSLAOnCustomerMessage starts the next response clock when a customer
+// writes after the agent's first response. The clock keeps running from the
+// first unanswered message.
+func (a *App) UpdateSLAOnCustomerMessage(orgID, contactID uuid.UUID) {
+	var transfer models.AgentTransfer
+	if err := a.DB.Where("organization_id = ? AND contact_id = ? AND status = ?", orgID, contactID, models.TransferStatusActive).
+		First(&transfer).Error; err != nil {
+		return
+	}
+	if transfer.SLA.NextResponseMinutes <= 0 || transfer.SLA.FirstResponseAt == nil || transfer.SLA.NextResponseDeadline != nil {
+		return
+	}
+
+	settings, _ := a.getChatbotSettingsCached(orgID, transfer.WhatsAppAccount)
+	deadline := slaDeadline(a.businessCalendar(settings), time.Now(), time.Duration(transfer.SLA.NextResponseMinutes)*time.Minute)
+	if err := a.DB.Model(&transfer).Update("sla_next_response_deadline", deadline).Error; err != nil {
+		a.Log.Error("Failed to set next response deadline", "error", err, "transfer_id", transfer.ID)
+	}
+}
+
 // processClientInactivity handles client inactivity reminders and auto-close for chatbot conversations only
 func (p *SLAProcessor) processClientInactivity(orgID uuid.UUID, settings models.ChatbotSettings, now time.Time) {
 	// Find contacts where chatbot has sent a message and is waiting for client response
@@ -449,6 +679,19 @@ func (p *SLAProcessor) sendChatbotReminder(contact models.Contact, settings mode
 	if settings.ClientInactivity.ReminderMessage == "" {
 		return
 	}
+	message := localizeChatbotSettings(&settings, contactLanguage(&settings, &contact)).ClientInactivity.ReminderMessage
+
+	// Mark the reminder as sent first, so only one replica sends it
+	result := p.app.DB.Model(&models.Contact{}).
+		Where("id = ? AND chatbot_reminder_sent = ?", contact.ID, false).
+		Update("chatbot_reminder_sent", true)
+	if result.Error != nil {
+		p.app.Log.Error("Failed to update chatbot_reminder_sent", "error", result.Error, "contact_id", contact.ID)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
 
 	// Get WhatsApp account
 	var account models.WhatsAppAccount
@@ -465,7 +708,7 @@ func (p *SLAProcessor) sendChatbotReminder(contact models.Contact, settings mode
 		Account: &account,
 		Contact: &contact,
 		Type:    models.MessageTypeText,
-		Content: settings.ClientInactivity.ReminderMessage,
+		Content: message,
 	}, SLASendOptions())
 
 	if err != nil {
@@ -473,11 +716,6 @@ func (p *SLAProcessor) sendChatbotReminder(contact models.Contact, settings mode
 		return
 	}
 
-	// Mark reminder as sent
-	if err := p.app.DB.Model(&contact).Update("chatbot_reminder_sent", true).Error; err != nil {
-		p.app.Log.Error("Failed to update chatbot_reminder_sent", "error", err, "contact_id", contact.ID)
-	}
-
 	p.app.Log.Info("Chatbot reminder sent",
 		"contact_id", contact.ID,
 		"phone", contact.PhoneNumber,
@@ -487,10 +725,26 @@ func (p *SLAProcessor) sendChatbotReminder(contact models.Contact, settings mode
 
 // autoCloseChatbotSession closes a chatbot session due to client inactivity
 func (p *SLAProcessor) autoCloseChatbotSession(contact models.Contact, settings models.ChatbotSettings) {
-	// Save the timestamp before clearing for logging
-	var inactiveSince time.Time
-	if contact.ChatbotLastMessageAt != nil {
-		inactiveSince = *contact.ChatbotLastMessageAt
+	if contact.ChatbotLastMessageAt == nil {
+		return
+	}
+	inactiveSince := *contact.ChatbotLastMessageAt
+
+	// Clear chatbot tracking fields to close the session. Matching the
+	// timestamp that was loaded makes this a no-op when another replica
+	// closed it or the chatbot has written since.
+	result := p.app.DB.Model(&models.Contact{}).
+		Where("id = ? AND chatbot_last_message_at = ?", contact.ID, inactiveSince).
+		Updates(map[string]any{
+			"chatbot_last_message_at": nil,
+			"chatbot_reminder_sent":   false,
+		})
+	if result.Error != nil {
+		p.app.Log.Error("Failed to close chatbot session for client inactivity", "error", result.Error, "contact_id", contact.ID)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
 	}
 
 	// Send auto-close message if configured
@@ -504,7 +758,7 @@ func (p *SLAProcessor) autoCloseChatbotSession(contact models.Contact, settings
 				Account: &account,
 				Contact: &contact,
 				Type:    models.MessageTypeText,
-				Content: settings.ClientInactivity.AutoCloseMessage,
+				Content: localizeChatbotSettings(&settings, contactLanguage(&settings, &contact)).ClientInactivity.AutoCloseMessage,
 			}, SLASendOptions())
 
 			if err != nil {
@@ -513,15 +767,6 @@ func (p *SLAProcessor) autoCloseChatbotSession(contact models.Contact, settings
 		}
 	}
 
-	// Clear chatbot tracking fields to close the session
-	if err := p.app.DB.Model(&contact).Updates(map[string]any{
-		"chatbot_last_message_at": nil,
-		"chatbot_reminder_sent":   false,
-	}).Error; err != nil {
-		p.app.Log.Error("Failed to close chatbot session for client inactivity", "error", err, "contact_id", contact.ID)
-		return
-	}
-
 	p.app.Log.Info("Chatbot session closed due to client inactivity",
 		"contact_id", contact.ID,
 		"phone", contact.PhoneNumber,
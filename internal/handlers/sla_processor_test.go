@@ -97,6 +97,72 @@ func TestSetSLADeadlines_PartialConfig(t *testing.T) {
 	assert.Nil(t, transfer.SLA.ExpiresAt)
 }
 
+func TestSetSLADeadlines_MatchingPolicy(t *testing.T) {
+	t.Parallel()
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+	require.NoError(t, app.DB.Model(contact).Update("tags", models.JSONBArray{"vip"}).Error)
+
+	policy := models.SLAPolicy{
+		OrganizationID:       org.ID,
+		Name:                 "VIP",
+		IsActive:             true,
+		Tags:                 models.StringArray{"vip"},
+		FirstResponseMinutes: 2,
+		NextResponseMinutes:  5,
+		EscalationLevels:     models.JSONBArray{map[string]interface{}{"after_minutes": float64(3)}},
+	}
+	require.NoError(t, app.DB.Create(&policy).Error)
+
+	transfer := &models.AgentTransfer{OrganizationID: org.ID, ContactID: contact.ID}
+	settings := &models.ChatbotSettings{
+		SLA: models.SLAConfig{Enabled: true, ResponseMinutes: 15, ResolutionMinutes: 60},
+	}
+
+	before := time.Now()
+	app.SetSLADeadlines(transfer, settings)
+
+	require.NotNil(t, transfer.SLA.PolicyID)
+	assert.Equal(t, policy.ID, *transfer.SLA.PolicyID)
+	assert.Equal(t, 5, transfer.SLA.NextResponseMinutes)
+	require.NotNil(t, transfer.SLA.ResponseDeadline)
+	assert.True(t, transfer.SLA.ResponseDeadline.Before(before.Add(3*time.Minute)), "policy target replaces the settings")
+	assert.Nil(t, transfer.SLA.ResolutionDeadline, "policy has no resolution target")
+	require.NotNil(t, transfer.SLA.EscalationAt)
+	assert.True(t, transfer.SLA.EscalationAt.Before(before.Add(4*time.Minute)))
+}
+
+func TestSetSLADeadlines_PolicyWithSLADisabled(t *testing.T) {
+	t.Parallel()
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	inactive := models.SLAPolicy{OrganizationID: org.ID, Name: "Paused", Priority: 10, IsActive: false, FirstResponseMinutes: 1}
+	require.NoError(t, app.DB.Create(&inactive).Error)
+	var stored models.SLAPolicy
+	require.NoError(t, app.DB.First(&stored, "id = ?", inactive.ID).Error)
+	assert.False(t, stored.IsActive, "policies can be created inactive")
+
+	policy := models.SLAPolicy{OrganizationID: org.ID, Name: "Everyone", IsActive: true, FirstResponseMinutes: 30}
+	require.NoError(t, app.DB.Create(&policy).Error)
+
+	transfer := &models.AgentTransfer{OrganizationID: org.ID, ContactID: contact.ID}
+	settings := &models.ChatbotSettings{
+		SLA: models.SLAConfig{Enabled: false, ResponseMinutes: 10, AutoCloseHours: 24},
+	}
+
+	before := time.Now()
+	app.SetSLADeadlines(transfer, settings)
+
+	require.NotNil(t, transfer.SLA.PolicyID)
+	assert.Equal(t, policy.ID, *transfer.SLA.PolicyID)
+	require.NotNil(t, transfer.SLA.ResponseDeadline)
+	assert.True(t, transfer.SLA.ResponseDeadline.After(before.Add(29*time.Minute)))
+	assert.Nil(t, transfer.SLA.ExpiresAt, "auto-close follows the settings")
+}
+
 // --- UpdateSLAOnPickup ---
 
 func TestUpdateSLAOnPickup_WithinDeadline(t *testing.T) {
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +15,7 @@ type TeamRequest struct {
 	Name               string                   `json:"name" validate:"required"`
 	Description        string                   `json:"description"`
 	AssignmentStrategy models.AssignmentStrategy `json:"assignment_strategy"` // round_robin, load_balanced, manual
+	OverflowTeamID     *string                  `json:"overflow_team_id"`    // Empty string clears it
 	IsActive           bool                     `json:"is_active"`
 }
 
@@ -29,6 +31,7 @@ type TeamResponse struct {
 	Name               string                    `json:"name"`
 	Description        string                    `json:"description"`
 	AssignmentStrategy models.AssignmentStrategy `json:"assignment_strategy"`
+	OverflowTeamID     *uuid.UUID                `json:"overflow_team_id,omitempty"`
 	IsActive           bool                      `json:"is_active"`
 	MemberCount        int                       `json:"member_count"`
 	Members            []TeamMemberResponse      `json:"members,omitempty"`
@@ -166,6 +169,7 @@ func (a *App) CreateTeam(r *fastglue.Request) error {
 	}
 
 	team := models.Team{
+		BaseModel:          models.BaseModel{ID: uuid.New()},
 		OrganizationID:     orgID,
 		Name:               req.Name,
 		Description:        req.Description,
@@ -173,6 +177,14 @@ func (a *App) CreateTeam(r *fastglue.Request) error {
 		IsActive:           true,
 	}
 
+	if req.OverflowTeamID != nil {
+		overflowTeamID, err := a.resolveOverflowTeam(orgID, team.ID, *req.OverflowTeamID)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid overflow team: "+err.Error(), nil, "")
+		}
+		team.OverflowTeamID = overflowTeamID
+	}
+
 	if err := a.DB.Create(&team).Error; err != nil {
 		a.Log.Error("Failed to create team", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create team", nil, "")
@@ -232,6 +244,14 @@ func (a *App) UpdateTeam(r *fastglue.Request) error {
 		team.AssignmentStrategy = req.AssignmentStrategy
 	}
 
+	if req.OverflowTeamID != nil {
+		overflowTeamID, err := a.resolveOverflowTeam(orgID, team.ID, *req.OverflowTeamID)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid overflow team: "+err.Error(), nil, "")
+		}
+		team.OverflowTeamID = overflowTeamID
+	}
+
 	if err := a.DB.Save(&team).Error; err != nil {
 		a.Log.Error("Failed to update team", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update team", nil, "")
@@ -273,6 +293,11 @@ func (a *App) DeleteTeam(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Team not found", nil, "")
 	}
 
+	// Teams overflowing to the deleted team no longer overflow
+	a.DB.Model(&models.Team{}).
+		Where("organization_id = ? AND overflow_team_id = ?", orgID, teamID).
+		Update("overflow_team_id", nil)
+
 	return r.SendEnvelope(map[string]string{"message": "Team deleted"})
 }
 
@@ -478,6 +503,41 @@ func (a *App) RemoveTeamMember(r *fastglue.Request) error {
 	return r.SendEnvelope(map[string]string{"message": "Member removed from team"})
 }
 
+// resolveOverflowTeam validates the overflow team of a team. The overflow
+// team must be another active team of the organization whose own overflow
+// chain doesn't lead back. An empty value clears the overflow team.
+func (a *App) resolveOverflowTeam(orgID, teamID uuid.UUID, value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	overflowID, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid team id")
+	}
+	if overflowID == teamID {
+		return nil, fmt.Errorf("a team can't overflow to itself")
+	}
+
+	next := &overflowID
+	for hops := 0; next != nil && hops <= maxOverflowHops; hops++ {
+		var t models.Team
+		if err := a.DB.Where("id = ? AND organization_id = ?", *next, orgID).First(&t).Error; err != nil {
+			if hops == 0 {
+				return nil, fmt.Errorf("team not found")
+			}
+			break
+		}
+		if hops == 0 && !t.IsActive {
+			return nil, fmt.Errorf("team is inactive")
+		}
+		if t.OverflowTeamID != nil && *t.OverflowTeamID == teamID {
+			return nil, fmt.Errorf("overflow teams can't form a loop")
+		}
+		next = t.OverflowTeamID
+	}
+	return &overflowID, nil
+}
+
 // Helper function to build team response
 func buildTeamResponse(team *models.Team, includeMembers bool) TeamResponse {
 	resp := TeamResponse{
@@ -485,6 +545,7 @@ func buildTeamResponse(team *models.Team, includeMembers bool) TeamResponse {
 		Name:               team.Name,
 		Description:        team.Description,
 		AssignmentStrategy: team.AssignmentStrategy,
+		OverflowTeamID:     team.OverflowTeamID,
 		IsActive:           team.IsActive,
 		MemberCount:        len(team.Members),
 		CreatedAt:          team.CreatedAt,
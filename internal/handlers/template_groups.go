@@ -0,0 +1,573 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// Template clone outcomes
+const (
+	cloneStatusCreated   = "created"   // Draft created on the account
+	cloneStatusSubmitted = "submitted" // Draft created and submitted to Meta
+	cloneStatusLinked    = "linked"    // The account already had the template; it joined the group
+	cloneStatusSkipped   = "skipped"
+)
+
+// TemplateGroupRequest represents the request body for creating/updating a template group
+type TemplateGroupRequest struct {
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	Category        string    `json:"category"`
+	DefaultLanguage string    `json:"default_language"` // Sent when no variant matches the contact's language
+	TemplateIDs     *[]string `json:"template_ids"`     // Templates in the group; replaces the current ones on update
+}
+
+// TemplateGroupResponse represents a template group and its variants
+type TemplateGroupResponse struct {
+	ID               uuid.UUID          `json:"id"`
+	Name             string             `json:"name"`
+	Description      string             `json:"description"`
+	Category         string             `json:"category"`
+	DefaultLanguage  string             `json:"default_language"`
+	Languages        []string           `json:"languages"`
+	WhatsAppAccounts []string           `json:"whatsapp_accounts"`
+	TemplateCount    int                `json:"template_count"`
+	Templates        []TemplateResponse `json:"templates,omitempty"`
+	CreatedAt        string             `json:"created_at"`
+	UpdatedAt        string             `json:"updated_at"`
+}
+
+// CloneTemplateRequest represents the request body for cloning templates to other accounts
+type CloneTemplateRequest struct {
+	WhatsAppAccounts []string `json:"whatsapp_accounts"` // Target account names
+	Submit           bool     `json:"submit"`            // Submit the clones to Meta for approval
+}
+
+// TemplateCloneResult is the outcome of cloning one template to one account
+type TemplateCloneResult struct {
+	WhatsAppAccount string     `json:"whatsapp_account"`
+	Language        string     `json:"language"`
+	TemplateID      *uuid.UUID `json:"template_id,omitempty"`
+	Status          string     `json:"status"` // created, submitted, linked, skipped
+	Error           string     `json:"error,omitempty"`
+}
+
+// ListTemplateGroups returns the organization's template groups
+func (a *App) ListTemplateGroups(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	pg := parsePagination(r)
+	query := a.DB.Where("organization_id = ?", orgID)
+	if search := string(r.RequestCtx.QueryArgs().Peek("search")); search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	query.Model(&models.TemplateGroup{}).Count(&total)
+
+	var groups []models.TemplateGroup
+	if err := pg.Apply(query.Preload("Templates").Order("name")).Find(&groups).Error; err != nil {
+		a.Log.Error("Failed to list template groups", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list template groups", nil, "")
+	}
+
+	response := make([]TemplateGroupResponse, len(groups))
+	for i, g := range groups {
+		response[i] = templateGroupToResponse(g, false)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"groups": response,
+		"total":  total,
+		"page":   pg.Page,
+		"limit":  pg.Limit,
+	})
+}
+
+// CreateTemplateGroup creates a template group, optionally with existing templates
+func (a *App) CreateTemplateGroup(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	var req TemplateGroupRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	var templates []models.Template
+	if req.TemplateIDs != nil {
+		if templates, err = a.findGroupTemplates(orgID, *req.TemplateIDs); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+	}
+
+	// Default the group's details from its first template
+	if len(templates) > 0 {
+		if req.Name == "" {
+			req.Name = templates[0].Name
+		}
+		if req.Category == "" {
+			req.Category = templates[0].Category
+		}
+		if req.DefaultLanguage == "" {
+			req.DefaultLanguage = templates[0].Language
+		}
+	}
+	if req.Name == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "name is required", nil, "")
+	}
+
+	group := models.TemplateGroup{
+		OrganizationID:  orgID,
+		Name:            req.Name,
+		Description:     req.Description,
+		Category:        strings.ToUpper(req.Category),
+		DefaultLanguage: req.DefaultLanguage,
+	}
+
+	if err := a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&group).Error; err != nil {
+			return err
+		}
+		return setGroupTemplates(tx, orgID, group.ID, templates)
+	}); err != nil {
+		a.Log.Error("Failed to create template group", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create template group", nil, "")
+	}
+
+	return a.sendTemplateGroup(r, orgID, group.ID)
+}
+
+// GetTemplateGroup returns a template group with its templates
+func (a *App) GetTemplateGroup(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "template group")
+	if err != nil {
+		return nil
+	}
+
+	return a.sendTemplateGroup(r, orgID, id)
+}
+
+// UpdateTemplateGroup updates a template group
+func (a *App) UpdateTemplateGroup(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "template group")
+	if err != nil {
+		return nil
+	}
+
+	group, err := findByIDAndOrg[models.TemplateGroup](a.DB, r, id, orgID, "Template group")
+	if err != nil {
+		return nil
+	}
+
+	var req TemplateGroupRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if req.Name != "" {
+		group.Name = req.Name
+	}
+	group.Description = req.Description
+	if req.Category != "" {
+		group.Category = strings.ToUpper(req.Category)
+	}
+	if req.DefaultLanguage != "" {
+		group.DefaultLanguage = req.DefaultLanguage
+	}
+
+	var templates []models.Template
+	if req.TemplateIDs != nil {
+		if templates, err = a.findGroupTemplates(orgID, *req.TemplateIDs); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+	}
+
+	if err := a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(group).Error; err != nil {
+			return err
+		}
+		if req.TemplateIDs == nil {
+			return nil
+		}
+		return setGroupTemplates(tx, orgID, group.ID, templates)
+	}); err != nil {
+		a.Log.Error("Failed to update template group", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update template group", nil, "")
+	}
+
+	return a.sendTemplateGroup(r, orgID, group.ID)
+}
+
+// DeleteTemplateGroup deletes a template group. Its templates are kept and leave the group.
+func (a *App) DeleteTemplateGroup(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "template group")
+	if err != nil {
+		return nil
+	}
+
+	group, err := findByIDAndOrg[models.TemplateGroup](a.DB, r, id, orgID, "Template group")
+	if err != nil {
+		return nil
+	}
+
+	if err := a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := setGroupTemplates(tx, orgID, group.ID, nil); err != nil {
+			return err
+		}
+		return tx.Delete(group).Error
+	}); err != nil {
+		a.Log.Error("Failed to delete template group", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete template group", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]string{"message": "Template group deleted successfully"})
+}
+
+// CloneTemplate copies a template to other WhatsApp accounts. The source and its
+// clones are kept together in a template group, which is created when needed.
+func (a *App) CloneTemplate(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "template")
+	if err != nil {
+		return nil
+	}
+
+	template, err := findByIDAndOrg[models.Template](a.DB, r, id, orgID, "Template")
+	if err != nil {
+		return nil
+	}
+
+	var req CloneTemplateRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	accounts, err := a.cloneTargetAccounts(orgID, req.WhatsAppAccounts)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	if template.TemplateGroupID == nil {
+		group := models.TemplateGroup{
+			OrganizationID:  orgID,
+			Name:            template.Name,
+			Category:        template.Category,
+			DefaultLanguage: template.Language,
+		}
+		if err := a.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&group).Error; err != nil {
+				return err
+			}
+			return tx.Model(template).Update("template_group_id", group.ID).Error
+		}); err != nil {
+			a.Log.Error("Failed to create template group", "error", err)
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create template group", nil, "")
+		}
+		template.TemplateGroupID = &group.ID
+	}
+
+	results := make([]TemplateCloneResult, 0, len(accounts))
+	for i := range accounts {
+		results = append(results, a.cloneTemplateToAccount(template, &accounts[i], req.Submit))
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"template_group_id": template.TemplateGroupID,
+		"results":           results,
+	})
+}
+
+// CloneTemplateGroup copies every language of a template group to other WhatsApp accounts
+func (a *App) CloneTemplateGroup(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "template group")
+	if err != nil {
+		return nil
+	}
+
+	group, err := findByIDAndOrg[models.TemplateGroup](a.DB, r, id, orgID, "Template group")
+	if err != nil {
+		return nil
+	}
+
+	var req CloneTemplateRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	accounts, err := a.cloneTargetAccounts(orgID, req.WhatsAppAccounts)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	var templates []models.Template
+	if err := a.DB.Where("organization_id = ? AND template_group_id = ?", orgID, group.ID).
+		Order("created_at").Find(&templates).Error; err != nil {
+		a.Log.Error("Failed to load group templates", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load group templates", nil, "")
+	}
+	sources := groupCloneSources(templates)
+	if len(sources) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Template group has no templates", nil, "")
+	}
+
+	results := make([]TemplateCloneResult, 0, len(accounts)*len(sources))
+	for i := range accounts {
+		for j := range sources {
+			results = append(results, a.cloneTemplateToAccount(&sources[j], &accounts[i], req.Submit))
+		}
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"template_group_id": group.ID,
+		"results":           results,
+	})
+}
+
+// cloneTemplateToAccount copies source to account as a draft in source's group, and
+// submits it to Meta when asked. Failures are reported in the result rather than returned.
+func (a *App) cloneTemplateToAccount(source *models.Template, account *models.WhatsAppAccount, submit bool) TemplateCloneResult {
+	result := TemplateCloneResult{WhatsAppAccount: account.Name, Language: source.Language}
+
+	var existing models.Template
+	if err := a.DB.Where("organization_id = ? AND whats_app_account = ? AND name = ? AND language = ?",
+		source.OrganizationID, account.Name, source.Name, source.Language).First(&existing).Error; err == nil {
+		result.TemplateID = &existing.ID
+		if existing.TemplateGroupID == nil && source.TemplateGroupID != nil {
+			a.DB.Model(&existing).Update("template_group_id", *source.TemplateGroupID)
+			result.Status = cloneStatusLinked
+			return result
+		}
+		result.Status = cloneStatusSkipped
+		result.Error = "A template with this name and language already exists on the account"
+		return result
+	}
+
+	clone := *source
+	clone.BaseModel = models.BaseModel{}
+	clone.WhatsAppAccount = account.Name
+	clone.MetaTemplateID = ""
+	clone.Status = "DRAFT"
+	clone.QualityScore = ""
+	clone.PreviousCategory = ""
+	clone.RejectionReason = ""
+	clone.StatusUpdatedAt = nil
+	clone.LastSyncedAt = nil
+	clone.Organization = nil
+
+	if err := a.DB.Create(&clone).Error; err != nil {
+		a.Log.Error("Failed to clone template", "error", err, "template", source.Name, "account", account.Name)
+		result.Status = cloneStatusSkipped
+		result.Error = "Failed to create template"
+		return result
+	}
+	result.TemplateID = &clone.ID
+	result.Status = cloneStatusCreated
+
+	if !submit {
+		return result
+	}
+	if issues := templateutil.Lint(&clone); templateutil.HasLintErrors(issues) {
+		result.Error = "Template has issues that Meta would reject; fix them and publish the draft"
+		return result
+	}
+	a.decryptAccountSecrets(account)
+	metaTemplateID, err := a.submitTemplateToMeta(account, &clone)
+	if err != nil {
+		a.Log.Error("Failed to submit cloned template to Meta", "error", err, "template", clone.Name, "account", account.Name)
+		result.Error = "Failed to submit template to Meta: " + err.Error()
+		return result
+	}
+	a.DB.Model(&clone).Updates(map[string]any{
+		"meta_template_id": metaTemplateID,
+		"status":           "PENDING",
+	})
+	result.Status = cloneStatusSubmitted
+	return result
+}
+
+// cloneTargetAccounts loads the named accounts of the organization
+func (a *App) cloneTargetAccounts(orgID uuid.UUID, names []string) ([]models.WhatsAppAccount, error) {
+	if len(names) == 0 {
+		return nil, errors.New("whatsapp_accounts is required")
+	}
+	var accounts []models.WhatsAppAccount
+	if err := a.DB.Where("organization_id = ? AND name IN ?", orgID, names).Find(&accounts).Error; err != nil {
+		return nil, errors.New("failed to load WhatsApp accounts")
+	}
+	for _, name := range names {
+		found := false
+		for _, acc := range accounts {
+			if acc.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("WhatsApp account %q not found", name)
+		}
+	}
+	return accounts, nil
+}
+
+// findGroupTemplates loads the templates with the given IDs, which must all belong to the organization
+func (a *App) findGroupTemplates(orgID uuid.UUID, ids []string) ([]models.Template, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	uuids := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template ID %q", id)
+		}
+		uuids = append(uuids, parsed)
+	}
+	var templates []models.Template
+	if err := a.DB.Where("organization_id = ? AND id IN ?", orgID, uuids).Order("created_at").Find(&templates).Error; err != nil {
+		return nil, errors.New("failed to load templates")
+	}
+	if len(templates) != len(uuids) {
+		return nil, errors.New("one or more templates not found")
+	}
+	return templates, nil
+}
+
+// templateGroupID validates a template group ID from a template request. An empty ID means no group.
+func (a *App) templateGroupID(orgID uuid.UUID, id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	groupID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid template_group_id")
+	}
+	var count int64
+	a.DB.Model(&models.TemplateGroup{}).Where("id = ? AND organization_id = ?", groupID, orgID).Count(&count)
+	if count == 0 {
+		return nil, errors.New("template group not found")
+	}
+	return &groupID, nil
+}
+
+// setGroupTemplates makes templates the group's only members
+func setGroupTemplates(tx *gorm.DB, orgID, groupID uuid.UUID, templates []models.Template) error {
+	if err := tx.Model(&models.Template{}).
+		Where("organization_id = ? AND template_group_id = ?", orgID, groupID).
+		Update("template_group_id", nil).Error; err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(templates))
+	for i, t := range templates {
+		ids[i] = t.ID
+	}
+	return tx.Model(&models.Template{}).
+		Where("organization_id = ? AND id IN ?", orgID, ids).
+		Update("template_group_id", groupID).Error
+}
+
+// sendTemplateGroup responds with a template group and its templates
+func (a *App) sendTemplateGroup(r *fastglue.Request, orgID, id uuid.UUID) error {
+	var group models.TemplateGroup
+	if err := a.DB.Where("id = ? AND organization_id = ?", id, orgID).
+		Preload("Templates", func(db *gorm.DB) *gorm.DB { return db.Order("whats_app_account, language") }).
+		First(&group).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Template group not found", nil, "")
+	}
+	return r.SendEnvelope(templateGroupToResponse(group, true))
+}
+
+// groupCloneSources picks one template per language to clone, preferring approved ones
+func groupCloneSources(templates []models.Template) []models.Template {
+	byLanguage := map[string]int{}
+	var sources []models.Template
+	for _, t := range templates {
+		i, ok := byLanguage[t.Language]
+		if !ok {
+			byLanguage[t.Language] = len(sources)
+			sources = append(sources, t)
+			continue
+		}
+		if sources[i].Status != "APPROVED" && t.Status == "APPROVED" {
+			sources[i] = t
+		}
+	}
+	return sources
+}
+
+func templateGroupToResponse(g models.TemplateGroup, withTemplates bool) TemplateGroupResponse {
+	languages := map[string]bool{}
+	accounts := map[string]bool{}
+	for _, t := range g.Templates {
+		languages[t.Language] = true
+		accounts[t.WhatsAppAccount] = true
+	}
+
+	resp := TemplateGroupResponse{
+		ID:               g.ID,
+		Name:             g.Name,
+		Description:      g.Description,
+		Category:         g.Category,
+		DefaultLanguage:  g.DefaultLanguage,
+		Languages:        sortedKeys(languages),
+		WhatsAppAccounts: sortedKeys(accounts),
+		TemplateCount:    len(g.Templates),
+		CreatedAt:        g.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:        g.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if withTemplates {
+		resp.Templates = make([]TemplateResponse, len(g.Templates))
+		for i, t := range g.Templates {
+			resp.Templates[i] = templateToResponse(t)
+		}
+	}
+	return resp
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,250 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func createTestTemplateVariant(t *testing.T, app *handlers.App, orgID uuid.UUID, accountName, language string, groupID *uuid.UUID) *models.Template {
+	t.Helper()
+
+	tmpl := &models.Template{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  orgID,
+		WhatsAppAccount: accountName,
+		Name:            "order_update",
+		DisplayName:     "Order update",
+		Language:        language,
+		Category:        "UTILITY",
+		Status:          "APPROVED",
+		BodyContent:     "Order {{1}} has shipped (" + language + ")",
+		SampleValues:    models.JSONBArray{map[string]interface{}{"component": "body", "index": float64(1), "value": "1234"}},
+		TemplateGroupID: groupID,
+	}
+	require.NoError(t, app.DB.Create(tmpl).Error)
+	return tmpl
+}
+
+func createTestTemplateGroup(t *testing.T, app *handlers.App, orgID uuid.UUID, defaultLanguage string) *models.TemplateGroup {
+	t.Helper()
+
+	group := &models.TemplateGroup{
+		OrganizationID:  orgID,
+		Name:            "order_update",
+		Category:        "UTILITY",
+		DefaultLanguage: defaultLanguage,
+	}
+	require.NoError(t, app.DB.Create(group).Error)
+	return group
+}
+
+func TestApp_CreateTemplateGroup_WithTemplates(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	en := createTestTemplateVariant(t, app, org.ID, account.Name, "en", nil)
+	es := createTestTemplateVariant(t, app, org.ID, account.Name, "es", nil)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"template_ids": []string{en.ID.String(), es.ID.String()},
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+
+	require.NoError(t, app.CreateTemplateGroup(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.TemplateGroupResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, "order_update", resp.Data.Name)
+	assert.Equal(t, "UTILITY", resp.Data.Category)
+	assert.Equal(t, "en", resp.Data.DefaultLanguage)
+	assert.Equal(t, []string{"en", "es"}, resp.Data.Languages)
+	assert.Len(t, resp.Data.Templates, 2)
+
+	var stored models.Template
+	require.NoError(t, app.DB.First(&stored, es.ID).Error)
+	require.NotNil(t, stored.TemplateGroupID)
+	assert.Equal(t, resp.Data.ID, *stored.TemplateGroupID)
+}
+
+func TestApp_CreateTemplateGroup_TemplateFromAnotherOrg(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	otherOrg := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	otherAccount := testutil.CreateTestWhatsAppAccount(t, app.DB, otherOrg.ID)
+	other := createTestTemplateVariant(t, app, otherOrg.ID, otherAccount.Name, "en", nil)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"name":         "order_update",
+		"template_ids": []string{other.ID.String()},
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+
+	require.NoError(t, app.CreateTemplateGroup(req))
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "not found")
+}
+
+func TestApp_DeleteTemplateGroup_KeepsTemplates(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	group := createTestTemplateGroup(t, app, org.ID, "en")
+	tmpl := createTestTemplateVariant(t, app, org.ID, account.Name, "en", &group.ID)
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", group.ID.String())
+
+	require.NoError(t, app.DeleteTemplateGroup(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var stored models.Template
+	require.NoError(t, app.DB.First(&stored, tmpl.ID).Error)
+	assert.Nil(t, stored.TemplateGroupID)
+}
+
+func TestApp_CloneTemplate_SubmitsToOtherAccounts(t *testing.T) {
+	t.Parallel()
+
+	server := newMockTemplateServer(t)
+	defer server.Close()
+	app := newTemplateTestApp(t, server)
+
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	source := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	target := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	tmpl := createTestTemplateVariant(t, app, org.ID, source.Name, "en", nil)
+
+	clone := func() []handlers.TemplateCloneResult {
+		req := testutil.NewJSONRequest(t, map[string]interface{}{
+			"whatsapp_accounts": []string{target.Name},
+			"submit":            true,
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", tmpl.ID.String())
+		require.NoError(t, app.CloneTemplate(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data struct {
+				Results []handlers.TemplateCloneResult `json:"results"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		require.Len(t, resp.Data.Results, 1)
+		return resp.Data.Results
+	}
+
+	results := clone()
+	assert.Equal(t, "submitted", results[0].Status)
+	assert.Empty(t, results[0].Error)
+
+	var sourceTemplate, cloned models.Template
+	require.NoError(t, app.DB.First(&sourceTemplate, tmpl.ID).Error)
+	require.NotNil(t, sourceTemplate.TemplateGroupID, "the source joins a new group")
+	require.NoError(t, app.DB.First(&cloned, *results[0].TemplateID).Error)
+	assert.Equal(t, target.Name, cloned.WhatsAppAccount)
+	assert.Equal(t, "PENDING", cloned.Status)
+	assert.NotEmpty(t, cloned.MetaTemplateID)
+	assert.Equal(t, tmpl.BodyContent, cloned.BodyContent)
+	assert.Equal(t, sourceTemplate.TemplateGroupID, cloned.TemplateGroupID)
+
+	// Cloning again doesn't duplicate the template
+	results = clone()
+	assert.Equal(t, "skipped", results[0].Status)
+}
+
+func TestApp_CloneTemplateGroup_AllLanguages(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	source := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	target := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	group := createTestTemplateGroup(t, app, org.ID, "en")
+	createTestTemplateVariant(t, app, org.ID, source.Name, "en", &group.ID)
+	createTestTemplateVariant(t, app, org.ID, source.Name, "es", &group.ID)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"whatsapp_accounts": []string{target.Name},
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", group.ID.String())
+
+	require.NoError(t, app.CloneTemplateGroup(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var clones []models.Template
+	require.NoError(t, app.DB.Where("whats_app_account = ?", target.Name).Order("language").Find(&clones).Error)
+	require.Len(t, clones, 2)
+	assert.Equal(t, "DRAFT", clones[0].Status)
+	assert.Equal(t, "es", clones[1].Language)
+	assert.Equal(t, group.ID, *clones[1].TemplateGroupID)
+}
+
+func TestApp_CloneTemplate_UnknownAccount(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	tmpl := createTestTemplateVariant(t, app, org.ID, account.Name, "en", nil)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"whatsapp_accounts": []string{"missing-account"},
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", tmpl.ID.String())
+
+	require.NoError(t, app.CloneTemplate(req))
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "not found")
+}
+
+func TestLanguageVariant_GroupAndDefaultLanguage(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	group := createTestTemplateGroup(t, app, org.ID, "en")
+	en := createTestTemplateVariant(t, app, org.ID, account.Name, "en", &group.ID)
+	es := createTestTemplateVariant(t, app, org.ID, account.Name, "es", &group.ID)
+	de := createTestTemplateVariant(t, app, org.ID, account.Name, "de", &group.ID)
+
+	variant, err := templateutil.LanguageVariant(app.DB, en, "es_MX")
+	require.NoError(t, err)
+	assert.Equal(t, es.ID, variant.ID)
+
+	// No French variant: the group's default language is used
+	variant, err = templateutil.LanguageVariant(app.DB, de, "fr")
+	require.NoError(t, err)
+	assert.Equal(t, en.ID, variant.ID)
+
+	// Unknown contact language keeps the chosen template
+	variant, err = templateutil.LanguageVariant(app.DB, de, "")
+	require.NoError(t, err)
+	assert.Equal(t, de.ID, variant.ID)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// TemplateLintResponse lists the problems found in a template
+type TemplateLintResponse struct {
+	Valid  bool                     `json:"valid"` // No errors; warnings may remain
+	Issues []templateutil.LintIssue `json:"issues"`
+}
+
+// TemplateRenderRequest holds the parameters for a template preview
+type TemplateRenderRequest struct {
+	Params       map[string]string   `json:"params"`        // Body params, named or positional
+	HeaderParams map[string]string   `json:"header_params"` // Text header params
+	ButtonParams map[string]string   `json:"button_params"` // URL button variables keyed by button position (1-based)
+	CardParams   []map[string]string `json:"card_params"`   // Body params of each carousel card, in card order
+}
+
+// LintTemplate checks a saved template against Meta's submission rules
+func (a *App) LintTemplate(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "template")
+	if err != nil {
+		return nil
+	}
+
+	template, err := findByIDAndOrg[models.Template](a.DB, r, id, orgID, "Template")
+	if err != nil {
+		return nil
+	}
+
+	return r.SendEnvelope(lintResponse(template))
+}
+
+// LintTemplateDraft checks an unsaved template, so the editor can show issues while typing
+func (a *App) LintTemplateDraft(r *fastglue.Request) error {
+	if _, err := a.getOrgID(r); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	var req TemplateRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	template := &models.Template{
+		Name:          normalizeTemplateName(req.Name),
+		Language:      req.Language,
+		Category:      strings.ToUpper(req.Category),
+		HeaderType:    strings.ToUpper(req.HeaderType),
+		HeaderContent: req.HeaderContent,
+		BodyContent:   req.BodyContent,
+		FooterContent: req.FooterContent,
+		Buttons:       convertToJSONBArray(req.Buttons),
+		SampleValues:  convertToJSONBArray(req.SampleValues),
+		Cards:         convertToJSONBArray(req.Cards),
+	}
+	applyTemplateTypeFields(template, &req)
+
+	return r.SendEnvelope(lintResponse(template))
+}
+
+// RenderTemplate returns the final message a template produces with the supplied parameters
+func (a *App) RenderTemplate(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "template")
+	if err != nil {
+		return nil
+	}
+
+	template, err := findByIDAndOrg[models.Template](a.DB, r, id, orgID, "Template")
+	if err != nil {
+		return nil
+	}
+
+	var req TemplateRenderRequest
+	if len(r.RequestCtx.PostBody()) > 0 {
+		if err := a.decodeRequest(r, &req); err != nil {
+			return nil
+		}
+	}
+
+	return r.SendEnvelope(templateutil.Render(template, templateutil.RenderParams{
+		Body:    req.Params,
+		Header:  req.HeaderParams,
+		Buttons: req.ButtonParams,
+		Cards:   req.CardParams,
+	}))
+}
+
+func lintResponse(template *models.Template) TemplateLintResponse {
+	issues := templateutil.Lint(template)
+	if issues == nil {
+		issues = []templateutil.LintIssue{}
+	}
+	return TemplateLintResponse{
+		Valid:  !templateutil.HasLintErrors(issues),
+		Issues: issues,
+	}
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
@@ -25,26 +28,45 @@ type TemplateRequest struct {
 	FooterContent   string        `json:"footer_content"`
 	Buttons         []interface{} `json:"buttons"`
 	SampleValues    []interface{} `json:"sample_values"`
+
+	// Carousel, limited-time-offer and authentication templates
+	Cards                     []interface{}          `json:"cards"`
+	LimitedTimeOffer          map[string]interface{} `json:"limited_time_offer"`
+	AddSecurityRecommendation *bool                  `json:"add_security_recommendation"`
+	CodeExpirationMinutes     *int                   `json:"code_expiration_minutes"`
+
+	TemplateGroupID *string `json:"template_group_id"` // Empty string removes the template from its group
 }
 
 // TemplateResponse represents the response for a template
 type TemplateResponse struct {
-	ID              uuid.UUID     `json:"id"`
-	WhatsAppAccount string        `json:"whatsapp_account"` // WhatsApp account name
-	MetaTemplateID  string        `json:"meta_template_id"`
-	Name            string        `json:"name"`
-	DisplayName     string        `json:"display_name"`
-	Language        string        `json:"language"`
-	Category        string        `json:"category"`
-	Status          string        `json:"status"`
-	HeaderType      string        `json:"header_type"`
-	HeaderContent   string        `json:"header_content"`
-	BodyContent     string        `json:"body_content"`
-	FooterContent   string        `json:"footer_content"`
-	Buttons         []interface{} `json:"buttons"`
-	SampleValues    []interface{} `json:"sample_values"`
-	CreatedAt       string        `json:"created_at"`
-	UpdatedAt       string        `json:"updated_at"`
+	ID               uuid.UUID     `json:"id"`
+	WhatsAppAccount  string        `json:"whatsapp_account"` // WhatsApp account name
+	MetaTemplateID   string        `json:"meta_template_id"`
+	Name             string        `json:"name"`
+	DisplayName      string        `json:"display_name"`
+	Language         string        `json:"language"`
+	Category         string        `json:"category"`
+	Status           string        `json:"status"`
+	HeaderType       string        `json:"header_type"`
+	HeaderContent    string        `json:"header_content"`
+	BodyContent      string        `json:"body_content"`
+	FooterContent    string        `json:"footer_content"`
+	Buttons          []interface{} `json:"buttons"`
+	SampleValues     []interface{} `json:"sample_values"`
+	QualityScore     string        `json:"quality_score,omitempty"`
+	PreviousCategory string        `json:"previous_category,omitempty"`
+	RejectionReason  string        `json:"rejection_reason,omitempty"`
+	CreatedAt        string        `json:"created_at"`
+	UpdatedAt        string        `json:"updated_at"`
+
+	Cards                     []interface{}          `json:"cards"`
+	LimitedTimeOffer          map[string]interface{} `json:"limited_time_offer,omitempty"`
+	AddSecurityRecommendation bool                   `json:"add_security_recommendation"`
+	CodeExpirationMinutes     int                    `json:"code_expiration_minutes"`
+
+	TemplateGroupID *uuid.UUID `json:"template_group_id,omitempty"`
+	LastSyncedAt    string     `json:"last_synced_at,omitempty"`
 }
 
 // ListTemplates returns all templates for the organization
@@ -108,6 +130,12 @@ func (a *App) CreateTemplate(r *fastglue.Request) error {
 		return nil
 	}
 
+	// Authentication templates use Meta's fixed wording, so the body is optional
+	category := strings.ToUpper(req.Category)
+	if category == "AUTHENTICATION" && req.BodyContent == "" {
+		req.BodyContent = templateutil.AuthenticationBody(req.AddSecurityRecommendation != nil && *req.AddSecurityRecommendation)
+	}
+
 	// Validate required fields
 	if req.WhatsAppAccount == "" || req.Name == "" || req.Language == "" || req.Category == "" || req.BodyContent == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "whatsapp_account, name, language, category, and body_content are required", nil, "")
@@ -122,10 +150,17 @@ func (a *App) CreateTemplate(r *fastglue.Request) error {
 	// Normalize template name (lowercase, underscores)
 	templateName := normalizeTemplateName(req.Name)
 
-	// Check if template with same name exists for this account
+	// Language variants share a name, so only the same name and language conflict
 	var existingTemplate models.Template
-	if err := a.DB.Where("organization_id = ? AND whats_app_account = ? AND name = ?", orgID, req.WhatsAppAccount, templateName).First(&existingTemplate).Error; err == nil {
-		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Template with this name already exists", nil, "")
+	if err := a.DB.Where("organization_id = ? AND whats_app_account = ? AND name = ? AND language = ?", orgID, req.WhatsAppAccount, templateName, req.Language).First(&existingTemplate).Error; err == nil {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Template with this name and language already exists", nil, "")
+	}
+
+	var groupID *uuid.UUID
+	if req.TemplateGroupID != nil {
+		if groupID, err = a.templateGroupID(orgID, *req.TemplateGroupID); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
 	}
 
 	displayName := req.DisplayName
@@ -139,7 +174,7 @@ func (a *App) CreateTemplate(r *fastglue.Request) error {
 		Name:            templateName,
 		DisplayName:     displayName,
 		Language:        req.Language,
-		Category:        strings.ToUpper(req.Category),
+		Category:        category,
 		Status:          "DRAFT", // Local draft until submitted to Meta
 		HeaderType:      strings.ToUpper(req.HeaderType),
 		HeaderContent:   req.HeaderContent,
@@ -147,7 +182,10 @@ func (a *App) CreateTemplate(r *fastglue.Request) error {
 		FooterContent:   req.FooterContent,
 		Buttons:         convertToJSONBArray(req.Buttons),
 		SampleValues:    convertToJSONBArray(req.SampleValues),
+		Cards:           convertToJSONBArray(req.Cards),
+		TemplateGroupID: groupID,
 	}
+	applyTemplateTypeFields(&template, &req)
 
 	if err := a.DB.Create(&template).Error; err != nil {
 		a.Log.Error("Failed to create template", "error", err)
@@ -228,6 +266,15 @@ func (a *App) UpdateTemplate(r *fastglue.Request) error {
 	if req.SampleValues != nil {
 		template.SampleValues = convertToJSONBArray(req.SampleValues)
 	}
+	if req.Cards != nil {
+		template.Cards = convertToJSONBArray(req.Cards)
+	}
+	applyTemplateTypeFields(template, &req)
+	if req.TemplateGroupID != nil {
+		if template.TemplateGroupID, err = a.templateGroupID(orgID, *req.TemplateGroupID); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+	}
 
 	if err := a.DB.Save(template).Error; err != nil {
 		a.Log.Error("Failed to update template", "error", err)
@@ -254,12 +301,22 @@ func (a *App) DeleteTemplate(r *fastglue.Request) error {
 		return nil
 	}
 
-	// If template exists on Meta, delete it there too
+	// If template exists on Meta, delete it there too. Deleting by name alone
+	// removes every language, so other languages still in use are kept by
+	// deleting this one by its ID.
 	if template.MetaTemplateID != "" {
 		var account models.WhatsAppAccount
 		if err := a.DB.Where("name = ? AND organization_id = ?", template.WhatsAppAccount, orgID).First(&account).Error; err == nil {
+			var otherLanguages int64
+			a.DB.Model(&models.Template{}).
+				Where("organization_id = ? AND whats_app_account = ? AND name = ? AND id != ?", orgID, template.WhatsAppAccount, template.Name, template.ID).
+				Count(&otherLanguages)
+			templateID := ""
+			if otherLanguages > 0 {
+				templateID = template.MetaTemplateID
+			}
 			// Delete from Meta API
-			go a.deleteTemplateFromMeta(&account, template.Name)
+			go a.deleteTemplateFromMeta(&account, template.Name, templateID)
 		}
 	}
 
@@ -293,6 +350,13 @@ func (a *App) SubmitTemplate(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Template is pending approval and cannot be modified", nil, "")
 	}
 
+	// Catch mistakes locally instead of waiting for Meta to reject the template
+	if issues := templateutil.Lint(template); templateutil.HasLintErrors(issues) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Template has issues that Meta would reject", map[string]any{
+			"issues": issues,
+		}, "")
+	}
+
 	// Get the WhatsApp account
 	var account models.WhatsAppAccount
 	if err := a.DB.Where("name = ? AND organization_id = ?", template.WhatsAppAccount, orgID).First(&account).Error; err != nil {
@@ -346,12 +410,34 @@ func (a *App) submitTemplateToMeta(account *models.WhatsAppAccount, template *mo
 		FooterContent:  template.FooterContent,
 		Buttons:        template.Buttons,
 		SampleValues:   template.SampleValues,
+
+		Cards:                     template.Cards,
+		LimitedTimeOffer:          template.LimitedTimeOffer,
+		AddSecurityRecommendation: template.AddSecurityRecommendation,
+		CodeExpirationMinutes:     template.CodeExpirationMinutes,
 	}
 
 	ctx := context.Background()
 	return a.WhatsApp.SubmitTemplate(ctx, waAccount, submission)
 }
 
+// templateSyncReport summarizes how a sync reconciled local templates with Meta
+type templateSyncReport struct {
+	Created   int
+	Updated   int
+	Edited    []TemplateSyncItem // Changed on Meta since the last sync
+	Conflicts []TemplateSyncItem // Changed on Meta while local edits were pending; local edits kept
+	Deleted   []TemplateSyncItem // No longer on Meta
+}
+
+// TemplateSyncItem identifies a template in a sync report
+type TemplateSyncItem struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Language string    `json:"language"`
+	Fields   []string  `json:"fields,omitempty"` // Content that differs from Meta
+}
+
 // SyncTemplates syncs templates from Meta API
 func (a *App) SyncTemplates(r *fastglue.Request) error {
 	orgID, err := a.getOrgID(r)
@@ -386,7 +472,9 @@ func (a *App) SyncTemplates(r *fastglue.Request) error {
 	}
 
 	// Sync to database
-	synced := 0
+	now := time.Now()
+	report := templateSyncReport{}
+	seen := make(map[uuid.UUID]bool, len(templates))
 	for _, metaTemplate := range templates {
 		template := models.Template{
 			OrganizationID:  orgID,
@@ -397,6 +485,7 @@ func (a *App) SyncTemplates(r *fastglue.Request) error {
 			Language:        metaTemplate.Language,
 			Category:        metaTemplate.Category,
 			Status:          metaTemplate.Status,
+			LastSyncedAt:    &now,
 		}
 
 		// Parse components
@@ -409,15 +498,21 @@ func (a *App) SyncTemplates(r *fastglue.Request) error {
 				}
 			case "BODY":
 				template.BodyContent = comp.Text
+				template.AddSecurityRecommendation = comp.AddSecurityRecommendation
 			case "FOOTER":
 				template.FooterContent = comp.Text
+				template.CodeExpirationMinutes = comp.CodeExpirationMinutes
 			case "BUTTONS":
-				// Convert []TemplateButton to []interface{}
-				buttons := make([]interface{}, len(comp.Buttons))
-				for i, btn := range comp.Buttons {
-					buttons[i] = btn
+				template.Buttons = metaButtonsToJSONB(comp.Buttons)
+			case "LIMITED_TIME_OFFER":
+				if comp.LimitedTimeOffer != nil {
+					template.LimitedTimeOffer = models.JSONB{
+						"text":           comp.LimitedTimeOffer.Text,
+						"has_expiration": comp.LimitedTimeOffer.HasExpiration,
+					}
 				}
-				template.Buttons = convertToJSONBArray(buttons)
+			case "CAROUSEL":
+				template.Cards = metaCardsToJSONB(comp.Cards)
 			}
 		}
 
@@ -425,30 +520,85 @@ func (a *App) SyncTemplates(r *fastglue.Request) error {
 		existing := models.Template{}
 		if err := a.DB.Unscoped().Where("organization_id = ? AND whats_app_account = ? AND name = ? AND language = ?",
 			orgID, account.Name, template.Name, template.Language).First(&existing).Error; err == nil {
-			// Update existing and restore if soft-deleted (explicitly set deleted_at to NULL)
+			seen[existing.ID] = true
 			template.ID = existing.ID
+			changes := templateContentChanges(&existing, &template)
+			item := TemplateSyncItem{ID: existing.ID, Name: existing.Name, Language: existing.Language, Fields: changes}
+
+			// Unsubmitted local edits win over Meta's copy; publishing them resolves the conflict
+			if existing.Status == "DRAFT" && existing.MetaTemplateID != "" && !existing.DeletedAt.Valid && len(changes) > 0 {
+				a.DB.Model(&existing).Updates(map[string]interface{}{
+					"meta_template_id": template.MetaTemplateID,
+					"last_synced_at":   now,
+				})
+				report.Conflicts = append(report.Conflicts, item)
+				continue
+			}
+
+			// Update existing and restore if soft-deleted (explicitly set deleted_at to NULL)
 			a.DB.Unscoped().Model(&template).Updates(map[string]interface{}{
-				"meta_template_id": template.MetaTemplateID,
-				"display_name":     template.DisplayName,
-				"category":         template.Category,
-				"status":           template.Status,
-				"header_type":      template.HeaderType,
-				"header_content":   template.HeaderContent,
-				"body_content":     template.BodyContent,
-				"footer_content":   template.FooterContent,
-				"buttons":          template.Buttons,
-				"deleted_at":       nil, // Restore soft-deleted template
+				"meta_template_id":            template.MetaTemplateID,
+				"display_name":                template.DisplayName,
+				"category":                    template.Category,
+				"status":                      template.Status,
+				"header_type":                 template.HeaderType,
+				"header_content":              template.HeaderContent,
+				"body_content":                template.BodyContent,
+				"footer_content":              template.FooterContent,
+				"buttons":                     template.Buttons,
+				"cards":                       convertToJSONBArray(template.Cards),
+				"limited_time_offer":          template.LimitedTimeOffer,
+				"add_security_recommendation": template.AddSecurityRecommendation,
+				"code_expiration_minutes":     template.CodeExpirationMinutes,
+				"last_synced_at":              now,
+				"deleted_at":                  nil, // Restore soft-deleted template
 			})
+			report.Updated++
+			// Only templates already linked to Meta can have been edited there
+			if existing.MetaTemplateID != "" && !existing.DeletedAt.Valid && len(changes) > 0 {
+				report.Edited = append(report.Edited, item)
+			}
 		} else {
-			// Create new
+			// New language variants join the group of their name's other variants
+			var sibling models.Template
+			if err := a.DB.Where("organization_id = ? AND name = ? AND template_group_id IS NOT NULL", orgID, template.Name).
+				First(&sibling).Error; err == nil {
+				template.TemplateGroupID = sibling.TemplateGroupID
+			}
 			a.DB.Create(&template)
+			seen[template.ID] = true
+			report.Created++
 		}
-		synced++
 	}
 
+	// Templates that were on Meta but are no longer listed were deleted there. The link
+	// to Meta is cleared so publishing the template creates it again.
+	var linked []models.Template
+	a.DB.Where("organization_id = ? AND whats_app_account = ? AND meta_template_id <> ''", orgID, account.Name).Find(&linked)
+	for _, t := range linked {
+		if seen[t.ID] {
+			continue
+		}
+		updates := map[string]interface{}{
+			"meta_template_id":  "",
+			"status_updated_at": now,
+		}
+		if t.Status != "DRAFT" {
+			updates["status"] = "DELETED"
+		}
+		a.DB.Model(&t).Updates(updates)
+		report.Deleted = append(report.Deleted, TemplateSyncItem{ID: t.ID, Name: t.Name, Language: t.Language})
+	}
+
+	synced := report.Created + report.Updated + len(report.Conflicts)
 	return r.SendEnvelope(map[string]interface{}{
-		"message": fmt.Sprintf("Synced %d templates", synced),
-		"count":   synced,
+		"message":   fmt.Sprintf("Synced %d templates", synced),
+		"count":     synced,
+		"created":   report.Created,
+		"updated":   report.Updated,
+		"edited":    nonNilSyncItems(report.Edited),
+		"conflicts": nonNilSyncItems(report.Conflicts),
+		"deleted":   nonNilSyncItems(report.Deleted),
 	})
 }
 
@@ -459,11 +609,11 @@ func (a *App) fetchTemplatesFromMeta(account *models.WhatsAppAccount) ([]whatsap
 	return a.WhatsApp.FetchTemplates(ctx, waAccount)
 }
 
-func (a *App) deleteTemplateFromMeta(account *models.WhatsAppAccount, templateName string) {
+func (a *App) deleteTemplateFromMeta(account *models.WhatsAppAccount, templateName, templateID string) {
 	waAccount := a.toWhatsAppAccount(account)
 
 	ctx := context.Background()
-	if err := a.WhatsApp.DeleteTemplate(ctx, waAccount, templateName); err != nil {
+	if err := a.WhatsApp.DeleteTemplate(ctx, waAccount, templateName, templateID); err != nil {
 		a.Log.Error("Failed to delete template from Meta", "error", err, "template", templateName)
 	}
 }
@@ -471,24 +621,150 @@ func (a *App) deleteTemplateFromMeta(account *models.WhatsAppAccount, templateNa
 // Helper functions
 
 func templateToResponse(t models.Template) TemplateResponse {
+	lastSyncedAt := ""
+	if t.LastSyncedAt != nil {
+		lastSyncedAt = t.LastSyncedAt.Format("2006-01-02T15:04:05Z")
+	}
 	return TemplateResponse{
-		ID:              t.ID,
-		WhatsAppAccount: t.WhatsAppAccount,
-		MetaTemplateID:  t.MetaTemplateID,
-		Name:            t.Name,
-		DisplayName:     t.DisplayName,
-		Language:        t.Language,
-		Category:        t.Category,
-		Status:          t.Status,
-		HeaderType:      t.HeaderType,
-		HeaderContent:   t.HeaderContent,
-		BodyContent:     t.BodyContent,
-		FooterContent:   t.FooterContent,
-		Buttons:         convertFromJSONBArray(t.Buttons),
-		SampleValues:    convertFromJSONBArray(t.SampleValues),
-		CreatedAt:       t.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:       t.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:               t.ID,
+		WhatsAppAccount:  t.WhatsAppAccount,
+		MetaTemplateID:   t.MetaTemplateID,
+		Name:             t.Name,
+		DisplayName:      t.DisplayName,
+		Language:         t.Language,
+		Category:         t.Category,
+		Status:           t.Status,
+		HeaderType:       t.HeaderType,
+		HeaderContent:    t.HeaderContent,
+		BodyContent:      t.BodyContent,
+		FooterContent:    t.FooterContent,
+		Buttons:          convertFromJSONBArray(t.Buttons),
+		SampleValues:     convertFromJSONBArray(t.SampleValues),
+		QualityScore:     t.QualityScore,
+		PreviousCategory: t.PreviousCategory,
+		RejectionReason:  t.RejectionReason,
+		CreatedAt:        t.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:        t.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+
+		Cards:                     convertFromJSONBArray(t.Cards),
+		LimitedTimeOffer:          t.LimitedTimeOffer,
+		AddSecurityRecommendation: t.AddSecurityRecommendation,
+		CodeExpirationMinutes:     t.CodeExpirationMinutes,
+
+		TemplateGroupID: t.TemplateGroupID,
+		LastSyncedAt:    lastSyncedAt,
+	}
+}
+
+// applyTemplateTypeFields copies the limited-time-offer and authentication settings of a request.
+// Authentication templates get Meta's fixed body and footer so previews match what recipients see.
+func applyTemplateTypeFields(template *models.Template, req *TemplateRequest) {
+	if req.LimitedTimeOffer != nil {
+		if len(req.LimitedTimeOffer) == 0 {
+			template.LimitedTimeOffer = nil
+		} else {
+			template.LimitedTimeOffer = models.JSONB(req.LimitedTimeOffer)
+		}
+	}
+	if req.AddSecurityRecommendation != nil {
+		template.AddSecurityRecommendation = *req.AddSecurityRecommendation
+	}
+	if req.CodeExpirationMinutes != nil {
+		template.CodeExpirationMinutes = *req.CodeExpirationMinutes
+	}
+
+	if template.Category == "AUTHENTICATION" {
+		template.BodyContent = templateutil.AuthenticationBody(template.AddSecurityRecommendation)
+		template.FooterContent = templateutil.AuthenticationFooter(template.CodeExpirationMinutes)
+	}
+}
+
+// templateContentChanges lists the content fields that differ between a stored template
+// and Meta's copy. Media header samples are skipped since Meta returns a new URL on every fetch.
+func templateContentChanges(local, remote *models.Template) []string {
+	var changes []string
+	if !strings.EqualFold(local.HeaderType, remote.HeaderType) ||
+		(strings.EqualFold(remote.HeaderType, "TEXT") && local.HeaderContent != remote.HeaderContent) {
+		changes = append(changes, "header")
+	}
+	if local.BodyContent != remote.BodyContent {
+		changes = append(changes, "body")
+	}
+	if local.FooterContent != remote.FooterContent {
+		changes = append(changes, "footer")
+	}
+	if buttonSignature(local.Buttons) != buttonSignature(remote.Buttons) {
+		changes = append(changes, "buttons")
+	}
+	if len(local.Cards) != len(remote.Cards) {
+		changes = append(changes, "cards")
+	}
+	return changes
+}
+
+// buttonSignature reduces buttons to the fields Meta returns, so locally added keys don't count as edits
+func buttonSignature(buttons models.JSONBArray) string {
+	var sig strings.Builder
+	for _, b := range buttons {
+		// Buttons are maps when loaded from the database and structs when fresh from Meta
+		var btn struct {
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			URL         string `json:"url"`
+			PhoneNumber string `json:"phone_number"`
+		}
+		if raw, err := json.Marshal(b); err == nil {
+			_ = json.Unmarshal(raw, &btn)
+		}
+		fmt.Fprintf(&sig, "%s|%s|%s|%s;", strings.ToUpper(btn.Type), btn.Text, btn.URL, btn.PhoneNumber)
+	}
+	return sig.String()
+}
+
+func nonNilSyncItems(items []TemplateSyncItem) []TemplateSyncItem {
+	if items == nil {
+		return []TemplateSyncItem{}
+	}
+	return items
+}
+
+// metaButtonsToJSONB converts buttons fetched from Meta for storage
+func metaButtonsToJSONB(metaButtons []whatsapp.TemplateButton) models.JSONBArray {
+	buttons := make([]interface{}, len(metaButtons))
+	for i, btn := range metaButtons {
+		buttons[i] = btn
+	}
+	return convertToJSONBArray(buttons)
+}
+
+// metaCardsToJSONB converts carousel cards fetched from Meta to the local card format
+func metaCardsToJSONB(metaCards []whatsapp.TemplateCard) models.JSONBArray {
+	cards := make([]interface{}, 0, len(metaCards))
+	for _, metaCard := range metaCards {
+		card := map[string]interface{}{}
+		for _, comp := range metaCard.Components {
+			switch comp.Type {
+			case "HEADER":
+				card["header_type"] = comp.Format
+				if comp.Example != nil && len(comp.Example.HeaderHandle) > 0 {
+					card["header_content"] = comp.Example.HeaderHandle[0]
+				}
+			case "BODY":
+				card["body_content"] = comp.Text
+				if comp.Example != nil && len(comp.Example.BodyText) > 0 {
+					samples := make([]interface{}, len(comp.Example.BodyText[0]))
+					for i, value := range comp.Example.BodyText[0] {
+						samples[i] = map[string]interface{}{"component": "body", "index": i + 1, "value": value}
+					}
+					card["sample_values"] = samples
+				}
+			case "BUTTONS":
+				card["buttons"] = []interface{}(metaButtonsToJSONB(comp.Buttons))
+			}
+		}
+		cards = append(cards, card)
 	}
+	return convertToJSONBArray(cards)
 }
 
 func normalizeTemplateName(name string) string {
@@ -418,6 +418,44 @@ func TestApp_CreateTemplate_MissingBodyContent(t *testing.T) {
 	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "required")
 }
 
+func TestApp_CreateTemplate_Authentication(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+
+	// Meta writes the body of authentication templates, so none is sent
+	body := map[string]interface{}{
+		"whatsapp_account":            account.Name,
+		"name":                        "login_code",
+		"language":                    "en",
+		"category":                    "authentication",
+		"add_security_recommendation": true,
+		"code_expiration_minutes":     10,
+		"buttons": []map[string]interface{}{
+			{"type": "OTP", "otp_type": "COPY_CODE", "text": "Copy code"},
+		},
+	}
+
+	req := testutil.NewJSONRequest(t, body)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+
+	err := app.CreateTemplate(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.TemplateResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, templateutil.AuthenticationBody(true), resp.Data.BodyContent)
+	assert.Equal(t, "This code expires in 10 minutes.", resp.Data.FooterContent)
+	assert.True(t, resp.Data.AddSecurityRecommendation)
+	assert.Equal(t, 10, resp.Data.CodeExpirationMinutes)
+}
+
 func TestApp_CreateTemplate_AccountNotFound(t *testing.T) {
 	t.Parallel()
 
@@ -1056,6 +1094,65 @@ func TestApp_SyncTemplates_Success(t *testing.T) {
 	assert.Len(t, templates, 2)
 }
 
+func TestApp_SyncTemplates_Reconciles(t *testing.T) {
+	t.Parallel()
+
+	server := newMockTemplateServer(t)
+	defer server.Close()
+	app := newTemplateTestApp(t, server)
+
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+
+	// Edited on Meta since the last sync
+	edited := createTestTemplateInDB(t, app, org.ID, account.Name, "synced_template_one", "APPROVED")
+	app.DB.Model(edited).Update("meta_template_id", "meta-synced-1")
+	// Edited locally and on Meta: local edits are kept
+	conflict := createTestTemplateInDB(t, app, org.ID, account.Name, "synced_template_two", "DRAFT")
+	app.DB.Model(conflict).Update("meta_template_id", "meta-synced-2")
+	// Deleted on Meta
+	deleted := createTestTemplateInDB(t, app, org.ID, account.Name, "gone_template", "APPROVED")
+	app.DB.Model(deleted).Update("meta_template_id", "meta-gone")
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"whatsapp_account": account.Name,
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+
+	require.NoError(t, app.SyncTemplates(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			Edited    []handlers.TemplateSyncItem `json:"edited"`
+			Conflicts []handlers.TemplateSyncItem `json:"conflicts"`
+			Deleted   []handlers.TemplateSyncItem `json:"deleted"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	require.Len(t, resp.Data.Edited, 1)
+	assert.Equal(t, edited.ID, resp.Data.Edited[0].ID)
+	assert.Equal(t, []string{"body"}, resp.Data.Edited[0].Fields)
+	require.Len(t, resp.Data.Conflicts, 1)
+	assert.Equal(t, conflict.ID, resp.Data.Conflicts[0].ID)
+	require.Len(t, resp.Data.Deleted, 1)
+	assert.Equal(t, deleted.ID, resp.Data.Deleted[0].ID)
+
+	var stored models.Template
+	require.NoError(t, app.DB.First(&stored, edited.ID).Error)
+	assert.Equal(t, "Synced body content", stored.BodyContent)
+	assert.NotNil(t, stored.LastSyncedAt)
+
+	require.NoError(t, app.DB.First(&stored, conflict.ID).Error)
+	assert.Equal(t, "DRAFT", stored.Status)
+	assert.Equal(t, conflict.BodyContent, stored.BodyContent)
+
+	require.NoError(t, app.DB.First(&stored, deleted.ID).Error)
+	assert.Equal(t, "DELETED", stored.Status)
+	assert.Empty(t, stored.MetaTemplateID)
+}
+
 func TestApp_SyncTemplates_MissingAccount(t *testing.T) {
 	t.Parallel()
 
@@ -1115,3 +1212,86 @@ func TestApp_SyncTemplates_ViaQueryParam(t *testing.T) {
 	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
 	assert.Equal(t, 2, resp.Data.Count)
 }
+
+// --- Lint / Render Tests ---
+
+func TestApp_SubmitTemplate_LintErrors(t *testing.T) {
+	t.Parallel()
+
+	server := newMockTemplateServer(t)
+	defer server.Close()
+	app := newTemplateTestApp(t, server)
+
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+
+	// No sample value for {{1}}
+	tmpl := createTestTemplateInDB(t, app, org.ID, account.Name, "lint_me", "DRAFT")
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", tmpl.ID.String())
+
+	err := app.SubmitTemplate(req)
+	require.NoError(t, err)
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "Meta would reject")
+
+	var updated models.Template
+	require.NoError(t, app.DB.First(&updated, tmpl.ID).Error)
+	assert.Equal(t, "DRAFT", updated.Status)
+	assert.Empty(t, updated.MetaTemplateID)
+}
+
+func TestApp_LintTemplate(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	tmpl := createTestTemplateInDB(t, app, org.ID, account.Name, "lint_check", "DRAFT")
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", tmpl.ID.String())
+
+	err := app.LintTemplate(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.TemplateLintResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.False(t, resp.Data.Valid)
+	require.NotEmpty(t, resp.Data.Issues)
+	assert.Equal(t, "missing_sample", resp.Data.Issues[0].Code)
+}
+
+func TestApp_RenderTemplate(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	tmpl := createTestTemplateInDB(t, app, org.ID, account.Name, "render_me", "APPROVED")
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"params": map[string]string{"1": "John"},
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", tmpl.ID.String())
+
+	err := app.RenderTemplate(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data templateutil.RenderedTemplate `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, "Hello John, welcome!", resp.Data.Body)
+	assert.Empty(t, resp.Data.MissingParams)
+}
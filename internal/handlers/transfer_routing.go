@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+)
+
+// maxOverflowHops bounds how many overflow teams a transfer is routed through
+const maxOverflowHops = 3
+
+// Routing candidate statuses
+const (
+	candidateEligible      = "eligible"
+	candidateUnavailable   = "unavailable"
+	candidateAtCapacity    = "at_capacity"
+	candidateMissingSkills = "missing_skills"
+	candidateWrongLanguage = "language_mismatch"
+)
+
+// transferOptions carries the routing inputs of a transfer
+type transferOptions struct {
+	Priority int
+	Skills   []string // Required agent skills
+	Language string   // Required agent language
+}
+
+// routingRequirements is what a transfer asks of the agent it is routed to.
+// Skills and Language are hard requirements; Preferred skills (the contact's
+// tags) and PreferredLanguage (the contact's language) only raise the score.
+type routingRequirements struct {
+	Skills            []string `json:"skills,omitempty"`
+	Language          string   `json:"language,omitempty"`
+	Preferred         []string `json:"preferred,omitempty"`
+	PreferredLanguage string   `json:"preferred_language,omitempty"`
+}
+
+// routingCandidate is a team member considered for a transfer
+type routingCandidate struct {
+	UserID        uuid.UUID `json:"user_id"`
+	Name          string    `json:"name"`
+	Status        string    `json:"status"`
+	Load          int       `json:"load"`
+	Capacity      int       `json:"capacity,omitempty"` // 0 = unlimited
+	Score         int       `json:"score"`
+	MissingSkills []string  `json:"missing_skills,omitempty"`
+
+	available      bool
+	skills         []string
+	languages      []string
+	lastAssignedAt *time.Time
+}
+
+// teamRoutingDecision records how one team was evaluated
+type teamRoutingDecision struct {
+	TeamID     uuid.UUID          `json:"team_id"`
+	TeamName   string             `json:"team_name"`
+	Strategy   string             `json:"strategy"`
+	Candidates []routingCandidate `json:"candidates"`
+	Reason     string             `json:"reason"`
+}
+
+// routingDecision explains why a transfer was assigned to an agent or left
+// in a queue. It is stored on the transfer.
+type routingDecision struct {
+	Requirements routingRequirements   `json:"requirements"`
+	Teams        []teamRoutingDecision `json:"teams"`
+	AgentID      *uuid.UUID            `json:"agent_id,omitempty"`
+	TeamID       *uuid.UUID            `json:"team_id,omitempty"`
+	Reason       string                `json:"reason"`
+	DecidedAt    time.Time             `json:"decided_at"`
+}
+
+// toJSONB converts the decision for storage on the transfer
+func (d *routingDecision) toJSONB() models.JSONB {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return nil
+	}
+	var out models.JSONB
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// normalizeSkills lowercases, trims and de-duplicates skills
+func normalizeSkills(skills []string) []string {
+	seen := make(map[string]bool, len(skills))
+	out := make([]string, 0, len(skills))
+	for _, s := range skills {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
+
+// stringList reads a list of strings from a JSON array value, ignoring
+// anything that isn't a string
+func stringList(value interface{}) []string {
+	var out []string
+	switch v := value.(type) {
+	case []string:
+		out = append(out, v...)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+	case models.JSONBArray:
+		return stringList([]interface{}(v))
+	}
+	return out
+}
+
+// transferOptionsFromConfig reads priority, skills and language from a flow
+// transfer step's transfer_config
+func transferOptionsFromConfig(config models.JSONB) transferOptions {
+	opts := transferOptions{}
+	if config == nil {
+		return opts
+	}
+	if p, ok := config["priority"].(float64); ok {
+		opts.Priority = int(p)
+	}
+	opts.Skills = normalizeSkills(stringList(config["skills"]))
+	opts.Language, _ = config["language"].(string)
+	return opts
+}
+
+// newRoutingRequirements combines a transfer's options with the contact's
+// tags and language
+func newRoutingRequirements(opts transferOptions, contact *models.Contact) routingRequirements {
+	req := routingRequirements{
+		Skills:   normalizeSkills(opts.Skills),
+		Language: templateutil.NormalizeLanguage(opts.Language),
+	}
+	if contact != nil {
+		req.Preferred = normalizeSkills(stringList(contact.Tags))
+		if req.Language == "" {
+			req.PreferredLanguage = templateutil.NormalizeLanguage(contact.Language)
+		}
+	}
+	return req
+}
+
+// evaluateCandidate sets the candidate's status and score for the requirements
+func evaluateCandidate(c *routingCandidate, req routingRequirements) {
+	skills := make(map[string]bool, len(c.skills))
+	for _, s := range normalizeSkills(c.skills) {
+		skills[s] = true
+	}
+
+	c.MissingSkills = nil
+	for _, s := range req.Skills {
+		if !skills[s] {
+			c.MissingSkills = append(c.MissingSkills, s)
+		}
+	}
+
+	c.Score = 0
+	for _, s := range req.Preferred {
+		if skills[s] {
+			c.Score++
+		}
+	}
+	if req.PreferredLanguage != "" && matchLanguage(c.languages, req.PreferredLanguage) != "" {
+		c.Score++
+	}
+
+	switch {
+	case !c.available:
+		c.Status = candidateUnavailable
+	case len(c.MissingSkills) > 0:
+		c.Status = candidateMissingSkills
+	case req.Language != "" && matchLanguage(c.languages, req.Language) == "":
+		c.Status = candidateWrongLanguage
+	case c.Capacity > 0 && c.Load >= c.Capacity:
+		c.Status = candidateAtCapacity
+	default:
+		c.Status = candidateEligible
+	}
+}
+
+// assignedBefore reports whether a was last assigned before b (never first)
+func assignedBefore(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+	return a.Before(*b)
+}
+
+// selectAgent evaluates the candidates and picks the best eligible one: the
+// highest score, then by the team's strategy (least load for load_balanced,
+// least recently assigned for round_robin). Returns nil and the reason when
+// nobody can take the transfer.
+func selectAgent(strategy models.AssignmentStrategy, candidates []routingCandidate, req routingRequirements) (*routingCandidate, string) {
+	if strategy == models.AssignmentStrategyManual {
+		return nil, "team uses manual assignment"
+	}
+
+	var eligible []*routingCandidate
+	for i := range candidates {
+		evaluateCandidate(&candidates[i], req)
+		if candidates[i].Status == candidateEligible {
+			eligible = append(eligible, &candidates[i])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, "team has no agents"
+	}
+	if len(eligible) == 0 {
+		return nil, "no agent is available with the required skills and capacity"
+	}
+
+	sort.SliceStable(eligible, func(i, j int) bool {
+		a, b := eligible[i], eligible[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if strategy == models.AssignmentStrategyLoadBalanced && a.Load != b.Load {
+			return a.Load < b.Load
+		}
+		return assignedBefore(a.lastAssignedAt, b.lastAssignedAt)
+	})
+
+	chosen := eligible[0]
+	reason := "least recently assigned"
+	if strategy == models.AssignmentStrategyLoadBalanced {
+		reason = fmt.Sprintf("lowest load (%d active)", chosen.Load)
+	}
+	if chosen.Score > 0 {
+		reason = fmt.Sprintf("best match (score %d), %s", chosen.Score, reason)
+	}
+	return chosen, reason
+}
+
+// agentLoads counts the active transfers of each agent
+func (a *App) agentLoads(orgID uuid.UUID, agentIDs []uuid.UUID) map[uuid.UUID]int {
+	loads := make(map[uuid.UUID]int, len(agentIDs))
+	if len(agentIDs) == 0 {
+		return loads
+	}
+	type agentLoad struct {
+		AgentID uuid.UUID `gorm:"column:agent_id"`
+		Count   int       `gorm:"column:count"`
+	}
+	var rows []agentLoad
+	a.DB.Model(&models.AgentTransfer{}).
+		Select("agent_id, COUNT(*) as count").
+		Where("organization_id = ? AND agent_id IN ? AND status = ?", orgID, agentIDs, models.TransferStatusActive).
+		Group("agent_id").
+		Scan(&rows)
+	for _, l := range rows {
+		loads[l.AgentID] = l.Count
+	}
+	return loads
+}
+
+// agentHasCapacity reports whether the agent can take another chat
+func (a *App) agentHasCapacity(orgID uuid.UUID, agent *models.User) bool {
+	if agent.MaxConcurrentChats <= 0 {
+		return true
+	}
+	return a.agentLoads(orgID, []uuid.UUID{agent.ID})[agent.ID] < agent.MaxConcurrentChats
+}
+
+// teamRoutingCandidates loads the agents of a team with their current load
+func (a *App) teamRoutingCandidates(teamID, orgID uuid.UUID) []routingCandidate {
+	var members []models.TeamMember
+	if err := a.DB.Preload("User").
+		Joins("JOIN users ON users.id = team_members.user_id AND users.deleted_at IS NULL").
+		Where("team_members.team_id = ? AND team_members.role = ? AND users.is_active = ?", teamID, models.TeamRoleAgent, true).
+		Find(&members).Error; err != nil {
+		a.Log.Error("Failed to load team members for routing", "error", err, "team_id", teamID)
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	loads := a.agentLoads(orgID, ids)
+
+	candidates := make([]routingCandidate, 0, len(members))
+	for _, m := range members {
+		if m.User == nil {
+			continue
+		}
+		candidates = append(candidates, routingCandidate{
+			UserID:         m.UserID,
+			Name:           m.User.FullName,
+			Load:           loads[m.UserID],
+			Capacity:       m.User.MaxConcurrentChats,
+			available:      m.User.IsAvailable,
+			skills:         m.User.Skills,
+			languages:      m.User.Languages,
+			lastAssignedAt: m.LastAssignedAt,
+		})
+	}
+	return candidates
+}
+
+// routeTransfer picks an agent for a transfer to a team, following the
+// team's overflow chain when nobody in it can take the transfer. It returns
+// the agent (nil to queue), the team the transfer belongs to and the
+// decision log. Without an agent the transfer stays in the requested team.
+func (a *App) routeTransfer(teamID, orgID uuid.UUID, req routingRequirements) (*uuid.UUID, uuid.UUID, routingDecision) {
+	decision := routingDecision{Requirements: req, DecidedAt: time.Now()}
+	visited := make(map[uuid.UUID]bool)
+	current := &teamID
+
+	for hop := 0; current != nil && hop <= maxOverflowHops; hop++ {
+		if visited[*current] {
+			break
+		}
+		visited[*current] = true
+
+		var team models.Team
+		if err := a.DB.Where("id = ? AND organization_id = ? AND is_active = ?", *current, orgID, true).First(&team).Error; err != nil {
+			a.Log.Error("Failed to get team for routing", "error", err, "team_id", *current)
+			break
+		}
+
+		candidates := a.teamRoutingCandidates(team.ID, orgID)
+		chosen, reason := selectAgent(team.AssignmentStrategy, candidates, req)
+		decision.Teams = append(decision.Teams, teamRoutingDecision{
+			TeamID:     team.ID,
+			TeamName:   team.Name,
+			Strategy:   string(team.AssignmentStrategy),
+			Candidates: candidates,
+			Reason:     reason,
+		})
+
+		if chosen != nil {
+			now := time.Now()
+			a.DB.Model(&models.TeamMember{}).
+				Where("team_id = ? AND user_id = ?", team.ID, chosen.UserID).
+				Update("last_assigned_at", now)
+
+			agentID := chosen.UserID
+			assignedTeam := team.ID
+			decision.AgentID = &agentID
+			decision.TeamID = &assignedTeam
+			decision.Reason = fmt.Sprintf("assigned to %s in %s: %s", chosen.Name, team.Name, reason)
+			if team.ID != teamID {
+				decision.Reason += " (overflow)"
+			}
+			a.Log.Debug("Transfer routed to agent", "team_id", team.ID, "user_id", agentID, "reason", reason)
+			return &agentID, team.ID, decision
+		}
+
+		// Manual teams keep their transfers for a manager to assign
+		if team.AssignmentStrategy == models.AssignmentStrategyManual {
+			break
+		}
+		current = team.OverflowTeamID
+	}
+
+	queued := teamID
+	decision.TeamID = &queued
+	decision.Reason = "queued: " + decision.lastReason()
+	return nil, teamID, decision
+}
+
+// lastReason returns the reason of the last team evaluated
+func (d *routingDecision) lastReason() string {
+	if len(d.Teams) == 0 {
+		return "team not found or inactive"
+	}
+	return d.Teams[len(d.Teams)-1].Reason
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func routingAgent(name string, load, capacity int, skills, languages []string, lastAssigned *time.Time) routingCandidate {
+	return routingCandidate{
+		UserID:         uuid.New(),
+		Name:           name,
+		Load:           load,
+		Capacity:       capacity,
+		available:      true,
+		skills:         skills,
+		languages:      languages,
+		lastAssignedAt: lastAssigned,
+	}
+}
+
+func TestSelectAgent_RoundRobin(t *testing.T) {
+	earlier := time.Now().Add(-time.Hour)
+	later := time.Now()
+
+	candidates := []routingCandidate{
+		routingAgent("Recent", 0, 0, nil, nil, &later),
+		routingAgent("Older", 5, 0, nil, nil, &earlier),
+		routingAgent("Never", 9, 0, nil, nil, nil),
+	}
+	chosen, _ := selectAgent(models.AssignmentStrategyRoundRobin, candidates, routingRequirements{})
+	require.NotNil(t, chosen)
+	assert.Equal(t, "Never", chosen.Name)
+}
+
+func TestSelectAgent_LoadBalanced(t *testing.T) {
+	candidates := []routingCandidate{
+		routingAgent("Busy", 4, 0, nil, nil, nil),
+		routingAgent("Quiet", 1, 0, nil, nil, nil),
+	}
+	chosen, reason := selectAgent(models.AssignmentStrategyLoadBalanced, candidates, routingRequirements{})
+	require.NotNil(t, chosen)
+	assert.Equal(t, "Quiet", chosen.Name)
+	assert.Contains(t, reason, "lowest load")
+}
+
+func TestSelectAgent_Capacity(t *testing.T) {
+	candidates := []routingCandidate{
+		routingAgent("Full", 0, 2, nil, nil, nil),
+		routingAgent("Free", 3, 5, nil, nil, nil),
+	}
+	candidates[0].Load = 2
+	chosen, _ := selectAgent(models.AssignmentStrategyLoadBalanced, candidates, routingRequirements{})
+	require.NotNil(t, chosen)
+	assert.Equal(t, "Free", chosen.Name)
+	assert.Equal(t, candidateAtCapacity, candidates[0].Status)
+
+	candidates[1].Load = 5
+	chosen, reason := selectAgent(models.AssignmentStrategyLoadBalanced, candidates, routingRequirements{})
+	assert.Nil(t, chosen)
+	assert.Contains(t, reason, "no agent is available")
+}
+
+func TestSelectAgent_Skills(t *testing.T) {
+	candidates := []routingCandidate{
+		routingAgent("Sales", 0, 0, []string{"sales"}, []string{"en"}, nil),
+		routingAgent("Billing", 3, 0, []string{"Billing", "sales"}, []string{"es"}, nil),
+	}
+	req := newRoutingRequirements(transferOptions{Skills: []string{"billing"}}, nil)
+	chosen, _ := selectAgent(models.AssignmentStrategyLoadBalanced, candidates, req)
+	require.NotNil(t, chosen)
+	assert.Equal(t, "Billing", chosen.Name)
+	assert.Equal(t, candidateMissingSkills, candidates[0].Status)
+	assert.Equal(t, []string{"billing"}, candidates[0].MissingSkills)
+
+	req = newRoutingRequirements(transferOptions{Language: "pt-BR"}, nil)
+	chosen, _ = selectAgent(models.AssignmentStrategyLoadBalanced, candidates, req)
+	assert.Nil(t, chosen, "nobody speaks Portuguese")
+	assert.Equal(t, candidateWrongLanguage, candidates[0].Status)
+}
+
+func TestSelectAgent_PrefersContactMatch(t *testing.T) {
+	candidates := []routingCandidate{
+		routingAgent("Idle", 0, 0, nil, []string{"en"}, nil),
+		routingAgent("VIP", 3, 0, []string{"vip"}, []string{"es"}, nil),
+	}
+	contact := &models.Contact{Tags: models.JSONBArray{"VIP"}, Language: "es_MX"}
+	req := newRoutingRequirements(transferOptions{}, contact)
+	assert.Equal(t, []string{"vip"}, req.Preferred)
+	assert.Equal(t, "es_mx", req.PreferredLanguage)
+
+	chosen, reason := selectAgent(models.AssignmentStrategyLoadBalanced, candidates, req)
+	require.NotNil(t, chosen)
+	assert.Equal(t, "VIP", chosen.Name)
+	assert.Equal(t, 2, chosen.Score)
+	assert.Contains(t, reason, "best match")
+}
+
+func TestSelectAgent_ManualAndUnavailable(t *testing.T) {
+	candidates := []routingCandidate{routingAgent("Agent", 0, 0, nil, nil, nil)}
+	chosen, reason := selectAgent(models.AssignmentStrategyManual, candidates, routingRequirements{})
+	assert.Nil(t, chosen)
+	assert.Equal(t, "team uses manual assignment", reason)
+
+	candidates[0].available = false
+	chosen, _ = selectAgent(models.AssignmentStrategyRoundRobin, candidates, routingRequirements{})
+	assert.Nil(t, chosen)
+	assert.Equal(t, candidateUnavailable, candidates[0].Status)
+
+	chosen, reason = selectAgent(models.AssignmentStrategyRoundRobin, nil, routingRequirements{})
+	assert.Nil(t, chosen)
+	assert.Equal(t, "team has no agents", reason)
+}
+
+func TestTransferOptionsFromConfig(t *testing.T) {
+	opts := transferOptionsFromConfig(models.JSONB{
+		"team_id":  uuid.New().String(),
+		"priority": float64(5),
+		"skills":   []interface{}{"Billing", " billing ", "refunds", 3},
+		"language": "es",
+	})
+	assert.Equal(t, 5, opts.Priority)
+	assert.Equal(t, []string{"billing", "refunds"}, opts.Skills)
+	assert.Equal(t, "es", opts.Language)
+
+	assert.Equal(t, transferOptions{Skills: []string{}}, transferOptionsFromConfig(models.JSONB{}))
+}
+
+func TestRoutingDecisionToJSONB(t *testing.T) {
+	agentID := uuid.New()
+	d := routingDecision{
+		Requirements: routingRequirements{Skills: []string{"billing"}},
+		Teams:        []teamRoutingDecision{{TeamID: uuid.New(), TeamName: "Support", Strategy: "round_robin", Reason: "least recently assigned"}},
+		AgentID:      &agentID,
+		Reason:       "assigned",
+	}
+	out := d.toJSONB()
+	assert.Equal(t, agentID.String(), out["agent_id"])
+	assert.Equal(t, "assigned", out["reason"])
+	teams, ok := out["teams"].([]interface{})
+	require.True(t, ok)
+	assert.Len(t, teams, 1)
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 	"golang.org/x/crypto/bcrypt"
@@ -19,6 +21,61 @@ type UserRequest struct {
 	FullName string     `json:"full_name"`
 	RoleID   *uuid.UUID `json:"role_id"`
 	IsActive *bool      `json:"is_active"`
+
+	// Transfer routing (requires users:write)
+	MaxConcurrentChats *int      `json:"max_concurrent_chats"`
+	Skills             *[]string `json:"skills"`
+	Languages          *[]string `json:"languages"`
+
+	// Shift schedule (requires users:write)
+	Timezone      *string                   `json:"timezone"`
+	ShiftSchedule *[]map[string]interface{} `json:"shift_schedule"`
+}
+
+// hasAgentFields reports whether the request changes transfer routing or
+// shift settings
+func (req *UserRequest) hasAgentFields() bool {
+	return req.MaxConcurrentChats != nil || req.Skills != nil || req.Languages != nil ||
+		req.Timezone != nil || req.ShiftSchedule != nil
+}
+
+// applyAgentFields validates and copies the transfer routing and shift
+// settings to the user
+func (req *UserRequest) applyAgentFields(user *models.User) error {
+	if req.MaxConcurrentChats != nil {
+		if *req.MaxConcurrentChats < 0 {
+			return fmt.Errorf("max_concurrent_chats must be 0 (unlimited) or more")
+		}
+		user.MaxConcurrentChats = *req.MaxConcurrentChats
+	}
+	if req.Skills != nil {
+		user.Skills = models.StringArray(normalizeSkills(*req.Skills))
+	}
+	if req.Languages != nil {
+		languages := models.StringArray{}
+		for _, l := range *req.Languages {
+			if l = templateutil.NormalizeLanguage(l); l != "" {
+				languages = append(languages, l)
+			}
+		}
+		user.Languages = languages
+	}
+	if req.Timezone != nil {
+		user.Timezone = *req.Timezone
+	}
+	if req.ShiftSchedule != nil {
+		schedule := make(models.JSONBArray, len(*req.ShiftSchedule))
+		for i, entry := range *req.ShiftSchedule {
+			schedule[i] = entry
+		}
+		user.ShiftSchedule = schedule
+	}
+	if req.Timezone != nil || req.ShiftSchedule != nil {
+		if err := validateShiftSchedule(user.Timezone, user.ShiftSchedule); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // superAdminField is used to extract is_super_admin separately from the request body.
@@ -38,19 +95,24 @@ func parseSuperAdminField(r *fastglue.Request) *bool {
 
 // UserResponse represents the response for a user (without sensitive data)
 type UserResponse struct {
-	ID             uuid.UUID    `json:"id"`
-	Email          string       `json:"email"`
-	FullName       string       `json:"full_name"`
-	RoleID         *uuid.UUID   `json:"role_id,omitempty"`
-	Role           *RoleInfo    `json:"role,omitempty"`
-	IsActive       bool         `json:"is_active"`
-	IsAvailable    bool         `json:"is_available"`
-	IsSuperAdmin   bool         `json:"is_super_admin"`
-	IsMember       bool         `json:"is_member"`
-	OrganizationID uuid.UUID    `json:"organization_id"`
-	Settings       models.JSONB `json:"settings,omitempty"`
-	CreatedAt      string       `json:"created_at"`
-	UpdatedAt      string       `json:"updated_at"`
+	ID                 uuid.UUID         `json:"id"`
+	Email              string            `json:"email"`
+	FullName           string            `json:"full_name"`
+	RoleID             *uuid.UUID        `json:"role_id,omitempty"`
+	Role               *RoleInfo         `json:"role,omitempty"`
+	IsActive           bool              `json:"is_active"`
+	IsAvailable        bool              `json:"is_available"`
+	IsSuperAdmin       bool              `json:"is_super_admin"`
+	IsMember           bool              `json:"is_member"`
+	OrganizationID     uuid.UUID         `json:"organization_id"`
+	Settings           models.JSONB      `json:"settings,omitempty"`
+	MaxConcurrentChats int               `json:"max_concurrent_chats"`
+	Skills             []string          `json:"skills"`
+	Languages          []string          `json:"languages"`
+	Timezone           string            `json:"timezone"`
+	ShiftSchedule      models.JSONBArray `json:"shift_schedule"`
+	CreatedAt          string            `json:"created_at"`
+	UpdatedAt          string            `json:"updated_at"`
 }
 
 // PermissionInfo represents permission info in role response
@@ -228,6 +290,11 @@ func (a *App) CreateUser(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Email, password, and full_name are required", nil, "")
 	}
 
+	var agent models.User
+	if err := req.applyAgentFields(&agent); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid agent settings: "+err.Error(), nil, "")
+	}
+
 	// Determine role
 	var roleID *uuid.UUID
 	if req.RoleID != nil {
@@ -271,13 +338,18 @@ func (a *App) CreateUser(r *fastglue.Request) error {
 	if err := a.DB.Unscoped().Where("email = ? AND deleted_at IS NOT NULL", req.Email).First(&softDeleted).Error; err == nil {
 		// Restore the soft-deleted user with new details
 		if err := a.DB.Unscoped().Model(&softDeleted).Updates(map[string]interface{}{
-			"deleted_at":      nil,
-			"organization_id": orgID,
-			"password_hash":   string(hashedPassword),
-			"full_name":       req.FullName,
-			"role_id":         roleID,
-			"is_active":       true,
-			"is_super_admin":  isSuperAdmin,
+			"deleted_at":           nil,
+			"organization_id":      orgID,
+			"password_hash":        string(hashedPassword),
+			"full_name":            req.FullName,
+			"role_id":              roleID,
+			"is_active":            true,
+			"is_super_admin":       isSuperAdmin,
+			"max_concurrent_chats": agent.MaxConcurrentChats,
+			"skills":               agent.Skills,
+			"languages":            agent.Languages,
+			"timezone":             agent.Timezone,
+			"shift_schedule":       agent.ShiftSchedule,
 		}).Error; err != nil {
 			a.Log.Error("Failed to restore user", "error", err)
 			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create user", nil, "")
@@ -313,18 +385,28 @@ func (a *App) CreateUser(r *fastglue.Request) error {
 		softDeleted.RoleID = roleID
 		softDeleted.IsActive = true
 		softDeleted.IsSuperAdmin = isSuperAdmin
+		softDeleted.MaxConcurrentChats = agent.MaxConcurrentChats
+		softDeleted.Skills = agent.Skills
+		softDeleted.Languages = agent.Languages
+		softDeleted.Timezone = agent.Timezone
+		softDeleted.ShiftSchedule = agent.ShiftSchedule
 
 		return r.SendEnvelope(userToResponse(softDeleted))
 	}
 
 	user := models.User{
-		OrganizationID: orgID,
-		Email:          req.Email,
-		PasswordHash:   string(hashedPassword),
-		FullName:       req.FullName,
-		RoleID:         roleID,
-		IsActive:       true,
-		IsSuperAdmin:   isSuperAdmin,
+		OrganizationID:     orgID,
+		Email:              req.Email,
+		PasswordHash:       string(hashedPassword),
+		FullName:           req.FullName,
+		RoleID:             roleID,
+		IsActive:           true,
+		IsSuperAdmin:       isSuperAdmin,
+		MaxConcurrentChats: agent.MaxConcurrentChats,
+		Skills:             agent.Skills,
+		Languages:          agent.Languages,
+		Timezone:           agent.Timezone,
+		ShiftSchedule:      agent.ShiftSchedule,
 	}
 
 	if err := a.DB.Create(&user).Error; err != nil {
@@ -403,6 +485,11 @@ func (a *App) UpdateUser(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Insufficient permissions to change roles", nil, "")
 	}
 
+	// Only users with users:write permission can change routing and shift settings
+	if req.hasAgentFields() && !a.HasPermission(currentUserID, models.ResourceUsers, models.ActionWrite, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Insufficient permissions to change routing or shift settings", nil, "")
+	}
+
 	// For cross-org members, only allow role updates
 	if isMember {
 		if req.RoleID == nil {
@@ -469,6 +556,10 @@ func (a *App) UpdateUser(r *fastglue.Request) error {
 		user.Role = nil // Clear the preloaded role to prevent GORM from using the old association
 	}
 
+	if err := req.applyAgentFields(&user); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid agent settings: "+err.Error(), nil, "")
+	}
+
 	if req.IsActive != nil {
 		// Prevent user from deactivating themselves
 		if currentUserID == id && !*req.IsActive {
@@ -739,17 +830,22 @@ func (a *App) ChangePassword(r *fastglue.Request) error {
 // Helper function to convert User to UserResponse
 func userToResponse(user models.User) UserResponse {
 	resp := UserResponse{
-		ID:             user.ID,
-		Email:          user.Email,
-		FullName:       user.FullName,
-		RoleID:         user.RoleID,
-		IsActive:       user.IsActive,
-		IsAvailable:    user.IsAvailable,
-		IsSuperAdmin:   user.IsSuperAdmin,
-		OrganizationID: user.OrganizationID,
-		Settings:       user.Settings,
-		CreatedAt:      user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:      user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:                 user.ID,
+		Email:              user.Email,
+		FullName:           user.FullName,
+		RoleID:             user.RoleID,
+		IsActive:           user.IsActive,
+		IsAvailable:        user.IsAvailable,
+		IsSuperAdmin:       user.IsSuperAdmin,
+		OrganizationID:     user.OrganizationID,
+		Settings:           user.Settings,
+		MaxConcurrentChats: user.MaxConcurrentChats,
+		Skills:             user.Skills,
+		Languages:          user.Languages,
+		Timezone:           user.Timezone,
+		ShiftSchedule:      user.ShiftSchedule,
+		CreatedAt:          user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:          user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 
 	// Include role info if loaded
@@ -828,7 +924,8 @@ func (a *App) ListMyOrganizations(r *fastglue.Request) error {
 
 // AvailabilityRequest represents the request body for updating availability
 type AvailabilityRequest struct {
-	IsAvailable bool `json:"is_available"`
+	IsAvailable bool              `json:"is_available"`
+	Reason      models.AwayReason `json:"reason"` // break, lunch, meeting, training, other (when going away)
 }
 
 // UpdateAvailability updates the current user's availability status (away/available)
@@ -848,58 +945,49 @@ func (a *App) UpdateAvailability(r *fastglue.Request) error {
 		return nil
 	}
 
-	// Only log if status is actually changing
-	if user.IsAvailable != req.IsAvailable {
-		now := time.Now()
-
-		// End the previous availability log (if exists)
-		a.DB.Model(&models.UserAvailabilityLog{}).
-			Where("user_id = ? AND ended_at IS NULL", userID).
-			Update("ended_at", now)
-
-		// Create new availability log
-		log := models.UserAvailabilityLog{
-			UserID:         userID,
-			OrganizationID: orgID,
-			IsAvailable:    req.IsAvailable,
-			StartedAt:      now,
+	if !req.IsAvailable {
+		if req.Reason == "" {
+			req.Reason = models.AwayReasonBreak
 		}
-		if err := a.DB.Create(&log).Error; err != nil {
-			a.Log.Error("Failed to create availability log", "error", err)
-			// Continue anyway - logging failure shouldn't block availability update
+		if !validAwayReason(req.Reason) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid away reason", nil, "")
 		}
 	}
 
-	user.IsAvailable = req.IsAvailable
+	// Coming back counts as activity, so idle auto-away doesn't trip at once
+	if req.IsAvailable && a.WSHub != nil {
+		a.WSHub.Touch(userID)
+	}
 
-	if err := a.DB.Save(&user).Error; err != nil {
+	transfersReturned, err := a.setAgentAvailability(&user, orgID, req.IsAvailable, req.Reason, models.AvailabilitySourceManual)
+	if err != nil {
 		a.Log.Error("Failed to update availability", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update availability", nil, "")
 	}
 
 	status := "available"
-	transfersReturned := 0
 	if !req.IsAvailable {
 		status = "away"
-		// Return agent's active transfers to queue when going away
-		transfersReturned = a.ReturnAgentTransfersToQueue(userID, orgID)
 	}
 
-	// Get the current break start time if away
+	// Get the current break start time and reason if away
 	var breakStartedAt *time.Time
+	var awayReason models.AwayReason
 	if !req.IsAvailable {
 		var currentLog models.UserAvailabilityLog
 		if err := a.DB.Where("user_id = ? AND is_available = false AND ended_at IS NULL", userID).
 			Order("started_at DESC").First(&currentLog).Error; err == nil {
 			breakStartedAt = &currentLog.StartedAt
+			awayReason = currentLog.Reason
 		}
 	}
 
 	return r.SendEnvelope(map[string]interface{}{
-		"message":             "Availability updated successfully",
-		"is_available":        user.IsAvailable,
-		"status":              status,
-		"break_started_at":    breakStartedAt,
-		"transfers_to_queue":  transfersReturned,
+		"message":            "Availability updated successfully",
+		"is_available":       user.IsAvailable,
+		"status":             status,
+		"break_started_at":   breakStartedAt,
+		"away_reason":        awayReason,
+		"transfers_to_queue": transfersReturned,
 	})
 }
@@ -1534,6 +1534,39 @@ func TestApp_UpdateAvailability_NoChangeNoNewLog(t *testing.T) {
 	assert.Equal(t, int64(0), logCount)
 }
 
+func TestApp_UpdateAvailability_AwayReason(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID,
+		testutil.WithEmail(testutil.UniqueEmail("avail-reason")),
+	)
+
+	// Unknown reasons are rejected
+	req := testutil.NewJSONRequest(t, map[string]interface{}{"is_available": false, "reason": "nap"})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.UpdateAvailability(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+
+	// Going to lunch, then switching to a meeting, starts a new log each time
+	for _, reason := range []string{"lunch", "meeting"} {
+		req = testutil.NewJSONRequest(t, map[string]interface{}{"is_available": false, "reason": reason})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.UpdateAvailability(req))
+		assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	}
+
+	var logs []models.UserAvailabilityLog
+	require.NoError(t, app.DB.Where("user_id = ?", user.ID).Order("started_at ASC").Find(&logs).Error)
+	require.Len(t, logs, 2)
+	assert.Equal(t, models.AwayReasonLunch, logs[0].Reason)
+	assert.NotNil(t, logs[0].EndedAt)
+	assert.Equal(t, models.AwayReasonMeeting, logs[1].Reason)
+	assert.Equal(t, models.AvailabilitySourceManual, logs[1].Source)
+	assert.Nil(t, logs[1].EndedAt)
+}
+
 func TestApp_ListUsers_CrossOrgExclusion(t *testing.T) {
 	t.Parallel()
 
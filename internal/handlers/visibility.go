@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"gorm.io/gorm"
+)
+
+// conversationScope describes which conversations a user may see. Users with
+// chat.all:read see all of them. Everyone else sees the contacts assigned to
+// them and the transfers waiting in their team queues or the general queue.
+// Team managers also see everything assigned to their teams or team members.
+type conversationScope struct {
+	All            bool
+	UserID         uuid.UUID
+	TeamIDs        []uuid.UUID // Teams the user belongs to
+	ManagedTeamIDs []uuid.UUID // Teams the user manages
+	ManagedUserIDs []uuid.UUID // Members of the managed teams
+}
+
+// transferRef holds the fields of an agent transfer that decide who sees it
+type transferRef struct {
+	AgentID *uuid.UUID
+	TeamID  *uuid.UUID
+}
+
+// canSeeTransfer reports whether the transfer is visible in the user's transfer list
+func (s *conversationScope) canSeeTransfer(t transferRef) bool {
+	if s.All {
+		return true
+	}
+	if t.AgentID != nil {
+		if *t.AgentID == s.UserID || slices.Contains(s.ManagedUserIDs, *t.AgentID) {
+			return true
+		}
+	} else if t.TeamID == nil || slices.Contains(s.TeamIDs, *t.TeamID) {
+		// Waiting in the general queue or one of the user's team queues
+		return true
+	}
+	return t.TeamID != nil && slices.Contains(s.ManagedTeamIDs, *t.TeamID)
+}
+
+// followsTransfer reports whether the user receives updates about the
+// transfer. Besides those who can see it, members of the queue it belongs to
+// follow it, so a transfer picked by someone else leaves their queue.
+func (s *conversationScope) followsTransfer(t transferRef) bool {
+	if s.canSeeTransfer(t) {
+		return true
+	}
+	return t.TeamID == nil || slices.Contains(s.TeamIDs, *t.TeamID)
+}
+
+// canSeeContact reports whether the user may see the conversation of a contact
+// assigned to assignedUserID with the given active transfers
+func (s *conversationScope) canSeeContact(assignedUserID *uuid.UUID, activeTransfers []transferRef) bool {
+	if s.All {
+		return true
+	}
+	if assignedUserID != nil && (*assignedUserID == s.UserID || slices.Contains(s.ManagedUserIDs, *assignedUserID)) {
+		return true
+	}
+	for _, t := range activeTransfers {
+		if s.canSeeTransfer(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// transferCondition returns the SQL condition matching canSeeTransfer for the
+// agent transfers table (or alias) named table
+func (s *conversationScope) transferCondition(table string) (string, []any) {
+	parts := []string{table + ".agent_id = ?"}
+	args := []any{s.UserID}
+	if len(s.TeamIDs) > 0 {
+		parts = append(parts, fmt.Sprintf("(%[1]s.agent_id IS NULL AND (%[1]s.team_id IS NULL OR %[1]s.team_id IN ?))", table))
+		args = append(args, s.TeamIDs)
+	} else {
+		parts = append(parts, fmt.Sprintf("(%[1]s.agent_id IS NULL AND %[1]s.team_id IS NULL)", table))
+	}
+	if len(s.ManagedTeamIDs) > 0 {
+		parts = append(parts, table+".team_id IN ?")
+		args = append(args, s.ManagedTeamIDs)
+	}
+	if len(s.ManagedUserIDs) > 0 {
+		parts = append(parts, table+".agent_id IN ?")
+		args = append(args, s.ManagedUserIDs)
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+// contactCondition returns the SQL condition matching canSeeContact for the contacts table
+func (s *conversationScope) contactCondition() (string, []any) {
+	parts := []string{"contacts.assigned_user_id = ?"}
+	args := []any{s.UserID}
+	if len(s.ManagedUserIDs) > 0 {
+		parts = append(parts, "contacts.assigned_user_id IN ?")
+		args = append(args, s.ManagedUserIDs)
+	}
+	transferCond, transferArgs := s.transferCondition("visible_transfers")
+	parts = append(parts, "EXISTS (SELECT 1 FROM agent_transfers visible_transfers"+
+		" WHERE visible_transfers.contact_id = contacts.id AND visible_transfers.status = ?"+
+		" AND visible_transfers.deleted_at IS NULL AND "+transferCond+")")
+	args = append(args, models.TransferStatusActive)
+	args = append(args, transferArgs...)
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+// conversationScope returns the conversation scope of a user in an organization
+func (a *App) conversationScope(userID, orgID uuid.UUID) *conversationScope {
+	return a.conversationScopes(orgID, []uuid.UUID{userID})[userID]
+}
+
+// conversationScopes returns the conversation scopes of several users,
+// loading their team memberships together
+func (a *App) conversationScopes(orgID uuid.UUID, userIDs []uuid.UUID) map[uuid.UUID]*conversationScope {
+	scopes := make(map[uuid.UUID]*conversationScope, len(userIDs))
+	var limited []uuid.UUID
+	for _, userID := range userIDs {
+		scope := &conversationScope{UserID: userID}
+		if a.HasPermission(userID, models.ResourceChatAll, models.ActionRead, orgID) {
+			scope.All = true
+		} else {
+			limited = append(limited, userID)
+		}
+		scopes[userID] = scope
+	}
+	if len(limited) == 0 {
+		return scopes
+	}
+
+	var memberships []models.TeamMember
+	if err := a.DB.Select("team_members.team_id, team_members.user_id, team_members.role").
+		Joins("JOIN teams ON teams.id = team_members.team_id AND teams.deleted_at IS NULL").
+		Where("team_members.user_id IN ? AND teams.organization_id = ?", limited, orgID).
+		Find(&memberships).Error; err != nil {
+		a.Log.Error("Failed to load team memberships", "error", err, "org_id", orgID)
+		return scopes
+	}
+
+	var managedTeamIDs []uuid.UUID
+	for _, m := range memberships {
+		scope := scopes[m.UserID]
+		scope.TeamIDs = append(scope.TeamIDs, m.TeamID)
+		if m.Role == models.TeamRoleManager {
+			scope.ManagedTeamIDs = append(scope.ManagedTeamIDs, m.TeamID)
+			managedTeamIDs = append(managedTeamIDs, m.TeamID)
+		}
+	}
+	if len(managedTeamIDs) == 0 {
+		return scopes
+	}
+
+	// Managers see the conversations of everyone in their teams
+	var members []models.TeamMember
+	if err := a.DB.Select("team_id, user_id").
+		Where("team_id IN ?", managedTeamIDs).
+		Find(&members).Error; err != nil {
+		a.Log.Error("Failed to load managed team members", "error", err, "org_id", orgID)
+		return scopes
+	}
+	for _, scope := range scopes {
+		for _, m := range members {
+			if slices.Contains(scope.ManagedTeamIDs, m.TeamID) && !slices.Contains(scope.ManagedUserIDs, m.UserID) {
+				scope.ManagedUserIDs = append(scope.ManagedUserIDs, m.UserID)
+			}
+		}
+	}
+	return scopes
+}
+
+// scopeContacts limits a contacts query to the conversations the user may see
+func (a *App) scopeContacts(query *gorm.DB, userID, orgID uuid.UUID) *gorm.DB {
+	scope := a.conversationScope(userID, orgID)
+	if scope.All {
+		return query
+	}
+	cond, args := scope.contactCondition()
+	return query.Where(cond, args...)
+}
+
+// canAccessContact reports whether the contact exists in the organization and
+// its conversation is visible to the user
+func (a *App) canAccessContact(contactID, userID, orgID uuid.UUID) bool {
+	var count int64
+	a.scopeContacts(a.DB.Model(&models.Contact{}).Where("id = ? AND organization_id = ?", contactID, orgID), userID, orgID).
+		Count(&count)
+	return count > 0
+}
+
+// ScopeBroadcasts makes WebSocket broadcasts about a conversation or transfer
+// reach only the users allowed to see it
+func (a *App) ScopeBroadcasts() {
+	if a.WSHub != nil {
+		a.WSHub.SetAudience(a.conversationAudience)
+	}
+}
+
+// conversationAudience picks the users that receive a scoped broadcast
+func (a *App) conversationAudience(msg websocket.BroadcastMessage, userIDs []uuid.UUID) []uuid.UUID {
+	var visible func(*conversationScope) bool
+
+	if msg.ScopeTransferID != uuid.Nil {
+		var transfer models.AgentTransfer
+		if err := a.DB.Select("agent_id, team_id").
+			Where("id = ? AND organization_id = ?", msg.ScopeTransferID, msg.OrgID).
+			First(&transfer).Error; err != nil {
+			return nil
+		}
+		ref := transferRef{AgentID: transfer.AgentID, TeamID: transfer.TeamID}
+		visible = func(s *conversationScope) bool { return s.followsTransfer(ref) }
+	} else {
+		var ok bool
+		if visible, ok = a.contactVisibility(msg.OrgID, msg.ScopeContactID); !ok {
+			return nil
+		}
+	}
+
+	audience := make([]uuid.UUID, 0, len(userIDs))
+	for userID, scope := range a.conversationScopes(msg.OrgID, userIDs) {
+		if visible(scope) {
+			audience = append(audience, userID)
+		}
+	}
+	return audience
+}
+
+// contactVisibility loads what decides who sees the contact's conversation and
+// returns the check for a scope. ok is false when the contact does not exist.
+func (a *App) contactVisibility(orgID, contactID uuid.UUID) (visible func(*conversationScope) bool, ok bool) {
+	var contact models.Contact
+	if err := a.DB.Select("assigned_user_id").
+		Where("id = ? AND organization_id = ?", contactID, orgID).
+		First(&contact).Error; err != nil {
+		return nil, false
+	}
+	var transfers []models.AgentTransfer
+	a.DB.Select("agent_id, team_id").
+		Where("contact_id = ? AND organization_id = ? AND status = ?", contactID, orgID, models.TransferStatusActive).
+		Find(&transfers)
+	refs := make([]transferRef, len(transfers))
+	for i, t := range transfers {
+		refs[i] = transferRef{AgentID: t.AgentID, TeamID: t.TeamID}
+	}
+	return func(s *conversationScope) bool { return s.canSeeContact(contact.AssignedUserID, refs) }, true
+}
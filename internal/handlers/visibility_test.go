@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversationScope_CanSeeTransfer(t *testing.T) {
+	user := uuid.New()
+	teammate := uuid.New()
+	stranger := uuid.New()
+	team := uuid.New()
+	managedTeam := uuid.New()
+	otherTeam := uuid.New()
+
+	agent := &conversationScope{UserID: user, TeamIDs: []uuid.UUID{team}}
+	manager := &conversationScope{
+		UserID:         user,
+		TeamIDs:        []uuid.UUID{team, managedTeam},
+		ManagedTeamIDs: []uuid.UUID{managedTeam},
+		ManagedUserIDs: []uuid.UUID{user, teammate},
+	}
+	admin := &conversationScope{UserID: user, All: true}
+
+	tests := []struct {
+		name    string
+		ref     transferRef
+		agent   bool
+		manager bool
+	}{
+		{"own transfer", transferRef{AgentID: &user, TeamID: &otherTeam}, true, true},
+		{"general queue", transferRef{}, true, true},
+		{"own team queue", transferRef{TeamID: &team}, true, true},
+		{"other team queue", transferRef{TeamID: &otherTeam}, false, false},
+		{"assigned to teammate", transferRef{AgentID: &teammate, TeamID: &otherTeam}, false, true},
+		{"assigned in managed team", transferRef{AgentID: &stranger, TeamID: &managedTeam}, false, true},
+		{"assigned to someone else", transferRef{AgentID: &stranger, TeamID: &team}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.agent, agent.canSeeTransfer(tt.ref), "agent")
+			assert.Equal(t, tt.manager, manager.canSeeTransfer(tt.ref), "manager")
+			assert.True(t, admin.canSeeTransfer(tt.ref), "admin")
+		})
+	}
+}
+
+func TestConversationScope_FollowsTransfer(t *testing.T) {
+	user := uuid.New()
+	other := uuid.New()
+	team := uuid.New()
+	otherTeam := uuid.New()
+	scope := &conversationScope{UserID: user, TeamIDs: []uuid.UUID{team}}
+
+	// A transfer picked from the user's queue still reaches them so it leaves the queue
+	assert.True(t, scope.followsTransfer(transferRef{AgentID: &other, TeamID: &team}))
+	assert.True(t, scope.followsTransfer(transferRef{AgentID: &other}))
+	assert.False(t, scope.followsTransfer(transferRef{AgentID: &other, TeamID: &otherTeam}))
+}
+
+func TestConversationScope_CanSeeContact(t *testing.T) {
+	user := uuid.New()
+	other := uuid.New()
+	team := uuid.New()
+	otherTeam := uuid.New()
+	scope := &conversationScope{UserID: user, TeamIDs: []uuid.UUID{team}}
+
+	assert.True(t, scope.canSeeContact(&user, nil))
+	assert.False(t, scope.canSeeContact(&other, nil))
+	assert.False(t, scope.canSeeContact(nil, nil))
+	assert.True(t, scope.canSeeContact(&other, []transferRef{{TeamID: &team}}))
+	assert.False(t, scope.canSeeContact(nil, []transferRef{{TeamID: &otherTeam}}))
+
+	manager := &conversationScope{UserID: user, ManagedUserIDs: []uuid.UUID{other}}
+	assert.True(t, manager.canSeeContact(&other, nil))
+}
+
+func TestConversationScope_Conditions(t *testing.T) {
+	user := uuid.New()
+	team := uuid.New()
+	member := uuid.New()
+
+	cond, args := (&conversationScope{UserID: user}).transferCondition("agent_transfers")
+	assert.Equal(t, "(agent_transfers.agent_id = ? OR (agent_transfers.agent_id IS NULL AND agent_transfers.team_id IS NULL))", cond)
+	assert.Equal(t, []any{user}, args)
+
+	manager := &conversationScope{
+		UserID:         user,
+		TeamIDs:        []uuid.UUID{team},
+		ManagedTeamIDs: []uuid.UUID{team},
+		ManagedUserIDs: []uuid.UUID{member},
+	}
+	cond, args = manager.transferCondition("t")
+	assert.Contains(t, cond, "t.team_id IN ?")
+	assert.Equal(t, []any{user, []uuid.UUID{team}, []uuid.UUID{team}, []uuid.UUID{member}}, args)
+
+	cond, args = manager.contactCondition()
+	assert.Contains(t, cond, "EXISTS (SELECT 1 FROM agent_transfers visible_transfers")
+	assert.Equal(t, []any{
+		user, []uuid.UUID{member}, models.TransferStatusActive,
+		user, []uuid.UUID{team}, []uuid.UUID{team}, []uuid.UUID{member},
+	}, args)
+}
@@ -6,7 +6,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"strings"
 	"time"
 
 	"github.com/shridarpatil/whatomate/internal/models"
@@ -87,105 +86,69 @@ type WebhookPayload struct {
 	Entry  []struct {
 		ID      string `json:"id"`
 		Changes []struct {
-			Value struct {
-				MessagingProduct string `json:"messaging_product"`
-				Metadata         struct {
-					DisplayPhoneNumber string `json:"display_phone_number"`
-					PhoneNumberID      string `json:"phone_number_id"`
-				} `json:"metadata"`
-				// Template status update fields (when field == "message_template_status_update")
-				Event                   string `json:"event,omitempty"`
-				MessageTemplateID       int64  `json:"message_template_id,omitempty"`
-				MessageTemplateName     string `json:"message_template_name,omitempty"`
-				MessageTemplateLanguage string `json:"message_template_language,omitempty"`
-				Reason                  string `json:"reason,omitempty"`
-				Contacts                []struct {
-					Profile struct {
-						Name string `json:"name"`
-					} `json:"profile"`
-					WaID string `json:"wa_id"`
-				} `json:"contacts"`
-				Messages []struct {
-					From      string `json:"from"`
-					ID        string `json:"id"`
-					Timestamp string `json:"timestamp"`
-					Type      string `json:"type"`
-					Text      *struct {
-						Body string `json:"body"`
-					} `json:"text,omitempty"`
-					Image *struct {
-						ID       string `json:"id"`
-						MimeType string `json:"mime_type"`
-						SHA256   string `json:"sha256"`
-						Caption  string `json:"caption,omitempty"`
-					} `json:"image,omitempty"`
-					Document *struct {
-						ID       string `json:"id"`
-						MimeType string `json:"mime_type"`
-						SHA256   string `json:"sha256"`
-						Filename string `json:"filename"`
-						Caption  string `json:"caption,omitempty"`
-					} `json:"document,omitempty"`
-					Audio *struct {
-						ID       string `json:"id"`
-						MimeType string `json:"mime_type"`
-					} `json:"audio,omitempty"`
-					Video *struct {
-						ID       string `json:"id"`
-						MimeType string `json:"mime_type"`
-						SHA256   string `json:"sha256"`
-						Caption  string `json:"caption,omitempty"`
-					} `json:"video,omitempty"`
-					Interactive *struct {
-						Type        string `json:"type"`
-						ButtonReply *struct {
-							ID    string `json:"id"`
-							Title string `json:"title"`
-						} `json:"button_reply,omitempty"`
-						ListReply *struct {
-							ID          string `json:"id"`
-							Title       string `json:"title"`
-							Description string `json:"description"`
-						} `json:"list_reply,omitempty"`
-						NFMReply *struct {
-							ResponseJSON string `json:"response_json"`
-							Body         string `json:"body"`
-							Name         string `json:"name"`
-						} `json:"nfm_reply,omitempty"`
-					} `json:"interactive,omitempty"`
-					Reaction *struct {
-						MessageID string `json:"message_id"`
-						Emoji     string `json:"emoji"`
-					} `json:"reaction,omitempty"`
-					Location *struct {
-						Latitude  float64 `json:"latitude"`
-						Longitude float64 `json:"longitude"`
-						Name      string  `json:"name,omitempty"`
-						Address   string  `json:"address,omitempty"`
-					} `json:"location,omitempty"`
-					Contacts []struct {
-						Name struct {
-							FormattedName string `json:"formatted_name"`
-							FirstName     string `json:"first_name,omitempty"`
-							LastName      string `json:"last_name,omitempty"`
-						} `json:"name"`
-						Phones []struct {
-							Phone string `json:"phone"`
-							Type  string `json:"type,omitempty"`
-						} `json:"phones,omitempty"`
-					} `json:"contacts,omitempty"`
-					Context *struct {
-						From string `json:"from"`
-						ID   string `json:"id"`
-					} `json:"context,omitempty"`
-				} `json:"messages,omitempty"`
-				Statuses []WebhookStatus `json:"statuses,omitempty"`
-			} `json:"value"`
-			Field string `json:"field"`
+			Value WebhookChangeValue `json:"value"`
+			Field string             `json:"field"`
 		} `json:"changes"`
 	} `json:"entry"`
 }
 
+// WebhookChangeValue is the value of a webhook change. Which fields are set
+// depends on the change's field.
+type WebhookChangeValue struct {
+	MessagingProduct string `json:"messaging_product"`
+	Metadata         struct {
+		DisplayPhoneNumber string `json:"display_phone_number"`
+		PhoneNumberID      string `json:"phone_number_id"`
+	} `json:"metadata"`
+	// Template status update fields (when field == "message_template_status_update")
+	Event                   string `json:"event,omitempty"`
+	MessageTemplateID       int64  `json:"message_template_id,omitempty"`
+	MessageTemplateName     string `json:"message_template_name,omitempty"`
+	MessageTemplateLanguage string `json:"message_template_language,omitempty"`
+	Reason                  string `json:"reason,omitempty"`
+	OtherInfo               *struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	} `json:"other_info,omitempty"`
+	// Template quality and category fields
+	PreviousQualityScore string `json:"previous_quality_score,omitempty"`
+	NewQualityScore      string `json:"new_quality_score,omitempty"`
+	PreviousCategory     string `json:"previous_category,omitempty"`
+	NewCategory          string `json:"new_category,omitempty"`
+	// Phone number fields (phone_number_quality_update, phone_number_name_update)
+	DisplayPhoneNumber    string `json:"display_phone_number,omitempty"`
+	CurrentLimit          string `json:"current_limit,omitempty"`
+	Decision              string `json:"decision,omitempty"`
+	RequestedVerifiedName string `json:"requested_verified_name,omitempty"`
+	RejectionReason       string `json:"rejection_reason,omitempty"`
+	// Account fields (account_update)
+	PhoneNumber string `json:"phone_number,omitempty"`
+	BanInfo     *struct {
+		WabaBanState webhookStringList `json:"waba_ban_state"`
+		WabaBanDate  string            `json:"waba_ban_date"`
+	} `json:"ban_info,omitempty"`
+	RestrictionInfo []struct {
+		RestrictionType string `json:"restriction_type"`
+		Expiration      string `json:"expiration"`
+	} `json:"restriction_info,omitempty"`
+	ViolationInfo *struct {
+		ViolationType string `json:"violation_type"`
+	} `json:"violation_info,omitempty"`
+	// Business capability fields (business_capability_update)
+	MaxDailyConversationPerPhone int64 `json:"max_daily_conversation_per_phone,omitempty"`
+	MaxPhoneNumbersPerBusiness   int64 `json:"max_phone_numbers_per_business,omitempty"`
+	MaxPhoneNumbersPerWaba       int64 `json:"max_phone_numbers_per_waba,omitempty"`
+
+	Contacts []struct {
+		Profile struct {
+			Name string `json:"name"`
+		} `json:"profile"`
+		WaID string `json:"wa_id"`
+	} `json:"contacts"`
+	Messages []IncomingTextMessage `json:"messages,omitempty"`
+	Statuses []WebhookStatus       `json:"statuses,omitempty"`
+}
+
 // WebhookHandler processes incoming webhook events from Meta
 func (a *App) WebhookHandler(r *fastglue.Request) error {
 	body := r.RequestCtx.PostBody()
@@ -211,7 +174,29 @@ func (a *App) WebhookHandler(r *fastglue.Request) error {
 					"template_language", change.Value.MessageTemplateLanguage,
 					"waba_id", entry.ID,
 				)
-				go a.processTemplateStatusUpdate(entry.ID, change.Value.Event, change.Value.MessageTemplateName, change.Value.MessageTemplateLanguage, change.Value.Reason)
+				go a.processTemplateStatusUpdate(entry.ID, change.Value)
+				continue
+			}
+
+			// Account, phone number and template health updates
+			switch change.Field {
+			case "account_update":
+				go a.processAccountUpdate(entry.ID, change.Value)
+				continue
+			case "phone_number_quality_update":
+				go a.processPhoneQualityUpdate(entry.ID, change.Value)
+				continue
+			case "phone_number_name_update":
+				go a.processPhoneNameUpdate(entry.ID, change.Value)
+				continue
+			case "business_capability_update":
+				go a.processBusinessCapabilityUpdate(entry.ID, change.Value)
+				continue
+			case "message_template_quality_update":
+				go a.processTemplateQualityUpdate(entry.ID, change.Value)
+				continue
+			case "template_category_update":
+				go a.processTemplateCategoryUpdate(entry.ID, change.Value)
 				continue
 			}
 
@@ -271,20 +256,7 @@ func (a *App) WebhookHandler(r *fastglue.Request) error {
 	return r.SendEnvelope(map[string]string{"status": "ok"})
 }
 
-func (a *App) processIncomingMessage(phoneNumberID string, msg interface{}, profileName string) {
-	// Convert msg interface to the message struct
-	msgBytes, err := json.Marshal(msg)
-	if err != nil {
-		a.Log.Error("Failed to marshal message", "error", err)
-		return
-	}
-
-	var textMsg IncomingTextMessage
-	if err := json.Unmarshal(msgBytes, &textMsg); err != nil {
-		a.Log.Error("Failed to unmarshal message", "error", err)
-		return
-	}
-
+func (a *App) processIncomingMessage(phoneNumberID string, textMsg IncomingTextMessage, profileName string) {
 	// Check for duplicate message - Meta sometimes sends the same message multiple times
 	if textMsg.ID != "" {
 		var existingMsg models.Message
@@ -398,7 +370,7 @@ func (a *App) updateMessageStatus(whatsappMsgID, statusValue string, errors []We
 
 	// Broadcast status update via WebSocket
 	if a.WSHub != nil {
-		a.WSHub.BroadcastToOrg(message.OrganizationID, websocket.WSMessage{
+		a.WSHub.BroadcastToConversation(message.OrganizationID, message.ContactID, websocket.WSMessage{
 			Type: websocket.TypeStatusUpdate,
 			Payload: map[string]any{
 				"message_id": message.ID.String(),
@@ -408,58 +380,6 @@ func (a *App) updateMessageStatus(whatsappMsgID, statusValue string, errors []We
 	}
 }
 
-// processTemplateStatusUpdate updates template status when Meta sends a status update webhook
-func (a *App) processTemplateStatusUpdate(wabaID, event, templateName, templateLanguage, reason string) {
-	if templateName == "" {
-		a.Log.Warn("Template status update missing template name")
-		return
-	}
-
-	// Keep status uppercase to match existing template status format
-	// Events: APPROVED, REJECTED, PENDING, DISABLED, PENDING_DELETION, DELETED, REINSTATED, FLAGGED
-	status := strings.ToUpper(event)
-
-	// Find WhatsApp accounts that use this WABA ID (business_id field)
-	var accounts []models.WhatsAppAccount
-	if err := a.DB.Where("business_id = ?", wabaID).Find(&accounts).Error; err != nil {
-		a.Log.Error("Failed to find WhatsApp accounts for WABA", "error", err, "waba_id", wabaID)
-		return
-	}
-
-	if len(accounts) == 0 {
-		a.Log.Warn("No WhatsApp accounts found for WABA", "waba_id", wabaID)
-		return
-	}
-
-	// Update template for each account that has it
-	for _, account := range accounts {
-		// Find and update the template
-		result := a.DB.Model(&models.Template{}).
-			Where("whats_app_account = ? AND name = ? AND language = ?", account.Name, templateName, templateLanguage).
-			Update("status", status)
-
-		if result.Error != nil {
-			a.Log.Error("Failed to update template status",
-				"error", result.Error,
-				"account", account.Name,
-				"template", templateName,
-				"language", templateLanguage,
-			)
-			continue
-		}
-
-		if result.RowsAffected > 0 {
-			a.Log.Info("Updated template status from webhook",
-				"account", account.Name,
-				"template", templateName,
-				"language", templateLanguage,
-				"status", status,
-				"reason", reason,
-			)
-		}
-	}
-}
-
 // verifyWebhookSignature verifies the X-Hub-Signature-256 header from Meta.
 // The signature is HMAC-SHA256 of the request body using the App Secret.
 func verifyWebhookSignature(body, signature, appSecret []byte) bool {
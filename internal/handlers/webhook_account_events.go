@@ -0,0 +1,493 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// Template statuses after which campaigns using the template are paused
+var templateStatusesPausingCampaigns = map[string]bool{
+	"FLAGGED":  true,
+	"PAUSED":   true,
+	"DISABLED": true,
+}
+
+// webhookStringList accepts a string or a list of strings; Meta sends both
+// forms for some fields
+type webhookStringList []string
+
+func (l *webhookStringList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*l = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s != "" {
+		*l = []string{s}
+	}
+	return nil
+}
+
+// webhookAccounts returns the accounts of a WABA. Phone number events are
+// narrowed to the account with that display number. When no account has it,
+// e.g. because it was never tested, the event only applies to a WABA with a
+// single account.
+func (a *App) webhookAccounts(wabaID, displayPhoneNumber string) []models.WhatsAppAccount {
+	var accounts []models.WhatsAppAccount
+	if err := a.DB.Where("business_id = ?", wabaID).Find(&accounts).Error; err != nil {
+		a.Log.Error("Failed to find WhatsApp accounts for WABA", "error", err, "waba_id", wabaID)
+		return nil
+	}
+	if len(accounts) == 0 {
+		a.Log.Warn("No WhatsApp accounts found for WABA", "waba_id", wabaID)
+		return nil
+	}
+
+	if phone := phoneDigits(displayPhoneNumber); phone != "" {
+		var matched []models.WhatsAppAccount
+		for _, account := range accounts {
+			if phoneDigits(account.DisplayPhoneNumber) == phone {
+				matched = append(matched, account)
+			}
+		}
+		if len(matched) > 0 {
+			return matched
+		}
+		if len(accounts) > 1 {
+			a.Log.Warn("No WhatsApp account matches the webhook's phone number, skipping",
+				"waba_id", wabaID, "phone_number", displayPhoneNumber, "accounts", len(accounts))
+			return nil
+		}
+	}
+	return accounts
+}
+
+// phoneDigits strips formatting from a phone number, e.g. "+1 555-0100"
+func phoneDigits(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+}
+
+// updateAccountHealth saves health fields reported by Meta on an account
+func (a *App) updateAccountHealth(account *models.WhatsAppAccount, updates map[string]any) bool {
+	updates["health_updated_at"] = time.Now()
+	if err := a.DB.Model(account).Updates(updates).Error; err != nil {
+		a.Log.Error("Failed to update WhatsApp account health", "error", err, "account", account.Name)
+		return false
+	}
+	a.InvalidateWhatsAppAccountCache(account.PhoneID)
+	return true
+}
+
+// processAccountUpdate handles account_update: verification, violations,
+// restrictions and bans of the business account
+func (a *App) processAccountUpdate(wabaID string, value WebhookChangeValue) {
+	updates := map[string]any{"account_event": value.Event}
+	details := map[string]any{}
+	if value.PhoneNumber != "" {
+		details["phone_number"] = value.PhoneNumber
+	}
+	if value.ViolationInfo != nil && value.ViolationInfo.ViolationType != "" {
+		details["violation_type"] = value.ViolationInfo.ViolationType
+	}
+	if value.BanInfo != nil && len(value.BanInfo.WabaBanState) > 0 {
+		updates["ban_state"] = value.BanInfo.WabaBanState[0]
+		details["ban_state"] = value.BanInfo.WabaBanState[0]
+		details["ban_date"] = value.BanInfo.WabaBanDate
+	}
+	if len(value.RestrictionInfo) > 0 {
+		restrictions := make(models.JSONBArray, 0, len(value.RestrictionInfo))
+		for _, r := range value.RestrictionInfo {
+			restrictions = append(restrictions, map[string]any{
+				"restriction_type": r.RestrictionType,
+				"expiration":       r.Expiration,
+			})
+		}
+		updates["restrictions"] = restrictions
+		details["restrictions"] = restrictions
+	}
+
+	body := value.Event
+	if v, ok := details["violation_type"]; ok {
+		body += fmt.Sprintf(" (violation: %v)", v)
+	}
+	if v, ok := details["ban_state"]; ok {
+		body += fmt.Sprintf(" (ban: %v)", v)
+	}
+
+	for _, account := range a.webhookAccounts(wabaID, value.PhoneNumber) {
+		if !a.updateAccountHealth(&account, updates) {
+			continue
+		}
+		a.Log.Info("Updated WhatsApp account from webhook", "account", account.Name, "event", value.Event)
+		a.notifyUsersWithPermission(account.OrganizationID, models.ResourceAccounts, models.ActionWrite,
+			models.NotificationTypeAccount, "Account update for "+account.Name, body)
+		a.dispatchAccountWebhook(&account, "account_update", value.Event, details)
+	}
+}
+
+// processPhoneQualityUpdate handles phone_number_quality_update: the number
+// being flagged for low quality and messaging limit changes
+func (a *App) processPhoneQualityUpdate(wabaID string, value WebhookChangeValue) {
+	updates := map[string]any{"quality_event": value.Event}
+	details := map[string]any{"display_phone_number": value.DisplayPhoneNumber}
+	if value.CurrentLimit != "" {
+		updates["messaging_limit_tier"] = value.CurrentLimit
+		details["messaging_limit_tier"] = value.CurrentLimit
+	}
+
+	body := "Quality event " + value.Event
+	if value.CurrentLimit != "" {
+		body += ", messaging limit " + value.CurrentLimit
+	}
+
+	accounts := a.webhookAccounts(wabaID, value.DisplayPhoneNumber)
+	for _, account := range accounts {
+		accountUpdates := updates
+		if account.DisplayPhoneNumber == "" && len(accounts) == 1 && value.DisplayPhoneNumber != "" {
+			accountUpdates = map[string]any{"display_phone_number": value.DisplayPhoneNumber}
+			for k, v := range updates {
+				accountUpdates[k] = v
+			}
+		}
+		if !a.updateAccountHealth(&account, accountUpdates) {
+			continue
+		}
+		a.Log.Info("Updated phone number quality from webhook", "account", account.Name,
+			"event", value.Event, "current_limit", value.CurrentLimit)
+		a.notifyUsersWithPermission(account.OrganizationID, models.ResourceAccounts, models.ActionWrite,
+			models.NotificationTypeAccount, "Phone number quality update for "+account.Name, body)
+		a.dispatchAccountWebhook(&account, "phone_number_quality_update", value.Event, details)
+	}
+}
+
+// processPhoneNameUpdate handles phone_number_name_update: the review
+// decision on a requested display name
+func (a *App) processPhoneNameUpdate(wabaID string, value WebhookChangeValue) {
+	updates := map[string]any{
+		"name_status":           value.Decision,
+		"name_rejection_reason": value.RejectionReason,
+	}
+	if value.Decision == "APPROVED" && value.RequestedVerifiedName != "" {
+		updates["verified_name"] = value.RequestedVerifiedName
+	}
+	details := map[string]any{
+		"display_phone_number":    value.DisplayPhoneNumber,
+		"requested_verified_name": value.RequestedVerifiedName,
+	}
+	body := fmt.Sprintf("Display name %q: %s", value.RequestedVerifiedName, value.Decision)
+	if value.RejectionReason != "" && value.RejectionReason != "NONE" {
+		details["rejection_reason"] = value.RejectionReason
+		body += " (" + value.RejectionReason + ")"
+	}
+
+	for _, account := range a.webhookAccounts(wabaID, value.DisplayPhoneNumber) {
+		if !a.updateAccountHealth(&account, updates) {
+			continue
+		}
+		a.Log.Info("Updated display name status from webhook", "account", account.Name, "decision", value.Decision)
+		a.notifyUsersWithPermission(account.OrganizationID, models.ResourceAccounts, models.ActionWrite,
+			models.NotificationTypeAccount, "Display name review for "+account.Name, body)
+		a.dispatchAccountWebhook(&account, "phone_number_name_update", value.Decision, details)
+	}
+}
+
+// processBusinessCapabilityUpdate handles business_capability_update: changes
+// to the conversation and phone number limits of the business
+func (a *App) processBusinessCapabilityUpdate(wabaID string, value WebhookChangeValue) {
+	capabilities := models.JSONB{}
+	if value.MaxDailyConversationPerPhone > 0 {
+		capabilities["max_daily_conversation_per_phone"] = value.MaxDailyConversationPerPhone
+	}
+	if value.MaxPhoneNumbersPerBusiness > 0 {
+		capabilities["max_phone_numbers_per_business"] = value.MaxPhoneNumbersPerBusiness
+	}
+	if value.MaxPhoneNumbersPerWaba > 0 {
+		capabilities["max_phone_numbers_per_waba"] = value.MaxPhoneNumbersPerWaba
+	}
+	if len(capabilities) == 0 {
+		return
+	}
+
+	body := ""
+	if value.MaxDailyConversationPerPhone > 0 {
+		body = fmt.Sprintf("Up to %d business-initiated conversations per phone number per day", value.MaxDailyConversationPerPhone)
+	}
+
+	for _, account := range a.webhookAccounts(wabaID, "") {
+		merged := models.JSONB{}
+		for k, v := range account.Capabilities {
+			merged[k] = v
+		}
+		for k, v := range capabilities {
+			merged[k] = v
+		}
+		if !a.updateAccountHealth(&account, map[string]any{"capabilities": merged}) {
+			continue
+		}
+		a.Log.Info("Updated business capabilities from webhook", "account", account.Name)
+		if body != "" {
+			a.notifyUsersWithPermission(account.OrganizationID, models.ResourceAccounts, models.ActionWrite,
+				models.NotificationTypeAccount, "Messaging limits updated for "+account.Name, body)
+		}
+		a.dispatchAccountWebhook(&account, "business_capability_update", "", capabilities)
+	}
+}
+
+// webhookTemplates returns the templates a template webhook refers to, by
+// Meta template ID or by name and language
+func (a *App) webhookTemplates(wabaID string, value WebhookChangeValue) []models.Template {
+	accounts := a.webhookAccounts(wabaID, "")
+	if len(accounts) == 0 {
+		return nil
+	}
+	// Match each account's own templates, not those of another organization
+	// that happens to have an account with the same name
+	pairs := make([][]any, len(accounts))
+	for i, account := range accounts {
+		pairs[i] = []any{account.OrganizationID, account.Name}
+	}
+
+	query := a.DB.Where("(organization_id, whats_app_account) IN ?", pairs)
+	if value.MessageTemplateID != 0 {
+		query = query.Where("(meta_template_id = ? OR (name = ? AND language = ?))",
+			strconv.FormatInt(value.MessageTemplateID, 10), value.MessageTemplateName, value.MessageTemplateLanguage)
+	} else {
+		query = query.Where("name = ? AND language = ?", value.MessageTemplateName, value.MessageTemplateLanguage)
+	}
+
+	var templates []models.Template
+	if err := query.Find(&templates).Error; err != nil {
+		a.Log.Error("Failed to find templates for webhook", "error", err, "template", value.MessageTemplateName)
+		return nil
+	}
+	if len(templates) == 0 {
+		a.Log.Warn("No templates found for webhook", "template", value.MessageTemplateName,
+			"language", value.MessageTemplateLanguage, "waba_id", wabaID)
+	}
+	return templates
+}
+
+// processTemplateStatusUpdate updates template status when Meta sends a status update webhook
+func (a *App) processTemplateStatusUpdate(wabaID string, value WebhookChangeValue) {
+	if value.MessageTemplateName == "" {
+		a.Log.Warn("Template status update missing template name")
+		return
+	}
+
+	// Keep status uppercase to match existing template status format
+	// Events: APPROVED, REJECTED, PENDING, DISABLED, PAUSED, PENDING_DELETION, DELETED, REINSTATED, FLAGGED
+	status := strings.ToUpper(value.Event)
+	reason := templateStatusReason(value)
+
+	for _, tmpl := range a.webhookTemplates(wabaID, value) {
+		if err := a.DB.Model(&tmpl).Updates(map[string]any{
+			"status":            status,
+			"rejection_reason":  reason,
+			"status_updated_at": time.Now(),
+		}).Error; err != nil {
+			a.Log.Error("Failed to update template status", "error", err, "template_id", tmpl.ID)
+			continue
+		}
+		a.Log.Info("Updated template status from webhook",
+			"account", tmpl.WhatsAppAccount,
+			"template", tmpl.Name,
+			"language", tmpl.Language,
+			"status", status,
+			"reason", reason,
+		)
+
+		paused := 0
+		if templateStatusesPausingCampaigns[status] {
+			paused = a.pauseTemplateCampaigns(&tmpl)
+		}
+
+		if status != "PENDING" {
+			body := reason
+			if paused > 0 {
+				body = strings.TrimSpace(fmt.Sprintf("%s %d running campaign(s) paused.", sentence(body), paused))
+			}
+			a.notifyUsersWithPermission(tmpl.OrganizationID, models.ResourceTemplates, models.ActionWrite,
+				models.NotificationTypeTemplate, fmt.Sprintf("Template %s (%s) is %s", tmpl.Name, tmpl.Language, status), body)
+		}
+
+		data := templateEventData(&tmpl, "message_template_status_update")
+		data.Status = status
+		data.Reason = reason
+		data.PausedCampaigns = paused
+		a.DispatchWebhook(tmpl.OrganizationID, models.WebhookEventTemplateUpdated, data)
+	}
+}
+
+// processTemplateQualityUpdate handles message_template_quality_update.
+// Campaigns are paused when a template's quality drops to RED.
+func (a *App) processTemplateQualityUpdate(wabaID string, value WebhookChangeValue) {
+	score := strings.ToUpper(value.NewQualityScore)
+
+	for _, tmpl := range a.webhookTemplates(wabaID, value) {
+		if err := a.DB.Model(&tmpl).Update("quality_score", score).Error; err != nil {
+			a.Log.Error("Failed to update template quality", "error", err, "template_id", tmpl.ID)
+			continue
+		}
+		a.Log.Info("Updated template quality from webhook", "template", tmpl.Name,
+			"previous", value.PreviousQualityScore, "quality", score)
+
+		paused := 0
+		if score == "RED" {
+			paused = a.pauseTemplateCampaigns(&tmpl)
+		}
+
+		body := fmt.Sprintf("Quality changed from %s to %s.", value.PreviousQualityScore, score)
+		if paused > 0 {
+			body += fmt.Sprintf(" %d running campaign(s) paused.", paused)
+		}
+		a.notifyUsersWithPermission(tmpl.OrganizationID, models.ResourceTemplates, models.ActionWrite,
+			models.NotificationTypeTemplate, fmt.Sprintf("Template %s (%s) quality is %s", tmpl.Name, tmpl.Language, score), body)
+
+		data := templateEventData(&tmpl, "message_template_quality_update")
+		data.QualityScore = score
+		data.PausedCampaigns = paused
+		a.DispatchWebhook(tmpl.OrganizationID, models.WebhookEventTemplateUpdated, data)
+	}
+}
+
+// processTemplateCategoryUpdate handles template_category_update, sent when
+// Meta recategorizes a template
+func (a *App) processTemplateCategoryUpdate(wabaID string, value WebhookChangeValue) {
+	if value.NewCategory == "" {
+		return
+	}
+	category := strings.ToUpper(value.NewCategory)
+
+	for _, tmpl := range a.webhookTemplates(wabaID, value) {
+		previous := value.PreviousCategory
+		if previous == "" {
+			previous = tmpl.Category
+		}
+		if err := a.DB.Model(&tmpl).Updates(map[string]any{
+			"category":          category,
+			"previous_category": previous,
+		}).Error; err != nil {
+			a.Log.Error("Failed to update template category", "error", err, "template_id", tmpl.ID)
+			continue
+		}
+		a.Log.Info("Updated template category from webhook", "template", tmpl.Name,
+			"previous", previous, "category", category)
+
+		a.notifyUsersWithPermission(tmpl.OrganizationID, models.ResourceTemplates, models.ActionWrite,
+			models.NotificationTypeTemplate, fmt.Sprintf("Template %s (%s) recategorized", tmpl.Name, tmpl.Language),
+			fmt.Sprintf("Category changed from %s to %s.", previous, category))
+
+		data := templateEventData(&tmpl, "template_category_update")
+		data.Category = category
+		data.PreviousCategory = previous
+		a.DispatchWebhook(tmpl.OrganizationID, models.WebhookEventTemplateUpdated, data)
+	}
+}
+
+// templateStatusReason combines the rejection reason and the explanation Meta
+// sends for disabled or paused templates
+func templateStatusReason(value WebhookChangeValue) string {
+	var parts []string
+	if value.Reason != "" && value.Reason != "NONE" {
+		parts = append(parts, value.Reason)
+	}
+	if value.OtherInfo != nil {
+		if value.OtherInfo.Title != "" {
+			parts = append(parts, value.OtherInfo.Title)
+		}
+		if value.OtherInfo.Description != "" {
+			parts = append(parts, value.OtherInfo.Description)
+		}
+	}
+	return strings.Join(parts, ": ")
+}
+
+// sentence ends non-empty text with a period
+func sentence(s string) string {
+	if s == "" || strings.HasSuffix(s, ".") {
+		return s
+	}
+	return s + "."
+}
+
+// pauseTemplateCampaigns pauses the running campaigns that send a template
+// and returns how many were paused
+func (a *App) pauseTemplateCampaigns(tmpl *models.Template) int {
+	result := a.DB.Model(&models.BulkMessageCampaign{}).
+		Where("organization_id = ? AND template_id = ? AND status IN ?", tmpl.OrganizationID, tmpl.ID,
+			[]models.CampaignStatus{models.CampaignStatusQueued, models.CampaignStatusProcessing}).
+		Update("status", models.CampaignStatusPaused)
+	if result.Error != nil {
+		a.Log.Error("Failed to pause campaigns for template", "error", result.Error, "template_id", tmpl.ID)
+		return 0
+	}
+	if result.RowsAffected > 0 {
+		a.Log.Warn("Paused campaigns for template", "template", tmpl.Name, "campaigns", result.RowsAffected)
+	}
+	return int(result.RowsAffected)
+}
+
+func templateEventData(tmpl *models.Template, field string) TemplateEventData {
+	return TemplateEventData{
+		TemplateID:      tmpl.ID.String(),
+		Name:            tmpl.Name,
+		Language:        tmpl.Language,
+		WhatsAppAccount: tmpl.WhatsAppAccount,
+		Field:           field,
+		Status:          tmpl.Status,
+		QualityScore:    tmpl.QualityScore,
+		Category:        tmpl.Category,
+	}
+}
+
+func (a *App) dispatchAccountWebhook(account *models.WhatsAppAccount, field, event string, details map[string]any) {
+	a.DispatchWebhook(account.OrganizationID, models.WebhookEventAccountUpdated, AccountEventData{
+		AccountID:       account.ID.String(),
+		WhatsAppAccount: account.Name,
+		BusinessID:      account.BusinessID,
+		Field:           field,
+		Event:           event,
+		Details:         details,
+	})
+}
+
+// notifyUsersWithPermission notifies the active members of an organization
+// who have a permission
+func (a *App) notifyUsersWithPermission(orgID uuid.UUID, resource, action string, typ models.NotificationType, title, body string) {
+	var userIDs []uuid.UUID
+	a.DB.Model(&models.UserOrganization{}).
+		Joins("JOIN users ON users.id = user_organizations.user_id AND users.deleted_at IS NULL").
+		Where("user_organizations.organization_id = ? AND users.is_active = ?", orgID, true).
+		Pluck("user_organizations.user_id", &userIDs)
+
+	notifications := make([]models.UserNotification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if !a.HasPermission(userID, resource, action, orgID) {
+			continue
+		}
+		notifications = append(notifications, models.UserNotification{
+			BaseModel:      models.BaseModel{ID: uuid.New()},
+			OrganizationID: orgID,
+			UserID:         userID,
+			Type:           typ,
+			Title:          title,
+			Body:           body,
+		})
+	}
+	a.notifyUsers(notifications)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookChangeValue_AccountUpdate(t *testing.T) {
+	// waba_ban_state arrives both as a list and as a single string
+	for _, banState := range []string{`["DISABLE"]`, `"DISABLE"`} {
+		var value WebhookChangeValue
+		payload := `{"phone_number":"15550100","event":"DISABLED_UPDATE","ban_info":{"waba_ban_state":` + banState + `,"waba_ban_date":"2024-05-01"}}`
+		require.NoError(t, json.Unmarshal([]byte(payload), &value))
+		require.NotNil(t, value.BanInfo)
+		assert.Equal(t, webhookStringList{"DISABLE"}, value.BanInfo.WabaBanState)
+	}
+}
+
+func TestTemplateStatusReason(t *testing.T) {
+	assert.Empty(t, templateStatusReason(WebhookChangeValue{Reason: "NONE"}))
+	assert.Equal(t, "INVALID_FORMAT", templateStatusReason(WebhookChangeValue{Reason: "INVALID_FORMAT"}))
+
+	var value WebhookChangeValue
+	require.NoError(t, json.Unmarshal([]byte(`{"event":"PAUSED","reason":"NONE","other_info":{"title":"FIRST_PAUSE","description":"Paused for 3 hours due to low quality"}}`), &value))
+	assert.Equal(t, "FIRST_PAUSE: Paused for 3 hours due to low quality", templateStatusReason(value))
+}
+
+func TestPhoneDigits(t *testing.T) {
+	assert.Equal(t, "15550100", phoneDigits("+1 555-0100"))
+	assert.Empty(t, phoneDigits(""))
+}
+
+func accountEventsTestApp(t *testing.T) *App {
+	t.Helper()
+	app := webhookTestApp(t)
+	app.Redis = testutil.SetupTestRedis(t)
+	if app.Redis == nil {
+		t.Skip("TEST_REDIS_URL not set, skipping test")
+	}
+	return app
+}
+
+func TestProcessTemplateStatusUpdate_FlaggedPausesCampaigns(t *testing.T) {
+	app := accountEventsTestApp(t)
+	_, _, campaign, _ := webhookTestData(t, app, models.MessageStatusSent)
+	require.NoError(t, app.DB.Model(&campaign).Update("status", models.CampaignStatusProcessing).Error)
+
+	var tmpl models.Template
+	require.NoError(t, app.DB.First(&tmpl, campaign.TemplateID).Error)
+	var account models.WhatsAppAccount
+	require.NoError(t, app.DB.Where("name = ?", tmpl.WhatsAppAccount).First(&account).Error)
+
+	app.processTemplateStatusUpdate(account.BusinessID, WebhookChangeValue{
+		Event:                   "FLAGGED",
+		MessageTemplateName:     tmpl.Name,
+		MessageTemplateLanguage: tmpl.Language,
+		Reason:                  "NONE",
+	})
+
+	require.NoError(t, app.DB.First(&tmpl, tmpl.ID).Error)
+	assert.Equal(t, "FLAGGED", tmpl.Status)
+	assert.NotNil(t, tmpl.StatusUpdatedAt)
+
+	require.NoError(t, app.DB.First(&campaign, campaign.ID).Error)
+	assert.Equal(t, models.CampaignStatusPaused, campaign.Status)
+}
+
+func TestProcessTemplateCategoryUpdate(t *testing.T) {
+	app := accountEventsTestApp(t)
+	_, _, campaign, _ := webhookTestData(t, app, models.MessageStatusSent)
+
+	var tmpl models.Template
+	require.NoError(t, app.DB.First(&tmpl, campaign.TemplateID).Error)
+	require.NoError(t, app.DB.Model(&tmpl).Update("category", "UTILITY").Error)
+	var account models.WhatsAppAccount
+	require.NoError(t, app.DB.Where("name = ?", tmpl.WhatsAppAccount).First(&account).Error)
+
+	app.processTemplateCategoryUpdate(account.BusinessID, WebhookChangeValue{
+		MessageTemplateName:     tmpl.Name,
+		MessageTemplateLanguage: tmpl.Language,
+		PreviousCategory:        "UTILITY",
+		NewCategory:             "MARKETING",
+	})
+
+	require.NoError(t, app.DB.First(&tmpl, tmpl.ID).Error)
+	assert.Equal(t, "MARKETING", tmpl.Category)
+	assert.Equal(t, "UTILITY", tmpl.PreviousCategory)
+}
+
+func TestWebhookTemplates_MatchesAccountsOfTheirOwnOrganization(t *testing.T) {
+	app := accountEventsTestApp(t)
+	uid := uuid.New().String()[:8]
+	wabaID := "waba-" + uid
+
+	orgs := make([]models.Organization, 2)
+	for i := range orgs {
+		orgs[i] = models.Organization{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "wt-" + uid + string(rune('a'+i)), Slug: "wt-" + uid + string(rune('a'+i))}
+		require.NoError(t, app.DB.Create(&orgs[i]).Error)
+	}
+	createAccount := func(orgID uuid.UUID, name, businessID string) {
+		require.NoError(t, app.DB.Create(&models.WhatsAppAccount{
+			BaseModel:      models.BaseModel{ID: uuid.New()},
+			OrganizationID: orgID,
+			Name:           name,
+			PhoneID:        "phone-" + uuid.New().String()[:8],
+			BusinessID:     businessID,
+			AccessToken:    "token",
+		}).Error)
+	}
+	// Both organizations use the WABA, on accounts with different names. The
+	// second organization also has an unrelated account named like the first's.
+	createAccount(orgs[0].ID, "shared-"+uid, wabaID)
+	createAccount(orgs[1].ID, "other-"+uid, wabaID)
+	createAccount(orgs[1].ID, "shared-"+uid, "waba-elsewhere-"+uid)
+
+	createTemplate := func(orgID uuid.UUID, account string) models.Template {
+		tmpl := models.Template{
+			BaseModel:       models.BaseModel{ID: uuid.New()},
+			OrganizationID:  orgID,
+			WhatsAppAccount: account,
+			Name:            "order_update",
+			Language:        "en",
+			Category:        "UTILITY",
+			Status:          "APPROVED",
+		}
+		require.NoError(t, app.DB.Create(&tmpl).Error)
+		return tmpl
+	}
+	own := createTemplate(orgs[0].ID, "shared-"+uid)
+	shared := createTemplate(orgs[1].ID, "other-"+uid)
+	unrelated := createTemplate(orgs[1].ID, "shared-"+uid)
+
+	templates := app.webhookTemplates(wabaID, WebhookChangeValue{MessageTemplateName: "order_update", MessageTemplateLanguage: "en"})
+	ids := make([]uuid.UUID, len(templates))
+	for i, tmpl := range templates {
+		ids[i] = tmpl.ID
+	}
+	assert.ElementsMatch(t, []uuid.UUID{own.ID, shared.ID}, ids)
+	assert.NotContains(t, ids, unrelated.ID)
+}
+
+func TestProcessPhoneQualityUpdate(t *testing.T) {
+	app := accountEventsTestApp(t)
+	uid := uuid.New().String()[:8]
+	org := models.Organization{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "pq-" + uid, Slug: "pq-" + uid}
+	require.NoError(t, app.DB.Create(&org).Error)
+
+	wabaID := "waba-" + uid
+	accounts := make([]models.WhatsAppAccount, 2)
+	for i, phone := range []string{"+1 555 0100", "+1 555 0199"} {
+		accounts[i] = models.WhatsAppAccount{
+			BaseModel:          models.BaseModel{ID: uuid.New()},
+			OrganizationID:     org.ID,
+			Name:               "pq-acct-" + uid + "-" + phone[len(phone)-2:],
+			PhoneID:            "phone-" + uid + phone[len(phone)-2:],
+			BusinessID:         wabaID,
+			AccessToken:        "token",
+			DisplayPhoneNumber: phone,
+		}
+		require.NoError(t, app.DB.Create(&accounts[i]).Error)
+	}
+
+	app.processPhoneQualityUpdate(wabaID, WebhookChangeValue{
+		DisplayPhoneNumber: "15550100",
+		Event:              "FLAGGED",
+		CurrentLimit:       "TIER_1K",
+	})
+
+	var flagged, other models.WhatsAppAccount
+	require.NoError(t, app.DB.First(&flagged, accounts[0].ID).Error)
+	require.NoError(t, app.DB.First(&other, accounts[1].ID).Error)
+	assert.Equal(t, "FLAGGED", flagged.QualityEvent)
+	assert.Equal(t, "TIER_1K", flagged.MessagingLimitTier)
+	assert.NotNil(t, flagged.HealthUpdatedAt)
+	assert.Empty(t, other.QualityEvent)
+}
+
+func TestProcessPhoneQualityUpdate_UnknownNumberInSharedWABA(t *testing.T) {
+	app := accountEventsTestApp(t)
+	uid := uuid.New().String()[:8]
+	org := models.Organization{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "pq-" + uid, Slug: "pq-" + uid}
+	require.NoError(t, app.DB.Create(&org).Error)
+
+	// Neither account was tested, so their display numbers are unknown
+	wabaID := "waba-" + uid
+	accounts := make([]models.WhatsAppAccount, 2)
+	for i := range accounts {
+		accounts[i] = models.WhatsAppAccount{
+			BaseModel:      models.BaseModel{ID: uuid.New()},
+			OrganizationID: org.ID,
+			Name:           fmt.Sprintf("pq-acct-%s-%d", uid, i),
+			PhoneID:        fmt.Sprintf("phone-%s-%d", uid, i),
+			BusinessID:     wabaID,
+			AccessToken:    "token",
+		}
+		require.NoError(t, app.DB.Create(&accounts[i]).Error)
+	}
+
+	app.processPhoneQualityUpdate(wabaID, WebhookChangeValue{
+		DisplayPhoneNumber: "15550100",
+		Event:              "FLAGGED",
+		CurrentLimit:       "TIER_1K",
+	})
+
+	for _, account := range accounts {
+		var stored models.WhatsAppAccount
+		require.NoError(t, app.DB.First(&stored, account.ID).Error)
+		assert.Empty(t, stored.QualityEvent, "the event can't be attributed to %s", account.Name)
+		assert.Empty(t, stored.MessagingLimitTier)
+	}
+}
@@ -34,6 +34,7 @@ type MessageEventData struct {
 	WhatsAppAccount string             `json:"whatsapp_account"`
 	Direction       models.Direction   `json:"direction,omitempty"`
 	SentByUserID    string             `json:"sent_by_user_id,omitempty"`
+	Metadata        models.JSONB       `json:"metadata,omitempty"` // Order, template button, referral or system details
 }
 
 // ContactEventData represents data for contact events
@@ -57,6 +58,52 @@ type TransferEventData struct {
 	WhatsAppAccount string                `json:"whatsapp_account"`
 }
 
+// NoteMentionEventData represents data for note mention events
+type NoteMentionEventData struct {
+	NoteID           string              `json:"note_id"`
+	ParentNoteID     *string             `json:"parent_note_id,omitempty"`
+	ContactID        string              `json:"contact_id"`
+	ContactPhone     string              `json:"contact_phone"`
+	ContactName      string              `json:"contact_name"`
+	AuthorID         string              `json:"author_id"`
+	AuthorName       string              `json:"author_name"`
+	Content          string              `json:"content"`
+	MentionedUsers   []MentionedUserData `json:"mentioned_users"`
+	MentionedTeamIDs []string            `json:"mentioned_team_ids,omitempty"`
+}
+
+// MentionedUserData identifies a user notified about a mention
+type MentionedUserData struct {
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+}
+
+// AccountEventData represents data for WhatsApp account events
+type AccountEventData struct {
+	AccountID       string         `json:"account_id"`
+	WhatsAppAccount string         `json:"whatsapp_account"`
+	BusinessID      string         `json:"business_id"`
+	Field           string         `json:"field"` // Meta webhook field, e.g. phone_number_quality_update
+	Event           string         `json:"event,omitempty"`
+	Details         map[string]any `json:"details,omitempty"`
+}
+
+// TemplateEventData represents data for template events
+type TemplateEventData struct {
+	TemplateID       string `json:"template_id"`
+	Name             string `json:"name"`
+	Language         string `json:"language"`
+	WhatsAppAccount  string `json:"whatsapp_account"`
+	Field            string `json:"field"` // Meta webhook field, e.g. message_template_quality_update
+	Status           string `json:"status"`
+	QualityScore     string `json:"quality_score,omitempty"`
+	Category         string `json:"category"`
+	PreviousCategory string `json:"previous_category,omitempty"`
+	Reason           string `json:"reason,omitempty"`
+	PausedCampaigns  int    `json:"paused_campaigns,omitempty"`
+}
+
 // maxConcurrentWebhooks limits the number of concurrent webhook deliveries per dispatch
 const maxConcurrentWebhooks = 10
 
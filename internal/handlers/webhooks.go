@@ -114,6 +114,11 @@ var AvailableWebhookEvents = []map[string]string{
 	{"value": string(models.WebhookEventTransferCreated), "label": "Transfer Created", "description": "When a transfer to human agent is requested"},
 	{"value": string(models.WebhookEventTransferAssigned), "label": "Transfer Assigned", "description": "When a transfer is assigned to an agent"},
 	{"value": string(models.WebhookEventTransferResumed), "label": "Transfer Resumed", "description": "When chatbot is resumed (transfer closed)"},
+	{"value": string(models.WebhookEventNoteMention), "label": "Note Mention", "description": "When a user or team is mentioned in a conversation note"},
+	{"value": string(models.WebhookEventAccountUpdated), "label": "Account Updated", "description": "When Meta reports an account, phone number quality, display name or capability change"},
+	{"value": string(models.WebhookEventTemplateUpdated), "label": "Template Updated", "description": "When Meta changes a template's status, quality or category"},
+	{"value": string(models.WebhookEventOrderCreated), "label": "Order Created", "description": "When a customer sends a cart from a catalog"},
+	{"value": string(models.WebhookEventOrderUpdated), "label": "Order Updated", "description": "When an order is confirmed, paid, shipped or cancelled"},
 }
 
 // ListWebhooks returns all webhooks for the organization
@@ -16,7 +16,7 @@ import (
 type WidgetRequest struct {
 	Name        string        `json:"name"`
 	Description string        `json:"description"`
-	DataSource  string        `json:"data_source"`  // messages, contacts, campaigns, transfers, sessions
+	DataSource  string        `json:"data_source"`  // messages, contacts, campaigns, transfers, sessions, csat
 	Metric      string        `json:"metric"`       // count, sum, avg
 	Field       string        `json:"field"`        // Field for sum/avg
 	Filters     []FilterInput `json:"filters"`      // Filter conditions
@@ -124,6 +124,7 @@ var widgetDataSources = map[string][]string{
 	"campaigns": {"status", "message_status"},
 	"transfers": {"status", "source"},
 	"sessions":  {"status"},
+	"csat":      {"status", "scale", "channel", "whatsapp_account"},
 }
 
 // Available metrics
@@ -250,6 +251,9 @@ func (a *App) CreateWidget(r *fastglue.Request) error {
 		if !contains(widgetMetrics, req.Metric) {
 			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid metric", nil, "")
 		}
+		if isCSATScoreAverage(req.DataSource, req.Metric, req.Field) && !hasCSATScaleFilter(req.Filters) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, csatScaleRequiredMessage, nil, "")
+		}
 	}
 
 	// Get max display order
@@ -471,6 +475,11 @@ func (a *App) UpdateWidget(r *fastglue.Request) error {
 		widget.GridH = *req.GridH
 	}
 
+	if isCSATScoreAverage(widget.DataSource, widget.Metric, widget.Field) &&
+		!hasCSATScaleFilter(widgetToResponse(*widget, userID).Filters) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, csatScaleRequiredMessage, nil, "")
+	}
+
 	if err := a.DB.Save(widget).Error; err != nil {
 		a.Log.Error("Failed to update widget", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update widget", nil, "")
@@ -820,6 +829,10 @@ func (a *App) executeWidgetQuery(orgID uuid.UUID, widget models.Widget, fromStr,
 	case "sessions":
 		currentValue = a.querySessions(orgID, widget.Metric, filters, periodStart, periodEnd)
 		previousValue = a.querySessions(orgID, widget.Metric, filters, previousPeriodStart, previousPeriodEnd)
+
+	case "csat":
+		currentValue = a.queryCSAT(orgID, widget.Metric, widget.Field, filters, periodStart, periodEnd)
+		previousValue = a.queryCSAT(orgID, widget.Metric, widget.Field, filters, previousPeriodStart, previousPeriodEnd)
 	}
 
 	response.Value = currentValue
@@ -937,6 +950,54 @@ func (a *App) querySessions(orgID uuid.UUID, _ string, filters []FilterInput, st
 	return float64(count)
 }
 
+// csatScaleRequiredMessage explains why a CSAT score average needs a scale filter
+const csatScaleRequiredMessage = "Averaging CSAT scores requires a scale filter (equals csat or nps), as the scales can't be averaged together"
+
+// isCSATScoreAverage reports whether a widget averages CSAT survey scores
+func isCSATScoreAverage(dataSource, metric, field string) bool {
+	return dataSource == "csat" && metric == "avg" && field == "score"
+}
+
+// hasCSATScaleFilter reports whether filters limit surveys to one scale, so
+// 1-5 CSAT and 0-10 NPS scores are never averaged together
+func hasCSATScaleFilter(filters []FilterInput) bool {
+	for _, f := range filters {
+		if f.Field == "scale" && (f.Operator == "equals" || f.Operator == "") && f.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// queryCSAT counts satisfaction surveys, or averages their score with the
+// "avg" metric on the "score" field for a single scale
+func (a *App) queryCSAT(orgID uuid.UUID, metric, field string, filters []FilterInput, start, end time.Time) float64 {
+	query := a.DB.Model(&models.CSATSurvey{}).Where("organization_id = ? AND created_at >= ? AND created_at <= ?", orgID, start, end)
+
+	for _, f := range filters {
+		query = applyFilter(query, f)
+	}
+
+	var result float64
+	switch metric {
+	case "count":
+		var count int64
+		query.Count(&count)
+		result = float64(count)
+	case "avg":
+		// Widgets saved before the scale filter was required show nothing
+		// rather than a mix of CSAT and NPS scores
+		if field == "score" && hasCSATScaleFilter(filters) {
+			var val float64
+			query.Where("status = ?", models.CSATSurveyStatusAnswered).
+				Select("COALESCE(AVG(score), 0)").
+				Scan(&val)
+			result = val
+		}
+	}
+	return result
+}
+
 func (a *App) getChartData(orgID uuid.UUID, widget models.Widget, filters []FilterInput, start, end time.Time) []ChartPoint {
 	chartData := make([]ChartPoint, 0)
 
@@ -988,6 +1049,8 @@ func resolveDataSourceTable(dataSource string) (tableName, dateField string, ok
 		return "agent_transfers", "transferred_at", true
 	case "sessions":
 		return "chatbot_sessions", "created_at", true
+	case "csat":
+		return "csat_surveys", "created_at", true
 	default:
 		return "", "", false
 	}
@@ -1023,6 +1086,7 @@ func (a *App) getGroupedData(orgID uuid.UUID, widget models.Widget, filters []Fi
 		"message_type": true, "assigned_user_id": true, "channel": true,
 		"is_active": true, "priority": true, "category": true,
 		"type": true, "action_type": true, "provider": true,
+		"scale": true,
 	}
 	if !allowedGroupByFields[widget.GroupByField] {
 		a.Log.Error("Invalid GroupByField", "field", widget.GroupByField)
@@ -1310,6 +1374,13 @@ var tableQuerySQL = map[string]struct{ base, orderBy string }{
 			WHERE s.organization_id = ? AND s.created_at >= ? AND s.created_at <= ?`,
 		orderBy: " ORDER BY s.created_at DESC LIMIT 10",
 	},
+	"csat": {
+		base: `SELECT id, COALESCE((SELECT COALESCE(c.profile_name, c.phone_number) FROM contacts c WHERE c.id = csat_surveys.contact_id), '') as label,
+			COALESCE(score::text, '-') as sub_label, status, '' as direction, created_at
+			FROM csat_surveys
+			WHERE organization_id = ? AND created_at >= ? AND created_at <= ?`,
+		orderBy: " ORDER BY created_at DESC LIMIT 10",
+	},
 }
 
 // getTableRows returns the last 10 rows for a table widget based on the data source.
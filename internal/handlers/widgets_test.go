@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
 )
 
 // getAnalyticsPermissions returns analytics permissions from the full permission set.
@@ -256,6 +257,36 @@ func TestApp_CreateWidget_Success(t *testing.T) {
 	assert.Equal(t, "green", resp.Data.Color)
 }
 
+func TestApp_CreateWidget_CSATAverageRequiresScale(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	perms := getAnalyticsPermissions(t, app)
+	role := testutil.CreateTestRoleExact(t, app.DB, org.ID, "Analytics User", false, false, perms)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("csat-widget")), testutil.WithPassword("password"), testutil.WithRoleID(&role.ID))
+
+	createWidget := func(filters []map[string]string) *fastglue.Request {
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"name":        "Average score",
+			"data_source": "csat",
+			"metric":      "avg",
+			"field":       "score",
+			"filters":     filters,
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.CreateWidget(req))
+		return req
+	}
+
+	// CSAT (1-5) and NPS (0-10) scores can't be averaged together
+	req := createWidget(nil)
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "scale filter")
+	req = createWidget([]map[string]string{{"field": "scale", "operator": "not_equals", "value": "csat"}})
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "scale filter")
+
+	req = createWidget([]map[string]string{{"field": "scale", "operator": "equals", "value": "nps"}})
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+}
+
 func TestApp_CreateWidget_NoPermission(t *testing.T) {
 	app := newTestApp(t)
 	org := testutil.CreateTestOrganization(t, app.DB)
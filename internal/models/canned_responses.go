@@ -4,23 +4,59 @@ import (
 	"github.com/google/uuid"
 )
 
-// CannedResponse represents a pre-defined response text for quick insertion in chat
+// CannedResponse represents a pre-defined response for quick insertion in chat.
+// Besides text it can carry a media attachment, reply buttons or a template
+// reference. Its scope decides who can see and use it.
 type CannedResponse struct {
 	BaseModel
-	OrganizationID uuid.UUID `gorm:"type:uuid;index;not null" json:"organization_id"`
-	Name           string    `gorm:"size:100;not null" json:"name"`
-	Shortcut       string    `gorm:"size:50;index" json:"shortcut"`
-	Content        string    `gorm:"type:text;not null" json:"content"`
-	Category       string    `gorm:"size:50" json:"category"`
-	IsActive       bool      `gorm:"default:true" json:"is_active"`
-	UsageCount     int       `gorm:"default:0" json:"usage_count"`
-	CreatedByID    uuid.UUID `gorm:"type:uuid" json:"created_by_id"`
+	OrganizationID uuid.UUID           `gorm:"type:uuid;index;not null" json:"organization_id"`
+	Name           string              `gorm:"size:100;not null" json:"name"`
+	Shortcut       string              `gorm:"size:50;index" json:"shortcut"`
+	Content        string              `gorm:"type:text;not null" json:"content"` // Supports {{variable}} placeholders
+	Category       string              `gorm:"size:50" json:"category"`
+	IsActive       bool                `gorm:"default:true" json:"is_active"`
+	UsageCount     int                 `gorm:"default:0" json:"usage_count"`
+	CreatedByID    uuid.UUID           `gorm:"type:uuid" json:"created_by_id"`
+	Scope          CannedResponseScope `gorm:"size:20;default:'organization';index" json:"scope"`
+	TeamID         *uuid.UUID          `gorm:"type:uuid;index" json:"team_id,omitempty"` // Set for team scope
+
+	// Media attachment (image, video, audio or document), sent with Content as caption
+	MediaPath     string `gorm:"size:500" json:"media_path,omitempty"` // Relative to media storage
+	MediaMimeType string `gorm:"size:100" json:"media_mime_type,omitempty"`
+	MediaFilename string `gorm:"size:255" json:"media_filename,omitempty"`
+
+	// Reply buttons sent with Content as body: [{"id": "...", "title": "..."}]
+	Buttons JSONBArray `gorm:"type:jsonb;default:'[]'" json:"buttons"`
+
+	// Template reference, sent instead of Content. Param values support placeholders.
+	TemplateID     *uuid.UUID `gorm:"type:uuid" json:"template_id,omitempty"`
+	TemplateParams JSONB      `gorm:"type:jsonb;default:'{}'" json:"template_params"`
 
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
 	CreatedBy    *User         `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	Team         *Team         `gorm:"foreignKey:TeamID" json:"team,omitempty"`
+	Template     *Template     `gorm:"foreignKey:TemplateID" json:"template,omitempty"`
 }
 
 func (CannedResponse) TableName() string {
 	return "canned_responses"
 }
+
+// CannedResponseUsage records a use of a canned response by an agent
+type CannedResponseUsage struct {
+	BaseModel
+	OrganizationID   uuid.UUID  `gorm:"type:uuid;index:idx_canned_response_usage_org_time;not null" json:"organization_id"`
+	CannedResponseID uuid.UUID  `gorm:"type:uuid;index;not null" json:"canned_response_id"`
+	UserID           uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	ContactID        *uuid.UUID `gorm:"type:uuid" json:"contact_id,omitempty"`
+	Sent             bool       `gorm:"default:false" json:"sent"` // Sent by the server rather than inserted in the editor
+
+	// Relations
+	CannedResponse *CannedResponse `gorm:"foreignKey:CannedResponseID" json:"canned_response,omitempty"`
+	User           *User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (CannedResponseUsage) TableName() string {
+	return "canned_response_usages"
+}
@@ -1,6 +1,10 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // Catalog represents a WhatsApp product catalog
 type Catalog struct {
@@ -25,16 +29,30 @@ type CatalogProduct struct {
 	BaseModel
 	OrganizationID uuid.UUID `gorm:"type:uuid;index;not null" json:"organization_id"`
 	CatalogID      uuid.UUID `gorm:"type:uuid;index;not null" json:"catalog_id"`
-	MetaProductID  string    `gorm:"size:100;uniqueIndex" json:"meta_product_id"`
+	MetaProductID  string    `gorm:"size:100" json:"meta_product_id"` // Unique among live products when set, empty until Meta processes a batch
 	Name           string    `gorm:"size:255;not null" json:"name"`
 	Description    string    `gorm:"type:text" json:"description"`
-	Price          int64     `gorm:"not null" json:"price"`            // Price in cents
+	Price          int64     `gorm:"not null" json:"price"` // Price in cents
 	Currency       string    `gorm:"size:3;default:'USD'" json:"currency"`
 	URL            string    `gorm:"size:500" json:"url"`
 	ImageURL       string    `gorm:"size:500" json:"image_url"`
 	RetailerID     string    `gorm:"size:100" json:"retailer_id"` // SKU
 	IsActive       bool      `gorm:"default:true" json:"is_active"`
 
+	// Commerce fields sent with feed imports and batch updates
+	Brand        string `gorm:"size:255" json:"brand"`
+	Availability string `gorm:"size:30;default:'in stock'" json:"availability"` // in stock, out of stock, preorder, ...
+	Condition    string `gorm:"size:20;default:'new'" json:"condition"`         // new, refurbished, used
+	Inventory    *int   `json:"inventory,omitempty"`
+	SalePrice    int64  `json:"sale_price"` // In cents, 0 when not on sale
+
+	// Sync with Meta
+	SyncStatus             ProductSyncStatus `gorm:"size:20;default:'synced';index" json:"sync_status"`
+	SyncError              string            `gorm:"type:text" json:"sync_error,omitempty"`
+	ReviewStatus           string            `gorm:"size:20;index" json:"review_status"` // Meta commerce review: approved, pending, rejected, outdated
+	ReviewRejectionReasons StringArray       `gorm:"type:jsonb;default:'[]'" json:"review_rejection_reasons"`
+	LastSyncedAt           *time.Time        `json:"last_synced_at,omitempty"`
+
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
 	Catalog      *Catalog      `gorm:"foreignKey:CatalogID" json:"catalog,omitempty"`
@@ -43,3 +61,154 @@ type CatalogProduct struct {
 func (CatalogProduct) TableName() string {
 	return "catalog_products"
 }
+
+// CatalogProductSet is a product set (collection) of a catalog, e.g. "Summer sale".
+// It matches products by retailer ID or by a Meta filter rule.
+type CatalogProductSet struct {
+	BaseModel
+	OrganizationID   uuid.UUID   `gorm:"type:uuid;index;not null" json:"organization_id"`
+	CatalogID        uuid.UUID   `gorm:"type:uuid;index;not null" json:"catalog_id"`
+	MetaProductSetID string      `gorm:"size:100;index" json:"meta_product_set_id"`
+	Name             string      `gorm:"size:255;not null" json:"name"`
+	RetailerIDs      StringArray `gorm:"type:jsonb;default:'[]'" json:"retailer_ids"` // Used when Filter is empty
+	Filter           JSONB       `gorm:"type:jsonb;default:'{}'" json:"filter"`       // Meta filter rule, e.g. {"brand":{"eq":"Acme"}}
+	ProductCount     int         `json:"product_count"`
+
+	// Relations
+	Catalog *Catalog `gorm:"foreignKey:CatalogID" json:"catalog,omitempty"`
+}
+
+func (CatalogProductSet) TableName() string {
+	return "catalog_product_sets"
+}
+
+// CatalogBatch is a batch of product changes submitted to Meta's items_batch
+// endpoint. Meta processes it asynchronously; the catalog processor polls it.
+type CatalogBatch struct {
+	BaseModel
+	OrganizationID uuid.UUID          `gorm:"type:uuid;index;not null" json:"organization_id"`
+	CatalogID      uuid.UUID          `gorm:"type:uuid;index;not null" json:"catalog_id"`
+	Source         string             `gorm:"size:20" json:"source"` // feed, schedule, manual
+	Handles        StringArray        `gorm:"type:jsonb;default:'[]'" json:"handles"`
+	RetailerIDs    StringArray        `gorm:"type:jsonb;default:'[]'" json:"retailer_ids"` // Products waiting on this batch
+	ItemCount      int                `json:"item_count"`
+	ErrorCount     int                `json:"error_count"`
+	Errors         JSONBArray         `gorm:"type:jsonb;default:'[]'" json:"errors"` // [{retailer_id, message}]
+	Status         CatalogBatchStatus `gorm:"size:20;default:'processing';index" json:"status"`
+	CompletedAt    *time.Time         `json:"completed_at,omitempty"`
+}
+
+func (CatalogBatch) TableName() string {
+	return "catalog_batches"
+}
+
+// CatalogScheduledUpdate applies price, sale price, inventory and availability
+// changes to products at a set time, e.g. to start or end a sale.
+type CatalogScheduledUpdate struct {
+	BaseModel
+	OrganizationID uuid.UUID                    `gorm:"type:uuid;index;not null" json:"organization_id"`
+	CatalogID      uuid.UUID                    `gorm:"type:uuid;index;not null" json:"catalog_id"`
+	Name           string                       `gorm:"size:255" json:"name"`
+	Updates        JSONBArray                   `gorm:"type:jsonb;default:'[]'" json:"updates"` // [{retailer_id, price, sale_price, inventory, availability}]
+	ScheduledAt    time.Time                    `gorm:"index" json:"scheduled_at"`
+	Status         CatalogScheduledUpdateStatus `gorm:"size:20;default:'scheduled';index" json:"status"`
+	BatchID        *uuid.UUID                   `gorm:"type:uuid" json:"batch_id,omitempty"`
+	Error          string                       `gorm:"type:text" json:"error,omitempty"`
+	AppliedAt      *time.Time                   `json:"applied_at,omitempty"`
+	CreatedByID    *uuid.UUID                   `gorm:"type:uuid" json:"created_by_id,omitempty"`
+}
+
+func (CatalogScheduledUpdate) TableName() string {
+	return "catalog_scheduled_updates"
+}
+
+// Order is a cart a customer sent from a catalog in a WhatsApp conversation
+type Order struct {
+	BaseModel
+	OrganizationID  uuid.UUID   `gorm:"type:uuid;index;not null" json:"organization_id"`
+	WhatsAppAccount string      `gorm:"size:100;index" json:"whatsapp_account"`
+	ContactID       uuid.UUID   `gorm:"type:uuid;index;not null" json:"contact_id"`
+	MessageID       *uuid.UUID  `gorm:"type:uuid;index" json:"message_id,omitempty"`
+	SourceWAMID     *string     `gorm:"column:source_wamid;size:255;uniqueIndex" json:"source_wamid,omitempty"`
+	CatalogID       *uuid.UUID  `gorm:"type:uuid;index" json:"catalog_id,omitempty"` // Nil when the catalog was not synced
+	MetaCatalogID   string      `gorm:"size:100" json:"meta_catalog_id"`
+	Text            string      `gorm:"type:text" json:"text"` // Note the customer sent with the cart
+	Currency        string      `gorm:"size:3" json:"currency"`
+	Total           int64       `json:"total"` // In cents
+	Status          OrderStatus `gorm:"size:20;default:'pending';index" json:"status"`
+
+	// Fulfilment
+	TrackingNumber string     `gorm:"size:100" json:"tracking_number,omitempty"`
+	TrackingURL    string     `gorm:"type:text" json:"tracking_url,omitempty"`
+	CancelReason   string     `gorm:"type:text" json:"cancel_reason,omitempty"`
+	ConfirmedAt    *time.Time `json:"confirmed_at,omitempty"`
+	PaidAt         *time.Time `json:"paid_at,omitempty"`
+	ShippedAt      *time.Time `json:"shipped_at,omitempty"`
+	CancelledAt    *time.Time `json:"cancelled_at,omitempty"`
+
+	// Relations
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+	Contact      *Contact      `gorm:"foreignKey:ContactID" json:"contact,omitempty"`
+	Catalog      *Catalog      `gorm:"foreignKey:CatalogID" json:"catalog,omitempty"`
+	Items        []OrderItem   `gorm:"foreignKey:OrderID" json:"items,omitempty"`
+	Events       []OrderEvent  `gorm:"foreignKey:OrderID" json:"events,omitempty"`
+}
+
+func (Order) TableName() string {
+	return "orders"
+}
+
+// OrderItem is a product line of an order
+type OrderItem struct {
+	BaseModel
+	OrderID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"order_id"`
+	ProductID  *uuid.UUID `gorm:"type:uuid;index" json:"product_id,omitempty"` // Nil when the product was not synced
+	RetailerID string     `gorm:"size:100" json:"retailer_id"`                 // SKU
+	Name       string     `gorm:"size:255" json:"name"`
+	Quantity   int        `json:"quantity"`
+	ItemPrice  int64      `json:"item_price"` // Unit price in cents
+	Currency   string     `gorm:"size:3" json:"currency"`
+
+	// Relations
+	Product *CatalogProduct `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (OrderItem) TableName() string {
+	return "order_items"
+}
+
+// OrderEvent records a change of an order's status and the customer
+// notification sent for it
+type OrderEvent struct {
+	BaseModel
+	OrderID               uuid.UUID   `gorm:"type:uuid;index;not null" json:"order_id"`
+	FromStatus            OrderStatus `gorm:"size:20" json:"from_status,omitempty"` // Empty when the order was created
+	ToStatus              OrderStatus `gorm:"size:20;not null" json:"to_status"`
+	Note                  string      `gorm:"type:text" json:"note,omitempty"`
+	UserID                *uuid.UUID  `gorm:"type:uuid" json:"user_id,omitempty"` // Nil for changes made by the system
+	NotificationMessageID *uuid.UUID  `gorm:"type:uuid" json:"notification_message_id,omitempty"`
+	NotificationError     string      `gorm:"type:text" json:"notification_error,omitempty"`
+
+	// Relations
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (OrderEvent) TableName() string {
+	return "order_events"
+}
+
+// OrderNotification is the template sent to the customer when an order
+// reaches a status. The template is looked up by name on the order's account.
+type OrderNotification struct {
+	BaseModel
+	OrganizationID uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_order_notification_status" json:"organization_id"`
+	Status         OrderStatus `gorm:"size:20;not null;uniqueIndex:idx_order_notification_status" json:"status"`
+	TemplateName   string      `gorm:"size:512;not null" json:"template_name"`
+	Language       string      `gorm:"size:10" json:"language"`               // Empty picks the contact's language
+	Params         JSONB       `gorm:"type:jsonb;default:'{}'" json:"params"` // Template parameter -> value with {{order.*}} variables
+	IsEnabled      bool        `gorm:"default:true" json:"is_enabled"`
+}
+
+func (OrderNotification) TableName() string {
+	return "order_notifications"
+}
@@ -8,51 +8,74 @@ import (
 
 // BusinessHoursConfig holds business hours settings
 type BusinessHoursConfig struct {
-	Enabled              bool       `gorm:"column:business_hours_enabled;default:false" json:"business_hours_enabled"`
-	Hours                JSONBArray `gorm:"column:business_hours;type:jsonb;default:'[]'" json:"business_hours"` // [{day, enabled, start_time, end_time}]
-	OutOfHoursMessage    string     `gorm:"column:out_of_hours_message;type:text" json:"out_of_hours_message"`
-	AllowAutomatedOutside bool      `gorm:"column:allow_automated_outside_hours;default:true" json:"allow_automated_outside_hours"` // Allow flows/keywords/AI outside business hours
+	Enabled               bool       `gorm:"column:business_hours_enabled;default:false" json:"business_hours_enabled"`
+	Hours                 JSONBArray `gorm:"column:business_hours;type:jsonb;default:'[]'" json:"business_hours"`                   // [{day, enabled, start_time, end_time, intervals: [{start_time, end_time}]}]
+	Timezone              string     `gorm:"column:business_hours_timezone;size:64" json:"business_hours_timezone"`                 // IANA zone; empty = organization timezone
+	Holidays              JSONBArray `gorm:"column:business_hours_holidays;type:jsonb;default:'[]'" json:"business_hours_holidays"` // [{date, end_date, name, message, intervals}]
+	OutOfHoursMessage     string     `gorm:"column:out_of_hours_message;type:text" json:"out_of_hours_message"`
+	AllowAutomatedOutside bool       `gorm:"column:allow_automated_outside_hours;default:true" json:"allow_automated_outside_hours"` // Allow flows/keywords/AI outside business hours
 }
 
 // AgentAssignmentConfig holds agent assignment and queue settings
 type AgentAssignmentConfig struct {
-	AllowQueuePickup        bool `gorm:"column:allow_agent_queue_pickup;default:true" json:"allow_agent_queue_pickup"`           // Allow agents to pick transfers from queue
-	AssignToSameAgent       bool `gorm:"column:assign_to_same_agent;default:true" json:"assign_to_same_agent"`                   // Auto-assign transfers to contact's existing agent
+	AllowQueuePickup        bool `gorm:"column:allow_agent_queue_pickup;default:true" json:"allow_agent_queue_pickup"`                // Allow agents to pick transfers from queue
+	AssignToSameAgent       bool `gorm:"column:assign_to_same_agent;default:true" json:"assign_to_same_agent"`                        // Auto-assign transfers to contact's existing agent
 	CurrentConversationOnly bool `gorm:"column:agent_current_conversation_only;default:false" json:"agent_current_conversation_only"` // Agents see only current session messages
+	IdleAwayMinutes         int  `gorm:"column:agent_idle_away_minutes;default:0" json:"agent_idle_away_minutes"`                     // Set agents away after this long without activity (0 = disabled)
 }
 
 // SLAConfig holds SLA tracking settings
 type SLAConfig struct {
-	Enabled             bool        `gorm:"column:sla_enabled;default:false" json:"sla_enabled"`                            // Enable SLA tracking
-	ResponseMinutes     int         `gorm:"column:sla_response_minutes;default:15" json:"sla_response_minutes"`             // Time to pick up transfer (default 15 min)
-	ResolutionMinutes   int         `gorm:"column:sla_resolution_minutes;default:60" json:"sla_resolution_minutes"`         // Time to resolve transfer (default 60 min)
-	EscalationMinutes   int         `gorm:"column:sla_escalation_minutes;default:30" json:"sla_escalation_minutes"`         // Time before escalation (default 30 min)
-	AutoCloseHours      int         `gorm:"column:sla_auto_close_hours;default:24" json:"sla_auto_close_hours"`             // Auto-close stale transfers (default 24h)
-	AutoCloseMessage    string      `gorm:"column:sla_auto_close_message;type:text" json:"sla_auto_close_message"`          // Message to customer when chat is auto-closed
-	WarningMessage      string      `gorm:"column:sla_warning_message;type:text" json:"sla_warning_message"`                // Message to customer when SLA breached
+	Enabled             bool        `gorm:"column:sla_enabled;default:false" json:"sla_enabled"`                                       // Enable SLA tracking
+	ResponseMinutes     int         `gorm:"column:sla_response_minutes;default:15" json:"sla_response_minutes"`                        // Time to pick up transfer (default 15 min)
+	ResolutionMinutes   int         `gorm:"column:sla_resolution_minutes;default:60" json:"sla_resolution_minutes"`                    // Time to resolve transfer (default 60 min)
+	EscalationMinutes   int         `gorm:"column:sla_escalation_minutes;default:30" json:"sla_escalation_minutes"`                    // Time before escalation (default 30 min)
+	AutoCloseHours      int         `gorm:"column:sla_auto_close_hours;default:24" json:"sla_auto_close_hours"`                        // Auto-close stale transfers (default 24h)
+	AutoCloseMessage    string      `gorm:"column:sla_auto_close_message;type:text" json:"sla_auto_close_message"`                     // Message to customer when chat is auto-closed
+	WarningMessage      string      `gorm:"column:sla_warning_message;type:text" json:"sla_warning_message"`                           // Message to customer when SLA breached
 	EscalationNotifyIDs StringArray `gorm:"column:sla_escalation_notify_ids;type:jsonb;default:'[]'" json:"sla_escalation_notify_ids"` // User IDs to notify on escalation
 }
 
 // ClientInactivityConfig holds client inactivity and reminder settings
 type ClientInactivityConfig struct {
-	ReminderEnabled  bool   `gorm:"column:client_reminder_enabled;default:false" json:"client_reminder_enabled"`   // Enable client inactivity reminders
-	ReminderMinutes  int    `gorm:"column:client_reminder_minutes;default:30" json:"client_reminder_minutes"`      // Send reminder after X minutes of client inactivity
-	ReminderMessage  string `gorm:"column:client_reminder_message;type:text" json:"client_reminder_message"`       // Reminder message to client
-	AutoCloseMinutes int    `gorm:"column:client_auto_close_minutes;default:60" json:"client_auto_close_minutes"`  // Auto-close after Y minutes of client inactivity
-	AutoCloseMessage string `gorm:"column:client_auto_close_message;type:text" json:"client_auto_close_message"`   // Message when closing due to client inactivity
+	ReminderEnabled  bool   `gorm:"column:client_reminder_enabled;default:false" json:"client_reminder_enabled"`  // Enable client inactivity reminders
+	ReminderMinutes  int    `gorm:"column:client_reminder_minutes;default:30" json:"client_reminder_minutes"`     // Send reminder after X minutes of client inactivity
+	ReminderMessage  string `gorm:"column:client_reminder_message;type:text" json:"client_reminder_message"`      // Reminder message to client
+	AutoCloseMinutes int    `gorm:"column:client_auto_close_minutes;default:60" json:"client_auto_close_minutes"` // Auto-close after Y minutes of client inactivity
+	AutoCloseMessage string `gorm:"column:client_auto_close_message;type:text" json:"client_auto_close_message"`  // Message when closing due to client inactivity
+}
+
+// CSATConfig holds customer satisfaction survey settings
+type CSATConfig struct {
+	Enabled         bool        `gorm:"column:csat_enabled;default:false" json:"csat_enabled"`                 // Survey customers after their transfer is closed
+	Scale           CSATScale   `gorm:"column:csat_scale;size:10;default:'csat'" json:"csat_scale"`            // csat (1-5) or nps (0-10)
+	Channel         CSATChannel `gorm:"column:csat_channel;size:10;default:'buttons'" json:"csat_channel"`     // buttons or flow
+	Message         string      `gorm:"column:csat_message;type:text" json:"csat_message"`                     // Survey question
+	FlowID          string      `gorm:"column:csat_flow_id;size:100" json:"csat_flow_id"`                      // Meta Flow ID returning "score" and optional "comment"
+	DelayMinutes    int         `gorm:"column:csat_delay_minutes;default:0" json:"csat_delay_minutes"`         // Wait this long after the close before sending
+	SuppressionDays int         `gorm:"column:csat_suppression_days;default:7" json:"csat_suppression_days"`   // Don't survey a contact again within this many days
+	ThankYouMessage string      `gorm:"column:csat_thank_you_message;type:text" json:"csat_thank_you_message"` // Sent after a response
+}
+
+// LanguageConfig holds multi-language settings
+type LanguageConfig struct {
+	DefaultLanguage    string      `gorm:"column:default_language;size:10" json:"default_language"`                       // Language of the untranslated texts
+	SupportedLanguages StringArray `gorm:"column:supported_languages;type:jsonb;default:'[]'" json:"supported_languages"` // Empty = any language with translations
+	ContactField       string      `gorm:"column:language_contact_field;size:100" json:"language_contact_field"`          // Contact metadata key holding the language
+	DetectLanguage     bool        `gorm:"column:detect_language;default:false" json:"detect_language"`                   // Detect the language from the first inbound message
 }
 
 // AIConfig holds AI provider settings
 type AIConfig struct {
-	Enabled        bool    `gorm:"column:ai_enabled;default:false" json:"ai_enabled"`
-	Provider       AIProvider `gorm:"column:ai_provider;size:20" json:"ai_provider"`                     // openai, anthropic, google
-	APIKey         string  `gorm:"column:ai_api_key;type:text" json:"-"`                                 // encrypted
-	Model          string  `gorm:"column:ai_model;size:100" json:"ai_model"`
-	MaxTokens      int     `gorm:"column:ai_max_tokens;default:500" json:"ai_max_tokens"`
-	Temperature    float64 `gorm:"column:ai_temperature;type:decimal(3,2);default:0.7" json:"ai_temperature"`
-	SystemPrompt   string  `gorm:"column:ai_system_prompt;type:text" json:"ai_system_prompt"`
-	IncludeHistory bool    `gorm:"column:ai_include_history;default:true" json:"ai_include_history"`
-	HistoryLimit   int     `gorm:"column:ai_history_limit;default:4" json:"ai_history_limit"`
+	Enabled        bool       `gorm:"column:ai_enabled;default:false" json:"ai_enabled"`
+	Provider       AIProvider `gorm:"column:ai_provider;size:20" json:"ai_provider"` // openai, anthropic, google
+	APIKey         string     `gorm:"column:ai_api_key;type:text" json:"-"`          // encrypted
+	Model          string     `gorm:"column:ai_model;size:100" json:"ai_model"`
+	MaxTokens      int        `gorm:"column:ai_max_tokens;default:500" json:"ai_max_tokens"`
+	Temperature    float64    `gorm:"column:ai_temperature;type:decimal(3,2);default:0.7" json:"ai_temperature"`
+	SystemPrompt   string     `gorm:"column:ai_system_prompt;type:text" json:"ai_system_prompt"`
+	IncludeHistory bool       `gorm:"column:ai_include_history;default:true" json:"ai_include_history"`
+	HistoryLimit   int        `gorm:"column:ai_history_limit;default:4" json:"ai_history_limit"`
 }
 
 // PanelFieldConfig defines a field to display in the contact info panel
@@ -99,7 +122,12 @@ type ChatbotSettings struct {
 	AgentAssignment  AgentAssignmentConfig  `gorm:"embedded"`
 	SLA              SLAConfig              `gorm:"embedded"`
 	ClientInactivity ClientInactivityConfig `gorm:"embedded"`
+	CSAT             CSATConfig             `gorm:"embedded"`
 	AI               AIConfig               `gorm:"embedded"`
+	Language         LanguageConfig         `gorm:"embedded"`
+
+	// Per-language variants of the texts above: {"es": {"default_response": "...", "greeting_buttons": ["..."]}}
+	Translations JSONB `gorm:"type:jsonb;default:'{}'" json:"translations"`
 
 	// Session settings
 	SessionTimeoutMins int        `gorm:"default:30" json:"session_timeout_minutes"`
@@ -141,22 +169,25 @@ func (KeywordRule) TableName() string {
 // ChatbotFlow defines multi-step conversation flows
 type ChatbotFlow struct {
 	BaseModel
-	OrganizationID     uuid.UUID   `gorm:"type:uuid;index;not null" json:"organization_id"`
-	WhatsAppAccount    string      `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
-	Name               string      `gorm:"size:255;not null" json:"name"`
-	IsEnabled          bool        `gorm:"default:true" json:"is_enabled"`
-	Description        string      `gorm:"type:text" json:"description"`
-	TriggerKeywords    StringArray `gorm:"type:jsonb" json:"trigger_keywords"`
-	TriggerButtonID    string      `gorm:"size:100" json:"trigger_button_id"`
+	OrganizationID     uuid.UUID    `gorm:"type:uuid;index;not null" json:"organization_id"`
+	WhatsAppAccount    string       `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
+	Name               string       `gorm:"size:255;not null" json:"name"`
+	IsEnabled          bool         `gorm:"default:true" json:"is_enabled"`
+	Description        string       `gorm:"type:text" json:"description"`
+	TriggerKeywords    StringArray  `gorm:"type:jsonb" json:"trigger_keywords"`
+	TriggerButtonID    string       `gorm:"size:100" json:"trigger_button_id"`
 	InitialMessage     string       `gorm:"type:text" json:"initial_message"`
 	InitialMessageType FlowStepType `gorm:"size:20;default:'text'" json:"initial_message_type"`
-	InitialTemplateID  *uuid.UUID  `gorm:"type:uuid" json:"initial_template_id,omitempty"`
-	CompletionMessage  string      `gorm:"type:text" json:"completion_message"`
-	OnCompleteAction   string      `gorm:"size:20" json:"on_complete_action"` // none, webhook, create_record
-	CompletionConfig   JSONB       `gorm:"type:jsonb" json:"completion_config"`
-	TimeoutMessage     string      `gorm:"type:text" json:"timeout_message"`
-	CancelKeywords     StringArray `gorm:"type:jsonb" json:"cancel_keywords"`
-	PanelConfig        JSONB       `gorm:"type:jsonb;default:'{}'" json:"panel_config"` // Contact info panel configuration
+	InitialTemplateID  *uuid.UUID   `gorm:"type:uuid" json:"initial_template_id,omitempty"`
+	CompletionMessage  string       `gorm:"type:text" json:"completion_message"`
+	OnCompleteAction   string       `gorm:"size:20" json:"on_complete_action"` // none, webhook, create_record
+	CompletionConfig   JSONB        `gorm:"type:jsonb" json:"completion_config"`
+	TimeoutMessage     string       `gorm:"type:text" json:"timeout_message"`
+	CancelKeywords     StringArray  `gorm:"type:jsonb" json:"cancel_keywords"`
+	PanelConfig        JSONB        `gorm:"type:jsonb;default:'{}'" json:"panel_config"` // Contact info panel configuration
+	PublishedVersion   int          `gorm:"default:0" json:"published_version"`          // Live version; 0 = never published, the draft is live
+	HasDraftChanges    bool         `gorm:"default:false" json:"has_draft_changes"`      // Draft differs from the published version
+	Translations       JSONB        `gorm:"type:jsonb;default:'{}'" json:"translations"` // {"es": {"initial_message": "...", "completion_message": "..."}}
 
 	// Relations
 	Organization    *Organization     `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
@@ -168,28 +199,54 @@ func (ChatbotFlow) TableName() string {
 	return "chatbot_flows"
 }
 
+// ChatbotFlowVersion is an immutable published snapshot of a flow and its steps.
+// The chatbot_flows/chatbot_flow_steps rows act as the editable draft.
+type ChatbotFlowVersion struct {
+	BaseModel
+	OrganizationID uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
+	FlowID         uuid.UUID  `gorm:"type:uuid;uniqueIndex:idx_flow_version;not null" json:"flow_id"`
+	Version        int        `gorm:"uniqueIndex:idx_flow_version;not null" json:"version"`
+	Note           string     `gorm:"type:text" json:"note"`
+	Snapshot       JSONB      `gorm:"type:jsonb;not null" json:"snapshot"` // ChatbotFlow with steps at publish time
+	RolledBackFrom int        `gorm:"default:0" json:"rolled_back_from"`   // Source version when created by a rollback
+	PublishedByID  *uuid.UUID `gorm:"type:uuid" json:"published_by_id,omitempty"`
+
+	// Relations
+	Flow        *ChatbotFlow `gorm:"foreignKey:FlowID" json:"flow,omitempty"`
+	PublishedBy *User        `gorm:"foreignKey:PublishedByID" json:"published_by,omitempty"`
+}
+
+func (ChatbotFlowVersion) TableName() string {
+	return "chatbot_flow_versions"
+}
+
 // ChatbotFlowStep defines individual steps in a conversation flow
 type ChatbotFlowStep struct {
 	BaseModel
-	FlowID          uuid.UUID  `gorm:"type:uuid;index;not null" json:"flow_id"`
-	StepName        string     `gorm:"size:100;not null" json:"step_name"`
-	StepOrder       int        `gorm:"not null" json:"step_order"`
+	FlowID          uuid.UUID    `gorm:"type:uuid;index;not null" json:"flow_id"`
+	StepName        string       `gorm:"size:100;not null" json:"step_name"`
+	StepOrder       int          `gorm:"not null" json:"step_order"`
 	Message         string       `gorm:"type:text;not null" json:"message"`
-	MessageType     FlowStepType `gorm:"size:20;default:'text'" json:"message_type"` // text, template, script, api_fetch, buttons, transfer
-	TemplateID      *uuid.UUID `gorm:"type:uuid" json:"template_id,omitempty"`
-	ApiConfig       JSONB      `gorm:"type:jsonb" json:"api_config"`      // {url, method, headers, body, response_path, fallback_message}
-	Buttons         JSONBArray `gorm:"type:jsonb" json:"buttons"`         // [{id, title}] - max 10 options (3=buttons, 4-10=list)
-	TransferConfig  JSONB      `gorm:"type:jsonb" json:"transfer_config"` // {team_id: uuid, notes: string} - for transfer message type
-	InputType       InputType  `gorm:"size:20" json:"input_type"`         // none, text, number, email, phone, date, select, button, whatsapp_flow
-	InputConfig     JSONB      `gorm:"type:jsonb" json:"input_config"`
-	ValidationRegex string     `gorm:"size:255" json:"validation_regex"`
-	ValidationError string     `gorm:"type:text" json:"validation_error"`
-	StoreAs         string     `gorm:"size:100" json:"store_as"`
-	NextStep        string     `gorm:"size:100" json:"next_step"`
-	ConditionalNext JSONB      `gorm:"type:jsonb" json:"conditional_next"` // {"option1": "step_a", "default": "step_b"}
-	SkipCondition   string     `gorm:"type:text" json:"skip_condition"`
-	RetryOnInvalid  bool       `gorm:"default:true" json:"retry_on_invalid"`
-	MaxRetries      int        `gorm:"default:3" json:"max_retries"`
+	MessageType     FlowStepType `gorm:"size:20;default:'text'" json:"message_type"` // text, template, script, api_fetch, buttons, transfer, goto_flow, call_flow, delay, set_variable, condition, media, location_request, assign_tag, language_select
+	TemplateID      *uuid.UUID   `gorm:"type:uuid" json:"template_id,omitempty"`
+	ApiConfig       JSONB        `gorm:"type:jsonb" json:"api_config"`      // {url, method, headers, body, response_path, fallback_message}
+	Buttons         JSONBArray   `gorm:"type:jsonb" json:"buttons"`         // [{id, title}] - max 10 options (3=buttons, 4-10=list)
+	TransferConfig  JSONB        `gorm:"type:jsonb" json:"transfer_config"` // {team_id: uuid, notes: string} - for transfer message type
+	JumpConfig      JSONB        `gorm:"type:jsonb" json:"jump_config"`     // {flow_id: uuid, step_name: string} - for goto_flow/call_flow message types
+	ActionConfig    JSONB        `gorm:"type:jsonb" json:"action_config"`   // Settings for delay, set_variable, condition and assign_tag message types
+	MediaConfig     JSONB        `gorm:"type:jsonb" json:"media_config"`    // {media_type, url, media_path, mime_type, filename, caption} - for media message type
+	InputType       InputType    `gorm:"size:20" json:"input_type"`         // none, text, number, email, phone, date, select, button, whatsapp_flow, location
+	InputConfig     JSONB        `gorm:"type:jsonb" json:"input_config"`
+	ValidationRegex string       `gorm:"size:255" json:"validation_regex"`
+	ValidationError string       `gorm:"type:text" json:"validation_error"`
+	StoreAs         string       `gorm:"size:100" json:"store_as"`
+	NextStep        string       `gorm:"size:100" json:"next_step"`
+	ConditionalNext JSONB        `gorm:"type:jsonb" json:"conditional_next"` // {"option1": "step_a", "default": "step_b"}
+	SkipCondition   string       `gorm:"type:text" json:"skip_condition"`
+	RetryOnInvalid  bool         `gorm:"default:true" json:"retry_on_invalid"`
+	MaxRetries      int          `gorm:"default:3" json:"max_retries"`
+	Position        JSONB        `gorm:"type:jsonb" json:"position"`                  // {x, y} - node position in the visual builder
+	Translations    JSONB        `gorm:"type:jsonb;default:'{}'" json:"translations"` // {"es": {"message": "...", "validation_error": "...", "buttons": ["..."], "caption": "..."}}
 
 	// Relations
 	Flow     *ChatbotFlow `gorm:"foreignKey:FlowID" json:"flow,omitempty"`
@@ -203,18 +260,21 @@ func (ChatbotFlowStep) TableName() string {
 // ChatbotSession tracks active conversation sessions
 type ChatbotSession struct {
 	BaseModel
-	OrganizationID  uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
-	ContactID       uuid.UUID  `gorm:"type:uuid;index;not null" json:"contact_id"`
-	WhatsAppAccount string     `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
-	PhoneNumber     string     `gorm:"size:50;not null" json:"phone_number"`
-	Status          SessionStatus `gorm:"size:20;default:'active'" json:"status"` // active, completed, cancelled, timeout
-	CurrentFlowID   *uuid.UUID `gorm:"type:uuid" json:"current_flow_id,omitempty"`
-	CurrentStep     string     `gorm:"size:100" json:"current_step"`
-	StepRetries     int        `gorm:"default:0" json:"step_retries"`
-	SessionData     JSONB      `gorm:"type:jsonb;default:'{}'" json:"session_data"`
-	StartedAt       time.Time  `gorm:"autoCreateTime" json:"started_at"`
-	LastActivityAt  time.Time  `json:"last_activity_at"`
-	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	OrganizationID     uuid.UUID     `gorm:"type:uuid;index;not null" json:"organization_id"`
+	ContactID          uuid.UUID     `gorm:"type:uuid;index;not null" json:"contact_id"`
+	WhatsAppAccount    string        `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
+	PhoneNumber        string        `gorm:"size:50;not null" json:"phone_number"`
+	Status             SessionStatus `gorm:"size:20;default:'active'" json:"status"` // active, completed, cancelled, timeout
+	CurrentFlowID      *uuid.UUID    `gorm:"type:uuid" json:"current_flow_id,omitempty"`
+	CurrentFlowVersion int           `gorm:"default:0" json:"current_flow_version"` // Flow version the session is pinned to (0 = draft)
+	CurrentStep        string        `gorm:"size:100" json:"current_step"`
+	StepRetries        int           `gorm:"default:0" json:"step_retries"`
+	ResumeAt           *time.Time    `gorm:"index" json:"resume_at,omitempty"` // When a delay step continues the flow
+	SessionData        JSONB         `gorm:"type:jsonb;default:'{}'" json:"session_data"`
+	FlowSnapshots      JSONB         `gorm:"type:jsonb;default:'{}'" json:"-"` // Flow ID -> draft of a never-published flow, as it was when the session entered it
+	StartedAt          time.Time     `gorm:"autoCreateTime" json:"started_at"`
+	LastActivityAt     time.Time     `json:"last_activity_at"`
+	CompletedAt        *time.Time    `json:"completed_at,omitempty"`
 
 	// Relations
 	Organization *Organization           `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
@@ -266,34 +326,109 @@ func (AIContext) TableName() string {
 
 // SLATracking holds SLA-related tracking fields for agent transfers
 type SLATracking struct {
-	ResponseDeadline   *time.Time `gorm:"column:sla_response_deadline;index" json:"sla_response_deadline,omitempty"`   // When pickup is due
+	ResponseDeadline   *time.Time `gorm:"column:sla_response_deadline;index" json:"sla_response_deadline,omitempty"`     // When pickup is due
 	ResolutionDeadline *time.Time `gorm:"column:sla_resolution_deadline;index" json:"sla_resolution_deadline,omitempty"` // When resolution is due
-	EscalationAt       *time.Time `gorm:"column:sla_escalation_at" json:"sla_escalation_at,omitempty"`                  // When escalation is due
-	ExpiresAt          *time.Time `gorm:"column:expires_at;index" json:"expires_at,omitempty"`                          // Auto-close deadline
-	PickedUpAt         *time.Time `gorm:"column:picked_up_at" json:"picked_up_at,omitempty"`                            // When agent first picked up
-	FirstResponseAt    *time.Time `gorm:"column:first_response_at" json:"first_response_at,omitempty"`                  // When agent first responded
-	EscalationLevel    int        `gorm:"column:escalation_level;default:0" json:"escalation_level"`                    // 0=normal, 1=warning, 2=escalated, 3=critical
-	EscalatedAt        *time.Time `gorm:"column:escalated_at" json:"escalated_at,omitempty"`                            // When escalation occurred
-	Breached           bool       `gorm:"column:sla_breached;default:false" json:"sla_breached"`                        // Whether SLA was breached
-	BreachedAt         *time.Time `gorm:"column:sla_breached_at" json:"sla_breached_at,omitempty"`                      // When SLA was breached
+	EscalationAt       *time.Time `gorm:"column:sla_escalation_at" json:"sla_escalation_at,omitempty"`                   // When escalation is due
+	ExpiresAt          *time.Time `gorm:"column:expires_at;index" json:"expires_at,omitempty"`                           // Auto-close deadline
+	PickedUpAt         *time.Time `gorm:"column:picked_up_at" json:"picked_up_at,omitempty"`                             // When agent first picked up
+	FirstResponseAt    *time.Time `gorm:"column:first_response_at" json:"first_response_at,omitempty"`                   // When agent first responded
+	EscalationLevel    int        `gorm:"column:escalation_level;default:0" json:"escalation_level"`                     // 0=normal, 1=warning, 2=escalated, 3=critical
+	EscalatedAt        *time.Time `gorm:"column:escalated_at" json:"escalated_at,omitempty"`                             // When escalation occurred
+	Breached           bool       `gorm:"column:sla_breached;default:false" json:"sla_breached"`                         // Whether SLA was breached
+	BreachedAt         *time.Time `gorm:"column:sla_breached_at" json:"sla_breached_at,omitempty"`                       // When SLA was breached
+	WarningSentAt      *time.Time `gorm:"column:sla_warning_sent_at" json:"sla_warning_sent_at,omitempty"`               // When the customer was sent the SLA warning
+
+	// Policy and next response tracking
+	PolicyID             *uuid.UUID `gorm:"column:sla_policy_id;type:uuid;index" json:"sla_policy_id,omitempty"`                 // SLA policy applied (null = chatbot settings)
+	NextResponseMinutes  int        `gorm:"column:sla_next_response_minutes;default:0" json:"sla_next_response_minutes"`         // Target for replies after the first response
+	NextResponseDeadline *time.Time `gorm:"column:sla_next_response_deadline;index" json:"sla_next_response_deadline,omitempty"` // When the reply to the customer's latest message is due
+	NextResponses        int        `gorm:"column:sla_next_responses;default:0" json:"sla_next_responses"`                       // Replies that were due
+	NextResponseBreaches int        `gorm:"column:sla_next_response_breaches;default:0" json:"sla_next_response_breaches"`       // Replies that were late
+}
+
+// SLAPolicy holds named SLA targets for the transfers matching its rules.
+// Policies are matched highest priority first; transfers matching none use
+// the SLA settings of the chatbot settings.
+type SLAPolicy struct {
+	BaseModel
+	OrganizationID uuid.UUID `gorm:"type:uuid;index;not null" json:"organization_id"`
+	Name           string    `gorm:"size:100;not null" json:"name"`
+	Description    string    `gorm:"size:500" json:"description"`
+	Priority       int       `gorm:"default:0" json:"priority"`
+	IsActive       bool      `json:"is_active"` // No default, so inactive policies can be created
+
+	// Rules (all given rules must match, empty = any)
+	TeamIDs          StringArray `gorm:"type:jsonb;default:'[]'" json:"team_ids"`
+	Tags             StringArray `gorm:"type:jsonb;default:'[]'" json:"tags"`              // Contact has any of these tags
+	WhatsAppAccounts StringArray `gorm:"type:jsonb;default:'[]'" json:"whatsapp_accounts"` // References WhatsAppAccount.Name
+	MinPriority      *int        `json:"min_priority,omitempty"`                           // Transfer priority at least this
+
+	// Targets in minutes (0 = not tracked)
+	FirstResponseMinutes int `gorm:"default:0" json:"first_response_minutes"`
+	NextResponseMinutes  int `gorm:"default:0" json:"next_response_minutes"`
+	ResolutionMinutes    int `gorm:"default:0" json:"resolution_minutes"`
+
+	// Escalation chain: [{after_minutes, notify_user_ids, notify_team_ids}]
+	EscalationLevels JSONBArray `gorm:"type:jsonb;default:'[]'" json:"escalation_levels"`
+
+	// Relations
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+}
+
+func (SLAPolicy) TableName() string {
+	return "sla_policies"
+}
+
+// CSATSurvey is a satisfaction survey sent to a customer after their
+// transfer was closed, with the customer's response
+type CSATSurvey struct {
+	BaseModel
+	OrganizationID  uuid.UUID        `gorm:"type:uuid;index;not null" json:"organization_id"`
+	TransferID      uuid.UUID        `gorm:"type:uuid;uniqueIndex;not null" json:"transfer_id"`
+	ContactID       uuid.UUID        `gorm:"type:uuid;index;not null" json:"contact_id"`
+	AgentID         *uuid.UUID       `gorm:"type:uuid;index" json:"agent_id,omitempty"`
+	TeamID          *uuid.UUID       `gorm:"type:uuid" json:"team_id,omitempty"`
+	WhatsAppAccount string           `gorm:"size:100;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
+	Scale           CSATScale        `gorm:"size:10;not null" json:"scale"`
+	Channel         CSATChannel      `gorm:"size:10;not null" json:"channel"`
+	Status          CSATSurveyStatus `gorm:"size:20;default:'scheduled';index" json:"status"`
+	SendAt          time.Time        `gorm:"index" json:"send_at"`
+	SentAt          *time.Time       `json:"sent_at,omitempty"`
+	AnsweredAt      *time.Time       `json:"answered_at,omitempty"`
+	Score           *int             `json:"score,omitempty"`
+	Comment         string           `gorm:"type:text" json:"comment"`
+
+	// Relations
+	Transfer *AgentTransfer `gorm:"foreignKey:TransferID" json:"transfer,omitempty"`
+	Contact  *Contact       `gorm:"foreignKey:ContactID" json:"contact,omitempty"`
+	Agent    *User          `gorm:"foreignKey:AgentID" json:"agent,omitempty"`
+}
+
+func (CSATSurvey) TableName() string {
+	return "csat_surveys"
 }
 
 // AgentTransfer tracks when conversations are transferred to human agents
 type AgentTransfer struct {
 	BaseModel
-	OrganizationID      uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
-	ContactID           uuid.UUID  `gorm:"type:uuid;index;not null" json:"contact_id"`
-	WhatsAppAccount     string     `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
-	PhoneNumber         string     `gorm:"size:50;not null" json:"phone_number"`
+	OrganizationID      uuid.UUID      `gorm:"type:uuid;index;not null" json:"organization_id"`
+	ContactID           uuid.UUID      `gorm:"type:uuid;index;not null" json:"contact_id"`
+	WhatsAppAccount     string         `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
+	PhoneNumber         string         `gorm:"size:50;not null" json:"phone_number"`
 	Status              TransferStatus `gorm:"size:20;default:'active'" json:"status"` // active, resumed
 	Source              TransferSource `gorm:"size:20;default:'manual'" json:"source"` // manual, flow, keyword, chatbot_disabled
-	AgentID             *uuid.UUID `gorm:"type:uuid" json:"agent_id,omitempty"`
-	TeamID              *uuid.UUID `gorm:"type:uuid;index" json:"team_id,omitempty"` // Team queue (null = general queue)
-	TransferredByUserID *uuid.UUID `gorm:"type:uuid" json:"transferred_by_user_id,omitempty"` // User who initiated the transfer (null for system)
-	Notes               string     `gorm:"type:text" json:"notes"`
-	TransferredAt       time.Time  `gorm:"autoCreateTime" json:"transferred_at"`
-	ResumedAt           *time.Time `json:"resumed_at,omitempty"`
-	ResumedBy           *uuid.UUID `gorm:"type:uuid" json:"resumed_by,omitempty"`
+	AgentID             *uuid.UUID     `gorm:"type:uuid" json:"agent_id,omitempty"`
+	TeamID              *uuid.UUID     `gorm:"type:uuid;index" json:"team_id,omitempty"`          // Team queue (null = general queue)
+	TransferredByUserID *uuid.UUID     `gorm:"type:uuid" json:"transferred_by_user_id,omitempty"` // User who initiated the transfer (null for system)
+	Notes               string         `gorm:"type:text" json:"notes"`
+	TransferredAt       time.Time      `gorm:"autoCreateTime" json:"transferred_at"`
+	ResumedAt           *time.Time     `json:"resumed_at,omitempty"`
+	ResumedBy           *uuid.UUID     `gorm:"type:uuid" json:"resumed_by,omitempty"`
+
+	// Routing
+	Priority        int         `gorm:"default:0;index" json:"priority"`                // Higher priorities are picked first
+	RequiredSkills  StringArray `gorm:"type:jsonb;default:'[]'" json:"required_skills"` // Skills the assigned agent must have
+	RoutingDecision JSONB       `gorm:"type:jsonb" json:"routing_decision,omitempty"`   // Why the agent (or queue) was chosen
 
 	// SLA Tracking (embedded - all fields stored in same table)
 	SLA SLATracking `gorm:"embedded"`
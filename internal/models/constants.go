@@ -31,6 +31,10 @@ const (
 	MessageTypeReaction    MessageType = "reaction"
 	MessageTypeLocation    MessageType = "location"
 	MessageTypeContact     MessageType = "contact"
+	MessageTypeOrder       MessageType = "order"       // Cart sent from a catalog
+	MessageTypeButton      MessageType = "button"      // Quick reply tap on a template message
+	MessageTypeSystem      MessageType = "system"      // e.g. the customer changed their number
+	MessageTypeUnsupported MessageType = "unsupported" // Types the Cloud API does not deliver
 )
 
 // MessageStatus represents the delivery status of a message
@@ -45,6 +49,15 @@ const (
 	MessageStatusReceived  MessageStatus = "received"
 )
 
+// MessageRevisionAction is how a message changed after it was sent
+type MessageRevisionAction string
+
+const (
+	MessageRevisionEdited    MessageRevisionAction = "edited"    // The customer edited the message
+	MessageRevisionDeleted   MessageRevisionAction = "deleted"   // The customer deleted the message for everyone
+	MessageRevisionRetracted MessageRevisionAction = "retracted" // An agent retracted an outgoing message
+)
+
 // AIProvider represents supported AI providers
 type AIProvider string
 
@@ -80,13 +93,22 @@ const (
 type FlowStepType string
 
 const (
-	FlowStepTypeText         FlowStepType = "text"
-	FlowStepTypeTemplate     FlowStepType = "template"
-	FlowStepTypeScript       FlowStepType = "script"
-	FlowStepTypeAPIFetch     FlowStepType = "api_fetch"
-	FlowStepTypeButtons      FlowStepType = "buttons"
-	FlowStepTypeTransfer     FlowStepType = "transfer"
-	FlowStepTypeWhatsAppFlow FlowStepType = "whatsapp_flow"
+	FlowStepTypeText            FlowStepType = "text"
+	FlowStepTypeTemplate        FlowStepType = "template"
+	FlowStepTypeScript          FlowStepType = "script"
+	FlowStepTypeAPIFetch        FlowStepType = "api_fetch"
+	FlowStepTypeButtons         FlowStepType = "buttons"
+	FlowStepTypeTransfer        FlowStepType = "transfer"
+	FlowStepTypeWhatsAppFlow    FlowStepType = "whatsapp_flow"
+	FlowStepTypeGotoFlow        FlowStepType = "goto_flow"        // Jump into another flow (no return)
+	FlowStepTypeCallFlow        FlowStepType = "call_flow"        // Run another flow as a sub-flow, then return
+	FlowStepTypeDelay           FlowStepType = "delay"            // Wait, then continue the flow
+	FlowStepTypeSetVariable     FlowStepType = "set_variable"     // Set session variables
+	FlowStepTypeCondition       FlowStepType = "condition"        // Branch on session data without sending a message
+	FlowStepTypeMedia           FlowStepType = "media"            // Send an image, document, video or audio file
+	FlowStepTypeLocationRequest FlowStepType = "location_request" // Ask the customer to share their location
+	FlowStepTypeAssignTag       FlowStepType = "assign_tag"       // Add or remove contact tags
+	FlowStepTypeLanguageSelect  FlowStepType = "language_select"  // Let the customer pick the chatbot language
 )
 
 // SessionStatus represents chatbot session states
@@ -118,6 +140,34 @@ const (
 	TransferSourceChatbotDisabled TransferSource = "chatbot_disabled"
 )
 
+// CSATScale represents the rating scale of satisfaction surveys
+type CSATScale string
+
+const (
+	CSATScaleCSAT CSATScale = "csat" // 1-5 rating
+	CSATScaleNPS  CSATScale = "nps"  // 0-10 likelihood to recommend
+)
+
+// CSATChannel represents how satisfaction surveys are sent
+type CSATChannel string
+
+const (
+	CSATChannelButtons CSATChannel = "buttons" // Interactive reply buttons (a list above 3 options)
+	CSATChannelFlow    CSATChannel = "flow"    // WhatsApp Flow
+)
+
+// CSATSurveyStatus represents satisfaction survey states
+type CSATSurveyStatus string
+
+const (
+	CSATSurveyStatusScheduled CSATSurveyStatus = "scheduled"
+	CSATSurveyStatusSent      CSATSurveyStatus = "sent"
+	CSATSurveyStatusAnswered  CSATSurveyStatus = "answered"
+	CSATSurveyStatusCancelled CSATSurveyStatus = "cancelled" // The contact was transferred again before it was sent
+	CSATSurveyStatusFailed    CSATSurveyStatus = "failed"
+	CSATSurveyStatusExpired   CSATSurveyStatus = "expired" // The customer service window closed before it was sent
+)
+
 // CampaignStatus represents bulk message campaign states
 type CampaignStatus string
 
@@ -171,6 +221,7 @@ const (
 	InputTypeSelect       InputType = "select"
 	InputTypeButton       InputType = "button"
 	InputTypeWhatsAppFlow InputType = "whatsapp_flow"
+	InputTypeLocation     InputType = "location"
 )
 
 // AssignmentStrategy represents team assignment strategies
@@ -182,6 +233,28 @@ const (
 	AssignmentStrategyManual       AssignmentStrategy = "manual"
 )
 
+// AwayReason represents why an agent is away
+type AwayReason string
+
+const (
+	AwayReasonBreak    AwayReason = "break"
+	AwayReasonLunch    AwayReason = "lunch"
+	AwayReasonMeeting  AwayReason = "meeting"
+	AwayReasonTraining AwayReason = "training"
+	AwayReasonOther    AwayReason = "other"
+	AwayReasonIdle     AwayReason = "idle"
+	AwayReasonOffShift AwayReason = "off_shift"
+)
+
+// AvailabilitySource represents what changed an agent's availability
+type AvailabilitySource string
+
+const (
+	AvailabilitySourceManual   AvailabilitySource = "manual"
+	AvailabilitySourceSchedule AvailabilitySource = "schedule"
+	AvailabilitySourceIdle     AvailabilitySource = "idle"
+)
+
 // SSOProviderType represents supported SSO providers
 type SSOProviderType string
 
@@ -204,6 +277,30 @@ const (
 	WebhookEventTransferCreated  WebhookEvent = "transfer.created"
 	WebhookEventTransferResumed  WebhookEvent = "transfer.resumed"
 	WebhookEventTransferAssigned WebhookEvent = "transfer.assigned"
+	WebhookEventNoteMention      WebhookEvent = "note.mention"
+	WebhookEventAccountUpdated   WebhookEvent = "account.updated"
+	WebhookEventTemplateUpdated  WebhookEvent = "template.updated"
+	WebhookEventOrderCreated     WebhookEvent = "order.created"
+	WebhookEventOrderUpdated     WebhookEvent = "order.updated"
+)
+
+// CannedResponseScope represents who can see and use a canned response
+type CannedResponseScope string
+
+const (
+	CannedResponseScopePersonal     CannedResponseScope = "personal"
+	CannedResponseScopeTeam         CannedResponseScope = "team"
+	CannedResponseScopeOrganization CannedResponseScope = "organization"
+)
+
+// NotificationType represents user notification types
+type NotificationType string
+
+const (
+	NotificationTypeMention   NotificationType = "mention"
+	NotificationTypeNoteReply NotificationType = "note_reply"
+	NotificationTypeAccount   NotificationType = "account"
+	NotificationTypeTemplate  NotificationType = "template"
 )
 
 // ActionType represents custom action types
@@ -214,3 +311,53 @@ const (
 	ActionTypeURL        ActionType = "url"
 	ActionTypeJavascript ActionType = "javascript"
 )
+
+// OrderStatus represents the state of an order placed in a conversation
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending" // Cart received, not yet reviewed
+	OrderStatusConfirmed OrderStatus = "confirmed"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// ProductSyncStatus represents whether a catalog product's latest changes reached Meta
+type ProductSyncStatus string
+
+const (
+	ProductSyncStatusSynced  ProductSyncStatus = "synced"
+	ProductSyncStatusPending ProductSyncStatus = "pending" // Submitted in a batch Meta is still processing
+	ProductSyncStatusFailed  ProductSyncStatus = "failed"
+)
+
+// CatalogBatchStatus represents the state of a batch of product changes sent to Meta
+type CatalogBatchStatus string
+
+const (
+	CatalogBatchStatusProcessing CatalogBatchStatus = "processing"
+	CatalogBatchStatusCompleted  CatalogBatchStatus = "completed" // Some products may still have failed, see the errors
+	CatalogBatchStatusFailed     CatalogBatchStatus = "failed"
+)
+
+// CatalogScheduledUpdateStatus represents the state of a scheduled price or inventory update
+type CatalogScheduledUpdateStatus string
+
+const (
+	CatalogScheduledUpdateStatusScheduled CatalogScheduledUpdateStatus = "scheduled"
+	CatalogScheduledUpdateStatusApplied   CatalogScheduledUpdateStatus = "applied"
+	CatalogScheduledUpdateStatusFailed    CatalogScheduledUpdateStatus = "failed"
+	CatalogScheduledUpdateStatusCancelled CatalogScheduledUpdateStatus = "cancelled"
+)
+
+// OTPStatus represents the state of a one-time password sent with an authentication template
+type OTPStatus string
+
+const (
+	OTPStatusPending   OTPStatus = "pending"
+	OTPStatusVerified  OTPStatus = "verified"
+	OTPStatusExpired   OTPStatus = "expired"
+	OTPStatusFailed    OTPStatus = "failed"    // Too many wrong attempts
+	OTPStatusCancelled OTPStatus = "cancelled" // Replaced by a newer code for the same number
+)
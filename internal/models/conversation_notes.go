@@ -1,23 +1,68 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
 // ConversationNote represents a private internal note on a contact, visible only to agents.
+// Notes with a ParentID are replies in the thread of that note.
 type ConversationNote struct {
 	BaseModel
-	OrganizationID uuid.UUID `gorm:"type:uuid;index;not null" json:"organization_id"`
-	ContactID      uuid.UUID `gorm:"type:uuid;index;not null" json:"contact_id"`
-	CreatedByID    uuid.UUID `gorm:"type:uuid;not null" json:"created_by_id"`
-	Content        string    `gorm:"type:text;not null" json:"content"`
+	OrganizationID uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
+	ContactID      uuid.UUID  `gorm:"type:uuid;index;not null" json:"contact_id"`
+	ParentID       *uuid.UUID `gorm:"type:uuid;index" json:"parent_id,omitempty"`
+	CreatedByID    uuid.UUID  `gorm:"type:uuid;not null" json:"created_by_id"`
+	Content        string     `gorm:"type:text;not null" json:"content"` // May contain @[Name](user:<id>) and @[Name](team:<id>) mentions
 
 	// Relations
-	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
-	Contact      *Contact      `gorm:"foreignKey:ContactID" json:"contact,omitempty"`
-	CreatedBy    *User         `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	Organization *Organization                `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+	Contact      *Contact                     `gorm:"foreignKey:ContactID" json:"contact,omitempty"`
+	CreatedBy    *User                        `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	Attachments  []ConversationNoteAttachment `gorm:"foreignKey:NoteID" json:"attachments,omitempty"`
 }
 
 func (ConversationNote) TableName() string {
 	return "conversation_notes"
 }
+
+// ConversationNoteAttachment is a file attached to a conversation note. It is
+// uploaded before the note is created and linked to it on creation.
+type ConversationNoteAttachment struct {
+	BaseModel
+	OrganizationID uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
+	ContactID      uuid.UUID  `gorm:"type:uuid;index;not null" json:"contact_id"`
+	NoteID         *uuid.UUID `gorm:"type:uuid;index" json:"note_id,omitempty"`
+	UploadedByID   uuid.UUID  `gorm:"type:uuid;not null" json:"uploaded_by_id"`
+	FileName       string     `gorm:"size:255" json:"file_name"`
+	MimeType       string     `gorm:"size:100" json:"mime_type"`
+	Size           int64      `json:"size"`
+	Path           string     `gorm:"size:500;not null" json:"-"` // Relative to media storage
+}
+
+func (ConversationNoteAttachment) TableName() string {
+	return "conversation_note_attachments"
+}
+
+// UserNotification is an entry in a user's notification inbox
+type UserNotification struct {
+	BaseModel
+	OrganizationID uuid.UUID        `gorm:"type:uuid;index;not null" json:"organization_id"`
+	UserID         uuid.UUID        `gorm:"type:uuid;index:idx_user_notifications_inbox;not null" json:"user_id"`
+	Type           NotificationType `gorm:"size:30;not null" json:"type"`
+	ActorID        *uuid.UUID       `gorm:"type:uuid" json:"actor_id,omitempty"`
+	ContactID      *uuid.UUID       `gorm:"type:uuid" json:"contact_id,omitempty"`
+	NoteID         *uuid.UUID       `gorm:"type:uuid;index" json:"note_id,omitempty"`
+	Title          string           `gorm:"size:255" json:"title"`
+	Body           string           `gorm:"type:text" json:"body"`
+	ReadAt         *time.Time       `gorm:"index:idx_user_notifications_inbox" json:"read_at,omitempty"`
+
+	// Relations
+	Actor   *User    `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Contact *Contact `gorm:"foreignKey:ContactID" json:"contact,omitempty"`
+}
+
+func (UserNotification) TableName() string {
+	return "user_notifications"
+}
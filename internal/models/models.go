@@ -111,8 +111,17 @@ type User struct {
 	RoleID         *uuid.UUID `gorm:"type:uuid;index" json:"role_id,omitempty"`
 	Settings       JSONB      `gorm:"type:jsonb;default:'{}'" json:"settings"`
 	IsActive       bool       `gorm:"default:true" json:"is_active"`
-	IsAvailable    bool       `gorm:"default:true" json:"is_available"` // Agent availability status (away/available)
-	IsSuperAdmin   bool       `gorm:"default:false" json:"is_super_admin"`  // Super admin can access all organizations
+	IsAvailable    bool       `gorm:"default:true" json:"is_available"`    // Agent availability status (away/available)
+	IsSuperAdmin   bool       `gorm:"default:false" json:"is_super_admin"` // Super admin can access all organizations
+
+	// Transfer routing
+	MaxConcurrentChats int         `gorm:"default:0" json:"max_concurrent_chats"`    // 0 = unlimited
+	Skills             StringArray `gorm:"type:jsonb;default:'[]'" json:"skills"`    // Matched against transfer required skills
+	Languages          StringArray `gorm:"type:jsonb;default:'[]'" json:"languages"` // Languages the agent can chat in
+
+	// Shift schedule: weekly hours in the user's timezone that set availability
+	Timezone      string     `gorm:"size:64" json:"timezone"`                       // IANA timezone, empty = organization timezone
+	ShiftSchedule JSONBArray `gorm:"type:jsonb;default:'[]'" json:"shift_schedule"` // [{day, enabled, start_time, end_time} or {day, enabled, intervals}]
 
 	// SSO fields
 	SSOProvider   string `gorm:"size:50" json:"sso_provider,omitempty"`     // google, microsoft, github, facebook, custom
@@ -148,12 +157,14 @@ func (UserOrganization) TableName() string {
 
 // UserAvailabilityLog tracks user availability changes for break time calculation
 type UserAvailabilityLog struct {
-	ID             uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	UserID         uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
-	OrganizationID uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
-	IsAvailable    bool       `gorm:"not null" json:"is_available"`
-	StartedAt      time.Time  `gorm:"not null" json:"started_at"`
-	EndedAt        *time.Time `json:"ended_at,omitempty"` // null means current status
+	ID             uuid.UUID          `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID         uuid.UUID          `gorm:"type:uuid;index;not null" json:"user_id"`
+	OrganizationID uuid.UUID          `gorm:"type:uuid;index;not null" json:"organization_id"`
+	IsAvailable    bool               `gorm:"not null" json:"is_available"`
+	Reason         AwayReason         `gorm:"size:50" json:"reason,omitempty"`        // Why the user went away
+	Source         AvailabilitySource `gorm:"size:20;default:'manual'" json:"source"` // manual, schedule, idle
+	StartedAt      time.Time          `gorm:"not null" json:"started_at"`
+	EndedAt        *time.Time         `json:"ended_at,omitempty"` // null means current status
 
 	// Relations
 	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -166,11 +177,12 @@ func (UserAvailabilityLog) TableName() string {
 // Team represents a group of agents handling specific types of chats
 type Team struct {
 	BaseModel
-	OrganizationID     uuid.UUID `gorm:"type:uuid;index;not null" json:"organization_id"`
-	Name               string    `gorm:"size:100;not null" json:"name"`
-	Description        string    `gorm:"size:500" json:"description"`
+	OrganizationID     uuid.UUID          `gorm:"type:uuid;index;not null" json:"organization_id"`
+	Name               string             `gorm:"size:100;not null" json:"name"`
+	Description        string             `gorm:"size:500" json:"description"`
 	AssignmentStrategy AssignmentStrategy `gorm:"size:50;default:'round_robin'" json:"assignment_strategy"` // round_robin, load_balanced, manual
-	IsActive           bool      `gorm:"default:true" json:"is_active"`
+	OverflowTeamID     *uuid.UUID         `gorm:"type:uuid" json:"overflow_team_id,omitempty"`              // Team to route to when no agent is available
+	IsActive           bool               `gorm:"default:true" json:"is_active"`
 
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
@@ -299,6 +311,27 @@ type WhatsAppAccount struct {
 	AutoReadReceipt    bool      `gorm:"default:false" json:"auto_read_receipt"`
 	Status             string    `gorm:"size:20;default:'active'" json:"status"`
 
+	// Phone number and account health, reported by Meta
+	DisplayPhoneNumber  string     `gorm:"size:50" json:"display_phone_number"`
+	VerifiedName        string     `gorm:"size:255" json:"verified_name"`
+	NameStatus          string     `gorm:"size:20" json:"name_status"` // APPROVED, REJECTED, DEFERRED
+	NameRejectionReason string     `gorm:"type:text" json:"name_rejection_reason"`
+	QualityRating       string     `gorm:"size:20" json:"quality_rating"`       // GREEN, YELLOW, RED, UNKNOWN
+	QualityEvent        string     `gorm:"size:30" json:"quality_event"`        // FLAGGED, UNFLAGGED, UPGRADE, DOWNGRADE, ONBOARDING
+	MessagingLimitTier  string     `gorm:"size:30" json:"messaging_limit_tier"` // TIER_250, TIER_1K, TIER_10K, TIER_100K, TIER_UNLIMITED
+	AccountEvent        string     `gorm:"size:50" json:"account_event"`        // Latest account_update event, e.g. ACCOUNT_VIOLATION
+	BanState            string     `gorm:"size:30" json:"ban_state"`            // SCHEDULE_FOR_DISABLE, DISABLE, REINSTATE
+	Restrictions        JSONBArray `gorm:"type:jsonb;default:'[]'" json:"restrictions"`
+	Capabilities        JSONB      `gorm:"type:jsonb;default:'{}'" json:"capabilities"` // Business capability limits
+	HealthUpdatedAt     *time.Time `json:"health_updated_at,omitempty"`
+
+	// WhatsApp Flows data endpoint encryption
+	FlowPrivateKey        string     `gorm:"type:text" json:"-"` // encrypted, PEM encoded RSA key
+	FlowPendingPrivateKey string     `gorm:"type:text" json:"-"` // encrypted key whose public key is being uploaded
+	FlowPublicKey         string     `gorm:"type:text" json:"flow_public_key"`
+	FlowPublicKeyStatus   string     `gorm:"size:20" json:"flow_public_key_status"` // VALID, MISMATCH
+	FlowKeyUploadedAt     *time.Time `json:"flow_key_uploaded_at,omitempty"`
+
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
 }
@@ -320,6 +353,13 @@ type Contact struct {
 	IsRead             bool       `gorm:"default:true" json:"is_read"`
 	Tags               JSONBArray `gorm:"type:jsonb;default:'[]'" json:"tags"`
 	Metadata           JSONB      `gorm:"type:jsonb;default:'{}'" json:"metadata"`
+	Language           string     `gorm:"size:20" json:"language"` // Chatbot language, chosen or detected
+
+	// Click-to-WhatsApp ad that last brought the contact in
+	ReferralSourceType string     `gorm:"size:20" json:"referral_source_type,omitempty"` // ad or post
+	ReferralSourceID   string     `gorm:"size:100;index" json:"referral_source_id,omitempty"`
+	ReferralSourceURL  string     `gorm:"size:500" json:"referral_source_url,omitempty"`
+	ReferredAt         *time.Time `json:"referred_at,omitempty"`
 
 	// Chatbot SLA tracking
 	ChatbotLastMessageAt *time.Time `json:"chatbot_last_message_at,omitempty"` // When chatbot last sent a message
@@ -335,30 +375,58 @@ func (Contact) TableName() string {
 	return "contacts"
 }
 
+// ContactReferral records a message a contact sent from a click-to-WhatsApp ad or post
+type ContactReferral struct {
+	BaseModel
+	OrganizationID  uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
+	ContactID       uuid.UUID  `gorm:"type:uuid;index;not null" json:"contact_id"`
+	MessageID       *uuid.UUID `gorm:"type:uuid" json:"message_id,omitempty"`
+	WhatsAppAccount string     `gorm:"size:100" json:"whatsapp_account"`
+	SourceType      string     `gorm:"size:20" json:"source_type"` // ad or post
+	SourceID        string     `gorm:"size:100;index" json:"source_id"`
+	SourceURL       string     `gorm:"size:500" json:"source_url"`
+	Headline        string     `gorm:"size:500" json:"headline"`
+	Body            string     `gorm:"type:text" json:"body"`
+	MediaType       string     `gorm:"size:20" json:"media_type"`
+	MediaURL        string     `gorm:"size:1000" json:"media_url"`
+	CtwaClid        string     `gorm:"size:255" json:"ctwa_clid"` // Click ID for conversion reporting
+	IsNewContact    bool       `json:"is_new_contact"`
+
+	// Relations
+	Contact *Contact `gorm:"foreignKey:ContactID" json:"contact,omitempty"`
+}
+
+func (ContactReferral) TableName() string {
+	return "contact_referrals"
+}
+
 // Message represents a WhatsApp message
 type Message struct {
 	BaseModel
-	OrganizationID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
-	WhatsAppAccount   string     `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
-	ContactID         uuid.UUID  `gorm:"type:uuid;index;not null" json:"contact_id"`
-	WhatsAppMessageID string     `gorm:"column:whats_app_message_id;size:255;index" json:"whatsapp_message_id"`
-	ConversationID    string     `gorm:"size:255;index" json:"conversation_id"`
-	Direction         Direction   `gorm:"size:10;not null" json:"direction"`
-	MessageType       MessageType `gorm:"size:20;not null" json:"message_type"`
-	Content           string     `gorm:"type:text" json:"content"`
-	MediaURL          string     `gorm:"type:text" json:"media_url"`
-	MediaMimeType     string     `gorm:"size:100" json:"media_mime_type"`
-	MediaFilename     string     `gorm:"size:255" json:"media_filename"`
-	TemplateName      string     `gorm:"size:255" json:"template_name"`
-	TemplateParams    JSONB      `gorm:"type:jsonb" json:"template_params"`
-	InteractiveData   JSONB      `gorm:"type:jsonb" json:"interactive_data"`
-	FlowResponse      JSONB      `gorm:"type:jsonb" json:"flow_response"`
+	OrganizationID    uuid.UUID     `gorm:"type:uuid;index;not null" json:"organization_id"`
+	WhatsAppAccount   string        `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
+	ContactID         uuid.UUID     `gorm:"type:uuid;index;not null" json:"contact_id"`
+	WhatsAppMessageID string        `gorm:"column:whats_app_message_id;size:255;index" json:"whatsapp_message_id"`
+	ConversationID    string        `gorm:"size:255;index" json:"conversation_id"`
+	Direction         Direction     `gorm:"size:10;not null" json:"direction"`
+	MessageType       MessageType   `gorm:"size:20;not null" json:"message_type"`
+	Content           string        `gorm:"type:text" json:"content"`
+	MediaURL          string        `gorm:"type:text" json:"media_url"`
+	MediaMimeType     string        `gorm:"size:100" json:"media_mime_type"`
+	MediaFilename     string        `gorm:"size:255" json:"media_filename"`
+	TemplateName      string        `gorm:"size:255" json:"template_name"`
+	TemplateParams    JSONB         `gorm:"type:jsonb" json:"template_params"`
+	InteractiveData   JSONB         `gorm:"type:jsonb" json:"interactive_data"`
+	FlowResponse      JSONB         `gorm:"type:jsonb" json:"flow_response"`
 	Status            MessageStatus `gorm:"size:20;default:'pending'" json:"status"`
-	ErrorMessage      string     `gorm:"type:text" json:"error_message"`
-	IsReply           bool       `gorm:"default:false" json:"is_reply"`
-	ReplyToMessageID  *uuid.UUID `gorm:"type:uuid" json:"reply_to_message_id,omitempty"`
-	SentByUserID      *uuid.UUID `gorm:"type:uuid;index" json:"sent_by_user_id,omitempty"` // User who sent outgoing message
-	Metadata          JSONB      `gorm:"type:jsonb;default:'{}'" json:"metadata"`
+	ErrorMessage      string        `gorm:"type:text" json:"error_message"`
+	IsReply           bool          `gorm:"default:false" json:"is_reply"`
+	ReplyToMessageID  *uuid.UUID    `gorm:"type:uuid" json:"reply_to_message_id,omitempty"`
+	SentByUserID      *uuid.UUID    `gorm:"type:uuid;index" json:"sent_by_user_id,omitempty"` // User who sent outgoing message
+	Metadata          JSONB         `gorm:"type:jsonb;default:'{}'" json:"metadata"`
+	EditedAt          *time.Time    `json:"edited_at,omitempty"`                           // Last time the customer edited the message
+	RevokedAt         *time.Time    `json:"revoked_at,omitempty"`                          // Deleted by the customer or retracted by an agent
+	RevokedByUserID   *uuid.UUID    `gorm:"type:uuid" json:"revoked_by_user_id,omitempty"` // Nil when the customer deleted it
 
 	// Relations
 	Organization   *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
@@ -371,23 +439,58 @@ func (Message) TableName() string {
 	return "messages"
 }
 
+// MessageRevision records a change to a message after it was sent: an edit or
+// deletion by the customer, or an agent retracting a message
+type MessageRevision struct {
+	BaseModel
+	OrganizationID  uuid.UUID             `gorm:"type:uuid;index;not null" json:"organization_id"`
+	MessageID       uuid.UUID             `gorm:"type:uuid;index;not null" json:"message_id"`
+	Action          MessageRevisionAction `gorm:"size:20;not null" json:"action"`
+	PreviousContent string                `gorm:"type:text" json:"previous_content"`
+	Content         string                `gorm:"type:text" json:"content"`           // New content of edits
+	UserID          *uuid.UUID            `gorm:"type:uuid" json:"user_id,omitempty"` // Agent who retracted the message
+	Reason          string                `gorm:"type:text" json:"reason,omitempty"`
+
+	// Relations
+	Message *Message `gorm:"foreignKey:MessageID" json:"message,omitempty"`
+	User    *User    `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (MessageRevision) TableName() string {
+	return "message_revisions"
+}
+
 // Template represents a WhatsApp message template
 type Template struct {
 	BaseModel
-	OrganizationID  uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
-	WhatsAppAccount string     `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
-	MetaTemplateID  string     `gorm:"size:100" json:"meta_template_id"`
-	Name            string     `gorm:"size:255;not null" json:"name"`
-	DisplayName     string     `gorm:"size:255" json:"display_name"`
-	Language        string     `gorm:"size:10;not null" json:"language"`
-	Category        string     `gorm:"size:50" json:"category"`                       // MARKETING, UTILITY, AUTHENTICATION
-	Status          string     `gorm:"size:20;default:'PENDING'" json:"status"`       // PENDING, APPROVED, REJECTED
-	HeaderType      string     `gorm:"size:20" json:"header_type"`                    // TEXT, IMAGE, DOCUMENT, VIDEO
-	HeaderContent   string     `gorm:"type:text" json:"header_content"`
-	BodyContent     string     `gorm:"type:text;not null" json:"body_content"`
-	FooterContent   string     `gorm:"type:text" json:"footer_content"`
-	Buttons         JSONBArray  `gorm:"type:jsonb;default:'[]'" json:"buttons"`
-	SampleValues    JSONBArray  `gorm:"type:jsonb;default:'[]'" json:"sample_values"`
+	OrganizationID   uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
+	WhatsAppAccount  string     `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
+	MetaTemplateID   string     `gorm:"size:100" json:"meta_template_id"`
+	Name             string     `gorm:"size:255;not null" json:"name"`
+	DisplayName      string     `gorm:"size:255" json:"display_name"`
+	Language         string     `gorm:"size:10;not null" json:"language"`
+	Category         string     `gorm:"size:50" json:"category"`                 // MARKETING, UTILITY, AUTHENTICATION
+	Status           string     `gorm:"size:20;default:'PENDING'" json:"status"` // PENDING, APPROVED, REJECTED
+	HeaderType       string     `gorm:"size:20" json:"header_type"`              // TEXT, IMAGE, DOCUMENT, VIDEO
+	HeaderContent    string     `gorm:"type:text" json:"header_content"`
+	BodyContent      string     `gorm:"type:text;not null" json:"body_content"`
+	FooterContent    string     `gorm:"type:text" json:"footer_content"`
+	Buttons          JSONBArray `gorm:"type:jsonb;default:'[]'" json:"buttons"`
+	SampleValues     JSONBArray `gorm:"type:jsonb;default:'[]'" json:"sample_values"`
+	QualityScore     string     `gorm:"size:20" json:"quality_score"`     // GREEN, YELLOW, RED, UNKNOWN
+	PreviousCategory string     `gorm:"size:50" json:"previous_category"` // Category before Meta recategorized the template
+	RejectionReason  string     `gorm:"type:text" json:"rejection_reason"`
+	StatusUpdatedAt  *time.Time `json:"status_updated_at,omitempty"`
+
+	// Carousel, limited-time-offer and authentication templates
+	Cards                     JSONBArray `gorm:"type:jsonb;default:'[]'" json:"cards"`           // [{header_type, header_content, body_content, buttons, sample_values}]
+	LimitedTimeOffer          JSONB      `gorm:"type:jsonb" json:"limited_time_offer,omitempty"` // {text, has_expiration}
+	AddSecurityRecommendation bool       `gorm:"default:false" json:"add_security_recommendation"`
+	CodeExpirationMinutes     int        `gorm:"default:0" json:"code_expiration_minutes"`
+
+	// Template library
+	TemplateGroupID *uuid.UUID `gorm:"type:uuid;index" json:"template_group_id,omitempty"`
+	LastSyncedAt    *time.Time `json:"last_synced_at,omitempty"` // Last time the template was seen on Meta
 
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
@@ -397,6 +500,43 @@ func (Template) TableName() string {
 	return "templates"
 }
 
+// TemplateGroup is one logical template: its language variants on every WhatsApp account
+type TemplateGroup struct {
+	BaseModel
+	OrganizationID  uuid.UUID `gorm:"type:uuid;index;not null" json:"organization_id"`
+	Name            string    `gorm:"size:255;not null" json:"name"`
+	Description     string    `gorm:"type:text" json:"description"`
+	Category        string    `gorm:"size:50" json:"category"`
+	DefaultLanguage string    `gorm:"size:10" json:"default_language"` // Sent when no variant matches the contact's language
+
+	// Relations
+	Templates []Template `gorm:"foreignKey:TemplateGroupID" json:"templates,omitempty"`
+}
+
+func (TemplateGroup) TableName() string {
+	return "template_groups"
+}
+
+// OTPVerification is a one-time password sent to a phone number with an authentication template
+type OTPVerification struct {
+	BaseModel
+	OrganizationID    uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
+	WhatsAppAccount   string     `gorm:"size:100;not null" json:"whatsapp_account"`
+	TemplateID        uuid.UUID  `gorm:"type:uuid;not null" json:"template_id"`
+	PhoneNumber       string     `gorm:"size:50;index;not null" json:"phone_number"`
+	CodeHash          string     `gorm:"size:100;not null" json:"-"` // bcrypt hash, the code itself is never stored
+	Status            OTPStatus  `gorm:"size:20;default:'pending'" json:"status"`
+	Attempts          int        `gorm:"default:0" json:"attempts"`
+	MaxAttempts       int        `gorm:"default:5" json:"max_attempts"`
+	ExpiresAt         time.Time  `gorm:"not null" json:"expires_at"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+	WhatsAppMessageID string     `gorm:"size:100" json:"whatsapp_message_id"`
+}
+
+func (OTPVerification) TableName() string {
+	return "otp_verifications"
+}
+
 // WhatsAppFlow represents a WhatsApp interactive flow
 type WhatsAppFlow struct {
 	BaseModel
@@ -412,6 +552,10 @@ type WhatsAppFlow struct {
 	PreviewURL      string     `gorm:"type:text" json:"preview_url"`
 	HasLocalChanges bool       `gorm:"default:true" json:"has_local_changes"` // True when local changes need to be synced to Meta
 
+	// Data exchange endpoint: screen ID (or INIT) -> handler config
+	EndpointEnabled bool  `gorm:"default:false" json:"endpoint_enabled"`
+	ScreenHandlers  JSONB `gorm:"type:jsonb;default:'{}'" json:"screen_handlers"`
+
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
 }
@@ -427,7 +571,7 @@ type Widget struct {
 	UserID         *uuid.UUID `gorm:"type:uuid;index" json:"user_id"` // Creator of the widget (nil for system defaults)
 	Name           string     `gorm:"size:255;not null" json:"name"`
 	Description    string     `gorm:"type:text" json:"description"`
-	DataSource     string     `gorm:"size:50;not null" json:"data_source"` // messages, contacts, campaigns, transfers, sessions
+	DataSource     string     `gorm:"size:50;not null" json:"data_source"` // messages, contacts, campaigns, transfers, sessions, csat
 	Metric         string     `gorm:"size:20;not null" json:"metric"`      // count, sum, avg
 	Field          string     `gorm:"size:100" json:"field"`               // Field for sum/avg (e.g., resolution_time)
 	Filters        JSONBArray `gorm:"type:jsonb;default:'[]'" json:"filters"`
@@ -61,6 +61,7 @@ const (
 	ResourceChatbotAI       = "chatbot.ai"
 	ResourceChat            = "chat"
 	ResourceChatAssign      = "chat.assign"
+	ResourceChatAll         = "chat.all"
 	ResourceContacts        = "contacts"
 	ResourceTags            = "tags"
 	ResourceAnalytics       = "analytics"
@@ -71,6 +72,7 @@ const (
 	ResourceCannedResponses = "canned_responses"
 	ResourceCustomActions   = "custom_actions"
 	ResourceOrganizations   = "organizations"
+	ResourceOrders          = "orders"
 )
 
 // PermissionAction constants for available actions
@@ -151,7 +153,9 @@ func DefaultPermissions() []Permission {
 		// Chat
 		{Resource: ResourceChat, Action: ActionRead, Description: "View chat conversations"},
 		{Resource: ResourceChat, Action: ActionWrite, Description: "Send messages"},
+		{Resource: ResourceChat, Action: ActionDelete, Description: "Retract messages sent by others"},
 		{Resource: ResourceChatAssign, Action: ActionWrite, Description: "Assign conversations to agents"},
+		{Resource: ResourceChatAll, Action: ActionRead, Description: "View all conversations, not only those of own teams"},
 
 		// Contacts
 		{Resource: ResourceContacts, Action: ActionRead, Description: "View contacts"},
@@ -201,6 +205,11 @@ func DefaultPermissions() []Permission {
 		{Resource: ResourceOrganizations, Action: ActionWrite, Description: "Create organizations"},
 		{Resource: ResourceOrganizations, Action: ActionDelete, Description: "Delete organizations"},
 		{Resource: ResourceOrganizations, Action: ActionAssign, Description: "Manage organization members"},
+
+		// Orders
+		{Resource: ResourceOrders, Action: ActionRead, Description: "View orders"},
+		{Resource: ResourceOrders, Action: ActionWrite, Description: "Update order status"},
+		{Resource: ResourceOrders, Action: ActionExport, Description: "Export orders"},
 	}
 }
 
@@ -231,7 +240,7 @@ func SystemRolePermissions() map[string][]string {
 		"chatbot.keywords:read", "chatbot.keywords:write", "chatbot.keywords:delete",
 		"chatbot.ai:read", "chatbot.ai:write",
 		// Chat
-		"chat:read", "chat:write", "chat.assign:write",
+		"chat:read", "chat:write", "chat:delete", "chat.assign:write", "chat.all:read",
 		// Contacts
 		"contacts:read", "contacts:write", "contacts:delete", "contacts:import", "contacts:export",
 		// Tags
@@ -248,6 +257,8 @@ func SystemRolePermissions() map[string][]string {
 		"custom_actions:read", "custom_actions:write", "custom_actions:delete",
 		// Organizations (read only)
 		"organizations:read",
+		// Orders
+		"orders:read", "orders:write", "orders:export",
 	}
 
 	agentPermissions := []string{
@@ -263,6 +274,8 @@ func SystemRolePermissions() map[string][]string {
 		"transfers:read", "transfers:write", "transfers:pickup",
 		// Canned Responses (read only)
 		"canned_responses:read",
+		// Orders
+		"orders:read", "orders:write",
 	}
 
 	return map[string][]string{
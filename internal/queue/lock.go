@@ -0,0 +1,146 @@
+package queue
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// LeaseKeyPrefix prefixes the Redis keys of leases held by background processors
+	LeaseKeyPrefix = "whatomate:lease:"
+	// ShardKeyPrefix prefixes the Redis sorted sets listing live processor instances
+	ShardKeyPrefix = "whatomate:shards:"
+)
+
+// acquireLeaseScript takes the lease if it is free and renews it if the
+// caller already holds it.
+var acquireLeaseScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// releaseLeaseScript deletes the lease only if the caller still holds it.
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Lease is a Redis lock held by at most one process at a time. The holder
+// renews it by calling Acquire again before the TTL runs out, which makes it
+// usable for leader election between replicas.
+type Lease struct {
+	client *redis.Client
+	key    string
+	owner  string
+	ttl    time.Duration
+}
+
+// NewLease creates a lease named name held on behalf of owner
+func NewLease(client *redis.Client, name, owner string, ttl time.Duration) *Lease {
+	return &Lease{
+		client: client,
+		key:    LeaseKeyPrefix + name,
+		owner:  owner,
+		ttl:    ttl,
+	}
+}
+
+// Acquire takes or renews the lease. It returns false while another owner holds it.
+func (l *Lease) Acquire(ctx context.Context) (bool, error) {
+	n, err := acquireLeaseScript.Run(ctx, l.client, []string{l.key}, l.owner, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// Release gives the lease up if it is still held by this owner
+func (l *Lease) Release(ctx context.Context) error {
+	return releaseLeaseScript.Run(ctx, l.client, []string{l.key}, l.owner).Err()
+}
+
+// Shards splits keys (such as organization IDs) between the live instances
+// of a background processor. Instances announce themselves on every run and
+// drop out once they stop announcing for longer than the TTL.
+type Shards struct {
+	client   *redis.Client
+	key      string
+	instance string
+	ttl      time.Duration
+}
+
+// NewShards creates the shard membership for the processor named name
+func NewShards(client *redis.Client, name, instance string, ttl time.Duration) *Shards {
+	return &Shards{
+		client:   client,
+		key:      ShardKeyPrefix + name,
+		instance: instance,
+		ttl:      ttl,
+	}
+}
+
+// Refresh announces this instance and returns all live instances
+func (s *Shards) Refresh(ctx context.Context) ([]string, error) {
+	now := time.Now()
+	cutoff := now.Add(-s.ttl).UnixMilli()
+
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, s.key, redis.Z{Score: float64(now.UnixMilli()), Member: s.instance})
+	pipe.ZRemRangeByScore(ctx, s.key, "-inf", "("+strconv.FormatInt(cutoff, 10))
+	members := pipe.ZRange(ctx, s.key, 0, -1)
+	pipe.PExpire(ctx, s.key, 2*s.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return members.Val(), nil
+}
+
+// Owns reports whether this instance is responsible for key
+func (s *Shards) Owns(key string, instances []string) bool {
+	return ShardOwner(key, instances) == s.instance
+}
+
+// Leave removes this instance so the others take over its keys on their next run
+func (s *Shards) Leave(ctx context.Context) error {
+	return s.client.ZRem(ctx, s.key, s.instance).Err()
+}
+
+// ShardOwner picks the instance responsible for key using rendezvous hashing,
+// so only the keys of an instance that joins or leaves move elsewhere.
+func ShardOwner(key string, instances []string) string {
+	var owner string
+	var best uint64
+	for _, instance := range instances {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(instance))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(key))
+		if score := mix64(h.Sum64()); owner == "" || score > best {
+			owner, best = instance, score
+		}
+	}
+	return owner
+}
+
+// mix64 spreads the bits of an FNV hash, whose high bits barely change for
+// inputs that differ in their last bytes
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,96 @@
+package queue_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardOwner(t *testing.T) {
+	instances := []string{"a", "b", "c"}
+	assert.Equal(t, "", queue.ShardOwner("org", nil))
+	assert.Equal(t, "a", queue.ShardOwner("org", []string{"a"}))
+
+	owners := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("org-%d", i)
+		owner := queue.ShardOwner(key, instances)
+		assert.Equal(t, owner, queue.ShardOwner(key, []string{"c", "a", "b"}), "owner doesn't depend on order")
+		owners[key] = owner
+		counts[owner]++
+	}
+	for _, instance := range instances {
+		assert.Greater(t, counts[instance], 50, "keys are spread over instances")
+	}
+
+	// Only the keys of the instance that left move
+	for key, owner := range owners {
+		if owner != "b" {
+			assert.Equal(t, owner, queue.ShardOwner(key, []string{"a", "c"}))
+		}
+	}
+}
+
+func TestLease_AcquireAndRelease(t *testing.T) {
+	client := skipIfNoRedis(t)
+	ctx := context.Background()
+	name := "test:" + uuid.NewString()
+	t.Cleanup(func() { client.Del(ctx, queue.LeaseKeyPrefix+name) })
+
+	first := queue.NewLease(client, name, "first", time.Minute)
+	second := queue.NewLease(client, name, "second", time.Minute)
+
+	held, err := first.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	held, err = first.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, held, "holder renews its lease")
+
+	held, err = second.Acquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, held)
+
+	require.NoError(t, second.Release(ctx))
+	held, err = second.Acquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, held, "only the holder can release")
+
+	require.NoError(t, first.Release(ctx))
+	held, err = second.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, held)
+}
+
+func TestShards_RefreshAndLeave(t *testing.T) {
+	client := skipIfNoRedis(t)
+	ctx := context.Background()
+	name := "test:" + uuid.NewString()
+	t.Cleanup(func() { client.Del(ctx, queue.ShardKeyPrefix+name) })
+
+	a := queue.NewShards(client, name, "a", time.Minute)
+	b := queue.NewShards(client, name, "b", time.Minute)
+
+	members, err := a.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, members)
+	assert.True(t, a.Owns("org", members))
+
+	members, err = b.Refresh(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, members)
+	assert.NotEqual(t, a.Owns("org", members), b.Owns("org", members))
+
+	require.NoError(t, b.Leave(ctx))
+	members, err = a.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, members)
+}
@@ -7,12 +7,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
 	"github.com/zerodha/logf"
 )
 
 const (
 	// CampaignStatsChannel is the Redis pub/sub channel for campaign stats updates
 	CampaignStatsChannel = "whatomate:campaign_stats"
+
+	// BroadcastChannel is the Redis pub/sub channel for WebSocket broadcasts
+	// made by processes without WebSocket clients, such as workers
+	BroadcastChannel = "whatomate:ws_broadcast"
 )
 
 // CampaignStatsUpdate represents a campaign stats update message
@@ -56,6 +61,20 @@ func (p *Publisher) PublishCampaignStats(ctx context.Context, update *CampaignSt
 	return nil
 }
 
+// PublishBroadcast publishes a WebSocket broadcast for the servers to deliver
+func (p *Publisher) PublishBroadcast(ctx context.Context, msg websocket.BroadcastMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if err := p.client.Publish(ctx, BroadcastChannel, payload).Err(); err != nil {
+		p.log.Error("Failed to publish WebSocket broadcast", "error", err, "type", msg.Message.Type)
+		return err
+	}
+	return nil
+}
+
 // Subscriber subscribes to Redis pub/sub channels
 type Subscriber struct {
 	client *redis.Client
@@ -112,6 +131,46 @@ func (s *Subscriber) SubscribeCampaignStats(ctx context.Context, handler func(up
 	return nil
 }
 
+// SubscribeBroadcasts subscribes to WebSocket broadcasts published by other processes
+// The handler is called for each received broadcast
+func (s *Subscriber) SubscribeBroadcasts(ctx context.Context, handler func(msg websocket.BroadcastMessage)) error {
+	s.pubsub = s.client.Subscribe(ctx, BroadcastChannel)
+
+	// Wait for subscription confirmation
+	_, err := s.pubsub.Receive(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.log.Info("Subscribed to WebSocket broadcast channel")
+
+	ch := s.pubsub.Channel()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				s.log.Info("WebSocket broadcast subscriber shutting down")
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					s.log.Info("WebSocket broadcast channel closed")
+					return
+				}
+
+				var broadcast websocket.BroadcastMessage
+				if err := json.Unmarshal([]byte(msg.Payload), &broadcast); err != nil {
+					s.log.Error("Failed to unmarshal WebSocket broadcast", "error", err)
+					continue
+				}
+
+				handler(broadcast)
+			}
+		}
+	}()
+
+	return nil
+}
+
 // Close closes the subscriber
 func (s *Subscriber) Close() error {
 	if s.pubsub != nil {
@@ -0,0 +1,236 @@
+package templateutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// Reserved recipient parameters for limited-time-offer and copy-code templates
+const (
+	ParamOfferExpiresAt = "offer_expires_at" // RFC3339 time or Unix milliseconds
+	ParamCouponCode     = "coupon_code"
+)
+
+// Carousel card parameters are keyed card_<n>.<name> (e.g. card_1.price or card_2.1), with
+// card_<n>.media overriding the card's header media URL and card_<n>.url filling a URL button variable.
+const cardParamPrefix = "card_"
+
+// AuthenticationBody returns the body text Meta generates for authentication templates
+func AuthenticationBody(addSecurityRecommendation bool) string {
+	body := "*{{1}}* is your verification code."
+	if addSecurityRecommendation {
+		body += " For your security, do not share this code."
+	}
+	return body
+}
+
+// AuthenticationFooter returns the footer text Meta generates for authentication templates
+func AuthenticationFooter(codeExpirationMinutes int) string {
+	if codeExpirationMinutes <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("This code expires in %d minutes.", codeExpirationMinutes)
+}
+
+// AuthenticationComponents builds the send components of an authentication template.
+// The code fills both the body and the copy-code / one-tap button.
+func AuthenticationComponents(code string) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"type":       "body",
+			"parameters": []map[string]interface{}{{"type": "text", "text": code}},
+		},
+		{
+			"type":       "button",
+			"sub_type":   "url",
+			"index":      "0",
+			"parameters": []map[string]interface{}{{"type": "text", "text": code}},
+		},
+	}
+}
+
+// ExtraComponents builds the send components beyond the header and body: the offer
+// expiration, copy-code coupon and carousel cards, filled from the recipient's parameters.
+func ExtraComponents(t *models.Template, params map[string]string) ([]map[string]interface{}, error) {
+	components, missing, err := buildExtraComponents(t, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing template parameters: %s", strings.Join(missing, ", "))
+	}
+	return components, nil
+}
+
+// MissingSendParams returns every parameter a send of the template needs but has no value for:
+// body variables, offer expiration, coupon code and carousel card values.
+func MissingSendParams(t *models.Template, params map[string]string) []string {
+	missing := MissingBodyParams(t.BodyContent, params)
+	if t.Category == "AUTHENTICATION" {
+		return missing
+	}
+	_, extra, _ := buildExtraComponents(t, params)
+	return append(missing, extra...)
+}
+
+// ParseOfferExpiration parses an offer expiration given as RFC3339 or Unix milliseconds
+func ParseOfferExpiration(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q: use RFC3339 or Unix milliseconds", ParamOfferExpiresAt, value)
+}
+
+func buildExtraComponents(t *models.Template, params map[string]string) ([]map[string]interface{}, []string, error) {
+	var components []map[string]interface{}
+	var missing []string
+
+	if hasExpiration, _ := t.LimitedTimeOffer["has_expiration"].(bool); hasExpiration {
+		if value := params[ParamOfferExpiresAt]; value == "" {
+			missing = append(missing, ParamOfferExpiresAt)
+		} else {
+			expiresAt, err := ParseOfferExpiration(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			components = append(components, map[string]interface{}{
+				"type": "limited_time_offer",
+				"parameters": []map[string]interface{}{{
+					"type":               "limited_time_offer",
+					"limited_time_offer": map[string]interface{}{"expiration_time_ms": expiresAt.UnixMilli()},
+				}},
+			})
+		}
+	}
+
+	for i, b := range t.Buttons {
+		btn, ok := b.(map[string]interface{})
+		if !ok || strings.ToUpper(stringValue(btn["type"])) != "COPY_CODE" {
+			continue
+		}
+		code := params[ParamCouponCode]
+		if code == "" {
+			missing = append(missing, ParamCouponCode)
+			continue
+		}
+		components = append(components, map[string]interface{}{
+			"type":       "button",
+			"sub_type":   "copy_code",
+			"index":      strconv.Itoa(i),
+			"parameters": []map[string]interface{}{{"type": "coupon_code", "coupon_code": code}},
+		})
+	}
+
+	if len(t.Cards) > 0 {
+		cards := make([]map[string]interface{}, 0, len(t.Cards))
+		for i, c := range t.Cards {
+			card, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			cardComponents, cardMissing := buildCardComponents(i, card, params)
+			missing = append(missing, cardMissing...)
+			cards = append(cards, map[string]interface{}{
+				"card_index": i,
+				"components": cardComponents,
+			})
+		}
+		components = append(components, map[string]interface{}{
+			"type":  "carousel",
+			"cards": cards,
+		})
+	}
+
+	return components, missing, nil
+}
+
+// buildCardComponents builds the send components of one carousel card
+func buildCardComponents(index int, card map[string]interface{}, params map[string]string) ([]map[string]interface{}, []string) {
+	prefix := cardParamPrefix + strconv.Itoa(index+1) + "."
+	cardParams := map[string]string{}
+	for k, v := range params {
+		if strings.HasPrefix(k, prefix) {
+			cardParams[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+
+	var components []map[string]interface{}
+	var missing []string
+
+	mediaType := strings.ToLower(stringValue(card["header_type"]))
+	mediaURL := cardParams["media"]
+	if mediaURL == "" {
+		mediaURL = stringValue(card["header_content"])
+	}
+	if mediaURL == "" {
+		missing = append(missing, prefix+"media")
+	} else if mediaType == "image" || mediaType == "video" {
+		components = append(components, map[string]interface{}{
+			"type": "header",
+			"parameters": []map[string]interface{}{{
+				"type":    mediaType,
+				mediaType: map[string]interface{}{"link": mediaURL},
+			}},
+		})
+	}
+
+	paramNames := ExtParamNames(stringValue(card["body_content"]))
+	if len(paramNames) > 0 {
+		values := ResolveParamsFromMap(paramNames, cardParams)
+		bodyParams := make([]map[string]interface{}, 0, len(paramNames))
+		for i, name := range paramNames {
+			if i >= len(values) || values[i] == "" {
+				missing = append(missing, prefix+name)
+				continue
+			}
+			bodyParams = append(bodyParams, map[string]interface{}{"type": "text", "text": values[i]})
+		}
+		components = append(components, map[string]interface{}{
+			"type":       "body",
+			"parameters": bodyParams,
+		})
+	}
+
+	buttons, _ := card["buttons"].([]interface{})
+	for i, b := range buttons {
+		btn, ok := b.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(stringValue(btn["type"])) {
+		case "URL":
+			if !strings.Contains(stringValue(btn["url"]), "{{") {
+				continue
+			}
+			value := cardParams["url"]
+			if value == "" {
+				missing = append(missing, prefix+"url")
+				continue
+			}
+			components = append(components, map[string]interface{}{
+				"type":       "button",
+				"sub_type":   "url",
+				"index":      strconv.Itoa(i),
+				"parameters": []map[string]interface{}{{"type": "text", "text": value}},
+			})
+		case "QUICK_REPLY", "":
+			// The payload tells us which card the recipient tapped
+			components = append(components, map[string]interface{}{
+				"type":       "button",
+				"sub_type":   "quick_reply",
+				"index":      strconv.Itoa(i),
+				"parameters": []map[string]interface{}{{"type": "payload", "payload": fmt.Sprintf("%s%d_%s", cardParamPrefix, index+1, stringValue(btn["text"]))}},
+			})
+		}
+	}
+
+	return components, missing
+}
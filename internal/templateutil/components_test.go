@@ -0,0 +1,180 @@
+package templateutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func offerTemplate() *models.Template {
+	return &models.Template{
+		Name:             "flash_sale",
+		Language:         "en",
+		Category:         "MARKETING",
+		BodyContent:      "Hi {{1}}, take 20% off everything this weekend.",
+		LimitedTimeOffer: models.JSONB{"text": "Weekend deal", "has_expiration": true},
+		Buttons: models.JSONBArray{
+			map[string]interface{}{"type": "COPY_CODE", "text": "Copy code", "example": "SAVE20"},
+			map[string]interface{}{"type": "URL", "text": "Shop", "url": "https://example.com/shop"},
+		},
+	}
+}
+
+func carouselTemplate() *models.Template {
+	card := func(media string) map[string]interface{} {
+		return map[string]interface{}{
+			"header_type":    "IMAGE",
+			"header_content": media,
+			"body_content":   "Now only {{1}}",
+			"sample_values":  []interface{}{map[string]interface{}{"component": "body", "index": float64(1), "value": "$20"}},
+			"buttons": []interface{}{
+				map[string]interface{}{"type": "QUICK_REPLY", "text": "Buy"},
+				map[string]interface{}{"type": "URL", "text": "View", "url": "https://example.com/p/{{1}}", "example": "sku1"},
+			},
+		}
+	}
+	return &models.Template{
+		Name:        "summer_picks",
+		Language:    "en",
+		Category:    "MARKETING",
+		BodyContent: "Our summer picks are here for you.",
+		Cards:       models.JSONBArray{card("https://cdn.example.com/1.jpg"), card("https://cdn.example.com/2.jpg")},
+	}
+}
+
+func TestAuthenticationText(t *testing.T) {
+	assert.Equal(t, "*{{1}}* is your verification code.", AuthenticationBody(false))
+	assert.Equal(t, "*{{1}}* is your verification code. For your security, do not share this code.", AuthenticationBody(true))
+	assert.Equal(t, "This code expires in 10 minutes.", AuthenticationFooter(10))
+	assert.Empty(t, AuthenticationFooter(0))
+}
+
+func TestAuthenticationComponents(t *testing.T) {
+	components := AuthenticationComponents("482913")
+	require.Len(t, components, 2)
+	assert.Equal(t, "body", components[0]["type"])
+	assert.Equal(t, "url", components[1]["sub_type"])
+	assert.Equal(t, "482913", components[1]["parameters"].([]map[string]interface{})[0]["text"])
+}
+
+func TestExtraComponents_LimitedTimeOffer(t *testing.T) {
+	expires := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+	components, err := ExtraComponents(offerTemplate(), map[string]string{
+		"1":                 "John",
+		ParamOfferExpiresAt: expires.Format(time.RFC3339),
+		ParamCouponCode:     "SAVE20",
+	})
+	require.NoError(t, err)
+	require.Len(t, components, 2)
+
+	offer := components[0]["parameters"].([]map[string]interface{})[0]["limited_time_offer"].(map[string]interface{})
+	assert.Equal(t, expires.UnixMilli(), offer["expiration_time_ms"])
+	assert.Equal(t, "copy_code", components[1]["sub_type"])
+	assert.Equal(t, "0", components[1]["index"])
+
+	_, err = ExtraComponents(offerTemplate(), map[string]string{ParamOfferExpiresAt: "next week", ParamCouponCode: "SAVE20"})
+	assert.Error(t, err)
+}
+
+func TestExtraComponents_Carousel(t *testing.T) {
+	components, err := ExtraComponents(carouselTemplate(), map[string]string{
+		"card_1.1":     "$20",
+		"card_1.url":   "sku1",
+		"card_2.1":     "$35",
+		"card_2.url":   "sku2",
+		"card_2.media": "https://cdn.example.com/override.jpg",
+	})
+	require.NoError(t, err)
+	require.Len(t, components, 1)
+	assert.Equal(t, "carousel", components[0]["type"])
+
+	cards := components[0]["cards"].([]map[string]interface{})
+	require.Len(t, cards, 2)
+	second := cards[1]["components"].([]map[string]interface{})
+	require.Len(t, second, 4)
+	header := second[0]["parameters"].([]map[string]interface{})[0]["image"].(map[string]interface{})
+	assert.Equal(t, "https://cdn.example.com/override.jpg", header["link"])
+	assert.Equal(t, "$35", second[1]["parameters"].([]map[string]interface{})[0]["text"])
+	assert.Equal(t, "card_2_Buy", second[2]["parameters"].([]map[string]interface{})[0]["payload"])
+	assert.Equal(t, "sku2", second[3]["parameters"].([]map[string]interface{})[0]["text"])
+}
+
+func TestMissingSendParams(t *testing.T) {
+	assert.Equal(t, []string{"1", ParamOfferExpiresAt, ParamCouponCode}, MissingSendParams(offerTemplate(), nil))
+	assert.Equal(t, []string{"card_1.1", "card_1.url", "card_2.url"}, MissingSendParams(carouselTemplate(), map[string]string{"card_2.1": "$35"}))
+
+	auth := &models.Template{Category: "AUTHENTICATION", BodyContent: AuthenticationBody(true)}
+	assert.Equal(t, []string{"1"}, MissingSendParams(auth, nil))
+	assert.Empty(t, MissingSendParams(auth, map[string]string{"1": "123456"}))
+}
+
+func TestParseOfferExpiration(t *testing.T) {
+	parsed, err := ParseOfferExpiration("1793534400000")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1793534400000), parsed.UnixMilli())
+
+	_, err = ParseOfferExpiration("tomorrow")
+	assert.Error(t, err)
+}
+
+func TestLint_Carousel(t *testing.T) {
+	assert.Empty(t, Lint(carouselTemplate()))
+
+	tmpl := carouselTemplate()
+	tmpl.HeaderType = "TEXT"
+	tmpl.HeaderContent = "Summer"
+	second := tmpl.Cards[1].(map[string]interface{})
+	second["header_type"] = "VIDEO"
+	second["buttons"] = []interface{}{map[string]interface{}{"type": "QUICK_REPLY", "text": "Buy"}}
+	second["sample_values"] = nil
+
+	codes := issueCodes(Lint(tmpl))
+	assert.Contains(t, codes, "carousel_header")
+	assert.Contains(t, codes, "card_consistency")
+	assert.Contains(t, codes, "missing_sample")
+}
+
+func TestLint_LimitedTimeOffer(t *testing.T) {
+	tmpl := offerTemplate()
+	tmpl.SampleValues = models.JSONBArray{map[string]interface{}{"component": "body", "index": float64(1), "value": "John"}}
+	assert.Empty(t, Lint(tmpl))
+
+	tmpl.LimitedTimeOffer["text"] = "This offer text is far too long"
+	tmpl.FooterContent = "Terms apply"
+	tmpl.Category = "UTILITY"
+	codes := issueCodes(Lint(tmpl))
+	assert.Contains(t, codes, "offer_text_length")
+	assert.Contains(t, codes, "offer_footer")
+	assert.Contains(t, codes, "offer_category")
+}
+
+func TestLint_AuthenticationGenerated(t *testing.T) {
+	auth := &models.Template{
+		Name:                  "login_code",
+		Language:              "en",
+		Category:              "AUTHENTICATION",
+		BodyContent:           AuthenticationBody(true),
+		FooterContent:         AuthenticationFooter(10),
+		CodeExpirationMinutes: 10,
+		Buttons:               models.JSONBArray{map[string]interface{}{"type": "OTP", "otp_type": "COPY_CODE", "text": "Copy code"}},
+	}
+	assert.False(t, HasLintErrors(Lint(auth)))
+
+	auth.CodeExpirationMinutes = 120
+	assert.Contains(t, issueCodes(Lint(auth)), "auth_expiration")
+}
+
+func TestRender_OfferAndCarousel(t *testing.T) {
+	offer := Render(offerTemplate(), RenderParams{Body: map[string]string{"1": "John"}})
+	assert.Equal(t, "Weekend deal", offer.Offer)
+
+	rendered := Render(carouselTemplate(), RenderParams{Cards: []map[string]string{{"1": "$20"}}})
+	require.Len(t, rendered.Cards, 2)
+	assert.Equal(t, "Now only $20", rendered.Cards[0].Body)
+	assert.Equal(t, "IMAGE", rendered.Cards[0].HeaderType)
+	assert.Len(t, rendered.Cards[0].Buttons, 2)
+	assert.Equal(t, []string{"card_2.1"}, rendered.MissingParams)
+}
@@ -0,0 +1,81 @@
+package templateutil
+
+import (
+	"strings"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"gorm.io/gorm"
+)
+
+// LanguageVariant returns the approved variant of t written in lang. Variants are the
+// templates on the same WhatsApp account sharing t's name or template group. When no
+// variant matches, the group's default language is tried before falling back to t.
+func LanguageVariant(db *gorm.DB, t *models.Template, lang string) (*models.Template, error) {
+	if lang == "" || sameLanguage(t.Language, lang) {
+		return t, nil
+	}
+
+	query := db.Where("organization_id = ? AND whats_app_account = ? AND status = ?", t.OrganizationID, t.WhatsAppAccount, "APPROVED")
+	if t.TemplateGroupID != nil {
+		query = query.Where("name = ? OR template_group_id = ?", t.Name, *t.TemplateGroupID)
+	} else {
+		query = query.Where("name = ?", t.Name)
+	}
+	var variants []models.Template
+	if err := query.Order("created_at").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+
+	if variant := SelectLanguage(variants, lang); variant != nil {
+		return variant, nil
+	}
+	if t.TemplateGroupID != nil {
+		var group models.TemplateGroup
+		if err := db.Select("default_language").Where("id = ?", *t.TemplateGroupID).First(&group).Error; err == nil &&
+			group.DefaultLanguage != "" && !sameLanguage(t.Language, group.DefaultLanguage) {
+			if variant := SelectLanguage(variants, group.DefaultLanguage); variant != nil {
+				return variant, nil
+			}
+		}
+	}
+	return t, nil
+}
+
+// SelectLanguage returns the template written in lang, or else the first one with the
+// same base language ("pt_BR" matches "pt" and "pt_PT"). Returns nil when nothing matches.
+func SelectLanguage(templates []models.Template, lang string) *models.Template {
+	if NormalizeLanguage(lang) == "" {
+		return nil
+	}
+	for i := range templates {
+		if sameLanguage(templates[i].Language, lang) {
+			return &templates[i]
+		}
+	}
+	base := BaseLanguage(lang)
+	for i := range templates {
+		if BaseLanguage(templates[i].Language) == base {
+			return &templates[i]
+		}
+	}
+	return nil
+}
+
+func sameLanguage(a, b string) bool {
+	return NormalizeLanguage(a) == NormalizeLanguage(b)
+}
+
+// NormalizeLanguage lowercases a language code and uses "_" as the region
+// separator, so Meta's "en_US" and a contact's "en-us" compare equal
+func NormalizeLanguage(code string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(code)), "-", "_")
+}
+
+// BaseLanguage returns a language code without its region ("pt_br" -> "pt")
+func BaseLanguage(code string) string {
+	code = NormalizeLanguage(code)
+	if i := strings.Index(code, "_"); i > 0 {
+		return code[:i]
+	}
+	return code
+}
@@ -0,0 +1,25 @@
+package templateutil
+
+import (
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectLanguage(t *testing.T) {
+	templates := []models.Template{
+		{Name: "order_update", Language: "en_US"},
+		{Name: "order_update", Language: "es"},
+		{Name: "order_update", Language: "pt_BR"},
+	}
+
+	require.NotNil(t, SelectLanguage(templates, "en-us"))
+	assert.Equal(t, "en_US", SelectLanguage(templates, "en-us").Language)
+	assert.Equal(t, "en_US", SelectLanguage(templates, "en").Language, "falls back to the base language")
+	assert.Equal(t, "pt_BR", SelectLanguage(templates, "pt_PT").Language)
+	assert.Equal(t, "es", SelectLanguage(templates, "es_MX").Language)
+	assert.Nil(t, SelectLanguage(templates, "fr"))
+	assert.Nil(t, SelectLanguage(templates, ""))
+}
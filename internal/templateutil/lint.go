@@ -0,0 +1,465 @@
+package templateutil
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+)
+
+// Meta limits for template components
+const (
+	MaxHeaderTextLength = 60
+	MaxBodyLength       = 1024
+	MaxFooterLength     = 60
+	MaxButtonTextLength = 25
+	MaxButtons          = 10
+	MaxURLButtons       = 2
+	MaxPhoneButtons     = 1
+	MaxCopyCodeButtons  = 1
+	MaxNameLength       = 512
+
+	MaxCarouselCards         = 10
+	MaxCardBodyLength        = 160
+	MaxCardButtons           = 2
+	MaxOfferTextLength       = 16
+	MaxCodeExpirationMinutes = 90
+)
+
+// Lint issue severities. Errors make Meta reject the template, warnings are likely
+// to get it rejected or recategorized.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintIssue is a single problem found in a template
+type LintIssue struct {
+	Severity  string `json:"severity"`
+	Component string `json:"component"` // name, header, body, footer, buttons, cards, offer, category
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+}
+
+var (
+	templateNamePattern   = regexp.MustCompile(`^[a-z0-9_]+$`)
+	namedParamPattern     = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	urlPattern            = regexp.MustCompile(`(?i)https?://|www\.`)
+	adjacentParamsPattern = regexp.MustCompile(`\}\}\s*\{\{`)
+)
+
+// Lint checks a template against Meta's submission rules before it is sent for approval
+func Lint(t *models.Template) []LintIssue {
+	l := &linter{}
+
+	if t.Name == "" {
+		l.add(LintError, "name", "name_required", "Template name is required")
+	} else if !templateNamePattern.MatchString(t.Name) {
+		l.add(LintError, "name", "name_format", "Template name may only contain lowercase letters, numbers and underscores")
+	} else if len(t.Name) > MaxNameLength {
+		l.add(LintError, "name", "name_length", fmt.Sprintf("Template name must be at most %d characters", MaxNameLength))
+	}
+	if t.Language == "" {
+		l.add(LintError, "name", "language_required", "Template language is required")
+	}
+
+	named := HasNamedParams(t)
+	l.lintHeader(t, named)
+	l.lintBody(t, named)
+	l.lintFooter(t)
+	l.lintButtons(t)
+	l.lintCarousel(t, named)
+	l.lintOffer(t)
+	l.lintCategory(t)
+
+	return l.issues
+}
+
+// HasLintErrors reports whether any issue is an error
+func HasLintErrors(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == LintError {
+			return true
+		}
+	}
+	return false
+}
+
+// HasNamedParams reports whether a template uses named ({{name}}) rather than positional parameters
+func HasNamedParams(t *models.Template) bool {
+	return whatsapp.HasNamedParams(t.BodyContent) || (t.HeaderType == "TEXT" && whatsapp.HasNamedParams(t.HeaderContent))
+}
+
+type linter struct {
+	issues []LintIssue
+}
+
+func (l *linter) add(severity, component, code, message string) {
+	l.issues = append(l.issues, LintIssue{Severity: severity, Component: component, Code: code, Message: message})
+}
+
+func (l *linter) lintHeader(t *models.Template, named bool) {
+	switch t.HeaderType {
+	case "", "NONE":
+		return
+	case "TEXT":
+		if t.HeaderContent == "" {
+			l.add(LintError, "header", "header_required", "Text header cannot be empty")
+			return
+		}
+		if n := utf8.RuneCountInString(t.HeaderContent); n > MaxHeaderTextLength {
+			l.add(LintError, "header", "header_length", fmt.Sprintf("Header text is %d characters; the limit is %d", n, MaxHeaderTextLength))
+		}
+		params := ExtParamNames(t.HeaderContent)
+		if len(params) > 1 {
+			l.add(LintError, "header", "header_variables", "Header text can contain at most one variable")
+		}
+		l.lintParams("header", params, named)
+		l.lintSamples(t, "header", params)
+	case "IMAGE", "VIDEO", "DOCUMENT":
+		if t.HeaderContent == "" {
+			l.add(LintError, "header", "header_sample_media", fmt.Sprintf("%s header requires a sample media upload", strings.ToLower(t.HeaderType)))
+		}
+	case "LOCATION":
+	default:
+		l.add(LintError, "header", "header_type", fmt.Sprintf("Unknown header type %q", t.HeaderType))
+	}
+}
+
+func (l *linter) lintBody(t *models.Template, named bool) {
+	body := t.BodyContent
+	if strings.TrimSpace(body) == "" {
+		l.add(LintError, "body", "body_required", "Body text is required")
+		return
+	}
+	if n := utf8.RuneCountInString(body); n > MaxBodyLength {
+		l.add(LintError, "body", "body_length", fmt.Sprintf("Body text is %d characters; the limit is %d", n, MaxBodyLength))
+	}
+
+	params := ExtParamNames(body)
+	l.lintParams("body", params, named)
+	if t.Category != "AUTHENTICATION" { // Meta writes the body of authentication templates
+		l.lintSamples(t, "body", params)
+	}
+
+	if len(params) == 0 {
+		return
+	}
+	trimmed := strings.TrimSpace(body)
+	if strings.HasPrefix(trimmed, "{{") || strings.HasSuffix(trimmed, "}}") {
+		l.add(LintError, "body", "body_variable_position", "Body text cannot start or end with a variable")
+	}
+	if adjacentParamsPattern.MatchString(body) {
+		l.add(LintWarning, "body", "adjacent_variables", "Variables should be separated by text")
+	}
+	words := len(strings.Fields(ParameterPattern.ReplaceAllString(body, "")))
+	if words < 2*len(params) {
+		l.add(LintWarning, "body", "variable_density", "Body has too many variables for its length; Meta may reject it")
+	}
+}
+
+// lintParams checks variable naming and numbering within a component
+func (l *linter) lintParams(component string, params []string, named bool) {
+	var positions []int
+	for _, p := range params {
+		if n, err := strconv.Atoi(p); err == nil {
+			if named {
+				l.add(LintError, component, "mixed_params", "Named and positional variables cannot be mixed")
+				return
+			}
+			positions = append(positions, n)
+			continue
+		}
+		if !namedParamPattern.MatchString(p) {
+			l.add(LintError, component, "param_name", fmt.Sprintf("Variable {{%s}} may only contain lowercase letters, numbers and underscores", p))
+		}
+	}
+
+	sort.Ints(positions)
+	for i, n := range positions {
+		if n != i+1 {
+			l.add(LintError, component, "param_numbering", fmt.Sprintf("Variables must be numbered sequentially from {{1}}; expected {{%d}}, found {{%d}}", i+1, n))
+			return
+		}
+	}
+}
+
+// lintSamples checks that every variable has a sample value
+func (l *linter) lintSamples(t *models.Template, component string, params []string) {
+	if len(params) == 0 {
+		return
+	}
+	samples := SampleValues(t.SampleValues, component)
+	for _, p := range params {
+		if samples[p] == "" {
+			l.add(LintError, component, "missing_sample", fmt.Sprintf("Sample value is required for {{%s}} in the %s", p, component))
+		}
+	}
+}
+
+func (l *linter) lintFooter(t *models.Template) {
+	if t.FooterContent == "" {
+		return
+	}
+	if n := utf8.RuneCountInString(t.FooterContent); n > MaxFooterLength {
+		l.add(LintError, "footer", "footer_length", fmt.Sprintf("Footer text is %d characters; the limit is %d", n, MaxFooterLength))
+	}
+	if strings.Contains(t.FooterContent, "{{") {
+		l.add(LintError, "footer", "footer_variables", "Footer text cannot contain variables")
+	}
+}
+
+func (l *linter) lintButtons(t *models.Template) {
+	if len(t.Buttons) == 0 {
+		return
+	}
+	if len(t.Buttons) > MaxButtons {
+		l.add(LintError, "buttons", "button_count", fmt.Sprintf("Templates can have at most %d buttons", MaxButtons))
+	}
+
+	counts := map[string]int{}
+	quickReplyGroups := 0
+	lastWasQuickReply := false
+	for i, b := range t.Buttons {
+		btn, ok := b.(map[string]interface{})
+		if !ok {
+			l.add(LintError, "buttons", "button_format", fmt.Sprintf("Button %d is not an object", i+1))
+			continue
+		}
+		btnType := strings.ToUpper(stringValue(btn["type"]))
+		if btnType == "" {
+			btnType = "QUICK_REPLY"
+		}
+		counts[btnType]++
+
+		if btnType == "QUICK_REPLY" {
+			if !lastWasQuickReply {
+				quickReplyGroups++
+			}
+			lastWasQuickReply = true
+		} else {
+			lastWasQuickReply = false
+		}
+
+		text := stringValue(btn["text"])
+		if text == "" {
+			l.add(LintError, "buttons", "button_text", fmt.Sprintf("Button %d needs a label", i+1))
+		} else if n := utf8.RuneCountInString(text); n > MaxButtonTextLength {
+			l.add(LintError, "buttons", "button_text_length", fmt.Sprintf("Button %q is %d characters; the limit is %d", text, n, MaxButtonTextLength))
+		}
+
+		switch btnType {
+		case "URL":
+			l.lintURLButton(i, btn)
+		case "PHONE_NUMBER":
+			if stringValue(btn["phone_number"]) == "" {
+				l.add(LintError, "buttons", "button_phone", fmt.Sprintf("Button %d needs a phone number", i+1))
+			}
+		case "COPY_CODE":
+			if stringValue(btn["example"]) == "" {
+				l.add(LintError, "buttons", "button_example", fmt.Sprintf("Copy code button %d needs a sample code", i+1))
+			}
+		case "QUICK_REPLY", "OTP", "FLOW", "CATALOG", "MPM", "VOICE_CALL":
+		default:
+			l.add(LintError, "buttons", "button_type", fmt.Sprintf("Button %d has unknown type %q", i+1, btnType))
+		}
+	}
+
+	if counts["URL"] > MaxURLButtons {
+		l.add(LintError, "buttons", "button_count", fmt.Sprintf("Templates can have at most %d URL buttons", MaxURLButtons))
+	}
+	if counts["PHONE_NUMBER"] > MaxPhoneButtons {
+		l.add(LintError, "buttons", "button_count", fmt.Sprintf("Templates can have at most %d phone number button", MaxPhoneButtons))
+	}
+	if counts["COPY_CODE"] > MaxCopyCodeButtons {
+		l.add(LintError, "buttons", "button_count", fmt.Sprintf("Templates can have at most %d copy code button", MaxCopyCodeButtons))
+	}
+	if quickReplyGroups > 1 {
+		l.add(LintError, "buttons", "button_order", "Quick reply buttons must be grouped together")
+	}
+}
+
+func (l *linter) lintURLButton(i int, btn map[string]interface{}) {
+	rawURL := stringValue(btn["url"])
+	if rawURL == "" {
+		l.add(LintError, "buttons", "button_url", fmt.Sprintf("URL button %d needs a URL", i+1))
+		return
+	}
+	if !strings.HasPrefix(rawURL, "https://") && !strings.HasPrefix(rawURL, "http://") {
+		l.add(LintError, "buttons", "button_url", fmt.Sprintf("URL button %d must start with http:// or https://", i+1))
+	}
+
+	params := ExtParamNames(rawURL)
+	if len(params) == 0 {
+		return
+	}
+	if len(params) > 1 || params[0] != "1" {
+		l.add(LintError, "buttons", "button_url_variable", fmt.Sprintf("URL button %d can only use a single {{1}} variable", i+1))
+	}
+	if !strings.HasSuffix(rawURL, "}}") {
+		l.add(LintError, "buttons", "button_url_variable", fmt.Sprintf("The variable in URL button %d must be at the end of the URL", i+1))
+	}
+	if stringValue(btn["example"]) == "" {
+		l.add(LintError, "buttons", "button_example", fmt.Sprintf("URL button %d needs a sample value for its variable", i+1))
+	}
+}
+
+func (l *linter) lintCategory(t *models.Template) {
+	switch t.Category {
+	case "AUTHENTICATION":
+		if t.HeaderType != "" && t.HeaderType != "NONE" && t.HeaderType != "TEXT" {
+			l.add(LintError, "category", "auth_header", "Authentication templates cannot have a media header")
+		}
+		if urlPattern.MatchString(t.BodyContent) {
+			l.add(LintError, "category", "auth_url", "Authentication templates cannot contain URLs")
+		}
+		hasCode := false
+		for _, b := range t.Buttons {
+			if btn, ok := b.(map[string]interface{}); ok {
+				switch strings.ToUpper(stringValue(btn["type"])) {
+				case "OTP", "COPY_CODE":
+					hasCode = true
+				case "URL", "PHONE_NUMBER":
+					l.add(LintError, "category", "auth_buttons", "Authentication templates can only have a one-time password button")
+				}
+			}
+		}
+		if !hasCode {
+			l.add(LintError, "category", "auth_otp_button", "Authentication templates need a one-time password button")
+		}
+		if t.CodeExpirationMinutes < 0 || t.CodeExpirationMinutes > MaxCodeExpirationMinutes {
+			l.add(LintError, "category", "auth_expiration", fmt.Sprintf("Code expiration must be between 1 and %d minutes", MaxCodeExpirationMinutes))
+		}
+	case "UTILITY":
+		if len(ExtParamNames(t.BodyContent)) == 0 {
+			l.add(LintWarning, "category", "utility_generic", "Utility templates without variables are often recategorized as marketing; reference the specific order, account or transaction")
+		}
+	case "MARKETING", "":
+	default:
+		l.add(LintError, "category", "category", fmt.Sprintf("Unknown category %q", t.Category))
+	}
+}
+
+func (l *linter) lintCarousel(t *models.Template, named bool) {
+	if len(t.Cards) == 0 {
+		return
+	}
+	if len(t.Cards) > MaxCarouselCards {
+		l.add(LintError, "cards", "card_count", fmt.Sprintf("Carousels can have at most %d cards", MaxCarouselCards))
+	}
+	if t.HeaderType != "" && t.HeaderType != "NONE" {
+		l.add(LintError, "cards", "carousel_header", "Carousel templates cannot have a header outside the cards")
+	}
+	if len(t.Buttons) > 0 {
+		l.add(LintError, "cards", "carousel_buttons", "Carousel templates cannot have buttons outside the cards")
+	}
+
+	// Every card must share the first card's media type and button layout
+	var headerType, buttonLayout string
+	for i, c := range t.Cards {
+		card, ok := c.(map[string]interface{})
+		if !ok {
+			l.add(LintError, "cards", "card_format", fmt.Sprintf("Card %d is not an object", i+1))
+			continue
+		}
+
+		cardHeader := strings.ToUpper(stringValue(card["header_type"]))
+		if cardHeader != "IMAGE" && cardHeader != "VIDEO" {
+			l.add(LintError, "cards", "card_header", fmt.Sprintf("Card %d needs an image or video header", i+1))
+		} else if stringValue(card["header_content"]) == "" {
+			l.add(LintError, "cards", "card_header", fmt.Sprintf("Card %d needs a sample media upload", i+1))
+		}
+		if i == 0 {
+			headerType = cardHeader
+		} else if cardHeader != headerType {
+			l.add(LintError, "cards", "card_consistency", "All cards must use the same header type")
+		}
+
+		body := stringValue(card["body_content"])
+		if n := utf8.RuneCountInString(body); n > MaxCardBodyLength {
+			l.add(LintError, "cards", "card_body_length", fmt.Sprintf("Card %d body is %d characters; the limit is %d", i+1, n, MaxCardBodyLength))
+		}
+		params := ExtParamNames(body)
+		l.lintParams("cards", params, named)
+		samples, _ := card["sample_values"].([]interface{})
+		cardSamples := SampleValues(models.JSONBArray(samples), "body")
+		for _, p := range params {
+			if cardSamples[p] == "" {
+				l.add(LintError, "cards", "missing_sample", fmt.Sprintf("Sample value is required for {{%s}} in card %d", p, i+1))
+			}
+		}
+
+		buttons, _ := card["buttons"].([]interface{})
+		if len(buttons) == 0 || len(buttons) > MaxCardButtons {
+			l.add(LintError, "cards", "card_buttons", fmt.Sprintf("Card %d needs 1 to %d buttons", i+1, MaxCardButtons))
+		}
+		types := make([]string, len(buttons))
+		for j, b := range buttons {
+			if btn, ok := b.(map[string]interface{}); ok {
+				types[j] = strings.ToUpper(stringValue(btn["type"]))
+			}
+		}
+		layout := strings.Join(types, ",")
+		if i == 0 {
+			buttonLayout = layout
+		} else if layout != buttonLayout {
+			l.add(LintError, "cards", "card_consistency", "All cards must have the same number and types of buttons")
+		}
+	}
+}
+
+func (l *linter) lintOffer(t *models.Template) {
+	if t.LimitedTimeOffer == nil {
+		return
+	}
+	text := stringValue(t.LimitedTimeOffer["text"])
+	if text == "" {
+		l.add(LintError, "offer", "offer_text", "Limited-time offers need an offer text")
+	} else if n := utf8.RuneCountInString(text); n > MaxOfferTextLength {
+		l.add(LintError, "offer", "offer_text_length", fmt.Sprintf("Offer text is %d characters; the limit is %d", n, MaxOfferTextLength))
+	}
+	if t.FooterContent != "" {
+		l.add(LintError, "offer", "offer_footer", "Limited-time offer templates cannot have a footer")
+	}
+	if t.Category != "MARKETING" {
+		l.add(LintError, "offer", "offer_category", "Limited-time offer templates must be in the MARKETING category")
+	}
+}
+
+// SampleValues returns the sample values of a component keyed by parameter name or position.
+// Supports {component, index, value}, {component, param_name, value} and legacy {component, values} entries.
+func SampleValues(samples models.JSONBArray, component string) map[string]string {
+	result := map[string]string{}
+	for _, sv := range samples {
+		svMap, ok := sv.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		comp := stringValue(svMap["component"])
+		if comp != component && (comp != "" || component != "body") {
+			continue
+		}
+		value := stringValue(svMap["value"])
+		if name := stringValue(svMap["param_name"]); name != "" {
+			result[name] = value
+		} else if idx, ok := svMap["index"]; ok {
+			result[fmt.Sprintf("%v", idx)] = value
+		}
+		if values, ok := svMap["values"].([]interface{}); ok {
+			for i, v := range values {
+				result[strconv.Itoa(i+1)] = stringValue(v)
+			}
+		}
+	}
+	return result
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
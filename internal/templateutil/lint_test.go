@@ -0,0 +1,176 @@
+package templateutil
+
+import (
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func validTemplate() *models.Template {
+	return &models.Template{
+		Name:        "order_update",
+		Language:    "en",
+		Category:    "UTILITY",
+		BodyContent: "Hi {{1}}, your order {{2}} has shipped.",
+		SampleValues: models.JSONBArray{
+			map[string]interface{}{"component": "body", "index": float64(1), "value": "John"},
+			map[string]interface{}{"component": "body", "index": float64(2), "value": "ORD-1"},
+		},
+	}
+}
+
+func issueCodes(issues []LintIssue) []string {
+	codes := make([]string, len(issues))
+	for i, issue := range issues {
+		codes[i] = issue.Code
+	}
+	return codes
+}
+
+func TestLint_ValidTemplate(t *testing.T) {
+	issues := Lint(validTemplate())
+	assert.Empty(t, issues)
+	assert.False(t, HasLintErrors(issues))
+}
+
+func TestLint_Variables(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{name: "numbering gap", body: "Hi {{1}}, your order {{3}} has shipped.", code: "param_numbering"},
+		{name: "mixed named and positional", body: "Hi {{name}}, your order {{1}} has shipped.", code: "mixed_params"},
+		{name: "invalid named param", body: "Hi {{First Name}}, your order has shipped.", code: "param_name"},
+		{name: "variable at start", body: "{{1}} your order {{2}} has shipped.", code: "body_variable_position"},
+		{name: "variable at end", body: "Hi {{1}}, your order has shipped {{2}}", code: "body_variable_position"},
+		{name: "adjacent variables", body: "Hi there, order {{1}} {{2}} has shipped today.", code: "adjacent_variables"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := validTemplate()
+			tmpl.BodyContent = tt.body
+			assert.Contains(t, issueCodes(Lint(tmpl)), tt.code)
+		})
+	}
+}
+
+func TestLint_MissingSamples(t *testing.T) {
+	tmpl := validTemplate()
+	tmpl.SampleValues = models.JSONBArray{
+		map[string]interface{}{"component": "body", "index": float64(1), "value": "John"},
+	}
+	issues := Lint(tmpl)
+	assert.Contains(t, issueCodes(issues), "missing_sample")
+	assert.True(t, HasLintErrors(issues))
+
+	named := validTemplate()
+	named.BodyContent = "Hi {{name}}, your order {{order_id}} has shipped."
+	named.SampleValues = models.JSONBArray{
+		map[string]interface{}{"component": "body", "param_name": "name", "value": "John"},
+		map[string]interface{}{"component": "body", "param_name": "order_id", "value": "ORD-1"},
+	}
+	assert.Empty(t, Lint(named))
+}
+
+func TestLint_ComponentLimits(t *testing.T) {
+	tmpl := validTemplate()
+	tmpl.HeaderType = "TEXT"
+	tmpl.HeaderContent = "This header is definitely much longer than sixty characters in total"
+	tmpl.FooterContent = "Reply {{1}} to stop"
+	tmpl.Buttons = models.JSONBArray{
+		map[string]interface{}{"type": "QUICK_REPLY", "text": "This label is longer than 25 chars"},
+	}
+
+	codes := issueCodes(Lint(tmpl))
+	assert.Contains(t, codes, "header_length")
+	assert.Contains(t, codes, "footer_variables")
+	assert.Contains(t, codes, "button_text_length")
+
+	media := validTemplate()
+	media.HeaderType = "IMAGE"
+	assert.Contains(t, issueCodes(Lint(media)), "header_sample_media")
+}
+
+func TestLint_Buttons(t *testing.T) {
+	tmpl := validTemplate()
+	tmpl.Buttons = models.JSONBArray{
+		map[string]interface{}{"type": "QUICK_REPLY", "text": "Yes"},
+		map[string]interface{}{"type": "URL", "text": "Track", "url": "https://example.com/{{1}}/track"},
+		map[string]interface{}{"type": "QUICK_REPLY", "text": "No"},
+		map[string]interface{}{"type": "URL", "text": "Shop", "url": "example.com"},
+		map[string]interface{}{"type": "URL", "text": "Help", "url": "https://example.com/help"},
+	}
+
+	codes := issueCodes(Lint(tmpl))
+	assert.Contains(t, codes, "button_order")
+	assert.Contains(t, codes, "button_url_variable")
+	assert.Contains(t, codes, "button_example")
+	assert.Contains(t, codes, "button_url")
+	assert.Contains(t, codes, "button_count")
+}
+
+func TestLint_Category(t *testing.T) {
+	auth := validTemplate()
+	auth.Category = "AUTHENTICATION"
+	auth.BodyContent = "Your code is {{1}}. Visit https://example.com for help."
+	auth.SampleValues = models.JSONBArray{map[string]interface{}{"component": "body", "index": float64(1), "value": "123456"}}
+	codes := issueCodes(Lint(auth))
+	assert.Contains(t, codes, "auth_url")
+	assert.Contains(t, codes, "auth_otp_button")
+
+	utility := validTemplate()
+	utility.BodyContent = "Thanks for being a customer!"
+	utility.SampleValues = nil
+	issues := Lint(utility)
+	assert.Contains(t, issueCodes(issues), "utility_generic")
+	assert.False(t, HasLintErrors(issues))
+}
+
+func TestHasNamedParams(t *testing.T) {
+	assert.False(t, HasNamedParams(&models.Template{BodyContent: "Hi {{1}}"}))
+	assert.True(t, HasNamedParams(&models.Template{BodyContent: "Hi {{name}}"}))
+	assert.True(t, HasNamedParams(&models.Template{HeaderType: "TEXT", HeaderContent: "Order {{order_id}}", BodyContent: "Hi"}))
+}
+
+func TestRender(t *testing.T) {
+	tmpl := &models.Template{
+		HeaderType:    "TEXT",
+		HeaderContent: "Order {{1}}",
+		BodyContent:   "Hi {{name}}, your order ships on {{date}}.",
+		FooterContent: "Reply STOP to opt out",
+		Buttons: models.JSONBArray{
+			map[string]interface{}{"type": "URL", "text": "Track", "url": "https://example.com/track/{{1}}"},
+			map[string]interface{}{"type": "QUICK_REPLY", "text": "Thanks"},
+		},
+	}
+
+	rendered := Render(tmpl, RenderParams{
+		Body:    map[string]string{"name": "John", "date": "Monday"},
+		Header:  map[string]string{"1": "ORD-1"},
+		Buttons: map[string]string{"1": "ORD-1"},
+	})
+	assert.Equal(t, "Order ORD-1", rendered.Header)
+	assert.Equal(t, "Hi John, your order ships on Monday.", rendered.Body)
+	assert.Equal(t, "https://example.com/track/ORD-1", rendered.Buttons[0].URL)
+	assert.Equal(t, "QUICK_REPLY", rendered.Buttons[1].Type)
+	assert.Empty(t, rendered.MissingParams)
+
+	partial := Render(tmpl, RenderParams{Body: map[string]string{"name": "John"}})
+	assert.Equal(t, "Hi John, your order ships on {{date}}.", partial.Body)
+	assert.Equal(t, []string{"body.date", "header.1", "buttons.1"}, partial.MissingParams)
+}
+
+func TestMissingBodyParams(t *testing.T) {
+	assert.Empty(t, MissingBodyParams("Hi {{1}}", map[string]string{"1": "John"}))
+	assert.Empty(t, MissingBodyParams("Hi {{name}}", map[string]string{"1": "John"}))
+	assert.Equal(t, []string{"2"}, MissingBodyParams("Hi {{1}}, order {{2}}", map[string]string{"1": "John"}))
+	assert.Empty(t, MissingBodyParams("Hello!", nil))
+}
+
+func TestStringParams(t *testing.T) {
+	assert.Nil(t, StringParams(nil))
+	assert.Equal(t, map[string]string{"1": "John", "2": "5"}, StringParams(map[string]interface{}{"1": "John", "2": 5, "3": nil}))
+}
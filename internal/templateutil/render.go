@@ -0,0 +1,172 @@
+package templateutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// RenderParams holds the values used to fill in a template. Body and header values are
+// keyed by parameter name or position; button values by the button's position (1-based).
+// Cards holds the body values of each carousel card, in card order.
+type RenderParams struct {
+	Body    map[string]string   `json:"body"`
+	Header  map[string]string   `json:"header"`
+	Buttons map[string]string   `json:"buttons"`
+	Cards   []map[string]string `json:"cards"`
+}
+
+// RenderedTemplate is the final message a template produces with the given parameters
+type RenderedTemplate struct {
+	HeaderType    string           `json:"header_type,omitempty"`
+	Header        string           `json:"header,omitempty"` // Text, or the media URL/handle for media headers
+	Body          string           `json:"body"`
+	Footer        string           `json:"footer,omitempty"`
+	Buttons       []RenderedButton `json:"buttons,omitempty"`
+	Offer         string           `json:"offer,omitempty"` // Limited-time offer text
+	Cards         []RenderedCard   `json:"cards,omitempty"`
+	MissingParams []string         `json:"missing_params,omitempty"` // e.g. body.name, header.1, buttons.2, card_1.price
+}
+
+// RenderedCard is a carousel card with its variables filled in
+type RenderedCard struct {
+	HeaderType string           `json:"header_type"`
+	Header     string           `json:"header"` // Media URL or handle
+	Body       string           `json:"body"`
+	Buttons    []RenderedButton `json:"buttons,omitempty"`
+}
+
+// RenderedButton is a template button with its variables filled in
+type RenderedButton struct {
+	Type        string `json:"type"`
+	Text        string `json:"text"`
+	URL         string `json:"url,omitempty"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+}
+
+// Render fills in a template's header, body and buttons and reports any parameters
+// without a value. Missing values are left as their {{placeholder}}.
+func Render(t *models.Template, params RenderParams) *RenderedTemplate {
+	result := &RenderedTemplate{
+		HeaderType: t.HeaderType,
+		Footer:     t.FooterContent,
+	}
+
+	result.Body = renderComponent(t.BodyContent, params.Body, "body", &result.MissingParams)
+
+	switch t.HeaderType {
+	case "", "NONE":
+		result.HeaderType = ""
+	case "TEXT":
+		result.Header = renderComponent(t.HeaderContent, params.Header, "header", &result.MissingParams)
+	default:
+		result.Header = t.HeaderContent
+	}
+
+	for i, b := range t.Buttons {
+		btn, ok := b.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rendered := renderButton(btn)
+		if rendered.Type == "URL" && strings.Contains(rendered.URL, "{{") {
+			key := strconv.Itoa(i + 1)
+			if val, ok := params.Buttons[key]; ok && val != "" {
+				rendered.URL = ParameterPattern.ReplaceAllLiteralString(rendered.URL, val)
+			} else {
+				result.MissingParams = append(result.MissingParams, "buttons."+key)
+			}
+		}
+		result.Buttons = append(result.Buttons, rendered)
+	}
+
+	result.Offer = stringValue(t.LimitedTimeOffer["text"])
+
+	for i, c := range t.Cards {
+		card, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var cardParams map[string]string
+		if i < len(params.Cards) {
+			cardParams = params.Cards[i]
+		}
+		rendered := RenderedCard{
+			HeaderType: strings.ToUpper(stringValue(card["header_type"])),
+			Header:     stringValue(card["header_content"]),
+			Body:       renderComponent(stringValue(card["body_content"]), cardParams, cardParamPrefix+strconv.Itoa(i+1), &result.MissingParams),
+		}
+		buttons, _ := card["buttons"].([]interface{})
+		for _, b := range buttons {
+			if btn, ok := b.(map[string]interface{}); ok {
+				rendered.Buttons = append(rendered.Buttons, renderButton(btn))
+			}
+		}
+		result.Cards = append(result.Cards, rendered)
+	}
+
+	return result
+}
+
+func renderButton(btn map[string]interface{}) RenderedButton {
+	rendered := RenderedButton{
+		Type:        strings.ToUpper(stringValue(btn["type"])),
+		Text:        stringValue(btn["text"]),
+		URL:         stringValue(btn["url"]),
+		PhoneNumber: stringValue(btn["phone_number"]),
+	}
+	if rendered.Type == "" {
+		rendered.Type = "QUICK_REPLY"
+	}
+	return rendered
+}
+
+// MissingBodyParams returns the body parameters of a template that have no value.
+// Used to reject sends before they reach Meta.
+func MissingBodyParams(bodyContent string, params map[string]string) []string {
+	paramNames := ExtParamNames(bodyContent)
+	values := ResolveParamsFromMap(paramNames, params)
+
+	var missing []string
+	for i, name := range paramNames {
+		if i >= len(values) || values[i] == "" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// StringParams converts JSONB template params (e.g. campaign recipient params) to strings
+func StringParams(params map[string]interface{}) map[string]string {
+	if len(params) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(params))
+	for k, v := range params {
+		if v == nil {
+			continue
+		}
+		result[k] = fmt.Sprintf("%v", v)
+	}
+	return result
+}
+
+// renderComponent replaces the placeholders of one component and records missing values
+func renderComponent(content string, params map[string]string, component string, missing *[]string) string {
+	paramNames := ExtParamNames(content)
+	if len(paramNames) == 0 {
+		return content
+	}
+
+	values := ResolveParamsFromMap(paramNames, params)
+	for i, name := range paramNames {
+		if i >= len(values) || values[i] == "" {
+			*missing = append(*missing, component+"."+name)
+			continue
+		}
+		content = strings.ReplaceAll(content, "{{"+name+"}}", values[i])
+	}
+	return content
+}
@@ -143,7 +143,13 @@ func (c *Client) handleMessage(data []byte) {
 
 	switch msg.Type {
 	case TypeSetContact:
+		c.hub.Touch(c.userID)
 		c.handleSetContact(msg.Payload)
+	case TypeActivity:
+		c.hub.Touch(c.userID)
+	case TypeTyping:
+		c.hub.Touch(c.userID)
+		c.handleTyping(msg.Payload)
 	case TypePing:
 		c.sendPong()
 	}
@@ -161,6 +167,7 @@ func (c *Client) handleSetContact(payload any) {
 		return
 	}
 
+	previous := c.currentContact
 	if setContact.ContactID == "" {
 		c.currentContact = nil
 		c.hub.log.Debug("Client cleared current contact", "user_id", c.userID)
@@ -174,6 +181,49 @@ func (c *Client) handleSetContact(payload any) {
 			"user_id", c.userID,
 			"contact_id", contactID)
 	}
+
+	if previous != nil && (c.currentContact == nil || *previous != *c.currentContact) &&
+		!c.hub.isViewing(c.organizationID, c.userID, *previous) {
+		c.hub.emit(c.event(*previous, PresenceLeft))
+	}
+	if c.currentContact != nil {
+		c.hub.emit(c.event(*c.currentContact, PresenceViewing))
+	}
+}
+
+// handleTyping reports that the user started or stopped composing a reply in
+// the contact they are viewing
+func (c *Client) handleTyping(payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	var typing TypingPayload
+	if err := json.Unmarshal(data, &typing); err != nil {
+		return
+	}
+
+	contactID, err := uuid.Parse(typing.ContactID)
+	if err != nil || c.currentContact == nil || *c.currentContact != contactID {
+		return
+	}
+
+	state := PresenceStoppedTyping
+	if typing.Typing {
+		state = PresenceTyping
+	}
+	c.hub.emit(c.event(contactID, state))
+}
+
+// event builds a ClientEvent of the client's user
+func (c *Client) event(contactID uuid.UUID, state string) ClientEvent {
+	return ClientEvent{
+		OrgID:     c.organizationID,
+		UserID:    c.userID,
+		ContactID: contactID,
+		State:     state,
+	}
 }
 
 // sendPong sends a pong response to the client
@@ -4,3 +4,8 @@ package websocket
 func ClientSendChan(c *Client) <-chan []byte {
 	return c.send
 }
+
+// HandleClientMessage passes a message from the client's connection to the client for testing.
+func HandleClientMessage(c *Client, data []byte) {
+	c.handleMessage(data)
+}
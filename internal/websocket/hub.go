@@ -3,11 +3,18 @@ package websocket
 import (
 	"encoding/json"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/zerodha/logf"
 )
 
+// activityInterval is how often a user's activity is passed on while they stay active
+const activityInterval = 30 * time.Second
+
+// AudienceFunc returns which of the given users may receive a scoped broadcast
+type AudienceFunc func(msg BroadcastMessage, userIDs []uuid.UUID) []uuid.UUID
+
 // Hub maintains the set of active clients and broadcasts messages to them
 type Hub struct {
 	// clients maps organization ID -> user ID -> set of clients (supports multiple tabs)
@@ -25,6 +32,24 @@ type Hub struct {
 	// mutex for thread-safe access to clients map
 	mu sync.RWMutex
 
+	// lastActivity maps user ID -> when the user's activity was last passed
+	// to the activity handler
+	lastActivity map[uuid.UUID]time.Time
+	activityMu   sync.Mutex
+
+	// activity, when set, is told when users are active
+	activity func(userID uuid.UUID)
+
+	// relay, when set, receives broadcasts instead of the local clients.
+	// Processes without WebSocket clients use it to pass them to the servers.
+	relay func(BroadcastMessage)
+
+	// audience, when set, picks the users that receive scoped broadcasts
+	audience AudienceFunc
+
+	// clientEvents, when set, is told when users open, type in or leave a conversation
+	clientEvents func(ClientEvent)
+
 	// logger
 	log logf.Logger
 }
@@ -37,6 +62,8 @@ func NewHub(log logf.Logger) *Hub {
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		log:        log,
+
+		lastActivity: make(map[uuid.UUID]time.Time),
 	}
 }
 
@@ -46,6 +73,7 @@ func (h *Hub) Run() {
 		select {
 		case client := <-h.register:
 			h.registerClient(client)
+			h.Touch(client.userID)
 
 		case client := <-h.unregister:
 			h.unregisterClient(client)
@@ -94,6 +122,11 @@ func (h *Hub) unregisterClient(client *Client) {
 				delete(userClients, client)
 				close(client.send)
 
+				// The user left the conversation unless another tab still shows it
+				if client.currentContact != nil && !viewedBy(userClients, *client.currentContact) {
+					go h.emit(client.event(*client.currentContact, PresenceLeft))
+				}
+
 				// Clean up empty user map
 				if len(userClients) == 0 {
 					delete(orgClients, client.userID)
@@ -147,14 +180,32 @@ func (h *Hub) broadcastMessage(msg BroadcastMessage) {
 		return
 	}
 
+	// Scoped messages only reach the users picked by the audience
+	var allowed map[uuid.UUID]bool
+	if msg.restricted {
+		allowed = make(map[uuid.UUID]bool, len(msg.recipients))
+		for _, userID := range msg.recipients {
+			allowed[userID] = true
+		}
+	}
+
 	// Iterate through all users in the organization
-	for _, userClients := range orgClients {
+	for userID, userClients := range orgClients {
+		if allowed != nil && !allowed[userID] {
+			continue
+		}
+		if userID == msg.ExcludeUserID {
+			continue
+		}
 		// Iterate through all clients (tabs) for each user
 		for client := range userClients {
 			// If ContactID is specified, only send to clients viewing that contact
 			if msg.ContactID != uuid.Nil && client.currentContact != nil && *client.currentContact != msg.ContactID {
 				continue
 			}
+			if msg.ViewersOnly && client.currentContact == nil {
+				continue
+			}
 
 			select {
 			case client.send <- data:
@@ -168,8 +219,53 @@ func (h *Hub) broadcastMessage(msg BroadcastMessage) {
 	}
 }
 
+// SetRelay sends all broadcasts to relay instead of this hub's clients
+func (h *Hub) SetRelay(relay func(BroadcastMessage)) {
+	h.relay = relay
+}
+
+// SetAudience makes scoped broadcasts reach only the users picked by audience.
+// Without it, scoped broadcasts reach the whole organization.
+func (h *Hub) SetAudience(audience AudienceFunc) {
+	h.audience = audience
+}
+
+// SetActivity calls handle when a user is active (see Touch),
+// at most once per activityInterval for each user. handle runs on its own
+// goroutine, so it may block (e.g. on Redis) without holding up the hub.
+func (h *Hub) SetActivity(handle func(userID uuid.UUID)) {
+	h.activity = handle
+}
+
+// SetClientEvents calls handle when users open, type in or leave a conversation.
+// It is called on the connection's goroutine, so it should not block for long.
+func (h *Hub) SetClientEvents(handle func(ClientEvent)) {
+	h.clientEvents = handle
+}
+
+// emit passes a client event to the handler set with SetClientEvents
+func (h *Hub) emit(ev ClientEvent) {
+	if h.clientEvents != nil {
+		h.clientEvents(ev)
+	}
+}
+
 // Broadcast sends a message to the broadcast channel
 func (h *Hub) Broadcast(msg BroadcastMessage) {
+	if h.relay != nil {
+		h.relay(msg)
+		return
+	}
+	// Resolve the audience here rather than in Run, so lookups don't hold up
+	// the other broadcasts
+	if msg.Scoped() && msg.UserID == uuid.Nil && h.audience != nil {
+		userIDs := h.connectedUsers(msg.OrgID)
+		if len(userIDs) == 0 {
+			return
+		}
+		msg.recipients = append(h.audience(msg, userIDs), msg.NotifyUserIDs...)
+		msg.restricted = true
+	}
 	select {
 	case h.broadcast <- msg:
 	default:
@@ -188,9 +284,44 @@ func (h *Hub) BroadcastToOrg(orgID uuid.UUID, msg WSMessage) {
 // BroadcastToContact sends a message to clients viewing a specific contact
 func (h *Hub) BroadcastToContact(orgID, contactID uuid.UUID, msg WSMessage) {
 	h.Broadcast(BroadcastMessage{
-		OrgID:     orgID,
-		ContactID: contactID,
-		Message:   msg,
+		OrgID:          orgID,
+		ContactID:      contactID,
+		ScopeContactID: contactID,
+		Message:        msg,
+	})
+}
+
+// BroadcastToConversation sends a message about a contact's conversation to
+// all users allowed to see it
+func (h *Hub) BroadcastToConversation(orgID, contactID uuid.UUID, msg WSMessage) {
+	h.Broadcast(BroadcastMessage{
+		OrgID:          orgID,
+		ScopeContactID: contactID,
+		Message:        msg,
+	})
+}
+
+// BroadcastToTransfer sends a message about an agent transfer to the users
+// following it, and to notifyUserIDs
+func (h *Hub) BroadcastToTransfer(orgID, transferID uuid.UUID, msg WSMessage, notifyUserIDs ...uuid.UUID) {
+	h.Broadcast(BroadcastMessage{
+		OrgID:           orgID,
+		ScopeTransferID: transferID,
+		NotifyUserIDs:   notifyUserIDs,
+		Message:         msg,
+	})
+}
+
+// BroadcastToViewers sends a message to the clients viewing a contact, except
+// those of excludeUserID
+func (h *Hub) BroadcastToViewers(orgID, contactID, excludeUserID uuid.UUID, msg WSMessage) {
+	h.Broadcast(BroadcastMessage{
+		OrgID:          orgID,
+		ContactID:      contactID,
+		ViewersOnly:    true,
+		ExcludeUserID:  excludeUserID,
+		ScopeContactID: contactID,
+		Message:        msg,
 	})
 }
 
@@ -221,6 +352,58 @@ func (h *Hub) countClients() int {
 	return count
 }
 
+// connectedUsers returns the users of an organization with open connections
+func (h *Hub) connectedUsers(orgID uuid.UUID) []uuid.UUID {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	orgClients := h.clients[orgID]
+	userIDs := make([]uuid.UUID, 0, len(orgClients))
+	for userID := range orgClients {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
+
+// Viewing returns a viewing event for each user and contact that a client
+// connected to this server is viewing
+func (h *Hub) Viewing() []ClientEvent {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var events []ClientEvent
+	for _, orgClients := range h.clients {
+		for _, userClients := range orgClients {
+			seen := make(map[uuid.UUID]bool)
+			for client := range userClients {
+				if client.currentContact == nil || seen[*client.currentContact] {
+					continue
+				}
+				seen[*client.currentContact] = true
+				events = append(events, client.event(*client.currentContact, PresenceViewing))
+			}
+		}
+	}
+	return events
+}
+
+// isViewing reports whether any of the user's clients is viewing a contact
+func (h *Hub) isViewing(orgID, userID, contactID uuid.UUID) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return viewedBy(h.clients[orgID][userID], contactID)
+}
+
+// viewedBy reports whether any of the clients is viewing a contact
+func viewedBy(clients map[*Client]struct{}, contactID uuid.UUID) bool {
+	for client := range clients {
+		if client.currentContact != nil && *client.currentContact == contactID {
+			return true
+		}
+	}
+	return false
+}
+
 // GetClientCount returns the number of connected clients (thread-safe)
 func (h *Hub) GetClientCount() int {
 	h.mu.RLock()
@@ -237,3 +420,22 @@ func (h *Hub) Register(client *Client) {
 func (h *Hub) Unregister(client *Client) {
 	h.unregister <- client
 }
+
+// Touch records activity for a user. It never blocks on the activity handler,
+// so it is safe to call from the Run loop, the read pumps and HTTP middleware.
+func (h *Hub) Touch(userID uuid.UUID) {
+	if h.activity == nil {
+		return
+	}
+
+	now := time.Now()
+	h.activityMu.Lock()
+	if last, ok := h.lastActivity[userID]; ok && now.Sub(last) < activityInterval {
+		h.activityMu.Unlock()
+		return
+	}
+	h.lastActivity[userID] = now
+	h.activityMu.Unlock()
+
+	go h.activity(userID)
+}
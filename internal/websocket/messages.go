@@ -12,10 +12,19 @@ type WSMessage struct {
 const (
 	TypeNewMessage    = "new_message"
 	TypeStatusUpdate  = "status_update"
+	TypeMessageUpdate = "message_update" // A message was edited, deleted or retracted
 	TypeContactUpdate = "contact_update"
 	TypeSetContact    = "set_contact"
 	TypePing          = "ping"
 	TypePong          = "pong"
+	TypeActivity      = "activity" // Client reports user activity (resets idle auto-away)
+	TypeTyping        = "typing"   // Client reports the agent started or stopped composing a reply
+
+	// Agent presence types
+	TypeAgentPresence = "agent_presence"
+
+	// Agent availability types
+	TypeAvailabilityUpdate = "availability_update"
 
 	// Agent transfer types
 	TypeAgentTransfer       = "agent_transfer"
@@ -32,14 +41,36 @@ const (
 	TypeConversationNoteCreated = "conversation_note_created"
 	TypeConversationNoteUpdated = "conversation_note_updated"
 	TypeConversationNoteDeleted = "conversation_note_deleted"
+
+	// Notification types
+	TypeNotification = "notification"
+
+	// Order types
+	TypeOrderCreated = "order_created"
+	TypeOrderUpdated = "order_updated"
 )
 
 // BroadcastMessage represents a message to be broadcast to clients
 type BroadcastMessage struct {
-	OrgID     uuid.UUID
-	UserID    uuid.UUID // Optional: only send to specific user
-	ContactID uuid.UUID // Optional: only send to users viewing this contact
-	Message   WSMessage
+	OrgID           uuid.UUID   `json:"org_id"`
+	UserID          uuid.UUID   `json:"user_id"`                   // Optional: only send to specific user
+	ContactID       uuid.UUID   `json:"contact_id"`                // Optional: only send to users viewing this contact
+	ViewersOnly     bool        `json:"viewers_only,omitempty"`    // Optional: with ContactID, skip clients not viewing any contact
+	ExcludeUserID   uuid.UUID   `json:"exclude_user_id"`           // Optional: don't send to this user
+	ScopeContactID  uuid.UUID   `json:"scope_contact_id"`          // Optional: only send to users allowed to see this contact
+	ScopeTransferID uuid.UUID   `json:"scope_transfer_id"`         // Optional: only send to users following this transfer
+	NotifyUserIDs   []uuid.UUID `json:"notify_user_ids,omitempty"` // Optional: also send a scoped message to these users
+	Message         WSMessage   `json:"message"`
+
+	// recipients are the users picked by the hub's audience for a scoped
+	// message. restricted is set once they are resolved.
+	recipients []uuid.UUID
+	restricted bool
+}
+
+// Scoped reports whether the message may only reach some users of the organization
+func (m BroadcastMessage) Scoped() bool {
+	return m.ScopeContactID != uuid.Nil || m.ScopeTransferID != uuid.Nil
 }
 
 // SetContactPayload is the payload for set_contact messages from client
@@ -47,6 +78,37 @@ type SetContactPayload struct {
 	ContactID string `json:"contact_id"`
 }
 
+// TypingPayload is the payload for typing messages from client
+type TypingPayload struct {
+	ContactID string `json:"contact_id"`
+	Typing    bool   `json:"typing"`
+}
+
+// Agent presence states
+const (
+	PresenceViewing       = "viewing"
+	PresenceTyping        = "typing"
+	PresenceStoppedTyping = "stopped_typing" // Still viewing
+	PresenceLeft          = "left"
+)
+
+// AgentPresencePayload is the payload for agent_presence messages
+type AgentPresencePayload struct {
+	ContactID string `json:"contact_id"`
+	UserID    string `json:"user_id"`
+	UserName  string `json:"user_name"`
+	State     string `json:"state"`
+}
+
+// ClientEvent is a change in what a user is doing in a conversation: opening
+// it, typing in it or leaving it
+type ClientEvent struct {
+	OrgID     uuid.UUID
+	UserID    uuid.UUID
+	ContactID uuid.UUID
+	State     string // One of the Presence states
+}
+
 // StatusUpdatePayload is the payload for status_update messages
 type StatusUpdatePayload struct {
 	MessageID string `json:"message_id"`
@@ -242,6 +242,52 @@ func TestHub_BroadcastToContact_SkipsClientsNotViewingContact(t *testing.T) {
 	assertReceivesMessage(t, c2, websocket.TypeNewMessage)
 }
 
+func TestHub_BroadcastToConversation_UsesAudience(t *testing.T) {
+	log := logf.New(logf.Opts{})
+	hub := websocket.NewHub(log)
+	orgID := uuid.New()
+	contactID := uuid.New()
+	allowed := uuid.New()
+	denied := uuid.New()
+
+	var gotContact uuid.UUID
+	hub.SetAudience(func(msg websocket.BroadcastMessage, userIDs []uuid.UUID) []uuid.UUID {
+		gotContact = msg.ScopeContactID
+		assert.ElementsMatch(t, []uuid.UUID{allowed, denied}, userIDs)
+		return []uuid.UUID{allowed}
+	})
+	go hub.Run()
+
+	c1 := newTestClient(hub, allowed, orgID)
+	c2 := newTestClient(hub, denied, orgID)
+	hub.Register(c1)
+	hub.Register(c2)
+	waitForClientCount(t, hub, 2)
+
+	hub.BroadcastToConversation(orgID, contactID, websocket.WSMessage{Type: websocket.TypeNewMessage})
+
+	assertReceivesMessage(t, c1, websocket.TypeNewMessage)
+	assertNoMessage(t, c2)
+	assert.Equal(t, contactID, gotContact)
+
+	// Unscoped broadcasts still reach everyone
+	hub.BroadcastToOrg(orgID, websocket.WSMessage{Type: websocket.TypeCampaignStatsUpdate})
+	assertReceivesMessage(t, c1, websocket.TypeCampaignStatsUpdate)
+	assertReceivesMessage(t, c2, websocket.TypeCampaignStatsUpdate)
+}
+
+func TestHub_BroadcastToTransfer_WithoutAudienceReachesOrg(t *testing.T) {
+	hub := newTestHub(t)
+	orgID := uuid.New()
+
+	c1 := newTestClient(hub, uuid.New(), orgID)
+	hub.Register(c1)
+	waitForClientCount(t, hub, 1)
+
+	hub.BroadcastToTransfer(orgID, uuid.New(), websocket.WSMessage{Type: websocket.TypeAgentTransfer})
+	assertReceivesMessage(t, c1, websocket.TypeAgentTransfer)
+}
+
 // --- BroadcastToOrg with nonexistent org ---
 
 func TestHub_BroadcastToOrg_NoClientsNoError(t *testing.T) {
@@ -354,3 +400,158 @@ func assertNoMessage(t *testing.T, client *websocket.Client) {
 func clientSendChan(c *websocket.Client) <-chan []byte {
 	return websocket.ClientSendChan(c)
 }
+
+// --- Activity ---
+
+func TestHub_Activity(t *testing.T) {
+	hub := newTestHub(t)
+	userID := uuid.New()
+
+	active := make(chan uuid.UUID, 10)
+	hub.SetActivity(func(id uuid.UUID) { active <- id })
+
+	hub.Register(newTestClient(hub, userID, uuid.New()))
+	waitForClientCount(t, hub, 1)
+
+	select {
+	case id := <-active:
+		assert.Equal(t, userID, id, "connecting counts as activity")
+	case <-time.After(time.Second):
+		t.Fatal("activity not reported on connect")
+	}
+
+	hub.Touch(userID)
+	assert.Empty(t, active, "activity is reported at most once per interval")
+
+	other := uuid.New()
+	hub.Touch(other)
+	assert.Equal(t, other, <-active)
+}
+
+func TestHub_SlowActivityDoesNotBlockHub(t *testing.T) {
+	hub := newTestHub(t)
+	release := make(chan struct{})
+	defer close(release)
+	hub.SetActivity(func(uuid.UUID) { <-release })
+
+	orgID := uuid.New()
+	client := newTestClient(hub, uuid.New(), orgID)
+	hub.Register(client)
+	waitForClientCount(t, hub, 1)
+
+	hub.Register(newTestClient(hub, uuid.New(), orgID))
+	waitForClientCount(t, hub, 2)
+
+	hub.BroadcastToOrg(orgID, websocket.WSMessage{Type: websocket.TypeNewMessage})
+	select {
+	case <-clientSendChan(client):
+	case <-time.After(time.Second):
+		t.Fatal("broadcast held up by the activity handler")
+	}
+}
+
+// --- Presence ---
+
+// setContact sends a set_contact message from the client
+func setContact(c *websocket.Client, contactID string) {
+	websocket.HandleClientMessage(c, []byte(`{"type":"set_contact","payload":{"contact_id":"`+contactID+`"}}`))
+}
+
+func TestClient_EmitsPresenceEvents(t *testing.T) {
+	hub := newTestHub(t)
+	events := make(chan websocket.ClientEvent, 10)
+	hub.SetClientEvents(func(ev websocket.ClientEvent) { events <- ev })
+
+	orgID := uuid.New()
+	userID := uuid.New()
+	contactA := uuid.New()
+	contactB := uuid.New()
+	client := newTestClient(hub, userID, orgID)
+	hub.Register(client)
+	waitForClientCount(t, hub, 1)
+
+	expect := func(contactID uuid.UUID, state string) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			assert.Equal(t, websocket.ClientEvent{OrgID: orgID, UserID: userID, ContactID: contactID, State: state}, ev)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %s event", state)
+		}
+	}
+
+	setContact(client, contactA.String())
+	expect(contactA, websocket.PresenceViewing)
+
+	websocket.HandleClientMessage(client, []byte(`{"type":"typing","payload":{"contact_id":"`+contactA.String()+`","typing":true}}`))
+	expect(contactA, websocket.PresenceTyping)
+	websocket.HandleClientMessage(client, []byte(`{"type":"typing","payload":{"contact_id":"`+contactA.String()+`","typing":false}}`))
+	expect(contactA, websocket.PresenceStoppedTyping)
+
+	// Typing in a conversation the client isn't viewing is ignored
+	websocket.HandleClientMessage(client, []byte(`{"type":"typing","payload":{"contact_id":"`+contactB.String()+`","typing":true}}`))
+
+	setContact(client, contactB.String())
+	expect(contactA, websocket.PresenceLeft)
+	expect(contactB, websocket.PresenceViewing)
+	assert.Equal(t, []websocket.ClientEvent{{OrgID: orgID, UserID: userID, ContactID: contactB, State: websocket.PresenceViewing}}, hub.Viewing())
+
+	hub.Unregister(client)
+	expect(contactB, websocket.PresenceLeft)
+}
+
+func TestClient_LeavingInOneTabKeepsViewing(t *testing.T) {
+	hub := newTestHub(t)
+	events := make(chan websocket.ClientEvent, 10)
+	hub.SetClientEvents(func(ev websocket.ClientEvent) { events <- ev })
+
+	orgID := uuid.New()
+	userID := uuid.New()
+	contactID := uuid.New()
+	tab1 := newTestClient(hub, userID, orgID)
+	tab2 := newTestClient(hub, userID, orgID)
+	hub.Register(tab1)
+	hub.Register(tab2)
+	waitForClientCount(t, hub, 2)
+
+	setContact(tab1, contactID.String())
+	setContact(tab2, contactID.String())
+	setContact(tab1, "")
+
+	for i := 0; i < 2; i++ {
+		ev := <-events
+		assert.Equal(t, websocket.PresenceViewing, ev.State)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected %s event", ev.State)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestHub_BroadcastToViewers(t *testing.T) {
+	hub := newTestHub(t)
+	orgID := uuid.New()
+	contactID := uuid.New()
+
+	senderID := uuid.New()
+	sender := newTestClient(hub, senderID, orgID)
+	colleague := newTestClient(hub, uuid.New(), orgID)
+	elsewhere := newTestClient(hub, uuid.New(), orgID)
+	idle := newTestClient(hub, uuid.New(), orgID)
+	for _, c := range []*websocket.Client{sender, colleague, elsewhere, idle} {
+		hub.Register(c)
+	}
+	waitForClientCount(t, hub, 4)
+
+	setContact(sender, contactID.String())
+	setContact(colleague, contactID.String())
+	setContact(elsewhere, uuid.New().String())
+
+	hub.BroadcastToViewers(orgID, contactID, senderID, websocket.WSMessage{Type: websocket.TypeAgentPresence})
+
+	assertReceivesMessage(t, colleague, websocket.TypeAgentPresence)
+	assertNoMessage(t, sender)
+	assertNoMessage(t, elsewhere)
+	assertNoMessage(t, idle)
+}
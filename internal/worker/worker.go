@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -98,6 +99,16 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 		return nil // Don't retry
 	}
 
+	// Send the variant in the contact's language when the template has one
+	template := campaign.Template
+	if template != nil {
+		if variant, err := templateutil.LanguageVariant(w.DB, template, contact.Language); err != nil {
+			w.Log.Error("Failed to load template variants", "error", err, "template", template.Name)
+		} else {
+			template = variant
+		}
+	}
+
 	// Build recipient for sending
 	recipient := &models.BulkMessageRecipient{
 		PhoneNumber:    job.PhoneNumber,
@@ -105,8 +116,13 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 		TemplateParams: job.TemplateParams,
 	}
 
-	// Send template message
-	waMessageID, err := w.sendTemplateMessage(ctx, &account, campaign.Template, recipient, campaign.HeaderMediaID)
+	// Send template message, unless parameters are missing and Meta would reject it
+	var waMessageID string
+	if missing := missingTemplateParams(template, job.TemplateParams); len(missing) > 0 {
+		err = fmt.Errorf("missing template parameters: %s", strings.Join(missing, ", "))
+	} else {
+		waMessageID, err = w.sendTemplateMessage(ctx, &account, template, recipient, campaign.HeaderMediaID)
+	}
 
 	// Create Message record
 	message := models.Message{
@@ -122,9 +138,9 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 			"recipient_name": job.RecipientName,
 		},
 	}
-	if campaign.Template != nil {
-		message.TemplateName = campaign.Template.Name
-		content := templateutil.ReplaceWithJSONBParams(campaign.Template.BodyContent, campaign.Template.BodyContent, job.TemplateParams)
+	if template != nil {
+		message.TemplateName = template.Name
+		content := templateutil.ReplaceWithJSONBParams(template.BodyContent, template.BodyContent, job.TemplateParams)
 		message.Content = content
 	}
 
@@ -152,6 +168,15 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 	return nil
 }
 
+// missingTemplateParams returns the template parameters the recipient has no value for,
+// including offer expiration, coupon code and carousel card values
+func missingTemplateParams(template *models.Template, params models.JSONB) []string {
+	if template == nil {
+		return nil
+	}
+	return templateutil.MissingSendParams(template, templateutil.StringParams(params))
+}
+
 // updateRecipientStatus updates the recipient's status in the database
 func (w *Worker) updateRecipientStatus(recipientID uuid.UUID, status models.MessageStatus, waMessageID, errorMsg string) {
 	updates := map[string]interface{}{
@@ -245,6 +270,18 @@ func (w *Worker) sendTemplateMessage(ctx context.Context, account *models.WhatsA
 		AccessToken: account.AccessToken,
 	}
 
+	// Resolve body parameters (supports both named and positional)
+	resolvedParams := templateutil.ResolveParams(template.BodyContent, recipient.TemplateParams)
+
+	// Authentication templates only take the code, which fills the body and the OTP button
+	if template.Category == "AUTHENTICATION" {
+		if len(resolvedParams) == 0 {
+			return "", fmt.Errorf("authentication template requires a code")
+		}
+		components := templateutil.AuthenticationComponents(resolvedParams[0])
+		return w.WhatsApp.SendTemplateMessageWithComponents(ctx, waAccount, recipient.PhoneNumber, template.Name, template.Language, components)
+	}
+
 	// Build template components with parameters
 	var components []map[string]interface{}
 
@@ -271,8 +308,6 @@ func (w *Worker) sendTemplateMessage(ctx context.Context, account *models.WhatsA
 		}
 	}
 
-	// Resolve body parameters (supports both named and positional)
-	resolvedParams := templateutil.ResolveParams(template.BodyContent, recipient.TemplateParams)
 	if len(resolvedParams) > 0 {
 		bodyParams := make([]map[string]interface{}, len(resolvedParams))
 		for i, val := range resolvedParams {
@@ -287,6 +322,13 @@ func (w *Worker) sendTemplateMessage(ctx context.Context, account *models.WhatsA
 		})
 	}
 
+	// Offer expiration, coupon code and carousel cards
+	extra, err := templateutil.ExtraComponents(template, templateutil.StringParams(recipient.TemplateParams))
+	if err != nil {
+		return "", err
+	}
+	components = append(components, extra...)
+
 	return w.WhatsApp.SendTemplateMessageWithComponents(ctx, waAccount, recipient.PhoneNumber, template.Name, template.Language, components)
 }
 
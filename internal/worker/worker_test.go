@@ -557,6 +557,54 @@ func TestWorker_sendTemplateMessage_NoParams(t *testing.T) {
 	}
 }
 
+func TestWorker_sendTemplateMessage_Authentication(t *testing.T) {
+	w := testWorker(t)
+
+	var capturedBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&capturedBody)
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"messages": []map[string]interface{}{
+				{"id": "wamid.auth"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	w.WhatsApp = whatsapp.NewWithBaseURL(w.Log, server.URL)
+
+	account := &models.WhatsAppAccount{
+		PhoneID:     "123",
+		BusinessID:  "456",
+		AccessToken: "token",
+		APIVersion:  "v21.0",
+	}
+
+	template := &models.Template{
+		Name:        "login_code",
+		Language:    "en",
+		Category:    "AUTHENTICATION",
+		BodyContent: "*{{1}}* is your verification code.",
+	}
+
+	recipient := &models.BulkMessageRecipient{
+		PhoneNumber:    "1234567890",
+		TemplateParams: models.JSONB{"1": "482913"},
+	}
+
+	_, err := w.sendTemplateMessage(context.Background(), account, template, recipient, "")
+	require.NoError(t, err)
+
+	// The code fills both the body and the OTP button
+	components := capturedBody["template"].(map[string]interface{})["components"].([]interface{})
+	require.Len(t, components, 2)
+	button := components[1].(map[string]interface{})
+	assert.Equal(t, "button", button["type"])
+	assert.Equal(t, "url", button["sub_type"])
+	assert.Equal(t, "482913", button["parameters"].([]interface{})[0].(map[string]interface{})["text"])
+}
+
 func TestWorker_Close_NilConsumer(t *testing.T) {
 	w := &Worker{
 		Consumer: nil, // No consumer
@@ -617,6 +665,37 @@ func TestWorker_HandleRecipientJob_Success(t *testing.T) {
 	assert.Equal(t, models.MessageTypeTemplate, message.MessageType)
 }
 
+func TestWorker_HandleRecipientJob_MissingParams(t *testing.T) {
+	w := testWorker(t)
+	org, _, _, campaign, recipient := createTestCampaignData(t, w)
+
+	// Meta must not be called when parameters are missing
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+	w.WhatsApp = whatsapp.NewWithBaseURL(w.Log, server.URL)
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+		TemplateParams: models.JSONB{"1": "John"},
+	}
+
+	err := w.HandleRecipientJob(context.Background(), job)
+	require.NoError(t, err)
+	assert.False(t, called)
+
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusFailed, updatedRecipient.Status)
+	assert.Contains(t, updatedRecipient.ErrorMessage, "missing template parameters: 2")
+}
+
 func TestWorker_HandleRecipientJob_WhatsAppError(t *testing.T) {
 	w := testWorker(t)
 	org, account, _, campaign, recipient := createTestCampaignData(t, w)
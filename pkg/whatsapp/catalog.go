@@ -9,6 +9,9 @@ import (
 	"strconv"
 )
 
+// maxProductPages caps how many pages of 500 products ListCatalogProducts follows
+const maxProductPages = 200
+
 // buildCatalogsURL builds the catalogs endpoint URL for a business
 func (c *Client) buildCatalogsURL(account *Account) string {
 	return fmt.Sprintf("%s/%s/%s/owned_product_catalogs", c.getBaseURL(), account.APIVersion, account.BusinessID)
@@ -72,26 +75,32 @@ func (c *Client) DeleteCatalog(ctx context.Context, account *Account, catalogID
 	return err
 }
 
-// ListCatalogProducts lists all products in a catalog
+// ListCatalogProducts lists all products in a catalog, following every page
 func (c *Client) ListCatalogProducts(ctx context.Context, account *Account, catalogID string) ([]ProductInfo, error) {
 	apiURL := c.buildCatalogProductsURL(account, catalogID)
 
 	// Add fields parameter to get all product details
 	params := url.Values{}
-	params.Add("fields", "id,name,price,currency,url,image_url,retailer_id,description")
+	params.Add("fields", "id,name,price,currency,url,image_url,retailer_id,description,brand,availability,condition,inventory,sale_price,review_status,review_rejection_reasons")
+	params.Add("limit", "500")
 	apiURL = apiURL + "?" + params.Encode()
 
-	respBody, err := c.doRequest(ctx, http.MethodGet, apiURL, nil, account.AccessToken)
-	if err != nil {
-		return nil, err
-	}
+	var products []ProductInfo
+	for page := 0; apiURL != "" && page < maxProductPages; page++ {
+		respBody, err := c.doRequest(ctx, http.MethodGet, apiURL, nil, account.AccessToken)
+		if err != nil {
+			return nil, err
+		}
 
-	var resp ProductListResponse
-	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		var resp ProductListResponse
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		products = append(products, resp.Data...)
+		apiURL = resp.Paging.Next
 	}
 
-	return resp.Data, nil
+	return products, nil
 }
 
 // CreateProduct adds a product to a catalog
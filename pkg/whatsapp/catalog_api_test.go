@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
@@ -294,3 +295,204 @@ func TestClient_DeleteProduct_APIError(t *testing.T) {
 	err := client.DeleteProduct(context.Background(), account, "nonexistent")
 	require.Error(t, err)
 }
+
+func TestClient_ListCatalogProducts_FollowsPaging(t *testing.T) {
+	t.Parallel()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Query().Get("after") == "" {
+			assert.Contains(t, r.URL.Query().Get("fields"), "review_status")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data":   []map[string]interface{}{{"id": "prod-1", "retailer_id": "SKU-1"}},
+				"paging": map[string]interface{}{"next": server.URL + r.URL.Path + "?after=cursor1"},
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{
+				"id":                       "prod-2",
+				"retailer_id":              "SKU-2",
+				"inventory":                3,
+				"review_status":            "rejected",
+				"review_rejection_reasons": []string{"PROHIBITED_CONTENT"},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	products, err := client.ListCatalogProducts(context.Background(), testAccount(server.URL), "catalog-123")
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+	assert.Equal(t, "rejected", products[1].ReviewStatus)
+	assert.Equal(t, []string{"PROHIBITED_CONTENT"}, products[1].ReviewRejectionReasons)
+	require.NotNil(t, products[1].Inventory)
+	assert.Equal(t, 3, *products[1].Inventory)
+}
+
+// --- BatchProducts ---
+
+func TestClient_BatchProducts_Chunks(t *testing.T) {
+	t.Parallel()
+
+	var calls []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Contains(t, r.URL.Path, "/catalog-123/items_batch")
+
+		var body struct {
+			ItemType string                   `json:"item_type"`
+			Requests []map[string]interface{} `json:"requests"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "PRODUCT_ITEM", body.ItemType)
+		calls = append(calls, len(body.Requests))
+
+		if len(calls) == 1 {
+			data := body.Requests[0]["data"].(map[string]interface{})
+			assert.Equal(t, "CREATE", body.Requests[0]["method"])
+			assert.Equal(t, "SKU-0", data["id"])
+			assert.Equal(t, "12.50 EUR", data["price"])
+			assert.Equal(t, float64(4), data["inventory"])
+			assert.NotContains(t, data, "sale_price")
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"handles": []string{"handle-" + string(rune('0'+len(calls)))},
+			"validation_status": []map[string]interface{}{
+				{"retailer_id": "SKU-1", "errors": []map[string]string{{"message": "Invalid image"}}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	inventory := 4
+	items := make([]whatsapp.ProductBatchItem, whatsapp.MaxBatchRequests+1)
+	for i := range items {
+		items[i] = whatsapp.ProductBatchItem{
+			Method:     whatsapp.BatchMethodCreate,
+			RetailerID: "SKU-" + strconv.Itoa(i),
+			Name:       "Shirt",
+			Price:      1250,
+			Currency:   "EUR",
+			Inventory:  &inventory,
+		}
+	}
+
+	client := newTestClient(t, server)
+	result, err := client.BatchProducts(context.Background(), testAccount(server.URL), "catalog-123", items)
+	require.NoError(t, err)
+	assert.Equal(t, []int{whatsapp.MaxBatchRequests, 1}, calls)
+	assert.Equal(t, []string{"handle-1", "handle-2"}, result.Handles)
+	assert.Equal(t, len(items), result.Submitted)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, "Invalid image", result.Errors[0].Message)
+}
+
+func TestClient_BatchProducts_UpdateAndDelete(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Requests []struct {
+				Method string                 `json:"method"`
+				Data   map[string]interface{} `json:"data"`
+			} `json:"requests"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Len(t, body.Requests, 2)
+		assert.Equal(t, map[string]interface{}{"id": "SKU-1", "price": "20.00 USD", "sale_price": ""}, body.Requests[0].Data)
+		assert.Equal(t, "DELETE", body.Requests[1].Method)
+		assert.Equal(t, map[string]interface{}{"id": "SKU-2"}, body.Requests[1].Data)
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"handles": []string{"handle-1"}})
+	}))
+	defer server.Close()
+
+	items := []whatsapp.ProductBatchItem{
+		{Method: whatsapp.BatchMethodUpdate, RetailerID: "SKU-1", Price: 2000, ClearSalePrice: true},
+		{Method: whatsapp.BatchMethodDelete, RetailerID: "SKU-2", Name: "ignored"},
+	}
+
+	client := newTestClient(t, server)
+	_, err := client.BatchProducts(context.Background(), testAccount(server.URL), "catalog-123", items)
+	require.NoError(t, err)
+}
+
+func TestClient_CheckBatchStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Contains(t, r.URL.Path, "/catalog-123/check_batch_request_status")
+		assert.Equal(t, "handle-1", r.URL.Query().Get("handle"))
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{
+				"status":             "finished",
+				"errors_total_count": 1,
+				"errors":             []map[string]interface{}{{"line": 2, "id": "SKU-1", "message": "Price is missing"}},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	status, err := client.CheckBatchStatus(context.Background(), testAccount(server.URL), "catalog-123", "handle-1")
+	require.NoError(t, err)
+	assert.Equal(t, "finished", status.Status)
+	assert.Equal(t, 1, status.ErrorCount)
+	assert.Equal(t, []whatsapp.ProductBatchError{{RetailerID: "SKU-1", Message: "Price is missing"}}, status.Errors)
+}
+
+// --- Product sets ---
+
+func TestClient_CreateProductSet_EncodesFilter(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Contains(t, r.URL.Path, "/catalog-123/product_sets")
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "Summer sale", body["name"])
+		assert.JSONEq(t, `{"retailer_id":{"is_any":["SKU-1","SKU-2"]}}`, body["filter"])
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": "set-1"})
+	}))
+	defer server.Close()
+
+	filter := map[string]interface{}{"retailer_id": map[string]interface{}{"is_any": []string{"SKU-1", "SKU-2"}}}
+
+	client := newTestClient(t, server)
+	id, err := client.CreateProductSet(context.Background(), testAccount(server.URL), "catalog-123", "Summer sale", filter)
+	require.NoError(t, err)
+	assert.Equal(t, "set-1", id)
+}
+
+func TestParseMetaPrice(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]int64{
+		"$1,234.50": 123450,
+		"12.5 USD":  1250,
+		"€8":        800,
+		"1999":      1999, // Plain integers are cents
+		"9.999":     999,
+	}
+	for price, cents := range tests {
+		got, ok := whatsapp.ParseMetaPrice(price)
+		assert.True(t, ok, price)
+		assert.Equal(t, cents, got, price)
+	}
+
+	_, ok := whatsapp.ParseMetaPrice("")
+	assert.False(t, ok)
+}
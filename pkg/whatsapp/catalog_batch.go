@@ -0,0 +1,330 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// MaxBatchRequests is the number of products Meta accepts in one items_batch call
+const MaxBatchRequests = 5000
+
+// Batch request methods
+const (
+	BatchMethodCreate = "CREATE"
+	BatchMethodUpdate = "UPDATE"
+	BatchMethodDelete = "DELETE"
+)
+
+// ProductBatchItem is one product change of an items_batch request. Empty fields
+// are left out, so an UPDATE only changes the fields that are set.
+type ProductBatchItem struct {
+	Method       string // CREATE, UPDATE or DELETE
+	RetailerID   string // SKU, identifies the product in the catalog
+	Name         string
+	Description  string
+	Brand        string
+	Price        int64 // In cents
+	SalePrice    int64 // In cents
+	Currency     string
+	URL          string
+	ImageURL     string
+	Availability string // in stock, out of stock, preorder, available for order, discontinued
+	Condition    string // new, refurbished, used
+	Inventory    *int
+
+	// ClearSalePrice ends a sale on UPDATE; a zero SalePrice alone leaves it unchanged
+	ClearSalePrice bool
+}
+
+// ProductBatchError is a product Meta refused in a batch
+type ProductBatchError struct {
+	RetailerID string `json:"retailer_id"`
+	Message    string `json:"message"`
+}
+
+// ProductBatchResult is the outcome of submitting a batch
+type ProductBatchResult struct {
+	Handles   []string            // Poll these with CheckBatchStatus
+	Submitted int                 // Items sent in successful calls; the rest were not sent
+	Errors    []ProductBatchError // Products that failed validation and were not queued
+}
+
+// ProductBatchStatus is the processing state of a submitted batch
+type ProductBatchStatus struct {
+	Status     string // in_progress, finished or error
+	ErrorCount int
+	Errors     []ProductBatchError
+}
+
+// ProductSetInfo represents a product set (collection) of a catalog
+type ProductSetInfo struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Filter       string `json:"filter"` // JSON rule, e.g. {"retailer_id":{"is_any":["sku-1"]}}
+	ProductCount int    `json:"product_count"`
+}
+
+// buildCatalogNodeURL builds the URL of a catalog edge, e.g. items_batch
+func (c *Client) buildCatalogNodeURL(account *Account, catalogID, edge string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", c.getBaseURL(), account.APIVersion, catalogID, edge)
+}
+
+// BatchProducts creates, updates and deletes products through the items_batch
+// endpoint, MaxBatchRequests at a time. Meta processes batches asynchronously.
+// On error the result holds the batches submitted so far.
+func (c *Client) BatchProducts(ctx context.Context, account *Account, catalogID string, items []ProductBatchItem) (*ProductBatchResult, error) {
+	apiURL := c.buildCatalogNodeURL(account, catalogID, "items_batch")
+	result := &ProductBatchResult{}
+
+	for start := 0; start < len(items); start += MaxBatchRequests {
+		end := start + MaxBatchRequests
+		if end > len(items) {
+			end = len(items)
+		}
+
+		requests := make([]map[string]interface{}, 0, end-start)
+		for i := range items[start:end] {
+			item := &items[start+i]
+			requests = append(requests, map[string]interface{}{
+				"method": item.Method,
+				"data":   batchItemData(item),
+			})
+		}
+
+		// allow_upsert lets an UPDATE create a product Meta doesn't have yet
+		body := map[string]interface{}{
+			"item_type":    "PRODUCT_ITEM",
+			"allow_upsert": true,
+			"requests":     requests,
+		}
+		respBody, err := c.doRequest(ctx, http.MethodPost, apiURL, body, account.AccessToken)
+		if err != nil {
+			return result, err
+		}
+
+		var resp struct {
+			Handles          []string `json:"handles"`
+			ValidationStatus []struct {
+				RetailerID string `json:"retailer_id"`
+				Errors     []struct {
+					Message string `json:"message"`
+				} `json:"errors"`
+			} `json:"validation_status"`
+		}
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return result, fmt.Errorf("failed to parse response: %w", err)
+		}
+		result.Handles = append(result.Handles, resp.Handles...)
+		result.Submitted = end
+		for _, vs := range resp.ValidationStatus {
+			for _, e := range vs.Errors {
+				result.Errors = append(result.Errors, ProductBatchError{RetailerID: vs.RetailerID, Message: e.Message})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// batchItemData converts a batch item to the data object Meta expects
+func batchItemData(item *ProductBatchItem) map[string]interface{} {
+	data := map[string]interface{}{"id": item.RetailerID}
+	if item.Method == BatchMethodDelete {
+		return data
+	}
+
+	setString := func(key, value string) {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	setString("title", item.Name)
+	setString("description", item.Description)
+	setString("brand", item.Brand)
+	setString("link", item.URL)
+	setString("image_link", item.ImageURL)
+	setString("availability", item.Availability)
+	setString("condition", item.Condition)
+	if item.Price > 0 {
+		data["price"] = FormatBatchPrice(item.Price, item.Currency)
+	}
+	if item.SalePrice > 0 {
+		data["sale_price"] = FormatBatchPrice(item.SalePrice, item.Currency)
+	} else if item.ClearSalePrice {
+		data["sale_price"] = ""
+	}
+	if item.Inventory != nil {
+		data["inventory"] = *item.Inventory
+	}
+	return data
+}
+
+// FormatBatchPrice formats cents the way the batch API expects, e.g. "12.50 USD"
+func FormatBatchPrice(cents int64, currency string) string {
+	if currency == "" {
+		currency = "USD"
+	}
+	return fmt.Sprintf("%d.%02d %s", cents/100, cents%100, currency)
+}
+
+// CheckBatchStatus returns the processing state of a batch submitted with BatchProducts
+func (c *Client) CheckBatchStatus(ctx context.Context, account *Account, catalogID, handle string) (*ProductBatchStatus, error) {
+	params := url.Values{}
+	params.Add("handle", handle)
+	apiURL := c.buildCatalogNodeURL(account, catalogID, "check_batch_request_status") + "?" + params.Encode()
+
+	respBody, err := c.doRequest(ctx, http.MethodGet, apiURL, nil, account.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data []struct {
+			Status           string `json:"status"`
+			ErrorsTotalCount int    `json:"errors_total_count"`
+			Errors           []struct {
+				ID      string `json:"id"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("no status returned for batch %s", handle)
+	}
+
+	data := resp.Data[0]
+	status := &ProductBatchStatus{Status: data.Status, ErrorCount: data.ErrorsTotalCount}
+	for _, e := range data.Errors {
+		status.Errors = append(status.Errors, ProductBatchError{RetailerID: e.ID, Message: e.Message})
+	}
+	if status.ErrorCount < len(status.Errors) {
+		status.ErrorCount = len(status.Errors)
+	}
+	return status, nil
+}
+
+// ListProductSets lists the product sets of a catalog
+func (c *Client) ListProductSets(ctx context.Context, account *Account, catalogID string) ([]ProductSetInfo, error) {
+	apiURL := c.buildCatalogNodeURL(account, catalogID, "product_sets") + "?fields=id,name,filter,product_count&limit=100"
+
+	var sets []ProductSetInfo
+	for page := 0; apiURL != "" && page < maxProductPages; page++ {
+		respBody, err := c.doRequest(ctx, http.MethodGet, apiURL, nil, account.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp struct {
+			Data   []ProductSetInfo `json:"data"`
+			Paging metaPaging       `json:"paging,omitempty"`
+		}
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		sets = append(sets, resp.Data...)
+		apiURL = resp.Paging.Next
+	}
+
+	return sets, nil
+}
+
+// CreateProductSet creates a product set matching filter and returns its ID
+func (c *Client) CreateProductSet(ctx context.Context, account *Account, catalogID, name string, filter map[string]interface{}) (string, error) {
+	body, err := productSetBody(name, filter)
+	if err != nil {
+		return "", err
+	}
+
+	respBody, err := c.doRequest(ctx, http.MethodPost, c.buildCatalogNodeURL(account, catalogID, "product_sets"), body, account.AccessToken)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	return resp.ID, nil
+}
+
+// UpdateProductSet renames a product set or replaces its filter
+func (c *Client) UpdateProductSet(ctx context.Context, account *Account, productSetID, name string, filter map[string]interface{}) error {
+	body, err := productSetBody(name, filter)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.doRequest(ctx, http.MethodPost, c.buildProductURL(account, productSetID), body, account.AccessToken)
+	return err
+}
+
+// DeleteProductSet deletes a product set. The products stay in the catalog.
+func (c *Client) DeleteProductSet(ctx context.Context, account *Account, productSetID string) error {
+	_, err := c.doRequest(ctx, http.MethodDelete, c.buildProductURL(account, productSetID), nil, account.AccessToken)
+	return err
+}
+
+// productSetBody builds a product set request; Meta expects the filter as a JSON string
+func productSetBody(name string, filter map[string]interface{}) (map[string]string, error) {
+	body := map[string]string{}
+	if name != "" {
+		body["name"] = name
+	}
+	if filter != nil {
+		encoded, err := json.Marshal(filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode filter: %w", err)
+		}
+		body["filter"] = string(encoded)
+	}
+	return body, nil
+}
+
+// ParseMetaPrice converts a price returned by the Graph API ("$1,234.50",
+// "12.50 USD" or "1250") to cents. Plain integers are already cents.
+func ParseMetaPrice(price string) (int64, bool) {
+	var digits []byte
+	decimals := -1
+	for i := 0; i < len(price); i++ {
+		ch := price[i]
+		switch {
+		case ch >= '0' && ch <= '9':
+			digits = append(digits, ch)
+			if decimals >= 0 {
+				decimals++
+			}
+		case ch == '.' && decimals < 0 && len(digits) > 0:
+			decimals = 0
+		}
+	}
+	if len(digits) == 0 {
+		return 0, false
+	}
+
+	value, err := strconv.ParseInt(string(digits), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	if decimals < 0 {
+		if len(digits) == len(price) {
+			return value, true
+		}
+		decimals = 0
+	}
+	for ; decimals < 2; decimals++ {
+		value *= 10
+	}
+	for ; decimals > 2; decimals-- {
+		value /= 10
+	}
+	return value, true
+}
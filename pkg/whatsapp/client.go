@@ -495,6 +495,30 @@ func (c *Client) MarkMessageRead(ctx context.Context, account *Account, messageI
 	return nil
 }
 
+// SendTypingIndicator shows the customer that a reply is being typed. WhatsApp
+// marks messageID, the customer's latest message, as read and shows the
+// indicator until the reply is sent or for up to 25 seconds.
+func (c *Client) SendTypingIndicator(ctx context.Context, account *Account, messageID string) error {
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"status":            "read",
+		"message_id":        messageID,
+		"typing_indicator": map[string]string{
+			"type": "text",
+		},
+	}
+
+	url := c.buildMessagesURL(account)
+	c.Log.Debug("Sending typing indicator", "message_id", messageID)
+
+	_, err := c.doRequest(ctx, "POST", url, payload, account.AccessToken)
+	if err != nil {
+		return fmt.Errorf("failed to send typing indicator: %w", err)
+	}
+
+	return nil
+}
+
 // ResumableUploadResponse represents response from creating upload session
 type ResumableUploadResponse struct {
 	ID string `json:"id"` // Upload session ID
@@ -395,6 +395,30 @@ func TestClient_MarkMessageRead(t *testing.T) {
 	}
 }
 
+func TestClient_SendTypingIndicator(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "read", body["status"])
+		assert.Equal(t, "wamid.test123", body["message_id"])
+		assert.Equal(t, map[string]interface{}{"type": "text"}, body["typing_indicator"])
+
+		_ = json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	}))
+	defer server.Close()
+
+	log := testutil.NopLogger()
+	client := whatsapp.NewWithTimeout(log, 5*time.Second)
+	client.HTTPClient = &http.Client{
+		Transport: &testServerTransport{serverURL: server.URL},
+	}
+
+	err := client.SendTypingIndicator(testutil.TestContext(t), testAccount(server.URL), "wamid.test123")
+	require.NoError(t, err)
+}
+
 func TestClient_SendImageMessage(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,309 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Flow data exchange actions sent by WhatsApp to a flow endpoint
+const (
+	FlowActionInit         = "INIT"
+	FlowActionDataExchange = "data_exchange"
+	FlowActionBack         = "BACK"
+	FlowActionPing         = "ping"
+)
+
+// ErrFlowDecryption is returned when an encrypted flow request cannot be decrypted.
+// Endpoints must answer with HTTP 421 so WhatsApp refreshes the public key and retries.
+var ErrFlowDecryption = errors.New("failed to decrypt flow request")
+
+// EncryptedFlowRequest is the body WhatsApp posts to a flow endpoint
+type EncryptedFlowRequest struct {
+	EncryptedFlowData string `json:"encrypted_flow_data"`
+	EncryptedAESKey   string `json:"encrypted_aes_key"`
+	InitialVector     string `json:"initial_vector"`
+}
+
+// FlowDataRequest is the decrypted payload of a flow endpoint request
+type FlowDataRequest struct {
+	Version   string                 `json:"version"`
+	Action    string                 `json:"action"`
+	Screen    string                 `json:"screen,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	FlowToken string                 `json:"flow_token,omitempty"`
+}
+
+// FlowDataSession holds the key material needed to encrypt the response to a request
+type FlowDataSession struct {
+	aesKey []byte
+	iv     []byte
+}
+
+// GenerateFlowKeyPair creates a 2048-bit RSA key pair for flow endpoint encryption.
+// Both keys are returned PEM encoded; the public key is uploaded to Meta.
+func GenerateFlowKeyPair() (privateKeyPEM, publicKeyPEM string, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate RSA key: %w", err)
+	}
+
+	privBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	pubBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+
+	privateKeyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privBytes}))
+	publicKeyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}))
+	return privateKeyPEM, publicKeyPEM, nil
+}
+
+// parseFlowPrivateKey parses a PKCS#8 or PKCS#1 PEM encoded RSA private key
+func parseFlowPrivateKey(privateKeyPEM string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, errors.New("invalid private key PEM")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// DecryptFlowRequest decrypts a flow endpoint request using the account's private key.
+// The AES key is RSA-OAEP (SHA-256) encrypted and the payload is AES-GCM with the tag appended.
+func DecryptFlowRequest(req *EncryptedFlowRequest, privateKeyPEM string) (*FlowDataRequest, *FlowDataSession, error) {
+	key, err := parseFlowPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey, err := base64.StdEncoding.DecodeString(req.EncryptedAESKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid aes key encoding", ErrFlowDecryption)
+	}
+	iv, err := base64.StdEncoding.DecodeString(req.InitialVector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid initial vector encoding", ErrFlowDecryption)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(req.EncryptedFlowData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid flow data encoding", ErrFlowDecryption)
+	}
+
+	aesKey, err := rsa.DecryptOAEP(sha256.New(), nil, key, encryptedKey, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrFlowDecryption, err)
+	}
+
+	gcm, err := newFlowGCM(aesKey, len(iv))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrFlowDecryption, err)
+	}
+	plaintext, err := gcm.Open(nil, iv, ciphertext, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrFlowDecryption, err)
+	}
+
+	var data FlowDataRequest
+	if err := json.Unmarshal(plaintext, &data); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse flow request: %w", err)
+	}
+
+	return &data, &FlowDataSession{aesKey: aesKey, iv: iv}, nil
+}
+
+// EncryptResponse encrypts a flow endpoint response with the request's AES key and
+// the bitwise-inverted initial vector, returning the base64 body to send as text/plain.
+func (s *FlowDataSession) EncryptResponse(response interface{}) (string, error) {
+	plaintext, err := json.Marshal(response)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal flow response: %w", err)
+	}
+
+	flipped := make([]byte, len(s.iv))
+	for i, b := range s.iv {
+		flipped[i] = ^b
+	}
+
+	gcm, err := newFlowGCM(s.aesKey, len(flipped))
+	if err != nil {
+		return "", err
+	}
+	ciphertext := gcm.Seal(nil, flipped, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// EncryptFlowRequest encrypts a flow request the way WhatsApp does. It is the counterpart
+// of DecryptFlowRequest and is used to exercise endpoints in tests and health checks.
+func EncryptFlowRequest(data *FlowDataRequest, publicKeyPEM string) (*EncryptedFlowRequest, *FlowDataSession, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, nil, errors.New("invalid public key PEM")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	pub, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, nil, errors.New("public key is not an RSA key")
+	}
+
+	aesKey := make([]byte, 16)
+	iv := make([]byte, 16)
+	if _, err := rand.Read(aesKey); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, aesKey, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt aes key: %w", err)
+	}
+
+	plaintext, err := json.Marshal(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := newFlowGCM(aesKey, len(iv))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &EncryptedFlowRequest{
+		EncryptedFlowData: base64.StdEncoding.EncodeToString(gcm.Seal(nil, iv, plaintext, nil)),
+		EncryptedAESKey:   base64.StdEncoding.EncodeToString(encryptedKey),
+		InitialVector:     base64.StdEncoding.EncodeToString(iv),
+	}, &FlowDataSession{aesKey: aesKey, iv: iv}, nil
+}
+
+// DecryptResponse decrypts a response produced by EncryptResponse
+func (s *FlowDataSession) DecryptResponse(body string, out interface{}) error {
+	ciphertext, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return err
+	}
+
+	flipped := make([]byte, len(s.iv))
+	for i, b := range s.iv {
+		flipped[i] = ^b
+	}
+
+	gcm, err := newFlowGCM(s.aesKey, len(flipped))
+	if err != nil {
+		return err
+	}
+	plaintext, err := gcm.Open(nil, flipped, ciphertext, nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plaintext, out)
+}
+
+func newFlowGCM(key []byte, nonceSize int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, nonceSize)
+}
+
+// BusinessEncryptionResponse represents the public key registered for a phone number
+type BusinessEncryptionResponse struct {
+	Data []struct {
+		BusinessPublicKey                string `json:"business_public_key"`
+		BusinessPublicKeySignatureStatus string `json:"business_public_key_signature_status"`
+	} `json:"data"`
+}
+
+// SetBusinessPublicKey uploads the flow endpoint public key for the account's phone number.
+// Calls POST /{api_version}/{phone_id}/whatsapp_business_encryption
+func (c *Client) SetBusinessPublicKey(ctx context.Context, account *Account, publicKeyPEM string) error {
+	url := fmt.Sprintf("%s/%s/%s/whatsapp_business_encryption", c.getBaseURL(), account.APIVersion, account.PhoneID)
+
+	payload := map[string]string{"business_public_key": publicKeyPEM}
+	respBody, err := c.doRequest(ctx, http.MethodPost, url, payload, account.AccessToken)
+	if err != nil {
+		return fmt.Errorf("failed to upload business public key: %w", err)
+	}
+
+	var result FlowPublishResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("business public key upload was not successful")
+	}
+
+	c.Log.Info("Business public key uploaded", "phone_id", account.PhoneID)
+	return nil
+}
+
+// GetBusinessPublicKey returns the public key registered for the account's phone number
+// and its signature status (VALID or MISMATCH).
+func (c *Client) GetBusinessPublicKey(ctx context.Context, account *Account) (publicKey, status string, err error) {
+	url := fmt.Sprintf("%s/%s/%s/whatsapp_business_encryption", c.getBaseURL(), account.APIVersion, account.PhoneID)
+
+	respBody, err := c.doRequest(ctx, http.MethodGet, url, nil, account.AccessToken)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch business public key: %w", err)
+	}
+
+	var result BusinessEncryptionResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(result.Data) == 0 {
+		return "", "", nil
+	}
+	return result.Data[0].BusinessPublicKey, result.Data[0].BusinessPublicKeySignatureStatus, nil
+}
+
+// SetFlowEndpoint sets the data exchange endpoint URI of a flow
+func (c *Client) SetFlowEndpoint(ctx context.Context, account *Account, flowID, endpointURI string) error {
+	url := fmt.Sprintf("%s/%s/%s", c.getBaseURL(), account.APIVersion, flowID)
+
+	payload := map[string]string{"endpoint_uri": endpointURI}
+	respBody, err := c.doRequest(ctx, http.MethodPost, url, payload, account.AccessToken)
+	if err != nil {
+		c.Log.Error("Failed to set flow endpoint", "error", err, "flow_id", flowID)
+		return err
+	}
+
+	var result FlowPublishResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("failed to set flow endpoint")
+	}
+
+	c.Log.Info("Flow endpoint set", "flow_id", flowID, "endpoint_uri", endpointURI)
+	return nil
+}
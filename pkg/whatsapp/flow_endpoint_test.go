@@ -0,0 +1,128 @@
+package whatsapp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Flow endpoint encryption ---
+
+func TestFlowEndpoint_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	privateKey, publicKey, err := whatsapp.GenerateFlowKeyPair()
+	require.NoError(t, err)
+	assert.Contains(t, privateKey, "BEGIN PRIVATE KEY")
+	assert.Contains(t, publicKey, "BEGIN PUBLIC KEY")
+
+	encrypted, clientSession, err := whatsapp.EncryptFlowRequest(&whatsapp.FlowDataRequest{
+		Version:   "3.0",
+		Action:    whatsapp.FlowActionDataExchange,
+		Screen:    "BOOKING",
+		Data:      map[string]interface{}{"date": "2026-01-02"},
+		FlowToken: "token-1",
+	}, publicKey)
+	require.NoError(t, err)
+
+	req, serverSession, err := whatsapp.DecryptFlowRequest(encrypted, privateKey)
+	require.NoError(t, err)
+	assert.Equal(t, whatsapp.FlowActionDataExchange, req.Action)
+	assert.Equal(t, "BOOKING", req.Screen)
+	assert.Equal(t, "token-1", req.FlowToken)
+	assert.Equal(t, "2026-01-02", req.Data["date"])
+
+	body, err := serverSession.EncryptResponse(map[string]interface{}{"screen": "SLOTS"})
+	require.NoError(t, err)
+
+	var resp map[string]interface{}
+	require.NoError(t, clientSession.DecryptResponse(body, &resp))
+	assert.Equal(t, "SLOTS", resp["screen"])
+}
+
+func TestFlowEndpoint_DecryptWithWrongKey(t *testing.T) {
+	t.Parallel()
+
+	_, publicKey, err := whatsapp.GenerateFlowKeyPair()
+	require.NoError(t, err)
+	otherPrivateKey, _, err := whatsapp.GenerateFlowKeyPair()
+	require.NoError(t, err)
+
+	encrypted, _, err := whatsapp.EncryptFlowRequest(&whatsapp.FlowDataRequest{Version: "3.0", Action: whatsapp.FlowActionPing}, publicKey)
+	require.NoError(t, err)
+
+	_, _, err = whatsapp.DecryptFlowRequest(encrypted, otherPrivateKey)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, whatsapp.ErrFlowDecryption))
+}
+
+func TestFlowEndpoint_DecryptInvalidPrivateKey(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := whatsapp.DecryptFlowRequest(&whatsapp.EncryptedFlowRequest{}, "not a key")
+	require.Error(t, err)
+}
+
+// --- Business public key ---
+
+func TestClient_SetBusinessPublicKey(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Contains(t, r.URL.Path, "/whatsapp_business_encryption")
+
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "PUBLIC KEY", body["business_public_key"])
+
+		_ = json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	err := client.SetBusinessPublicKey(context.Background(), testAccount(server.URL), "PUBLIC KEY")
+	require.NoError(t, err)
+}
+
+func TestClient_GetBusinessPublicKey(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		_, _ = w.Write([]byte(`{"data":[{"business_public_key":"PUBLIC KEY","business_public_key_signature_status":"VALID"}]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	key, status, err := client.GetBusinessPublicKey(context.Background(), testAccount(server.URL))
+	require.NoError(t, err)
+	assert.Equal(t, "PUBLIC KEY", key)
+	assert.Equal(t, "VALID", status)
+}
+
+func TestClient_SetFlowEndpoint(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, "/flow-123")
+
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "https://example.com/api/webhook/flows/abc", body["endpoint_uri"])
+
+		_ = json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	err := client.SetFlowEndpoint(context.Background(), testAccount(server.URL), "flow-123", "https://example.com/api/webhook/flows/abc")
+	require.NoError(t, err)
+}
@@ -209,6 +209,53 @@ func (c *Client) SendCTAURLButton(ctx context.Context, account *Account, phoneNu
 	return messageID, nil
 }
 
+// SendLocationRequest sends an interactive message asking the user to share their location
+func (c *Client) SendLocationRequest(ctx context.Context, account *Account, phoneNumber, bodyText string) (string, error) {
+	if bodyText == "" {
+		return "", fmt.Errorf("body text is required")
+	}
+
+	interactive := map[string]interface{}{
+		"type": "location_request_message",
+		"body": map[string]interface{}{
+			"text": bodyText,
+		},
+		"action": map[string]interface{}{
+			"name": "send_location",
+		},
+	}
+
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"recipient_type":    "individual",
+		"to":                phoneNumber,
+		"type":              "interactive",
+		"interactive":       interactive,
+	}
+
+	apiURL := c.buildMessagesURL(account)
+	c.Log.Debug("Sending location request message", "phone", phoneNumber)
+
+	respBody, err := c.doRequest(ctx, "POST", apiURL, payload, account.AccessToken)
+	if err != nil {
+		c.Log.Error("Failed to send location request message", "error", err, "phone", phoneNumber)
+		return "", fmt.Errorf("failed to send location request message: %w", err)
+	}
+
+	var resp MetaAPIResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(resp.Messages) == 0 {
+		return "", fmt.Errorf("no message ID in response")
+	}
+
+	messageID := resp.Messages[0].ID
+	c.Log.Info("Location request message sent", "message_id", messageID, "phone", phoneNumber)
+	return messageID, nil
+}
+
 // TemplateParam represents a parameter for template message
 type TemplateParam struct {
 	Type  string `json:"type"`
@@ -302,11 +349,18 @@ func (c *Client) SendTemplateMessage(ctx context.Context, account *Account, phon
 	return messageID, nil
 }
 
+// Flow message actions: how a flow message opens the flow
+const (
+	FlowMessageActionNavigate     = "navigate"      // Open the screen given in the message
+	FlowMessageActionDataExchange = "data_exchange" // Ask the flow endpoint for the first screen
+)
+
 // SendFlowMessage sends an interactive WhatsApp Flow message
 // flowID is the Meta Flow ID, headerText is optional header, bodyText is the message body,
 // ctaText is the button text, flowToken is a unique token for tracking the flow response,
-// and firstScreen is the name of the first screen to navigate to
-func (c *Client) SendFlowMessage(ctx context.Context, account *Account, phoneNumber, flowID, headerText, bodyText, ctaText, flowToken, firstScreen string) (string, error) {
+// flowAction is FlowMessageActionNavigate (default) or FlowMessageActionDataExchange,
+// and firstScreen is the name of the first screen to navigate to (ignored for data_exchange)
+func (c *Client) SendFlowMessage(ctx context.Context, account *Account, phoneNumber, flowID, headerText, bodyText, ctaText, flowToken, flowAction, firstScreen string) (string, error) {
 	if flowID == "" {
 		return "", fmt.Errorf("flow ID is required")
 	}
@@ -319,6 +373,12 @@ func (c *Client) SendFlowMessage(ctx context.Context, account *Account, phoneNum
 	if flowToken == "" {
 		flowToken = fmt.Sprintf("flow_%d", time.Now().UnixNano())
 	}
+	if flowAction == "" {
+		flowAction = FlowMessageActionNavigate
+	}
+	if flowAction != FlowMessageActionNavigate && flowAction != FlowMessageActionDataExchange {
+		return "", fmt.Errorf("invalid flow action: %s", flowAction)
+	}
 	if firstScreen == "" {
 		firstScreen = "FIRST_SCREEN" // Default fallback
 	}
@@ -328,23 +388,27 @@ func (c *Client) SendFlowMessage(ctx context.Context, account *Account, phoneNum
 		ctaText = ctaText[:20]
 	}
 
+	parameters := map[string]interface{}{
+		"flow_message_version": "3",
+		"flow_token":           flowToken,
+		"flow_id":              flowID,
+		"flow_cta":             ctaText,
+		"flow_action":          flowAction,
+	}
+	if flowAction == FlowMessageActionNavigate {
+		parameters["flow_action_payload"] = map[string]interface{}{
+			"screen": firstScreen,
+		}
+	}
+
 	interactive := map[string]interface{}{
 		"type": "flow",
 		"body": map[string]interface{}{
 			"text": bodyText,
 		},
 		"action": map[string]interface{}{
-			"name": "flow",
-			"parameters": map[string]interface{}{
-				"flow_message_version": "3",
-				"flow_token":           flowToken,
-				"flow_id":              flowID,
-				"flow_cta":             ctaText,
-				"flow_action":          "navigate",
-				"flow_action_payload": map[string]interface{}{
-					"screen": firstScreen,
-				},
-			},
+			"name":       "flow",
+			"parameters": parameters,
 		},
 	}
 
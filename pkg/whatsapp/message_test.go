@@ -405,6 +405,49 @@ func TestClient_SendCTAURLButton(t *testing.T) {
 	}
 }
 
+func TestClient_SendLocationRequest(t *testing.T) {
+	t.Parallel()
+
+	var capturedBody map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&capturedBody)
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": []map[string]string{{"id": "wamid.loc123"}},
+		})
+	}))
+	defer server.Close()
+
+	log := testutil.NopLogger()
+	client := whatsapp.NewWithTimeout(log, 5*time.Second)
+	client.HTTPClient = &http.Client{
+		Transport: &testServerTransport{serverURL: server.URL},
+	}
+
+	account := &whatsapp.Account{
+		PhoneID:     "123456789",
+		BusinessID:  "987654321",
+		APIVersion:  "v21.0",
+		AccessToken: "test-token",
+	}
+	ctx := testutil.TestContext(t)
+
+	_, err := client.SendLocationRequest(ctx, account, "1234567890", "")
+	require.Error(t, err)
+
+	msgID, err := client.SendLocationRequest(ctx, account, "1234567890", "Where should we deliver?")
+	require.NoError(t, err)
+	assert.Equal(t, "wamid.loc123", msgID)
+
+	interactive := capturedBody["interactive"].(map[string]interface{})
+	assert.Equal(t, "location_request_message", interactive["type"])
+	action := interactive["action"].(map[string]interface{})
+	assert.Equal(t, "send_location", action["name"])
+	body := interactive["body"].(map[string]interface{})
+	assert.Equal(t, "Where should we deliver?", body["text"])
+}
+
 func TestClient_SendTemplateMessageWithComponents(t *testing.T) {
 	t.Parallel()
 
@@ -461,3 +504,54 @@ func TestClient_SendTemplateMessageWithComponents(t *testing.T) {
 	assert.Len(t, sentComponents, 2)
 }
 
+
+func TestClient_SendFlowMessage(t *testing.T) {
+	t.Parallel()
+
+	var capturedBody map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&capturedBody)
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": []map[string]string{{"id": "wamid.flow123"}},
+		})
+	}))
+	defer server.Close()
+
+	log := testutil.NopLogger()
+	client := whatsapp.NewWithTimeout(log, 5*time.Second)
+	client.HTTPClient = &http.Client{
+		Transport: &testServerTransport{serverURL: server.URL},
+	}
+
+	account := &whatsapp.Account{
+		PhoneID:     "123456789",
+		BusinessID:  "987654321",
+		APIVersion:  "v21.0",
+		AccessToken: "test-token",
+	}
+	ctx := testutil.TestContext(t)
+
+	parameters := func() map[string]interface{} {
+		interactive := capturedBody["interactive"].(map[string]interface{})
+		action := interactive["action"].(map[string]interface{})
+		return action["parameters"].(map[string]interface{})
+	}
+
+	// A screen may be named like an action; it is still navigated to
+	_, err := client.SendFlowMessage(ctx, account, "1234567890", "flow-1", "", "Book now", "Book", "token-1", "", "data_exchange")
+	require.NoError(t, err)
+	params := parameters()
+	assert.Equal(t, whatsapp.FlowMessageActionNavigate, params["flow_action"])
+	assert.Equal(t, map[string]interface{}{"screen": "data_exchange"}, params["flow_action_payload"])
+
+	_, err = client.SendFlowMessage(ctx, account, "1234567890", "flow-1", "", "Book now", "Book", "token-2", whatsapp.FlowMessageActionDataExchange, "")
+	require.NoError(t, err)
+	params = parameters()
+	assert.Equal(t, whatsapp.FlowMessageActionDataExchange, params["flow_action"])
+	assert.NotContains(t, params, "flow_action_payload")
+
+	_, err = client.SendFlowMessage(ctx, account, "1234567890", "flow-1", "", "Book now", "Book", "token-3", "open", "")
+	require.Error(t, err)
+}
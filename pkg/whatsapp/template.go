@@ -5,9 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 )
 
+// maxTemplatePages caps how many pages of 100 templates FetchTemplates follows
+const maxTemplatePages = 100
+
 // TemplateSubmission represents a template to be submitted to Meta
 type TemplateSubmission struct {
 	MetaTemplateID  string        // If set, update existing template instead of creating new
@@ -21,6 +25,14 @@ type TemplateSubmission struct {
 	FooterContent   string
 	Buttons         []interface{}
 	SampleValues    []interface{} // For named: [{param_name: "name", value: "John"}, ...]
+
+	// Carousel cards: [{header_type, header_content, body_content, buttons, sample_values}, ...]
+	Cards []interface{}
+	// Limited-time offer: {text, has_expiration}
+	LimitedTimeOffer map[string]interface{}
+	// Authentication templates: Meta generates the body and footer text from these
+	AddSecurityRecommendation bool
+	CodeExpirationMinutes     int
 }
 
 // SubmitTemplate submits a template to Meta's API (creates new or updates existing)
@@ -34,236 +46,414 @@ func (c *Client) SubmitTemplate(ctx context.Context, account *Account, template
 		url = c.buildTemplatesURL(account)
 	}
 
-	// Build components array
-	components := []map[string]interface{}{}
-
 	// Check if using named parameters
-	isNamedParams := template.ParameterFormat == "named" || hasNamedParams(template.BodyContent)
+	isNamedParams := template.ParameterFormat == "named" || HasNamedParams(template.BodyContent)
+
+	components, err := buildTemplateComponents(template, isNamedParams)
+	if err != nil {
+		return "", err
+	}
+
+	// Build request payload
+	var payload map[string]interface{}
+	if isUpdate {
+		// Update only sends components (name, language, category are immutable)
+		payload = map[string]interface{}{
+			"components": components,
+		}
+	} else {
+		// Create sends full template
+		payload = map[string]interface{}{
+			"name":       template.Name,
+			"language":   template.Language,
+			"category":   template.Category,
+			"components": components,
+		}
+		// Add parameter_format for named parameters (only for create)
+		if isNamedParams {
+			payload["parameter_format"] = "NAMED"
+		}
+	}
+
+	// Log payload for debugging
+	action := "Submitting"
+	if isUpdate {
+		action = "Updating"
+	}
+	payloadJSON, _ := json.MarshalIndent(payload, "", "  ")
+	c.Log.Info(action+" template to Meta", "url", url, "name", template.Name, "payload", string(payloadJSON))
+
+	respBody, err := c.doRequest(ctx, http.MethodPost, url, payload, account.AccessToken)
+	if err != nil {
+		c.Log.Error("Failed to "+action+" template", "error", err, "name", template.Name)
+		return "", err
+	}
+
+	// For updates, return existing ID; for creates, parse response for new ID
+	if isUpdate {
+		c.Log.Info("Template updated", "template_id", template.MetaTemplateID, "name", template.Name)
+		return template.MetaTemplateID, nil
+	}
+
+	var result TemplateResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	c.Log.Info("Template submitted", "template_id", result.ID, "name", template.Name)
+	return result.ID, nil
+}
+
+// buildTemplateComponents builds the components array of a template submission
+func buildTemplateComponents(template *TemplateSubmission, isNamedParams bool) ([]map[string]interface{}, error) {
+	// Authentication templates use a fixed layout generated by Meta
+	if strings.ToUpper(template.Category) == "AUTHENTICATION" {
+		return buildAuthenticationComponents(template), nil
+	}
+
+	components := []map[string]interface{}{}
 
 	// Header component (must come before BODY)
-	if template.HeaderType != "" && template.HeaderType != "NONE" {
-		header := map[string]interface{}{
-			"type":   "HEADER",
-			"format": template.HeaderType,
+	if header := buildHeaderComponent(template.HeaderType, template.HeaderContent, template.SampleValues, isNamedParams); header != nil {
+		components = append(components, header)
+	}
+
+	// Limited-time offer component (between header and body)
+	if template.LimitedTimeOffer != nil {
+		offerText, _ := template.LimitedTimeOffer["text"].(string)
+		hasExpiration, _ := template.LimitedTimeOffer["has_expiration"].(bool)
+		components = append(components, map[string]interface{}{
+			"type": "LIMITED_TIME_OFFER",
+			"limited_time_offer": map[string]interface{}{
+				"text":           offerText,
+				"has_expiration": hasExpiration,
+			},
+		})
+	}
+
+	// Body component (required)
+	body, err := buildBodyComponent(template.BodyContent, template.SampleValues, isNamedParams)
+	if err != nil {
+		return nil, err
+	}
+	components = append(components, body)
+
+	// Footer component (not allowed on limited-time offers)
+	if template.FooterContent != "" && template.LimitedTimeOffer == nil {
+		components = append(components, map[string]interface{}{
+			"type": "FOOTER",
+			"text": template.FooterContent,
+		})
+	}
+
+	// Buttons component
+	if buttons := buildButtonsComponent(template.Buttons); buttons != nil {
+		components = append(components, buttons)
+	}
+
+	// Carousel component (after the body bubble)
+	if len(template.Cards) > 0 {
+		carousel, err := buildCarouselComponent(template.Cards, isNamedParams)
+		if err != nil {
+			return nil, err
 		}
-		addHeader := true
-		switch template.HeaderType {
-		case "TEXT":
-			header["text"] = template.HeaderContent
-			if strings.Contains(template.HeaderContent, "{{") {
-				if isNamedParams {
-					namedExamples := extractNamedExamplesForComponent(template.SampleValues, "header")
-					if len(namedExamples) > 0 {
-						header["example"] = map[string]interface{}{
-							"header_text_named_params": namedExamples,
-						}
-					}
-				} else {
-					headerExamples := extractExamplesForComponent(template.SampleValues, "header")
-					if len(headerExamples) > 0 {
-						header["example"] = map[string]interface{}{
-							"header_text": headerExamples,
-						}
+		components = append(components, carousel)
+	}
+
+	return components, nil
+}
+
+// buildHeaderComponent builds a HEADER component, or returns nil if there is none
+func buildHeaderComponent(headerType, headerContent string, sampleValues []interface{}, isNamedParams bool) map[string]interface{} {
+	if headerType == "" || headerType == "NONE" {
+		return nil
+	}
+
+	header := map[string]interface{}{
+		"type":   "HEADER",
+		"format": headerType,
+	}
+	switch headerType {
+	case "TEXT":
+		header["text"] = headerContent
+		if strings.Contains(headerContent, "{{") {
+			if isNamedParams {
+				namedExamples := extractNamedExamplesForComponent(sampleValues, "header")
+				if len(namedExamples) > 0 {
+					header["example"] = map[string]interface{}{
+						"header_text_named_params": namedExamples,
 					}
 				}
-			}
-		case "IMAGE", "VIDEO", "DOCUMENT":
-			// Media headers require a handle - skip if not provided
-			if template.HeaderContent != "" {
-				header["example"] = map[string]interface{}{
-					"header_handle": []string{template.HeaderContent},
-				}
 			} else {
-				// Don't add media header without a handle
-				addHeader = false
+				headerExamples := extractExamplesForComponent(sampleValues, "header")
+				if len(headerExamples) > 0 {
+					header["example"] = map[string]interface{}{
+						"header_text": headerExamples,
+					}
+				}
 			}
 		}
-		if addHeader {
-			components = append(components, header)
+	case "IMAGE", "VIDEO", "DOCUMENT":
+		// Media headers require a handle - skip if not provided
+		if headerContent == "" {
+			return nil
+		}
+		header["example"] = map[string]interface{}{
+			"header_handle": []string{headerContent},
 		}
 	}
+	return header
+}
 
-	// Body component (required)
+// buildBodyComponent builds a BODY component with examples for its variables
+func buildBodyComponent(bodyContent string, sampleValues []interface{}, isNamedParams bool) (map[string]interface{}, error) {
 	body := map[string]interface{}{
 		"type": "BODY",
-		"text": template.BodyContent,
+		"text": bodyContent,
 	}
 	// Add examples if there are variables in body
-	if strings.Contains(template.BodyContent, "{{") {
+	if strings.Contains(bodyContent, "{{") {
 		if isNamedParams {
-			namedExamples := extractNamedExamplesForComponent(template.SampleValues, "body")
+			namedExamples := extractNamedExamplesForComponent(sampleValues, "body")
 			if len(namedExamples) > 0 {
 				body["example"] = map[string]interface{}{
 					"body_text_named_params": namedExamples,
 				}
 			} else {
-				varCount := strings.Count(template.BodyContent, "{{")
+				varCount := strings.Count(bodyContent, "{{")
 				if varCount > 0 {
-					return "", fmt.Errorf("sample values are required for template variables. Found %d variable(s) in body but no sample values provided", varCount)
+					return nil, fmt.Errorf("sample values are required for template variables. Found %d variable(s) in body but no sample values provided", varCount)
 				}
 			}
 		} else {
-			bodyExamples := extractExamplesForComponent(template.SampleValues, "body")
+			bodyExamples := extractExamplesForComponent(sampleValues, "body")
 			if len(bodyExamples) > 0 {
 				body["example"] = map[string]interface{}{
 					"body_text": [][]string{bodyExamples},
 				}
 			} else {
-				varCount := strings.Count(template.BodyContent, "{{")
+				varCount := strings.Count(bodyContent, "{{")
 				if varCount > 0 {
-					return "", fmt.Errorf("sample values are required for template variables. Found %d variable(s) in body but no sample values provided", varCount)
+					return nil, fmt.Errorf("sample values are required for template variables. Found %d variable(s) in body but no sample values provided", varCount)
 				}
 			}
 		}
 	}
-	components = append(components, body)
+	return body, nil
+}
 
-	// Footer component
-	if template.FooterContent != "" {
-		components = append(components, map[string]interface{}{
-			"type": "FOOTER",
-			"text": template.FooterContent,
-		})
+// buildButtonsComponent builds a BUTTONS component, or returns nil if there are no valid buttons
+func buildButtonsComponent(templateButtons []interface{}) map[string]interface{} {
+	if len(templateButtons) == 0 {
+		return nil
 	}
 
-	// Buttons component
-	if len(template.Buttons) > 0 {
-		buttons := []map[string]interface{}{}
-		for _, btn := range template.Buttons {
-			if btnMap, ok := btn.(map[string]interface{}); ok {
-				btnType, _ := btnMap["type"].(string)
-				btnType = strings.ToUpper(btnType)
-				btnText, _ := btnMap["text"].(string)
-
-				if btnText == "" {
-					continue
-				}
+	buttons := []map[string]interface{}{}
+	for _, btn := range templateButtons {
+		if btnMap, ok := btn.(map[string]interface{}); ok {
+			btnType, _ := btnMap["type"].(string)
+			btnType = strings.ToUpper(btnType)
+			btnText, _ := btnMap["text"].(string)
 
-				button := map[string]interface{}{}
+			if btnText == "" {
+				continue
+			}
 
-				switch btnType {
-				case "QUICK_REPLY":
-					button["type"] = "QUICK_REPLY"
-					button["text"] = btnText
-				case "URL":
-					btnURL, _ := btnMap["url"].(string)
-					if btnURL == "" {
-						continue
-					}
-					button["type"] = "URL"
-					button["text"] = btnText
-					button["url"] = btnURL
-					if strings.Contains(btnURL, "{{") {
-						if example, ok := btnMap["example"].(string); ok && example != "" {
-							button["example"] = []string{example}
-						}
-					}
-				case "PHONE_NUMBER":
-					phoneNum, _ := btnMap["phone_number"].(string)
-					if phoneNum == "" {
-						continue
-					}
-					button["type"] = "PHONE_NUMBER"
-					button["text"] = btnText
-					button["phone_number"] = phoneNum
-				case "COPY_CODE":
-					button["type"] = "COPY_CODE"
-					button["text"] = btnText
+			button := map[string]interface{}{}
+
+			switch btnType {
+			case "QUICK_REPLY":
+				button["type"] = "QUICK_REPLY"
+				button["text"] = btnText
+			case "URL":
+				btnURL, _ := btnMap["url"].(string)
+				if btnURL == "" {
+					continue
+				}
+				button["type"] = "URL"
+				button["text"] = btnText
+				button["url"] = btnURL
+				if strings.Contains(btnURL, "{{") {
 					if example, ok := btnMap["example"].(string); ok && example != "" {
-						button["example"] = example
+						button["example"] = []string{example}
 					}
-				default:
-					button["type"] = "QUICK_REPLY"
-					button["text"] = btnText
 				}
-
-				if len(button) > 0 {
-					buttons = append(buttons, button)
+			case "PHONE_NUMBER":
+				phoneNum, _ := btnMap["phone_number"].(string)
+				if phoneNum == "" {
+					continue
+				}
+				button["type"] = "PHONE_NUMBER"
+				button["text"] = btnText
+				button["phone_number"] = phoneNum
+			case "COPY_CODE":
+				button["type"] = "COPY_CODE"
+				button["text"] = btnText
+				if example, ok := btnMap["example"].(string); ok && example != "" {
+					button["example"] = example
 				}
+			default:
+				button["type"] = "QUICK_REPLY"
+				button["text"] = btnText
+			}
+
+			if len(button) > 0 {
+				buttons = append(buttons, button)
 			}
-		}
-		if len(buttons) > 0 {
-			components = append(components, map[string]interface{}{
-				"type":    "BUTTONS",
-				"buttons": buttons,
-			})
 		}
 	}
+	if len(buttons) == 0 {
+		return nil
+	}
+	return map[string]interface{}{
+		"type":    "BUTTONS",
+		"buttons": buttons,
+	}
+}
 
-	// Build request payload
-	var payload map[string]interface{}
-	if isUpdate {
-		// Update only sends components (name, language, category are immutable)
-		payload = map[string]interface{}{
-			"components": components,
+// buildCarouselComponent builds a CAROUSEL component. Every card has a media header,
+// a body and its own buttons; card variables take their examples from the card's sample_values.
+func buildCarouselComponent(cards []interface{}, isNamedParams bool) (map[string]interface{}, error) {
+	metaCards := make([]map[string]interface{}, 0, len(cards))
+	for i, c := range cards {
+		card, ok := c.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("carousel card %d is not an object", i+1)
 		}
-	} else {
-		// Create sends full template
-		payload = map[string]interface{}{
-			"name":       template.Name,
-			"language":   template.Language,
-			"category":   template.Category,
-			"components": components,
+
+		headerType, _ := card["header_type"].(string)
+		headerContent, _ := card["header_content"].(string)
+		bodyContent, _ := card["body_content"].(string)
+		sampleValues, _ := card["sample_values"].([]interface{})
+		buttons, _ := card["buttons"].([]interface{})
+
+		header := buildHeaderComponent(strings.ToUpper(headerType), headerContent, nil, false)
+		if header == nil {
+			return nil, fmt.Errorf("carousel card %d requires an image or video header", i+1)
 		}
-		// Add parameter_format for named parameters (only for create)
-		if isNamedParams {
-			payload["parameter_format"] = "NAMED"
+		cardComponents := []map[string]interface{}{header}
+
+		if bodyContent != "" {
+			body, err := buildBodyComponent(bodyContent, sampleValues, isNamedParams)
+			if err != nil {
+				return nil, fmt.Errorf("carousel card %d: %w", i+1, err)
+			}
+			cardComponents = append(cardComponents, body)
 		}
-	}
 
-	// Log payload for debugging
-	action := "Submitting"
-	if isUpdate {
-		action = "Updating"
+		if buttonsComponent := buildButtonsComponent(buttons); buttonsComponent != nil {
+			cardComponents = append(cardComponents, buttonsComponent)
+		}
+
+		metaCards = append(metaCards, map[string]interface{}{"components": cardComponents})
 	}
-	payloadJSON, _ := json.MarshalIndent(payload, "", "  ")
-	c.Log.Info(action+" template to Meta", "url", url, "name", template.Name, "payload", string(payloadJSON))
 
-	respBody, err := c.doRequest(ctx, http.MethodPost, url, payload, account.AccessToken)
-	if err != nil {
-		c.Log.Error("Failed to "+action+" template", "error", err, "name", template.Name)
-		return "", err
+	return map[string]interface{}{
+		"type":  "CAROUSEL",
+		"cards": metaCards,
+	}, nil
+}
+
+// buildAuthenticationComponents builds the components of an authentication template.
+// Meta supplies the body and footer text; the template only chooses the options and OTP button.
+func buildAuthenticationComponents(template *TemplateSubmission) []map[string]interface{} {
+	components := []map[string]interface{}{
+		{
+			"type":                        "BODY",
+			"add_security_recommendation": template.AddSecurityRecommendation,
+		},
 	}
 
-	// For updates, return existing ID; for creates, parse response for new ID
-	if isUpdate {
-		c.Log.Info("Template updated", "template_id", template.MetaTemplateID, "name", template.Name)
-		return template.MetaTemplateID, nil
+	if template.CodeExpirationMinutes > 0 {
+		components = append(components, map[string]interface{}{
+			"type":                    "FOOTER",
+			"code_expiration_minutes": template.CodeExpirationMinutes,
+		})
 	}
 
-	var result TemplateResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+	var otpButton map[string]interface{}
+	for _, btn := range template.Buttons {
+		btnMap, ok := btn.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		btnType, _ := btnMap["type"].(string)
+		if t := strings.ToUpper(btnType); t != "OTP" && t != "COPY_CODE" {
+			continue
+		}
+
+		otpType, _ := btnMap["otp_type"].(string)
+		otpType = strings.ToUpper(otpType)
+		if otpType == "" {
+			otpType = "COPY_CODE"
+		}
+		otpButton = map[string]interface{}{
+			"type":     "OTP",
+			"otp_type": otpType,
+		}
+		if text, _ := btnMap["text"].(string); text != "" {
+			otpButton["text"] = text
+		}
+		if otpType == "ONE_TAP" || otpType == "ZERO_TAP" {
+			if autofill, _ := btnMap["autofill_text"].(string); autofill != "" {
+				otpButton["autofill_text"] = autofill
+			}
+			if apps, ok := btnMap["supported_apps"].([]interface{}); ok && len(apps) > 0 {
+				otpButton["supported_apps"] = apps
+			}
+			if otpType == "ZERO_TAP" {
+				otpButton["zero_tap_terms_accepted"] = true
+			}
+		}
+		break
+	}
+	if otpButton == nil {
+		otpButton = map[string]interface{}{
+			"type":     "OTP",
+			"otp_type": "COPY_CODE",
+		}
 	}
 
-	c.Log.Info("Template submitted", "template_id", result.ID, "name", template.Name)
-	return result.ID, nil
+	return append(components, map[string]interface{}{
+		"type":    "BUTTONS",
+		"buttons": []map[string]interface{}{otpButton},
+	})
 }
 
 // FetchTemplates fetches all templates from Meta's API
 func (c *Client) FetchTemplates(ctx context.Context, account *Account) ([]MetaTemplate, error) {
 	url := fmt.Sprintf("%s?limit=100", c.buildTemplatesURL(account))
 
-	respBody, err := c.doRequest(ctx, http.MethodGet, url, nil, account.AccessToken)
-	if err != nil {
-		c.Log.Error("Failed to fetch templates", "error", err)
-		return nil, err
-	}
+	// Follow every page: callers treat templates missing from the result as deleted on Meta
+	var templates []MetaTemplate
+	for page := 0; url != "" && page < maxTemplatePages; page++ {
+		respBody, err := c.doRequest(ctx, http.MethodGet, url, nil, account.AccessToken)
+		if err != nil {
+			c.Log.Error("Failed to fetch templates", "error", err, "page", page+1)
+			return nil, err
+		}
 
-	var result TemplateListResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		var result TemplateListResponse
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		templates = append(templates, result.Data...)
+		url = result.Paging.Next
 	}
 
-	c.Log.Info("Fetched templates from Meta", "count", len(result.Data))
-	return result.Data, nil
+	c.Log.Info("Fetched templates from Meta", "count", len(templates))
+	return templates, nil
 }
 
-// DeleteTemplate deletes a template from Meta's API
-func (c *Client) DeleteTemplate(ctx context.Context, account *Account, templateName string) error {
-	url := fmt.Sprintf("%s?name=%s", c.buildTemplatesURL(account), templateName)
+// DeleteTemplate deletes a template from Meta's API. Deleting by name removes
+// every language of the template; passing its ID removes only that language.
+func (c *Client) DeleteTemplate(ctx context.Context, account *Account, templateName, templateID string) error {
+	params := url.Values{"name": {templateName}}
+	if templateID != "" {
+		params.Set("hsm_id", templateID)
+	}
 
-	_, err := c.doRequest(ctx, http.MethodDelete, url, nil, account.AccessToken)
+	_, err := c.doRequest(ctx, http.MethodDelete, c.buildTemplatesURL(account)+"?"+params.Encode(), nil, account.AccessToken)
 	if err != nil {
 		c.Log.Error("Failed to delete template", "error", err, "template", templateName)
 		return err
@@ -335,8 +525,8 @@ func extractExamplesForComponent(sampleValues []interface{}, componentType strin
 	return examples
 }
 
-// hasNamedParams checks if the body content uses named parameters (non-numeric)
-func hasNamedParams(content string) bool {
+// HasNamedParams reports whether the content uses named parameters (non-numeric)
+func HasNamedParams(content string) bool {
 	// Extract all parameter names
 	matches := strings.Split(content, "{{")
 	for _, m := range matches[1:] { // Skip first part before any {{
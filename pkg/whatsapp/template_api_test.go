@@ -173,6 +173,152 @@ func TestClient_SubmitTemplate_MissingVariableSamples(t *testing.T) {
 	assert.Contains(t, err.Error(), "sample values are required")
 }
 
+// submittedComponents submits a template and returns the components Meta received, keyed by type
+func submittedComponents(t *testing.T, tmpl *whatsapp.TemplateSubmission) map[string]map[string]interface{} {
+	t.Helper()
+
+	components := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for _, c := range body["components"].([]interface{}) {
+			comp := c.(map[string]interface{})
+			components[comp["type"].(string)] = comp
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": "tmpl-1"})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	_, err := client.SubmitTemplate(context.Background(), testAccount(server.URL), tmpl)
+	require.NoError(t, err)
+	return components
+}
+
+func TestClient_SubmitTemplate_Carousel(t *testing.T) {
+	t.Parallel()
+
+	components := submittedComponents(t, &whatsapp.TemplateSubmission{
+		Name:        "summer_sale",
+		Language:    "en",
+		Category:    "MARKETING",
+		BodyContent: "Our summer picks are here!",
+		Cards: []interface{}{
+			map[string]interface{}{
+				"header_type":    "image",
+				"header_content": "handle-1",
+				"body_content":   "Sandals for {{1}}",
+				"sample_values":  []interface{}{map[string]interface{}{"component": "body", "index": 1, "value": "$20"}},
+				"buttons":        []interface{}{map[string]interface{}{"type": "QUICK_REPLY", "text": "Buy"}},
+			},
+			map[string]interface{}{
+				"header_type":    "IMAGE",
+				"header_content": "handle-2",
+				"body_content":   "Sunglasses",
+				"buttons":        []interface{}{map[string]interface{}{"type": "URL", "text": "View", "url": "https://example.com/sun"}},
+			},
+		},
+	})
+
+	require.Contains(t, components, "BODY")
+	carousel := components["CAROUSEL"]
+	require.NotNil(t, carousel)
+	cards := carousel["cards"].([]interface{})
+	require.Len(t, cards, 2)
+
+	first := cards[0].(map[string]interface{})["components"].([]interface{})
+	require.Len(t, first, 3)
+	header := first[0].(map[string]interface{})
+	assert.Equal(t, "IMAGE", header["format"])
+	assert.Equal(t, []interface{}{"handle-1"}, header["example"].(map[string]interface{})["header_handle"])
+	body := first[1].(map[string]interface{})
+	assert.Equal(t, []interface{}{[]interface{}{"$20"}}, body["example"].(map[string]interface{})["body_text"])
+}
+
+func TestClient_SubmitTemplate_CarouselCardWithoutMedia(t *testing.T) {
+	t.Parallel()
+
+	client := whatsapp.NewWithTimeout(testutil.NopLogger(), 5*time.Second)
+	_, err := client.SubmitTemplate(context.Background(), &whatsapp.Account{}, &whatsapp.TemplateSubmission{
+		Name:        "summer_sale",
+		Language:    "en",
+		Category:    "MARKETING",
+		BodyContent: "Our summer picks are here!",
+		Cards:       []interface{}{map[string]interface{}{"body_content": "Sandals"}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "carousel card 1")
+}
+
+func TestClient_SubmitTemplate_LimitedTimeOffer(t *testing.T) {
+	t.Parallel()
+
+	components := submittedComponents(t, &whatsapp.TemplateSubmission{
+		Name:             "flash_sale",
+		Language:         "en",
+		Category:         "MARKETING",
+		BodyContent:      "Get 20% off everything this weekend only.",
+		FooterContent:    "Not allowed on offers",
+		LimitedTimeOffer: map[string]interface{}{"text": "Weekend deal!", "has_expiration": true},
+		Buttons: []interface{}{
+			map[string]interface{}{"type": "COPY_CODE", "text": "Copy code", "example": "SAVE20"},
+		},
+	})
+
+	offer := components["LIMITED_TIME_OFFER"]
+	require.NotNil(t, offer)
+	assert.Equal(t, map[string]interface{}{"text": "Weekend deal!", "has_expiration": true}, offer["limited_time_offer"])
+	assert.NotContains(t, components, "FOOTER")
+	assert.Contains(t, components, "BUTTONS")
+}
+
+func TestClient_SubmitTemplate_Authentication(t *testing.T) {
+	t.Parallel()
+
+	components := submittedComponents(t, &whatsapp.TemplateSubmission{
+		Name:                      "login_code",
+		Language:                  "en",
+		Category:                  "AUTHENTICATION",
+		BodyContent:               "*{{1}}* is your verification code.",
+		AddSecurityRecommendation: true,
+		CodeExpirationMinutes:     10,
+		Buttons: []interface{}{
+			map[string]interface{}{
+				"type":           "OTP",
+				"otp_type":       "one_tap",
+				"text":           "Copy code",
+				"autofill_text":  "Autofill",
+				"supported_apps": []interface{}{map[string]interface{}{"package_name": "com.example.app", "signature_hash": "K8a/AINcGX7"}},
+			},
+		},
+	})
+
+	body := components["BODY"]
+	assert.Equal(t, true, body["add_security_recommendation"])
+	assert.NotContains(t, body, "text")
+	assert.Equal(t, float64(10), components["FOOTER"]["code_expiration_minutes"])
+
+	button := components["BUTTONS"]["buttons"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "OTP", button["type"])
+	assert.Equal(t, "ONE_TAP", button["otp_type"])
+	assert.Equal(t, "Autofill", button["autofill_text"])
+	assert.Len(t, button["supported_apps"], 1)
+}
+
+func TestClient_SubmitTemplate_AuthenticationDefaultButton(t *testing.T) {
+	t.Parallel()
+
+	components := submittedComponents(t, &whatsapp.TemplateSubmission{
+		Name:     "login_code",
+		Language: "en",
+		Category: "AUTHENTICATION",
+	})
+
+	assert.NotContains(t, components, "FOOTER")
+	button := components["BUTTONS"]["buttons"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "OTP", "otp_type": "COPY_CODE"}, button)
+}
+
 // --- FetchTemplates ---
 
 func TestClient_FetchTemplates_Success(t *testing.T) {
@@ -203,6 +349,92 @@ func TestClient_FetchTemplates_Success(t *testing.T) {
 	assert.Equal(t, "goodbye", templates[1].Name)
 }
 
+func TestClient_FetchTemplates_FollowsPaging(t *testing.T) {
+	t.Parallel()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Query().Get("after") == "" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data":   []map[string]interface{}{{"id": "1", "name": "hello", "language": "en"}},
+				"paging": map[string]interface{}{"next": server.URL + r.URL.Path + "?limit=100&after=cursor1"},
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{"id": "2", "name": "hello", "language": "es"}},
+		})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	templates, err := client.FetchTemplates(context.Background(), testAccount(server.URL))
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "es", templates[1].Language)
+}
+
+func TestClient_FetchTemplates_PageError(t *testing.T) {
+	t.Parallel()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("after") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"message": "boom"}})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":   []map[string]interface{}{{"id": "1", "name": "hello", "language": "en"}},
+			"paging": map[string]interface{}{"next": server.URL + r.URL.Path + "?after=cursor1"},
+		})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	_, err := client.FetchTemplates(context.Background(), testAccount(server.URL))
+	assert.Error(t, err, "a partial list must not be returned")
+}
+
+func TestClient_FetchTemplates_CarouselAndAuthentication(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": [
+			{"id": "1", "name": "summer_sale", "language": "en", "category": "MARKETING", "status": "APPROVED", "components": [
+				{"type": "BODY", "text": "Our summer picks"},
+				{"type": "CAROUSEL", "cards": [{"components": [
+					{"type": "HEADER", "format": "IMAGE", "example": {"header_handle": ["https://cdn.example.com/1.jpg"]}},
+					{"type": "BODY", "text": "Sandals"},
+					{"type": "BUTTONS", "buttons": [{"type": "QUICK_REPLY", "text": "Buy"}]}
+				]}]}
+			]},
+			{"id": "2", "name": "login_code", "language": "en", "category": "AUTHENTICATION", "status": "APPROVED", "components": [
+				{"type": "BODY", "text": "*{{1}}* is your verification code.", "add_security_recommendation": true},
+				{"type": "FOOTER", "text": "This code expires in 10 minutes.", "code_expiration_minutes": 10},
+				{"type": "BUTTONS", "buttons": [{"type": "OTP", "otp_type": "COPY_CODE", "text": "Copy code"}]}
+			]}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	templates, err := client.FetchTemplates(context.Background(), testAccount(server.URL))
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+
+	cards := templates[0].Components[1].Cards
+	require.Len(t, cards, 1)
+	assert.Equal(t, []string{"https://cdn.example.com/1.jpg"}, cards[0].Components[0].Example.HeaderHandle)
+
+	auth := templates[1].Components
+	assert.True(t, auth[0].AddSecurityRecommendation)
+	assert.Equal(t, 10, auth[1].CodeExpirationMinutes)
+	assert.Equal(t, "COPY_CODE", auth[2].Buttons[0].OTPType)
+}
+
 func TestClient_FetchTemplates_Empty(t *testing.T) {
 	t.Parallel()
 
@@ -259,7 +491,28 @@ func TestClient_DeleteTemplate_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, http.MethodDelete, r.Method)
 		assert.Contains(t, r.URL.Path, "/message_templates")
-		assert.Contains(t, r.URL.RawQuery, "name=hello_world")
+		assert.Equal(t, "hello_world", r.URL.Query().Get("name"))
+		assert.False(t, r.URL.Query().Has("hsm_id"))
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	account := testAccount(server.URL)
+
+	err := client.DeleteTemplate(context.Background(), account, "hello_world", "")
+	require.NoError(t, err)
+}
+
+func TestClient_DeleteTemplate_SingleLanguage(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "hello_world", r.URL.Query().Get("name"))
+		assert.Equal(t, "1234567890", r.URL.Query().Get("hsm_id"))
 
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(map[string]bool{"success": true})
@@ -269,7 +522,7 @@ func TestClient_DeleteTemplate_Success(t *testing.T) {
 	client := newTestClient(t, server)
 	account := testAccount(server.URL)
 
-	err := client.DeleteTemplate(context.Background(), account, "hello_world")
+	err := client.DeleteTemplate(context.Background(), account, "hello_world", "1234567890")
 	require.NoError(t, err)
 }
 
@@ -300,6 +553,6 @@ func TestClient_DeleteTemplate_APIError(t *testing.T) {
 	client := newTestClient(t, server)
 	account := testAccount(server.URL)
 
-	err := client.DeleteTemplate(context.Background(), account, "nonexistent")
+	err := client.DeleteTemplate(context.Background(), account, "nonexistent", "")
 	require.Error(t, err)
 }
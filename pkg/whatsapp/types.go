@@ -63,6 +63,23 @@ type TemplateComponent struct {
 	Text    string           `json:"text,omitempty"`
 	Buttons []TemplateButton `json:"buttons,omitempty"`
 	Example *TemplateExample `json:"example,omitempty"`
+
+	// Carousel, limited-time-offer and authentication components
+	Cards                     []TemplateCard            `json:"cards,omitempty"`
+	LimitedTimeOffer          *TemplateLimitedTimeOffer `json:"limited_time_offer,omitempty"`
+	AddSecurityRecommendation bool                      `json:"add_security_recommendation,omitempty"`
+	CodeExpirationMinutes     int                       `json:"code_expiration_minutes,omitempty"`
+}
+
+// TemplateCard represents a card of a carousel template
+type TemplateCard struct {
+	Components []TemplateComponent `json:"components"`
+}
+
+// TemplateLimitedTimeOffer represents the offer component of a limited-time-offer template
+type TemplateLimitedTimeOffer struct {
+	Text          string `json:"text"`
+	HasExpiration bool   `json:"has_expiration"`
 }
 
 // TemplateButton represents a button in a template
@@ -72,17 +89,30 @@ type TemplateButton struct {
 	URL         string `json:"url,omitempty"`
 	PhoneNumber string `json:"phone_number,omitempty"`
 	Example     any    `json:"example,omitempty"`
+
+	// One-time password buttons of authentication templates
+	OTPType       string                 `json:"otp_type,omitempty"` // COPY_CODE, ONE_TAP, ZERO_TAP
+	AutofillText  string                 `json:"autofill_text,omitempty"`
+	SupportedApps []TemplateSupportedApp `json:"supported_apps,omitempty"`
+}
+
+// TemplateSupportedApp identifies the Android app a one-tap OTP button autofills
+type TemplateSupportedApp struct {
+	PackageName   string `json:"package_name"`
+	SignatureHash string `json:"signature_hash"`
 }
 
 // TemplateExample represents example values for template variables
 type TemplateExample struct {
-	HeaderText []string   `json:"header_text,omitempty"`
-	BodyText   [][]string `json:"body_text,omitempty"`
+	HeaderText   []string   `json:"header_text,omitempty"`
+	HeaderHandle []string   `json:"header_handle,omitempty"`
+	BodyText     [][]string `json:"body_text,omitempty"`
 }
 
 // TemplateListResponse represents response from fetching templates
 type TemplateListResponse struct {
-	Data []MetaTemplate `json:"data"`
+	Data   []MetaTemplate `json:"data"`
+	Paging metaPaging     `json:"paging,omitempty"`
 }
 
 // WebhookPayload represents the incoming webhook from Meta
@@ -265,11 +295,23 @@ type ProductInfo struct {
 	ImageURL    string `json:"image_url"`
 	RetailerID  string `json:"retailer_id"`
 	Description string `json:"description"`
+
+	// Commerce fields
+	Brand        string `json:"brand"`
+	Availability string `json:"availability"`
+	Condition    string `json:"condition"`
+	Inventory    *int   `json:"inventory"`
+	SalePrice    string `json:"sale_price"`
+
+	// Meta commerce review: approved, pending, rejected or outdated
+	ReviewStatus           string   `json:"review_status"`
+	ReviewRejectionReasons []string `json:"review_rejection_reasons"`
 }
 
 // ProductListResponse represents response from listing products
 type ProductListResponse struct {
-	Data []ProductInfo `json:"data"`
+	Data   []ProductInfo `json:"data"`
+	Paging metaPaging    `json:"paging,omitempty"`
 }
 
 // ProductCreateResponse represents response from creating a product
@@ -95,19 +95,26 @@ func runMigrations(db *gorm.DB) error {
 		// WhatsApp models
 		&models.WhatsAppAccount{},
 		&models.Contact{},
+		&models.ContactReferral{},
 		&models.Tag{},
 		&models.Message{},
+		&models.MessageRevision{},
+		&models.TemplateGroup{},
 		&models.Template{},
 		&models.WhatsAppFlow{},
+		&models.OTPVerification{},
 		// Chatbot models
 		&models.ChatbotSettings{},
 		&models.KeywordRule{},
 		&models.ChatbotFlow{},
 		&models.ChatbotFlowStep{},
+		&models.ChatbotFlowVersion{},
 		&models.ChatbotSession{},
 		&models.ChatbotSessionMessage{},
 		&models.AIContext{},
 		&models.AgentTransfer{},
+		&models.SLAPolicy{},
+		&models.CSATSurvey{},
 		// Bulk message models
 		&models.BulkMessageCampaign{},
 		&models.BulkMessageRecipient{},
@@ -115,10 +122,22 @@ func runMigrations(db *gorm.DB) error {
 		// Catalog models
 		&models.Catalog{},
 		&models.CatalogProduct{},
+		&models.CatalogProductSet{},
+		&models.CatalogBatch{},
+		&models.CatalogScheduledUpdate{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.OrderEvent{},
+		&models.OrderNotification{},
 		// Canned responses
 		&models.CannedResponse{},
+		&models.CannedResponseUsage{},
 		// Dashboard
 		&models.Widget{},
+		// Conversation notes
+		&models.ConversationNote{},
+		&models.ConversationNoteAttachment{},
+		&models.UserNotification{},
 	)
 }
 
@@ -128,10 +147,22 @@ func cleanupTables(db *gorm.DB) {
 	tables := []string{
 		// Dashboard tables
 		"widgets",
+		// Conversation note tables
+		"user_notifications",
+		"conversation_note_attachments",
+		"conversation_notes",
 		// Catalog tables
+		"order_notifications",
+		"order_events",
+		"order_items",
+		"orders",
+		"catalog_scheduled_updates",
+		"catalog_batches",
+		"catalog_product_sets",
 		"catalog_products",
 		"catalogs",
 		// Canned responses
+		"canned_response_usages",
 		"canned_responses",
 		// Bulk message tables
 		"bulk_message_recipients",
@@ -140,6 +171,7 @@ func cleanupTables(db *gorm.DB) {
 		// Chatbot tables
 		"chatbot_session_messages",
 		"chatbot_sessions",
+		"chatbot_flow_versions",
 		"chatbot_flow_steps",
 		"chatbot_flows",
 		"keyword_rules",
@@ -147,10 +179,14 @@ func cleanupTables(db *gorm.DB) {
 		"ai_contexts",
 		"agent_transfers",
 		// WhatsApp tables
+		"message_revisions",
 		"messages",
+		"contact_referrals",
 		"tags",
 		"contacts",
+		"otp_verifications",
 		"templates",
+		"template_groups",
 		"whatsapp_flows",
 		"whatsapp_accounts",
 		// Roles and permissions
@@ -179,24 +215,40 @@ func cleanupTables(db *gorm.DB) {
 func TruncateTables(db *gorm.DB) {
 	tables := []string{
 		"widgets",
+		"user_notifications",
+		"conversation_note_attachments",
+		"conversation_notes",
+		"order_notifications",
+		"order_events",
+		"order_items",
+		"orders",
+		"catalog_scheduled_updates",
+		"catalog_batches",
+		"catalog_product_sets",
 		"catalog_products",
 		"catalogs",
+		"canned_response_usages",
 		"canned_responses",
 		"bulk_message_recipients",
 		"bulk_message_campaigns",
 		"notification_rules",
 		"chatbot_session_messages",
 		"chatbot_sessions",
+		"chatbot_flow_versions",
 		"chatbot_flow_steps",
 		"chatbot_flows",
 		"keyword_rules",
 		"chatbot_settings",
 		"ai_contexts",
 		"agent_transfers",
+		"message_revisions",
 		"messages",
+		"contact_referrals",
 		"tags",
 		"contacts",
+		"otp_verifications",
 		"templates",
+		"template_groups",
 		"whatsapp_flows",
 		"whatsapp_accounts",
 		"role_permissions",
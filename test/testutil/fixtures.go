@@ -12,6 +12,7 @@ import (
 	"github.com/zerodha/fastglue"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // TestJWTSecret is the shared JWT secret for tests.
@@ -281,16 +282,25 @@ func GetOrCreateTestPermissions(t *testing.T, db *gorm.DB) []models.Permission {
 	t.Helper()
 
 	var existingPerms []models.Permission
-	if err := db.Order("resource, action").Find(&existingPerms).Error; err == nil && len(existingPerms) > 0 {
-		return existingPerms
-	}
+	require.NoError(t, db.Order("resource, action").Find(&existingPerms).Error)
 
-	perms := models.DefaultPermissions()
-	for i := range perms {
-		perms[i].ID = uuid.New()
+	// Add permissions introduced since the test database was seeded
+	existing := make(map[string]bool, len(existingPerms))
+	for _, p := range existingPerms {
+		existing[p.Resource+":"+p.Action] = true
+	}
+	var missing []models.Permission
+	for _, p := range models.DefaultPermissions() {
+		if !existing[p.Resource+":"+p.Action] {
+			p.ID = uuid.New()
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		require.NoError(t, db.Clauses(clause.OnConflict{DoNothing: true}).Create(&missing).Error)
+		require.NoError(t, db.Order("resource, action").Find(&existingPerms).Error)
 	}
-	require.NoError(t, db.Create(&perms).Error)
-	return perms
+	return existingPerms
 }
 
 // CreateTestRole creates a role with specified permissions for testing.